@@ -172,6 +172,17 @@ func (m *Conflux) CI(
 		testResults, lintResults, buildResults), nil
 }
 
+// DevBackend runs the backend API with a PostgreSQL database for local development
+// Start it with: dagger call dev-backend up --ports 8080:8080
+func (m *Conflux) DevBackend(
+	// +defaultPath="."
+	source *dagger.Directory,
+) *dagger.Service {
+	return dag.Backend().Dev(dagger.BackendDevOpts{
+		Source: source.Directory("backend"),
+	})
+}
+
 // PackageBackend creates production container image for backend
 func (c *Conflux) PackageBackend(source *dagger.Directory) *dagger.Container {
 	return dag.Backend().Package(dagger.BackendPackageOpts{
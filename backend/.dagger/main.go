@@ -4,6 +4,7 @@ import (
 	"context"
 	"dagger/backend/internal/dagger"
 	"fmt"
+	"strings"
 )
 
 type Backend struct{}
@@ -78,6 +79,137 @@ func (m *Backend) Package(
 		WithEntrypoint([]string{"/app/server"})
 }
 
+// Database returns a throwaway PostgreSQL service for development
+func (m *Backend) Database() *dagger.Service {
+	return dag.Container().
+		From("postgres:15").
+		WithEnvVariable("POSTGRES_DB", "appdb").
+		WithEnvVariable("POSTGRES_USER", "appuser").
+		WithEnvVariable("POSTGRES_PASSWORD", "apppassword").
+		WithExposedPort(5432).
+		AsService()
+}
+
+// migrationCheck runs the migrate CLI through up, up, down, up and compares the
+// applied migrations after each step. The CLI retries until the database accepts
+// connections, since a service's port can open before it is ready for queries
+const migrationCheck = `set -eu
+tries=0
+until ./migrate status >/dev/null 2>&1; do
+	tries=$((tries + 1))
+	if [ "$tries" -ge 30 ]; then echo "database never became ready"; ./migrate status; exit 1; fi
+	sleep 2
+done
+
+./migrate up
+applied=$(./migrate status)
+echo "applied:"; echo "$applied"
+
+./migrate up
+if [ "$(./migrate status)" != "$applied" ]; then echo "second up changed the applied migrations"; exit 1; fi
+
+./migrate down
+reverted=$(./migrate status)
+if [ "$reverted" != "$(echo "$applied" | sed '$d')" ]; then
+	echo "down did not revert exactly the last migration, applied after down:"; echo "$reverted"; exit 1
+fi
+
+./migrate up
+if [ "$(./migrate status)" != "$applied" ]; then echo "up after down did not restore the migrations"; exit 1; fi
+echo "up, down, and up again succeeded"
+`
+
+// Migrate checks the migrations against fresh PostgreSQL and MySQL databases
+// Each dialect runs up, up again, down, then up; the second up must change nothing,
+// down must revert only the last migration, and the final up must restore it.
+// Returns the combined output and fails if any dialect fails
+func (m *Backend) Migrate(
+	ctx context.Context,
+	// +defaultPath="."
+	source *dagger.Directory,
+) (string, error) {
+	binary := m.BuildEnvironment().
+		WithDirectory("/app", source).
+		WithExec([]string{"go", "mod", "download"}).
+		WithExec([]string{"go", "build", "-o", "migrate", "./cmd/migrate"}).
+		File("/app/migrate")
+
+	databases := []struct {
+		dbType  string
+		port    string
+		service *dagger.Service
+	}{
+		{dbType: "postgres", port: "5432", service: m.Database()},
+		{dbType: "mysql", port: "3306", service: m.MySQLDatabase()},
+	}
+
+	var output strings.Builder
+	var failed []string
+	for _, db := range databases {
+		ctr := dag.Container().
+			From("alpine:3.19").
+			WithWorkdir("/app").
+			WithFile("/app/migrate", binary).
+			WithServiceBinding("db", db.service).
+			WithEnvVariable("DB_TYPE", db.dbType).
+			WithEnvVariable("DB_HOST", "db").
+			WithEnvVariable("DB_PORT", db.port).
+			WithEnvVariable("DB_NAME", "appdb").
+			WithEnvVariable("DB_USER", "appuser").
+			WithEnvVariable("DB_PASSWORD", "apppassword").
+			WithExec([]string{"sh", "-c", migrationCheck}, dagger.ContainerWithExecOpts{Expect: dagger.ReturnTypeAny})
+
+		// Collect the output even when the check fails, so every dialect is reported
+		out, err := ctr.CombinedOutput(ctx)
+		if err != nil {
+			return output.String(), fmt.Errorf("%s migrations could not run: %w", db.dbType, err)
+		}
+		fmt.Fprintf(&output, "== %s ==\n%s\n", db.dbType, out)
+		if code, err := ctr.ExitCode(ctx); err != nil || code != 0 {
+			failed = append(failed, db.dbType)
+		}
+	}
+
+	if len(failed) > 0 {
+		return output.String(), fmt.Errorf("migrations failed on %s", strings.Join(failed, ", "))
+	}
+	return output.String(), nil
+}
+
+// MySQLDatabase returns a throwaway MySQL service for migration checks
+func (m *Backend) MySQLDatabase() *dagger.Service {
+	return dag.Container().
+		From("mysql:8.0").
+		WithEnvVariable("MYSQL_ROOT_PASSWORD", "rootpassword").
+		WithEnvVariable("MYSQL_DATABASE", "appdb").
+		WithEnvVariable("MYSQL_USER", "appuser").
+		WithEnvVariable("MYSQL_PASSWORD", "apppassword").
+		WithExposedPort(3306).
+		AsService()
+}
+
+// Dev runs the API server against a fresh PostgreSQL database
+// The server applies migrations on startup, which also seed the dev user
+// Start it with: dagger call -m backend dev up --ports 8080:8080
+func (m *Backend) Dev(
+	// +defaultPath="."
+	source *dagger.Directory,
+) *dagger.Service {
+	return m.Build(source).
+		WithServiceBinding("db", m.Database()).
+		WithEnvVariable("ENVIRONMENT", "development").
+		WithEnvVariable("DB_TYPE", "postgres").
+		WithEnvVariable("DB_HOST", "db").
+		WithEnvVariable("DB_PORT", "5432").
+		WithEnvVariable("DB_NAME", "appdb").
+		WithEnvVariable("DB_USER", "appuser").
+		WithEnvVariable("DB_PASSWORD", "apppassword").
+		WithEnvVariable("JWT_SECRET", "dev-secret-key").
+		WithEnvVariable("ALLOWED_ORIGINS", "http://localhost:3000,http://localhost:5173").
+		WithExposedPort(8080).
+		AsService(dagger.ContainerAsServiceOpts{Args: []string{"/app/server"}})
+}
+
 // Publish builds and publishes the backend container image
 // For now, this is stubbed out as requested
 func (m *Backend) Publish(
@@ -0,0 +1,229 @@
+// HTTP client for the Conflux config API
+// Wraps the REST endpoints used by configctl and decodes JSON error bodies
+// Authenticates with a bearer token or an API key
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"conflux/internal/models"
+	"conflux/pkg/config"
+)
+
+// listPageSize is the page size used when fetching every configuration
+const listPageSize = 100
+
+// APIError is a non-2xx response from the server
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("server returned %d: %s", e.Status, e.Message)
+}
+
+// Client calls a running Conflux server
+type Client struct {
+	baseURL    string
+	token      string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the server at baseURL
+// token is sent as a bearer token; apiKey, if set, in the X-API-Key header
+func NewClient(baseURL, token, apiKey string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ListConfigs returns all of the caller's configurations, following pagination
+func (c *Client) ListConfigs(templateID *int) ([]*models.UserConfig, error) {
+	var configs []*models.UserConfig
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("page", strconv.Itoa(page))
+		query.Set("limit", strconv.Itoa(listPageSize))
+		if templateID != nil {
+			query.Set("template_id", strconv.Itoa(*templateID))
+		}
+
+		var resp struct {
+			Items      []*models.UserConfig `json:"items"`
+			Pagination struct {
+				Total int64 `json:"total"`
+			} `json:"pagination"`
+		}
+		if err := c.do(http.MethodGet, "/api/configs?"+query.Encode(), nil, &resp); err != nil {
+			return nil, err
+		}
+
+		configs = append(configs, resp.Items...)
+		if len(resp.Items) < listPageSize || int64(len(configs)) >= resp.Pagination.Total {
+			return configs, nil
+		}
+	}
+}
+
+// GetConfig returns a single configuration
+func (c *Client) GetConfig(id int) (*models.UserConfig, error) {
+	var config models.UserConfig
+	if err := c.do(http.MethodGet, fmt.Sprintf("/api/configs/%d", id), nil, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// CreateConfig creates a configuration from a template's default content
+func (c *Client) CreateConfig(templateID int, name string) (*models.UserConfig, error) {
+	body := map[string]interface{}{"template_id": templateID, "name": name}
+
+	var config models.UserConfig
+	if err := c.do(http.MethodPost, "/api/configs", body, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// UpdateConfig replaces a configuration's content, creating a new version
+// A non-nil format also changes the stored format
+func (c *Client) UpdateConfig(id int, content, changeNote string, format *models.ConfigFormat) (*models.UserConfig, error) {
+	body := map[string]interface{}{"content": content, "change_note": changeNote}
+	if format != nil {
+		body["format"] = *format
+	}
+
+	var config models.UserConfig
+	if err := c.do(http.MethodPut, fmt.Sprintf("/api/configs/%d", id), body, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Convert converts content between formats on the server
+// Warnings list values the target format couldn't represent faithfully
+func (c *Client) Convert(content string, from, to models.ConfigFormat) (string, []config.ConversionWarning, error) {
+	body := map[string]interface{}{"content": content, "from_format": from, "to_format": to}
+
+	var resp struct {
+		Content  string                     `json:"content"`
+		Warnings []config.ConversionWarning `json:"warnings"`
+	}
+	if err := c.do(http.MethodPost, "/api/configs/convert", body, &resp); err != nil {
+		return "", nil, err
+	}
+	return resp.Content, resp.Warnings, nil
+}
+
+// Validate checks content on the server, against a template's schema if given
+func (c *Client) Validate(content string, format models.ConfigFormat, templateID *int) error {
+	body := map[string]interface{}{"content": content, "format": format}
+	if templateID != nil {
+		body["template_id"] = *templateID
+	}
+	return c.do(http.MethodPost, "/api/configs/validate", body, nil)
+}
+
+// Export returns a configuration's content serialized as format
+// An empty format lets the server apply the caller's default export format
+func (c *Client) Export(id int, format models.ConfigFormat, resolveSecrets bool) (string, error) {
+	query := url.Values{}
+	if format != "" {
+		query.Set("format", string(format))
+	}
+	if resolveSecrets {
+		query.Set("resolve_secrets", "true")
+	}
+
+	path := fmt.Sprintf("/api/configs/%d/export", id)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	resp, err := c.send(http.MethodGet, path, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read export: %w", err)
+	}
+	return string(content), nil
+}
+
+// do sends a JSON request and decodes a JSON response into out, if non-nil
+func (c *Client) do(method, path string, body, out interface{}) error {
+	resp, err := c.send(method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// send performs an authenticated request, turning non-2xx responses into *APIError
+func (c *Client) send(method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		apiErr := &APIError{Status: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+
+		var errBody struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errBody) == nil && errBody.Message != "" {
+			apiErr.Message = errBody.Message
+		}
+		return nil, apiErr
+	}
+	return resp, nil
+}
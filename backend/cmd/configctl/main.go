@@ -0,0 +1,414 @@
+// Command-line client for the Conflux config API
+// Lists, fetches, creates, updates, converts, validates, and exports configurations
+// Content is checked locally with the shared parser before it is sent
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"conflux/internal/models"
+	"conflux/pkg/config"
+)
+
+const usage = `Usage: configctl [global flags] <command> [flags]
+
+Commands:
+  list      [-template ID]                                       List configurations
+  get       ID [-json]                                           Print a configuration's content
+  create    -template ID -name NAME [-f FILE] [-format F]        Create a configuration
+  update    ID [-f FILE] [-format F] [-note NOTE]                Replace a configuration's content
+  convert   -to F [-f FILE] [-format F] [-o FILE]                Convert content between formats
+  validate  [-f FILE] [-format F] [-template ID]                 Validate content
+  export    ID [-format F] [-resolve-secrets] [-o FILE]          Export a configuration
+
+Content is read from -f FILE, or from stdin when -f is omitted or "-".
+The input format comes from -format, then the file extension, then detection.
+
+Global flags:
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes a configctl invocation and returns the process exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("configctl", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	server := global.String("server", getEnv("CONFLUX_SERVER", "http://localhost:8080"), "server URL (CONFLUX_SERVER)")
+	token := global.String("token", os.Getenv("CONFLUX_TOKEN"), "bearer token (CONFLUX_TOKEN)")
+	apiKey := global.String("api-key", os.Getenv("CONFLUX_API_KEY"), "API key (CONFLUX_API_KEY)")
+	global.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		global.PrintDefaults()
+	}
+
+	if err := global.Parse(args); err != nil {
+		return 2
+	}
+	if global.NArg() == 0 {
+		global.Usage()
+		return 2
+	}
+
+	cli := &cli{
+		client: NewClient(*server, *token, *apiKey),
+		parser: config.NewParser(),
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+
+	commands := map[string]func([]string) error{
+		"list":     cli.list,
+		"get":      cli.get,
+		"create":   cli.create,
+		"update":   cli.update,
+		"convert":  cli.convert,
+		"validate": cli.validate,
+		"export":   cli.export,
+	}
+
+	name, cmdArgs := global.Arg(0), global.Args()[1:]
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "configctl: unknown command %q\n\n", name)
+		global.Usage()
+		return 2
+	}
+
+	if err := command(cmdArgs); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(stderr, "configctl %s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+// cli holds the dependencies shared by all commands
+type cli struct {
+	client *Client
+	parser *config.Parser
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (c *cli) list(args []string) error {
+	fs := c.flagSet("list")
+	templateID := fs.Int("template", 0, "only list configurations created from this template")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var filter *int
+	if *templateID != 0 {
+		filter = templateID
+	}
+	configs, err := c.client.ListConfigs(filter)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tFORMAT\tUPDATED")
+	for _, cfg := range configs {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", cfg.ID, cfg.Name, cfg.Format, cfg.UpdatedAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+func (c *cli) get(args []string) error {
+	fs := c.flagSet("get")
+	asJSON := fs.Bool("json", false, "print the full configuration record as JSON")
+	id, err := parseWithID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := c.client.GetConfig(id)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		out, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeOutput(c.stdout, "", string(out))
+	}
+	return writeOutput(c.stdout, "", cfg.Content)
+}
+
+func (c *cli) create(args []string) error {
+	fs := c.flagSet("create")
+	templateID := fs.Int("template", 0, "template to create the configuration from (required)")
+	name := fs.String("name", "", "configuration name (required)")
+	file := fs.String("f", "", "initial content; the template default is kept when omitted")
+	format := fs.String("format", "", "format of the content")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *templateID == 0 || *name == "" {
+		return fmt.Errorf("-template and -name are required")
+	}
+
+	// Check the content before creating anything
+	var content string
+	var contentFormat models.ConfigFormat
+	if *file != "" {
+		var err error
+		if content, contentFormat, err = c.readContent(*file, *format); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := c.client.CreateConfig(*templateID, *name)
+	if err != nil {
+		return err
+	}
+
+	if *file != "" {
+		updated, err := c.client.UpdateConfig(cfg.ID, content, "Initial content", &contentFormat)
+		if err != nil {
+			return fmt.Errorf("created configuration %d but failed to set content: %w", cfg.ID, err)
+		}
+		cfg = updated
+	}
+
+	fmt.Fprintf(c.stdout, "created configuration %d (%s)\n", cfg.ID, cfg.Format)
+	return nil
+}
+
+func (c *cli) update(args []string) error {
+	fs := c.flagSet("update")
+	file := fs.String("f", "", "new content")
+	format := fs.String("format", "", "format of the content")
+	note := fs.String("note", "", "change note recorded with the new version")
+	id, err := parseWithID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	content, contentFormat, err := c.readContent(*file, *format)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := c.client.UpdateConfig(id, content, *note, &contentFormat)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "updated configuration %d\n", cfg.ID)
+	for _, warning := range cfg.Warnings {
+		fmt.Fprintf(c.stdout, "warning: %s\n", warning)
+	}
+	return nil
+}
+
+func (c *cli) convert(args []string) error {
+	fs := c.flagSet("convert")
+	file := fs.String("f", "", "content to convert")
+	format := fs.String("format", "", "format of the content")
+	to := fs.String("to", "", "target format (required)")
+	output := fs.String("o", "", "write the result to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	toFormat, err := parseFormat(*to)
+	if err != nil {
+		return fmt.Errorf("-to: %w", err)
+	}
+	content, fromFormat, err := c.readContent(*file, *format)
+	if err != nil {
+		return err
+	}
+
+	converted, warnings, err := c.client.Convert(content, fromFormat, toFormat)
+	if err != nil {
+		return err
+	}
+	// Warnings go to stderr so piped output stays clean
+	for _, warning := range warnings {
+		fmt.Fprintf(c.stderr, "warning: %s", warning.Message)
+		if len(warning.Paths) > 0 {
+			fmt.Fprintf(c.stderr, " (%s)", strings.Join(warning.Paths, ", "))
+		}
+		fmt.Fprintln(c.stderr)
+	}
+	return writeOutput(c.stdout, *output, converted)
+}
+
+func (c *cli) validate(args []string) error {
+	fs := c.flagSet("validate")
+	file := fs.String("f", "", "content to validate")
+	format := fs.String("format", "", "format of the content")
+	templateID := fs.Int("template", 0, "also validate against this template's schema")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	content, contentFormat, err := c.readContent(*file, *format)
+	if err != nil {
+		return err
+	}
+
+	var template *int
+	if *templateID != 0 {
+		template = templateID
+	}
+	if err := c.client.Validate(content, contentFormat, template); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "valid %s\n", contentFormat)
+	return nil
+}
+
+func (c *cli) export(args []string) error {
+	fs := c.flagSet("export")
+	format := fs.String("format", "", "export format; defaults to the account's preference")
+	resolveSecrets := fs.Bool("resolve-secrets", false, "substitute ${secret:NAME} placeholders")
+	output := fs.String("o", "", "write the export to this file instead of stdout")
+	id, err := parseWithID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var exportFormat models.ConfigFormat
+	if *format != "" {
+		if exportFormat, err = parseFormat(*format); err != nil {
+			return fmt.Errorf("-format: %w", err)
+		}
+	}
+
+	content, err := c.client.Export(id, exportFormat, *resolveSecrets)
+	if err != nil {
+		return err
+	}
+	return writeOutput(c.stdout, *output, content)
+}
+
+// readContent reads content from path, or stdin for "" and "-", resolves its
+// format, and checks that it parses before anything is sent to the server
+func (c *cli) readContent(path, format string) (string, models.ConfigFormat, error) {
+	var data []byte
+	var err error
+	if path == "" || path == "-" {
+		data, err = io.ReadAll(c.stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read content: %w", err)
+	}
+
+	content := string(data)
+	if strings.TrimSpace(content) == "" {
+		return "", "", fmt.Errorf("content is empty")
+	}
+
+	contentFormat, err := c.resolveFormat(path, format, content)
+	if err != nil {
+		return "", "", err
+	}
+
+	if _, err := c.parser.ParseConfig(content, contentFormat); err != nil {
+		return "", "", fmt.Errorf("invalid %s: %w", contentFormat, err)
+	}
+	return content, contentFormat, nil
+}
+
+// resolveFormat picks the content format: explicit flag, then file extension,
+// then the most likely detected format
+func (c *cli) resolveFormat(path, format, content string) (models.ConfigFormat, error) {
+	if format != "" {
+		return parseFormat(format)
+	}
+	if ext := filepath.Ext(path); ext != "" {
+		if detected, ok := config.FormatForExtension(ext); ok {
+			return detected, nil
+		}
+	}
+
+	candidates, err := c.parser.DetectFormatCandidates(content)
+	if err != nil {
+		return "", fmt.Errorf("%w; pass -format", err)
+	}
+	return candidates[0].Format, nil
+}
+
+// parseFormat validates a format name against the codec registry
+func parseFormat(name string) (models.ConfigFormat, error) {
+	format := models.ConfigFormat(strings.ToLower(name))
+	if _, ok := config.LookupCodec(format); !ok {
+		return "", fmt.Errorf("unsupported format %q", name)
+	}
+	return format, nil
+}
+
+// parseWithID parses a command's flags around a leading configuration ID,
+// so both "get 3 -json" and "get -json 3" work
+func parseWithID(fs *flag.FlagSet, args []string) (int, error) {
+	var idArg string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		idArg, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return 0, err
+	}
+	if idArg == "" && fs.NArg() > 0 {
+		idArg = fs.Arg(0)
+	}
+	if idArg == "" {
+		return 0, fmt.Errorf("configuration ID is required")
+	}
+
+	id, err := strconv.Atoi(idArg)
+	if err != nil {
+		return 0, fmt.Errorf("invalid configuration ID %q", idArg)
+	}
+	return id, nil
+}
+
+// writeOutput writes content to path, or stdout when path is empty,
+// ending it with a newline
+func writeOutput(stdout io.Writer, path, content string) error {
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if path == "" {
+		_, err := io.WriteString(stdout, content)
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0o644)
+}
+
+// flagSet creates a command flag set that reports errors instead of exiting
+func (c *cli) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("configctl "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+// fakeServer records the last request body and answers the endpoints configctl uses
+func fakeServer(t *testing.T, lastBody *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": true, "message": "Invalid token"})
+			return
+		}
+
+		*lastBody = nil
+		if r.Body != nil {
+			_ = json.NewDecoder(r.Body).Decode(lastBody)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/configs":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"items":      []*models.UserConfig{{ID: 1, Name: "app", Format: models.FormatYAML}},
+				"pagination": map[string]interface{}{"page": 1, "limit": 100, "total": 1},
+			})
+		case r.Method == http.MethodPut && r.URL.Path == "/api/configs/1":
+			_ = json.NewEncoder(w).Encode(&models.UserConfig{ID: 1, Warnings: []string{"password looks like a secret"}})
+		case r.Method == http.MethodPost && r.URL.Path == "/api/configs/convert":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"content":  "port = 8080\n",
+				"warnings": []map[string]interface{}{{"code": "nulls_lost", "message": "toml has no null", "paths": []string{"a", "b"}}},
+			})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/configs/1/export":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"format":"` + r.URL.Query().Get("format") + `"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": true, "message": "Configuration not found"})
+		}
+	}))
+}
+
+func TestRun(t *testing.T) {
+	var lastBody map[string]interface{}
+	server := fakeServer(t, &lastBody)
+	defer server.Close()
+
+	dir := t.TempDir()
+	tomlFile := filepath.Join(dir, "app.toml")
+	if err := os.WriteFile(tomlFile, []byte("port = 8080\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+		wantBody   map[string]interface{}
+	}{
+		{
+			name:       "list",
+			args:       []string{"list"},
+			wantStdout: "1   app   yaml",
+		},
+		{
+			name:       "update from stdin detects format",
+			args:       []string{"update", "1", "-note", "bump"},
+			stdin:      `{"port": 9090}`,
+			wantStdout: "warning: password looks like a secret",
+			wantBody:   map[string]interface{}{"content": `{"port": 9090}`, "change_note": "bump", "format": "json"},
+		},
+		{
+			name:       "convert uses file extension",
+			args:       []string{"convert", "-f", tomlFile, "-to", "yaml"},
+			wantStdout: "port = 8080",
+			wantStderr: "warning: toml has no null (a, b)",
+			wantBody:   map[string]interface{}{"content": "port = 8080\n", "from_format": "toml", "to_format": "yaml"},
+		},
+		{
+			name:       "export passes format",
+			args:       []string{"export", "1", "-format", "json"},
+			wantStdout: `{"format":"json"}`,
+		},
+		{
+			name:       "invalid content is rejected locally",
+			args:       []string{"update", "1", "-format", "json"},
+			stdin:      `{"port":`,
+			wantCode:   1,
+			wantStderr: "invalid json",
+		},
+		{
+			name:       "server errors are reported",
+			args:       []string{"get", "2"},
+			wantCode:   1,
+			wantStderr: "server returned 404: Configuration not found",
+		},
+		{
+			name:       "unknown command",
+			args:       []string{"frobnicate"},
+			wantCode:   2,
+			wantStderr: `unknown command "frobnicate"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			args := append([]string{"-server", server.URL, "-token", "test-token"}, tt.args...)
+
+			code := run(args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("exit code = %d, want %d (stderr: %s)", code, tt.wantCode, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.wantStdout)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+			if tt.wantBody != nil {
+				for key, want := range tt.wantBody {
+					if lastBody[key] != want {
+						t.Errorf("request %s = %v, want %v", key, lastBody[key], want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRun_Unauthorized(t *testing.T) {
+	t.Setenv("CONFLUX_TOKEN", "")
+	t.Setenv("CONFLUX_API_KEY", "")
+
+	var lastBody map[string]interface{}
+	server := fakeServer(t, &lastBody)
+	defer server.Close()
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-server", server.URL, "list"}, strings.NewReader(""), &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("exit code = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "server returned 401: Invalid token") {
+		t.Errorf("stderr = %q, want 401 error", stderr.String())
+	}
+}
@@ -0,0 +1,90 @@
+// Standalone database migration runner
+// Applies, rolls back, or lists schema migrations without starting the API server
+// Connection settings come from the same environment variables as the server
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"conflux/internal/config"
+	"conflux/internal/database"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: migrate [command]
+
+Commands:
+  up        Apply all pending migrations (the default)
+  down      Roll back the most recently applied migration
+  status    List applied migrations, oldest first, one per line
+
+The database is chosen by DB_TYPE, DB_HOST, DB_PORT, DB_NAME, DB_USER, and DB_PASSWORD.
+For DB_TYPE=sqlite, DB_NAME is the database file.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes a migrate invocation and returns the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 1 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	command := "up"
+	if len(args) == 1 {
+		command = args[0]
+	}
+	if command != "up" && command != "down" && command != "status" {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", command, usage)
+		return 2
+	}
+
+	// Logs go to stderr so status output stays machine-readable
+	logger := slog.New(slog.NewTextHandler(stderr, nil))
+
+	if err := godotenv.Load("../.env"); err != nil {
+		logger.Debug("No .env file found, using system environment variables")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("Failed to load configuration", "error", err)
+		return 1
+	}
+	logger = slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: cfg.LogLevel}))
+
+	dbFactory := database.NewConnectionFactory(cfg)
+	db, err := dbFactory.NewConnectionWithRetry(context.Background(),
+		database.DefaultConnectAttempts, database.DefaultConnectRetryDelay)
+	if err != nil {
+		logger.Error("Failed to connect to database", "error", err)
+		return 1
+	}
+	defer db.Close()
+
+	migrator := database.NewMigrator(db, cfg.DBType, logger)
+	switch command {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		err = migrator.Down()
+	case "status":
+		var versions []string
+		if versions, err = migrator.Applied(); err == nil {
+			for _, version := range versions {
+				fmt.Fprintln(stdout, version)
+			}
+		}
+	}
+	if err != nil {
+		logger.Error("Migration command failed", "command", command, "error", err)
+		return 1
+	}
+	return 0
+}
@@ -4,99 +4,283 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"conflux/internal/api"
 	apiHandlers "conflux/internal/api/handlers"
+	"conflux/internal/api/middleware"
 	"conflux/internal/config"
 	"conflux/internal/database"
+	"conflux/internal/repository"
 	"conflux/internal/repository/mysql"
 	"conflux/internal/repository/postgres"
+	"conflux/internal/repository/sqlite"
 	"conflux/internal/service"
+	parser "conflux/pkg/config"
+	"conflux/pkg/jwt"
 
 	"github.com/gorilla/handlers"
 	"github.com/joho/godotenv"
 )
 
+// shutdownTimeout bounds how long in-flight requests get to finish on SIGINT or SIGTERM
+const shutdownTimeout = 30 * time.Second
+
 func main() {
+	// Leveled logger shared by all components; the level can change on reload
+	logLevel := new(slog.LevelVar)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
+	slog.SetDefault(logger)
+
 	// Load environment variables from .env file
 	if err := godotenv.Load("../.env"); err != nil {
-		log.Println("No .env file found, using system environment variables")
+		logger.Info("No .env file found, using system environment variables")
 	}
 
 	// Load configuration from environment variables
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal("Failed to load configuration:", err)
+		fatal(logger, "Failed to load configuration", err)
 	}
+	logLevel.Set(cfg.LogLevel)
 
-	// Initialize database connection (MySQL or PostgreSQL based on config)
+	// Refuse to sign tokens with a guessable secret, except in development
+	if err := config.CheckJWTSecret(cfg.JWTSecret, cfg.JWTSecretMinLength); err != nil {
+		if !cfg.IsDevelopment() {
+			fatal(logger, "Refusing to start with an insecure JWT_SECRET; set a long random secret "+
+				"(e.g. openssl rand -base64 48) or ENVIRONMENT=development", err)
+		}
+		logger.Warn("INSECURE JWT_SECRET: acceptable for development only, never deploy this configuration", "error", err)
+	}
+
+	// Initialize database connection (MySQL, PostgreSQL, or SQLite based on config)
+	// The database may still be starting up (e.g. under docker-compose), so retry with backoff
 	dbFactory := database.NewConnectionFactory(cfg)
-	db, err := dbFactory.NewConnection()
+	db, err := dbFactory.NewConnectionWithRetry(context.Background(),
+		database.DefaultConnectAttempts, database.DefaultConnectRetryDelay)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		fatal(logger, "Failed to connect to database", err)
 	}
 	defer func() {
 		if err := db.Close(); err != nil {
-			log.Printf("Error closing database: %v", err)
+			logger.Error("Error closing database", "error", err)
 		}
 	}()
 
-	// Health check database connection
-	if err := dbFactory.HealthCheck(db); err != nil {
-		log.Fatal("Database health check failed:", err)
-	}
-
 	// Run database migrations
-	migrator := database.NewMigrator(db, cfg.DBType)
+	migrator := database.NewMigrator(db, cfg.DBType, logger)
 	if err := migrator.Up(); err != nil {
-		log.Fatal("Failed to run migrations:", err)
+		fatal(logger, "Failed to run migrations", err)
 	}
 
 	// Set up repository layer with database connection
 	var userRepo service.UserRepository
 	var authRepo service.AuthRepository
+	var apiKeyRepo service.APIKeyRepository
+	var auditRepo service.AuditRepository
+	var emailChangeRepo service.EmailChangeRepository
+	var verificationRepo service.EmailVerificationRepository
+	var knownDeviceRepo service.KnownDeviceRepository
+	var importTokenRepo service.ImportTokenRepository
+	var configRepo service.ConfigRepository
+
+	// Config and version content is optionally compressed on the way to the database
+	codec := repository.ContentCodec{Compress: cfg.CompressConfigStorage, MinBytes: cfg.CompressMinBytes}
 
 	switch cfg.DBType {
 	case "mysql":
 		userRepo = mysql.NewUserRepository(db)
 		authRepo = mysql.NewAuthRepository(db)
+		apiKeyRepo = mysql.NewAPIKeyRepository(db)
+		auditRepo = mysql.NewAuditRepository(db)
+		emailChangeRepo = mysql.NewEmailChangeRepository(db)
+		verificationRepo = mysql.NewEmailVerificationRepository(db)
+		knownDeviceRepo = mysql.NewKnownDeviceRepository(db)
+		importTokenRepo = mysql.NewImportTokenRepository(db)
+		configRepo = mysql.NewConfigRepository(db, codec, cfg.UniqueConfigNames)
 	case "postgres":
 		userRepo = postgres.NewUserRepository(db)
 		authRepo = postgres.NewAuthRepository(db)
+		apiKeyRepo = postgres.NewAPIKeyRepository(db)
+		auditRepo = postgres.NewAuditRepository(db)
+		emailChangeRepo = postgres.NewEmailChangeRepository(db)
+		verificationRepo = postgres.NewEmailVerificationRepository(db)
+		knownDeviceRepo = postgres.NewKnownDeviceRepository(db)
+		importTokenRepo = postgres.NewImportTokenRepository(db)
+		configRepo = postgres.NewConfigRepository(db, codec, cfg.UniqueConfigNames)
+	case "sqlite":
+		userRepo = sqlite.NewUserRepository(db)
+		authRepo = sqlite.NewAuthRepository(db)
+		apiKeyRepo = sqlite.NewAPIKeyRepository(db)
+		auditRepo = sqlite.NewAuditRepository(db)
+		emailChangeRepo = sqlite.NewEmailChangeRepository(db)
+		verificationRepo = sqlite.NewEmailVerificationRepository(db)
+		knownDeviceRepo = sqlite.NewKnownDeviceRepository(db)
+		importTokenRepo = sqlite.NewImportTokenRepository(db)
+		configRepo = sqlite.NewConfigRepository(db, codec, cfg.UniqueConfigNames)
 	default:
-		log.Fatal("Unsupported database type:", cfg.DBType)
+		fatal(logger, "Unsupported database type", fmt.Errorf("%q", cfg.DBType))
 	}
 
 	// Initialize service layer with repository dependencies
-	userService := service.NewUserService(userRepo)
-	authService := service.NewAuthService(userRepo, authRepo)
-	devService := service.NewDevService(userService, authService)
+	emailSender := service.NewLogEmailSender(logger)
+	verificationService := service.NewEmailVerificationService(userRepo, verificationRepo, emailSender)
+	userOpts := []service.UserServiceOption{service.WithUserLogger(logger)}
+	if cfg.RequireEmailVerification {
+		userOpts = append(userOpts, service.WithEmailVerification(verificationService))
+	}
+	userService := service.NewUserService(userRepo, userOpts...)
+	auditService := service.NewAuditService(auditRepo)
+	// Devices are tracked even without SMTP, so enabling mail later doesn't flag every sign-in
+	var notifier service.Notifier = service.NopNotifier{}
+	if cfg.SMTPHost != "" {
+		notifier = service.NewSMTPNotifier(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	}
+	// One token manager signs tokens at login and validates them in the auth middleware
+	tokenManager := jwt.NewTokenManager(cfg.JWTSecret, cfg.JWTIssuer)
+	authService := service.NewAuthService(
+		userRepo, authRepo, tokenManager,
+		service.WithSessionAudit(auditService),
+		service.WithSessionTokenBytes(cfg.SessionTokenBytes),
+		service.WithSessionIdleTimeout(cfg.SessionIdleTimeout),
+		service.WithSecurityNotifications(notifier, knownDeviceRepo, logger),
+	)
+	go authService.RunSessionJanitor(context.Background(), service.DefaultSessionJanitorInterval)
+	devService := service.NewDevService(userService, authService, logger)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo, auditService, logger)
+	emailChangeService := service.NewEmailChangeService(userRepo, emailChangeRepo, emailSender, auditService)
+	// Stored GitHub tokens are encrypted with a key derived from JWT_SECRET
+	importTokenService := service.NewImportTokenService(importTokenRepo, cfg.JWTSecret)
+	configService := service.NewConfigService(
+		configRepo,
+		service.WithSecretScan(cfg.ScanSecrets),
+		service.WithSecretSource(parser.EnvSecretSource{Prefix: cfg.SecretEnvPrefix}),
+		service.WithTemplateCache(cfg.TemplateCacheTTL),
+		service.WithAuditLog(auditService),
+		service.WithChangeNotePolicy(cfg.ChangeNoteMinLines),
+		service.WithEditLockTTL(cfg.EditLockTTL),
+		service.WithUniqueConfigNames(cfg.UniqueConfigNames),
+		service.WithTemplateCreateRateLimit(cfg.TemplateCreateRateLimit, cfg.TemplateCreateBurst),
+		service.WithGitHubAPI(cfg.GitHubAPIURL, importTokenService),
+		service.WithPrivateImportURLs(cfg.ImportPrivateURLs),
+	)
+
+	// Fail imports a previous run left processing; their workers died with it
+	if n, err := configService.ReconcileStuckImports(cfg.ImportStaleAfter); err != nil {
+		logger.Warn("Failed to reconcile stuck imports", "error", err)
+	} else if n > 0 {
+		logger.Info("Failed imports left processing by a previous run", "count", n)
+	}
+
+	// Read-only maintenance switch, set from MAINTENANCE_MODE and toggled by admins
+	maintenance := middleware.NewMaintenance(cfg.MaintenanceMode, cfg.MaintenanceRetryAfter)
+	if cfg.MaintenanceMode {
+		logger.Warn("Starting in read-only maintenance mode")
+	}
+
+	// Load shedding beyond MAX_CONCURRENT_REQUESTS simultaneous requests
+	concurrency := middleware.NewConcurrencyLimiter(cfg.MaxConcurrentRequests)
 
 	// Set up API handlers with service dependencies
-	healthHandler := apiHandlers.NewHealthHandler(db)
-	authHandler := apiHandlers.NewAuthHandler(authService)
-	userHandler := apiHandlers.NewUserHandler(userService)
+	healthHandler := apiHandlers.NewHealthHandler(db, cfg.Features, maintenance, cfg.HealthCheckTimeout, cfg.HealthCacheTTL)
+	authHandler := apiHandlers.NewAuthHandler(authService, userService, verificationService)
+	userHandler := apiHandlers.NewUserHandler(userService, emailChangeService, authService)
 	devHandler := apiHandlers.NewDevHandler(devService)
+	formatHandler := apiHandlers.NewFormatHandler(parser.NewParser())
+	apiKeyHandler := apiHandlers.NewAPIKeyHandler(apiKeyService)
+	activityHandler := apiHandlers.NewActivityHandler(auditService)
+	maintenanceHandler := apiHandlers.NewMaintenanceHandler(maintenance, logger)
+	metricsHandler := apiHandlers.NewMetricsHandler(concurrency)
+	configHandler := apiHandlers.NewConfigHandler(configService, userService)
+	importTokenHandler := apiHandlers.NewImportTokenHandler(importTokenService)
 
 	// Configure middleware chain and set up routes
-	router := api.SetupRoutes(userHandler, authHandler, healthHandler, devHandler)
+	realIP, err := middleware.NewRealIP(cfg.TrustedProxies)
+	if err != nil {
+		fatal(logger, "Invalid trusted proxy configuration", err)
+	}
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimitRequests, cfg.RateLimitBurst)
+	auth := middleware.NewAuth(tokenManager, authService, apiKeyService)
+	router := api.SetupRoutes(
+		userHandler, authHandler, healthHandler, devHandler, formatHandler, apiKeyHandler, activityHandler,
+		maintenanceHandler, metricsHandler, configHandler, importTokenHandler,
+		auth, realIP, rateLimiter, maintenance, concurrency,
+		cfg.MaxBodyBytes, logger,
+	)
+
+	// Reload safely-reloadable settings on SIGHUP without dropping connections
+	reloader := config.NewReloader(cfg, func() (*config.Config, error) {
+		// Overload so edits to .env replace values read at startup
+		if err := godotenv.Overload("../.env"); err != nil {
+			logger.Warn("No .env file reloaded", "error", err)
+		}
+		return config.Load()
+	}, logger)
+	maintenanceSetting := cfg.MaintenanceMode
+	reloader.OnReload(func(c *config.Config) {
+		rateLimiter.SetLimits(c.RateLimitRequests, c.RateLimitBurst)
+		logLevel.Set(c.LogLevel)
+		// Only a changed setting applies, so a reload doesn't undo an admin's toggle
+		if c.MaintenanceMode != maintenanceSetting {
+			maintenanceSetting = c.MaintenanceMode
+			maintenance.SetEnabled(c.MaintenanceMode)
+			logger.Warn("Maintenance mode changed by reload", "enabled", c.MaintenanceMode)
+		}
+	})
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	go reloader.Watch(context.Background(), sighup)
 
-	// Configure CORS
+	// Configure CORS (origins are read from the live config on every request)
 	corsHandler := handlers.CORS(
-		handlers.AllowedOrigins(cfg.AllowedOrigins),
-		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
+		handlers.AllowedOriginValidator(func(origin string) bool {
+			return reloader.Current().OriginAllowed(origin)
+		}),
+		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
 		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),
+		handlers.ExposedHeaders([]string{
+			middleware.HeaderRateLimitLimit,
+			middleware.HeaderRateLimitRemaining,
+			middleware.HeaderRateLimitReset,
+			"Retry-After",
+		}),
 		handlers.AllowCredentials(),
 	)(router)
 
 	// Start HTTP server
 	addr := cfg.Host + ":" + cfg.Port
-	log.Printf("Server starting on %s", addr)
-	log.Printf("Database type: %s", cfg.DBType)
+	logger.Info("Server starting", "addr", addr, "db_type", cfg.DBType, "log_level", cfg.LogLevel)
+
+	server := &http.Server{Addr: addr, Handler: corsHandler}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal(logger, "Server failed to start", err)
+		}
+	}()
 
-	if err := http.ListenAndServe(addr, corsHandler); err != nil {
-		log.Fatal("Server failed to start:", err)
+	<-stop
+	logger.Info("Server shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Error("Error shutting down server", "error", err)
 	}
+	// No request can start another notification now, so let those in flight finish
+	authService.WaitForNotifications()
+}
+
+// fatal logs an unrecoverable startup error and exits
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,61 @@
+// API response shapes for users
+// Each field sent to clients is listed and mapped explicitly, so fields added
+// to the database models stay private until they are deliberately exposed here
+package dto
+
+import (
+	"time"
+
+	"conflux/internal/models"
+)
+
+// UserResponse is the public view of a user account
+type UserResponse struct {
+	ID            int                    `json:"id"`
+	Email         string                 `json:"email"`
+	FirstName     string                 `json:"first_name"`
+	LastName      string                 `json:"last_name"`
+	Role          string                 `json:"role"`
+	Preferences   models.UserPreferences `json:"preferences"`
+	EmailVerified bool                   `json:"email_verified"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+
+	// The account still has the temporary password it was imported with
+	ResetRequired bool `json:"reset_required"`
+}
+
+// NewUserResponse maps a user model to its public view
+func NewUserResponse(user *models.User) *UserResponse {
+	if user == nil {
+		return nil
+	}
+	return &UserResponse{
+		ID:            user.ID,
+		Email:         user.Email,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Role:          user.Role,
+		Preferences:   user.Preferences,
+		EmailVerified: user.EmailVerified,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		ResetRequired: user.PasswordResetRequired,
+	}
+}
+
+// AuthResponse is returned after a successful login
+type AuthResponse struct {
+	Token     string        `json:"token"`
+	ExpiresIn int           `json:"expires_in"`
+	User      *UserResponse `json:"user"`
+}
+
+// NewAuthResponse maps a login result, replacing the user model with its public view
+func NewAuthResponse(response *models.AuthResponse) *AuthResponse {
+	return &AuthResponse{
+		Token:     response.Token,
+		ExpiresIn: response.ExpiresIn,
+		User:      NewUserResponse(response.User),
+	}
+}
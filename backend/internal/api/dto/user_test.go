@@ -0,0 +1,59 @@
+package dto
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+// TestUserResponse_HasNoSecretFields guards the DTO's shape itself, so a
+// credential can't be exposed by adding a field and forgetting a json:"-" tag
+func TestUserResponse_HasNoSecretFields(t *testing.T) {
+	responseType := reflect.TypeOf(UserResponse{})
+	for i := 0; i < responseType.NumField(); i++ {
+		field := responseType.Field(i)
+		name := strings.ToLower(field.Name + " " + field.Tag.Get("json"))
+		for _, secret := range []string{"password", "hash", "token", "secret"} {
+			if strings.Contains(name, secret) {
+				t.Errorf("UserResponse.%s looks like a secret (%q)", field.Name, secret)
+			}
+		}
+	}
+}
+
+func TestNewUserResponse(t *testing.T) {
+	user := &models.User{
+		ID:            7,
+		Email:         "ada@example.com",
+		Password:      "$2a$10$hashedpassword",
+		FirstName:     "Ada",
+		LastName:      "Lovelace",
+		Role:          models.RoleAdmin,
+		EmailVerified: true,
+	}
+
+	data, err := json.Marshal(NewAuthResponse(&models.AuthResponse{Token: "jwt", ExpiresIn: 3600, User: user}))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if strings.Contains(string(data), user.Password) {
+		t.Errorf("response leaks the password hash: %s", data)
+	}
+
+	var decoded struct {
+		User map[string]interface{} `json:"user"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded.User["email"] != "ada@example.com" || decoded.User["role"] != models.RoleAdmin || decoded.User["id"] != float64(7) {
+		t.Errorf("user = %v, want id, email and role mapped", decoded.User)
+	}
+
+	if NewUserResponse(nil) != nil {
+		t.Error("NewUserResponse(nil) should be nil")
+	}
+}
@@ -0,0 +1,59 @@
+// Activity feed HTTP handlers
+// Serves the requesting user's recent configuration activity from the audit log
+// Entries are always scoped to the caller; there is no way to read another user's feed
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"conflux/internal/service"
+	"conflux/pkg/utils"
+)
+
+// ActivityHandler handles activity feed HTTP requests
+type ActivityHandler struct {
+	auditService *service.AuditService
+}
+
+// NewActivityHandler creates activity handler with service dependency
+func NewActivityHandler(auditService *service.AuditService) *ActivityHandler {
+	return &ActivityHandler{
+		auditService: auditService,
+	}
+}
+
+// GetMyActivity handles GET /api/me/activity
+// Returns the caller's config creations, updates, restores, and imports, newest first
+func (h *ActivityHandler) GetMyActivity(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil {
+		page = 1
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 20
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	entries, total, err := h.auditService.ListActivity(r.Context(), userID, page, limit)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve activity")
+		return
+	}
+
+	response := utils.NewListResponse(entries, utils.OffsetPagination(page, limit, total)).WithLegacyKey("activity")
+	utils.JSONResponse(w, http.StatusOK, response)
+}
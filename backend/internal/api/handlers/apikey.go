@@ -0,0 +1,60 @@
+// API key HTTP handlers
+// Processes API key management requests for programmatic access
+// Includes bulk rotation for incident response
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"conflux/internal/api/middleware"
+	"conflux/internal/models"
+	"conflux/internal/service"
+	"conflux/pkg/utils"
+)
+
+// APIKeyHandler handles API key HTTP requests
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+}
+
+// NewAPIKeyHandler creates API key handler with service dependency
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// RotateKeys handles POST /api/keys/rotate
+// Deactivates all of a user's keys and optionally returns one fresh key
+func (h *APIKeyHandler) RotateKeys(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// An empty body rotates the caller's own keys without issuing a new one
+	var req models.RotateAPIKeysRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	response, err := h.apiKeyService.RotateKeys(
+		r.Context(), userID, isAdminFromContext(r), &req, middleware.ClientIP(r),
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "unauthorized") {
+			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Key rotation failed")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, response)
+}
@@ -5,22 +5,37 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"conflux/internal/api/dto"
+	"conflux/internal/api/middleware"
 	"conflux/internal/models"
 	"conflux/internal/service"
 	"conflux/pkg/utils"
+
+	"github.com/gorilla/mux"
 )
 
 // AuthHandler handles authentication HTTP requests
 type AuthHandler struct {
-	authService *service.AuthService
+	authService         *service.AuthService
+	userService         *service.UserService
+	verificationService *service.EmailVerificationService
 }
 
-// NewAuthHandler creates authentication handler with service dependency
-func NewAuthHandler(authService *service.AuthService) *AuthHandler {
+// NewAuthHandler creates authentication handler with service dependencies
+func NewAuthHandler(
+	authService *service.AuthService,
+	userService *service.UserService,
+	verificationService *service.EmailVerificationService,
+) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
+		authService:         authService,
+		userService:         userService,
+		verificationService: verificationService,
 	}
 }
 
@@ -38,28 +53,95 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.authService.Login(r.Context(), &req)
+	response, err := h.authService.Login(r.Context(), &req, middleware.ClientIP(r), r.UserAgent())
 	if err != nil {
-		utils.ErrorResponse(w, http.StatusUnauthorized, err.Error())
+		if strings.Contains(err.Error(), "email not verified") {
+			utils.ErrorResponse(w, http.StatusForbidden, "Email not verified; check your inbox or request a new verification email")
+		} else {
+			utils.ErrorResponse(w, http.StatusUnauthorized, err.Error())
+		}
 		return
 	}
 
-	utils.JSONResponse(w, http.StatusOK, response)
+	utils.JSONResponse(w, http.StatusOK, dto.NewAuthResponse(response))
 }
 
 // Register handles user registration requests
-// POST /auth/register - Creates new user account
+// POST /auth/register - Creates new user account and returns it without a token;
+// the client logs in next (after verifying the email when verification is required)
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
-	// Registration handler implementation
 	var req models.RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	// Note: This would typically use UserService.CreateUser
-	// For now, we'll implement basic registration logic here
-	utils.ErrorResponse(w, http.StatusNotImplemented, "Registration not yet implemented")
+	user, err := h.userService.CreateUser(r.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrEmailTaken):
+			utils.ErrorResponse(w, http.StatusConflict, "An account with this email already exists")
+		case strings.Contains(err.Error(), "validation failed"):
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Registration failed")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusCreated, dto.NewUserResponse(user))
+}
+
+// VerifyEmail handles email verification
+// GET /auth/verify-email?token=... - Verifies from an emailed link
+// POST /auth/verify-email - Verifies with {"token": "..."}
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req models.VerifyEmailRequest
+	if r.Method == http.MethodGet {
+		req.Token = r.URL.Query().Get("token")
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	user, err := h.verificationService.VerifyEmail(r.Context(), &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "validation failed") || strings.Contains(err.Error(), "invalid or expired") {
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Email verification failed")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, dto.NewUserResponse(user))
+}
+
+// ResendVerification handles verification email resend requests
+// POST /auth/resend-verification - Sends a new token to an unverified account
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req models.ResendVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.verificationService.ResendVerification(r.Context(), &req); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "validation failed"):
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		case strings.Contains(err.Error(), "too many"):
+			utils.ErrorResponse(w, http.StatusTooManyRequests, err.Error())
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to resend verification")
+		}
+		return
+	}
+
+	// Same response whether or not the address has an unverified account
+	utils.JSONResponse(w, http.StatusAccepted, map[string]string{
+		"message": "If the account needs verification, a new email has been sent",
+	})
 }
 
 // Logout handles user logout requests
@@ -84,3 +166,87 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 
 	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "Logged out successfully"})
 }
+
+// ListSessions lists the current user's active sessions
+// GET /api/me/sessions - Sessions are identified by the opaque ID in each token's jti
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	sessions, err := h.authService.ListSessions(r.Context(), userID, token)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve sessions")
+		return
+	}
+
+	// Sessions aren't paginated; one page holds them all
+	count := len(sessions)
+	response := utils.NewListResponse(sessions, utils.OffsetPagination(1, count, int64(count)))
+	utils.JSONResponse(w, http.StatusOK, response)
+}
+
+// RevokeSession revokes one of the current user's sessions
+// DELETE /api/me/sessions/{session_id} - Signs that device out without affecting the others
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := h.authService.RevokeSession(r.Context(), userID, mux.Vars(r)["session_id"]); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "validation failed"):
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid session ID")
+		case strings.Contains(err.Error(), "not found"):
+			utils.ErrorResponse(w, http.StatusNotFound, "Session not found")
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to revoke session")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "Session revoked"})
+}
+
+// PurgeUserSessions force-logs-out another user
+// DELETE /api/admin/users/{id}/sessions - Invalidates all of the user's sessions (admin only)
+func (h *AuthHandler) PurgeUserSessions(w http.ResponseWriter, r *http.Request) {
+	actorID := getUserIDFromContext(r)
+	if actorID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	targetUserID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || targetUserID < 1 {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	removed, err := h.authService.PurgeUserSessions(
+		r.Context(), actorID, isAdminFromContext(r), targetUserID, middleware.ClientIP(r),
+	)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "unauthorized"):
+			utils.ErrorResponse(w, http.StatusForbidden, "Admin access required")
+		case strings.Contains(err.Error(), "validation failed"):
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		case strings.Contains(err.Error(), "user not found"):
+			utils.ErrorResponse(w, http.StatusNotFound, "User not found")
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to purge sessions")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"user_id": targetUserID,
+		"removed": removed,
+	})
+}
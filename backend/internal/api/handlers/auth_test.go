@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+	"conflux/internal/service"
+)
+
+// memoryUserRepo stores users by email; methods registration doesn't use panic
+type memoryUserRepo struct {
+	service.UserRepository
+	users map[string]*models.User
+}
+
+func (r *memoryUserRepo) Create(ctx context.Context, user *models.User) error {
+	if _, ok := r.users[user.Email]; ok {
+		return models.ErrEmailTaken
+	}
+	user.ID = len(r.users) + 1
+	r.users[user.Email] = user
+	return nil
+}
+
+func (r *memoryUserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	if user, ok := r.users[email]; ok {
+		return user, nil
+	}
+	return nil, errors.New("user not found")
+}
+
+func TestAuthHandler_Register(t *testing.T) {
+	repo := &memoryUserRepo{users: map[string]*models.User{}}
+	h := NewAuthHandler(nil, service.NewUserService(repo), nil)
+
+	register := func() *httptest.ResponseRecorder {
+		body := `{"email": "new@example.com", "password": "password123", "first_name": "New", "last_name": "User"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/register", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		h.Register(rec, req)
+		return rec
+	}
+
+	rec := register()
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var user map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &user); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if user["email"] != "new@example.com" || user["id"] != float64(1) {
+		t.Errorf("response = %v, want the new user", user)
+	}
+	if _, ok := user["password"]; ok || strings.Contains(rec.Body.String(), "password123") {
+		t.Errorf("response exposes the password: %s", rec.Body.String())
+	}
+
+	// The same email again conflicts
+	if rec := register(); rec.Code != http.StatusConflict {
+		t.Errorf("duplicate email status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if len(repo.users) != 1 {
+		t.Errorf("stored users = %d, want 1", len(repo.users))
+	}
+}
+
+func TestAuthHandler_RegisterInvalidBody(t *testing.T) {
+	h := NewAuthHandler(nil, service.NewUserService(&memoryUserRepo{users: map[string]*models.User{}}), nil)
+
+	rec := httptest.NewRecorder()
+	h.Register(rec, httptest.NewRequest(http.MethodPost, "/api/auth/register", strings.NewReader("{")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -4,13 +4,24 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
 	"net/http"
+	"path"
 	"strconv"
 	"strings"
 
+	"conflux/internal/api/middleware"
 	"conflux/internal/models"
 	"conflux/internal/service"
+	"conflux/pkg/config"
 	"conflux/pkg/utils"
 
 	"github.com/gorilla/mux"
@@ -19,12 +30,14 @@ import (
 // ConfigHandler handles configuration-related HTTP requests
 type ConfigHandler struct {
 	configService *service.ConfigService
+	userService   *service.UserService
 }
 
 // NewConfigHandler creates a new configuration handler
-func NewConfigHandler(configService *service.ConfigService) *ConfigHandler {
+func NewConfigHandler(configService *service.ConfigService, userService *service.UserService) *ConfigHandler {
 	return &ConfigHandler{
 		configService: configService,
+		userService:   userService,
 	}
 }
 
@@ -57,15 +70,7 @@ func (h *ConfigHandler) GetTemplates(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := map[string]interface{}{
-		"templates": templates,
-		"pagination": map[string]interface{}{
-			"page":  page,
-			"limit": limit,
-			"total": total,
-		},
-	}
-
+	response := utils.NewListResponse(templates, utils.OffsetPagination(page, limit, total)).WithLegacyKey("templates")
 	utils.JSONResponse(w, http.StatusOK, response)
 }
 
@@ -123,7 +128,14 @@ func (h *ConfigHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "Template updated successfully"})
+	response := map[string]interface{}{
+		"message": "Template updated successfully",
+		"version": updates.Version,
+	}
+	if len(updates.Warnings) > 0 {
+		response["warnings"] = updates.Warnings
+	}
+	utils.JSONResponse(w, http.StatusOK, response)
 }
 
 // DeleteTemplate handles DELETE /api/templates/{id}
@@ -143,6 +155,93 @@ func (h *ConfigHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
 	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "Template deleted successfully"})
 }
 
+// GetTemplateVersions handles GET /api/templates/{id}/versions
+func (h *ConfigHandler) GetTemplateVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+
+	versions, err := h.configService.GetTemplateVersions(id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Template not found")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"template_id": id,
+		"versions":    versions,
+	})
+}
+
+// GetTemplateDependents handles GET /api/templates/{id}/dependents
+// Lists configurations created from the template with their owners (admin only)
+func (h *ConfigHandler) GetTemplateDependents(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	dependents, total, err := h.configService.GetTemplateDependents(id, isAdminFromContext(r), page, limit)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "unauthorized"):
+			utils.ErrorResponse(w, http.StatusForbidden, "Admin access required")
+		case strings.Contains(err.Error(), "not found"):
+			utils.ErrorResponse(w, http.StatusNotFound, "Template not found")
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve dependents")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, utils.NewListResponse(dependents, utils.OffsetPagination(page, limit, total)))
+}
+
+// PreviewTemplate handles POST /api/templates/{id}/preview
+// Renders the template with sample variable values without creating a configuration
+func (h *ConfigHandler) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+
+	var req models.TemplatePreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	preview, err := h.configService.PreviewTemplate(id, &req)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "template not found"):
+			utils.ErrorResponse(w, http.StatusNotFound, "Template not found")
+		case strings.Contains(err.Error(), "validation failed"):
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to render template preview")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, preview)
+}
+
 // User Configuration Endpoints
 
 // GetUserConfigs handles GET /api/configs
@@ -178,21 +277,35 @@ func (h *ConfigHandler) GetUserConfigs(w http.ResponseWriter, r *http.Request) {
 		limit = 20
 	}
 
-	configs, total, err := h.configService.GetUserConfigs(userID, templateID, page, limit)
+	order, err := listSort(r, models.UserConfigSortColumns, models.DefaultUserConfigSort)
 	if err != nil {
-		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve configurations")
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	response := map[string]interface{}{
-		"configs": configs,
-		"pagination": map[string]interface{}{
-			"page":  page,
-			"limit": limit,
-			"total": total,
-		},
+	// ?cursor= opts into keyset pagination; an empty cursor requests the first page
+	if r.URL.Query().Has("cursor") {
+		configs, nextCursor, err := h.configService.GetUserConfigsByCursor(userID, templateID, r.URL.Query().Get("cursor"), limit)
+		if err != nil {
+			if errors.Is(err, utils.ErrInvalidCursor) {
+				utils.ErrorResponse(w, http.StatusBadRequest, "Invalid cursor")
+			} else {
+				utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve configurations")
+			}
+			return
+		}
+		response := utils.NewListResponse(configs, utils.CursorPagination(limit, nextCursor)).WithLegacyKey("configs")
+		utils.JSONResponse(w, http.StatusOK, response)
+		return
+	}
+
+	configs, total, err := h.configService.GetUserConfigs(userID, templateID, order, page, limit)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve configurations")
+		return
 	}
 
+	response := utils.NewListResponse(configs, sortedPagination(page, limit, total, order)).WithLegacyKey("configs")
 	utils.JSONResponse(w, http.StatusOK, response)
 }
 
@@ -211,7 +324,7 @@ func (h *ConfigHandler) GetUserConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	config, err := h.configService.GetUserConfig(id, userID)
+	config, err := h.configService.GetUserConfigDetail(id, userID)
 	if err != nil {
 		if strings.Contains(err.Error(), "unauthorized") {
 			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
@@ -233,8 +346,9 @@ func (h *ConfigHandler) CreateUserConfig(w http.ResponseWriter, r *http.Request)
 	}
 
 	var req struct {
-		TemplateID int    `json:"template_id"`
-		Name       string `json:"name"`
+		TemplateID int               `json:"template_id"`
+		Name       string            `json:"name"`
+		Variables  map[string]string `json:"variables"` // Values keyed by template variable name
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -247,8 +361,19 @@ func (h *ConfigHandler) CreateUserConfig(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	config, err := h.configService.CreateUserConfig(userID, req.TemplateID, req.Name)
+	config, err := h.configService.CreateUserConfig(userID, req.TemplateID, req.Name, req.Variables)
 	if err != nil {
+		var limited *service.RateLimitError
+		if errors.As(err, &limited) {
+			seconds := int(math.Ceil(limited.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+			utils.ErrorResponse(w, http.StatusTooManyRequests, "Too many configurations created from this template, try again later")
+			return
+		}
+		if strings.Contains(err.Error(), "already have a config named") {
+			utils.ErrorResponse(w, http.StatusConflict, "Failed to create configuration: "+err.Error())
+			return
+		}
 		utils.ErrorResponse(w, http.StatusBadRequest, "Failed to create configuration: "+err.Error())
 		return
 	}
@@ -256,6 +381,93 @@ func (h *ConfigHandler) CreateUserConfig(w http.ResponseWriter, r *http.Request)
 	utils.JSONResponse(w, http.StatusCreated, config)
 }
 
+// ForkUserConfig handles POST /api/configs/{id}/fork
+// Creates an editable copy owned by the caller; read access to the source is enough
+func (h *ConfigHandler) ForkUserConfig(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	configID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid configuration ID")
+		return
+	}
+
+	// The body is optional; without a name the fork is named after its source
+	var req struct {
+		Name string `json:"name"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	fork, err := h.configService.ForkUserConfig(configID, userID, req.Name)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "unauthorized"):
+			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
+		case strings.Contains(err.Error(), "not found"):
+			utils.ErrorResponse(w, http.StatusNotFound, "Configuration not found")
+		case strings.Contains(err.Error(), "already have a config named"):
+			utils.ErrorResponse(w, http.StatusConflict, "Failed to fork configuration: "+err.Error())
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fork configuration")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusCreated, fork)
+}
+
+// ShareUserConfig handles POST /api/configs/{id}/share
+// Only the owner can share; the body's config_id is ignored in favor of the path.
+// A share_with user that doesn't exist is a 404, and nothing is shared
+func (h *ConfigHandler) ShareUserConfig(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	configID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid configuration ID")
+		return
+	}
+
+	var req models.ShareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.ConfigID = configID
+
+	shares, err := h.configService.ShareUserConfig(configID, userID, &req)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "validation failed"):
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		case strings.Contains(err.Error(), "unauthorized"):
+			utils.ErrorResponse(w, http.StatusForbidden, "Only the owner can share a configuration")
+		case strings.Contains(err.Error(), "user not found"):
+			utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+		case strings.Contains(err.Error(), "not found"):
+			utils.ErrorResponse(w, http.StatusNotFound, "Configuration not found")
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to share configuration")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{"shares": shares})
+}
+
 // UpdateUserConfig handles PUT /api/configs/{id}
 func (h *ConfigHandler) UpdateUserConfig(w http.ResponseWriter, r *http.Request) {
 	userID := getUserIDFromContext(r)
@@ -289,6 +501,9 @@ func (h *ConfigHandler) UpdateUserConfig(w http.ResponseWriter, r *http.Request)
 
 	config, err := h.configService.UpdateUserConfig(id, userID, req.Content, req.ChangeNote, req.Format)
 	if err != nil {
+		if writeConfigLocked(w, err) {
+			return
+		}
 		if strings.Contains(err.Error(), "unauthorized") {
 			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
 		} else {
@@ -300,6 +515,56 @@ func (h *ConfigHandler) UpdateUserConfig(w http.ResponseWriter, r *http.Request)
 	utils.JSONResponse(w, http.StatusOK, config)
 }
 
+// mergePatchContentType is the media type of a JSON Merge Patch (RFC 7396)
+const mergePatchContentType = "application/merge-patch+json"
+
+// PatchUserConfig handles PATCH /api/configs/{id}
+// The patch format is chosen by Content-Type; application/merge-patch+json applies a
+// JSON Merge Patch to the parsed configuration. The change note comes from ?change_note=
+func (h *ConfigHandler) PatchUserConfig(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid configuration ID")
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != mergePatchContentType {
+		utils.ErrorResponse(w, http.StatusUnsupportedMediaType, "Content-Type must be "+mergePatchContentType)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	config, err := h.configService.PatchUserConfig(id, userID, patch, r.URL.Query().Get("change_note"))
+	if err != nil {
+		if writeConfigLocked(w, err) {
+			return
+		}
+		switch {
+		case strings.Contains(err.Error(), "unauthorized"):
+			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
+		case strings.Contains(err.Error(), "not found"):
+			utils.ErrorResponse(w, http.StatusNotFound, "Configuration not found")
+		default:
+			utils.ErrorResponse(w, http.StatusBadRequest, "Failed to patch configuration: "+err.Error())
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, config)
+}
+
 // DeleteUserConfig handles DELETE /api/configs/{id}
 func (h *ConfigHandler) DeleteUserConfig(w http.ResponseWriter, r *http.Request) {
 	userID := getUserIDFromContext(r)
@@ -327,6 +592,83 @@ func (h *ConfigHandler) DeleteUserConfig(w http.ResponseWriter, r *http.Request)
 	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "Configuration deleted successfully"})
 }
 
+// LockUserConfig handles POST /api/configs/{id}/lock
+// Acquires the edit lock, or renews it when the caller already holds it
+func (h *ConfigHandler) LockUserConfig(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid configuration ID")
+		return
+	}
+
+	lock, err := h.configService.LockUserConfig(id, userID)
+	if err != nil {
+		switch {
+		case writeConfigLocked(w, err):
+		case strings.Contains(err.Error(), "unauthorized"):
+			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
+		case strings.Contains(err.Error(), "not found"):
+			utils.ErrorResponse(w, http.StatusNotFound, "Configuration not found")
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to lock configuration")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, lock)
+}
+
+// UnlockUserConfig handles DELETE /api/configs/{id}/lock
+func (h *ConfigHandler) UnlockUserConfig(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid configuration ID")
+		return
+	}
+
+	if err := h.configService.UnlockUserConfig(id, userID); err != nil {
+		switch {
+		case writeConfigLocked(w, err):
+		case strings.Contains(err.Error(), "unauthorized"):
+			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
+		case strings.Contains(err.Error(), "not found"):
+			utils.ErrorResponse(w, http.StatusNotFound, "Configuration not found")
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to unlock configuration")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "Configuration unlocked"})
+}
+
+// writeConfigLocked responds 423 Locked with the lock in force when err is a *service.ConfigLockedError
+func writeConfigLocked(w http.ResponseWriter, err error) bool {
+	var locked *service.ConfigLockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	utils.JSONResponse(w, http.StatusLocked, map[string]interface{}{
+		"error":   true,
+		"message": "Configuration is locked for editing by another user",
+		"status":  http.StatusLocked,
+		"lock":    locked.Lock,
+	})
+	return true
+}
+
 // Version Management Endpoints
 
 // GetConfigVersions handles GET /api/configs/{id}/versions
@@ -360,7 +702,32 @@ func (h *ConfigHandler) GetConfigVersions(w http.ResponseWriter, r *http.Request
 		limit = 10
 	}
 
-	versions, total, err := h.configService.GetConfigVersions(configID, userID, page, limit)
+	order, err := listSort(r, models.ConfigVersionSortColumns, models.DefaultConfigVersionSort)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// ?cursor= opts into keyset pagination; an empty cursor requests the first page
+	if r.URL.Query().Has("cursor") {
+		versions, nextCursor, err := h.configService.GetConfigVersionsByCursor(configID, userID, r.URL.Query().Get("cursor"), limit)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "unauthorized"):
+				utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
+			case errors.Is(err, utils.ErrInvalidCursor):
+				utils.ErrorResponse(w, http.StatusBadRequest, "Invalid cursor")
+			default:
+				utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve versions")
+			}
+			return
+		}
+		response := utils.NewListResponse(versions, utils.CursorPagination(limit, nextCursor)).WithLegacyKey("versions")
+		utils.JSONResponse(w, http.StatusOK, response)
+		return
+	}
+
+	versions, total, err := h.configService.GetConfigVersions(configID, userID, order, page, limit)
 	if err != nil {
 		if strings.Contains(err.Error(), "unauthorized") {
 			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
@@ -370,20 +737,12 @@ func (h *ConfigHandler) GetConfigVersions(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	response := map[string]interface{}{
-		"versions": versions,
-		"pagination": map[string]interface{}{
-			"page":  page,
-			"limit": limit,
-			"total": total,
-		},
-	}
-
+	response := utils.NewListResponse(versions, sortedPagination(page, limit, total, order)).WithLegacyKey("versions")
 	utils.JSONResponse(w, http.StatusOK, response)
 }
 
-// RestoreConfigVersion handles POST /api/configs/{id}/versions/{version_id}/restore
-func (h *ConfigHandler) RestoreConfigVersion(w http.ResponseWriter, r *http.Request) {
+// GetVersionGraph handles GET /api/configs/{id}/history/graph
+func (h *ConfigHandler) GetVersionGraph(w http.ResponseWriter, r *http.Request) {
 	userID := getUserIDFromContext(r)
 	if userID == 0 {
 		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
@@ -397,92 +756,140 @@ func (h *ConfigHandler) RestoreConfigVersion(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	versionID, err := strconv.Atoi(vars["version_id"])
-	if err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid version ID")
-		return
-	}
-
-	config, err := h.configService.RestoreConfigVersion(configID, versionID, userID)
+	nodes, err := h.configService.GetVersionGraph(configID, userID)
 	if err != nil {
 		if strings.Contains(err.Error(), "unauthorized") {
 			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
 		} else {
-			utils.ErrorResponse(w, http.StatusBadRequest, "Failed to restore version: "+err.Error())
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve version history")
 		}
 		return
 	}
 
-	utils.JSONResponse(w, http.StatusOK, config)
+	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"config_id": configID,
+		"versions":  nodes,
+	})
 }
 
-// Utility Endpoints
-
-// DetectFormat handles POST /api/configs/detect-format
-func (h *ConfigHandler) DetectFormat(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Content string `json:"content"`
+// GetHistoryArchive handles GET /api/configs/{id}/history/archive
+// Streams a tar.gz of every version plus manifest.json; ?mode=patches writes
+// unified diffs to apply in order instead of full snapshots
+func (h *ConfigHandler) GetHistoryArchive(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+	vars := mux.Vars(r)
+	configID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid configuration ID")
 		return
 	}
 
-	format, err := h.configService.DetectFormat(req.Content)
+	mode := service.HistoryArchiveMode(r.URL.Query().Get("mode"))
+	if mode == "" {
+		mode = service.HistoryArchiveSnapshots
+	}
+
+	archive, err := h.configService.GetHistoryArchive(configID, userID, mode)
 	if err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, "Unable to detect format: "+err.Error())
+		if strings.Contains(err.Error(), "validation failed") {
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
+		} else if strings.Contains(err.Error(), "not found") {
+			utils.ErrorResponse(w, http.StatusNotFound, "Configuration not found")
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve version history")
+		}
 		return
 	}
 
-	utils.JSONResponse(w, http.StatusOK, map[string]string{"format": string(format)})
-}
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition",
+		"attachment; filename="+convertedFilename(archive.Config.Name)+"-history.tar.gz")
+	w.WriteHeader(http.StatusOK)
 
-// ConvertFormat handles POST /api/configs/convert
-func (h *ConfigHandler) ConvertFormat(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Content    string              `json:"content"`
-		FromFormat models.ConfigFormat `json:"from_format"`
-		ToFormat   models.ConfigFormat `json:"to_format"`
+	if err := archive.Write(w); err != nil {
+		// Headers are already written; a truncated archive fails gzip verification on the client
+		return
 	}
+}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+// GetTemplateDrift handles GET /api/configs/{id}/template-drift
+func (h *ConfigHandler) GetTemplateDrift(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	converted, err := h.configService.ConvertFormat(req.Content, req.FromFormat, req.ToFormat)
+	vars := mux.Vars(r)
+	configID, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, "Conversion failed: "+err.Error())
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid configuration ID")
 		return
 	}
 
-	utils.JSONResponse(w, http.StatusOK, map[string]string{"content": converted})
-}
-
-// ValidateConfig handles POST /api/configs/validate
-func (h *ConfigHandler) ValidateConfig(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Content    string              `json:"content"`
-		Format     models.ConfigFormat `json:"format"`
-		TemplateID *int                `json:"template_id,omitempty"`
+	drift, err := h.configService.GetTemplateDrift(configID, userID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "unauthorized"):
+			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
+		case strings.Contains(err.Error(), "validation failed"):
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.ErrorResponse(w, http.StatusNotFound, "Configuration not found")
+		}
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+	utils.JSONResponse(w, http.StatusOK, drift)
+}
+
+// RebaseUserConfig handles POST /api/configs/{id}/rebase
+// Merges the template changes made since the configuration was based on it and saves
+// the result as a new version; conflicts keep the configuration's values and are listed
+func (h *ConfigHandler) RebaseUserConfig(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	if err := h.configService.ValidateConfig(req.Content, req.Format, req.TemplateID); err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, "Validation failed: "+err.Error())
+	configID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid configuration ID")
+		return
+	}
+
+	result, err := h.configService.RebaseUserConfig(configID, userID)
+	if err != nil {
+		if writeConfigLocked(w, err) {
+			return
+		}
+		switch {
+		case strings.Contains(err.Error(), "unauthorized"):
+			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
+		case strings.Contains(err.Error(), "validation failed"):
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		case strings.Contains(err.Error(), "not found"):
+			utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to rebase configuration")
+		}
 		return
 	}
 
-	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "Configuration is valid"})
+	utils.JSONResponse(w, http.StatusOK, result)
 }
 
-// ExportConfig handles GET /api/configs/{id}/export?format=yaml
-func (h *ConfigHandler) ExportConfig(w http.ResponseWriter, r *http.Request) {
+// RestoreConfigVersion handles POST /api/configs/{id}/versions/{version_id}/restore
+// Responds with the restored configuration and the version numbers created and restored from
+func (h *ConfigHandler) RestoreConfigVersion(w http.ResponseWriter, r *http.Request) {
 	userID := getUserIDFromContext(r)
 	if userID == 0 {
 		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
@@ -496,34 +903,454 @@ func (h *ConfigHandler) ExportConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	format := models.ConfigFormat(r.URL.Query().Get("format"))
-	if format == "" {
-		format = models.FormatYAML // Default format
+	versionID, err := strconv.Atoi(vars["version_id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid version ID")
+		return
 	}
 
-	content, err := h.configService.ExportConfig(configID, userID, format)
+	result, err := h.configService.RestoreConfigVersion(configID, versionID, userID)
 	if err != nil {
+		if writeConfigLocked(w, err) {
+			return
+		}
 		if strings.Contains(err.Error(), "unauthorized") {
 			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
 		} else {
-			utils.ErrorResponse(w, http.StatusBadRequest, "Export failed: "+err.Error())
+			utils.ErrorResponse(w, http.StatusBadRequest, "Failed to restore version: "+err.Error())
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, result)
+}
+
+// GetVersionDiff handles GET /api/configs/{id}/versions/{from}/diff/{to}
+// Returns structured line changes by default; Accept: text/x-diff or ?format=unified
+// returns a unified diff that patch and diff viewers understand, and
+// ?format=structural compares the parsed versions key by key
+func (h *ConfigHandler) GetVersionDiff(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	configID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid configuration ID")
+		return
+	}
+	fromID, err := strconv.Atoi(vars["from"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid version ID")
+		return
+	}
+	toID, err := strconv.Atoi(vars["to"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid version ID")
+		return
+	}
+
+	writeError := func(err error) {
+		if strings.Contains(err.Error(), "unauthorized") {
+			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
+		} else if strings.Contains(err.Error(), "validation failed") {
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		} else {
+			utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+		}
+	}
+
+	if r.URL.Query().Get("format") == "unified" || strings.Contains(r.Header.Get("Accept"), "text/x-diff") {
+		diff, err := h.configService.UnifiedVersionDiff(configID, fromID, toID, userID)
+		if err != nil {
+			writeError(err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(diff))
+		return
+	}
+
+	diffVersions := h.configService.DiffVersionLines
+	if r.URL.Query().Get("format") == "structural" {
+		diffVersions = h.configService.DiffVersions
+	}
+	diffs, err := diffVersions(configID, fromID, toID, userID)
+	if err != nil {
+		writeError(err)
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"from": fromID,
+		"to":   toID,
+		"diff": diffs,
+	})
+}
+
+// Utility Endpoints
+
+// DetectFormat handles POST /api/configs/detect-format
+// Ambiguous content gets 300 Multiple Choices with the candidates in the body;
+// ?best_guess=true always picks the most likely format instead
+func (h *ConfigHandler) DetectFormat(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Content string `json:"content"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	bestGuess, _ := strconv.ParseBool(r.URL.Query().Get("best_guess"))
+
+	format, err := h.configService.DetectFormat(req.Content, bestGuess)
+	if err != nil {
+		var ambiguous *service.AmbiguousFormatError
+		if errors.As(err, &ambiguous) {
+			utils.JSONResponse(w, http.StatusMultipleChoices, map[string]interface{}{
+				"error":      true,
+				"message":    "Content matches several formats; choose one or retry with best_guess=true",
+				"status":     http.StatusMultipleChoices,
+				"candidates": ambiguous.Candidates,
+			})
+			return
+		}
+		utils.ErrorResponse(w, http.StatusBadRequest, "Unable to detect format: "+err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]string{"format": string(format)})
+}
+
+// ConvertFormat handles POST /api/configs/convert
+func (h *ConfigHandler) ConvertFormat(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Content     string              `json:"content"`
+		FromFormat  models.ConfigFormat `json:"from_format"`
+		ToFormat    models.ConfigFormat `json:"to_format"`
+		YAMLAnchors bool                `json:"yaml_anchors"` // Re-anchor repeated subtrees in YAML output
+
+		TOMLDatetimes bool `json:"toml_datetimes"` // Write datetime-looking strings as TOML datetimes
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var opts []config.SerializeOption
+	if req.YAMLAnchors {
+		opts = append(opts, config.WithYAMLAnchors())
+	}
+	if req.TOMLDatetimes {
+		opts = append(opts, config.WithTOMLDatetimes())
+	}
+
+	converted, warnings, err := h.configService.ConvertFormatWithWarnings(req.Content, req.FromFormat, req.ToFormat, opts...)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Conversion failed: "+err.Error())
+		return
+	}
+
+	// Warnings flag values that were flattened, stringified, or dropped
+	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"content":  converted,
+		"warnings": warnings,
+	})
+}
+
+// MergeConfigs handles POST /api/configs/merge
+// Layers override onto base: maps merge recursively, override scalars win, and
+// arrays replace unless append_arrays is set
+func (h *ConfigHandler) MergeConfigs(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Base         string              `json:"base"`
+		Override     string              `json:"override"`
+		Format       models.ConfigFormat `json:"format"`
+		AppendArrays bool                `json:"append_arrays"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var opts []config.MergeOption
+	if req.AppendArrays {
+		opts = append(opts, config.WithAppendArrays())
+	}
+
+	merged, err := h.configService.MergeConfigs(req.Base, req.Override, req.Format, opts...)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Merge failed: "+err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"content": merged,
+		"format":  req.Format,
+	})
+}
+
+// maxConvertFileBytes caps the size of a file uploaded for conversion
+const maxConvertFileBytes int64 = 1 << 20
+
+// multipartOverhead allows for part headers and boundaries around the uploaded file
+const multipartOverhead int64 = 16 << 10
+
+// ConvertFile handles POST /api/configs/convert/file
+// Takes a multipart upload (file, to, and optionally from) and responds with the
+// converted file as an attachment; X-Source-Format reports the detected source format
+func (h *ConfigHandler) ConvertFile(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxConvertFileBytes+multipartOverhead)
+	if err := r.ParseMultipartForm(maxConvertFileBytes + multipartOverhead); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.ErrorResponse(w, http.StatusRequestEntityTooLarge,
+				"File exceeds "+strconv.FormatInt(maxConvertFileBytes, 10)+" bytes")
+			return
+		}
+		utils.ErrorResponse(w, http.StatusBadRequest, "Expected a multipart/form-data upload")
+		return
+	}
+	defer func() { _ = r.MultipartForm.RemoveAll() }()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing file")
+		return
+	}
+	defer file.Close()
+	if header.Size > maxConvertFileBytes {
+		utils.ErrorResponse(w, http.StatusRequestEntityTooLarge,
+			"File exceeds "+strconv.FormatInt(maxConvertFileBytes, 10)+" bytes")
+		return
+	}
+	content, err := io.ReadAll(file)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Failed to read file")
+		return
+	}
+
+	toFormat := models.ConfigFormat(r.FormValue("to"))
+	if toFormat == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing target format")
+		return
+	}
+	fromFormat := models.ConfigFormat(r.FormValue("from"))
+
+	converted, sourceFormat, err := h.configService.ConvertFile(string(content), header.Filename, fromFormat, toFormat)
+	if err != nil {
+		var ambiguous *service.AmbiguousFormatError
+		if errors.As(err, &ambiguous) {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Conversion failed: "+err.Error()+"; set from to choose")
+			return
+		}
+		utils.ErrorResponse(w, http.StatusBadRequest, "Conversion failed: "+err.Error())
+		return
+	}
+
+	codec, _ := config.LookupCodec(toFormat)
+	w.Header().Set("Content-Type", codec.ContentType)
+	w.Header().Set("Content-Disposition",
+		"attachment; filename="+convertedFilename(header.Filename)+"."+codec.Extensions[0])
+	w.Header().Set("X-Source-Format", string(sourceFormat))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(converted))
+}
+
+// convertedFilename returns the upload's base name without its extension,
+// reduced to characters safe in a Content-Disposition header
+func convertedFilename(filename string) string {
+	base := path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	base = strings.TrimSuffix(base, path.Ext(base))
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return -1
+		}
+	}, base)
+	if strings.Trim(safe, ".") == "" {
+		return "config"
+	}
+	return safe
+}
+
+// ConvertBatch handles POST /api/configs/convert-batch
+func (h *ConfigHandler) ConvertBatch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Items []models.ConvertBatchItem `json:"items"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	response, err := h.configService.ConvertBatch(req.Items)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Batch conversion failed: "+err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, response)
+}
+
+// ValidateConfig handles POST /api/configs/validate
+// With template_id, the 400 body lists schema errors and unfilled required variables
+// ?include=parsed adds the content as Conflux parsed it, in canonical JSON form
+func (h *ConfigHandler) ValidateConfig(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Content     string              `json:"content"`
+		Format      models.ConfigFormat `json:"format"`
+		TemplateID  *int                `json:"template_id,omitempty"`
+		AllowedKeys []string            `json:"allowed_keys,omitempty"` // Permitted top-level keys when there's no schema
+		Strict      bool                `json:"strict"`                 // Unexpected keys are errors rather than warnings
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	opts := service.ValidateOptions{
+		AllowedKeys:   req.AllowedKeys,
+		Strict:        req.Strict,
+		IncludeParsed: includes(r, "parsed"),
+	}
+	result, err := h.configService.ValidateConfig(req.Content, req.Format, req.TemplateID, opts)
+	writeValidationResponse(w, result, err, opts, req.TemplateID != nil)
+}
+
+// ValidateUserConfig handles POST /api/configs/{id}/validate
+// Validates the stored content against the template's current schema and variables,
+// responding like POST /api/configs/validate; ?strict=true fails on unexpected keys
+func (h *ConfigHandler) ValidateUserConfig(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	configID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid configuration ID")
+		return
+	}
+
+	strict, _ := strconv.ParseBool(r.URL.Query().Get("strict"))
+	opts := service.ValidateOptions{Strict: strict, IncludeParsed: includes(r, "parsed")}
+	result, err := h.configService.ValidateUserConfig(configID, userID, opts)
+	if err != nil {
+		var templateErr *service.TemplateValidationError
+		switch {
+		case errors.As(err, &templateErr):
+		case strings.Contains(err.Error(), "unauthorized"):
+			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
+			return
+		case strings.Contains(err.Error(), "not found"):
+			utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+	}
+	writeValidationResponse(w, result, err, opts, true)
+}
+
+// writeValidationResponse writes the outcome of a validation
+// Template validation failures list every issue found; other errors mean the content
+// didn't parse, and syntax errors are listed under parse_errors with their position
+func writeValidationResponse(
+	w http.ResponseWriter, result *service.ValidationResult, err error, opts service.ValidateOptions, hasTemplate bool,
+) {
+	if err != nil {
+		var templateErr *service.TemplateValidationError
+		if errors.As(err, &templateErr) {
+			message := "Configuration is not ready to use with this template"
+			if !hasTemplate {
+				message = "Configuration has unexpected keys"
+			}
+			response := map[string]interface{}{
+				"error":              true,
+				"message":            message,
+				"status":             http.StatusBadRequest,
+				"errors":             templateErr.SchemaErrors,
+				"unfilled_variables": templateErr.UnfilledVariables,
+				"unknown_keys":       templateErr.UnknownKeys,
+			}
+			if result != nil {
+				response["warnings"] = result.Warnings
+				if opts.IncludeParsed {
+					response["parsed"] = result.Parsed
+				}
+			}
+			utils.JSONResponse(w, http.StatusBadRequest, response)
+			return
+		}
+		var parseErr *config.ParseError
+		if errors.As(err, &parseErr) {
+			utils.JSONResponse(w, http.StatusBadRequest, map[string]interface{}{
+				"error":        true,
+				"message":      "Validation failed: " + err.Error(),
+				"status":       http.StatusBadRequest,
+				"parse_errors": []*config.ParseError{parseErr},
+			})
+			return
 		}
+		utils.ErrorResponse(w, http.StatusBadRequest, "Validation failed: "+err.Error())
 		return
 	}
 
-	// Set appropriate content type
+	response := map[string]interface{}{
+		"message":  "Configuration is valid",
+		"warnings": result.Warnings,
+	}
+	if opts.IncludeParsed {
+		response["parsed"] = result.Parsed
+	}
+	utils.JSONResponse(w, http.StatusOK, response)
+}
+
+// includes reports whether the comma-separated ?include= list names field
+func includes(r *http.Request, field string) bool {
+	for _, value := range r.URL.Query()["include"] {
+		for _, name := range strings.Split(value, ",") {
+			if strings.TrimSpace(name) == field {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExportConfig handles GET /api/configs/{id}/export?format=yaml
+// ?resolve_secrets=true substitutes ${secret:NAME} placeholders in the output
+// The Digest header carries the SHA-256 of the body, as served by /raw.sha256
+func (h *ConfigHandler) ExportConfig(w http.ResponseWriter, r *http.Request) {
+	content, format, ok := h.exportContent(w, r)
+	if !ok {
+		return
+	}
+
+	// Set content type and file extension from the format registry
 	contentType := "text/plain"
-	switch format {
-	case models.FormatJSON:
-		contentType = "application/json"
-	case models.FormatYAML:
-		contentType = "application/x-yaml"
-	case models.FormatTOML:
-		contentType = "application/toml"
+	extension := string(format)
+	if codec, ok := config.LookupCodec(format); ok {
+		contentType = codec.ContentType
+		extension = codec.Extensions[0]
 	}
 
+	sum := sha256.Sum256([]byte(content))
 	w.Header().Set("Content-Type", contentType)
-	w.Header().Set("Content-Disposition", "attachment; filename=config."+string(format))
+	w.Header().Set("Content-Disposition", "attachment; filename=config."+extension)
+	w.Header().Set("Digest", "sha-256="+base64.StdEncoding.EncodeToString(sum[:]))
 	w.WriteHeader(http.StatusOK)
 
 	if _, err := w.Write([]byte(content)); err != nil {
@@ -533,10 +1360,308 @@ func (h *ConfigHandler) ExportConfig(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// Helper function to extract user ID from request context
-func getUserIDFromContext(r *http.Request) int {
-	if userID, ok := r.Context().Value("user_id").(int); ok {
-		return userID
+// ExportAllConfigs handles GET /api/configs/export-all
+// Sends every configuration the user owns as a zip; ?format= converts them all,
+// while "original" or no format keeps each configuration's own format
+func (h *ConfigHandler) ExportAllConfigs(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var format *models.ConfigFormat
+	if requested := r.URL.Query().Get("format"); requested != "" && requested != "original" {
+		target := models.ConfigFormat(requested)
+		format = &target
+	}
+
+	archive := &exportArchiveWriter{w: w}
+	if err := h.configService.ExportAll(userID, format, archive); err != nil {
+		if archive.started {
+			// Headers are already written; the zip is cut short and fails to open on the client
+			return
+		}
+		if strings.Contains(err.Error(), "validation failed") {
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to export configurations")
+		}
 	}
-	return 0
+}
+
+// exportArchiveWriter sends the export-all headers just before the first byte of the zip,
+// so an export that fails before writing anything can still get an error response
+type exportArchiveWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (a *exportArchiveWriter) Write(p []byte) (int, error) {
+	if !a.started {
+		a.started = true
+		a.w.Header().Set("Content-Type", "application/zip")
+		a.w.Header().Set("Content-Disposition", "attachment; filename=conflux-export.zip")
+		a.w.WriteHeader(http.StatusOK)
+	}
+	return a.w.Write(p)
+}
+
+// GetExportDigest handles GET /api/configs/{id}/raw.sha256
+// Takes the same ?format= and ?resolve_secrets= as the export endpoint and hashes the
+// exact bytes it would return, in sha256sum format so `sha256sum -c` can check a download
+func (h *ConfigHandler) GetExportDigest(w http.ResponseWriter, r *http.Request) {
+	content, format, ok := h.exportContent(w, r)
+	if !ok {
+		return
+	}
+
+	extension := string(format)
+	if codec, ok := config.LookupCodec(format); ok {
+		extension = codec.Extensions[0]
+	}
+
+	sum := sha256.Sum256([]byte(content))
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprintf(w, "%s  config.%s\n", hex.EncodeToString(sum[:]), extension)
+}
+
+// exportContent renders a configuration for export, writing an error response on failure
+func (h *ConfigHandler) exportContent(w http.ResponseWriter, r *http.Request) (string, models.ConfigFormat, bool) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return "", "", false
+	}
+
+	vars := mux.Vars(r)
+	configID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid configuration ID")
+		return "", "", false
+	}
+
+	format := h.exportFormat(r, userID)
+	resolveSecrets, _ := strconv.ParseBool(r.URL.Query().Get("resolve_secrets"))
+
+	content, err := h.configService.ExportConfig(configID, userID, format, resolveSecrets)
+	if err != nil {
+		var unresolved *config.UnresolvedSecretsError
+		if errors.As(err, &unresolved) {
+			utils.ErrorResponse(w, http.StatusUnprocessableEntity, "Export failed: "+err.Error())
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
+		} else {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Export failed: "+err.Error())
+		}
+		return "", "", false
+	}
+	return content, format, true
+}
+
+// Import Endpoints
+
+// StartImport handles POST /api/imports
+// Starts a background import; poll GET /api/imports/{id} for its outcome
+func (h *ConfigHandler) StartImport(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req struct {
+		SourceType models.ConfigSourceType `json:"source_type"` // "url" or "github"
+		SourceURL  string                  `json:"source_url"`
+		Format     models.ConfigFormat     `json:"format"` // Empty detects the format
+		Dedupe     bool                    `json:"dedupe"` // Reuse a matching config instead of creating a duplicate
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	importRecord, err := h.configService.ImportConfig(userID, req.SourceType, req.SourceURL, req.Format, req.Dedupe)
+	if err != nil {
+		if strings.Contains(err.Error(), "validation failed") {
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to start import")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusAccepted, importRecord)
+}
+
+// GetImport handles GET /api/imports/{id}
+func (h *ConfigHandler) GetImport(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	importID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid import ID")
+		return
+	}
+
+	importRecord, err := h.configService.GetImport(importID, userID, isAdminFromContext(r))
+	if err != nil {
+		if strings.Contains(err.Error(), "unauthorized") {
+			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
+		} else {
+			utils.ErrorResponse(w, http.StatusNotFound, "Import not found")
+		}
+		return
+	}
+
+	// A source that was down or errored is the upstream's fault, not the request's
+	if importRecord.Status == models.ImportFailed && importRecord.SourceFailed {
+		utils.JSONResponse(w, http.StatusBadGateway, importRecord)
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, importRecord)
+}
+
+// CancelImport handles POST /api/imports/{id}/cancel
+func (h *ConfigHandler) CancelImport(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	importID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid import ID")
+		return
+	}
+
+	importRecord, err := h.configService.CancelImport(importID, userID, isAdminFromContext(r))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "unauthorized"):
+			utils.ErrorResponse(w, http.StatusForbidden, "Unauthorized access")
+		case strings.Contains(err.Error(), "not in progress"):
+			utils.ErrorResponse(w, http.StatusConflict, "Import is not in progress")
+		default:
+			utils.ErrorResponse(w, http.StatusNotFound, "Import not found")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, importRecord)
+}
+
+// ListImports handles GET /api/admin/imports
+// Lists every user's imports in ?status= (default processing), oldest first, with their age
+func (h *ConfigHandler) ListImports(w http.ResponseWriter, r *http.Request) {
+	status := models.ImportStatus(r.URL.Query().Get("status"))
+	if status == "" {
+		status = models.ImportProcessing
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	imports, total, err := h.configService.ListImportsByStatus(status, isAdminFromContext(r), page, limit)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "unauthorized"):
+			utils.ErrorResponse(w, http.StatusForbidden, "Admin access required")
+		case strings.Contains(err.Error(), "validation failed"):
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid import status")
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve imports")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, utils.NewListResponse(imports, utils.OffsetPagination(page, limit, total)))
+}
+
+// FailImport handles POST /api/admin/imports/{id}/fail
+// Forces a stuck import to failed and releases its concurrency slot
+func (h *ConfigHandler) FailImport(w http.ResponseWriter, r *http.Request) {
+	importID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid import ID")
+		return
+	}
+
+	importRecord, err := h.configService.ForceFailImport(importID, isAdminFromContext(r))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "unauthorized"):
+			utils.ErrorResponse(w, http.StatusForbidden, "Admin access required")
+		case strings.Contains(err.Error(), "not in progress"):
+			utils.ErrorResponse(w, http.StatusConflict, "Import is not in progress")
+		default:
+			utils.ErrorResponse(w, http.StatusNotFound, "Import not found")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, importRecord)
+}
+
+// exportFormat resolves the export format: explicit ?format=, then the
+// user's default_export_format preference, then YAML
+func (h *ConfigHandler) exportFormat(r *http.Request, userID int) models.ConfigFormat {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return models.ConfigFormat(format)
+	}
+
+	if h.userService != nil {
+		if user, err := h.userService.GetUserByID(r.Context(), userID); err == nil && user.Preferences.DefaultExportFormat != "" {
+			return user.Preferences.DefaultExportFormat
+		}
+	}
+
+	return models.FormatYAML // Global default format
+}
+
+// listSort reads ?sort= and ?order= against a listing's sortable columns
+// Cursor pages always follow keyset order, so sorting them is rejected
+func listSort(r *http.Request, allowed []string, fallback models.ListSort) (models.ListSort, error) {
+	query := r.URL.Query()
+	if query.Has("cursor") && (query.Get("sort") != "" || query.Get("order") != "") {
+		return models.ListSort{}, errors.New("validation failed: sort and order are not supported with cursor pagination")
+	}
+	return models.ParseListSort(query.Get("sort"), query.Get("order"), allowed, fallback)
+}
+
+// sortedPagination describes a page of an offset-paginated listing and its order
+func sortedPagination(page, limit int, total int64, order models.ListSort) utils.Pagination {
+	pagination := utils.OffsetPagination(page, limit, total)
+	pagination.Sort, pagination.Order = order.Column, "asc"
+	if order.Descending {
+		pagination.Order = "desc"
+	}
+	return pagination
+}
+
+// Helper function to extract the authenticated user ID set by the auth middleware
+func getUserIDFromContext(r *http.Request) int {
+	return middleware.UserID(r)
+}
+
+// Helper function to check whether the authenticated user is an admin
+func isAdminFromContext(r *http.Request) bool {
+	claims, ok := middleware.Claims(r)
+	return ok && claims.Role == models.RoleAdmin
 }
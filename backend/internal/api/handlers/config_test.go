@@ -0,0 +1,817 @@
+package handlers
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"conflux/internal/api/middleware"
+	"conflux/internal/models"
+	"conflux/internal/service"
+	"conflux/pkg/config"
+	"conflux/pkg/jwt"
+
+	"github.com/gorilla/mux"
+)
+
+// withUser authenticates req as userID the way the auth middleware does
+func withUser(req *http.Request, userID int) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), middleware.UserKey, &jwt.Claims{UserID: userID}))
+}
+
+// emptyConfigRepo has one template and one configuration, but no list results
+// List methods return nil slices, as a repository scanning zero rows might
+type emptyConfigRepo struct {
+	service.ConfigRepository
+}
+
+func (emptyConfigRepo) GetTemplate(id int) (*models.ConfigTemplate, error) {
+	return &models.ConfigTemplate{ID: id, Name: "app", Format: models.FormatYAML}, nil
+}
+
+func (emptyConfigRepo) GetTemplates(category, search string, page, limit int) ([]*models.ConfigTemplate, int64, error) {
+	return nil, 0, nil
+}
+
+func (emptyConfigRepo) GetTemplateVersions(templateID int) ([]*models.TemplateVersion, error) {
+	return nil, nil
+}
+
+func (emptyConfigRepo) GetUserConfig(id int) (*models.UserConfig, error) {
+	return &models.UserConfig{ID: id, UserID: 1, Name: "mine", Format: models.FormatYAML}, nil
+}
+
+func (emptyConfigRepo) GetConfigLock(configID int) (*models.ConfigLock, error) {
+	return nil, nil
+}
+
+func (emptyConfigRepo) GetConfigShare(configID, userID int) (*models.ConfigShare, error) {
+	return nil, nil
+}
+
+func (emptyConfigRepo) GetUserConfigs(
+	userID int, templateID *int, order models.ListSort, page, limit int,
+) ([]*models.UserConfig, int64, error) {
+	return nil, 0, nil
+}
+
+func (emptyConfigRepo) GetUserConfigsAfter(userID int, templateID *int, afterID, limit int) ([]*models.UserConfig, error) {
+	return nil, nil
+}
+
+func (emptyConfigRepo) GetConfigVersions(
+	configID int, order models.ListSort, page, limit int,
+) ([]*models.ConfigVersion, int64, error) {
+	return nil, 0, nil
+}
+
+func (emptyConfigRepo) GetConfigVersionsBefore(configID, beforeID, limit int) ([]*models.ConfigVersion, error) {
+	return nil, nil
+}
+
+func TestConfigHandler_EmptyListsSerializeAsArrays(t *testing.T) {
+	handler := NewConfigHandler(service.NewConfigService(emptyConfigRepo{}), nil)
+
+	tests := []struct {
+		name   string
+		target string
+		vars   map[string]string
+		handle http.HandlerFunc
+		want   []string
+	}{
+		{name: "templates", target: "/api/templates", handle: handler.GetTemplates, want: []string{`"items":[]`, `"templates":[]`}},
+		{
+			name: "template versions", target: "/api/templates/1/versions", vars: map[string]string{"id": "1"},
+			handle: handler.GetTemplateVersions, want: []string{`"versions":[]`},
+		},
+		{name: "configs", target: "/api/configs", handle: handler.GetUserConfigs, want: []string{`"items":[]`, `"configs":[]`}},
+		{name: "configs by cursor", target: "/api/configs?cursor=", handle: handler.GetUserConfigs, want: []string{`"items":[]`, `"configs":[]`}},
+		{
+			name: "versions", target: "/api/configs/1/versions", vars: map[string]string{"id": "1"},
+			handle: handler.GetConfigVersions, want: []string{`"items":[]`, `"versions":[]`},
+		},
+		{
+			name: "versions by cursor", target: "/api/configs/1/versions?cursor=", vars: map[string]string{"id": "1"},
+			handle: handler.GetConfigVersions, want: []string{`"items":[]`, `"versions":[]`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req = withUser(req, 1)
+			if tt.vars != nil {
+				req = mux.SetURLVars(req, tt.vars)
+			}
+			rec := httptest.NewRecorder()
+			tt.handle(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			body := rec.Body.String()
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("body = %s, want %s", body, want)
+				}
+			}
+		})
+	}
+}
+
+// restoreConfigRepo holds configuration 1 with versions 1 (id 10) and 2 (id 11)
+type restoreConfigRepo struct {
+	emptyConfigRepo
+	created *models.ConfigVersion
+}
+
+func (r *restoreConfigRepo) GetConfigVersion(id int) (*models.ConfigVersion, error) {
+	return &models.ConfigVersion{ID: id, ConfigID: 1, Version: id - 9, Content: "port: 8080\n"}, nil
+}
+
+func (r *restoreConfigRepo) GetConfigVersions(
+	configID int, order models.ListSort, page, limit int,
+) ([]*models.ConfigVersion, int64, error) {
+	return []*models.ConfigVersion{{ID: 11, ConfigID: 1, Version: 2, Content: "port: 9090\n"}}, 2, nil
+}
+
+func (r *restoreConfigRepo) UpdateUserConfig(id int, config *models.UserConfig) error {
+	return nil
+}
+
+func (r *restoreConfigRepo) CreateVersion(version *models.ConfigVersion) error {
+	version.ID = 12
+	r.created = version
+	return nil
+}
+
+func TestConfigHandler_RestoreConfigVersionReportsVersions(t *testing.T) {
+	repo := &restoreConfigRepo{}
+	handler := NewConfigHandler(service.NewConfigService(repo), nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/configs/1/versions/10/restore", nil)
+	req = withUser(req, 1)
+	req = mux.SetURLVars(req, map[string]string{"id": "1", "version_id": "10"})
+	rec := httptest.NewRecorder()
+	handler.RestoreConfigVersion(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Config              *models.UserConfig `json:"config"`
+		Version             int                `json:"version"`
+		VersionID           int                `json:"version_id"`
+		RestoredFrom        int                `json:"restored_from"`
+		RestoredFromVersion int                `json:"restored_from_version"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if body.Version != 3 || body.VersionID != 12 || body.RestoredFrom != 10 || body.RestoredFromVersion != 1 {
+		t.Errorf("response = %+v, want version 3 (id 12) restored from version 1 (id 10)", body)
+	}
+	if body.Config == nil || body.Config.Content != "port: 8080\n" {
+		t.Errorf("config = %+v, want the restored content", body.Config)
+	}
+	if repo.created == nil || repo.created.RestoredFrom == nil || *repo.created.RestoredFrom != 10 {
+		t.Errorf("created version = %+v, want restored_from 10", repo.created)
+	}
+}
+
+// multipartUpload builds a multipart request with a file part and form fields
+func multipartUpload(t *testing.T, filename string, content []byte, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("CreateFormFile() error = %v", err)
+	}
+	_, _ = part.Write(content)
+	for name, value := range fields {
+		_ = writer.WriteField(name, value)
+	}
+	_ = writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/configs/convert/file", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestConfigHandler_ConvertFile(t *testing.T) {
+	handler := NewConfigHandler(service.NewConfigService(emptyConfigRepo{}), nil)
+
+	t.Run("converts and names the download", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ConvertFile(rec, multipartUpload(t, "app.settings.yml", []byte("server:\n  port: 8080\n"), map[string]string{"to": "json"}))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+		if got := rec.Header().Get("Content-Disposition"); got != "attachment; filename=app.settings.json" {
+			t.Errorf("Content-Disposition = %q", got)
+		}
+		if got := rec.Header().Get("X-Source-Format"); got != "yaml" {
+			t.Errorf("X-Source-Format = %q, want yaml", got)
+		}
+		var converted map[string]map[string]float64
+		if err := json.Unmarshal(rec.Body.Bytes(), &converted); err != nil || converted["server"]["port"] != 8080 {
+			t.Errorf("body = %s, want the converted JSON", rec.Body)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		filename string
+		content  []byte
+		fields   map[string]string
+		want     int
+	}{
+		{name: "explicit source format", filename: "upload", content: []byte("PORT=8080\n"), fields: map[string]string{"from": "env", "to": "yaml"}, want: http.StatusOK},
+		{name: "missing target", filename: "app.yaml", content: []byte("port: 8080\n"), want: http.StatusBadRequest},
+		{name: "unsupported target", filename: "app.yaml", content: []byte("port: 8080\n"), fields: map[string]string{"to": "xml"}, want: http.StatusBadRequest},
+		{name: "binary file", filename: "app.yaml", content: []byte{0x89, 'P', 'N', 'G', 0, 0}, fields: map[string]string{"to": "json"}, want: http.StatusBadRequest},
+		{name: "too large", filename: "app.yaml", content: bytes.Repeat([]byte("a: 1\n"), int(maxConvertFileBytes/5)+1), fields: map[string]string{"to": "json"}, want: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ConvertFile(rec, multipartUpload(t, tt.filename, tt.content, tt.fields))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/configs/convert/file", strings.NewReader(`{"to": "json"}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ConvertFile(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("JSON body status = %d, want 400", rec.Code)
+	}
+}
+
+// exportConfigRepo serves one YAML configuration for export
+type exportConfigRepo struct {
+	emptyConfigRepo
+}
+
+func (exportConfigRepo) GetUserConfig(id int) (*models.UserConfig, error) {
+	return &models.UserConfig{ID: id, UserID: 1, Name: "mine", Format: models.FormatYAML, Content: "port: 8080\n"}, nil
+}
+
+func TestConfigHandler_ExportDigestMatchesExport(t *testing.T) {
+	handler := NewConfigHandler(service.NewConfigService(exportConfigRepo{}), nil)
+
+	for _, format := range []string{"yaml", "json"} {
+		t.Run(format, func(t *testing.T) {
+			get := func(path string, serve http.HandlerFunc) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodGet, path+"?format="+format, nil)
+				req = withUser(req, 1)
+				req = mux.SetURLVars(req, map[string]string{"id": "1"})
+				rec := httptest.NewRecorder()
+				serve(rec, req)
+				if rec.Code != http.StatusOK {
+					t.Fatalf("%s status = %d, want 200: %s", path, rec.Code, rec.Body)
+				}
+				return rec
+			}
+
+			export := get("/api/configs/1/export", handler.ExportConfig)
+			sum := sha256.Sum256(export.Body.Bytes())
+			if got, want := export.Header().Get("Digest"), "sha-256="+base64.StdEncoding.EncodeToString(sum[:]); got != want {
+				t.Errorf("Digest = %q, want %q", got, want)
+			}
+
+			digest := get("/api/configs/1/raw.sha256", handler.GetExportDigest)
+			if got, want := digest.Body.String(), hex.EncodeToString(sum[:])+"  config."+format+"\n"; got != want {
+				t.Errorf("raw.sha256 = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestConfigHandler_ValidateConfigIncludeParsed(t *testing.T) {
+	handler := NewConfigHandler(service.NewConfigService(emptyConfigRepo{}), nil)
+	body := `{"content": "server:\n  port: 8080\n  tags: [a, b]\n", "format": "yaml"}`
+
+	validate := func(target string) map[string]interface{} {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ValidateConfig(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("invalid response body: %v", err)
+		}
+		return response
+	}
+
+	response := validate("/api/configs/validate?include=warnings,parsed")
+	want := map[string]interface{}{
+		"server": map[string]interface{}{"port": float64(8080), "tags": []interface{}{"a", "b"}},
+	}
+	if !reflect.DeepEqual(response["parsed"], want) {
+		t.Errorf("parsed = %v, want %v", response["parsed"], want)
+	}
+
+	if _, ok := validate("/api/configs/validate")["parsed"]; ok {
+		t.Error("parsed is included without ?include=parsed")
+	}
+}
+
+func TestConfigHandler_ValidateConfigParseErrors(t *testing.T) {
+	handler := NewConfigHandler(service.NewConfigService(emptyConfigRepo{}), nil)
+	body := `{"content": "name: app\nserver: local\n  port: 8080\n", "format": "yaml"}`
+
+	rec := httptest.NewRecorder()
+	handler.ValidateConfig(rec, httptest.NewRequest(http.MethodPost, "/api/configs/validate", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body)
+	}
+
+	var response struct {
+		ParseErrors []config.ParseError `json:"parse_errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response body: %v", err)
+	}
+	if len(response.ParseErrors) != 1 || response.ParseErrors[0].Line != 3 || response.ParseErrors[0].Message == "" {
+		t.Errorf("parse_errors = %+v, want one error on line 3", response.ParseErrors)
+	}
+}
+
+func TestConfigHandler_ValidateUserConfig(t *testing.T) {
+	handler := NewConfigHandler(service.NewConfigService(emptyConfigRepo{}), nil)
+
+	validate := func(userID int) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/configs/1/validate?strict=true", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		req = withUser(req, userID)
+		rec := httptest.NewRecorder()
+		handler.ValidateUserConfig(rec, req)
+		return rec
+	}
+
+	if rec := validate(1); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Configuration is valid") {
+		t.Errorf("owner: status = %d, body = %s; want 200 valid", rec.Code, rec.Body)
+	}
+	if rec := validate(2); rec.Code != http.StatusForbidden {
+		t.Errorf("other user: status = %d, want 403", rec.Code)
+	}
+}
+
+// lockedConfigRepo is emptyConfigRepo with its configuration locked by user 2
+type lockedConfigRepo struct {
+	emptyConfigRepo
+}
+
+func (lockedConfigRepo) GetConfigLock(configID int) (*models.ConfigLock, error) {
+	return &models.ConfigLock{ConfigID: configID, HolderID: 2, ExpiresAt: time.Now().Add(time.Minute)}, nil
+}
+
+func (r lockedConfigRepo) AcquireConfigLock(configID, holderID int, ttl time.Duration) (*models.ConfigLock, error) {
+	return r.GetConfigLock(configID)
+}
+
+func TestConfigHandler_LockedConfig(t *testing.T) {
+	handler := NewConfigHandler(service.NewConfigService(lockedConfigRepo{}), nil)
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		handle     http.HandlerFunc
+		wantStatus int
+	}{
+		{name: "detail shows the lock", method: http.MethodGet, handle: handler.GetUserConfig, wantStatus: http.StatusOK},
+		{
+			name: "update by a non-holder", method: http.MethodPut, body: `{"content": "port: 9000"}`,
+			handle: handler.UpdateUserConfig, wantStatus: http.StatusLocked,
+		},
+		{name: "lock held by another user", method: http.MethodPost, handle: handler.LockUserConfig, wantStatus: http.StatusLocked},
+		{name: "unlock held by another user", method: http.MethodDelete, handle: handler.UnlockUserConfig, wantStatus: http.StatusLocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/configs/1", strings.NewReader(tt.body))
+			req = mux.SetURLVars(req, map[string]string{"id": "1"})
+			req = withUser(req, 1)
+			rec := httptest.NewRecorder()
+			tt.handle(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			var body struct {
+				Lock *models.ConfigLock `json:"lock"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if body.Lock == nil || body.Lock.HolderID != 2 {
+				t.Errorf("lock = %+v, want held by user 2", body.Lock)
+			}
+		})
+	}
+}
+
+func TestConfigHandler_PatchUserConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantContent string
+	}{
+		{
+			name: "merge patch", contentType: "application/merge-patch+json", body: `{"port": 9090}`,
+			wantStatus: http.StatusOK, wantContent: "port: 9090\n",
+		},
+		{name: "patch that isn't an object", contentType: "application/merge-patch+json", body: `[1]`, wantStatus: http.StatusBadRequest},
+		{name: "JSON Patch isn't supported", contentType: "application/json-patch+json", body: `[]`, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "plain JSON", contentType: "application/json", body: `{"port": 9090}`, wantStatus: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewConfigHandler(service.NewConfigService(&restoreConfigRepo{}), nil)
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/configs/1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req = mux.SetURLVars(req, map[string]string{"id": "1"})
+			req = withUser(req, 1)
+			rec := httptest.NewRecorder()
+			handler.PatchUserConfig(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantContent == "" {
+				return
+			}
+			var config models.UserConfig
+			if err := json.NewDecoder(rec.Body).Decode(&config); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if config.Content != tt.wantContent {
+				t.Errorf("content = %q, want %q", config.Content, tt.wantContent)
+			}
+		})
+	}
+}
+
+// historyConfigRepo is emptyConfigRepo with three versions of its configuration
+type historyConfigRepo struct {
+	emptyConfigRepo
+}
+
+func (historyConfigRepo) GetConfigVersionsBefore(configID, beforeID, limit int) ([]*models.ConfigVersion, error) {
+	if beforeID != 0 {
+		return nil, nil
+	}
+	restored := 10
+	return []*models.ConfigVersion{
+		{ID: 12, ConfigID: configID, Version: 3, Content: "port: 8080\n", ChangeNote: "Restored version 1", RestoredFrom: &restored},
+		{ID: 11, ConfigID: configID, Version: 2, Content: "port: 9090\n", ChangeNote: "Bump port"},
+		{ID: 10, ConfigID: configID, Version: 1, Content: "port: 8080\n", ChangeNote: "Initial version"},
+	}, nil
+}
+
+// readHistoryArchive returns the files of a tar.gz history archive in order
+func readHistoryArchive(t *testing.T, body []byte) ([]string, map[string]string) {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("archive is not gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	files := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return names, files
+		}
+		if err != nil {
+			t.Fatalf("archive is not tar: %v", err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("read %s: %v", header.Name, err)
+		}
+		names = append(names, header.Name)
+		files[header.Name] = string(content)
+	}
+}
+
+func TestConfigHandler_GetHistoryArchive(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		userID     int
+		wantStatus int
+		wantFiles  []string
+	}{
+		{
+			name:       "snapshots",
+			userID:     1,
+			wantStatus: http.StatusOK,
+			wantFiles:  []string{"manifest.json", "versions/0001.yaml", "versions/0002.yaml", "versions/0003.yaml"},
+		},
+		{
+			name:       "patches",
+			query:      "?mode=patches",
+			userID:     1,
+			wantStatus: http.StatusOK,
+			wantFiles:  []string{"manifest.json", "patches/0001.patch", "patches/0002.patch", "patches/0003.patch"},
+		},
+		{name: "unknown mode", query: "?mode=zip", userID: 1, wantStatus: http.StatusBadRequest},
+		{name: "not the owner", userID: 2, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewConfigHandler(service.NewConfigService(historyConfigRepo{}), nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/configs/1/history/archive"+tt.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": "1"})
+			req = withUser(req, tt.userID)
+			rec := httptest.NewRecorder()
+			handler.GetHistoryArchive(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantFiles == nil {
+				return
+			}
+			if got := rec.Header().Get("Content-Disposition"); got != "attachment; filename=mine-history.tar.gz" {
+				t.Errorf("Content-Disposition = %q", got)
+			}
+
+			names, files := readHistoryArchive(t, rec.Body.Bytes())
+			if !reflect.DeepEqual(names, tt.wantFiles) {
+				t.Fatalf("files = %v, want %v", names, tt.wantFiles)
+			}
+
+			var manifest models.HistoryManifest
+			if err := json.Unmarshal([]byte(files["manifest.json"]), &manifest); err != nil {
+				t.Fatalf("manifest is not JSON: %v", err)
+			}
+			if len(manifest.Versions) != 3 || manifest.Versions[0].ChangeNote != "Initial version" {
+				t.Fatalf("manifest versions = %+v", manifest.Versions)
+			}
+			if restored := manifest.Versions[2].RestoredFrom; restored == nil || *restored != 1 {
+				t.Errorf("version 3 restored_from = %v, want 1", restored)
+			}
+			for i, entry := range manifest.Versions {
+				if entry.File != tt.wantFiles[i+1] {
+					t.Errorf("version %d file = %q, want %q", entry.Version, entry.File, tt.wantFiles[i+1])
+				}
+			}
+
+			if tt.query == "" {
+				if files["versions/0002.yaml"] != "port: 9090\n" {
+					t.Errorf("version 2 snapshot = %q", files["versions/0002.yaml"])
+				}
+				return
+			}
+			first := files["patches/0001.patch"]
+			if !strings.HasPrefix(first, "--- /dev/null\n+++ b/config.yaml\n") || !strings.Contains(first, "+port: 8080\n") {
+				t.Errorf("first patch does not create the file:\n%s", first)
+			}
+			if second := files["patches/0002.patch"]; !strings.Contains(second, "-port: 8080\n+port: 9090\n") {
+				t.Errorf("second patch:\n%s", second)
+			}
+		})
+	}
+}
+
+func TestConfigHandler_MergeConfigs(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantContent string
+	}{
+		{
+			name:        "nested override",
+			body:        `{"base": "{\"server\": {\"host\": \"a\", \"port\": 80}}", "override": "{\"server\": {\"port\": 443}}", "format": "json"}`,
+			wantStatus:  http.StatusOK,
+			wantContent: "{\n  \"server\": {\n    \"host\": \"a\",\n    \"port\": 443\n  }\n}",
+		},
+		{
+			name:        "append arrays",
+			body:        `{"base": "hosts:\n  - a\n", "override": "hosts:\n  - b\n", "format": "yaml", "append_arrays": true}`,
+			wantStatus:  http.StatusOK,
+			wantContent: "hosts:\n    - a\n    - b\n",
+		},
+		{name: "invalid base", body: `{"base": "{", "format": "json"}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid body", body: `{`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewConfigHandler(service.NewConfigService(emptyConfigRepo{}), nil)
+			rec := httptest.NewRecorder()
+			handler.MergeConfigs(rec, httptest.NewRequest(http.MethodPost, "/api/configs/merge", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantContent == "" {
+				return
+			}
+			var body struct {
+				Content string `json:"content"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if body.Content != tt.wantContent {
+				t.Errorf("content = %q, want %q", body.Content, tt.wantContent)
+			}
+		})
+	}
+}
+
+// importStatusRepo serves one import record per ID
+type importStatusRepo struct {
+	service.ConfigRepository
+	imports map[int]*models.ConfigImport
+}
+
+func (r importStatusRepo) GetImport(id int) (*models.ConfigImport, error) {
+	if importRecord, ok := r.imports[id]; ok {
+		return importRecord, nil
+	}
+	return nil, errors.New("import not found")
+}
+
+func TestConfigHandler_GetImportSourceFailure(t *testing.T) {
+	message := "import source returned status 500 (Internal Server Error)"
+	invalid := "not a text config"
+	repo := importStatusRepo{imports: map[int]*models.ConfigImport{
+		1: {ID: 1, UserID: 1, Status: models.ImportFailed, ErrorMessage: &message, SourceFailed: true},
+		2: {ID: 2, UserID: 1, Status: models.ImportFailed, ErrorMessage: &invalid},
+		3: {ID: 3, UserID: 1, Status: models.ImportCompleted},
+	}}
+	handler := NewConfigHandler(service.NewConfigService(repo), nil)
+
+	tests := []struct {
+		id         string
+		wantStatus int
+	}{
+		{id: "1", wantStatus: http.StatusBadGateway},
+		{id: "2", wantStatus: http.StatusOK},
+		{id: "3", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/imports/"+tt.id, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": tt.id})
+		req = withUser(req, 1)
+		w := httptest.NewRecorder()
+
+		handler.GetImport(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("import %s: status = %d, want %d", tt.id, w.Code, tt.wantStatus)
+		}
+		var body models.ConfigImport
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.ID == 0 {
+			t.Errorf("import %s: body = %s, want the import record", tt.id, w.Body.String())
+		}
+	}
+}
+
+// startImportRepo records created imports; status updates from the worker are ignored
+type startImportRepo struct {
+	service.ConfigRepository
+	created *[]models.ConfigImport
+}
+
+func (r startImportRepo) CreateImport(importRecord *models.ConfigImport) error {
+	importRecord.ID = len(*r.created) + 1
+	*r.created = append(*r.created, *importRecord)
+	return nil
+}
+
+func (r startImportRepo) UpdateImport(id int, importRecord *models.ConfigImport) error {
+	return nil
+}
+
+func (r startImportRepo) GetImport(id int) (*models.ConfigImport, error) {
+	return &models.ConfigImport{ID: id}, nil
+}
+
+func TestConfigHandler_StartImport(t *testing.T) {
+	var created []models.ConfigImport
+	handler := NewConfigHandler(service.NewConfigService(startImportRepo{created: &created}), nil)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "url with dedupe",
+			body:       `{"source_type": "url", "source_url": "http://127.0.0.1:1/app.yaml", "format": "yaml", "dedupe": true}`,
+			wantStatus: http.StatusAccepted,
+		},
+		{name: "missing url", body: `{"source_type": "url"}`, wantStatus: http.StatusBadRequest},
+		{name: "unsupported source", body: `{"source_type": "gitlab", "source_url": "https://gitlab.com/a/b"}`, wantStatus: http.StatusBadRequest},
+		{name: "unsupported format", body: `{"source_type": "url", "source_url": "https://example.com/a", "format": "xml"}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid github source", body: `{"source_type": "github", "source_url": "not-a-repo"}`, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := withUser(httptest.NewRequest(http.MethodPost, "/api/imports", strings.NewReader(tt.body)), 1)
+			w := httptest.NewRecorder()
+
+			handler.StartImport(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	if len(created) != 1 || !created[0].Dedupe || created[0].UserID != 1 || created[0].SourceType != models.SourceURL {
+		t.Errorf("created imports = %+v, want one deduplicating URL import for user 1", created)
+	}
+}
+
+func TestConfigHandler_ExportAllConfigsWithoutConfigs(t *testing.T) {
+	handler := NewConfigHandler(service.NewConfigService(emptyConfigRepo{}), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/configs/export-all?format=original", nil)
+	req = withUser(req, 1)
+	rec := httptest.NewRecorder()
+
+	handler.ExportAllConfigs(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != "attachment; filename=conflux-export.zip" {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("body is not a zip: %v", err)
+	}
+	if len(zr.File) != 0 {
+		t.Errorf("zip has %d entries, want 0", len(zr.File))
+	}
+}
+
+// unconvertibleConfigRepo lists one configuration whose content doesn't parse
+type unconvertibleConfigRepo struct {
+	emptyConfigRepo
+}
+
+func (unconvertibleConfigRepo) GetUserConfigsAfter(userID int, templateID *int, afterID, limit int) ([]*models.UserConfig, error) {
+	return []*models.UserConfig{{ID: 1, UserID: userID, Name: "broken", Format: models.FormatYAML, Content: "port: [8080\n"}}, nil
+}
+
+func TestConfigHandler_ExportAllConfigsFailsBeforeStreaming(t *testing.T) {
+	handler := NewConfigHandler(service.NewConfigService(unconvertibleConfigRepo{}), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/configs/export-all?format=json", nil)
+	req = withUser(req, 1)
+	rec := httptest.NewRecorder()
+
+	handler.ExportAllConfigs(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got == "application/zip" {
+		t.Errorf("Content-Type = %q, want an error response", got)
+	}
+}
@@ -41,7 +41,7 @@ func (h *DevHandler) GetDevToken(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"token": token,
 		"user": map[string]string{
-			"email":      "dev@conflux.local",
+			"email":      service.DevUserEmail,
 			"first_name": "Dev",
 			"last_name":  "User",
 		},
@@ -73,8 +73,8 @@ func (h *DevHandler) CreateDevUser(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"message": "Development user ready",
 		"credentials": map[string]string{
-			"email":    "dev@conflux.local",
-			"password": "password123",
+			"email":    service.DevUserEmail,
+			"password": service.DevUserPassword,
 		},
 	}
 
@@ -0,0 +1,20 @@
+// Fallback handlers for requests that match no route
+// Keeps unmatched-route responses in the same JSON shape as the rest of the API
+// Registered on the router as NotFoundHandler and MethodNotAllowedHandler
+package handlers
+
+import (
+	"net/http"
+
+	"conflux/pkg/utils"
+)
+
+// NotFound handles requests to paths with no registered route
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	utils.ErrorResponse(w, http.StatusNotFound, "Resource not found: "+r.URL.Path)
+}
+
+// MethodNotAllowed handles requests to known paths using an unsupported method
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method "+r.Method+" not allowed on "+r.URL.Path)
+}
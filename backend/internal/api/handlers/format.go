@@ -0,0 +1,34 @@
+// Format capabilities handler
+// Exposes the supported configuration formats and conversion matrix
+// Lets clients discover formats instead of hardcoding them
+package handlers
+
+import (
+	"net/http"
+
+	"conflux/pkg/config"
+	"conflux/pkg/utils"
+)
+
+// FormatHandler serves format capability information
+type FormatHandler struct {
+	parser *config.Parser
+}
+
+// NewFormatHandler creates a new format capabilities handler
+func NewFormatHandler(parser *config.Parser) *FormatHandler {
+	return &FormatHandler{
+		parser: parser,
+	}
+}
+
+// GetFormats handles GET /api/formats
+// Returns supported formats with content types, extensions, and conversion caveats
+func (h *FormatHandler) GetFormats(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{
+		"formats":     h.parser.Formats(),
+		"conversions": h.parser.Conversions(),
+	}
+
+	utils.JSONResponse(w, http.StatusOK, response)
+}
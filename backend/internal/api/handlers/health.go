@@ -4,52 +4,107 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
+	"sync"
+	"time"
+
+	"conflux/internal/api/middleware"
+	"conflux/internal/config"
 )
 
+// dbPinger is the part of *sql.DB the readiness check needs
+type dbPinger interface {
+	PingContext(ctx context.Context) error
+}
+
 // HealthHandler provides health check endpoints
 type HealthHandler struct {
-	db *sql.DB
+	db       dbPinger
+	features config.Features
+	timeout  time.Duration // Longest a database ping may take
+	cacheTTL time.Duration // How long a ping result is reused
+	now      func() time.Time
+
+	maintenance *middleware.Maintenance // nil when maintenance mode isn't available
+
+	mu        sync.Mutex // Held across the ping so concurrent probes share one
+	checkedAt time.Time
+	dbErr     error
 }
 
 // NewHealthHandler creates a new health check handler
-func NewHealthHandler(db *sql.DB) *HealthHandler {
-	return &HealthHandler{db: db}
+// Enabled feature flags and maintenance mode are reported so operators can see what is switched on
+// Database pings give up after timeout and their result is reused for cacheTTL
+func NewHealthHandler(
+	db *sql.DB, features config.Features, maintenance *middleware.Maintenance, timeout, cacheTTL time.Duration,
+) *HealthHandler {
+	h := &HealthHandler{features: features, maintenance: maintenance, timeout: timeout, cacheTTL: cacheTTL, now: time.Now}
+	if db != nil {
+		h.db = db
+	}
+	return h
 }
 
-// CheckHealth returns service health status
-// GET /health - Returns 200 OK if service is healthy
-func (h *HealthHandler) CheckHealth(w http.ResponseWriter, r *http.Request) {
-	// Health check implementation:
-	// - Verify database connectivity
-	// - Check critical dependencies
-	// - Return appropriate HTTP status
+// CheckLiveness reports that the process is up and serving requests
+// GET /health/live - Always 200; it never touches dependencies so a slow
+// database can't get the process restarted
+func (h *HealthHandler) CheckLiveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"status":"alive"}` + "\n"))
+}
 
+// CheckHealth returns service readiness
+// GET /health - Returns 200 OK if service is healthy, 503 if the database is unreachable
+// Maintenance mode doesn't affect readiness, since reads are still served
+func (h *HealthHandler) CheckHealth(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]string{}
 	response := map[string]interface{}{
-		"status": "healthy",
-		"checks": map[string]string{},
+		"status":      "healthy",
+		"checks":      checks,
+		"features":    h.features.List(),
+		"maintenance": h.maintenance.Enabled(),
 	}
+	status := http.StatusOK
 
 	// Check database connectivity
 	if h.db != nil {
-		if err := h.db.Ping(); err != nil {
+		if err := h.pingDatabase(r.Context()); err != nil {
 			response["status"] = "unhealthy"
-			if checks, ok := response["checks"].(map[string]string); ok {
-				checks["database"] = "failed: " + err.Error()
-			}
-			w.WriteHeader(http.StatusServiceUnavailable)
+			checks["database"] = "failed: " + err.Error()
+			status = http.StatusServiceUnavailable
 		} else {
-			if checks, ok := response["checks"].(map[string]string); ok {
-				checks["database"] = "healthy"
-			}
+			checks["database"] = "healthy"
 		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		// Log error but headers are already written
 		return
 	}
 }
+
+// pingDatabase pings the database, reusing a result younger than cacheTTL
+// so frequent probes don't each cost a round trip
+func (h *HealthHandler) pingDatabase(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	if !h.checkedAt.IsZero() && now.Sub(h.checkedAt) < h.cacheTTL {
+		return h.dbErr
+	}
+
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	h.dbErr = h.db.PingContext(ctx)
+	h.checkedAt = now
+	return h.dbErr
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"conflux/internal/api/middleware"
+)
+
+// countingPinger counts pings and fails while err is set
+type countingPinger struct {
+	pings int
+	err   error
+	block bool // Wait for the context to end instead of answering
+}
+
+func (p *countingPinger) PingContext(ctx context.Context) error {
+	p.pings++
+	if p.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return p.err
+}
+
+func TestHealthHandler_CachesDatabasePing(t *testing.T) {
+	pinger := &countingPinger{}
+	clock := time.Unix(0, 0)
+	h := &HealthHandler{db: pinger, timeout: time.Second, cacheTTL: time.Second, now: func() time.Time { return clock }}
+
+	probe := func() int {
+		rec := httptest.NewRecorder()
+		h.CheckHealth(rec, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+		return rec.Code
+	}
+
+	if code := probe(); code != http.StatusOK {
+		t.Fatalf("first probe status = %d, want 200", code)
+	}
+	pinger.err = errors.New("connection refused")
+	clock = clock.Add(500 * time.Millisecond)
+	if code := probe(); code != http.StatusOK {
+		t.Errorf("probe within cache window status = %d, want cached 200", code)
+	}
+	if pinger.pings != 1 {
+		t.Errorf("pings within cache window = %d, want 1", pinger.pings)
+	}
+
+	clock = clock.Add(time.Second)
+	if code := probe(); code != http.StatusServiceUnavailable {
+		t.Errorf("probe after cache window status = %d, want 503", code)
+	}
+	if pinger.pings != 2 {
+		t.Errorf("pings after cache window = %d, want 2", pinger.pings)
+	}
+}
+
+func TestHealthHandler_PingTimeout(t *testing.T) {
+	h := &HealthHandler{db: &countingPinger{block: true}, timeout: 10 * time.Millisecond, now: time.Now}
+
+	rec := httptest.NewRecorder()
+	h.CheckHealth(rec, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("stalled database status = %d, want 503", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+}
+
+func TestHealthHandler_LivenessSkipsDatabase(t *testing.T) {
+	pinger := &countingPinger{err: errors.New("down")}
+	h := &HealthHandler{db: pinger, now: time.Now}
+
+	rec := httptest.NewRecorder()
+	h.CheckLiveness(rec, httptest.NewRequest(http.MethodGet, "/api/health/live", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("liveness status = %d, want 200", rec.Code)
+	}
+	if pinger.pings != 0 {
+		t.Errorf("liveness pinged the database %d times", pinger.pings)
+	}
+}
+
+func TestHealthHandler_ReportsMaintenance(t *testing.T) {
+	maintenance := middleware.NewMaintenance(true, time.Minute)
+	h := &HealthHandler{maintenance: maintenance, now: time.Now}
+
+	rec := httptest.NewRecorder()
+	h.CheckHealth(rec, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+
+	// Reads still work, so the service stays ready
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+	var body struct {
+		Maintenance bool `json:"maintenance"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || !body.Maintenance {
+		t.Errorf("maintenance = %v (%v), want true", body.Maintenance, err)
+	}
+}
@@ -0,0 +1,80 @@
+// Import token HTTP handlers
+// Lets users save the access token their imports of private sources use
+// Tokens are write-only: they are never returned once saved
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"conflux/internal/models"
+	"conflux/internal/service"
+	"conflux/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// ImportTokenHandler handles import token HTTP requests
+type ImportTokenHandler struct {
+	importTokenService *service.ImportTokenService
+}
+
+// NewImportTokenHandler creates import token handler with service dependency
+func NewImportTokenHandler(importTokenService *service.ImportTokenService) *ImportTokenHandler {
+	return &ImportTokenHandler{
+		importTokenService: importTokenService,
+	}
+}
+
+// SetImportToken handles PUT /api/me/import-tokens/{source}
+// Saves the caller's token for the source, replacing any previous one
+func (h *ImportTokenHandler) SetImportToken(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	source := models.ConfigSourceType(mux.Vars(r)["source"])
+	if err := h.importTokenService.SetToken(r.Context(), userID, source, req.Token); err != nil {
+		if strings.Contains(err.Error(), "validation failed") {
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to save import token")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "Import token saved"})
+}
+
+// DeleteImportToken handles DELETE /api/me/import-tokens/{source}
+// Later imports from the source are made anonymously
+func (h *ImportTokenHandler) DeleteImportToken(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	source := models.ConfigSourceType(mux.Vars(r)["source"])
+	if err := h.importTokenService.DeleteToken(r.Context(), userID, source); err != nil {
+		if strings.Contains(err.Error(), "validation failed") {
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to delete import token")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "Import token deleted"})
+}
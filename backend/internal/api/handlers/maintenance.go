@@ -0,0 +1,46 @@
+// Maintenance mode HTTP handlers
+// Lets administrators see and switch read-only maintenance mode at runtime
+// The switch itself stays writable while maintenance mode is on
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"conflux/internal/api/middleware"
+	"conflux/pkg/utils"
+)
+
+// MaintenanceHandler handles maintenance mode HTTP requests
+type MaintenanceHandler struct {
+	maintenance *middleware.Maintenance
+	logger      *slog.Logger
+}
+
+// NewMaintenanceHandler creates a maintenance handler for the given switch
+func NewMaintenanceHandler(maintenance *middleware.Maintenance, logger *slog.Logger) *MaintenanceHandler {
+	return &MaintenanceHandler{maintenance: maintenance, logger: logger}
+}
+
+// GetMaintenance handles GET /api/admin/maintenance
+func (h *MaintenanceHandler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	utils.JSONResponse(w, http.StatusOK, map[string]bool{"enabled": h.maintenance.Enabled()})
+}
+
+// SetMaintenance handles PUT /api/admin/maintenance
+// The change lasts until the next toggle, restart, or reload that changes MAINTENANCE_MODE
+func (h *MaintenanceHandler) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Request body must set enabled to true or false")
+		return
+	}
+
+	h.maintenance.SetEnabled(*req.Enabled)
+	h.logger.Warn("Maintenance mode changed by an administrator",
+		"enabled", *req.Enabled, "user_id", getUserIDFromContext(r))
+	utils.JSONResponse(w, http.StatusOK, map[string]bool{"enabled": *req.Enabled})
+}
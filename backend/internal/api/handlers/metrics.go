@@ -0,0 +1,30 @@
+// Operational metrics HTTP handler
+// Reports request load so operators can see how close the server is to shedding
+package handlers
+
+import (
+	"net/http"
+
+	"conflux/internal/api/middleware"
+	"conflux/pkg/utils"
+)
+
+// MetricsHandler handles operational metrics requests
+type MetricsHandler struct {
+	concurrency *middleware.ConcurrencyLimiter
+}
+
+// NewMetricsHandler creates a metrics handler reporting the limiter's counters
+func NewMetricsHandler(concurrency *middleware.ConcurrencyLimiter) *MetricsHandler {
+	return &MetricsHandler{concurrency: concurrency}
+}
+
+// GetMetrics handles GET /api/admin/metrics
+// max_in_flight is 0 when concurrency isn't limited; shed_total counts since startup
+func (h *MetricsHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	utils.JSONResponse(w, http.StatusOK, map[string]int64{
+		"in_flight":     h.concurrency.InFlight(),
+		"max_in_flight": int64(h.concurrency.Limit()),
+		"shed_total":    h.concurrency.Shed(),
+	})
+}
@@ -4,9 +4,15 @@
 package handlers
 
 import (
+	"encoding/json"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"conflux/internal/api/dto"
+	"conflux/internal/api/middleware"
+	"conflux/internal/models"
 	"conflux/internal/service"
 	"conflux/pkg/utils"
 
@@ -15,13 +21,19 @@ import (
 
 // UserHandler handles user-related HTTP requests
 type UserHandler struct {
-	userService *service.UserService
+	userService        *service.UserService
+	emailChangeService *service.EmailChangeService
+	authService        *service.AuthService
 }
 
-// NewUserHandler creates user handler with service dependency
-func NewUserHandler(userService *service.UserService) *UserHandler {
+// NewUserHandler creates user handler with service dependencies
+func NewUserHandler(
+	userService *service.UserService, emailChangeService *service.EmailChangeService, authService *service.AuthService,
+) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:        userService,
+		emailChangeService: emailChangeService,
+		authService:        authService,
 	}
 }
 
@@ -41,8 +53,232 @@ func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 // UpdateProfile handles user profile updates
 // PUT /users/profile - Updates current user's profile
 func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
-	// Profile update implementation
-	utils.ErrorResponse(w, http.StatusNotImplemented, "Profile update not yet implemented")
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req models.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	user, err := h.userService.GetUserByID(r.Context(), userID)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	// Email changes must be verified through the email-change flow
+	if req.Email != nil && !strings.EqualFold(strings.TrimSpace(*req.Email), user.Email) {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Email cannot be changed here; use POST /api/users/email-change")
+		return
+	}
+	if req.FirstName != nil {
+		user.FirstName = *req.FirstName
+	}
+	if req.LastName != nil {
+		user.LastName = *req.LastName
+	}
+
+	if err := h.userService.UpdateUser(r.Context(), user); err != nil {
+		if strings.Contains(err.Error(), "validation failed") {
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update profile")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, dto.NewUserResponse(user))
+}
+
+// UpdatePreferences handles user preference updates
+// PUT /users/preferences - Replaces current user's preferences
+func (h *UserHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var prefs models.UserPreferences
+	if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	user, err := h.userService.UpdatePreferences(r.Context(), userID, prefs)
+	if err != nil {
+		if strings.Contains(err.Error(), "validation failed") {
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		} else {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update preferences")
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, user.Preferences)
+}
+
+// RequestEmailChange handles email change requests
+// POST /users/email-change - Sends a verification token to the new address
+func (h *UserHandler) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req models.EmailChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.emailChangeService.RequestEmailChange(r.Context(), userID, &req); err != nil {
+		writeEmailChangeError(w, err, "Failed to request email change")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusAccepted, map[string]string{
+		"message": "Verification sent to " + req.NewEmail + "; your current email stays active until it is confirmed",
+	})
+}
+
+// ConfirmEmailChange handles email change confirmation
+// POST /users/email-change/confirm - Applies the pending change for a valid token
+func (h *UserHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req models.ConfirmEmailChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	user, err := h.emailChangeService.ConfirmEmailChange(r.Context(), userID, &req, middleware.ClientIP(r))
+	if err != nil {
+		writeEmailChangeError(w, err, "Failed to confirm email change")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, dto.NewUserResponse(user))
+}
+
+// ChangePassword handles password changes
+// PUT /users/password - Replaces current user's password, clearing any required reset
+// The user's other sessions are signed out and they are notified of the change
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.userService.ChangePassword(r.Context(), userID, &req); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "validation failed"):
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		case strings.Contains(err.Error(), "invalid credentials"):
+			utils.ErrorResponse(w, http.StatusForbidden, "Current password is incorrect")
+		case strings.Contains(err.Error(), "user not found"):
+			utils.ErrorResponse(w, http.StatusNotFound, "User not found")
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to change password")
+		}
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if err := h.authService.CompletePasswordChange(
+		r.Context(), userID, token, middleware.ClientIP(r), r.UserAgent(),
+	); err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Password changed, but signing out other sessions failed")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "Password changed"})
+}
+
+// ImportUsers handles bulk user creation
+// POST /admin/users/import - Creates users from a JSON array or a CSV upload (admin only)
+// Each row reports created, skipped (email taken), or failed; created rows carry
+// the temporary password to hand to the user
+func (h *UserHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	if getUserIDFromContext(r) == 0 {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var rows []models.UserImportRow
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&rows); err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body: expected a JSON array of users")
+			return
+		}
+	case "text/csv":
+		parsed, err := service.ParseUserImportCSV(r.Body)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		rows = parsed
+	default:
+		utils.ErrorResponse(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json or text/csv")
+		return
+	}
+
+	results, err := h.userService.ImportUsers(r.Context(), isAdminFromContext(r), rows)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "unauthorized"):
+			utils.ErrorResponse(w, http.StatusForbidden, "Admin access required")
+		case strings.Contains(err.Error(), "validation failed"):
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to import users")
+		}
+		return
+	}
+
+	counts := map[string]int{models.UserImportCreated: 0, models.UserImportSkipped: 0, models.UserImportFailed: 0}
+	for _, result := range results {
+		counts[result.Status]++
+	}
+	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"results": results,
+		"created": counts[models.UserImportCreated],
+		"skipped": counts[models.UserImportSkipped],
+		"failed":  counts[models.UserImportFailed],
+	})
+}
+
+// writeEmailChangeError maps email change errors to HTTP responses
+func writeEmailChangeError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed"),
+		strings.Contains(err.Error(), "invalid or expired"):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case strings.Contains(err.Error(), "already exists"):
+		utils.ErrorResponse(w, http.StatusConflict, "Email already in use")
+	default:
+		utils.ErrorResponse(w, http.StatusInternalServerError, fallback)
+	}
 }
 
 // GetUser handles user retrieval by ID
@@ -68,5 +304,5 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.JSONResponse(w, http.StatusOK, user)
+	utils.JSONResponse(w, http.StatusOK, dto.NewUserResponse(user))
 }
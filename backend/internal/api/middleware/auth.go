@@ -1,13 +1,15 @@
-// JWT authentication middleware
-// Validates JWT tokens and extracts user information from requests
+// JWT and API key authentication middleware
+// Validates JWT tokens or API keys and extracts user information from requests
 // Protects API endpoints requiring authentication
 package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
+	"conflux/internal/models"
 	"conflux/pkg/jwt"
 	"conflux/pkg/utils"
 )
@@ -15,18 +17,92 @@ import (
 // UserContextKey is the key for storing user in context
 type UserContextKey string
 
+// UserKey holds the authenticated request's *jwt.Claims; read it with Claims or UserID
 const UserKey UserContextKey = "user"
 
-// AuthMiddleware validates JWT tokens from Authorization header
+// Claims returns the token claims Auth stored for the request
+func Claims(r *http.Request) (*jwt.Claims, bool) {
+	claims, ok := r.Context().Value(UserKey).(*jwt.Claims)
+	return claims, ok && claims != nil
+}
+
+// UserID returns the authenticated user's ID, or 0 when the request isn't authenticated
+func UserID(r *http.Request) int {
+	if claims, ok := Claims(r); ok {
+		return claims.UserID
+	}
+	return 0
+}
+
+// SessionValidator checks a token and the server-side session it belongs to
+// Implemented by service.AuthService
+type SessionValidator interface {
+	ValidateToken(ctx context.Context, token string) (*models.User, error)
+}
+
+// APIKeyAuthenticator resolves a plaintext API key to the user that owns it
+// Implemented by service.APIKeyService
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, secret string) (*models.User, error)
+}
+
+// apiKeyHeader carries an API key for scripts and CI, instead of a bearer JWT
+const apiKeyHeader = "X-API-Key"
+
+// Auth authenticates requests by the JWT in the Authorization header, or by an API key
+// Build it with the token manager AuthService signs tokens with
+type Auth struct {
+	tokenManager *jwt.TokenManager
+	sessions     SessionValidator
+	apiKeys      APIKeyAuthenticator // nil disables API key authentication
+}
+
+// NewAuth creates the authentication middleware
+// sessions rejects tokens whose session was logged out, revoked, or expired
+func NewAuth(tokenManager *jwt.TokenManager, sessions SessionValidator, apiKeys APIKeyAuthenticator) *Auth {
+	return &Auth{tokenManager: tokenManager, sessions: sessions, apiKeys: apiKeys}
+}
+
+// authenticateAPIKey returns claims for the owner of an active API key
+// The claims carry the user's current role, so RequireAdmin works for keys too
+func (a *Auth) authenticateAPIKey(r *http.Request, secret string) (*jwt.Claims, error) {
+	if a.apiKeys == nil {
+		return nil, errors.New("API keys are not accepted")
+	}
+	user, err := a.apiKeys.Authenticate(r.Context(), secret)
+	if err != nil {
+		return nil, err
+	}
+	return &jwt.Claims{UserID: user.ID, Email: user.Email, Role: user.Role}, nil
+}
+
+// authenticate returns the claims of a token with a valid signature and an active session
+func (a *Auth) authenticate(r *http.Request, token string) (*jwt.Claims, error) {
+	claims, err := a.tokenManager.ValidateToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := a.sessions.ValidateToken(r.Context(), token); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// Middleware validates JWT tokens from Authorization header and checks their session
+// A request with an X-API-Key header is authenticated by that key instead
 // Extracts user information and adds to request context
-// Returns 401 Unauthorized for invalid or missing tokens
-func AuthMiddleware(next http.Handler) http.Handler {
+// Returns 401 Unauthorized for invalid or missing tokens and ended sessions
+func (a *Auth) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// JWT validation implementation:
-		// - Extract token from Authorization header
-		// - Validate JWT signature and expiration
-		// - Extract user claims and add to context
-		// - Call next handler or return 401
+		if apiKey := r.Header.Get(apiKeyHeader); apiKey != "" {
+			claims, err := a.authenticateAPIKey(r, apiKey)
+			if err != nil {
+				utils.ErrorResponse(w, http.StatusUnauthorized, "Invalid API key")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), UserKey, claims)))
+			return
+		}
 
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -43,8 +119,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		token := authHeader[7:] // Remove "Bearer " prefix
 
 		// Validate token
-		tokenManager := jwt.NewTokenManager("default-secret", "conflux") // Should come from config
-		claims, err := tokenManager.ValidateToken(token)
+		claims, err := a.authenticate(r, token)
 		if err != nil {
 			utils.ErrorResponse(w, http.StatusUnauthorized, "Invalid token")
 			return
@@ -56,16 +131,22 @@ func AuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// OptionalAuthMiddleware validates tokens when present
+// Optional validates tokens when present
 // Used for endpoints that work with or without authentication
-func OptionalAuthMiddleware(next http.Handler) http.Handler {
+func (a *Auth) Optional(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Optional authentication implementation
+		if apiKey := r.Header.Get(apiKeyHeader); apiKey != "" {
+			if claims, err := a.authenticateAPIKey(r, apiKey); err == nil {
+				r = r.WithContext(context.WithValue(r.Context(), UserKey, claims))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		authHeader := r.Header.Get("Authorization")
 		if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
 			token := authHeader[7:]
-			tokenManager := jwt.NewTokenManager("default-secret", "conflux")
-			if claims, err := tokenManager.ValidateToken(token); err == nil {
+			if claims, err := a.authenticate(r, token); err == nil {
 				ctx := context.WithValue(r.Context(), UserKey, claims)
 				r = r.WithContext(ctx)
 			}
@@ -73,3 +154,16 @@ func OptionalAuthMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// RequireAdmin rejects requests whose token doesn't carry the admin role
+// Must run after Auth; returns 403 Forbidden for non-admins
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := Claims(r)
+		if !ok || claims.Role != models.RoleAdmin {
+			utils.ErrorResponse(w, http.StatusForbidden, "Admin access required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
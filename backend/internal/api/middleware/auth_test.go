@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+	"conflux/pkg/jwt"
+)
+
+// fakeSessions reports every token except the revoked ones as an active session
+type fakeSessions struct {
+	revoked map[string]bool
+}
+
+func (f *fakeSessions) ValidateToken(ctx context.Context, token string) (*models.User, error) {
+	if f.revoked[token] {
+		return nil, errors.New("session not found or expired")
+	}
+	return &models.User{}, nil
+}
+
+func TestAuth_SetsUserID(t *testing.T) {
+	tokenManager := jwt.NewTokenManager("test-secret", "conflux")
+	token, err := tokenManager.GenerateToken(42, "user@example.com", time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	var gotUserID int
+	handler := NewAuth(tokenManager, &fakeSessions{}, nil).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserID = UserID(r)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/configs", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if gotUserID != 42 {
+		t.Errorf("UserID() in the downstream handler = %d, want 42", gotUserID)
+	}
+}
+
+func TestAuth_RejectsMissingOrInvalidToken(t *testing.T) {
+	tokenManager := jwt.NewTokenManager("test-secret", "conflux")
+	revoked, err := tokenManager.GenerateToken(42, "user@example.com", time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	auth := NewAuth(tokenManager, &fakeSessions{revoked: map[string]bool{revoked: true}}, nil)
+	handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("downstream handler called for an unauthenticated request")
+	}))
+
+	tests := map[string]string{
+		"missing":         "",
+		"not bearer":      "Basic abc",
+		"invalid":         "Bearer not-a-jwt",
+		"revoked session": "Bearer " + revoked,
+	}
+	for name, header := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/configs", nil)
+			if header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+
+	// Optional lets the request through, unauthenticated
+	gotUserID := -1
+	optional := auth.Optional(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserID = UserID(r)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+revoked)
+	optional.ServeHTTP(httptest.NewRecorder(), req)
+	if gotUserID != 0 {
+		t.Errorf("UserID() behind Optional with a revoked session = %d, want 0", gotUserID)
+	}
+}
+
+func TestUserID_WithoutMiddleware(t *testing.T) {
+	if got := UserID(httptest.NewRequest(http.MethodGet, "/", nil)); got != 0 {
+		t.Errorf("UserID() = %d, want 0", got)
+	}
+}
+
+// fakeAPIKeys accepts one key, owned by an admin
+type fakeAPIKeys struct {
+	secret string
+}
+
+func (f *fakeAPIKeys) Authenticate(ctx context.Context, secret string) (*models.User, error) {
+	if secret != f.secret {
+		return nil, errors.New("invalid API key")
+	}
+	return &models.User{ID: 7, Email: "ci@example.com", Role: models.RoleAdmin}, nil
+}
+
+func TestAuth_APIKey(t *testing.T) {
+	tokenManager := jwt.NewTokenManager("test-secret", "conflux")
+	var gotUserID int
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserID = UserID(r)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	serve := func(auth *Auth, key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/metrics", nil)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		auth.Middleware(RequireAdmin(ok)).ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	auth := NewAuth(tokenManager, &fakeSessions{}, &fakeAPIKeys{secret: "cfx_valid"})
+	if status := serve(auth, "cfx_valid"); status != http.StatusNoContent || gotUserID != 7 {
+		t.Errorf("status, user ID = %d, %d, want %d, 7", status, gotUserID, http.StatusNoContent)
+	}
+	if status := serve(auth, "cfx_revoked"); status != http.StatusUnauthorized {
+		t.Errorf("unknown key status = %d, want %d", status, http.StatusUnauthorized)
+	}
+	if status := serve(NewAuth(tokenManager, &fakeSessions{}, nil), "cfx_valid"); status != http.StatusUnauthorized {
+		t.Errorf("status without API key support = %d, want %d", status, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxBodyBytes(t *testing.T) {
+	const limit = 16
+
+	tests := []struct {
+		name          string
+		body          string
+		chunked       bool
+		wantStatus    int
+		wantForwarded bool
+	}{
+		{name: "under limit", body: "small", wantStatus: http.StatusOK, wantForwarded: true},
+		{name: "exactly at limit", body: strings.Repeat("a", limit), wantStatus: http.StatusOK, wantForwarded: true},
+		{name: "over limit", body: strings.Repeat("a", limit+1), wantStatus: http.StatusRequestEntityTooLarge},
+		{
+			name: "over limit without content length", body: strings.Repeat("a", limit*4), chunked: true,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{name: "empty body", body: "", wantStatus: http.StatusOK, wantForwarded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received string
+			forwarded := false
+			handler := MaxBodyBytes(limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				forwarded = true
+				data, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Errorf("handler failed to read body: %v", err)
+				}
+				received = string(data)
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if forwarded != tt.wantForwarded {
+				t.Errorf("forwarded = %v, want %v", forwarded, tt.wantForwarded)
+			}
+			if tt.wantForwarded && received != tt.body {
+				t.Errorf("handler received %q, want %q", received, tt.body)
+			}
+		})
+	}
+}
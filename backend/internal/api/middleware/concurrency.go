@@ -0,0 +1,81 @@
+// Concurrency limiting middleware
+// Caps simultaneous in-flight requests and sheds the excess with 503, so extreme
+// load is refused up front instead of exhausting the database pool or memory
+package middleware
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"conflux/pkg/utils"
+)
+
+// loadShedRetryAfter is the Retry-After hint, in seconds, sent with shed requests
+// In-flight requests finish quickly, so clients are told to come back soon
+const loadShedRetryAfter = "1"
+
+// concurrencyExemptPaths are never shed, so probes keep passing while the server is busy
+var concurrencyExemptPaths = map[string]bool{
+	"/api/health":      true,
+	"/api/health/live": true,
+}
+
+// ConcurrencyLimiter bounds how many requests are handled at once
+// The in-flight count is tracked even without a limit, for metrics
+type ConcurrencyLimiter struct {
+	slots    chan struct{} // nil when unlimited
+	inFlight atomic.Int64
+	shed     atomic.Int64
+}
+
+// NewConcurrencyLimiter creates a limiter admitting up to max concurrent requests
+// A max of 0 or less admits every request
+func NewConcurrencyLimiter(max int) *ConcurrencyLimiter {
+	l := &ConcurrencyLimiter{}
+	if max > 0 {
+		l.slots = make(chan struct{}, max)
+	}
+	return l
+}
+
+// Limit returns the most requests handled at once, or 0 when unlimited
+func (l *ConcurrencyLimiter) Limit() int {
+	return cap(l.slots)
+}
+
+// InFlight returns how many requests are being handled right now
+func (l *ConcurrencyLimiter) InFlight() int64 {
+	return l.inFlight.Load()
+}
+
+// Shed returns how many requests have been refused since startup
+func (l *ConcurrencyLimiter) Shed() int64 {
+	return l.shed.Load()
+}
+
+// Middleware refuses requests with 503 and Retry-After while every slot is taken
+// Requests never wait for a slot; queueing would only add latency under overload
+func (l *ConcurrencyLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if concurrencyExemptPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if l.slots != nil {
+			select {
+			case l.slots <- struct{}{}:
+				defer func() { <-l.slots }()
+			default:
+				l.shed.Add(1)
+				w.Header().Set("Retry-After", loadShedRetryAfter)
+				utils.ErrorResponse(w, http.StatusServiceUnavailable, "Server is busy, try again shortly")
+				return
+			}
+		}
+
+		l.inFlight.Add(1)
+		defer l.inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestConcurrencyLimiter_ShedsBeyondLimit(t *testing.T) {
+	limiter := NewConcurrencyLimiter(2)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/slow" {
+			entered <- struct{}{}
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	// Fill both slots with requests that block until released
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if w := serve("/api/slow"); w.Code != http.StatusOK {
+				t.Errorf("admitted request: status = %d, want 200", w.Code)
+			}
+		}()
+		<-entered
+	}
+	if got := limiter.InFlight(); got != 2 {
+		t.Errorf("InFlight() = %d, want 2", got)
+	}
+
+	w := serve("/api/formats")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("request beyond the cap: status = %d, want 503", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Errorf("Retry-After = %q, want 1", w.Header().Get("Retry-After"))
+	}
+	if limiter.Shed() != 1 {
+		t.Errorf("Shed() = %d, want 1", limiter.Shed())
+	}
+
+	// Probes are never shed
+	for _, path := range []string{"/api/health", "/api/health/live"} {
+		if w := serve(path); w.Code != http.StatusOK {
+			t.Errorf("%s while saturated: status = %d, want 200", path, w.Code)
+		}
+	}
+
+	close(release)
+	wg.Wait()
+	if w := serve("/api/formats"); w.Code != http.StatusOK {
+		t.Errorf("after release: status = %d, want 200", w.Code)
+	}
+	if got := limiter.InFlight(); got != 0 {
+		t.Errorf("InFlight() after release = %d, want 0", got)
+	}
+}
+
+func TestConcurrencyLimiter_Unlimited(t *testing.T) {
+	limiter := NewConcurrencyLimiter(0)
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := limiter.InFlight(); got != 1 {
+			t.Errorf("InFlight() inside handler = %d, want 1", got)
+		}
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/formats", nil))
+	if w.Code != http.StatusOK || limiter.Limit() != 0 {
+		t.Errorf("status = %d, limit = %d; want 200 and no limit", w.Code, limiter.Limit())
+	}
+}
@@ -0,0 +1,41 @@
+// Content type enforcement middleware
+// Rejects request bodies that aren't JSON before handlers try to decode them
+// Applied to JSON route groups; upload routes register without it
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"conflux/pkg/utils"
+)
+
+// RequireJSON rejects POST, PUT, and PATCH requests whose body isn't declared as
+// application/json with 415 Unsupported Media Type
+// JSON-based types with a +json suffix, such as application/merge-patch+json, are
+// accepted too. Requests without a body, such as a bare logout, are let through
+func RequireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.ContentLength == 0 || r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		isJSON := mediaType == "application/json" ||
+			(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+		if err != nil || !isJSON {
+			utils.ErrorResponse(w, http.StatusUnsupportedMediaType,
+				"Content-Type must be application/json")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
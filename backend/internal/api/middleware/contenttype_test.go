@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequireJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{name: "json", method: http.MethodPost, contentType: "application/json", body: `{}`, wantStatus: http.StatusOK},
+		{name: "json with charset", method: http.MethodPut, contentType: "application/json; charset=utf-8", body: `{}`, wantStatus: http.StatusOK},
+		{name: "mixed case", method: http.MethodPatch, contentType: "Application/JSON", body: `{}`, wantStatus: http.StatusOK},
+		{name: "json suffix", method: http.MethodPatch, contentType: "application/merge-patch+json", body: `{}`, wantStatus: http.StatusOK},
+		{name: "form encoded", method: http.MethodPost, contentType: "application/x-www-form-urlencoded", body: "a=b", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "plain text", method: http.MethodPut, contentType: "text/plain", body: `{}`, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "missing", method: http.MethodPost, body: `{}`, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "malformed", method: http.MethodPost, contentType: "application/json;;", body: `{}`, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "no body", method: http.MethodPost, wantStatus: http.StatusOK},
+		{name: "get ignores content type", method: http.MethodGet, contentType: "text/plain", body: "x", wantStatus: http.StatusOK},
+		{name: "delete ignores content type", method: http.MethodDelete, contentType: "text/plain", body: "x", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequireJSON(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(tt.method, "/api/users/profile", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusUnsupportedMediaType && !strings.Contains(w.Body.String(), "application/json") {
+				t.Errorf("body = %s, want a message naming application/json", w.Body)
+			}
+		})
+	}
+}
@@ -4,7 +4,7 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 )
@@ -26,22 +26,36 @@ func (rw *responseWriter) WriteHeader(code int) {
 
 // Logging middleware logs HTTP requests and responses
 // Records request method, URL, status code, and duration
-// Essential for monitoring and debugging API usage
-func Logging(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		// Logging implementation:
-		// - Log incoming request details
-		// - Wrap response writer to capture status code
-		// - Call next handler
-		// - Log response details and duration
-
-		wrapped := newResponseWriter(w)
-
-		next.ServeHTTP(wrapped, r)
-
-		duration := time.Since(start)
-		log.Printf("%s %s - %d - %v", r.Method, r.URL.Path, wrapped.statusCode, duration)
-	})
+// Server errors are logged at error level, client errors at warn, the rest at info
+func Logging(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			// Logging implementation:
+			// - Wrap response writer to capture status code
+			// - Call next handler
+			// - Log response details and duration at a level matching the status
+
+			wrapped := newResponseWriter(w)
+
+			next.ServeHTTP(wrapped, r)
+
+			level := slog.LevelInfo
+			switch {
+			case wrapped.statusCode >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case wrapped.statusCode >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+
+			logger.LogAttrs(r.Context(), level, "HTTP request",
+				slog.String("ip", ClientIP(r)),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", wrapped.statusCode),
+				slog.Duration("duration", time.Since(start)),
+			)
+		})
+	}
 }
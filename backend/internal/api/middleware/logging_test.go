@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogging_LevelByStatus(t *testing.T) {
+	tests := []struct {
+		status    int
+		wantLevel string
+	}{
+		{status: http.StatusOK, wantLevel: "level=INFO"},
+		{status: http.StatusNotFound, wantLevel: "level=WARN"},
+		{status: http.StatusInternalServerError, wantLevel: "level=ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+			handler := Logging(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			line := buf.String()
+			for _, want := range []string{tt.wantLevel, "method=GET", "path=/api/health", "ip=192.0.2.1"} {
+				if !strings.Contains(line, want) {
+					t.Errorf("log line %q missing %q", line, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLogging_RespectsLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	handler := Logging(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if buf.Len() != 0 {
+		t.Errorf("successful request logged at warn level: %q", buf.String())
+	}
+}
@@ -0,0 +1,71 @@
+// Read-only maintenance mode middleware
+// While enabled, mutating requests are refused with 503 so reads keep working
+// during schema changes and incidents
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"conflux/pkg/utils"
+)
+
+// maintenanceExemptPaths accept writes even in maintenance mode: signing in and out
+// and verifying email keep users able to reach the read-only API, and the admin
+// toggle must stay usable or maintenance mode could never be switched off
+var maintenanceExemptPaths = map[string]bool{
+	"/api/auth/login":        true,
+	"/api/auth/logout":       true,
+	"/api/auth/verify-email": true,
+	"/api/admin/maintenance": true,
+}
+
+// Maintenance switches the API between normal and read-only operation
+// The mode can be flipped at runtime; requests in flight are unaffected
+type Maintenance struct {
+	enabled    atomic.Bool
+	retryAfter time.Duration
+}
+
+// NewMaintenance creates the maintenance switch
+// retryAfter is the Retry-After hint sent with refused writes
+func NewMaintenance(enabled bool, retryAfter time.Duration) *Maintenance {
+	m := &Maintenance{retryAfter: retryAfter}
+	m.enabled.Store(enabled)
+	return m
+}
+
+// Enabled reports whether writes are currently refused
+// A nil Maintenance is never enabled
+func (m *Maintenance) Enabled() bool {
+	return m != nil && m.enabled.Load()
+}
+
+// SetEnabled turns maintenance mode on or off
+func (m *Maintenance) SetEnabled(enabled bool) {
+	m.enabled.Store(enabled)
+}
+
+// Middleware refuses POST, PUT, PATCH, and DELETE requests with 503 and a
+// Retry-After header while maintenance mode is on; other methods pass through
+func (m *Maintenance) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.Enabled() || maintenanceExemptPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			seconds := int(math.Ceil(m.retryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+			utils.ErrorResponse(w, http.StatusServiceUnavailable,
+				"The service is in read-only maintenance mode, try again later")
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMaintenance_Middleware(t *testing.T) {
+	maintenance := NewMaintenance(false, 90*time.Second)
+	handler := maintenance.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	if w := serve(http.MethodPost, "/api/keys/rotate"); w.Code != http.StatusOK {
+		t.Fatalf("write while off: status = %d, want 200", w.Code)
+	}
+
+	maintenance.SetEnabled(true)
+	tests := []struct {
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{http.MethodGet, "/api/users/profile", http.StatusOK},
+		{http.MethodHead, "/api/users/profile", http.StatusOK},
+		{http.MethodPost, "/api/keys/rotate", http.StatusServiceUnavailable},
+		{http.MethodPut, "/api/users/profile", http.StatusServiceUnavailable},
+		{http.MethodPatch, "/api/users/profile", http.StatusServiceUnavailable},
+		{http.MethodDelete, "/api/me/sessions/abc", http.StatusServiceUnavailable},
+		{http.MethodPost, "/api/auth/login", http.StatusOK},
+		{http.MethodPost, "/api/auth/verify-email", http.StatusOK},
+		{http.MethodPut, "/api/admin/maintenance", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := serve(tt.method, tt.path)
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, w.Code, tt.wantStatus)
+		}
+		if tt.wantStatus == http.StatusServiceUnavailable && w.Header().Get("Retry-After") != "90" {
+			t.Errorf("%s %s: Retry-After = %q, want 90", tt.method, tt.path, w.Header().Get("Retry-After"))
+		}
+	}
+
+	maintenance.SetEnabled(false)
+	if w := serve(http.MethodDelete, "/api/me/sessions/abc"); w.Code != http.StatusOK {
+		t.Errorf("write after switching off: status = %d, want 200", w.Code)
+	}
+}
+
+func TestMaintenance_NilIsDisabled(t *testing.T) {
+	var maintenance *Maintenance
+	if maintenance.Enabled() {
+		t.Error("nil Maintenance reports enabled")
+	}
+}
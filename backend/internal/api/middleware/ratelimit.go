@@ -0,0 +1,155 @@
+// Rate limiting middleware
+// Throttles clients with a per-client token bucket
+// Emits X-RateLimit-* headers so well-behaved clients can self-throttle
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"conflux/pkg/utils"
+)
+
+// Rate limit response headers
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+)
+
+// bucketIdleTTL is how long an untouched bucket is kept before eviction
+const bucketIdleTTL = 10 * time.Minute
+
+// tokenBucket tracks the available tokens for a single client
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// RateLimiter enforces a per-client request rate using token buckets
+// Each client may burst up to `burst` requests, refilled at `rate` tokens per second
+type RateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	rate      float64
+	burst     int
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// rateLimitState is the bucket state reported to the client after a request
+type rateLimitState struct {
+	allowed    bool
+	limit      int
+	remaining  int
+	reset      time.Time
+	retryAfter int // Seconds until the next token is available
+}
+
+// NewRateLimiter creates a rate limiter allowing requestsPerMinute sustained
+// requests per client with bursts of up to burst requests
+func NewRateLimiter(requestsPerMinute, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		buckets: make(map[string]*tokenBucket),
+		rate:    float64(requestsPerMinute) / 60.0,
+		burst:   burst,
+		now:     time.Now,
+	}
+}
+
+// SetLimits changes the sustained rate and burst size at runtime
+// Existing buckets keep their tokens, capped at the new burst on their next request
+func (rl *RateLimiter) SetLimits(requestsPerMinute, burst int) {
+	if burst < 1 {
+		burst = 1
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.rate = float64(requestsPerMinute) / 60.0
+	rl.burst = burst
+}
+
+// Middleware rejects requests exceeding the client's rate with 429 Too Many Requests
+// Rate limit headers are set on both allowed and rejected responses
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := rl.take(ClientIP(r))
+
+		w.Header().Set(HeaderRateLimitLimit, strconv.Itoa(state.limit))
+		w.Header().Set(HeaderRateLimitRemaining, strconv.Itoa(state.remaining))
+		w.Header().Set(HeaderRateLimitReset, strconv.FormatInt(state.reset.Unix(), 10))
+
+		if !state.allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(state.retryAfter))
+			utils.ErrorResponse(w, http.StatusTooManyRequests, "Rate limit exceeded")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// take consumes a token from the client's bucket and reports the resulting state
+func (rl *RateLimiter) take(key string) rateLimitState {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	rl.sweep(now)
+
+	bucket, ok := rl.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(rl.burst), lastSeen: now}
+		rl.buckets[key] = bucket
+	}
+
+	// Refill based on elapsed time since the last request
+	elapsed := now.Sub(bucket.lastSeen).Seconds()
+	bucket.tokens = math.Min(float64(rl.burst), bucket.tokens+elapsed*rl.rate)
+	bucket.lastSeen = now
+
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	}
+
+	return rateLimitState{
+		allowed:    allowed,
+		limit:      rl.burst,
+		remaining:  int(math.Floor(bucket.tokens)),
+		reset:      now.Add(rl.timeUntil(bucket.tokens, float64(rl.burst))),
+		retryAfter: int(math.Ceil(rl.timeUntil(bucket.tokens, 1).Seconds())),
+	}
+}
+
+// timeUntil returns how long the bucket needs to refill from tokens to target
+func (rl *RateLimiter) timeUntil(tokens, target float64) time.Duration {
+	if tokens >= target {
+		return 0
+	}
+	if rl.rate <= 0 {
+		return time.Minute
+	}
+	return time.Duration((target - tokens) / rl.rate * float64(time.Second))
+}
+
+// sweep evicts idle buckets so the map doesn't grow without bound
+func (rl *RateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.lastSweep) < time.Minute {
+		return
+	}
+	rl.lastSweep = now
+
+	for key, bucket := range rl.buckets {
+		if now.Sub(bucket.lastSeen) > bucketIdleTTL {
+			delete(rl.buckets, key)
+		}
+	}
+}
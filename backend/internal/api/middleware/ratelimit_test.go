@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_HeadersDecrement(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(60, 3)
+	limiter.now = func() time.Time { return now }
+
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name          string
+		wantStatus    int
+		wantRemaining int
+	}{
+		{name: "first request", wantStatus: http.StatusOK, wantRemaining: 2},
+		{name: "second request", wantStatus: http.StatusOK, wantRemaining: 1},
+		{name: "third request", wantStatus: http.StatusOK, wantRemaining: 0},
+		{name: "over limit", wantStatus: http.StatusTooManyRequests, wantRemaining: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get(HeaderRateLimitLimit); got != "3" {
+				t.Errorf("%s = %q, want %q", HeaderRateLimitLimit, got, "3")
+			}
+			if got := w.Header().Get(HeaderRateLimitRemaining); got != strconv.Itoa(tt.wantRemaining) {
+				t.Errorf("%s = %q, want %d", HeaderRateLimitRemaining, got, tt.wantRemaining)
+			}
+
+			reset, err := strconv.ParseInt(w.Header().Get(HeaderRateLimitReset), 10, 64)
+			if err != nil {
+				t.Fatalf("invalid %s header: %v", HeaderRateLimitReset, err)
+			}
+			if reset < now.Unix() {
+				t.Errorf("%s = %d, want >= %d", HeaderRateLimitReset, reset, now.Unix())
+			}
+
+			if tt.wantStatus == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+				t.Error("expected Retry-After header on 429 response")
+			}
+		})
+	}
+}
+
+func TestRateLimiter_Refill(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(60, 1)
+	limiter.now = func() time.Time { return now }
+
+	if state := limiter.take("client"); !state.allowed {
+		t.Fatal("first request should be allowed")
+	}
+	if state := limiter.take("client"); state.allowed {
+		t.Fatal("second request should be rejected")
+	}
+
+	// One token per second at 60 requests per minute
+	now = now.Add(time.Second)
+	if state := limiter.take("client"); !state.allowed {
+		t.Error("request after refill should be allowed")
+	}
+}
+
+func TestRateLimiter_PerClient(t *testing.T) {
+	limiter := NewRateLimiter(60, 1)
+
+	if state := limiter.take("client-a"); !state.allowed {
+		t.Fatal("client-a should be allowed")
+	}
+	if state := limiter.take("client-b"); !state.allowed {
+		t.Error("client-b should have its own bucket")
+	}
+}
+
+func TestRateLimiter_SetLimits(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(60, 5)
+	limiter.now = func() time.Time { return now }
+
+	if state := limiter.take("client"); state.remaining != 4 || state.limit != 5 {
+		t.Fatalf("before reload: remaining %d limit %d, want 4/5", state.remaining, state.limit)
+	}
+
+	// Shrinking the burst caps existing buckets on their next request
+	limiter.SetLimits(60, 2)
+	state := limiter.take("client")
+	if state.limit != 2 || state.remaining != 1 {
+		t.Errorf("after reload: remaining %d limit %d, want 1/2", state.remaining, state.limit)
+	}
+}
@@ -0,0 +1,124 @@
+// Client IP resolution middleware
+// Resolves the real client address behind trusted reverse proxies
+// Stores the result in the request context for logging, rate limiting, and auditing
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// contextKey is the type for values this package stores in request contexts
+type contextKey string
+
+const clientIPKey contextKey = "client_ip"
+
+// RealIP resolves client IPs, honoring forwarding headers only from trusted proxies
+type RealIP struct {
+	trusted []*net.IPNet
+}
+
+// NewRealIP creates a resolver trusting the given proxy CIDRs
+// Bare IP addresses are accepted and treated as single-host networks
+func NewRealIP(trustedProxies []string) (*RealIP, error) {
+	ri := &RealIP{}
+	for _, entry := range trustedProxies {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy: %s", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			ri.trusted = append(ri.trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy: %s", entry)
+		}
+		ri.trusted = append(ri.trusted, network)
+	}
+	return ri, nil
+}
+
+// Middleware stores the resolved client IP in the request context
+func (ri *RealIP) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), clientIPKey, ri.resolve(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// resolve returns the client IP for a request
+// X-Forwarded-For is walked right to left, skipping trusted proxies, so a
+// client cannot spoof its address by prepending entries to the header
+func (ri *RealIP) resolve(r *http.Request) string {
+	peer := remoteHost(r)
+	if !ri.isTrusted(peer) {
+		return peer
+	}
+
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		hops := strings.Split(forwarded, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			if i == 0 || !ri.isTrusted(hop) {
+				return hop
+			}
+		}
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+
+	return peer
+}
+
+// isTrusted reports whether ip belongs to a trusted proxy network
+func (ri *RealIP) isTrusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range ri.trusted {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the client IP resolved by RealIP
+// Falls back to the immediate peer when the middleware hasn't run
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey).(string); ok {
+		return ip
+	}
+	return remoteHost(r)
+}
+
+// remoteHost returns the host part of the request's remote address
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
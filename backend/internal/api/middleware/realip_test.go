@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP_Resolve(t *testing.T) {
+	realIP, err := NewRealIP([]string{"10.0.0.0/8", "192.0.2.10"})
+	if err != nil {
+		t.Fatalf("NewRealIP() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIPHdr  string
+		want       string
+	}{
+		{
+			name:       "untrusted peer ignores forwarded header",
+			remoteAddr: "203.0.113.5:4000",
+			forwarded:  "198.51.100.1",
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "untrusted peer ignores real ip header",
+			remoteAddr: "203.0.113.5:4000",
+			realIPHdr:  "198.51.100.1",
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "trusted peer uses forwarded client",
+			remoteAddr: "10.1.2.3:4000",
+			forwarded:  "198.51.100.1",
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "trusted single-host proxy",
+			remoteAddr: "192.0.2.10:4000",
+			forwarded:  "198.51.100.1",
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "spoofed leftmost entry is skipped",
+			remoteAddr: "10.1.2.3:4000",
+			forwarded:  "1.2.3.4, 198.51.100.1, 10.0.0.7",
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "all hops trusted falls back to leftmost",
+			remoteAddr: "10.1.2.3:4000",
+			forwarded:  "10.0.0.9, 10.0.0.7",
+			want:       "10.0.0.9",
+		},
+		{
+			name:       "trusted peer uses real ip header",
+			remoteAddr: "10.1.2.3:4000",
+			realIPHdr:  "198.51.100.2",
+			want:       "198.51.100.2",
+		},
+		{
+			name:       "trusted peer without headers",
+			remoteAddr: "10.1.2.3:4000",
+			want:       "10.1.2.3",
+		},
+		{
+			name:       "trusted peer with garbage header",
+			remoteAddr: "10.1.2.3:4000",
+			forwarded:  "not-an-ip",
+			want:       "10.1.2.3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIPHdr != "" {
+				req.Header.Set("X-Real-IP", tt.realIPHdr)
+			}
+
+			var got string
+			handler := realIP.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ClientIP(r)
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRealIP_InvalidProxy(t *testing.T) {
+	for _, entry := range []string{"not-a-cidr", "10.0.0.0/99"} {
+		if _, err := NewRealIP([]string{entry}); err == nil {
+			t.Errorf("NewRealIP(%q) expected error", entry)
+		}
+	}
+}
+
+func TestClientIP_WithoutMiddleware(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	if got := ClientIP(req); got != "192.0.2.1" {
+		t.Errorf("ClientIP() = %q, want %q", got, "192.0.2.1")
+	}
+}
@@ -4,31 +4,34 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
+	"runtime/debug"
 )
 
 // Recovery middleware catches panics and returns 500 Internal Server Error
 // Logs panic details for debugging while preventing server crashes
 // Essential for production stability and error handling
-func Recovery(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				// Recovery implementation:
-				// - Log panic details and stack trace
-				// - Return 500 Internal Server Error
-				// - Prevent response from being written multiple times
+func Recovery(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if err := recover(); err != nil {
+					// Recovery implementation:
+					// - Log panic details and stack trace
+					// - Return 500 Internal Server Error
+					// - Prevent response from being written multiple times
 
-				log.Printf("Panic: %v", err)
+					logger.Error("Panic", "error", err, "path", r.URL.Path, "stack", string(debug.Stack()))
 
-				// Check if headers have already been written
-				if w.Header().Get("Content-Type") == "" {
-					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					// Check if headers have already been written
+					if w.Header().Get("Content-Type") == "" {
+						http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					}
 				}
-			}
-		}()
+			}()
 
-		next.ServeHTTP(w, r)
-	})
+			next.ServeHTTP(w, r)
+		})
+	}
 }
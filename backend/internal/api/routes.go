@@ -4,7 +4,9 @@
 package api
 
 import (
+	"log/slog"
 	"net/http"
+	"strings"
 
 	"conflux/internal/api/handlers"
 	"conflux/internal/api/middleware"
@@ -12,6 +14,12 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// authMaxBodyBytes caps auth request bodies, which only carry credentials
+const authMaxBodyBytes int64 = 16 << 10
+
+// userImportMaxBodyBytes caps a bulk user import, JSON or CSV
+const userImportMaxBodyBytes int64 = 256 << 10
+
 // SetupRoutes configures all HTTP routes and middleware
 // Returns configured router ready for HTTP server
 func SetupRoutes(
@@ -19,39 +27,197 @@ func SetupRoutes(
 	authHandler *handlers.AuthHandler,
 	healthHandler *handlers.HealthHandler,
 	devHandler *handlers.DevHandler,
+	formatHandler *handlers.FormatHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
+	activityHandler *handlers.ActivityHandler,
+	maintenanceHandler *handlers.MaintenanceHandler,
+	metricsHandler *handlers.MetricsHandler,
+	configHandler *handlers.ConfigHandler,
+	importTokenHandler *handlers.ImportTokenHandler,
+	authMiddleware *middleware.Auth,
+	realIP *middleware.RealIP,
+	rateLimiter *middleware.RateLimiter,
+	maintenance *middleware.Maintenance,
+	concurrency *middleware.ConcurrencyLimiter,
+	maxBodyBytes int64,
+	logger *slog.Logger,
 ) *mux.Router {
 	router := mux.NewRouter()
 
+	// JSON error bodies for unmatched routes
+	router.NotFoundHandler = unmatchedRouteHandler(router)
+	router.MethodNotAllowedHandler = unmatchedRouteHandler(router)
+
 	// Global middleware chain
-	router.Use(middleware.Logging)
-	router.Use(middleware.Recovery)
+	router.Use(realIP.Middleware)
+	router.Use(middleware.Logging(logger))
+	router.Use(middleware.Recovery(logger))
+	router.Use(concurrency.Middleware)
+	router.Use(maintenance.Middleware)
 
 	// API routes
 	api := router.PathPrefix("/api").Subrouter()
+	api.Use(rateLimiter.Middleware)
 
-	// Health check endpoint
+	// Health check endpoints: readiness checks the database, liveness only the process
 	api.HandleFunc("/health", healthHandler.CheckHealth).Methods("GET")
+	api.HandleFunc("/health/live", healthHandler.CheckLiveness).Methods("GET")
+
+	// Format capabilities endpoint
+	api.HandleFunc("/formats", formatHandler.GetFormats).Methods("GET")
 
 	// Public routes (no authentication required)
 	auth := api.PathPrefix("/auth").Subrouter()
+	auth.Use(middleware.MaxBodyBytes(authMaxBodyBytes))
+	auth.Use(middleware.RequireJSON)
 	auth.HandleFunc("/login", authHandler.Login).Methods("POST")
 	auth.HandleFunc("/register", authHandler.Register).Methods("POST")
+	auth.HandleFunc("/verify-email", authHandler.VerifyEmail).Methods("GET", "POST")
+	auth.HandleFunc("/resend-verification", authHandler.ResendVerification).Methods("POST")
 
 	// Protected routes (authentication required)
 	protected := api.PathPrefix("/users").Subrouter()
-	protected.Use(middleware.AuthMiddleware)
+	protected.Use(authMiddleware.Middleware)
+	protected.Use(middleware.MaxBodyBytes(maxBodyBytes))
+	protected.Use(middleware.RequireJSON)
 	protected.HandleFunc("/profile", userHandler.GetProfile).Methods("GET")
 	protected.HandleFunc("/profile", userHandler.UpdateProfile).Methods("PUT")
+	protected.HandleFunc("/preferences", userHandler.UpdatePreferences).Methods("PUT")
+	protected.HandleFunc("/email-change", userHandler.RequestEmailChange).Methods("POST")
+	protected.HandleFunc("/email-change/confirm", userHandler.ConfirmEmailChange).Methods("POST")
+	protected.HandleFunc("/password", userHandler.ChangePassword).Methods("PUT")
 	protected.HandleFunc("/{id}", userHandler.GetUser).Methods("GET")
 
+	// API key management (requires auth)
+	keys := api.PathPrefix("/keys").Subrouter()
+	keys.Use(authMiddleware.Middleware)
+	keys.Use(middleware.MaxBodyBytes(maxBodyBytes))
+	keys.Use(middleware.RequireJSON)
+	keys.HandleFunc("/rotate", apiKeyHandler.RotateKeys).Methods("POST")
+
+	// Configuration templates (requires auth); only admins change the shared catalog
+	templates := api.PathPrefix("/templates").Subrouter()
+	templates.Use(authMiddleware.Middleware)
+	templates.Use(middleware.MaxBodyBytes(maxBodyBytes))
+	templates.Use(middleware.RequireJSON)
+	templates.HandleFunc("", configHandler.GetTemplates).Methods("GET")
+	templates.Handle("", middleware.RequireAdmin(http.HandlerFunc(configHandler.CreateTemplate))).Methods("POST")
+	templates.HandleFunc("/{id}", configHandler.GetTemplate).Methods("GET")
+	templates.Handle("/{id}", middleware.RequireAdmin(http.HandlerFunc(configHandler.UpdateTemplate))).Methods("PUT")
+	templates.Handle("/{id}", middleware.RequireAdmin(http.HandlerFunc(configHandler.DeleteTemplate))).Methods("DELETE")
+	templates.HandleFunc("/{id}/versions", configHandler.GetTemplateVersions).Methods("GET")
+	templates.HandleFunc("/{id}/dependents", configHandler.GetTemplateDependents).Methods("GET")
+	templates.HandleFunc("/{id}/preview", configHandler.PreviewTemplate).Methods("POST")
+
+	// User configurations (requires auth)
+	configs := api.PathPrefix("/configs").Subrouter()
+	configs.Use(authMiddleware.Middleware)
+	// Takes a multipart upload, so it is outside RequireJSON; the handler caps the file size
+	configs.HandleFunc("/convert/file", configHandler.ConvertFile).Methods("POST")
+	userConfigs := configs.NewRoute().Subrouter()
+	userConfigs.Use(middleware.MaxBodyBytes(maxBodyBytes))
+	userConfigs.Use(middleware.RequireJSON)
+	// Fixed paths are registered before /{id} so they aren't taken for a configuration ID
+	userConfigs.HandleFunc("", configHandler.GetUserConfigs).Methods("GET")
+	userConfigs.HandleFunc("", configHandler.CreateUserConfig).Methods("POST")
+	userConfigs.HandleFunc("/detect-format", configHandler.DetectFormat).Methods("POST")
+	userConfigs.HandleFunc("/convert", configHandler.ConvertFormat).Methods("POST")
+	userConfigs.HandleFunc("/convert-batch", configHandler.ConvertBatch).Methods("POST")
+	userConfigs.HandleFunc("/merge", configHandler.MergeConfigs).Methods("POST")
+	userConfigs.HandleFunc("/validate", configHandler.ValidateConfig).Methods("POST")
+	userConfigs.HandleFunc("/export-all", configHandler.ExportAllConfigs).Methods("GET")
+	userConfigs.HandleFunc("/{id}", configHandler.GetUserConfig).Methods("GET")
+	userConfigs.HandleFunc("/{id}", configHandler.UpdateUserConfig).Methods("PUT")
+	userConfigs.HandleFunc("/{id}", configHandler.PatchUserConfig).Methods("PATCH")
+	userConfigs.HandleFunc("/{id}", configHandler.DeleteUserConfig).Methods("DELETE")
+	userConfigs.HandleFunc("/{id}/versions", configHandler.GetConfigVersions).Methods("GET")
+	userConfigs.HandleFunc("/{id}/versions/{version_id}/restore", configHandler.RestoreConfigVersion).Methods("POST")
+	userConfigs.HandleFunc("/{id}/versions/{from}/diff/{to}", configHandler.GetVersionDiff).Methods("GET")
+	userConfigs.HandleFunc("/{id}/history/graph", configHandler.GetVersionGraph).Methods("GET")
+	userConfigs.HandleFunc("/{id}/history/archive", configHandler.GetHistoryArchive).Methods("GET")
+	userConfigs.HandleFunc("/{id}/template-drift", configHandler.GetTemplateDrift).Methods("GET")
+	userConfigs.HandleFunc("/{id}/rebase", configHandler.RebaseUserConfig).Methods("POST")
+	userConfigs.HandleFunc("/{id}/fork", configHandler.ForkUserConfig).Methods("POST")
+	userConfigs.HandleFunc("/{id}/share", configHandler.ShareUserConfig).Methods("POST")
+	userConfigs.HandleFunc("/{id}/lock", configHandler.LockUserConfig).Methods("POST")
+	userConfigs.HandleFunc("/{id}/lock", configHandler.UnlockUserConfig).Methods("DELETE")
+	userConfigs.HandleFunc("/{id}/validate", configHandler.ValidateUserConfig).Methods("POST")
+	userConfigs.HandleFunc("/{id}/export", configHandler.ExportConfig).Methods("GET")
+	userConfigs.HandleFunc("/{id}/raw.sha256", configHandler.GetExportDigest).Methods("GET")
+
+	// Imports from URLs and GitHub, and their status (requires auth)
+	imports := api.PathPrefix("/imports").Subrouter()
+	imports.Use(authMiddleware.Middleware)
+	imports.Use(middleware.MaxBodyBytes(maxBodyBytes))
+	imports.Use(middleware.RequireJSON)
+	imports.HandleFunc("", configHandler.StartImport).Methods("POST")
+	imports.HandleFunc("/{id}", configHandler.GetImport).Methods("GET")
+	imports.HandleFunc("/{id}/cancel", configHandler.CancelImport).Methods("POST")
+
+	// Current user's activity feed (requires auth)
+	me := api.PathPrefix("/me").Subrouter()
+	me.Use(authMiddleware.Middleware)
+	me.Use(middleware.MaxBodyBytes(maxBodyBytes))
+	me.Use(middleware.RequireJSON)
+	me.HandleFunc("/activity", activityHandler.GetMyActivity).Methods("GET")
+	me.HandleFunc("/sessions", authHandler.ListSessions).Methods("GET")
+	me.HandleFunc("/sessions/{session_id}", authHandler.RevokeSession).Methods("DELETE")
+	me.HandleFunc("/import-tokens/{source}", importTokenHandler.SetImportToken).Methods("PUT")
+	me.HandleFunc("/import-tokens/{source}", importTokenHandler.DeleteImportToken).Methods("DELETE")
+
+	// Admin tools (requires auth and the admin role)
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(authMiddleware.Middleware)
+	admin.Use(middleware.RequireAdmin)
+	admin.HandleFunc("/users/{id}/sessions", authHandler.PurgeUserSessions).Methods("DELETE")
+	// Takes CSV as well as JSON, so it is outside RequireJSON; the handler checks the type
+	admin.Handle("/users/import", middleware.MaxBodyBytes(userImportMaxBodyBytes)(http.HandlerFunc(userHandler.ImportUsers))).Methods("POST")
+	admin.HandleFunc("/maintenance", maintenanceHandler.GetMaintenance).Methods("GET")
+	admin.Handle("/maintenance", middleware.RequireJSON(http.HandlerFunc(maintenanceHandler.SetMaintenance))).Methods("PUT")
+	admin.HandleFunc("/metrics", metricsHandler.GetMetrics).Methods("GET")
+	admin.HandleFunc("/imports", configHandler.ListImports).Methods("GET")
+	admin.HandleFunc("/imports/{id}/fail", configHandler.FailImport).Methods("POST")
+
 	// Logout endpoint (requires auth)
-	logoutHandler := middleware.AuthMiddleware(http.HandlerFunc(authHandler.Logout))
+	logoutHandler := authMiddleware.Middleware(http.HandlerFunc(authHandler.Logout))
 	auth.Handle("/logout", logoutHandler).Methods("POST")
 
 	// Development endpoints (only available in development environment)
 	dev := router.PathPrefix("/dev").Subrouter()
+	dev.Use(middleware.RequireJSON)
 	dev.HandleFunc("/token", devHandler.GetDevToken).Methods("POST")
 	dev.HandleFunc("/user", devHandler.CreateDevUser).Methods("POST")
 
 	return router
 }
+
+// probeMethods are the methods checked when building an Allow header
+var probeMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// unmatchedRouteHandler answers requests that match no route with a JSON error
+// mux reports a method mismatch inside nested subrouters as not found, so the
+// router is probed with other methods to tell 404 and 405 apart
+func unmatchedRouteHandler(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var allowed []string
+		for _, method := range probeMethods {
+			probe := r.Clone(r.Context())
+			probe.Method = method
+
+			var match mux.RouteMatch
+			if router.Match(probe, &match) && match.MatchErr == nil {
+				allowed = append(allowed, method)
+			}
+		}
+
+		if len(allowed) == 0 {
+			handlers.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		handlers.MethodNotAllowed(w, r)
+	})
+}
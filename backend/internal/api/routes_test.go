@@ -0,0 +1,337 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"conflux/internal/api/handlers"
+	"conflux/internal/api/middleware"
+	"conflux/internal/models"
+	"conflux/pkg/jwt"
+
+	"github.com/gorilla/mux"
+)
+
+// testTokenManager signs tokens for the test router's auth middleware
+var testTokenManager = jwt.NewTokenManager("test-secret", "conflux")
+
+// activeSessions treats every token as belonging to an active session
+type activeSessions struct{}
+
+func (activeSessions) ValidateToken(ctx context.Context, token string) (*models.User, error) {
+	return &models.User{}, nil
+}
+
+func newTestRouter() http.Handler {
+	return newMaintenanceTestRouter(middleware.NewMaintenance(false, time.Minute))
+}
+
+func newMaintenanceTestRouter(maintenance *middleware.Maintenance) http.Handler {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	concurrency := middleware.NewConcurrencyLimiter(0)
+	return SetupRoutes(
+		&handlers.UserHandler{},
+		&handlers.AuthHandler{},
+		&handlers.HealthHandler{},
+		&handlers.DevHandler{},
+		&handlers.FormatHandler{},
+		&handlers.APIKeyHandler{},
+		&handlers.ActivityHandler{},
+		handlers.NewMaintenanceHandler(maintenance, logger),
+		handlers.NewMetricsHandler(concurrency),
+		&handlers.ConfigHandler{},
+		&handlers.ImportTokenHandler{},
+		middleware.NewAuth(testTokenManager, activeSessions{}, nil),
+		&middleware.RealIP{},
+		middleware.NewRateLimiter(600, 100),
+		maintenance,
+		concurrency,
+		1<<20,
+		logger,
+	)
+}
+
+func TestSetupRoutes_UnmatchedRoutes(t *testing.T) {
+	router := newTestRouter()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantAllow  string
+	}{
+		{name: "unknown api path", method: http.MethodGet, path: "/api/does-not-exist", wantStatus: http.StatusNotFound},
+		{name: "unknown top-level path", method: http.MethodGet, path: "/nope", wantStatus: http.StatusNotFound},
+		{
+			name: "wrong method on health", method: http.MethodPost, path: "/api/health",
+			wantStatus: http.StatusMethodNotAllowed, wantAllow: "GET",
+		},
+		{
+			name: "wrong method on login", method: http.MethodGet, path: "/api/auth/login",
+			wantStatus: http.StatusMethodNotAllowed, wantAllow: "POST",
+		},
+		{
+			name: "wrong method on profile", method: http.MethodDelete, path: "/api/users/profile",
+			wantStatus: http.StatusMethodNotAllowed, wantAllow: "GET, PUT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+
+			var body map[string]interface{}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if body["error"] != true {
+				t.Errorf("error = %v, want true", body["error"])
+			}
+			if body["status"] != float64(tt.wantStatus) {
+				t.Errorf("status field = %v, want %d", body["status"], tt.wantStatus)
+			}
+			if msg, _ := body["message"].(string); msg == "" {
+				t.Error("expected a non-empty message")
+			}
+		})
+	}
+}
+
+func TestSetupRoutes_AuthBodyLimit(t *testing.T) {
+	router := newTestRouter()
+
+	body := strings.NewReader(strings.Repeat("a", int(authMaxBodyBytes)+1))
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", body)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestSetupRoutes_JSONBodyLimit(t *testing.T) {
+	router := newTestRouter()
+	token, err := testTokenManager.GenerateTokenWithRole(1, "user@example.com", models.RoleUser, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateTokenWithRole() error = %v", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{method: http.MethodPost, path: "/api/imports"},
+		{method: http.MethodPut, path: "/api/me/import-tokens/github"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			body := strings.NewReader(`{"token":"` + strings.Repeat("a", 1<<20) + `"}`)
+			req := httptest.NewRequest(tt.method, tt.path, body)
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+			}
+		})
+	}
+}
+
+func TestSetupRoutes_RequireJSON(t *testing.T) {
+	router := newTestRouter()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader("email=a&password=b"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnsupportedMediaType)
+	}
+}
+
+func TestSetupRoutes_AdminGate(t *testing.T) {
+	router := newTestRouter()
+
+	tests := []struct {
+		name       string
+		role       string
+		wantStatus int
+	}{
+		{name: "no token", wantStatus: http.StatusUnauthorized},
+		{name: "regular user", role: models.RoleUser, wantStatus: http.StatusForbidden},
+		{name: "token without role", role: "", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/api/admin/users/2/sessions", nil)
+			if tt.wantStatus != http.StatusUnauthorized {
+				token, err := testTokenManager.GenerateTokenWithRole(1, "user@example.com", tt.role, time.Hour)
+				if err != nil {
+					t.Fatalf("GenerateTokenWithRole() error = %v", err)
+				}
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSetupRoutes_ConfigRoutes(t *testing.T) {
+	router := newTestRouter().(*mux.Router)
+
+	tests := []struct {
+		method   string
+		path     string
+		template string
+	}{
+		{method: http.MethodPost, path: "/api/configs/convert/file", template: "/api/configs/convert/file"},
+		{method: http.MethodPost, path: "/api/configs/merge", template: "/api/configs/merge"},
+		{method: http.MethodPost, path: "/api/configs/validate", template: "/api/configs/validate"},
+		{method: http.MethodGet, path: "/api/configs/export-all", template: "/api/configs/export-all"},
+		{method: http.MethodGet, path: "/api/configs/7", template: "/api/configs/{id}"},
+		{method: http.MethodPatch, path: "/api/configs/7", template: "/api/configs/{id}"},
+		{method: http.MethodPost, path: "/api/configs/7/validate", template: "/api/configs/{id}/validate"},
+		{method: http.MethodPost, path: "/api/configs/7/fork", template: "/api/configs/{id}/fork"},
+		{method: http.MethodGet, path: "/api/configs/7/raw.sha256", template: "/api/configs/{id}/raw.sha256"},
+		{method: http.MethodGet, path: "/api/configs/7/versions/1/diff/2", template: "/api/configs/{id}/versions/{from}/diff/{to}"},
+		{method: http.MethodDelete, path: "/api/configs/7/lock", template: "/api/configs/{id}/lock"},
+		{method: http.MethodPost, path: "/api/templates/3/preview", template: "/api/templates/{id}/preview"},
+		{method: http.MethodPost, path: "/api/imports", template: "/api/imports"},
+		{method: http.MethodPost, path: "/api/imports/5/cancel", template: "/api/imports/{id}/cancel"},
+		{method: http.MethodPut, path: "/api/me/import-tokens/github", template: "/api/me/import-tokens/{source}"},
+		{method: http.MethodDelete, path: "/api/me/import-tokens/github", template: "/api/me/import-tokens/{source}"},
+		{method: http.MethodPost, path: "/api/admin/imports/5/fail", template: "/api/admin/imports/{id}/fail"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			var match mux.RouteMatch
+			if !router.Match(httptest.NewRequest(tt.method, tt.path, nil), &match) || match.MatchErr != nil {
+				t.Fatalf("no route matched (error: %v)", match.MatchErr)
+			}
+			template, err := match.Route.GetPathTemplate()
+			if err != nil || template != tt.template {
+				t.Errorf("matched %q (error: %v), want %q", template, err, tt.template)
+			}
+		})
+	}
+}
+
+func TestSetupRoutes_TemplateWritesRequireAdmin(t *testing.T) {
+	router := newTestRouter()
+	token, err := testTokenManager.
+		GenerateTokenWithRole(1, "user@example.com", models.RoleUser, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateTokenWithRole() error = %v", err)
+	}
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		req := httptest.NewRequest(method, "/api/templates/3", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s status = %d, want %d", method, w.Code, http.StatusForbidden)
+		}
+	}
+}
+
+func TestSetupRoutes_MaintenanceMode(t *testing.T) {
+	maintenance := middleware.NewMaintenance(true, time.Minute)
+	router := newMaintenanceTestRouter(maintenance)
+
+	register := httptest.NewRequest(http.MethodPost, "/api/auth/register", strings.NewReader(`{}`))
+	register.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, register)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "60" {
+		t.Errorf("register: status = %d, Retry-After = %q, want 503 and 60", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Unknown routes still get their own errors
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/does-not-exist", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown route: status = %d, want 404", w.Code)
+	}
+
+	// The admin toggle stays writable so maintenance mode can be switched off
+	token, err := testTokenManager.GenerateTokenWithRole(1, "admin@example.com", models.RoleAdmin, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateTokenWithRole() error = %v", err)
+	}
+	toggle := httptest.NewRequest(http.MethodPut, "/api/admin/maintenance", strings.NewReader(`{"enabled": false}`))
+	toggle.Header.Set("Content-Type", "application/json")
+	toggle.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, toggle)
+	if w.Code != http.StatusOK {
+		t.Fatalf("toggle: status = %d, want 200: %s", w.Code, w.Body)
+	}
+	if maintenance.Enabled() {
+		t.Error("maintenance mode still enabled after the admin switched it off")
+	}
+}
+
+func TestSetupRoutes_Metrics(t *testing.T) {
+	router := newTestRouter()
+
+	token, err := testTokenManager.GenerateTokenWithRole(1, "admin@example.com", models.RoleAdmin, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateTokenWithRole() error = %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/metrics", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+
+	var metrics map[string]int64
+	if err := json.Unmarshal(w.Body.Bytes(), &metrics); err != nil {
+		t.Fatalf("invalid response body: %v", err)
+	}
+	// The metrics request itself is in flight
+	if metrics["in_flight"] != 1 || metrics["max_in_flight"] != 0 || metrics["shed_total"] != 0 {
+		t.Errorf("metrics = %v, want one request in flight and no limit", metrics)
+	}
+}
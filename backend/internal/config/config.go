@@ -4,45 +4,170 @@
 package config
 
 import (
+	"fmt"
+	"log/slog"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
 	// Server configuration
-	Port string
-	Host string
+	Port        string
+	Host        string
+	Environment string // "development" relaxes startup safety checks
 
 	// Database configuration
-	DBType     string // "mysql" or "postgres"
+	DBType     string // "mysql", "postgres", or "sqlite"
 	DBHost     string
 	DBPort     string
-	DBName     string
+	DBName     string // SQLite: the database file, or ":memory:"
 	DBUser     string
 	DBPassword string
 
+	// Database connection pool
+	DBMaxOpenConns    int           // Open connections at most; 0 leaves it unlimited
+	DBMaxIdleConns    int           // Idle connections kept for reuse
+	DBConnMaxLifetime time.Duration // Connections older than this are replaced; 0 keeps them
+
 	// JWT configuration
-	JWTSecret     string
-	JWTExpiration int
+	JWTSecret          string
+	JWTIssuer          string // iss claim of issued tokens
+	JWTExpiration      int
+	JWTSecretMinLength int // Shortest JWT_SECRET accepted outside development
 
 	// CORS configuration
 	AllowedOrigins []string
+
+	// Proxy configuration
+	TrustedProxies []string // CIDRs whose X-Forwarded-For/X-Real-IP headers are honored
+
+	// Rate limiting configuration
+	RateLimitRequests int // Sustained requests per minute per client
+	RateLimitBurst    int // Maximum burst size per client
+
+	// Request limits
+	MaxBodyBytes int64 // Default request body limit for route groups without an override
+
+	// Feature flags enabled at startup
+	Features Features
+
+	// Logging configuration
+	LogLevel slog.Level // Minimum level written; reloadable
+
+	// Maintenance mode
+	MaintenanceMode       bool          // Refuse writes with 503 while serving reads; reloadable
+	MaintenanceRetryAfter time.Duration // Retry-After hint sent with refused writes
+
+	// Content checks
+	ScanSecrets     bool   // Warn about secret-like values when configs are saved
+	SecretEnvPrefix string // Env var prefix ${secret:NAME} placeholders resolve from
+
+	// Storage
+	CompressConfigStorage bool // Gzip config and version content at or above CompressMinBytes
+	CompressMinBytes      int
+
+	// Change note policy
+	ChangeNoteMinLines int // Edits touching this many lines need a change note; 0 disables
+
+	// Naming
+	UniqueConfigNames bool // Each user's configuration names must differ, ignoring case
+
+	// Per-template creation limit
+	TemplateCreateRateLimit int // Configurations created per minute from one template; 0 disables
+	TemplateCreateBurst     int // Creations allowed at once; 0 uses TemplateCreateRateLimit
+
+	// Caching
+	TemplateCacheTTL time.Duration // How long templates stay cached; 0 disables the cache
+
+	// Edit locks
+	EditLockTTL time.Duration // How long a config edit lock lasts unless renewed
+
+	// Imports
+	ImportStaleAfter  time.Duration // Imports processing longer than this at startup are failed
+	GitHubAPIURL      string        // GitHub REST API used for github imports, e.g. a GitHub Enterprise /api/v3
+	ImportPrivateURLs bool          // URL imports may reach loopback, private, and link-local addresses
+
+	// Load shedding
+	MaxConcurrentRequests int // Requests handled at once before the rest get 503; 0 disables
+
+	// Health checks
+	HealthCheckTimeout time.Duration // Longest the readiness check waits for a database ping
+	HealthCacheTTL     time.Duration // How long a readiness result is reused; 0 pings on every probe
+
+	// Accounts
+	RequireEmailVerification bool          // New users must verify their email before logging in
+	SessionTokenBytes        int           // Random bytes behind each opaque session ID (16-64)
+	SessionIdleTimeout       time.Duration // Sessions unused this long expire before their token does; 0 disables
+
+	// Security notification mail; notifications are off when SMTPHost is empty
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+}
+
+// Features is the set of feature flags enabled via the FEATURES env var
+// Flags are static for the lifetime of the process
+type Features map[string]bool
+
+// Enabled reports whether the named feature flag is on
+func (f Features) Enabled(name string) bool {
+	return f[strings.ToLower(name)]
+}
+
+// List returns the enabled feature flags in sorted order
+func (f Features) List() []string {
+	names := make([]string, 0, len(f))
+	for name, enabled := range f {
+		if enabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseFeatures parses a comma-separated list of feature flag names
+func parseFeatures(value string) Features {
+	features := make(Features)
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			features[name] = true
+		}
+	}
+	return features
 }
 
 // Load reads configuration from environment variables
 // Validates required settings and returns configured struct
 func Load() (*Config, error) {
 	config := &Config{
-		Port:       getEnv("PORT", "8080"),
-		Host:       getEnv("HOST", "0.0.0.0"),
-		DBType:     getEnv("DB_TYPE", "mysql"),
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     getEnv("DB_PORT", "3306"),
-		DBName:     getEnv("DB_NAME", "appdb"),
-		DBUser:     getEnv("DB_USER", "appuser"),
-		DBPassword: getEnv("DB_PASSWORD", "apppassword"),
-		JWTSecret:  getEnv("JWT_SECRET", "your-secret-key"),
+		Port:        getEnv("PORT", "8080"),
+		Host:        getEnv("HOST", "0.0.0.0"),
+		Environment: getEnv("ENVIRONMENT", "production"),
+		DBType:      getEnv("DB_TYPE", "mysql"),
+		DBHost:      getEnv("DB_HOST", "localhost"),
+		DBPort:      getEnv("DB_PORT", "3306"),
+		DBName:      getEnv("DB_NAME", "appdb"),
+		DBUser:      getEnv("DB_USER", "appuser"),
+		DBPassword:  getEnv("DB_PASSWORD", "apppassword"),
+		JWTSecret:   getEnv("JWT_SECRET", "your-secret-key"),
+		JWTIssuer:   getEnv("JWT_ISSUER", "conflux"),
+	}
+
+	// Parse connection pool settings
+	config.DBMaxOpenConns = getEnvInt("DB_MAX_OPEN_CONNS", 25)
+	config.DBMaxIdleConns = getEnvInt("DB_MAX_IDLE_CONNS", 25)
+	if config.DBMaxOpenConns < 0 || config.DBMaxIdleConns < 0 {
+		return nil, fmt.Errorf("invalid DB_MAX_OPEN_CONNS or DB_MAX_IDLE_CONNS: must not be negative")
+	}
+	var err error
+	if config.DBConnMaxLifetime, err = getEnvDuration("DB_CONN_MAX_LIFETIME", "0"); err != nil {
+		return nil, err
 	}
 
 	// Parse JWT expiration
@@ -53,10 +178,136 @@ func Load() (*Config, error) {
 		config.JWTExpiration = 3600
 	}
 
+	// Parse JWT secret minimum length (checked at startup outside development)
+	config.JWTSecretMinLength = getEnvInt("JWT_SECRET_MIN_LENGTH", DefaultJWTSecretMinLength)
+	if config.JWTSecretMinLength < 1 {
+		return nil, fmt.Errorf("invalid JWT_SECRET_MIN_LENGTH: must be positive")
+	}
+
 	// Parse allowed origins
 	originsStr := getEnv("ALLOWED_ORIGINS", "http://localhost:3000")
 	config.AllowedOrigins = strings.Split(originsStr, ",")
 
+	// Parse trusted proxies (empty means forwarding headers are ignored)
+	if proxies := getEnv("TRUSTED_PROXIES", ""); proxies != "" {
+		config.TrustedProxies = strings.Split(proxies, ",")
+	}
+
+	// Parse rate limiting settings
+	config.RateLimitRequests = getEnvInt("RATE_LIMIT_REQUESTS", 120)
+	config.RateLimitBurst = getEnvInt("RATE_LIMIT_BURST", 30)
+
+	// Parse request body limit
+	config.MaxBodyBytes = int64(getEnvInt("MAX_BODY_BYTES", 1<<20))
+
+	// Parse feature flags
+	config.Features = parseFeatures(getEnv("FEATURES", ""))
+
+	// Parse secret scanning toggle
+	config.ScanSecrets = getEnvBool("SECRET_SCAN", true)
+	config.SecretEnvPrefix = getEnv("SECRET_ENV_PREFIX", "CONFLUX_SECRET_")
+
+	// Parse storage compression (existing rows read correctly either way)
+	config.CompressConfigStorage = getEnvBool("COMPRESS_CONFIG_STORAGE", false)
+	config.CompressMinBytes = getEnvInt("COMPRESS_CONFIG_MIN_BYTES", 4096)
+	if config.CompressMinBytes < 0 {
+		return nil, fmt.Errorf("invalid COMPRESS_CONFIG_MIN_BYTES: must not be negative")
+	}
+
+	// Parse change note policy (0 leaves change notes optional)
+	config.ChangeNoteMinLines = getEnvInt("CHANGE_NOTE_MIN_LINES", 0)
+	if config.ChangeNoteMinLines < 0 {
+		return nil, fmt.Errorf("invalid CHANGE_NOTE_MIN_LINES: must not be negative")
+	}
+
+	// Parse config naming (off allows duplicate names per user)
+	config.UniqueConfigNames = getEnvBool("UNIQUE_CONFIG_NAMES", false)
+
+	// Parse per-template creation limit (0 leaves creation unlimited)
+	config.TemplateCreateRateLimit = getEnvInt("TEMPLATE_CREATE_RATE_LIMIT", 0)
+	config.TemplateCreateBurst = getEnvInt("TEMPLATE_CREATE_BURST", 0)
+	if config.TemplateCreateRateLimit < 0 || config.TemplateCreateBurst < 0 {
+		return nil, fmt.Errorf("invalid TEMPLATE_CREATE_RATE_LIMIT or TEMPLATE_CREATE_BURST: must not be negative")
+	}
+
+	// Parse template cache TTL (Go duration, e.g. 5m; 0 disables)
+	ttl, err := getEnvDuration("TEMPLATE_CACHE_TTL", "5m")
+	if err != nil {
+		return nil, err
+	}
+	config.TemplateCacheTTL = ttl
+
+	// Parse edit lock lifetime (a lock a crashed client never released lapses after this)
+	if config.EditLockTTL, err = getEnvDuration("EDIT_LOCK_TTL", "5m"); err != nil {
+		return nil, err
+	}
+	if config.EditLockTTL <= 0 {
+		return nil, fmt.Errorf("invalid EDIT_LOCK_TTL: must be positive")
+	}
+
+	// Parse maintenance mode (reads keep working while writes are refused)
+	config.MaintenanceMode = getEnvBool("MAINTENANCE_MODE", false)
+	if config.MaintenanceRetryAfter, err = getEnvDuration("MAINTENANCE_RETRY_AFTER", "5m"); err != nil {
+		return nil, err
+	}
+
+	// Parse import reconciliation age (imports left processing by a previous run)
+	if config.ImportStaleAfter, err = getEnvDuration("IMPORT_STALE_AFTER", "30m"); err != nil {
+		return nil, err
+	}
+	if config.ImportStaleAfter == 0 {
+		return nil, fmt.Errorf("invalid IMPORT_STALE_AFTER: must be greater than zero")
+	}
+	config.GitHubAPIURL = getEnv("GITHUB_API_URL", "https://api.github.com")
+	config.ImportPrivateURLs = getEnvBool("IMPORT_ALLOW_PRIVATE_URLS", false)
+
+	// Parse load shedding limit
+	config.MaxConcurrentRequests = getEnvInt("MAX_CONCURRENT_REQUESTS", 0)
+	if config.MaxConcurrentRequests < 0 {
+		return nil, fmt.Errorf("invalid MAX_CONCURRENT_REQUESTS: must not be negative")
+	}
+
+	// Parse health check settings
+	if config.HealthCheckTimeout, err = getEnvDuration("HEALTH_CHECK_TIMEOUT", "2s"); err != nil {
+		return nil, err
+	}
+	if config.HealthCheckTimeout == 0 {
+		return nil, fmt.Errorf("invalid HEALTH_CHECK_TIMEOUT: must be greater than zero")
+	}
+	if config.HealthCacheTTL, err = getEnvDuration("HEALTH_CACHE_TTL", "1s"); err != nil {
+		return nil, err
+	}
+
+	// Parse account settings
+	config.RequireEmailVerification = getEnvBool("REQUIRE_EMAIL_VERIFICATION", false)
+	config.SessionTokenBytes = getEnvInt("SESSION_TOKEN_BYTES", 32)
+	if config.SessionTokenBytes < 16 || config.SessionTokenBytes > 64 {
+		return nil, fmt.Errorf("invalid SESSION_TOKEN_BYTES: must be between 16 and 64")
+	}
+	if config.SessionIdleTimeout, err = getEnvDuration("SESSION_IDLE_TIMEOUT", "0"); err != nil {
+		return nil, err
+	}
+
+	// Parse security notification mail settings
+	config.SMTPHost = getEnv("SMTP_HOST", "")
+	config.SMTPPort = getEnvInt("SMTP_PORT", 587)
+	config.SMTPUsername = getEnv("SMTP_USERNAME", "")
+	config.SMTPPassword = getEnv("SMTP_PASSWORD", "")
+	config.SMTPFrom = getEnv("SMTP_FROM", "")
+	if config.SMTPHost != "" {
+		if config.SMTPPort < 1 || config.SMTPPort > 65535 {
+			return nil, fmt.Errorf("invalid SMTP_PORT: must be between 1 and 65535")
+		}
+		if config.SMTPFrom == "" {
+			return nil, fmt.Errorf("SMTP_FROM is required when SMTP_HOST is set")
+		}
+	}
+
+	// Parse log level (debug, info, warn, error)
+	if err := config.LogLevel.UnmarshalText([]byte(getEnv("LOG_LEVEL", "info"))); err != nil {
+		return nil, fmt.Errorf("invalid LOG_LEVEL: %w", err)
+	}
+
 	return config, nil
 }
 
@@ -67,3 +318,28 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvBool gets a boolean environment variable with fallback to default value
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvInt gets an integer environment variable with fallback to default value
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvDuration parses a non-negative Go duration environment variable with fallback to default value
+func getEnvDuration(key, defaultValue string) (time.Duration, error) {
+	duration, err := time.ParseDuration(getEnv(key, defaultValue))
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", key, os.Getenv(key))
+	}
+	return duration, nil
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseFeatures(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{name: "empty", value: "", want: []string{}},
+		{name: "single flag", value: "sliding_sessions", want: []string{"sliding_sessions"}},
+		{name: "trims and lowercases", value: " INI_Codec , sliding_sessions,, ", want: []string{"ini_codec", "sliding_sessions"}},
+		{name: "duplicates collapse", value: "a,a,b", want: []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			features := parseFeatures(tt.value)
+			if got := features.List(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeatures_Enabled(t *testing.T) {
+	features := parseFeatures("sliding_sessions")
+
+	if !features.Enabled("sliding_sessions") {
+		t.Error("expected sliding_sessions to be enabled")
+	}
+	if !features.Enabled("Sliding_Sessions") {
+		t.Error("flag lookup should be case-insensitive")
+	}
+	if features.Enabled("ini_codec") {
+		t.Error("expected ini_codec to be disabled")
+	}
+
+	var none Features
+	if none.Enabled("anything") {
+		t.Error("nil Features should report every flag disabled")
+	}
+}
+
+func TestLoad_Features(t *testing.T) {
+	t.Setenv("FEATURES", "beta_diff,sliding_sessions")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.Features.Enabled("beta_diff") || !cfg.Features.Enabled("sliding_sessions") {
+		t.Errorf("Features = %v, want beta_diff and sliding_sessions", cfg.Features.List())
+	}
+}
+
+func TestLoad_TemplateCacheTTL(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "", want: 5 * time.Minute},
+		{value: "30s", want: 30 * time.Second},
+		{value: "0", want: 0},
+		{value: "-1m", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("TEMPLATE_CACHE_TTL", tt.value)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error for invalid TEMPLATE_CACHE_TTL")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.TemplateCacheTTL != tt.want {
+				t.Errorf("TemplateCacheTTL = %v, want %v", cfg.TemplateCacheTTL, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_HealthCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		timeout     string
+		cacheTTL    string
+		wantTimeout time.Duration
+		wantTTL     time.Duration
+		wantErr     bool
+	}{
+		{name: "defaults", wantTimeout: 2 * time.Second, wantTTL: time.Second},
+		{name: "custom", timeout: "500ms", cacheTTL: "0", wantTimeout: 500 * time.Millisecond, wantTTL: 0},
+		{name: "zero timeout", timeout: "0", wantErr: true},
+		{name: "invalid ttl", cacheTTL: "often", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HEALTH_CHECK_TIMEOUT", tt.timeout)
+			t.Setenv("HEALTH_CACHE_TTL", tt.cacheTTL)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error for invalid health check setting")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.HealthCheckTimeout != tt.wantTimeout || cfg.HealthCacheTTL != tt.wantTTL {
+				t.Errorf("timeout, ttl = %v, %v, want %v, %v", cfg.HealthCheckTimeout, cfg.HealthCacheTTL, tt.wantTimeout, tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestLoad_LogLevel(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{value: "", want: slog.LevelInfo},
+		{value: "debug", want: slog.LevelDebug},
+		{value: "WARN", want: slog.LevelWarn},
+		{value: "error", want: slog.LevelError},
+		{value: "verbose", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("LOG_LEVEL", tt.value)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error for invalid LOG_LEVEL")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.LogLevel != tt.want {
+				t.Errorf("LogLevel = %v, want %v", cfg.LogLevel, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_SessionTokenBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", want: 32},
+		{name: "custom", value: "48", want: 48},
+		{name: "too short", value: "8", wantErr: true},
+		{name: "too long", value: "128", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SESSION_TOKEN_BYTES", tt.value)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error for out-of-range SESSION_TOKEN_BYTES")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.SessionTokenBytes != tt.want {
+				t.Errorf("SessionTokenBytes = %d, want %d", cfg.SessionTokenBytes, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_DBPool(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxOpen      string
+		maxIdle      string
+		lifetime     string
+		wantOpen     int
+		wantIdle     int
+		wantLifetime time.Duration
+		wantErr      bool
+	}{
+		{name: "defaults", wantOpen: 25, wantIdle: 25, wantLifetime: 0},
+		{name: "custom", maxOpen: "50", maxIdle: "10", lifetime: "30m", wantOpen: 50, wantIdle: 10, wantLifetime: 30 * time.Minute},
+		{name: "unparseable counts fall back", maxOpen: "many", maxIdle: "some", wantOpen: 25, wantIdle: 25},
+		{name: "negative open", maxOpen: "-1", wantErr: true},
+		{name: "negative idle", maxIdle: "-5", wantErr: true},
+		{name: "invalid lifetime", lifetime: "forever", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DB_MAX_OPEN_CONNS", tt.maxOpen)
+			t.Setenv("DB_MAX_IDLE_CONNS", tt.maxIdle)
+			t.Setenv("DB_CONN_MAX_LIFETIME", tt.lifetime)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error for invalid connection pool setting")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.DBMaxOpenConns != tt.wantOpen || cfg.DBMaxIdleConns != tt.wantIdle || cfg.DBConnMaxLifetime != tt.wantLifetime {
+				t.Errorf("open, idle, lifetime = %d, %d, %v, want %d, %d, %v",
+					cfg.DBMaxOpenConns, cfg.DBMaxIdleConns, cfg.DBConnMaxLifetime, tt.wantOpen, tt.wantIdle, tt.wantLifetime)
+			}
+		})
+	}
+}
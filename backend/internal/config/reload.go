@@ -0,0 +1,163 @@
+// Runtime configuration reloading
+// Re-reads the environment on SIGHUP and swaps in safely-reloadable settings
+// Settings that need a restart or reconnect are kept and reported in the log
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Reloader holds the live configuration and applies reloads atomically
+type Reloader struct {
+	current atomic.Pointer[Config]
+	load    func() (*Config, error)
+	logger  *slog.Logger
+
+	mu          sync.Mutex // Serializes reloads and subscriber registration
+	subscribers []func(*Config)
+}
+
+// NewReloader creates a reloader serving initial until the first reload
+// load is called on each reload; it defaults to Load when nil
+func NewReloader(initial *Config, load func() (*Config, error), logger *slog.Logger) *Reloader {
+	if load == nil {
+		load = Load
+	}
+	r := &Reloader{load: load, logger: logger}
+	r.current.Store(initial)
+	return r
+}
+
+// Current returns the live configuration
+// Callers must treat the returned value as read-only
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// OnReload registers fn to be called with the new configuration after each reload
+func (r *Reloader) OnReload(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Reload re-reads configuration and swaps in the reloadable fields
+// Fields requiring a restart keep their current values; changes to them are logged
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	current := r.Current()
+	for _, field := range restartRequiredChanges(current, loaded) {
+		r.logger.Warn("Config reload: setting changed but requires a restart, keeping current value", "setting", field)
+	}
+
+	next := *current
+	next.AllowedOrigins = loaded.AllowedOrigins
+	next.RateLimitRequests = loaded.RateLimitRequests
+	next.RateLimitBurst = loaded.RateLimitBurst
+	next.LogLevel = loaded.LogLevel
+	next.MaintenanceMode = loaded.MaintenanceMode
+	r.current.Store(&next)
+
+	for _, fn := range r.subscribers {
+		fn(&next)
+	}
+
+	r.logger.Info("Configuration reloaded")
+	return nil
+}
+
+// Watch reloads configuration each time a signal arrives until ctx is done
+// main passes a channel registered for SIGHUP; tests can send on it directly
+func (r *Reloader) Watch(ctx context.Context, signals <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			if err := r.Reload(); err != nil {
+				r.logger.Error("Config reload failed, keeping current configuration", "error", err)
+			}
+		}
+	}
+}
+
+// OriginAllowed reports whether a CORS origin is in the allowed list
+func (c *Config) OriginAllowed(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// restartRequiredChanges lists fields that differ but cannot be applied at runtime
+func restartRequiredChanges(current, loaded *Config) []string {
+	fields := []struct {
+		name       string
+		old, fresh interface{}
+	}{
+		{"HOST/PORT", current.Host + ":" + current.Port, loaded.Host + ":" + loaded.Port},
+		{"DB_TYPE", current.DBType, loaded.DBType},
+		{"DB_HOST", current.DBHost, loaded.DBHost},
+		{"DB_PORT", current.DBPort, loaded.DBPort},
+		{"DB_NAME", current.DBName, loaded.DBName},
+		{"DB_USER", current.DBUser, loaded.DBUser},
+		{"DB_PASSWORD", current.DBPassword, loaded.DBPassword},
+		{"DB_MAX_OPEN_CONNS", current.DBMaxOpenConns, loaded.DBMaxOpenConns},
+		{"DB_MAX_IDLE_CONNS", current.DBMaxIdleConns, loaded.DBMaxIdleConns},
+		{"DB_CONN_MAX_LIFETIME", current.DBConnMaxLifetime, loaded.DBConnMaxLifetime},
+		{"ENVIRONMENT", current.Environment, loaded.Environment},
+		{"JWT_SECRET", current.JWTSecret, loaded.JWTSecret},
+		{"JWT_ISSUER", current.JWTIssuer, loaded.JWTIssuer},
+		{"JWT_SECRET_MIN_LENGTH", current.JWTSecretMinLength, loaded.JWTSecretMinLength},
+		{"TRUSTED_PROXIES", current.TrustedProxies, loaded.TrustedProxies},
+		{"MAX_BODY_BYTES", current.MaxBodyBytes, loaded.MaxBodyBytes},
+		{"FEATURES", current.Features.List(), loaded.Features.List()},
+		{"SECRET_SCAN", current.ScanSecrets, loaded.ScanSecrets},
+		{"SECRET_ENV_PREFIX", current.SecretEnvPrefix, loaded.SecretEnvPrefix},
+		{"COMPRESS_CONFIG_STORAGE", current.CompressConfigStorage, loaded.CompressConfigStorage},
+		{"COMPRESS_CONFIG_MIN_BYTES", current.CompressMinBytes, loaded.CompressMinBytes},
+		{"CHANGE_NOTE_MIN_LINES", current.ChangeNoteMinLines, loaded.ChangeNoteMinLines},
+		{"UNIQUE_CONFIG_NAMES", current.UniqueConfigNames, loaded.UniqueConfigNames},
+		{"TEMPLATE_CREATE_RATE_LIMIT", current.TemplateCreateRateLimit, loaded.TemplateCreateRateLimit},
+		{"TEMPLATE_CREATE_BURST", current.TemplateCreateBurst, loaded.TemplateCreateBurst},
+		{"TEMPLATE_CACHE_TTL", current.TemplateCacheTTL, loaded.TemplateCacheTTL},
+		{"EDIT_LOCK_TTL", current.EditLockTTL, loaded.EditLockTTL},
+		{"IMPORT_STALE_AFTER", current.ImportStaleAfter, loaded.ImportStaleAfter},
+		{"GITHUB_API_URL", current.GitHubAPIURL, loaded.GitHubAPIURL},
+		{"IMPORT_ALLOW_PRIVATE_URLS", current.ImportPrivateURLs, loaded.ImportPrivateURLs},
+		{"MAINTENANCE_RETRY_AFTER", current.MaintenanceRetryAfter, loaded.MaintenanceRetryAfter},
+		{"MAX_CONCURRENT_REQUESTS", current.MaxConcurrentRequests, loaded.MaxConcurrentRequests},
+		{"HEALTH_CHECK_TIMEOUT", current.HealthCheckTimeout, loaded.HealthCheckTimeout},
+		{"HEALTH_CACHE_TTL", current.HealthCacheTTL, loaded.HealthCacheTTL},
+		{"REQUIRE_EMAIL_VERIFICATION", current.RequireEmailVerification, loaded.RequireEmailVerification},
+		{"SESSION_TOKEN_BYTES", current.SessionTokenBytes, loaded.SessionTokenBytes},
+		{"SESSION_IDLE_TIMEOUT", current.SessionIdleTimeout, loaded.SessionIdleTimeout},
+		{"SMTP_HOST", current.SMTPHost, loaded.SMTPHost},
+		{"SMTP_PORT", current.SMTPPort, loaded.SMTPPort},
+		{"SMTP_USERNAME", current.SMTPUsername, loaded.SMTPUsername},
+		{"SMTP_PASSWORD", current.SMTPPassword, loaded.SMTPPassword},
+		{"SMTP_FROM", current.SMTPFrom, loaded.SMTPFrom},
+	}
+
+	var changed []string
+	for _, f := range fields {
+		if !reflect.DeepEqual(f.old, f.fresh) {
+			changed = append(changed, f.name)
+		}
+	}
+	return changed
+}
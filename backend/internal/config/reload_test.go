@@ -0,0 +1,129 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestReloader_ReloadOnSignal(t *testing.T) {
+	initial := &Config{
+		Port:              "8080",
+		DBHost:            "db-1",
+		AllowedOrigins:    []string{"http://localhost:3000"},
+		RateLimitRequests: 120,
+		RateLimitBurst:    30,
+	}
+
+	reloader := NewReloader(initial, func() (*Config, error) {
+		return &Config{
+			Port:              "9090", // Requires restart, must be ignored
+			DBHost:            "db-2", // Requires reconnect, must be ignored
+			AllowedOrigins:    []string{"https://app.example.com"},
+			RateLimitRequests: 60,
+			RateLimitBurst:    10,
+			LogLevel:          slog.LevelDebug,
+		}, nil
+	}, discardLogger)
+
+	reloaded := make(chan *Config, 1)
+	reloader.OnReload(func(c *Config) { reloaded <- c })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	go reloader.Watch(ctx, signals)
+
+	// Fake SIGHUP
+	signals <- syscall.SIGHUP
+
+	var got *Config
+	select {
+	case got = <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for reload")
+	}
+
+	if got != reloader.Current() {
+		t.Error("subscribers should receive the live configuration")
+	}
+	if !reflect.DeepEqual(got.AllowedOrigins, []string{"https://app.example.com"}) {
+		t.Errorf("AllowedOrigins = %v, want reloaded value", got.AllowedOrigins)
+	}
+	if got.RateLimitRequests != 60 || got.RateLimitBurst != 10 {
+		t.Errorf("rate limits = %d/%d, want 60/10", got.RateLimitRequests, got.RateLimitBurst)
+	}
+	if got.LogLevel != slog.LevelDebug {
+		t.Errorf("LogLevel = %v, want DEBUG", got.LogLevel)
+	}
+	if got.Port != "8080" || got.DBHost != "db-1" {
+		t.Errorf("restart-only fields changed: port %q, db host %q", got.Port, got.DBHost)
+	}
+
+	// The original config is never mutated in place
+	if initial.RateLimitBurst != 30 || initial.AllowedOrigins[0] != "http://localhost:3000" {
+		t.Error("reload must swap in a new Config rather than mutate the old one")
+	}
+}
+
+func TestReloader_LoadErrorKeepsCurrent(t *testing.T) {
+	initial := &Config{AllowedOrigins: []string{"http://localhost:3000"}}
+	reloader := NewReloader(initial, func() (*Config, error) {
+		return nil, errors.New("bad environment")
+	}, discardLogger)
+
+	if err := reloader.Reload(); err == nil {
+		t.Fatal("expected reload error")
+	}
+	if reloader.Current() != initial {
+		t.Error("failed reload must keep the current configuration")
+	}
+}
+
+func TestRestartRequiredChanges(t *testing.T) {
+	current := &Config{Port: "8080", DBPassword: "a", AllowedOrigins: []string{"x"}}
+	loaded := &Config{Port: "8080", DBPassword: "b", AllowedOrigins: []string{"y"}}
+
+	got := restartRequiredChanges(current, loaded)
+	if !reflect.DeepEqual(got, []string{"DB_PASSWORD"}) {
+		t.Errorf("restartRequiredChanges() = %v, want [DB_PASSWORD]", got)
+	}
+
+	pooled := &Config{DBMaxOpenConns: 25, DBMaxIdleConns: 25}
+	resized := &Config{DBMaxOpenConns: 50, DBMaxIdleConns: 25, DBConnMaxLifetime: time.Minute}
+	got = restartRequiredChanges(pooled, resized)
+	if !reflect.DeepEqual(got, []string{"DB_MAX_OPEN_CONNS", "DB_CONN_MAX_LIFETIME"}) {
+		t.Errorf("restartRequiredChanges() = %v, want [DB_MAX_OPEN_CONNS DB_CONN_MAX_LIFETIME]", got)
+	}
+}
+
+func TestConfig_OriginAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{name: "listed origin", allowed: []string{"http://a", "http://b"}, origin: "http://b", want: true},
+		{name: "unlisted origin", allowed: []string{"http://a"}, origin: "http://evil", want: false},
+		{name: "wildcard", allowed: []string{"*"}, origin: "http://any", want: true},
+		{name: "empty list", allowed: nil, origin: "http://a", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{AllowedOrigins: tt.allowed}
+			if got := cfg.OriginAllowed(tt.origin); got != tt.want {
+				t.Errorf("OriginAllowed(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// JWT secret self-check
+// Catches the placeholder secrets that ship in code, examples, and dev tooling
+// before the server signs tokens anyone could forge
+package config
+
+import "fmt"
+
+// DefaultJWTSecretMinLength is the shortest JWT_SECRET accepted outside development
+const DefaultJWTSecretMinLength = 32
+
+// insecureJWTSecrets are publicly known secrets: code fallbacks, the .env.example
+// value, and the dev tooling default
+var insecureJWTSecrets = map[string]bool{
+	"your-secret-key": true,
+	"default-secret":  true,
+	"dev-secret-key":  true,
+	"your-super-secret-jwt-key-change-in-production": true,
+}
+
+// CheckJWTSecret returns an error explaining why secret is unsafe to sign tokens with
+// A known default lets anyone mint valid tokens; a short secret can be brute-forced
+func CheckJWTSecret(secret string, minLength int) error {
+	if insecureJWTSecrets[secret] {
+		return fmt.Errorf("JWT_SECRET is a publicly known default, so anyone can forge session tokens")
+	}
+	if len(secret) < minLength {
+		return fmt.Errorf("JWT_SECRET is %d characters, shorter than the minimum of %d, so it can be brute-forced",
+			len(secret), minLength)
+	}
+	return nil
+}
+
+// IsDevelopment reports whether the server runs with ENVIRONMENT=development
+func (c *Config) IsDevelopment() bool {
+	return c.Environment == "development"
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckJWTSecret(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		minLength int
+		wantErr   string
+	}{
+		{name: "long random secret", secret: "q8Vt0mZr3kXyW1nB6pLs9dHf2jCa5uEg", minLength: 32},
+		{name: "code fallback", secret: "your-secret-key", minLength: 1, wantErr: "publicly known default"},
+		{name: "middleware fallback", secret: "default-secret", minLength: 1, wantErr: "publicly known default"},
+		{name: "dev tooling default", secret: "dev-secret-key", minLength: 1, wantErr: "publicly known default"},
+		{
+			name: "example file value", secret: "your-super-secret-jwt-key-change-in-production", minLength: 32,
+			wantErr: "publicly known default",
+		},
+		{name: "too short", secret: "s3cr3t-but-short", minLength: 32, wantErr: "shorter than the minimum of 32"},
+		{name: "exactly the minimum", secret: strings.Repeat("x", 16), minLength: 16},
+		{name: "empty", secret: "", minLength: 32, wantErr: "shorter than the minimum"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckJWTSecret(tt.secret, tt.minLength)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckJWTSecret() error = %v, want accepted", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckJWTSecret() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_JWTSecretGuardSettings(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "")
+	t.Setenv("JWT_SECRET_MIN_LENGTH", "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.IsDevelopment() || cfg.JWTSecretMinLength != DefaultJWTSecretMinLength {
+		t.Errorf("defaults = development %v, min length %d; want production and %d",
+			cfg.IsDevelopment(), cfg.JWTSecretMinLength, DefaultJWTSecretMinLength)
+	}
+
+	t.Setenv("ENVIRONMENT", "development")
+	if cfg, err = Load(); err != nil || !cfg.IsDevelopment() {
+		t.Errorf("Load() with ENVIRONMENT=development = %v, %v; want development", cfg, err)
+	}
+
+	t.Setenv("JWT_SECRET_MIN_LENGTH", "0")
+	if _, err := Load(); err == nil {
+		t.Error("Load() with JWT_SECRET_MIN_LENGTH=0 succeeded, want error")
+	}
+}
@@ -1,36 +1,55 @@
 // Database connection management and factory
-// Abstracts database connection creation for MySQL and PostgreSQL
+// Abstracts database connection creation for MySQL, PostgreSQL, and SQLite
 // Provides connection pooling and health checking capabilities
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
 
 	"conflux/internal/config"
 
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
 // Database driver constants
 const (
 	DriverMySQL    = "mysql"
 	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
 )
 
+// Startup connection retry defaults: about 30 seconds of waiting for the database in total
+const (
+	DefaultConnectAttempts   = 6
+	DefaultConnectRetryDelay = time.Second
+)
+
+// sqliteMemory is the DB_NAME that opens a throwaway in-memory SQLite database
+const sqliteMemory = ":memory:"
+
 // ConnectionFactory creates database connections based on configuration
 type ConnectionFactory struct {
 	config *config.Config
+	// connect opens and pings one connection; replaced in tests
+	connect func(ctx context.Context) (*sql.DB, error)
 }
 
 // NewConnectionFactory creates a new connection factory
 func NewConnectionFactory(cfg *config.Config) *ConnectionFactory {
-	return &ConnectionFactory{config: cfg}
+	cf := &ConnectionFactory{config: cfg}
+	cf.connect = cf.openAndPing
+	return cf
 }
 
 // NewConnection creates a new database connection based on DB_TYPE
-// Returns *sql.DB instance configured for either MySQL or PostgreSQL
+// Returns *sql.DB instance configured for MySQL, PostgreSQL, or SQLite
 func (cf *ConnectionFactory) NewConnection() (*sql.DB, error) {
 	var dsn string
 	var driverName string
@@ -38,7 +57,8 @@ func (cf *ConnectionFactory) NewConnection() (*sql.DB, error) {
 	switch cf.config.DBType {
 	case DriverMySQL:
 		driverName = DriverMySQL
-		dsn = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		// Keep the session and driver in UTC so TIMESTAMP values never shift with server zone
+		dsn = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC&time_zone=%%27%%2B00%%3A00%%27",
 			cf.config.DBUser,
 			cf.config.DBPassword,
 			cf.config.DBHost,
@@ -47,13 +67,18 @@ func (cf *ConnectionFactory) NewConnection() (*sql.DB, error) {
 		)
 	case DriverPostgres:
 		driverName = DriverPostgres
-		dsn = fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		dsn = fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable timezone=UTC",
 			cf.config.DBHost,
 			cf.config.DBPort,
 			cf.config.DBUser,
 			cf.config.DBPassword,
 			cf.config.DBName,
 		)
+	case DriverSQLite:
+		driverName = DriverSQLite
+		// DB_NAME is the database file. Foreign keys are off in SQLite unless enabled per
+		// connection, and times are written in a format SQLite's date functions can read
+		dsn = cf.config.DBName + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite"
 	default:
 		return nil, fmt.Errorf("unsupported database type: %s", cf.config.DBType)
 	}
@@ -64,8 +89,16 @@ func (cf *ConnectionFactory) NewConnection() (*sql.DB, error) {
 	}
 
 	// Configure connection pool
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
+	db.SetMaxOpenConns(cf.config.DBMaxOpenConns)
+	db.SetMaxIdleConns(cf.config.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cf.config.DBConnMaxLifetime)
+	if cf.config.DBType == DriverSQLite && cf.config.DBName == sqliteMemory {
+		// Every connection to :memory: opens its own empty database, and closing the
+		// only one discards it, so keep exactly one connection for the pool's lifetime
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		db.SetConnMaxLifetime(0)
+	}
 
 	return db, nil
 }
@@ -74,3 +107,55 @@ func (cf *ConnectionFactory) NewConnection() (*sql.DB, error) {
 func (cf *ConnectionFactory) HealthCheck(db *sql.DB) error {
 	return db.Ping()
 }
+
+// NewConnectionWithRetry opens a connection and pings it, retrying up to attempts times
+// The delay starts at baseDelay and doubles after each failure, with up to 50% random
+// jitter so replicas starting together don't retry in lockstep. Returns the last error
+// if every attempt fails, or the context's error if it is cancelled while waiting
+func (cf *ConnectionFactory) NewConnectionWithRetry(ctx context.Context, attempts int, baseDelay time.Duration) (*sql.DB, error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	delay := baseDelay
+	for attempt := 1; attempt <= attempts; attempt++ {
+		db, err := cf.connect(ctx)
+		if err == nil {
+			return db, nil
+		}
+		lastErr = err
+		if attempt == attempts {
+			break
+		}
+
+		wait := delay
+		if delay > 0 {
+			wait += rand.N(delay/2 + 1)
+		}
+		slog.Warn("Database not ready, retrying", "attempt", attempt, "attempts", attempts, "retry_in", wait, "error", err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+	return nil, fmt.Errorf("database unavailable after %d attempts: %w", attempts, lastErr)
+}
+
+// openAndPing opens a connection and verifies it, closing it again if the ping fails
+func (cf *ConnectionFactory) openAndPing(ctx context.Context) (*sql.DB, error) {
+	db, err := cf.NewConnection()
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return db, nil
+}
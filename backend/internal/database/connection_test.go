@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"conflux/internal/config"
+)
+
+func TestConnectionFactory_SQLiteMemory_RunsMigrations(t *testing.T) {
+	factory := NewConnectionFactory(&config.Config{DBType: DriverSQLite, DBName: ":memory:"})
+	db, err := factory.NewConnection()
+	if err != nil {
+		t.Fatalf("NewConnection() error = %v", err)
+	}
+	defer db.Close()
+	if err := factory.HealthCheck(db); err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+
+	migrator := NewMigrator(db, DriverSQLite, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	migrations, err := migrator.migrations()
+	if err != nil {
+		t.Fatalf("migrations() error = %v", err)
+	}
+	applied, err := migrator.Applied()
+	if err != nil {
+		t.Fatalf("Applied() error = %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("applied %d migrations, want %d", len(applied), len(migrations))
+	}
+
+	// The seeded dev user exists and foreign keys are enforced
+	var devUsers int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users WHERE email = ?", "dev@conflux.local").Scan(&devUsers); err != nil {
+		t.Fatalf("failed to query users: %v", err)
+	}
+	if devUsers != 1 {
+		t.Errorf("dev users = %d, want 1", devUsers)
+	}
+	if _, err := db.Exec("INSERT INTO sessions (user_id, token, expires_at) VALUES (999, 't', CURRENT_TIMESTAMP)"); err == nil {
+		t.Error("inserting a session for a missing user succeeded, want a foreign key error")
+	}
+
+	// Every migration rolls back cleanly, and the schema can be rebuilt
+	for range migrations {
+		if err := migrator.Down(); err != nil {
+			t.Fatalf("Down() error = %v", err)
+		}
+	}
+	if applied, _ := migrator.Applied(); len(applied) != 0 {
+		t.Fatalf("applied after rolling back everything = %v, want none", applied)
+	}
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("Up() after rolling back error = %v", err)
+	}
+}
+
+func TestConnectionFactory_NewConnectionWithRetry(t *testing.T) {
+	factory := NewConnectionFactory(&config.Config{DBType: DriverSQLite, DBName: ":memory:"})
+	calls := 0
+	factory.connect = func(ctx context.Context) (*sql.DB, error) {
+		calls++
+		if calls < 3 {
+			return nil, fmt.Errorf("connection refused (attempt %d)", calls)
+		}
+		return factory.openAndPing(ctx)
+	}
+
+	db, err := factory.NewConnectionWithRetry(context.Background(), 5, time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewConnectionWithRetry() error = %v", err)
+	}
+	defer db.Close()
+	if calls != 3 {
+		t.Errorf("connect called %d times, want 3", calls)
+	}
+	if err := factory.HealthCheck(db); err != nil {
+		t.Errorf("HealthCheck() error = %v", err)
+	}
+}
+
+func TestConnectionFactory_NewConnectionWithRetry_ReturnsLastError(t *testing.T) {
+	factory := NewConnectionFactory(&config.Config{DBType: DriverSQLite, DBName: ":memory:"})
+	calls := 0
+	factory.connect = func(ctx context.Context) (*sql.DB, error) {
+		calls++
+		return nil, fmt.Errorf("connection refused (attempt %d)", calls)
+	}
+
+	_, err := factory.NewConnectionWithRetry(context.Background(), 3, time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "attempt 3") {
+		t.Errorf("NewConnectionWithRetry() error = %v, want the third attempt's error", err)
+	}
+	if calls != 3 {
+		t.Errorf("connect called %d times, want 3", calls)
+	}
+
+	// A cancelled context stops waiting between attempts
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = 0
+	if _, err := factory.NewConnectionWithRetry(ctx, 3, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("NewConnectionWithRetry() with a cancelled context error = %v, want context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Errorf("connect called %d times after cancellation, want 1", calls)
+	}
+}
+
+func TestConnectionFactory_NewConnection_PoolSettings(t *testing.T) {
+	factory := NewConnectionFactory(&config.Config{
+		DBType:            DriverSQLite,
+		DBName:            filepath.Join(t.TempDir(), "pool.db"),
+		DBMaxOpenConns:    7,
+		DBMaxIdleConns:    3,
+		DBConnMaxLifetime: time.Minute,
+	})
+	db, err := factory.NewConnection()
+	if err != nil {
+		t.Fatalf("NewConnection() error = %v", err)
+	}
+	defer db.Close()
+	if got := db.Stats().MaxOpenConnections; got != 7 {
+		t.Errorf("MaxOpenConnections = %d, want 7", got)
+	}
+
+	// :memory: keeps its single connection whatever the pool settings say
+	memory := NewConnectionFactory(&config.Config{DBType: DriverSQLite, DBName: ":memory:", DBMaxOpenConns: 7})
+	memDB, err := memory.NewConnection()
+	if err != nil {
+		t.Fatalf("NewConnection() error = %v", err)
+	}
+	defer memDB.Close()
+	if got := memDB.Stats().MaxOpenConnections; got != 1 {
+		t.Errorf("in-memory MaxOpenConnections = %d, want 1", got)
+	}
+}
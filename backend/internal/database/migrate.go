@@ -1,55 +1,328 @@
 // Database migration management system
-// Handles schema migrations for both MySQL and PostgreSQL
+// Handles schema migrations for MySQL, PostgreSQL, and SQLite
 // Ensures database schema is up-to-date on application startup
 package database
 
 import (
+	"context"
 	"database/sql"
+	"embed"
 	"fmt"
-	"log"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// migrationFiles holds each database type's migrations as
+// migrations/{type}/NNN_name.up.sql and, to make it reversible, NNN_name.down.sql
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationFileName matches a migration file; the version is NNN_name
+var migrationFileName = regexp.MustCompile(`^((\d+)_\w+)\.(up|down)\.sql$`)
+
+const (
+	// migrationLockName is the MySQL GET_LOCK name guarding migrations
+	migrationLockName = "conflux_migrations"
+
+	// migrationLockID is the PostgreSQL advisory lock key guarding migrations
+	migrationLockID int64 = 0x636f6e666c7578 // "conflux"
+
+	// migrationLockTimeout bounds how long an instance waits for another's migrations
+	migrationLockTimeout = 5 * time.Minute
+)
+
+// migration is one schema change and the SQL that reverts it
+type migration struct {
+	version   string
+	query     string
+	downQuery string
+}
+
 // Migrator handles database schema migrations
 type Migrator struct {
 	db     *sql.DB
 	dbType string
+	logger *slog.Logger
+	files  fs.FS // Migration files, one directory per database type
 }
 
 // NewMigrator creates a new migration manager
-func NewMigrator(db *sql.DB, dbType string) *Migrator {
+func NewMigrator(db *sql.DB, dbType string, logger *slog.Logger) *Migrator {
 	return &Migrator{
 		db:     db,
 		dbType: dbType,
+		logger: logger,
+		files:  migrationFiles,
 	}
 }
 
 // Up runs all pending migrations
+// An advisory lock serializes concurrent runs, so when several instances start
+// together one migrates while the others wait and then find nothing to apply
 func (m *Migrator) Up() error {
-	log.Println("Running database migrations...")
+	if !supportedMigrationType(m.dbType) {
+		return fmt.Errorf("unsupported database type: %s", m.dbType)
+	}
+
+	m.logger.Info("Running database migrations")
+
+	ctx := context.Background()
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer unlock()
 
 	// Create migrations table if it doesn't exist
 	if err := m.createMigrationsTable(); err != nil {
 		return fmt.Errorf("failed to create migrations table: %w", err)
 	}
 
-	// Run migrations based on database type
-	switch m.dbType {
-	case "mysql":
-		return m.runMySQLMigrations()
-	case "postgres":
-		return m.runPostgreSQLMigrations()
-	default:
-		return fmt.Errorf("unsupported database type: %s", m.dbType)
+	migrations, err := m.migrations()
+	if err != nil {
+		return err
 	}
+	return m.runMigrations(migrations)
 }
 
-// Down rolls back the last migration
+// Down rolls back the most recently applied migration
+// The rollback SQL and the removal of its tracking row share a transaction;
+// MySQL commits DDL implicitly, so there only the row removal is transactional.
+// Rolling back a database with no applied migrations does nothing
 func (m *Migrator) Down() error {
-	log.Println("Rolling back last migration...")
-	// Implementation for rollback would go here
+	if !supportedMigrationType(m.dbType) {
+		return fmt.Errorf("unsupported database type: %s", m.dbType)
+	}
+
+	ctx := context.Background()
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer unlock()
+
+	if err := m.createMigrationsTable(); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	var version string
+	err = m.db.QueryRowContext(ctx, "SELECT version FROM migrations ORDER BY id DESC LIMIT 1").Scan(&version)
+	if err == sql.ErrNoRows {
+		m.logger.Info("No migrations to roll back")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find last migration: %w", err)
+	}
+
+	migrations, err := m.migrations()
+	if err != nil {
+		return err
+	}
+
+	var target *migration
+	for _, candidate := range migrations {
+		if candidate.version == version {
+			target = &candidate
+			break
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("unknown migration %s", version)
+	}
+	if target.downQuery == "" {
+		return fmt.Errorf("migration %s can't be rolled back", version)
+	}
+
+	m.logger.Info("Rolling back migration", "version", version)
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to roll back migration %s: %w", version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, target.downQuery); err != nil {
+		return fmt.Errorf("failed to roll back migration %s: %w", version, err)
+	}
+
+	deleteQuery := m.bind("DELETE FROM migrations WHERE version = ?")
+	if _, err := tx.ExecContext(ctx, deleteQuery, version); err != nil {
+		return fmt.Errorf("failed to remove migration record %s: %w", version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to roll back migration %s: %w", version, err)
+	}
+
+	m.logger.Info("Successfully rolled back migration", "version", version)
 	return nil
 }
 
+// Applied returns the versions recorded in the migrations table, in the order they were applied
+// A database that has never been migrated has none
+func (m *Migrator) Applied() ([]string, error) {
+	if err := m.createMigrationsTable(); err != nil {
+		return nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	rows, err := m.db.Query("SELECT version FROM migrations ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []string
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to list migrations: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+// migrations loads the database type's migrations, ordered by their numeric prefix
+func (m *Migrator) migrations() ([]migration, error) {
+	dir := path.Join("migrations", m.dbType)
+	entries, err := fs.ReadDir(m.files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[string]*migration)
+	order := make(map[string]int)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+		version, direction := match[1], match[3]
+		number, err := strconv.Atoi(match[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+
+		content, err := fs.ReadFile(m.files, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		current := byVersion[version]
+		if current == nil {
+			current = &migration{version: version}
+			byVersion[version] = current
+			order[version] = number
+		}
+		if direction == "up" {
+			current.query = string(content)
+		} else {
+			current.downQuery = string(content)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	numbers := make(map[int]string, len(byVersion))
+	for version, current := range byVersion {
+		if current.query == "" {
+			return nil, fmt.Errorf("migration %s has no up file", version)
+		}
+		if other, ok := numbers[order[version]]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share a number", other, version)
+		}
+		numbers[order[version]] = version
+		migrations = append(migrations, *current)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return order[migrations[i].version] < order[migrations[j].version]
+	})
+	return migrations, nil
+}
+
+// supportedMigrationType reports whether migrations exist for a database type
+func supportedMigrationType(dbType string) bool {
+	return dbType == "mysql" || dbType == "postgres" || dbType == "sqlite"
+}
+
+// bind rewrites a query's ? placeholders for the database type
+// PostgreSQL numbers its placeholders; MySQL and SQLite take ? as is
+func (m *Migrator) bind(query string) string {
+	if m.dbType != "postgres" {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// lock takes the database-wide migration lock and returns a func releasing it
+// Advisory locks belong to a session, so the lock is held on a dedicated
+// connection that stays checked out of the pool until released.
+// SQLite serializes writers itself and an in-memory database allows a single
+// connection, so there it takes no lock
+func (m *Migrator) lock(ctx context.Context) (func(), error) {
+	if m.dbType == "sqlite" {
+		return func() {}, nil
+	}
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, migrationLockTimeout)
+	defer cancel()
+
+	switch m.dbType {
+	case "mysql":
+		var acquired sql.NullInt64
+		err = conn.QueryRowContext(lockCtx, "SELECT GET_LOCK(?, ?)",
+			migrationLockName, int(migrationLockTimeout.Seconds())).Scan(&acquired)
+		if err == nil && acquired.Int64 != 1 {
+			err = fmt.Errorf("timed out waiting for lock %s", migrationLockName)
+		}
+	case "postgres":
+		_, err = conn.ExecContext(lockCtx, "SELECT pg_advisory_lock($1)", migrationLockID)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return func() {
+		var err error
+		switch m.dbType {
+		case "mysql":
+			_, err = conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", migrationLockName)
+		case "postgres":
+			_, err = conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockID)
+		}
+		if err != nil {
+			// Closing the session below releases the lock anyway
+			m.logger.Warn("Failed to release migration lock", "error", err)
+		}
+		conn.Close()
+	}, nil
+}
+
 // createMigrationsTable creates the migrations tracking table
 func (m *Migrator) createMigrationsTable() error {
 	var query string
@@ -69,176 +342,46 @@ func (m *Migrator) createMigrationsTable() error {
 				version VARCHAR(255) NOT NULL UNIQUE,
 				applied_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 			)`
+	case "sqlite":
+		query = `
+			CREATE TABLE IF NOT EXISTS migrations (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				version VARCHAR(255) NOT NULL UNIQUE,
+				applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			)`
 	}
 
 	_, err := m.db.Exec(query)
 	return err
 }
 
-// runMySQLMigrations runs MySQL-specific migrations
-func (m *Migrator) runMySQLMigrations() error {
-	migrations := []struct {
-		version string
-		query   string
-	}{
-		{
-			version: "001_create_users_table",
-			query: `
-				CREATE TABLE IF NOT EXISTS users (
-					id INT AUTO_INCREMENT PRIMARY KEY,
-					email VARCHAR(255) UNIQUE NOT NULL,
-					password_hash VARCHAR(255) NOT NULL,
-					first_name VARCHAR(100) NOT NULL,
-					last_name VARCHAR(100) NOT NULL,
-					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-					updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-					INDEX idx_email (email)
-				)`,
-		},
-		{
-			version: "002_create_sessions_table",
-			query: `
-				CREATE TABLE IF NOT EXISTS sessions (
-					id INT AUTO_INCREMENT PRIMARY KEY,
-					user_id INT NOT NULL,
-					token VARCHAR(500) NOT NULL UNIQUE,
-					expires_at TIMESTAMP NOT NULL,
-					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-					FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-					INDEX idx_token (token),
-					INDEX idx_user_id (user_id)
-				)`,
-		},
-		{
-			version: "004_seed_dev_user",
-			query: `
-				INSERT INTO users (email, password_hash, first_name, last_name, created_at, updated_at)
-				SELECT 
-					'dev@conflux.local',
-					'$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi',
-					'Dev',
-					'User',
-					NOW(),
-					NOW()
-				WHERE NOT EXISTS (
-					SELECT 1 FROM users WHERE email = 'dev@conflux.local'
-				)`,
-		},
-	}
-
-	return m.runMigrations(migrations)
-}
-
-// runPostgreSQLMigrations runs PostgreSQL-specific migrations
-func (m *Migrator) runPostgreSQLMigrations() error {
-	migrations := []struct {
-		version string
-		query   string
-	}{
-		{
-			version: "001_create_users_table",
-			query: `
-				CREATE TABLE IF NOT EXISTS users (
-					id SERIAL PRIMARY KEY,
-					email VARCHAR(255) UNIQUE NOT NULL,
-					password_hash VARCHAR(255) NOT NULL,
-					first_name VARCHAR(100) NOT NULL,
-					last_name VARCHAR(100) NOT NULL,
-					created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-					updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
-				);
-				
-				CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
-				
-				CREATE OR REPLACE FUNCTION update_updated_at_column()
-				RETURNS TRIGGER AS $$
-				BEGIN
-					NEW.updated_at = NOW();
-					RETURN NEW;
-				END;
-				$$ language 'plpgsql';
-				
-				DROP TRIGGER IF EXISTS update_users_updated_at ON users;
-				CREATE TRIGGER update_users_updated_at BEFORE UPDATE
-					ON users FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();`,
-		},
-		{
-			version: "002_create_sessions_table",
-			query: `
-				CREATE TABLE IF NOT EXISTS sessions (
-					id SERIAL PRIMARY KEY,
-					user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-					token VARCHAR(500) NOT NULL UNIQUE,
-					expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
-					created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
-				);
-				
-				CREATE INDEX IF NOT EXISTS idx_sessions_token ON sessions(token);
-				CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);`,
-		},
-		{
-			version: "004_seed_dev_user",
-			query: `
-				INSERT INTO users (email, password_hash, first_name, last_name, created_at, updated_at)
-				SELECT 
-					'dev@conflux.local',
-					'$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi',
-					'Dev',
-					'User',
-					NOW(),
-					NOW()
-				WHERE NOT EXISTS (
-					SELECT 1 FROM users WHERE email = 'dev@conflux.local'
-				)`,
-		},
-	}
-
-	return m.runMigrations(migrations)
-}
-
 // runMigrations executes a list of migrations
-func (m *Migrator) runMigrations(migrations []struct {
-	version string
-	query   string
-}) error {
+func (m *Migrator) runMigrations(migrations []migration) error {
 	for _, migration := range migrations {
 		// Check if migration already applied
 		var count int
-		err := m.db.QueryRow("SELECT COUNT(*) FROM migrations WHERE version = ?", migration.version).Scan(&count)
-		if err != nil && m.dbType == "postgres" {
-			// PostgreSQL uses $1 instead of ?
-			err = m.db.QueryRow("SELECT COUNT(*) FROM migrations WHERE version = $1", migration.version).Scan(&count)
-		}
+		err := m.db.QueryRow(m.bind("SELECT COUNT(*) FROM migrations WHERE version = ?"), migration.version).Scan(&count)
 		if err != nil {
 			return fmt.Errorf("failed to check migration status: %w", err)
 		}
 
 		if count > 0 {
-			log.Printf("Migration %s already applied, skipping", migration.version)
+			m.logger.Debug("Migration already applied, skipping", "version", migration.version)
 			continue
 		}
 
 		// Run migration
-		log.Printf("Applying migration: %s", migration.version)
+		m.logger.Info("Applying migration", "version", migration.version)
 		if _, execErr := m.db.Exec(migration.query); execErr != nil {
 			return fmt.Errorf("failed to apply migration %s: %w", migration.version, execErr)
 		}
 
 		// Record migration
-		var insertQuery string
-		if m.dbType == "mysql" {
-			insertQuery = "INSERT INTO migrations (version) VALUES (?)"
-			_, err = m.db.Exec(insertQuery, migration.version)
-		} else {
-			insertQuery = "INSERT INTO migrations (version) VALUES ($1)"
-			_, err = m.db.Exec(insertQuery, migration.version)
-		}
-
-		if err != nil {
+		if _, err := m.db.Exec(m.bind("INSERT INTO migrations (version) VALUES (?)"), migration.version); err != nil {
 			return fmt.Errorf("failed to record migration %s: %w", migration.version, err)
 		}
 
-		log.Printf("Successfully applied migration: %s", migration.version)
+		m.logger.Info("Successfully applied migration", "version", migration.version)
 	}
 
 	return nil
@@ -0,0 +1,345 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// memoryDB is a minimal in-memory database/sql backend for migration tests
+// It understands the migration bookkeeping queries and both advisory lock
+// dialects; the lock blocks like the real thing, so concurrent runs race
+type memoryDB struct {
+	lock chan struct{} // Held advisory lock, buffered to one holder
+
+	mu      sync.Mutex
+	applied map[string]bool // Versions recorded in the migrations table
+	order   []string        // Recorded versions, oldest first
+	runs    map[string]int  // Times each migration's DDL was executed
+	queries []string        // DDL statements in execution order
+	commits int             // Committed transactions
+}
+
+func newMemoryDB() *memoryDB {
+	return &memoryDB{
+		lock:    make(chan struct{}, 1),
+		applied: make(map[string]bool),
+		runs:    make(map[string]int),
+	}
+}
+
+func (d *memoryDB) Connect(context.Context) (driver.Conn, error) { return &memoryConn{db: d}, nil }
+func (d *memoryDB) Driver() driver.Driver                        { return nil }
+
+type memoryConn struct {
+	db *memoryDB
+}
+
+func (c *memoryConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *memoryConn) Close() error                        { return nil }
+func (c *memoryConn) Begin() (driver.Tx, error)           { return &memoryTx{db: c.db}, nil }
+
+// memoryTx applies statements immediately; it only counts commits
+type memoryTx struct {
+	db *memoryDB
+}
+
+func (t *memoryTx) Commit() error {
+	t.db.mu.Lock()
+	defer t.db.mu.Unlock()
+	t.db.commits++
+	return nil
+}
+
+func (t *memoryTx) Rollback() error { return nil }
+
+func (c *memoryConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	switch {
+	case strings.Contains(query, "pg_advisory_lock"):
+		select {
+		case c.db.lock <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	case strings.Contains(query, "pg_advisory_unlock"), strings.Contains(query, "RELEASE_LOCK"):
+		<-c.db.lock
+	case strings.HasPrefix(query, "INSERT INTO migrations"):
+		c.db.mu.Lock()
+		c.db.applied[args[0].Value.(string)] = true
+		c.db.order = append(c.db.order, args[0].Value.(string))
+		c.db.mu.Unlock()
+	case strings.HasPrefix(query, "DELETE FROM migrations"):
+		c.db.mu.Lock()
+		version := args[0].Value.(string)
+		delete(c.db.applied, version)
+		for i, applied := range c.db.order {
+			if applied == version {
+				c.db.order = append(c.db.order[:i], c.db.order[i+1:]...)
+				break
+			}
+		}
+		c.db.mu.Unlock()
+	default:
+		// Widen the window between checking and recording a migration
+		time.Sleep(time.Millisecond)
+		c.db.mu.Lock()
+		c.db.runs[query]++
+		c.db.queries = append(c.db.queries, query)
+		c.db.mu.Unlock()
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *memoryConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	switch {
+	case strings.Contains(query, "GET_LOCK"):
+		select {
+		case c.db.lock <- struct{}{}:
+			return &memoryRows{values: []driver.Value{int64(1)}}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	case strings.HasPrefix(query, "SELECT COUNT(*) FROM migrations"):
+		c.db.mu.Lock()
+		defer c.db.mu.Unlock()
+		count := int64(0)
+		if c.db.applied[args[0].Value.(string)] {
+			count = 1
+		}
+		return &memoryRows{values: []driver.Value{count}}, nil
+	case strings.HasPrefix(query, "SELECT version FROM migrations ORDER BY id DESC"):
+		c.db.mu.Lock()
+		defer c.db.mu.Unlock()
+		if len(c.db.order) == 0 {
+			return &memoryRows{}, nil
+		}
+		return &memoryRows{values: []driver.Value{c.db.order[len(c.db.order)-1]}}, nil
+	}
+	return nil, fmt.Errorf("unexpected query: %s", query)
+}
+
+// memoryRows is a single-row, single-column result, or no rows when values is nil
+type memoryRows struct {
+	values []driver.Value
+	done   bool
+}
+
+func (r *memoryRows) Columns() []string { return []string{"value"} }
+func (r *memoryRows) Close() error      { return nil }
+
+func (r *memoryRows) Next(dest []driver.Value) error {
+	if r.done || r.values == nil {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
+
+func TestMigrator_Up_ConcurrentInstances(t *testing.T) {
+	for _, dbType := range []string{"postgres", "mysql"} {
+		t.Run(dbType, func(t *testing.T) {
+			memory := newMemoryDB()
+			db := sql.OpenDB(memory)
+			defer db.Close()
+
+			const instances = 4
+			var wg sync.WaitGroup
+			errs := make(chan error, instances)
+			for i := 0; i < instances; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs <- NewMigrator(db, dbType, slog.New(slog.NewTextHandler(io.Discard, nil))).Up()
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				if err != nil {
+					t.Fatalf("Up() error = %v", err)
+				}
+			}
+
+			if len(memory.applied) == 0 {
+				t.Fatal("no migrations were recorded")
+			}
+			for query, runs := range memory.runs {
+				isTrackingTable := strings.Contains(query, "CREATE TABLE IF NOT EXISTS migrations")
+				if !isTrackingTable && runs != 1 {
+					t.Errorf("migration ran %d times, want once:\n%s", runs, query)
+				}
+			}
+			if n := len(memory.queries) - instances; n != len(memory.applied) {
+				t.Errorf("executed %d migrations, recorded %d", n, len(memory.applied))
+			}
+			if len(memory.lock) != 0 {
+				t.Error("migration lock was not released")
+			}
+		})
+	}
+}
+
+func TestMigrator_Up_SkipsLoggedAtDebug(t *testing.T) {
+	db := sql.OpenDB(newMemoryDB())
+	defer db.Close()
+
+	if err := NewMigrator(db, "postgres", slog.New(slog.NewTextHandler(io.Discard, nil))).Up(); err != nil {
+		t.Fatalf("first Up() error = %v", err)
+	}
+
+	for _, tt := range []struct {
+		level     slog.Level
+		wantSkips bool
+	}{
+		{level: slog.LevelInfo, wantSkips: false},
+		{level: slog.LevelDebug, wantSkips: true},
+	} {
+		var buf strings.Builder
+		logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: tt.level}))
+		if err := NewMigrator(db, "postgres", logger).Up(); err != nil {
+			t.Fatalf("Up() error = %v", err)
+		}
+
+		if got := strings.Contains(buf.String(), "already applied"); got != tt.wantSkips {
+			t.Errorf("at %v: skip lines logged = %v, want %v", tt.level, got, tt.wantSkips)
+		}
+		if strings.Contains(buf.String(), "Applying migration") {
+			t.Errorf("at %v: migrations re-applied", tt.level)
+		}
+	}
+}
+
+func TestMigrator_Down_RollsBackSessionsTable(t *testing.T) {
+	for _, dbType := range []string{"postgres", "mysql"} {
+		t.Run(dbType, func(t *testing.T) {
+			memory := newMemoryDB()
+			db := sql.OpenDB(memory)
+			defer db.Close()
+
+			migrator := NewMigrator(db, dbType, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if err := migrator.createMigrationsTable(); err != nil {
+				t.Fatalf("createMigrationsTable() error = %v", err)
+			}
+			migrations, err := migrator.migrations()
+			if err != nil {
+				t.Fatalf("migrations() error = %v", err)
+			}
+			// Apply up to and including the sessions table
+			if err := migrator.runMigrations(migrations[:2]); err != nil {
+				t.Fatalf("runMigrations() error = %v", err)
+			}
+			if !memory.applied["002_create_sessions_table"] {
+				t.Fatal("sessions migration was not recorded")
+			}
+
+			if err := migrator.Down(); err != nil {
+				t.Fatalf("Down() error = %v", err)
+			}
+
+			if memory.applied["002_create_sessions_table"] {
+				t.Error("sessions migration is still recorded after Down()")
+			}
+			if !memory.applied["001_create_users_table"] {
+				t.Error("Down() removed more than the last migration")
+			}
+			if last := memory.queries[len(memory.queries)-1]; !strings.Contains(last, "DROP TABLE IF EXISTS sessions") {
+				t.Errorf("last statement = %q, want the sessions table dropped", last)
+			}
+			if memory.commits != 1 {
+				t.Errorf("commits = %d, want the rollback committed once", memory.commits)
+			}
+			if len(memory.lock) != 0 {
+				t.Error("migration lock was not released")
+			}
+		})
+	}
+}
+
+func TestMigrator_Down_NothingApplied(t *testing.T) {
+	memory := newMemoryDB()
+	db := sql.OpenDB(memory)
+	defer db.Close()
+
+	if err := NewMigrator(db, "postgres", slog.New(slog.NewTextHandler(io.Discard, nil))).Down(); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	if memory.commits != 0 {
+		t.Errorf("commits = %d, want none", memory.commits)
+	}
+}
+
+func TestMigrations_AllReversible(t *testing.T) {
+	for _, dbType := range []string{"postgres", "mysql", "sqlite"} {
+		migrations, err := NewMigrator(nil, dbType, slog.New(slog.NewTextHandler(io.Discard, nil))).migrations()
+		if err != nil {
+			t.Fatalf("%s migrations() error = %v", dbType, err)
+		}
+		if len(migrations) == 0 {
+			t.Fatalf("no %s migrations were embedded", dbType)
+		}
+		for _, m := range migrations {
+			if strings.TrimSpace(m.downQuery) == "" {
+				t.Errorf("%s migration %s has no down query", dbType, m.version)
+			}
+		}
+	}
+}
+
+func TestMigrator_Up_AppliesFilesInNumericOrder(t *testing.T) {
+	memory := newMemoryDB()
+	db := sql.OpenDB(memory)
+	defer db.Close()
+
+	migrator := NewMigrator(db, "postgres", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	migrator.files = fstest.MapFS{
+		// Numeric order differs from name order
+		"migrations/postgres/10_third.up.sql":    {Data: []byte("CREATE TABLE third (id INT)")},
+		"migrations/postgres/9_second.up.sql":    {Data: []byte("CREATE TABLE second (id INT)")},
+		"migrations/postgres/001_first.up.sql":   {Data: []byte("CREATE TABLE first (id INT)")},
+		"migrations/postgres/001_first.down.sql": {Data: []byte("DROP TABLE first")},
+		"migrations/postgres/README.md":          {Data: []byte("not a migration")},
+		"migrations/mysql/002_other.up.sql":      {Data: []byte("CREATE TABLE other (id INT)")},
+	}
+
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	wantVersions := []string{"001_first", "9_second", "10_third"}
+	if strings.Join(memory.order, ",") != strings.Join(wantVersions, ",") {
+		t.Errorf("applied %v, want %v", memory.order, wantVersions)
+	}
+	// The first query creates the tracking table
+	wantQueries := []string{"CREATE TABLE first (id INT)", "CREATE TABLE second (id INT)", "CREATE TABLE third (id INT)"}
+	if got := memory.queries[1:]; strings.Join(got, ";") != strings.Join(wantQueries, ";") {
+		t.Errorf("executed %q, want %q", got, wantQueries)
+	}
+}
+
+func TestMigrator_Migrations_InvalidFiles(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"bad name":       {"migrations/postgres/first.up.sql": {Data: []byte("SELECT 1")}},
+		"missing up":     {"migrations/postgres/001_first.down.sql": {Data: []byte("SELECT 1")}},
+		"shared number":  {"migrations/postgres/001_a.up.sql": {Data: []byte("SELECT 1")}, "migrations/postgres/1_b.up.sql": {Data: []byte("SELECT 1")}},
+		"missing folder": {"migrations/mysql/001_a.up.sql": {Data: []byte("SELECT 1")}},
+	}
+	for name, files := range tests {
+		t.Run(name, func(t *testing.T) {
+			migrator := NewMigrator(nil, "postgres", slog.New(slog.NewTextHandler(io.Discard, nil)))
+			migrator.files = files
+			if migrations, err := migrator.migrations(); err == nil {
+				t.Errorf("migrations() = %+v, want error", migrations)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS sessions (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	user_id INT NOT NULL,
+	token VARCHAR(500) NOT NULL UNIQUE,
+	expires_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	INDEX idx_token (token),
+	INDEX idx_user_id (user_id)
+)
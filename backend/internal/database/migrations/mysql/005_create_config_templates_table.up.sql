@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS config_templates (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	display_name VARCHAR(255) NOT NULL DEFAULT '',
+	description TEXT NOT NULL,
+	version VARCHAR(50) NOT NULL,
+	category VARCHAR(100) NOT NULL DEFAULT '',
+	format VARCHAR(20) NOT NULL,
+	supported_formats JSON NULL,
+	default_content MEDIUMTEXT NOT NULL,
+	`schema` MEDIUMTEXT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	INDEX idx_config_templates_category (category)
+)
@@ -0,0 +1,21 @@
+-- name_key holds the lowercased name only while unique names are enforced
+CREATE TABLE IF NOT EXISTS user_configs (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	user_id INT NOT NULL,
+	template_id INT NULL,
+	name VARCHAR(255) NOT NULL,
+	name_key VARCHAR(255) NULL,
+	template_version VARCHAR(50) NOT NULL DEFAULT '',
+	description TEXT NOT NULL,
+	format VARCHAR(20) NOT NULL,
+	content MEDIUMTEXT NOT NULL,
+	is_shared BOOLEAN NOT NULL DEFAULT FALSE,
+	forked_from INT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (template_id) REFERENCES config_templates(id) ON DELETE SET NULL,
+	INDEX idx_user_configs_user_id (user_id),
+	INDEX idx_user_configs_template_id (template_id),
+	UNIQUE INDEX idx_user_configs_user_name_key (user_id, name_key)
+)
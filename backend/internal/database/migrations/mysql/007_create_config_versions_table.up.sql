@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS config_versions (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	config_id INT NOT NULL,
+	version INT NOT NULL,
+	content MEDIUMTEXT NOT NULL,
+	change_note TEXT NOT NULL,
+	restored_from INT NULL,
+	change_set JSON NULL,
+	created_by INT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (config_id) REFERENCES user_configs(id) ON DELETE CASCADE,
+	INDEX idx_config_versions_config_id (config_id),
+	UNIQUE INDEX idx_config_versions_config_version (config_id, version)
+)
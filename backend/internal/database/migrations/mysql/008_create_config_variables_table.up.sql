@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS config_variables (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	template_id INT NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	path VARCHAR(255) NOT NULL,
+	type VARCHAR(20) NOT NULL DEFAULT 'string',
+	description TEXT NOT NULL,
+	default_value TEXT NULL,
+	required BOOLEAN NOT NULL DEFAULT FALSE,
+	validation_rule VARCHAR(500) NULL,
+	FOREIGN KEY (template_id) REFERENCES config_templates(id) ON DELETE CASCADE,
+	INDEX idx_config_variables_template_id (template_id)
+)
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS config_imports (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	user_id INT NOT NULL,
+	source_type VARCHAR(20) NOT NULL,
+	source_url VARCHAR(2048) NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'pending',
+	error_message TEXT NULL,
+	source_failed BOOLEAN NOT NULL DEFAULT FALSE,
+	config_id INT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	retry_after TIMESTAMP NULL,
+	dedupe BOOLEAN NOT NULL DEFAULT FALSE,
+	dedup_result VARCHAR(20) NOT NULL DEFAULT '',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	completed_at TIMESTAMP NULL,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (config_id) REFERENCES user_configs(id) ON DELETE SET NULL,
+	INDEX idx_config_imports_user_id (user_id),
+	INDEX idx_config_imports_status_created_at (status, created_at)
+)
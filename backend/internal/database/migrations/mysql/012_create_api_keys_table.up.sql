@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	user_id INT NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	key_hash VARCHAR(64) NOT NULL UNIQUE,
+	permissions JSON NOT NULL,
+	last_used_at TIMESTAMP NULL,
+	expires_at TIMESTAMP NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	is_active BOOLEAN NOT NULL DEFAULT TRUE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	INDEX idx_api_keys_user_id (user_id)
+)
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	actor_id INT NOT NULL,
+	action VARCHAR(100) NOT NULL,
+	target_user_id INT NULL,
+	ip_address VARCHAR(45) NOT NULL DEFAULT '',
+	details TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX idx_audit_log_actor_id (actor_id),
+	INDEX idx_audit_log_target_user_id (target_user_id),
+	INDEX idx_audit_log_created_at (created_at)
+)
@@ -0,0 +1,3 @@
+ALTER TABLE audit_log
+	DROP COLUMN target_config_id,
+	DROP COLUMN target_name
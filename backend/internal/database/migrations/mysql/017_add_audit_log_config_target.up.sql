@@ -0,0 +1,3 @@
+ALTER TABLE audit_log
+	ADD COLUMN target_config_id INT NULL,
+	ADD COLUMN target_name VARCHAR(255) NOT NULL DEFAULT ''
@@ -0,0 +1,3 @@
+ALTER TABLE sessions
+	DROP INDEX idx_sessions_session_id,
+	DROP COLUMN session_id
@@ -0,0 +1,4 @@
+-- Existing sessions keep a NULL session ID and are still matched by token
+ALTER TABLE sessions
+	ADD COLUMN session_id VARCHAR(128) NULL,
+	ADD UNIQUE INDEX idx_sessions_session_id (session_id)
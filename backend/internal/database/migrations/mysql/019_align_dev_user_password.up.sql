@@ -0,0 +1,4 @@
+-- 004 seeded the dev user with a different password than POST /dev/user creates
+-- and documents; only an untouched seeded hash is replaced
+UPDATE users SET password_hash = '$2a$10$NXAF9OMExtH8E34GDQWxJeDgBFFdQgMIeaEC18rgtAsYrGkXkWYcO'
+WHERE email = 'dev@conflux.local' AND password_hash = '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi'
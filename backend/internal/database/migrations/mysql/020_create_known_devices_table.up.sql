@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS known_devices (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	user_id INT NOT NULL,
+	fingerprint CHAR(64) NOT NULL,
+	first_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	UNIQUE INDEX idx_known_devices_user_fingerprint (user_id, fingerprint)
+)
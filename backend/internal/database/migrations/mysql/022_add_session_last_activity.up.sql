@@ -0,0 +1,2 @@
+-- Existing sessions count as active from the time of the upgrade
+				ALTER TABLE sessions ADD COLUMN last_activity TIMESTAMP DEFAULT CURRENT_TIMESTAMP
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS template_versions (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	template_id INT NOT NULL,
+	version VARCHAR(50) NOT NULL,
+	previous_version VARCHAR(50) NOT NULL,
+	bump VARCHAR(10) NOT NULL DEFAULT '',
+	changes JSON NOT NULL,
+	previous_content MEDIUMTEXT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (template_id) REFERENCES config_templates(id) ON DELETE CASCADE,
+	INDEX idx_template_versions_template_id (template_id)
+)
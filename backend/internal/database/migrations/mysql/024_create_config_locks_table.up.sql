@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS config_locks (
+	config_id INT PRIMARY KEY,
+	holder_id INT NOT NULL,
+	acquired_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	expires_at TIMESTAMP NOT NULL,
+	FOREIGN KEY (config_id) REFERENCES user_configs(id) ON DELETE CASCADE,
+	FOREIGN KEY (holder_id) REFERENCES users(id) ON DELETE CASCADE
+)
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS config_shares (
+	config_id INT NOT NULL,
+	user_id INT NOT NULL,
+	permission VARCHAR(10) NOT NULL,
+	shared_by INT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (config_id, user_id),
+	FOREIGN KEY (config_id) REFERENCES user_configs(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	INDEX idx_config_shares_user_id (user_id)
+)
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS import_tokens (
+	user_id INT NOT NULL,
+	source_type VARCHAR(20) NOT NULL,
+	token TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	PRIMARY KEY (user_id, source_type),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+)
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS config_templates (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	display_name VARCHAR(255) NOT NULL DEFAULT '',
+	description TEXT NOT NULL DEFAULT '',
+	version VARCHAR(50) NOT NULL,
+	category VARCHAR(100) NOT NULL DEFAULT '',
+	format VARCHAR(20) NOT NULL,
+	supported_formats JSONB,
+	default_content TEXT NOT NULL,
+	schema TEXT,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_config_templates_category ON config_templates(category);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS config_versions (
+	id SERIAL PRIMARY KEY,
+	config_id INTEGER NOT NULL REFERENCES user_configs(id) ON DELETE CASCADE,
+	version INTEGER NOT NULL,
+	content TEXT NOT NULL,
+	change_note TEXT NOT NULL DEFAULT '',
+	restored_from INTEGER,
+	change_set JSONB,
+	created_by INTEGER NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	UNIQUE (config_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_config_versions_config_id ON config_versions(config_id);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS config_imports (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	source_type VARCHAR(20) NOT NULL,
+	source_url VARCHAR(2048) NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'pending',
+	error_message TEXT,
+	source_failed BOOLEAN NOT NULL DEFAULT FALSE,
+	config_id INTEGER REFERENCES user_configs(id) ON DELETE SET NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	retry_after TIMESTAMP WITH TIME ZONE,
+	dedupe BOOLEAN NOT NULL DEFAULT FALSE,
+	dedup_result VARCHAR(20) NOT NULL DEFAULT '',
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_config_imports_user_id ON config_imports(user_id);
+CREATE INDEX IF NOT EXISTS idx_config_imports_status_created_at ON config_imports(status, created_at);
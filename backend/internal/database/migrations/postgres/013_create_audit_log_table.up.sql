@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+	id SERIAL PRIMARY KEY,
+	actor_id INTEGER NOT NULL,
+	action VARCHAR(100) NOT NULL,
+	target_user_id INTEGER,
+	ip_address VARCHAR(45) NOT NULL DEFAULT '',
+	details TEXT,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor_id ON audit_log(actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target_user_id ON audit_log(target_user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
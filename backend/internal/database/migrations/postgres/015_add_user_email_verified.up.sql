@@ -0,0 +1,2 @@
+-- Existing accounts predate verification and are treated as verified
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT TRUE
@@ -0,0 +1,3 @@
+ALTER TABLE audit_log
+	DROP COLUMN IF EXISTS target_config_id,
+	DROP COLUMN IF EXISTS target_name
@@ -0,0 +1,3 @@
+ALTER TABLE audit_log
+	ADD COLUMN IF NOT EXISTS target_config_id INTEGER,
+	ADD COLUMN IF NOT EXISTS target_name VARCHAR(255) NOT NULL DEFAULT ''
@@ -0,0 +1,4 @@
+-- Existing sessions keep a NULL session ID and are still matched by token
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS session_id VARCHAR(128);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_session_id ON sessions(session_id);
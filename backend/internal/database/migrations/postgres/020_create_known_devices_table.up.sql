@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS known_devices (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	fingerprint CHAR(64) NOT NULL,
+	first_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	UNIQUE (user_id, fingerprint)
+)
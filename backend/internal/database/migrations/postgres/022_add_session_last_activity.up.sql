@@ -0,0 +1,2 @@
+-- Existing sessions count as active from the time of the upgrade
+				ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_activity TIMESTAMP WITH TIME ZONE DEFAULT NOW()
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS template_versions (
+	id SERIAL PRIMARY KEY,
+	template_id INTEGER NOT NULL REFERENCES config_templates(id) ON DELETE CASCADE,
+	version VARCHAR(50) NOT NULL,
+	previous_version VARCHAR(50) NOT NULL,
+	bump VARCHAR(10) NOT NULL DEFAULT '',
+	changes JSONB NOT NULL DEFAULT '[]',
+	previous_content TEXT,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_template_versions_template_id ON template_versions(template_id);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS config_templates (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name VARCHAR(100) NOT NULL,
+	display_name VARCHAR(255) NOT NULL DEFAULT '',
+	description TEXT NOT NULL DEFAULT '',
+	version VARCHAR(50) NOT NULL,
+	category VARCHAR(100) NOT NULL DEFAULT '',
+	format VARCHAR(20) NOT NULL,
+	supported_formats TEXT,
+	default_content TEXT NOT NULL,
+	schema TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_config_templates_category ON config_templates(category);
@@ -0,0 +1,20 @@
+-- name_key holds the lowercased name only while unique names are enforced
+CREATE TABLE IF NOT EXISTS user_configs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	template_id INTEGER REFERENCES config_templates(id) ON DELETE SET NULL,
+	name VARCHAR(255) NOT NULL,
+	name_key VARCHAR(255),
+	template_version VARCHAR(50) NOT NULL DEFAULT '',
+	description TEXT NOT NULL DEFAULT '',
+	format VARCHAR(20) NOT NULL,
+	content TEXT NOT NULL,
+	is_shared BOOLEAN NOT NULL DEFAULT FALSE,
+	forked_from INTEGER,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (user_id, name_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_configs_user_id ON user_configs(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_configs_template_id ON user_configs(template_id);
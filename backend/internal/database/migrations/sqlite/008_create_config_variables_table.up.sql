@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS config_variables (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	template_id INTEGER NOT NULL REFERENCES config_templates(id) ON DELETE CASCADE,
+	name VARCHAR(100) NOT NULL,
+	path VARCHAR(255) NOT NULL,
+	type VARCHAR(20) NOT NULL DEFAULT 'string',
+	description TEXT NOT NULL DEFAULT '',
+	default_value TEXT,
+	required BOOLEAN NOT NULL DEFAULT FALSE,
+	validation_rule VARCHAR(500)
+);
+
+CREATE INDEX IF NOT EXISTS idx_config_variables_template_id ON config_variables(template_id);
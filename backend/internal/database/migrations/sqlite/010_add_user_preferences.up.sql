@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN preferences TEXT NOT NULL DEFAULT '{}'
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS email_changes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	new_email VARCHAR(255) NOT NULL,
+	token_hash VARCHAR(64) NOT NULL UNIQUE,
+	expires_at DATETIME NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_changes_user_id ON email_changes(user_id);
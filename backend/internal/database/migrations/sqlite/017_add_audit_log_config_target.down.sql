@@ -0,0 +1,2 @@
+ALTER TABLE audit_log DROP COLUMN target_config_id;
+ALTER TABLE audit_log DROP COLUMN target_name;
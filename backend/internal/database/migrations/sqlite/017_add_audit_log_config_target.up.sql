@@ -0,0 +1,3 @@
+-- SQLite adds one column per statement
+ALTER TABLE audit_log ADD COLUMN target_config_id INTEGER;
+ALTER TABLE audit_log ADD COLUMN target_name VARCHAR(255) NOT NULL DEFAULT '';
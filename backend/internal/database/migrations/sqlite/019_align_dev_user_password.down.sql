@@ -0,0 +1,2 @@
+UPDATE users SET password_hash = '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi'
+WHERE email = 'dev@conflux.local' AND password_hash = '$2a$10$NXAF9OMExtH8E34GDQWxJeDgBFFdQgMIeaEC18rgtAsYrGkXkWYcO'
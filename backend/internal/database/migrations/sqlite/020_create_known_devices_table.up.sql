@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS known_devices (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	fingerprint CHAR(64) NOT NULL,
+	first_seen_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	last_seen_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (user_id, fingerprint)
+)
@@ -0,0 +1,2 @@
+-- Users created by an admin import get a temporary password to replace
+ALTER TABLE users ADD COLUMN password_reset_required BOOLEAN NOT NULL DEFAULT FALSE
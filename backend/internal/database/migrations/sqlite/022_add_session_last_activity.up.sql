@@ -0,0 +1,5 @@
+-- SQLite can't add a column defaulting to CURRENT_TIMESTAMP, so the repository
+-- sets last_activity on insert; existing sessions count as active from the upgrade
+ALTER TABLE sessions ADD COLUMN last_activity DATETIME;
+
+UPDATE sessions SET last_activity = CURRENT_TIMESTAMP;
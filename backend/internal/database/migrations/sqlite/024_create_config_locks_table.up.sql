@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS config_locks (
+	config_id INTEGER PRIMARY KEY REFERENCES user_configs(id) ON DELETE CASCADE,
+	holder_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	acquired_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	expires_at DATETIME NOT NULL
+)
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS config_shares (
+	config_id INTEGER NOT NULL REFERENCES user_configs(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	permission VARCHAR(10) NOT NULL,
+	shared_by INTEGER NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (config_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_config_shares_user_id ON config_shares(user_id);
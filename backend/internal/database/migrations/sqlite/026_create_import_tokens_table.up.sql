@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS import_tokens (
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	source_type VARCHAR(20) NOT NULL,
+	token TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (user_id, source_type)
+)
@@ -0,0 +1,37 @@
+// Audit log data models
+// Records security-relevant actions for incident response and compliance
+// Entries are append-only and never modified after creation
+package models
+
+import (
+	"time"
+)
+
+// Audit actions
+const (
+	AuditAPIKeysRotated = "api_keys.rotated"
+	AuditEmailChanged   = "user.email_changed"
+	AuditSessionsPurged = "sessions.purged"
+	AuditSessionCreated = "sessions.created"
+	AuditConfigCreated  = "config.created"
+	AuditConfigUpdated  = "config.updated"
+	AuditConfigRestored = "config.restored"
+	AuditConfigImported = "config.imported"
+	AuditConfigShared   = "config.shared"
+)
+
+// AuditConfigActionPrefix matches every configuration action, for activity feeds
+const AuditConfigActionPrefix = "config."
+
+// AuditEntry represents a single recorded action
+type AuditEntry struct {
+	ID             int       `json:"id" db:"id"`
+	ActorID        int       `json:"actor_id" db:"actor_id"`                           // User who performed the action
+	Action         string    `json:"action" db:"action"`                               // e.g., "api_keys.rotated"
+	TargetUserID   *int      `json:"target_user_id,omitempty" db:"target_user_id"`     // User affected, if any
+	TargetConfigID *int      `json:"target_config_id,omitempty" db:"target_config_id"` // Configuration affected, if any
+	TargetName     string    `json:"target_name,omitempty" db:"target_name"`           // Name of the target when recorded
+	IPAddress      string    `json:"ip_address" db:"ip_address"`
+	Details        string    `json:"details" db:"details"` // Human-readable summary
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
@@ -47,10 +47,15 @@ type AuthResponse struct {
 }
 
 // Session represents a user session
+// SessionID is the opaque identifier carried in the token's jti claim;
+// sessions created before it existed have none and are matched by token
 type Session struct {
-	ID        int       `json:"id" db:"id"`
-	UserID    int       `json:"user_id" db:"user_id"`
-	Token     string    `json:"token" db:"token"`
-	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID           int       `json:"id" db:"id"`
+	UserID       int       `json:"user_id" db:"user_id"`
+	SessionID    string    `json:"session_id" db:"session_id"`
+	Token        string    `json:"-" db:"token"`
+	ExpiresAt    time.Time `json:"expires_at" db:"expires_at"`
+	LastActivity time.Time `json:"last_activity" db:"last_activity"` // Last time the session was validated
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	Current      bool      `json:"current" db:"-"` // Session of the token making the request
 }
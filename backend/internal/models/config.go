@@ -4,6 +4,9 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -15,6 +18,9 @@ const (
 	FormatJSON ConfigFormat = "json"
 	FormatTOML ConfigFormat = "toml"
 	FormatENV  ConfigFormat = "env"
+	FormatINI  ConfigFormat = "ini"
+	// FormatJSONC is JSON with comments and trailing commas; it serializes as standard JSON
+	FormatJSONC ConfigFormat = "jsonc"
 )
 
 // ConfigTemplate represents a default configuration template for an application
@@ -32,6 +38,56 @@ type ConfigTemplate struct {
 	Variables        []ConfigVariable `json:"variables" db:"-"`             // Template variables
 	CreatedAt        time.Time        `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time        `json:"updated_at" db:"updated_at"`
+
+	// Non-blocking advisories about the default content, e.g. a likely format mislabel; not stored
+	Warnings []string `json:"warnings,omitempty" db:"-"`
+}
+
+// VersionBump names the semantic version component a template change increments
+type VersionBump string
+
+const (
+	BumpNone  VersionBump = ""
+	BumpPatch VersionBump = "patch"
+	BumpMinor VersionBump = "minor"
+	BumpMajor VersionBump = "major"
+)
+
+// TemplateVersion records one automatic version bump of a template
+type TemplateVersion struct {
+	ID              int         `json:"id" db:"id"`
+	TemplateID      int         `json:"template_id" db:"template_id"`
+	Version         string      `json:"version" db:"version"`                   // Version after the change
+	PreviousVersion string      `json:"previous_version" db:"previous_version"` // Version before the change
+	Bump            VersionBump `json:"bump" db:"bump"`
+	Changes         []string    `json:"changes" db:"-"` // Stored as JSON in DB
+	CreatedAt       time.Time   `json:"created_at" db:"created_at"`
+
+	// Default content before the change, the merge base when rebasing; nil in older history
+	PreviousContent *string `json:"-" db:"previous_content"`
+}
+
+// TemplateVersionPlanner decides a template update's version bump from the template
+// as stored when the update runs, with its current variables
+// It returns the history entry to record, or nil when the version stays unchanged
+type TemplateVersionPlanner func(existing *ConfigTemplate, variables []*ConfigVariable) *TemplateVersion
+
+// TemplateDrift reports how far a template has moved since a configuration was based on it
+type TemplateDrift struct {
+	TemplateID     int    `json:"template_id"`
+	ConfigVersion  string `json:"config_version"` // Template version the configuration was created from
+	CurrentVersion string `json:"current_version"`
+	VersionsBehind int    `json:"versions_behind"`
+}
+
+// TemplateDependent is a configuration created from a template, as listed for template authors
+type TemplateDependent struct {
+	ConfigID        int       `json:"config_id" db:"id"`
+	Name            string    `json:"name" db:"name"`
+	OwnerID         int       `json:"owner_id" db:"user_id"`
+	OwnerEmail      string    `json:"owner_email" db:"email"`
+	TemplateVersion string    `json:"template_version,omitempty" db:"template_version"` // Template version it was created from
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // ConfigVariable represents a variable in a configuration template
@@ -47,33 +103,142 @@ type ConfigVariable struct {
 	ValidationRule *string `json:"validation_rule,omitempty" db:"validation_rule"` // Regex or constraint
 }
 
+// TemplatePreviewRequest supplies sample variable values for rendering a template
+// Values are keyed by variable name; strings are converted to the variable's type
+type TemplatePreviewRequest struct {
+	Values map[string]interface{} `json:"values"`
+}
+
+// TemplatePreview is a template rendered with sample values; nothing is stored
+type TemplatePreview struct {
+	TemplateID        int               `json:"template_id"`
+	Format            ConfigFormat      `json:"format"`
+	Content           string            `json:"content"`
+	UnfilledVariables []*ConfigVariable `json:"unfilled_variables"` // Required variables left without a value
+}
+
 // UserConfig represents a user's configuration instance
 type UserConfig struct {
-	ID          int          `json:"id" db:"id"`
-	UserID      int          `json:"user_id" db:"user_id"`
-	TemplateID  *int         `json:"template_id,omitempty" db:"template_id"` // Null for custom configs
-	Name        string       `json:"name" db:"name"`                         // User-defined name
-	Description string       `json:"description" db:"description"`
-	Format      ConfigFormat `json:"format" db:"format"`
-	Content     string       `json:"content" db:"content"` // Current content
-	IsShared    bool         `json:"is_shared" db:"is_shared"`
-	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time    `json:"updated_at" db:"updated_at"`
+	ID              int          `json:"id" db:"id"`
+	UserID          int          `json:"user_id" db:"user_id"`
+	TemplateID      *int         `json:"template_id,omitempty" db:"template_id"`           // Null for custom configs
+	Name            string       `json:"name" db:"name"`                                   // User-defined name
+	TemplateVersion string       `json:"template_version,omitempty" db:"template_version"` // Template version it was created from
+	Description     string       `json:"description" db:"description"`
+	Format          ConfigFormat `json:"format" db:"format"`
+	Content         string       `json:"content" db:"content"` // Current content
+	IsShared        bool         `json:"is_shared" db:"is_shared"`
+	ForkedFrom      *int         `json:"forked_from,omitempty" db:"forked_from"` // Config this one was forked from
+	CreatedAt       time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at" db:"updated_at"`
 
 	// Relationships
 	Template *ConfigTemplate `json:"template,omitempty" db:"-"`
 	Versions []ConfigVersion `json:"versions,omitempty" db:"-"`
+
+	// Non-blocking advisories about the content, e.g. plaintext secrets; not stored
+	Warnings []string `json:"warnings,omitempty" db:"-"`
+
+	// Edit lock in force, set on the detail response; not stored with the configuration
+	Lock *ConfigLock `json:"lock,omitempty" db:"-"`
+}
+
+// ConfigLock is an advisory edit lock on a user configuration
+// While it is held only the holder can change the content; it lapses at ExpiresAt
+type ConfigLock struct {
+	ConfigID   int       `json:"config_id" db:"config_id"`
+	HolderID   int       `json:"holder_id" db:"holder_id"`
+	AcquiredAt time.Time `json:"acquired_at" db:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at" db:"expires_at"`
 }
 
 // ConfigVersion represents a version in the configuration history
 type ConfigVersion struct {
-	ID         int       `json:"id" db:"id"`
-	ConfigID   int       `json:"config_id" db:"config_id"`
-	Version    int       `json:"version" db:"version"` // Incremental version number
-	Content    string    `json:"content" db:"content"`
-	ChangeNote string    `json:"change_note" db:"change_note"` // User-provided change description
-	CreatedBy  int       `json:"created_by" db:"created_by"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	ID           int        `json:"id" db:"id"`
+	ConfigID     int        `json:"config_id" db:"config_id"`
+	Version      int        `json:"version" db:"version"` // Incremental version number
+	Content      string     `json:"content" db:"content"`
+	ChangeNote   string     `json:"change_note" db:"change_note"`               // User-provided change description
+	RestoredFrom *int       `json:"restored_from,omitempty" db:"restored_from"` // Version ID whose content was restored
+	ChangeSet    *ChangeSet `json:"change_set,omitempty" db:"change_set"`       // Summary of changes from the previous version
+	CreatedBy    int        `json:"created_by" db:"created_by"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ChangeSet summarizes how a version differs from the one before it
+// Stored as a JSON column; nil for first versions and unchanged content
+type ChangeSet struct {
+	Added    int      `json:"added"`    // Keys present only in the new version
+	Removed  int      `json:"removed"`  // Keys present only in the previous version
+	Modified int      `json:"modified"` // Keys whose value changed
+	Keys     []string `json:"keys"`     // Top-level keys touched, sorted
+}
+
+// Value implements driver.Valuer so a change set can be written as JSON
+func (c *ChangeSet) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner so a change set can be read from a JSON column
+func (c *ChangeSet) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*c = ChangeSet{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported change set type: %T", src)
+	}
+
+	*c = ChangeSet{}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, c)
+}
+
+// VersionNode is one version in a configuration's lineage graph
+// Versions are stored linearly, so each node's parent is the version before it;
+// restores additionally point at the older version their content came from
+type VersionNode struct {
+	ID           int       `json:"id"`
+	Version      int       `json:"version"`
+	Parent       *int      `json:"parent,omitempty"`        // Version number this one was saved on top of
+	RestoredFrom *int      `json:"restored_from,omitempty"` // Version number whose content was restored
+	ChangeNote   string    `json:"change_note"`
+	CreatedBy    int       `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// HistoryManifest describes the versions in a configuration history archive
+type HistoryManifest struct {
+	ConfigID   int                    `json:"config_id"`
+	Name       string                 `json:"name"`
+	Format     ConfigFormat           `json:"format"`
+	Mode       string                 `json:"mode"` // "snapshots" or "patches"
+	ExportedAt time.Time              `json:"exported_at"`
+	Versions   []HistoryManifestEntry `json:"versions"` // Oldest first
+}
+
+// HistoryManifestEntry maps one version to its file in a history archive
+type HistoryManifestEntry struct {
+	Version      int       `json:"version"`
+	File         string    `json:"file"` // Snapshot or patch path within the archive
+	ChangeNote   string    `json:"change_note"`
+	CreatedBy    int       `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	RestoredFrom *int      `json:"restored_from,omitempty"` // Version number whose content was restored
 }
 
 // ConfigImport represents an import operation from external sources
@@ -84,7 +249,12 @@ type ConfigImport struct {
 	SourceURL    string           `json:"source_url" db:"source_url"`
 	Status       ImportStatus     `json:"status" db:"status"`
 	ErrorMessage *string          `json:"error_message,omitempty" db:"error_message"`
-	ConfigID     *int             `json:"config_id,omitempty" db:"config_id"` // Result config ID
+	SourceFailed bool             `json:"source_failed,omitempty" db:"source_failed"` // Failed because the source was unreachable or errored
+	ConfigID     *int             `json:"config_id,omitempty" db:"config_id"`         // Result config ID
+	Attempts     int              `json:"attempts" db:"attempts"`                     // Fetch attempts so far
+	RetryAfter   *time.Time       `json:"retry_after,omitempty" db:"retry_after"`     // Scheduled retry after an upstream rate limit
+	Dedupe       bool             `json:"dedupe" db:"dedupe"`                         // Reuse an existing config instead of creating a duplicate
+	DedupResult  DedupResult      `json:"dedup_result,omitempty" db:"dedup_result"`   // How a deduplicated import was resolved
 	CreatedAt    time.Time        `json:"created_at" db:"created_at"`
 	CompletedAt  *time.Time       `json:"completed_at,omitempty" db:"completed_at"`
 }
@@ -99,6 +269,12 @@ const (
 	SourceGitLab ConfigSourceType = "gitlab" // GitLab repository
 )
 
+// ImportWithAge is an import listed for operators, with how long ago it was created
+type ImportWithAge struct {
+	*ConfigImport
+	AgeSeconds int64 `json:"age_seconds"`
+}
+
 // ImportStatus represents the status of a configuration import
 type ImportStatus string
 
@@ -109,19 +285,98 @@ const (
 	ImportFailed     ImportStatus = "failed"
 )
 
+// DedupResult records how a deduplicating import reused an existing configuration
+type DedupResult string
+
+const (
+	DedupNone       DedupResult = ""            // No match; a new configuration was created
+	DedupLinked     DedupResult = "linked"      // Identical content already existed and was linked
+	DedupNewVersion DedupResult = "new_version" // The source was imported before; its config got a new version
+)
+
+// RestoreResult reports the outcome of restoring a configuration to an earlier version
+// The restore creates a new version; RestoredFrom matches its restored_from lineage
+type RestoreResult struct {
+	Config              *UserConfig `json:"config"`
+	Version             int         `json:"version"`               // Number of the version the restore created
+	VersionID           int         `json:"version_id"`            // ID of the version the restore created
+	RestoredFrom        int         `json:"restored_from"`         // ID of the version whose content was restored
+	RestoredFromVersion int         `json:"restored_from_version"` // Number of the version whose content was restored
+}
+
+// RebaseResult reports the outcome of moving a configuration onto its template's current version
+// The rebase creates a new version; conflicting keys keep the configuration's own value
+type RebaseResult struct {
+	Config              *UserConfig     `json:"config"`
+	Version             int             `json:"version"`    // Number of the version the rebase created
+	VersionID           int             `json:"version_id"` // ID of the version the rebase created
+	FromTemplateVersion string          `json:"from_template_version"`
+	ToTemplateVersion   string          `json:"to_template_version"`
+	Conflicts           []MergeConflict `json:"conflicts"`
+	Warnings            []string        `json:"warnings,omitempty"`
+}
+
+// MergeConflict is a key the configuration and its template both changed, in different ways
+// A side's value is omitted when the key is absent there
+type MergeConflict struct {
+	Path   string      `json:"path"`             // Dotted key path, e.g. server.port
+	Base   interface{} `json:"base,omitempty"`   // Value in the template version the configuration was based on
+	Ours   interface{} `json:"ours,omitempty"`   // Value in the configuration, which the merge keeps
+	Theirs interface{} `json:"theirs,omitempty"` // Value in the current template
+}
+
 // ConfigDiff represents differences between two configuration versions
+// A line diff sets LineNumber; a structural diff sets Path and the typed values,
+// with the content fields holding those values as text
 type ConfigDiff struct {
-	LineNumber int    `json:"line_number"`
-	Type       string `json:"type"` // "added", "removed", "modified"
-	OldContent string `json:"old_content"`
-	NewContent string `json:"new_content"`
+	LineNumber int         `json:"line_number,omitempty"`
+	Path       string      `json:"path,omitempty"` // Dotted key path, e.g. server.port
+	Type       string      `json:"type"`           // "added", "removed", "modified"
+	OldContent string      `json:"old_content"`
+	NewContent string      `json:"new_content"`
+	OldValue   interface{} `json:"old_value,omitempty"`
+	NewValue   interface{} `json:"new_value,omitempty"`
 }
 
 // ShareRequest represents a request to share a configuration
 type ShareRequest struct {
-	ConfigID    int    `json:"config_id"`
-	ShareWith   []int  `json:"share_with"`  // User IDs to share with
-	Permissions string `json:"permissions"` // "read", "write"
+	ConfigID    int             `json:"config_id"`
+	ShareWith   []int           `json:"share_with"`  // User IDs to share with
+	Permissions SharePermission `json:"permissions"` // "read", "write"
+}
+
+// Validate checks the request names users to share with and a known permission
+func (r *ShareRequest) Validate() error {
+	if len(r.ShareWith) == 0 {
+		return fmt.Errorf("share_with must list at least one user")
+	}
+	for _, userID := range r.ShareWith {
+		if userID <= 0 {
+			return fmt.Errorf("invalid user ID in share_with: %d", userID)
+		}
+	}
+	if r.Permissions != ShareRead && r.Permissions != ShareWrite {
+		return fmt.Errorf("permissions must be %q or %q", ShareRead, ShareWrite)
+	}
+	return nil
+}
+
+// SharePermission is the access a configuration share grants
+type SharePermission string
+
+const (
+	ShareRead  SharePermission = "read"  // View the configuration, its versions, and exports
+	ShareWrite SharePermission = "write" // Also edit, restore, rebase, and lock it
+)
+
+// ConfigShare grants a user access to someone else's configuration
+// Only the owner can delete a configuration or share it further
+type ConfigShare struct {
+	ConfigID   int             `json:"config_id" db:"config_id"`
+	UserID     int             `json:"user_id" db:"user_id"` // User the configuration is shared with
+	Permission SharePermission `json:"permission" db:"permission"`
+	SharedBy   int             `json:"shared_by" db:"shared_by"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
 }
 
 // APIKey represents an API key for programmatic access
@@ -136,3 +391,38 @@ type APIKey struct {
 	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
 	IsActive    bool       `json:"is_active" db:"is_active"`
 }
+
+// RotateAPIKeysRequest represents a request to revoke a user's API keys
+type RotateAPIKeysRequest struct {
+	UserID   *int   `json:"user_id,omitempty"` // Target user; defaults to the caller (admin only for others)
+	IssueNew bool   `json:"issue_new"`         // Issue one fresh key after revoking
+	Name     string `json:"name"`              // Name for the fresh key
+}
+
+// RotateAPIKeysResponse reports the outcome of a key rotation
+type RotateAPIKeysResponse struct {
+	Revoked int64   `json:"revoked"`
+	Key     *APIKey `json:"key,omitempty"`
+	Secret  string  `json:"secret,omitempty"` // Plaintext of the new key, returned only once
+}
+
+// ConvertBatchItem is one conversion in a batch request
+type ConvertBatchItem struct {
+	Content string       `json:"content"`
+	From    ConfigFormat `json:"from"`
+	To      ConfigFormat `json:"to"`
+}
+
+// ConvertBatchResult is the outcome of one batch item, in request order
+type ConvertBatchResult struct {
+	Index  int    `json:"index"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ConvertBatchResponse reports per-item results and a summary of the batch
+type ConvertBatchResponse struct {
+	Results   []ConvertBatchResult `json:"results"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+}
@@ -0,0 +1,59 @@
+// List sorting
+// Sort orders for list queries, restricted to allowlisted columns
+// Columns are interpolated into ORDER BY, so only allowlisted names may reach a query
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ListSort orders a list query by a single column
+type ListSort struct {
+	Column     string
+	Descending bool
+}
+
+// Sortable columns and default orders for each listing
+var (
+	UserConfigSortColumns    = []string{"id", "name", "created_at", "updated_at"}
+	ConfigVersionSortColumns = []string{"version", "created_at"}
+
+	DefaultUserConfigSort    = ListSort{Column: "id"}
+	DefaultConfigVersionSort = ListSort{Column: "version", Descending: true} // Version numbering reads the newest first
+)
+
+// ParseListSort builds a sort from ?sort= and ?order= values
+// Empty values fall back to the default's column and direction
+func ParseListSort(column, order string, allowed []string, fallback ListSort) (ListSort, error) {
+	sort := fallback
+	if column != "" {
+		if !slices.Contains(allowed, column) {
+			return ListSort{}, fmt.Errorf("validation failed: cannot sort by %q (allowed: %s)", column, strings.Join(allowed, ", "))
+		}
+		sort.Column = column
+	}
+	switch strings.ToLower(order) {
+	case "":
+	case "asc":
+		sort.Descending = false
+	case "desc":
+		sort.Descending = true
+	default:
+		return ListSort{}, fmt.Errorf("validation failed: order must be asc or desc")
+	}
+	return sort, nil
+}
+
+// OrderBy renders the sort as an ORDER BY clause, breaking ties by id in the same direction
+func (s ListSort) OrderBy() string {
+	direction := "ASC"
+	if s.Descending {
+		direction = "DESC"
+	}
+	if s.Column == "id" {
+		return "ORDER BY id " + direction
+	}
+	return fmt.Sprintf("ORDER BY %s %s, id %s", s.Column, direction, direction)
+}
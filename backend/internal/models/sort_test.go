@@ -0,0 +1,71 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseListSort(t *testing.T) {
+	tests := []struct {
+		name        string
+		column      string
+		order       string
+		want        ListSort
+		wantOrderBy string
+		wantErr     string
+	}{
+		{
+			name:        "default",
+			want:        DefaultConfigVersionSort,
+			wantOrderBy: "ORDER BY version DESC, id DESC",
+		},
+		{
+			name:        "column keeps default direction",
+			column:      "created_at",
+			want:        ListSort{Column: "created_at", Descending: true},
+			wantOrderBy: "ORDER BY created_at DESC, id DESC",
+		},
+		{
+			name:        "ascending",
+			column:      "version",
+			order:       "ASC",
+			want:        ListSort{Column: "version"},
+			wantOrderBy: "ORDER BY version ASC, id ASC",
+		},
+		{
+			name:    "column outside allowlist",
+			column:  "content; DROP TABLE config_versions",
+			wantErr: "cannot sort by",
+		},
+		{
+			name:    "unknown direction",
+			order:   "sideways",
+			wantErr: "order must be asc or desc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseListSort(tt.column, tt.order, ConfigVersionSortColumns, DefaultConfigVersionSort)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseListSort() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseListSort() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseListSort() = %+v, want %+v", got, tt.want)
+			}
+			if orderBy := got.OrderBy(); orderBy != tt.wantOrderBy {
+				t.Errorf("OrderBy() = %q, want %q", orderBy, tt.wantOrderBy)
+			}
+		})
+	}
+
+	if got := DefaultUserConfigSort.OrderBy(); got != "ORDER BY id ASC" {
+		t.Errorf("default config OrderBy() = %q, want ORDER BY id ASC", got)
+	}
+}
@@ -0,0 +1,99 @@
+// Timestamp normalization for persisted models
+// Converts database timestamps to UTC after they are read
+// Keeps JSON output RFC3339 UTC regardless of driver or server time zone
+package models
+
+import (
+	"time"
+)
+
+// utcPtr converts an optional timestamp to UTC in place
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// NormalizeTimestamps converts the user's timestamps to UTC
+func (u *User) NormalizeTimestamps() {
+	u.CreatedAt = u.CreatedAt.UTC()
+	u.UpdatedAt = u.UpdatedAt.UTC()
+}
+
+// NormalizeTimestamps converts the session's timestamps to UTC
+func (s *Session) NormalizeTimestamps() {
+	s.ExpiresAt = s.ExpiresAt.UTC()
+	s.LastActivity = s.LastActivity.UTC()
+	s.CreatedAt = s.CreatedAt.UTC()
+}
+
+// NormalizeTimestamps converts the template's timestamps to UTC
+func (t *ConfigTemplate) NormalizeTimestamps() {
+	t.CreatedAt = t.CreatedAt.UTC()
+	t.UpdatedAt = t.UpdatedAt.UTC()
+}
+
+// NormalizeTimestamps converts the template version's timestamp to UTC
+func (v *TemplateVersion) NormalizeTimestamps() {
+	v.CreatedAt = v.CreatedAt.UTC()
+}
+
+// NormalizeTimestamps converts the configuration's timestamps to UTC
+func (c *UserConfig) NormalizeTimestamps() {
+	c.CreatedAt = c.CreatedAt.UTC()
+	c.UpdatedAt = c.UpdatedAt.UTC()
+}
+
+// NormalizeTimestamps converts the version's timestamps to UTC
+func (v *ConfigVersion) NormalizeTimestamps() {
+	v.CreatedAt = v.CreatedAt.UTC()
+}
+
+// NormalizeTimestamps converts the dependent's timestamp to UTC
+func (d *TemplateDependent) NormalizeTimestamps() {
+	d.UpdatedAt = d.UpdatedAt.UTC()
+}
+
+// NormalizeTimestamps converts the lock's timestamps to UTC
+func (l *ConfigLock) NormalizeTimestamps() {
+	l.AcquiredAt = l.AcquiredAt.UTC()
+	l.ExpiresAt = l.ExpiresAt.UTC()
+}
+
+// NormalizeTimestamps converts the share's timestamp to UTC
+func (s *ConfigShare) NormalizeTimestamps() {
+	s.CreatedAt = s.CreatedAt.UTC()
+}
+
+// NormalizeTimestamps converts the import's timestamps to UTC
+func (i *ConfigImport) NormalizeTimestamps() {
+	i.CreatedAt = i.CreatedAt.UTC()
+	i.RetryAfter = utcPtr(i.RetryAfter)
+	i.CompletedAt = utcPtr(i.CompletedAt)
+}
+
+// NormalizeTimestamps converts the API key's timestamps to UTC
+func (k *APIKey) NormalizeTimestamps() {
+	k.LastUsedAt = utcPtr(k.LastUsedAt)
+	k.ExpiresAt = utcPtr(k.ExpiresAt)
+	k.CreatedAt = k.CreatedAt.UTC()
+}
+
+// NormalizeTimestamps converts the audit entry's timestamps to UTC
+func (e *AuditEntry) NormalizeTimestamps() {
+	e.CreatedAt = e.CreatedAt.UTC()
+}
+
+// NormalizeTimestamps converts the email change's timestamps to UTC
+func (c *EmailChange) NormalizeTimestamps() {
+	c.ExpiresAt = c.ExpiresAt.UTC()
+	c.CreatedAt = c.CreatedAt.UTC()
+}
+
+// NormalizeTimestamps converts the verification's timestamps to UTC
+func (v *EmailVerification) NormalizeTimestamps() {
+	v.ExpiresAt = v.ExpiresAt.UTC()
+	v.CreatedAt = v.CreatedAt.UTC()
+}
@@ -4,33 +4,205 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
 	"strings"
 	"time"
 )
 
 // User represents a user entity in the system
 type User struct {
+	ID            int             `json:"id" db:"id"`
+	Email         string          `json:"email" db:"email"`
+	Password      string          `json:"-" db:"password_hash"` // Hidden from JSON
+	FirstName     string          `json:"first_name" db:"first_name"`
+	LastName      string          `json:"last_name" db:"last_name"`
+	Role          string          `json:"role" db:"role"` // RoleUser or RoleAdmin
+	Preferences   UserPreferences `json:"preferences" db:"preferences"`
+	EmailVerified bool            `json:"email_verified" db:"email_verified"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at" db:"updated_at"`
+
+	// Set for accounts created with a temporary password; cleared when the user changes it
+	PasswordResetRequired bool `json:"reset_required" db:"password_reset_required"`
+}
+
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// ErrEmailTaken is returned when an email address already belongs to an account
+// Repositories translate unique-index violations on users.email into it
+var ErrEmailTaken = errors.New("email already exists")
+
+// UpdateProfileRequest carries a partial profile update; omitted fields are kept
+type UpdateProfileRequest struct {
+	Email     *string `json:"email,omitempty"`
+	FirstName *string `json:"first_name,omitempty"`
+	LastName  *string `json:"last_name,omitempty"`
+}
+
+// ChangePasswordRequest replaces the current user's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// UserImportRow is one user to create in a bulk import
+type UserImportRow struct {
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// User import row outcomes
+const (
+	UserImportCreated = "created"
+	UserImportSkipped = "skipped" // The email already has an account
+	UserImportFailed  = "failed"
+)
+
+// UserImportResult reports what happened to one row of a bulk import
+// TemporaryPassword is only set for created users; they must change it on first login
+type UserImportResult struct {
+	Row               int    `json:"row"` // 1-based position in the submitted batch
+	Email             string `json:"email"`
+	Status            string `json:"status"`
+	UserID            int    `json:"user_id,omitempty"`
+	TemporaryPassword string `json:"temporary_password,omitempty"`
+	Error             string `json:"error,omitempty"`
+}
+
+// EmailChange is a pending email change awaiting confirmation
+// The user's current email stays active until the token is presented
+type EmailChange struct {
+	ID        int       `json:"id" db:"id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	NewEmail  string    `json:"new_email" db:"new_email"`
+	TokenHash string    `json:"-" db:"token_hash"` // SHA-256 of the emailed token
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// EmailChangeRequest starts an email change for the current user
+type EmailChangeRequest struct {
+	NewEmail string `json:"new_email"`
+}
+
+// Validate trims and checks the requested address
+func (r *EmailChangeRequest) Validate() error {
+	r.NewEmail = strings.TrimSpace(r.NewEmail)
+	if r.NewEmail == "" {
+		return errors.New("new email is required")
+	}
+	if !isValidEmail(r.NewEmail) {
+		return errors.New("invalid email address: " + r.NewEmail)
+	}
+	return nil
+}
+
+// ConfirmEmailChangeRequest carries the token sent to the new address
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token"`
+}
+
+// EmailVerification is an outstanding token confirming a new account's email
+type EmailVerification struct {
 	ID        int       `json:"id" db:"id"`
-	Email     string    `json:"email" db:"email"`
-	Password  string    `json:"-" db:"password_hash"` // Hidden from JSON
-	FirstName string    `json:"first_name" db:"first_name"`
-	LastName  string    `json:"last_name" db:"last_name"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	TokenHash string    `json:"-" db:"token_hash"` // SHA-256 of the emailed token
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// VerifyEmailRequest carries the token sent at registration
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// ResendVerificationRequest asks for a fresh verification token
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+// UserPreferences holds per-user settings stored as a JSON column
+type UserPreferences struct {
+	DefaultExportFormat ConfigFormat `json:"default_export_format,omitempty"` // Used when export omits ?format=
+}
+
+// Value implements driver.Valuer so preferences can be written as JSON
+func (p UserPreferences) Value() (driver.Value, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner so preferences can be read from a JSON column
+func (p *UserPreferences) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*p = UserPreferences{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported preferences type: %T", src)
+	}
+
+	*p = UserPreferences{}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, p)
 }
 
 // Validate performs business rule validation on user data
+// Whitespace is trimmed first; every violated rule is reported
 func (u *User) Validate() error {
 	// Trim whitespace
 	u.Email = strings.TrimSpace(u.Email)
 	u.FirstName = strings.TrimSpace(u.FirstName)
 	u.LastName = strings.TrimSpace(u.LastName)
 
-	// Basic validation rules would go here
-	// For now, we'll keep it simple
+	var problems []string
+	if u.Email == "" {
+		problems = append(problems, "email is required")
+	} else if !isValidEmail(u.Email) {
+		problems = append(problems, "invalid email address: "+u.Email)
+	}
+	if u.FirstName == "" {
+		problems = append(problems, "first name is required")
+	}
+	if u.LastName == "" {
+		problems = append(problems, "last name is required")
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
 	return nil
 }
 
+// isValidEmail reports whether email is a bare address like user@example.com
+func isValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email && strings.Contains(email[strings.LastIndex(email, "@"):], ".")
+}
+
+// IsAdmin reports whether the user has the admin role
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
 // FullName returns the user's full name
 func (u *User) FullName() string {
 	return u.FirstName + " " + u.LastName
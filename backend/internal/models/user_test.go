@@ -9,9 +9,9 @@ import (
 
 func TestUser_Validate(t *testing.T) {
 	tests := []struct {
-		name string
-		user User
-		want error
+		name    string
+		user    User
+		wantErr string
 	}{
 		{
 			name: "valid user",
@@ -20,7 +20,6 @@ func TestUser_Validate(t *testing.T) {
 				FirstName: "John",
 				LastName:  "Doe",
 			},
-			want: nil,
 		},
 		{
 			name: "user with whitespace",
@@ -29,12 +28,36 @@ func TestUser_Validate(t *testing.T) {
 				FirstName: "  John  ",
 				LastName:  "  Doe  ",
 			},
-			want: nil,
 		},
 		{
-			name: "empty user",
-			user: User{},
-			want: nil,
+			name:    "empty user",
+			user:    User{},
+			wantErr: "email is required; first name is required; last name is required",
+		},
+		{
+			name:    "whitespace-only email",
+			user:    User{Email: "   ", FirstName: "John", LastName: "Doe"},
+			wantErr: "email is required",
+		},
+		{
+			name:    "malformed email",
+			user:    User{Email: "not-an-email", FirstName: "John", LastName: "Doe"},
+			wantErr: "invalid email address: not-an-email",
+		},
+		{
+			name:    "email with display name",
+			user:    User{Email: "John <john@example.com>", FirstName: "John", LastName: "Doe"},
+			wantErr: "invalid email address",
+		},
+		{
+			name:    "email without domain dot",
+			user:    User{Email: "john@localhost", FirstName: "John", LastName: "Doe"},
+			wantErr: "invalid email address",
+		},
+		{
+			name:    "missing last name",
+			user:    User{Email: "test@example.com", FirstName: "John", LastName: " "},
+			wantErr: "last name is required",
 		},
 	}
 
@@ -46,8 +69,11 @@ func TestUser_Validate(t *testing.T) {
 
 			err := tt.user.Validate()
 
-			if err != tt.want {
-				t.Errorf("User.Validate() error = %v, want %v", err, tt.want)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("User.Validate() error = %v, want nil", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("User.Validate() error = %v, want %q", err, tt.wantErr)
 			}
 
 			// Check that whitespace was trimmed
@@ -267,10 +293,9 @@ func TestUser_ZeroValue(t *testing.T) {
 		t.Errorf("FullName() with zero values = %q, want %q", fullName, " ")
 	}
 
-	// Validate should work with zero values
-	err := user.Validate()
-	if err != nil {
-		t.Errorf("Validate() with zero values = %v, want nil", err)
+	// Validate rejects zero values, which can't be persisted
+	if err := user.Validate(); err == nil {
+		t.Error("Validate() with zero values = nil, want error")
 	}
 }
 
@@ -332,3 +357,46 @@ func BenchmarkUser_JSONMarshal(b *testing.B) {
 		}
 	}
 }
+
+func TestUserPreferences_ValueScan(t *testing.T) {
+	tests := []struct {
+		name string
+		src  interface{}
+		want UserPreferences
+	}{
+		{name: "json bytes", src: []byte(`{"default_export_format":"toml"}`), want: UserPreferences{DefaultExportFormat: FormatTOML}},
+		{name: "json string", src: `{"default_export_format":"json"}`, want: UserPreferences{DefaultExportFormat: FormatJSON}},
+		{name: "empty object", src: `{}`, want: UserPreferences{}},
+		{name: "null column", src: nil, want: UserPreferences{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefs := UserPreferences{DefaultExportFormat: FormatENV}
+			if err := prefs.Scan(tt.src); err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if prefs != tt.want {
+				t.Errorf("Scan() = %+v, want %+v", prefs, tt.want)
+			}
+
+			// Value must round-trip through Scan
+			value, err := prefs.Value()
+			if err != nil {
+				t.Fatalf("Value() error = %v", err)
+			}
+			var roundTrip UserPreferences
+			if err := roundTrip.Scan(value); err != nil {
+				t.Fatalf("Scan(Value()) error = %v", err)
+			}
+			if roundTrip != prefs {
+				t.Errorf("round trip = %+v, want %+v", roundTrip, prefs)
+			}
+		})
+	}
+
+	var prefs UserPreferences
+	if err := prefs.Scan(42); err == nil {
+		t.Error("expected error scanning unsupported type")
+	}
+}
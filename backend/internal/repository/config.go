@@ -4,34 +4,76 @@
 package repository
 
 import (
+	"errors"
+	"time"
+
 	"conflux/internal/models"
 )
 
+// Errors returned when a lookup by ID matches nothing
+var (
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrConfigNotFound   = errors.New("configuration not found")
+	ErrVersionNotFound  = errors.New("version not found")
+	ErrImportNotFound   = errors.New("import not found")
+	ErrUserNotFound     = errors.New("user not found")
+)
+
 // ConfigRepository defines the interface for configuration data access
+// Create and update methods fill in the model's ID and database-generated
+// created_at/updated_at values; callers never set timestamps themselves
+// List orders arrive validated against the model's allowlist; apply them with ListSort.OrderBy
 type ConfigRepository interface {
 	// Template management
 	CreateTemplate(template *models.ConfigTemplate) error
 	GetTemplate(id int) (*models.ConfigTemplate, error)
 	GetTemplates(category, search string, page, limit int) ([]*models.ConfigTemplate, int64, error)
-	UpdateTemplate(id int, updates *models.ConfigTemplate) error
+	// plan, when not nil, decides the version bump inside the update's transaction
+	UpdateTemplate(id int, updates *models.ConfigTemplate, plan models.TemplateVersionPlanner) error
 	DeleteTemplate(id int) error
 
+	// Template version history, newest first; entries are added by UpdateTemplate
+	GetTemplateVersions(templateID int) ([]*models.TemplateVersion, error)
+
+	// Configurations created from a template with their owners, most recently updated first
+	GetTemplateDependents(templateID, page, limit int) ([]*models.TemplateDependent, int64, error)
+
 	// User configuration management
 	CreateUserConfig(config *models.UserConfig) error
 	GetUserConfig(id int) (*models.UserConfig, error)
-	GetUserConfigs(userID int, templateID *int, page, limit int) ([]*models.UserConfig, int64, error)
+	GetUserConfigs(userID int, templateID *int, order models.ListSort, page, limit int) ([]*models.UserConfig, int64, error)
+	GetUserConfigsAfter(userID int, templateID *int, afterID, limit int) ([]*models.UserConfig, error) // Keyset page in ID order
+	// Whether the user has a configuration with this name, ignoring case
+	UserConfigNameExists(userID int, name string) (bool, error)
 	UpdateUserConfig(id int, config *models.UserConfig) error
 	DeleteUserConfig(id int) error
 
+	// Edit locks; a lock past its expiry counts as released
+	// AcquireConfigLock locks for holderID until ttl from now, or renews the holder's lock,
+	// unless someone else holds it; it returns the lock in force either way
+	AcquireConfigLock(configID, holderID int, ttl time.Duration) (*models.ConfigLock, error)
+	GetConfigLock(configID int) (*models.ConfigLock, error) // nil when unlocked
+	ReleaseConfigLock(configID, holderID int) error
+
+	// Shares; sharing with a user who already has a share replaces its permission.
+	// ShareConfig records all the shares or, when a recipient doesn't exist, none of them
+	ShareConfig(shares []*models.ConfigShare) error
+	GetConfigShare(configID, userID int) (*models.ConfigShare, error) // nil when not shared with the user
+
 	// Version management
 	CreateVersion(version *models.ConfigVersion) error
 	GetConfigVersion(id int) (*models.ConfigVersion, error)
-	GetConfigVersions(configID int, page, limit int) ([]*models.ConfigVersion, int64, error)
+	GetConfigVersions(configID int, order models.ListSort, page, limit int) ([]*models.ConfigVersion, int64, error)
+	GetConfigVersionsBefore(configID, beforeID, limit int) ([]*models.ConfigVersion, error) // Keyset page, newest first; beforeID 0 starts at the newest
 
 	// Import management
 	CreateImport(importRecord *models.ConfigImport) error
 	GetImport(id int) (*models.ConfigImport, error)
 	UpdateImport(id int, updates *models.ConfigImport) error
+	GetLatestCompletedImport(userID int, sourceURL string) (*models.ConfigImport, error) // Most recent completed import of a source
+
+	// Imports in a status across all users, oldest first, for operators
+	GetImportsByStatus(status models.ImportStatus, page, limit int) ([]*models.ConfigImport, int64, error)
 
 	// Template variables
 	CreateVariable(variable *models.ConfigVariable) error
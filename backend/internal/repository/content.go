@@ -0,0 +1,79 @@
+// Stored content encoding
+// Optionally gzips large config content before it reaches the content columns
+// Rows are self-describing, so compressed and plain rows can be read side by side
+package repository
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// compressedPrefix marks content stored as base64-encoded gzip
+// Content columns are text, so the compressed bytes are base64 encoded
+const compressedPrefix = "gzip+base64:"
+
+// DefaultCompressMinBytes is the smallest content compressed by default
+// Below this the gzip header and base64 overhead outweigh the savings
+const DefaultCompressMinBytes = 4096
+
+// ContentCodec encodes config content for storage and decodes it on read
+// The zero value stores everything as plain text
+type ContentCodec struct {
+	Compress bool // Compress content of at least MinBytes
+	MinBytes int
+}
+
+// Encode returns content as it should be stored
+// Plain content that happens to begin with the marker is always compressed,
+// so Decode never mistakes it for compressed content
+func (c ContentCodec) Encode(content string) (string, error) {
+	markerCollision := strings.HasPrefix(content, compressedPrefix)
+	if !markerCollision && (!c.Compress || len(content) < c.MinBytes) {
+		return content, nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(content)); err != nil {
+		return "", fmt.Errorf("failed to compress content: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress content: %w", err)
+	}
+
+	encoded := compressedPrefix + base64.StdEncoding.EncodeToString(buf.Bytes())
+	if !markerCollision && len(encoded) >= len(content) {
+		return content, nil // Incompressible content is cheaper stored as is
+	}
+	return encoded, nil
+}
+
+// Decode returns the original content of a stored value
+// Values without the marker, including every row written before compression
+// was enabled, are returned unchanged
+func (c ContentCodec) Decode(stored string) (string, error) {
+	encoded, ok := strings.CutPrefix(stored, compressedPrefix)
+	if !ok {
+		return stored, nil
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode compressed content: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress content: %w", err)
+	}
+	defer zr.Close()
+
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress content: %w", err)
+	}
+	return string(content), nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContentCodec_RoundTrip(t *testing.T) {
+	large := strings.Repeat("server:\n  port: 8080\n  host: localhost\n", 200)
+	marker := compressedPrefix + "not actually compressed"
+
+	tests := []struct {
+		name           string
+		codec          ContentCodec
+		content        string
+		wantCompressed bool
+	}{
+		{name: "disabled", codec: ContentCodec{}, content: large},
+		{name: "below threshold", codec: ContentCodec{Compress: true, MinBytes: 1 << 20}, content: large},
+		{name: "above threshold", codec: ContentCodec{Compress: true, MinBytes: 1024}, content: large, wantCompressed: true},
+		{name: "incompressible", codec: ContentCodec{Compress: true}, content: "k: v\n"},
+		{name: "empty", codec: ContentCodec{Compress: true}, content: ""},
+		{name: "plain content starting with the marker", codec: ContentCodec{}, content: marker, wantCompressed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored, err := tt.codec.Encode(tt.content)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if compressed := stored != tt.content; compressed != tt.wantCompressed {
+				t.Errorf("compressed = %v, want %v", compressed, tt.wantCompressed)
+			}
+			if tt.wantCompressed && len(tt.content) > 1024 && len(stored) >= len(tt.content)/4 {
+				t.Errorf("stored %d bytes for %d bytes of repetitive content", len(stored), len(tt.content))
+			}
+
+			// Decoding doesn't depend on the codec's settings
+			got, err := ContentCodec{}.Decode(stored)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if got != tt.content {
+				t.Errorf("Decode() = %q, want the original content", got)
+			}
+		})
+	}
+}
+
+func TestContentCodec_DecodeUncompressedRows(t *testing.T) {
+	// Rows written before compression existed carry no marker
+	for _, stored := range []string{"", "port: 8080\n", `{"gzip": true}`, "gzip+base64"} {
+		got, err := ContentCodec{Compress: true}.Decode(stored)
+		if err != nil || got != stored {
+			t.Errorf("Decode(%q) = %q, %v, want it unchanged", stored, got, err)
+		}
+	}
+}
+
+func TestContentCodec_DecodeCorrupt(t *testing.T) {
+	for _, stored := range []string{compressedPrefix + "!!!", compressedPrefix + "aGVsbG8="} {
+		if _, err := (ContentCodec{}).Decode(stored); err == nil {
+			t.Errorf("Decode(%q) succeeded, want an error", stored)
+		}
+	}
+}
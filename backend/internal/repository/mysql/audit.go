@@ -0,0 +1,94 @@
+// MySQL implementation of AuditRepository interface
+// Persists audit log entries specific to MySQL database
+// Entries are insert-only
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"conflux/internal/models"
+)
+
+// AuditRepository implements service.AuditRepository for MySQL
+type AuditRepository struct {
+	db *sql.DB
+}
+
+// NewAuditRepository creates a new MySQL audit repository
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Create inserts a new audit entry into MySQL database
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (actor_id, action, target_user_id, target_config_id, target_name, ip_address, details) 
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.ExecContext(ctx, query,
+		entry.ActorID, entry.Action, entry.TargetUserID, entry.TargetConfigID, entry.TargetName,
+		entry.IPAddress, entry.Details,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	entry.ID = int(id)
+
+	// MySQL has no RETURNING; read back the database-generated timestamp
+	err = r.db.QueryRowContext(ctx, `SELECT created_at FROM audit_log WHERE id = ?`, entry.ID).Scan(&entry.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	entry.NormalizeTimestamps()
+	return nil
+}
+
+// ListByActor returns a page of the actor's entries whose action starts with actionPrefix, newest first
+func (r *AuditRepository) ListByActor(
+	ctx context.Context, actorID int, actionPrefix string, page, limit int,
+) ([]*models.AuditEntry, int64, error) {
+	pattern := actionPrefix + "%"
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM audit_log WHERE actor_id = ? AND action LIKE ?`
+	if err := r.db.QueryRowContext(ctx, countQuery, actorID, pattern).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT id, actor_id, action, target_user_id, target_config_id, target_name, ip_address, details, created_at
+		FROM audit_log WHERE actor_id = ? AND action LIKE ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?`
+
+	rows, err := r.db.QueryContext(ctx, query, actorID, pattern, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditEntry{}
+	for rows.Next() {
+		entry := &models.AuditEntry{}
+		var details sql.NullString
+		if err := rows.Scan(
+			&entry.ID, &entry.ActorID, &entry.Action, &entry.TargetUserID, &entry.TargetConfigID,
+			&entry.TargetName, &entry.IPAddress, &details, &entry.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		entry.Details = details.String
+		entry.NormalizeTimestamps()
+		entries = append(entries, entry)
+	}
+
+	return entries, total, rows.Err()
+}
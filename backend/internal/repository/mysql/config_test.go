@@ -0,0 +1,97 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+	"conflux/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestConfigRepository_CreateUserConfig_UsesDatabaseTimestamps(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	created := time.Date(2001, 2, 3, 4, 5, 6, 0, time.FixedZone("EST", -5*60*60))
+
+	// Without unique names, name_key stays NULL
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_configs")).
+		WithArgs(1, nil, "app", nil, "", "", models.FormatINI, "[server]\nport = 8080\n", false, nil).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT created_at, updated_at FROM user_configs WHERE id = ?")).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(created, created))
+
+	repo := NewConfigRepository(db, repository.ContentCodec{}, false)
+	config := &models.UserConfig{UserID: 1, Name: "app", Format: models.FormatINI, Content: "[server]\nport = 8080\n"}
+	if err := repo.CreateUserConfig(config); err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	if config.ID != 7 || !config.CreatedAt.Equal(created) || config.CreatedAt.Location() != time.UTC {
+		t.Errorf("after CreateUserConfig: id=%d created_at=%v, want 7 and %v in UTC", config.ID, config.CreatedAt, created)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestConfigRepository_UpdateUserConfig_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE user_configs")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT created_at, updated_at FROM user_configs WHERE id = ?")).
+		WithArgs(4).
+		WillReturnError(sql.ErrNoRows)
+
+	repo := NewConfigRepository(db, repository.ContentCodec{}, true)
+	err = repo.UpdateUserConfig(4, &models.UserConfig{Name: "app", Format: models.FormatYAML})
+	if !errors.Is(err, repository.ErrConfigNotFound) {
+		t.Errorf("UpdateUserConfig() error = %v, want %v", err, repository.ErrConfigNotFound)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestConfigRepository_UpdateImport_UnchangedRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// MySQL reports 0 affected rows both for an unchanged import and a missing one
+	for id, exists := range map[int]bool{1: true, 2: false} {
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE config_imports")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM config_imports WHERE id = ?)")).
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(exists))
+
+		err := NewConfigRepository(db, repository.ContentCodec{}, false).
+			UpdateImport(id, &models.ConfigImport{Status: models.ImportCompleted})
+		if exists && err != nil {
+			t.Errorf("UpdateImport(%d) error = %v, want nil for an unchanged import", id, err)
+		}
+		if !exists && !errors.Is(err, repository.ErrImportNotFound) {
+			t.Errorf("UpdateImport(%d) error = %v, want %v", id, err, repository.ErrImportNotFound)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
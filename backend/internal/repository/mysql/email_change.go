@@ -0,0 +1,76 @@
+// MySQL implementation of EmailChangeRepository interface
+// Handles pending email change persistence specific to MySQL database
+// Tokens are stored by hash, so a leaked row can't confirm a change
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"conflux/internal/models"
+)
+
+// EmailChangeRepository implements service.EmailChangeRepository for MySQL
+type EmailChangeRepository struct {
+	db *sql.DB
+}
+
+// NewEmailChangeRepository creates a new MySQL email change repository
+func NewEmailChangeRepository(db *sql.DB) *EmailChangeRepository {
+	return &EmailChangeRepository{db: db}
+}
+
+// Create inserts a pending email change into MySQL database
+func (r *EmailChangeRepository) Create(ctx context.Context, change *models.EmailChange) error {
+	query := `
+		INSERT INTO email_changes (user_id, new_email, token_hash, expires_at) 
+		VALUES (?, ?, ?, ?)`
+
+	result, err := r.db.ExecContext(ctx, query,
+		change.UserID, change.NewEmail, change.TokenHash, change.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	change.ID = int(id)
+
+	// MySQL has no RETURNING; read back the database-generated timestamp
+	err = r.db.QueryRowContext(ctx, `SELECT created_at FROM email_changes WHERE id = ?`, change.ID).Scan(&change.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	change.NormalizeTimestamps()
+	return nil
+}
+
+// GetByTokenHash retrieves a pending email change by its token hash
+func (r *EmailChangeRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.EmailChange, error) {
+	query := `
+		SELECT id, user_id, new_email, token_hash, expires_at, created_at
+		FROM email_changes WHERE token_hash = ?`
+
+	change := &models.EmailChange{}
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&change.ID, &change.UserID, &change.NewEmail, &change.TokenHash, &change.ExpiresAt, &change.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	change.NormalizeTimestamps()
+	return change, nil
+}
+
+// DeleteForUser removes every pending email change for the user
+func (r *EmailChangeRepository) DeleteForUser(ctx context.Context, userID int) error {
+	query := `DELETE FROM email_changes WHERE user_id = ?`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
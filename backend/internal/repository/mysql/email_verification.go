@@ -0,0 +1,95 @@
+// MySQL implementation of EmailVerificationRepository interface
+// Handles account email verification token persistence specific to MySQL database
+// Tokens are stored by hash, so a leaked row can't verify an account
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"conflux/internal/models"
+)
+
+// EmailVerificationRepository implements service.EmailVerificationRepository for MySQL
+type EmailVerificationRepository struct {
+	db *sql.DB
+}
+
+// NewEmailVerificationRepository creates a new MySQL email verification repository
+func NewEmailVerificationRepository(db *sql.DB) *EmailVerificationRepository {
+	return &EmailVerificationRepository{db: db}
+}
+
+// Create inserts a verification token into MySQL database
+func (r *EmailVerificationRepository) Create(ctx context.Context, verification *models.EmailVerification) error {
+	query := `
+		INSERT INTO email_verifications (user_id, token_hash, expires_at) 
+		VALUES (?, ?, ?)`
+
+	result, err := r.db.ExecContext(ctx, query,
+		verification.UserID, verification.TokenHash, verification.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	verification.ID = int(id)
+
+	// MySQL has no RETURNING; read back the database-generated timestamp
+	err = r.db.QueryRowContext(ctx, `SELECT created_at FROM email_verifications WHERE id = ?`, verification.ID).Scan(&verification.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	verification.NormalizeTimestamps()
+	return nil
+}
+
+// GetByTokenHash retrieves a verification by its token hash
+func (r *EmailVerificationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.EmailVerification, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, created_at
+		FROM email_verifications WHERE token_hash = ?`
+
+	verification := &models.EmailVerification{}
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&verification.ID, &verification.UserID, &verification.TokenHash, &verification.ExpiresAt, &verification.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	verification.NormalizeTimestamps()
+	return verification, nil
+}
+
+// GetLatestForUser retrieves the user's most recently issued verification
+func (r *EmailVerificationRepository) GetLatestForUser(ctx context.Context, userID int) (*models.EmailVerification, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, created_at
+		FROM email_verifications WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC LIMIT 1`
+
+	verification := &models.EmailVerification{}
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&verification.ID, &verification.UserID, &verification.TokenHash, &verification.ExpiresAt, &verification.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	verification.NormalizeTimestamps()
+	return verification, nil
+}
+
+// DeleteForUser removes every verification token for the user
+func (r *EmailVerificationRepository) DeleteForUser(ctx context.Context, userID int) error {
+	query := `DELETE FROM email_verifications WHERE user_id = ?`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
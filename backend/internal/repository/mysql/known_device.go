@@ -0,0 +1,45 @@
+// MySQL implementation of KnownDeviceRepository interface
+// Remembers which devices each user has signed in from, by fingerprint only
+package mysql
+
+import (
+	"context"
+	"database/sql"
+)
+
+// KnownDeviceRepository implements service.KnownDeviceRepository for MySQL
+type KnownDeviceRepository struct {
+	db *sql.DB
+}
+
+// NewKnownDeviceRepository creates a new MySQL known device repository
+func NewKnownDeviceRepository(db *sql.DB) *KnownDeviceRepository {
+	return &KnownDeviceRepository{db: db}
+}
+
+// CountDevices returns how many devices the user has signed in from
+func (r *KnownDeviceRepository) CountDevices(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM known_devices WHERE user_id = ?`, userID).Scan(&count)
+	return count, err
+}
+
+// RememberDevice records a sign-in from the device, reporting whether it was new
+func (r *KnownDeviceRepository) RememberDevice(ctx context.Context, userID int, fingerprint string) (bool, error) {
+	query := `
+		INSERT INTO known_devices (user_id, fingerprint) 
+		VALUES (?, ?) 
+		ON DUPLICATE KEY UPDATE last_seen_at = CURRENT_TIMESTAMP`
+
+	result, err := r.db.ExecContext(ctx, query, userID, fingerprint)
+	if err != nil {
+		return false, err
+	}
+
+	// MySQL reports 1 affected row for an insert and 2 (or 0) for an update
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
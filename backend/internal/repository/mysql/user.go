@@ -6,9 +6,11 @@ package mysql
 import (
 	"context"
 	"database/sql"
-	"time"
+	"errors"
 
 	"conflux/internal/models"
+
+	"github.com/go-sql-driver/mysql"
 )
 
 // UserRepository implements repository.UserRepository for MySQL
@@ -24,10 +26,15 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 // Create inserts a new user into MySQL database
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (email, password_hash, first_name, last_name) 
-		VALUES (?, ?, ?, ?)`
+		INSERT INTO users (email, password_hash, first_name, last_name, email_verified, password_reset_required) 
+		VALUES (?, ?, ?, ?, ?, ?)`
 
-	result, err := r.db.ExecContext(ctx, query, user.Email, user.Password, user.FirstName, user.LastName)
+	result, err := r.db.ExecContext(ctx, query,
+		user.Email, user.Password, user.FirstName, user.LastName, user.EmailVerified, user.PasswordResetRequired,
+	)
+	if isDuplicateKey(err) {
+		return models.ErrEmailTaken
+	}
 	if err != nil {
 		return err
 	}
@@ -38,47 +45,54 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	}
 
 	user.ID = int(id)
-	user.CreatedAt = time.Now()
-	user.UpdatedAt = time.Now()
 
+	// MySQL has no RETURNING; read back the database-generated timestamps
+	query = `SELECT created_at, updated_at FROM users WHERE id = ?`
+	if err := r.db.QueryRowContext(ctx, query, user.ID).Scan(&user.CreatedAt, &user.UpdatedAt); err != nil {
+		return err
+	}
+
+	user.NormalizeTimestamps()
 	return nil
 }
 
 // GetByID retrieves user by ID from MySQL
 func (r *UserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
 	query := `
-		SELECT id, email, password_hash, first_name, last_name, created_at, updated_at 
+		SELECT id, email, password_hash, first_name, last_name, role, preferences, email_verified, password_reset_required, created_at, updated_at 
 		FROM users WHERE id = ?`
 
 	user := &models.User{}
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.Role, &user.Preferences, &user.EmailVerified, &user.PasswordResetRequired, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
+	user.NormalizeTimestamps()
 	return user, nil
 }
 
 // GetByEmail retrieves user by email from MySQL
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT id, email, password_hash, first_name, last_name, created_at, updated_at 
+		SELECT id, email, password_hash, first_name, last_name, role, preferences, email_verified, password_reset_required, created_at, updated_at 
 		FROM users WHERE email = ?`
 
 	user := &models.User{}
 	err := r.db.QueryRowContext(ctx, query, email).Scan(
 		&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.Role, &user.Preferences, &user.EmailVerified, &user.PasswordResetRequired, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
+	user.NormalizeTimestamps()
 	return user, nil
 }
 
@@ -89,7 +103,46 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 		SET email = ?, first_name = ?, last_name = ?, updated_at = CURRENT_TIMESTAMP 
 		WHERE id = ?`
 
-	_, err := r.db.ExecContext(ctx, query, user.Email, user.FirstName, user.LastName, user.ID)
+	if _, err := r.db.ExecContext(ctx, query, user.Email, user.FirstName, user.LastName, user.ID); err != nil {
+		return err
+	}
+
+	// Read back the database-generated timestamp
+	query = `SELECT updated_at FROM users WHERE id = ?`
+	if err := r.db.QueryRowContext(ctx, query, user.ID).Scan(&user.UpdatedAt); err != nil {
+		return err
+	}
+
+	user.NormalizeTimestamps()
+	return nil
+}
+
+// UpdatePreferences replaces the user's stored preferences in MySQL
+func (r *UserRepository) UpdatePreferences(ctx context.Context, userID int, prefs models.UserPreferences) error {
+	query := `
+		UPDATE users 
+		SET preferences = ?, updated_at = CURRENT_TIMESTAMP 
+		WHERE id = ?`
+
+	_, err := r.db.ExecContext(ctx, query, prefs, userID)
+	return err
+}
+
+// MarkEmailVerified flags the user's email as verified in MySQL
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userID int) error {
+	query := `UPDATE users SET email_verified = TRUE, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+// UpdatePassword stores a new password hash and clears any pending reset in MySQL
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	query := `
+		UPDATE users 
+		SET password_hash = ?, password_reset_required = FALSE, updated_at = CURRENT_TIMESTAMP 
+		WHERE id = ?`
+
+	_, err := r.db.ExecContext(ctx, query, passwordHash, userID)
 	return err
 }
 
@@ -99,3 +152,9 @@ func (r *UserRepository) Delete(ctx context.Context, id int) error {
 	_, err := r.db.ExecContext(ctx, query, id)
 	return err
 }
+
+// isDuplicateKey reports whether err is a MySQL unique-index violation
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 // ER_DUP_ENTRY
+}
@@ -0,0 +1,82 @@
+// PostgreSQL implementation of AuditRepository interface
+// Persists audit log entries specific to PostgreSQL database
+// Entries are insert-only
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"conflux/internal/models"
+)
+
+// AuditRepository implements service.AuditRepository for PostgreSQL
+type AuditRepository struct {
+	db *sql.DB
+}
+
+// NewAuditRepository creates a new PostgreSQL audit repository
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Create inserts a new audit entry into PostgreSQL database
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (actor_id, action, target_user_id, target_config_id, target_name, ip_address, details) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7) 
+		RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		entry.ActorID, entry.Action, entry.TargetUserID, entry.TargetConfigID, entry.TargetName,
+		entry.IPAddress, entry.Details,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	entry.NormalizeTimestamps()
+	return nil
+}
+
+// ListByActor returns a page of the actor's entries whose action starts with actionPrefix, newest first
+func (r *AuditRepository) ListByActor(
+	ctx context.Context, actorID int, actionPrefix string, page, limit int,
+) ([]*models.AuditEntry, int64, error) {
+	pattern := actionPrefix + "%"
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM audit_log WHERE actor_id = $1 AND action LIKE $2`
+	if err := r.db.QueryRowContext(ctx, countQuery, actorID, pattern).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT id, actor_id, action, target_user_id, target_config_id, target_name, ip_address, details, created_at
+		FROM audit_log WHERE actor_id = $1 AND action LIKE $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := r.db.QueryContext(ctx, query, actorID, pattern, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditEntry{}
+	for rows.Next() {
+		entry := &models.AuditEntry{}
+		var details sql.NullString
+		if err := rows.Scan(
+			&entry.ID, &entry.ActorID, &entry.Action, &entry.TargetUserID, &entry.TargetConfigID,
+			&entry.TargetName, &entry.IPAddress, &details, &entry.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		entry.Details = details.String
+		entry.NormalizeTimestamps()
+		entries = append(entries, entry)
+	}
+
+	return entries, total, rows.Err()
+}
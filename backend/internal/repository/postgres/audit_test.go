@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestAuditRepository_Create_UsesDatabaseTimestamp(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	created := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("RETURNING id, created_at")).
+		WithArgs(1, "api_keys.rotated", nil, nil, "", "127.0.0.1", "rotated").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, created))
+
+	entry := &models.AuditEntry{ActorID: 1, Action: "api_keys.rotated", IPAddress: "127.0.0.1", Details: "rotated"}
+	if err := NewAuditRepository(db).Create(context.Background(), entry); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if entry.ID != 3 || !entry.CreatedAt.Equal(created) {
+		t.Errorf("entry id=%d created_at=%v, want 3 and %v", entry.ID, entry.CreatedAt, created)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAuditRepository_ListByActor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	created := time.Date(2001, 2, 3, 4, 5, 6, 0, time.FixedZone("EST", -5*60*60))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM audit_log")).
+		WithArgs(7, "config.%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+	mock.ExpectQuery(regexp.QuoteMeta("ORDER BY created_at DESC, id DESC")).
+		WithArgs(7, "config.%", 10, 20).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "actor_id", "action", "target_user_id", "target_config_id", "target_name", "ip_address", "details", "created_at",
+		}).AddRow(5, 7, "config.updated", nil, 3, "app", "", nil, created))
+
+	entries, total, err := NewAuditRepository(db).ListByActor(context.Background(), 7, "config.", 3, 10)
+	if err != nil {
+		t.Fatalf("ListByActor() error = %v", err)
+	}
+	if total != 21 || len(entries) != 1 {
+		t.Fatalf("ListByActor() = %d entries, total %d; want 1 and 21", len(entries), total)
+	}
+	entry := entries[0]
+	if entry.TargetConfigID == nil || *entry.TargetConfigID != 3 || entry.TargetName != "app" {
+		t.Errorf("entry target = %v %q, want 3 app", entry.TargetConfigID, entry.TargetName)
+	}
+	if entry.CreatedAt.Location() != time.UTC {
+		t.Errorf("created_at = %v, want UTC", entry.CreatedAt)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -22,25 +22,40 @@ func NewAuthRepository(db *sql.DB) *AuthRepository {
 }
 
 // CreateSession creates a new session record in PostgreSQL
-func (r *AuthRepository) CreateSession(ctx context.Context, userID int, token string, expiresAt time.Time) error {
+func (r *AuthRepository) CreateSession(ctx context.Context, userID int, sessionID, token string, expiresAt time.Time) error {
 	query := `
-		INSERT INTO sessions (user_id, token, expires_at) 
-		VALUES ($1, $2, $3)`
+		INSERT INTO sessions (user_id, session_id, token, expires_at)
+		VALUES ($1, $2, $3, $4)`
 
-	_, err := r.db.ExecContext(ctx, query, userID, token, expiresAt)
+	_, err := r.db.ExecContext(ctx, query, userID, sessionID, token, expiresAt)
 	return err
 }
 
 // ValidateSession validates session token and returns user if valid
-func (r *AuthRepository) ValidateSession(ctx context.Context, token string) (*models.User, error) {
+// Sessions idle for longer than idleTimeout are rejected; 0 disables the check
+func (r *AuthRepository) ValidateSession(ctx context.Context, token string, idleTimeout time.Duration) (*models.User, error) {
+	return r.validateSession(ctx, "token", token, idleTimeout)
+}
+
+// ValidateSessionID validates an opaque session ID and returns the user if valid
+// Sessions idle for longer than idleTimeout are rejected; 0 disables the check
+func (r *AuthRepository) ValidateSessionID(ctx context.Context, sessionID string, idleTimeout time.Duration) (*models.User, error) {
+	return r.validateSession(ctx, "session_id", sessionID, idleTimeout)
+}
+
+// validateSession looks up the user for the session whose column matches value
+// and records the lookup as activity on the session
+func (r *AuthRepository) validateSession(ctx context.Context, column, value string, idleTimeout time.Duration) (*models.User, error) {
 	query := `
 		SELECT u.id, u.email, u.password_hash, u.first_name, u.last_name, u.created_at, u.updated_at
 		FROM users u
 		INNER JOIN sessions s ON u.id = s.user_id
-		WHERE s.token = $1 AND s.expires_at > NOW()`
+		WHERE s.` + column + ` = $1 AND s.expires_at > NOW()
+		AND ($2::bigint = 0 OR s.last_activity > NOW() - $2::bigint * INTERVAL '1 second')`
 
+	idle := idleSeconds(idleTimeout)
 	user := &models.User{}
-	err := r.db.QueryRowContext(ctx, query, token).Scan(
+	err := r.db.QueryRowContext(ctx, query, value, idle).Scan(
 		&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName,
 		&user.CreatedAt, &user.UpdatedAt,
 	)
@@ -49,12 +64,106 @@ func (r *AuthRepository) ValidateSession(ctx context.Context, token string) (*mo
 		return nil, err
 	}
 
+	touch := `UPDATE sessions SET last_activity = NOW() WHERE ` + column + ` = $1`
+	if _, err := r.db.ExecContext(ctx, touch, value); err != nil {
+		return nil, err
+	}
+
+	user.NormalizeTimestamps()
 	return user, nil
 }
 
+// ListSessions returns a user's unexpired sessions that have a session ID, newest first
+// Sessions idle for longer than idleTimeout are left out; 0 disables the check
+func (r *AuthRepository) ListSessions(ctx context.Context, userID int, idleTimeout time.Duration) ([]*models.Session, error) {
+	query := `
+		SELECT id, user_id, session_id, expires_at, last_activity, created_at
+		FROM sessions
+		WHERE user_id = $1 AND session_id IS NOT NULL AND expires_at > NOW()
+		AND ($2::bigint = 0 OR last_activity > NOW() - $2::bigint * INTERVAL '1 second')
+		ORDER BY created_at DESC, id DESC`
+
+	idle := idleSeconds(idleTimeout)
+	rows, err := r.db.QueryContext(ctx, query, userID, idle)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		session := &models.Session{}
+		if err := rows.Scan(
+			&session.ID, &session.UserID, &session.SessionID, &session.ExpiresAt,
+			&session.LastActivity, &session.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		session.NormalizeTimestamps()
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
 // InvalidateSession removes session from PostgreSQL database
 func (r *AuthRepository) InvalidateSession(ctx context.Context, token string) error {
 	query := `DELETE FROM sessions WHERE token = $1`
 	_, err := r.db.ExecContext(ctx, query, token)
 	return err
 }
+
+// InvalidateSessionID removes one of a user's sessions by its session ID
+// Reports whether a session was removed
+func (r *AuthRepository) InvalidateSessionID(ctx context.Context, userID int, sessionID string) (bool, error) {
+	query := `DELETE FROM sessions WHERE user_id = $1 AND session_id = $2`
+	result, err := r.db.ExecContext(ctx, query, userID, sessionID)
+	if err != nil {
+		return false, err
+	}
+	removed, err := result.RowsAffected()
+	return removed > 0, err
+}
+
+// InvalidateAllSessions removes every session belonging to a user
+// Returns the number of sessions removed
+func (r *AuthRepository) InvalidateAllSessions(ctx context.Context, userID int) (int64, error) {
+	query := `DELETE FROM sessions WHERE user_id = $1`
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// InvalidateOtherSessions removes every session of a user except keepSessionID
+// Sessions without a session ID can't be told apart and are removed too
+// Returns the number of sessions removed
+func (r *AuthRepository) InvalidateOtherSessions(ctx context.Context, userID int, keepSessionID string) (int64, error) {
+	query := `DELETE FROM sessions WHERE user_id = $1 AND (session_id IS NULL OR session_id <> $2)`
+	result, err := r.db.ExecContext(ctx, query, userID, keepSessionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteExpiredSessions removes sessions past their expiry or, when idleTimeout
+// is positive, idle for longer than it
+// Returns the number of sessions removed
+func (r *AuthRepository) DeleteExpiredSessions(ctx context.Context, idleTimeout time.Duration) (int64, error) {
+	query := `
+		DELETE FROM sessions
+		WHERE expires_at <= NOW() OR ($1::bigint > 0 AND last_activity <= NOW() - $1::bigint * INTERVAL '1 second')`
+
+	idle := idleSeconds(idleTimeout)
+	result, err := r.db.ExecContext(ctx, query, idle)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// idleSeconds converts an idle timeout to whole seconds for SQL interval math
+func idleSeconds(timeout time.Duration) int64 {
+	return int64(timeout / time.Second)
+}
@@ -0,0 +1,1063 @@
+// PostgreSQL implementation of ConfigRepository interface
+// Handles templates, user configs, versions, locks, shares, and imports
+// Content columns pass through the repository's ContentCodec
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"conflux/internal/models"
+	"conflux/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// Column lists shared by the queries reading each table
+const (
+	templateColumns = `id, name, display_name, description, version, category, format, supported_formats,
+		default_content, schema, created_at, updated_at`
+	userConfigColumns = `id, user_id, template_id, name, template_version, description, format, content,
+		is_shared, forked_from, created_at, updated_at`
+	versionColumns = `id, config_id, version, content, change_note, restored_from, change_set, created_by, created_at`
+	importColumns  = `id, user_id, source_type, source_url, status, error_message, source_failed, config_id,
+		attempts, retry_after, dedupe, dedup_result, created_at, completed_at`
+	variableColumns = `id, template_id, name, path, type, description, default_value, required, validation_rule`
+)
+
+// rowScanner is a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// ConfigRepository implements repository.ConfigRepository for PostgreSQL
+type ConfigRepository struct {
+	db          *sql.DB
+	content     repository.ContentCodec
+	uniqueNames bool
+}
+
+// NewConfigRepository creates a new PostgreSQL config repository
+// Config and version content is stored through content; with uniqueNames, the
+// name_key unique index keeps each user's configuration names distinct
+func NewConfigRepository(db *sql.DB, content repository.ContentCodec, uniqueNames bool) *ConfigRepository {
+	return &ConfigRepository{db: db, content: content, uniqueNames: uniqueNames}
+}
+
+// Template management
+
+// CreateTemplate inserts a template and its variables in one transaction
+func (r *ConfigRepository) CreateTemplate(template *models.ConfigTemplate) error {
+	supportedFormats, err := json.Marshal(template.SupportedFormats)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO config_templates (name, display_name, description, version, category, format,
+			supported_formats, default_content, schema)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRow(query,
+		template.Name, template.DisplayName, template.Description, template.Version, template.Category,
+		template.Format, string(supportedFormats), template.DefaultContent, template.Schema,
+	).Scan(&template.ID, &template.CreatedAt, &template.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := insertVariables(tx, template.ID, template.Variables); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	template.NormalizeTimestamps()
+	return nil
+}
+
+// GetTemplate retrieves a template with its variables
+func (r *ConfigRepository) GetTemplate(id int) (*models.ConfigTemplate, error) {
+	query := `SELECT ` + templateColumns + ` FROM config_templates WHERE id = $1`
+
+	template, err := scanTemplate(r.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	variables, err := r.variablesByTemplate(template.ID)
+	if err != nil {
+		return nil, err
+	}
+	template.Variables = variables[template.ID]
+	return template, nil
+}
+
+// GetTemplates returns a page of templates in a category, matching search, in ID order
+// Empty category and search match every template
+func (r *ConfigRepository) GetTemplates(category, search string, page, limit int) ([]*models.ConfigTemplate, int64, error) {
+	var conditions []string
+	var args []interface{}
+	if category != "" {
+		args = append(args, category)
+		conditions = append(conditions, "category = $"+strconv.Itoa(len(args)))
+	}
+	if search != "" {
+		args = append(args, "%"+search+"%")
+		n := "$" + strconv.Itoa(len(args))
+		conditions = append(conditions, "(name ILIKE "+n+" OR display_name ILIKE "+n+" OR description ILIKE "+n+")")
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM config_templates`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + templateColumns + ` FROM config_templates` + where +
+		fmt.Sprintf(" ORDER BY id LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	rows, err := r.db.Query(query, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	templates := []*models.ConfigTemplate{}
+	var ids []int
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		templates = append(templates, template)
+		ids = append(ids, template.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	variables, err := r.variablesByTemplate(ids...)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, template := range templates {
+		template.Variables = variables[template.ID]
+	}
+	return templates, total, nil
+}
+
+// UpdateTemplate applies the set fields of updates and fills in the stored result
+// Empty strings and nil schema, supported formats, or variables leave the stored
+// value unchanged; non-nil variables replace the template's variables
+// A non-nil plan sees the template as stored within the same transaction; the
+// version it returns becomes the template's version and is added to its history
+func (r *ConfigRepository) UpdateTemplate(
+	id int, updates *models.ConfigTemplate, plan models.TemplateVersionPlanner,
+) error {
+	var supportedFormats interface{}
+	if updates.SupportedFormats != nil {
+		data, err := json.Marshal(updates.SupportedFormats)
+		if err != nil {
+			return err
+		}
+		supportedFormats = string(data)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var version *models.TemplateVersion
+	if plan != nil {
+		existing, variables, err := templateForUpdate(tx, id)
+		if err != nil {
+			return err
+		}
+		if version = plan(existing, variables); version != nil {
+			version.TemplateID = id
+			updates.Version = version.Version
+		}
+	}
+
+	query := `
+		UPDATE config_templates SET
+			name = COALESCE(NULLIF($1, ''), name),
+			display_name = COALESCE(NULLIF($2, ''), display_name),
+			description = COALESCE(NULLIF($3, ''), description),
+			version = COALESCE(NULLIF($4, ''), version),
+			category = COALESCE(NULLIF($5, ''), category),
+			format = COALESCE(NULLIF($6, ''), format),
+			supported_formats = COALESCE($7::jsonb, supported_formats),
+			default_content = COALESCE(NULLIF($8, ''), default_content),
+			schema = COALESCE($9, schema),
+			updated_at = NOW()
+		WHERE id = $10
+		RETURNING ` + templateColumns
+
+	stored, err := scanTemplate(tx.QueryRow(query,
+		updates.Name, updates.DisplayName, updates.Description, updates.Version, updates.Category,
+		updates.Format, supportedFormats, updates.DefaultContent, updates.Schema, id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrTemplateNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if updates.Variables != nil {
+		if _, err := tx.Exec(`DELETE FROM config_variables WHERE template_id = $1`, id); err != nil {
+			return err
+		}
+		if err := insertVariables(tx, id, updates.Variables); err != nil {
+			return err
+		}
+		stored.Variables = updates.Variables
+	}
+	if version != nil {
+		if err := r.insertTemplateVersion(tx, version); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if stored.Variables == nil {
+		variables, err := r.variablesByTemplate(id)
+		if err != nil {
+			return err
+		}
+		stored.Variables = variables[id]
+	}
+	stored.Warnings = updates.Warnings
+	*updates = *stored
+	return nil
+}
+
+// DeleteTemplate removes a template with its variables and version history
+// Configurations created from it are kept and lose their template link
+func (r *ConfigRepository) DeleteTemplate(id int) error {
+	_, err := r.db.Exec(`DELETE FROM config_templates WHERE id = $1`, id)
+	return err
+}
+
+// Template version history
+
+// insertTemplateVersion records a template version bump within tx
+func (r *ConfigRepository) insertTemplateVersion(tx *sql.Tx, version *models.TemplateVersion) error {
+	changes, err := json.Marshal(emptyIfNilStrings(version.Changes))
+	if err != nil {
+		return err
+	}
+	previousContent, err := r.encodeOptional(version.PreviousContent)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO template_versions (template_id, version, previous_version, bump, changes, previous_content)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	err = tx.QueryRow(query,
+		version.TemplateID, version.Version, version.PreviousVersion, version.Bump, string(changes), previousContent,
+	).Scan(&version.ID, &version.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	version.NormalizeTimestamps()
+	return nil
+}
+
+// GetTemplateVersions returns a template's version history, newest first
+func (r *ConfigRepository) GetTemplateVersions(templateID int) ([]*models.TemplateVersion, error) {
+	query := `
+		SELECT id, template_id, version, previous_version, bump, changes, previous_content, created_at
+		FROM template_versions WHERE template_id = $1
+		ORDER BY id DESC`
+
+	rows, err := r.db.Query(query, templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []*models.TemplateVersion{}
+	for rows.Next() {
+		version := &models.TemplateVersion{}
+		var changes []byte
+		var previousContent sql.NullString
+		if err := rows.Scan(
+			&version.ID, &version.TemplateID, &version.Version, &version.PreviousVersion, &version.Bump,
+			&changes, &previousContent, &version.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &version.Changes); err != nil {
+			return nil, err
+		}
+		if version.PreviousContent, err = r.decodeOptional(previousContent); err != nil {
+			return nil, err
+		}
+		version.NormalizeTimestamps()
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+// GetTemplateDependents returns a page of configurations created from a template with
+// their owners, most recently updated first
+func (r *ConfigRepository) GetTemplateDependents(templateID, page, limit int) ([]*models.TemplateDependent, int64, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM user_configs WHERE template_id = $1`
+	if err := r.db.QueryRow(countQuery, templateID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT c.id, c.name, c.user_id, u.email, c.template_version, c.updated_at
+		FROM user_configs c JOIN users u ON u.id = c.user_id
+		WHERE c.template_id = $1
+		ORDER BY c.updated_at DESC, c.id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(query, templateID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	dependents := []*models.TemplateDependent{}
+	for rows.Next() {
+		dependent := &models.TemplateDependent{}
+		if err := rows.Scan(
+			&dependent.ConfigID, &dependent.Name, &dependent.OwnerID, &dependent.OwnerEmail,
+			&dependent.TemplateVersion, &dependent.UpdatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		dependent.NormalizeTimestamps()
+		dependents = append(dependents, dependent)
+	}
+	return dependents, total, rows.Err()
+}
+
+// User configuration management
+
+// CreateUserConfig inserts a user configuration
+func (r *ConfigRepository) CreateUserConfig(config *models.UserConfig) error {
+	content, err := r.content.Encode(config.Content)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO user_configs (user_id, template_id, name, name_key, template_version, description, format,
+			content, is_shared, forked_from)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at`
+
+	err = r.db.QueryRow(query,
+		config.UserID, config.TemplateID, config.Name, r.nameKey(config.Name), config.TemplateVersion,
+		config.Description, config.Format, content, config.IsShared, config.ForkedFrom,
+	).Scan(&config.ID, &config.CreatedAt, &config.UpdatedAt)
+	if isDuplicateKey(err) {
+		return fmt.Errorf("you already have a config named %q", config.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	config.NormalizeTimestamps()
+	return nil
+}
+
+// GetUserConfig retrieves a user configuration by ID
+func (r *ConfigRepository) GetUserConfig(id int) (*models.UserConfig, error) {
+	query := `SELECT ` + userConfigColumns + ` FROM user_configs WHERE id = $1`
+
+	config, err := r.scanUserConfig(r.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrConfigNotFound
+	}
+	return config, err
+}
+
+// GetUserConfigs returns a page of the user's configurations, optionally only those
+// created from templateID
+func (r *ConfigRepository) GetUserConfigs(
+	userID int, templateID *int, order models.ListSort, page, limit int,
+) ([]*models.UserConfig, int64, error) {
+	where, args := userConfigFilter(userID, templateID)
+
+	var total int64
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM user_configs WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + userConfigColumns + ` FROM user_configs WHERE ` + where + ` ` + order.OrderBy() +
+		fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	configs, err := r.queryUserConfigs(query, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return configs, total, nil
+}
+
+// GetUserConfigsAfter returns up to limit of the user's configurations with IDs above afterID, in ID order
+func (r *ConfigRepository) GetUserConfigsAfter(userID int, templateID *int, afterID, limit int) ([]*models.UserConfig, error) {
+	where, args := userConfigFilter(userID, templateID)
+	query := `SELECT ` + userConfigColumns + ` FROM user_configs WHERE ` + where +
+		fmt.Sprintf(" AND id > $%d ORDER BY id LIMIT $%d", len(args)+1, len(args)+2)
+	return r.queryUserConfigs(query, append(args, afterID, limit)...)
+}
+
+// UserConfigNameExists reports whether the user has a configuration with this name, ignoring case
+func (r *ConfigRepository) UserConfigNameExists(userID int, name string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM user_configs WHERE user_id = $1 AND LOWER(name) = LOWER($2))`
+
+	var exists bool
+	err := r.db.QueryRow(query, userID, name).Scan(&exists)
+	return exists, err
+}
+
+// UpdateUserConfig stores a configuration's editable fields and fills in its timestamps
+func (r *ConfigRepository) UpdateUserConfig(id int, config *models.UserConfig) error {
+	content, err := r.content.Encode(config.Content)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE user_configs
+		SET name = $1, name_key = $2, template_version = $3, description = $4, format = $5, content = $6,
+			is_shared = $7, updated_at = NOW()
+		WHERE id = $8
+		RETURNING created_at, updated_at`
+
+	err = r.db.QueryRow(query,
+		config.Name, r.nameKey(config.Name), config.TemplateVersion, config.Description, config.Format, content,
+		config.IsShared, id,
+	).Scan(&config.CreatedAt, &config.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrConfigNotFound
+	}
+	if isDuplicateKey(err) {
+		return fmt.Errorf("you already have a config named %q", config.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	config.NormalizeTimestamps()
+	return nil
+}
+
+// DeleteUserConfig removes a configuration with its versions, lock, and shares
+func (r *ConfigRepository) DeleteUserConfig(id int) error {
+	_, err := r.db.Exec(`DELETE FROM user_configs WHERE id = $1`, id)
+	return err
+}
+
+// Edit locks
+
+// AcquireConfigLock takes or renews the lock for holderID unless another holder's lock is in force
+// Expiry is computed with the database clock; returns the lock in force afterwards
+func (r *ConfigRepository) AcquireConfigLock(configID, holderID int, ttl time.Duration) (*models.ConfigLock, error) {
+	query := `
+		INSERT INTO config_locks (config_id, holder_id, acquired_at, expires_at)
+		VALUES ($1, $2, NOW(), NOW() + $3::bigint * INTERVAL '1 millisecond')
+		ON CONFLICT (config_id) DO UPDATE SET
+			holder_id = EXCLUDED.holder_id,
+			acquired_at = CASE WHEN config_locks.expires_at <= NOW() THEN EXCLUDED.acquired_at
+				ELSE config_locks.acquired_at END,
+			expires_at = EXCLUDED.expires_at
+		WHERE config_locks.holder_id = EXCLUDED.holder_id OR config_locks.expires_at <= NOW()`
+
+	if _, err := r.db.Exec(query, configID, holderID, ttl.Milliseconds()); err != nil {
+		return nil, err
+	}
+
+	lock, err := r.GetConfigLock(configID)
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil {
+		return nil, fmt.Errorf("lock on configuration %d lapsed while acquiring it", configID)
+	}
+	return lock, nil
+}
+
+// GetConfigLock returns the unexpired lock on a configuration, or nil
+func (r *ConfigRepository) GetConfigLock(configID int) (*models.ConfigLock, error) {
+	query := `
+		SELECT config_id, holder_id, acquired_at, expires_at
+		FROM config_locks WHERE config_id = $1 AND expires_at > NOW()`
+
+	lock := &models.ConfigLock{}
+	err := r.db.QueryRow(query, configID).Scan(&lock.ConfigID, &lock.HolderID, &lock.AcquiredAt, &lock.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lock.NormalizeTimestamps()
+	return lock, nil
+}
+
+// ReleaseConfigLock removes the holder's lock on a configuration, if it holds one
+func (r *ConfigRepository) ReleaseConfigLock(configID, holderID int) error {
+	_, err := r.db.Exec(`DELETE FROM config_locks WHERE config_id = $1 AND holder_id = $2`, configID, holderID)
+	return err
+}
+
+// Shares
+
+// ShareConfig records shares in one transaction, replacing the permission of existing ones
+// Returns an error wrapping repository.ErrUserNotFound, with nothing recorded, when a
+// recipient doesn't exist
+func (r *ConfigRepository) ShareConfig(shares []*models.ConfigShare) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO config_shares (config_id, user_id, permission, shared_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (config_id, user_id) DO UPDATE SET
+			permission = EXCLUDED.permission, shared_by = EXCLUDED.shared_by, created_at = NOW()
+		RETURNING created_at`
+
+	for _, share := range shares {
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, share.UserID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("share with user %d: %w", share.UserID, repository.ErrUserNotFound)
+		}
+
+		err := tx.QueryRow(query, share.ConfigID, share.UserID, share.Permission, share.SharedBy).Scan(&share.CreatedAt)
+		if err != nil {
+			return err
+		}
+		share.NormalizeTimestamps()
+	}
+
+	return tx.Commit()
+}
+
+// GetConfigShare returns the configuration's share with a user, or nil
+func (r *ConfigRepository) GetConfigShare(configID, userID int) (*models.ConfigShare, error) {
+	query := `
+		SELECT config_id, user_id, permission, shared_by, created_at
+		FROM config_shares WHERE config_id = $1 AND user_id = $2`
+
+	share := &models.ConfigShare{}
+	err := r.db.QueryRow(query, configID, userID).Scan(
+		&share.ConfigID, &share.UserID, &share.Permission, &share.SharedBy, &share.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	share.NormalizeTimestamps()
+	return share, nil
+}
+
+// Version management
+
+// CreateVersion inserts a configuration version
+func (r *ConfigRepository) CreateVersion(version *models.ConfigVersion) error {
+	content, err := r.content.Encode(version.Content)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO config_versions (config_id, version, content, change_note, restored_from, change_set, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	err = r.db.QueryRow(query,
+		version.ConfigID, version.Version, content, version.ChangeNote, version.RestoredFrom, version.ChangeSet,
+		version.CreatedBy,
+	).Scan(&version.ID, &version.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	version.NormalizeTimestamps()
+	return nil
+}
+
+// GetConfigVersion retrieves a configuration version by ID
+func (r *ConfigRepository) GetConfigVersion(id int) (*models.ConfigVersion, error) {
+	query := `SELECT ` + versionColumns + ` FROM config_versions WHERE id = $1`
+
+	version, err := r.scanVersion(r.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrVersionNotFound
+	}
+	return version, err
+}
+
+// GetConfigVersions returns a page of a configuration's versions
+func (r *ConfigRepository) GetConfigVersions(
+	configID int, order models.ListSort, page, limit int,
+) ([]*models.ConfigVersion, int64, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM config_versions WHERE config_id = $1`
+	if err := r.db.QueryRow(countQuery, configID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + versionColumns + ` FROM config_versions WHERE config_id = $1 ` + order.OrderBy() +
+		` LIMIT $2 OFFSET $3`
+	versions, err := r.queryVersions(query, configID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return versions, total, nil
+}
+
+// GetConfigVersionsBefore returns up to limit versions with IDs below beforeID, newest first
+// A beforeID of 0 starts at the newest version
+func (r *ConfigRepository) GetConfigVersionsBefore(configID, beforeID, limit int) ([]*models.ConfigVersion, error) {
+	if beforeID > 0 {
+		query := `SELECT ` + versionColumns + ` FROM config_versions WHERE config_id = $1 AND id < $2
+			ORDER BY id DESC LIMIT $3`
+		return r.queryVersions(query, configID, beforeID, limit)
+	}
+
+	query := `SELECT ` + versionColumns + ` FROM config_versions WHERE config_id = $1 ORDER BY id DESC LIMIT $2`
+	return r.queryVersions(query, configID, limit)
+}
+
+// Import management
+
+// CreateImport inserts an import record
+func (r *ConfigRepository) CreateImport(importRecord *models.ConfigImport) error {
+	query := `
+		INSERT INTO config_imports (user_id, source_type, source_url, status, error_message, source_failed,
+			config_id, attempts, retry_after, dedupe, dedup_result, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at`
+
+	err := r.db.QueryRow(query,
+		importRecord.UserID, importRecord.SourceType, importRecord.SourceURL, importRecord.Status,
+		importRecord.ErrorMessage, importRecord.SourceFailed, importRecord.ConfigID, importRecord.Attempts,
+		importRecord.RetryAfter, importRecord.Dedupe, importRecord.DedupResult, importRecord.CompletedAt,
+	).Scan(&importRecord.ID, &importRecord.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	importRecord.NormalizeTimestamps()
+	return nil
+}
+
+// GetImport retrieves an import record by ID
+func (r *ConfigRepository) GetImport(id int) (*models.ConfigImport, error) {
+	query := `SELECT ` + importColumns + ` FROM config_imports WHERE id = $1`
+
+	importRecord, err := scanImport(r.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrImportNotFound
+	}
+	return importRecord, err
+}
+
+// UpdateImport stores an import's progress and outcome
+func (r *ConfigRepository) UpdateImport(id int, updates *models.ConfigImport) error {
+	query := `
+		UPDATE config_imports
+		SET status = $1, error_message = $2, source_failed = $3, config_id = $4, attempts = $5,
+			retry_after = $6, dedup_result = $7, completed_at = $8
+		WHERE id = $9`
+
+	result, err := r.db.Exec(query,
+		updates.Status, updates.ErrorMessage, updates.SourceFailed, updates.ConfigID, updates.Attempts,
+		updates.RetryAfter, updates.DedupResult, updates.CompletedAt, id,
+	)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return repository.ErrImportNotFound
+	}
+	return nil
+}
+
+// GetLatestCompletedImport returns the user's most recent completed import of a source
+func (r *ConfigRepository) GetLatestCompletedImport(userID int, sourceURL string) (*models.ConfigImport, error) {
+	query := `SELECT ` + importColumns + ` FROM config_imports
+		WHERE user_id = $1 AND source_url = $2 AND status = $3
+		ORDER BY id DESC LIMIT 1`
+
+	importRecord, err := scanImport(r.db.QueryRow(query, userID, sourceURL, models.ImportCompleted))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrImportNotFound
+	}
+	return importRecord, err
+}
+
+// GetImportsByStatus returns a page of imports in a status across all users, oldest first
+func (r *ConfigRepository) GetImportsByStatus(status models.ImportStatus, page, limit int) ([]*models.ConfigImport, int64, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM config_imports WHERE status = $1`
+	if err := r.db.QueryRow(countQuery, status).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + importColumns + ` FROM config_imports WHERE status = $1
+		ORDER BY created_at, id LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(query, status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	imports := []*models.ConfigImport{}
+	for rows.Next() {
+		importRecord, err := scanImport(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		imports = append(imports, importRecord)
+	}
+	return imports, total, rows.Err()
+}
+
+// Template variables
+
+// CreateVariable inserts a template variable
+func (r *ConfigRepository) CreateVariable(variable *models.ConfigVariable) error {
+	query := `
+		INSERT INTO config_variables (template_id, name, path, type, description, default_value, required, validation_rule)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	return r.db.QueryRow(query,
+		variable.TemplateID, variable.Name, variable.Path, variable.Type, variable.Description,
+		variable.DefaultValue, variable.Required, variable.ValidationRule,
+	).Scan(&variable.ID)
+}
+
+// GetTemplateVariables returns a template's variables in ID order
+func (r *ConfigRepository) GetTemplateVariables(templateID int) ([]*models.ConfigVariable, error) {
+	query := `SELECT ` + variableColumns + ` FROM config_variables WHERE template_id = $1 ORDER BY id`
+
+	rows, err := r.db.Query(query, templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variables := []*models.ConfigVariable{}
+	for rows.Next() {
+		variable, err := scanVariable(rows)
+		if err != nil {
+			return nil, err
+		}
+		variables = append(variables, variable)
+	}
+	return variables, rows.Err()
+}
+
+// UpdateVariable replaces a template variable's definition
+func (r *ConfigRepository) UpdateVariable(id int, updates *models.ConfigVariable) error {
+	query := `
+		UPDATE config_variables
+		SET name = $1, path = $2, type = $3, description = $4, default_value = $5, required = $6, validation_rule = $7
+		WHERE id = $8`
+
+	_, err := r.db.Exec(query,
+		updates.Name, updates.Path, updates.Type, updates.Description, updates.DefaultValue, updates.Required,
+		updates.ValidationRule, id,
+	)
+	return err
+}
+
+// DeleteVariable removes a template variable
+func (r *ConfigRepository) DeleteVariable(id int) error {
+	_, err := r.db.Exec(`DELETE FROM config_variables WHERE id = $1`, id)
+	return err
+}
+
+// nameKey returns the value for name_key: the lowercased name while unique names
+// are enforced, otherwise NULL so the unique index ignores the row
+func (r *ConfigRepository) nameKey(name string) interface{} {
+	if !r.uniqueNames {
+		return nil
+	}
+	return strings.ToLower(name)
+}
+
+// encodeOptional encodes optional content, keeping NULL as NULL
+func (r *ConfigRepository) encodeOptional(content *string) (interface{}, error) {
+	if content == nil {
+		return nil, nil
+	}
+	return r.content.Encode(*content)
+}
+
+// decodeOptional decodes optional stored content
+func (r *ConfigRepository) decodeOptional(stored sql.NullString) (*string, error) {
+	if !stored.Valid {
+		return nil, nil
+	}
+	content, err := r.content.Decode(stored.String)
+	if err != nil {
+		return nil, err
+	}
+	return &content, nil
+}
+
+// variablesByTemplate loads the variables of the given templates, keyed by template ID
+func (r *ConfigRepository) variablesByTemplate(templateIDs ...int) (map[int][]models.ConfigVariable, error) {
+	byTemplate := make(map[int][]models.ConfigVariable, len(templateIDs))
+	if len(templateIDs) == 0 {
+		return byTemplate, nil
+	}
+	for _, id := range templateIDs {
+		byTemplate[id] = []models.ConfigVariable{}
+	}
+
+	query := `SELECT ` + variableColumns + ` FROM config_variables WHERE template_id = ANY($1) ORDER BY id`
+	rows, err := r.db.Query(query, pq.Array(templateIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		variable, err := scanVariable(rows)
+		if err != nil {
+			return nil, err
+		}
+		byTemplate[variable.TemplateID] = append(byTemplate[variable.TemplateID], *variable)
+	}
+	return byTemplate, rows.Err()
+}
+
+// insertVariables stores a template's variables, filling in their IDs
+func insertVariables(tx *sql.Tx, templateID int, variables []models.ConfigVariable) error {
+	query := `
+		INSERT INTO config_variables (template_id, name, path, type, description, default_value, required, validation_rule)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	for i := range variables {
+		variable := &variables[i]
+		variable.TemplateID = templateID
+		err := tx.QueryRow(query,
+			templateID, variable.Name, variable.Path, variable.Type, variable.Description,
+			variable.DefaultValue, variable.Required, variable.ValidationRule,
+		).Scan(&variable.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// userConfigFilter returns the WHERE clause and arguments selecting a user's configurations
+func userConfigFilter(userID int, templateID *int) (string, []interface{}) {
+	if templateID != nil {
+		return "user_id = $1 AND template_id = $2", []interface{}{userID, *templateID}
+	}
+	return "user_id = $1", []interface{}{userID}
+}
+
+// queryUserConfigs runs a query selecting userConfigColumns
+func (r *ConfigRepository) queryUserConfigs(query string, args ...interface{}) ([]*models.UserConfig, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	configs := []*models.UserConfig{}
+	for rows.Next() {
+		config, err := r.scanUserConfig(rows)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	return configs, rows.Err()
+}
+
+// queryVersions runs a query selecting versionColumns
+func (r *ConfigRepository) queryVersions(query string, args ...interface{}) ([]*models.ConfigVersion, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []*models.ConfigVersion{}
+	for rows.Next() {
+		version, err := r.scanVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+// templateForUpdate reads a template and its variables within tx, locking the row
+func templateForUpdate(tx *sql.Tx, id int) (*models.ConfigTemplate, []*models.ConfigVariable, error) {
+	template, err := scanTemplate(tx.QueryRow(`SELECT `+templateColumns+` FROM config_templates WHERE id = $1 FOR UPDATE`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, repository.ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := tx.Query(`SELECT `+variableColumns+` FROM config_variables WHERE template_id = $1 ORDER BY id`, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	variables := []*models.ConfigVariable{}
+	for rows.Next() {
+		variable, err := scanVariable(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		variables = append(variables, variable)
+	}
+	return template, variables, rows.Err()
+}
+
+// scanTemplate reads a row of templateColumns
+func scanTemplate(row rowScanner) (*models.ConfigTemplate, error) {
+	template := &models.ConfigTemplate{}
+	var supportedFormats []byte
+	if err := row.Scan(
+		&template.ID, &template.Name, &template.DisplayName, &template.Description, &template.Version,
+		&template.Category, &template.Format, &supportedFormats, &template.DefaultContent, &template.Schema,
+		&template.CreatedAt, &template.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if len(supportedFormats) > 0 {
+		if err := json.Unmarshal(supportedFormats, &template.SupportedFormats); err != nil {
+			return nil, err
+		}
+	}
+
+	template.NormalizeTimestamps()
+	return template, nil
+}
+
+// scanUserConfig reads a row of userConfigColumns, decoding the content
+func (r *ConfigRepository) scanUserConfig(row rowScanner) (*models.UserConfig, error) {
+	config := &models.UserConfig{}
+	if err := row.Scan(
+		&config.ID, &config.UserID, &config.TemplateID, &config.Name, &config.TemplateVersion,
+		&config.Description, &config.Format, &config.Content, &config.IsShared, &config.ForkedFrom,
+		&config.CreatedAt, &config.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if config.Content, err = r.content.Decode(config.Content); err != nil {
+		return nil, err
+	}
+	config.NormalizeTimestamps()
+	return config, nil
+}
+
+// scanVersion reads a row of versionColumns, decoding the content
+func (r *ConfigRepository) scanVersion(row rowScanner) (*models.ConfigVersion, error) {
+	version := &models.ConfigVersion{}
+	if err := row.Scan(
+		&version.ID, &version.ConfigID, &version.Version, &version.Content, &version.ChangeNote,
+		&version.RestoredFrom, &version.ChangeSet, &version.CreatedBy, &version.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if version.Content, err = r.content.Decode(version.Content); err != nil {
+		return nil, err
+	}
+	version.NormalizeTimestamps()
+	return version, nil
+}
+
+// scanImport reads a row of importColumns
+func scanImport(row rowScanner) (*models.ConfigImport, error) {
+	importRecord := &models.ConfigImport{}
+	if err := row.Scan(
+		&importRecord.ID, &importRecord.UserID, &importRecord.SourceType, &importRecord.SourceURL,
+		&importRecord.Status, &importRecord.ErrorMessage, &importRecord.SourceFailed, &importRecord.ConfigID,
+		&importRecord.Attempts, &importRecord.RetryAfter, &importRecord.Dedupe, &importRecord.DedupResult,
+		&importRecord.CreatedAt, &importRecord.CompletedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	importRecord.NormalizeTimestamps()
+	return importRecord, nil
+}
+
+// scanVariable reads a row of variableColumns
+func scanVariable(row rowScanner) (*models.ConfigVariable, error) {
+	variable := &models.ConfigVariable{}
+	err := row.Scan(
+		&variable.ID, &variable.TemplateID, &variable.Name, &variable.Path, &variable.Type,
+		&variable.Description, &variable.DefaultValue, &variable.Required, &variable.ValidationRule,
+	)
+	return variable, err
+}
+
+// emptyIfNilStrings returns an empty slice in place of nil, so it is stored as [] rather than null
+func emptyIfNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+	"conflux/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+// encodedContent matches content stored through the compressing codec
+type encodedContent struct{}
+
+func (encodedContent) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	return ok && strings.HasPrefix(s, "gzip+base64:")
+}
+
+func TestConfigRepository_CreateUserConfig_EncodesContentAndNameKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.FixedZone("EST", -5*60*60))
+	content := strings.Repeat("port: 8080\n", 100)
+
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO user_configs")).
+		WithArgs(1, nil, "App", "app", "", "", models.FormatYAML, encodedContent{}, false, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(5, created, created))
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO user_configs")).
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	repo := NewConfigRepository(db, repository.ContentCodec{Compress: true, MinBytes: 1}, true)
+	config := &models.UserConfig{UserID: 1, Name: "App", Format: models.FormatYAML, Content: content}
+	if err := repo.CreateUserConfig(config); err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	if config.ID != 5 || config.Content != content || config.CreatedAt.Location() != time.UTC {
+		t.Errorf("config = id %d, content changed %v, location %v; want id 5, unchanged content, UTC",
+			config.ID, config.Content != content, config.CreatedAt.Location())
+	}
+
+	err = repo.CreateUserConfig(&models.UserConfig{UserID: 1, Name: "APP", Format: models.FormatYAML})
+	if err == nil || !strings.Contains(err.Error(), `already have a config named "APP"`) {
+		t.Errorf("CreateUserConfig() duplicate error = %v, want already have a config named", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestConfigRepository_GetUserConfig_DecodesContent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	content := strings.Repeat("debug = true\n", 100)
+	codec := repository.ContentCodec{Compress: true, MinBytes: 1}
+	stored, err := codec.Encode(content)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{
+		"id", "user_id", "template_id", "name", "template_version", "description", "format", "content",
+		"is_shared", "forked_from", "created_at", "updated_at",
+	}).AddRow(3, 1, 2, "app", "1.0.0", "", "toml", stored, false, nil, now, now)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM user_configs WHERE id =")).WithArgs(3).WillReturnRows(rows)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM user_configs WHERE id =")).WithArgs(4).WillReturnError(sql.ErrNoRows)
+
+	// Reading doesn't depend on compression being enabled
+	repo := NewConfigRepository(db, repository.ContentCodec{}, false)
+	config, err := repo.GetUserConfig(3)
+	if err != nil {
+		t.Fatalf("GetUserConfig() error = %v", err)
+	}
+	if config.Content != content || config.TemplateID == nil || *config.TemplateID != 2 || config.ForkedFrom != nil {
+		t.Errorf("config = %+v, want decoded content from template 2", config)
+	}
+
+	if _, err := repo.GetUserConfig(4); !errors.Is(err, repository.ErrConfigNotFound) {
+		t.Errorf("GetUserConfig() missing error = %v, want %v", err, repository.ErrConfigNotFound)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestConfigRepository_AcquireConfigLock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	acquired := time.Now().Truncate(time.Second)
+	expires := acquired.Add(5 * time.Minute)
+
+	// Another holder's unexpired lock is left in place and returned
+	mock.ExpectExec(regexp.QuoteMeta("ON CONFLICT (config_id) DO UPDATE")).
+		WithArgs(9, 2, int64(300000)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM config_locks WHERE config_id = $1 AND expires_at > NOW()")).
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"config_id", "holder_id", "acquired_at", "expires_at"}).
+			AddRow(9, 1, acquired, expires))
+
+	lock, err := NewConfigRepository(db, repository.ContentCodec{}, false).AcquireConfigLock(9, 2, 5*time.Minute)
+	if err != nil {
+		t.Fatalf("AcquireConfigLock() error = %v", err)
+	}
+	if lock.HolderID != 1 || !lock.ExpiresAt.Equal(expires) {
+		t.Errorf("lock = %+v, want the existing lock held by user 1", lock)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
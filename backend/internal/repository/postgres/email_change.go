@@ -0,0 +1,64 @@
+// PostgreSQL implementation of EmailChangeRepository interface
+// Handles pending email change persistence specific to PostgreSQL database
+// Tokens are stored by hash, so a leaked row can't confirm a change
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"conflux/internal/models"
+)
+
+// EmailChangeRepository implements service.EmailChangeRepository for PostgreSQL
+type EmailChangeRepository struct {
+	db *sql.DB
+}
+
+// NewEmailChangeRepository creates a new PostgreSQL email change repository
+func NewEmailChangeRepository(db *sql.DB) *EmailChangeRepository {
+	return &EmailChangeRepository{db: db}
+}
+
+// Create inserts a pending email change into PostgreSQL database
+func (r *EmailChangeRepository) Create(ctx context.Context, change *models.EmailChange) error {
+	query := `
+		INSERT INTO email_changes (user_id, new_email, token_hash, expires_at) 
+		VALUES ($1, $2, $3, $4) 
+		RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		change.UserID, change.NewEmail, change.TokenHash, change.ExpiresAt,
+	).Scan(&change.ID, &change.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	change.NormalizeTimestamps()
+	return nil
+}
+
+// GetByTokenHash retrieves a pending email change by its token hash
+func (r *EmailChangeRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.EmailChange, error) {
+	query := `
+		SELECT id, user_id, new_email, token_hash, expires_at, created_at
+		FROM email_changes WHERE token_hash = $1`
+
+	change := &models.EmailChange{}
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&change.ID, &change.UserID, &change.NewEmail, &change.TokenHash, &change.ExpiresAt, &change.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	change.NormalizeTimestamps()
+	return change, nil
+}
+
+// DeleteForUser removes every pending email change for the user
+func (r *EmailChangeRepository) DeleteForUser(ctx context.Context, userID int) error {
+	query := `DELETE FROM email_changes WHERE user_id = $1`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
@@ -0,0 +1,41 @@
+// PostgreSQL implementation of KnownDeviceRepository interface
+// Remembers which devices each user has signed in from, by fingerprint only
+package postgres
+
+import (
+	"context"
+	"database/sql"
+)
+
+// KnownDeviceRepository implements service.KnownDeviceRepository for PostgreSQL
+type KnownDeviceRepository struct {
+	db *sql.DB
+}
+
+// NewKnownDeviceRepository creates a new PostgreSQL known device repository
+func NewKnownDeviceRepository(db *sql.DB) *KnownDeviceRepository {
+	return &KnownDeviceRepository{db: db}
+}
+
+// CountDevices returns how many devices the user has signed in from
+func (r *KnownDeviceRepository) CountDevices(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM known_devices WHERE user_id = $1`, userID).Scan(&count)
+	return count, err
+}
+
+// RememberDevice records a sign-in from the device, reporting whether it was new
+func (r *KnownDeviceRepository) RememberDevice(ctx context.Context, userID int, fingerprint string) (bool, error) {
+	// xmax is zero only for a freshly inserted row
+	query := `
+		INSERT INTO known_devices (user_id, fingerprint) 
+		VALUES ($1, $2) 
+		ON CONFLICT (user_id, fingerprint) DO UPDATE SET last_seen_at = NOW() 
+		RETURNING (xmax = 0)`
+
+	var inserted bool
+	if err := r.db.QueryRowContext(ctx, query, userID, fingerprint).Scan(&inserted); err != nil {
+		return false, err
+	}
+	return inserted, nil
+}
@@ -6,8 +6,11 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 
 	"conflux/internal/models"
+
+	"github.com/lib/pq"
 )
 
 // UserRepository implements repository.UserRepository for PostgreSQL
@@ -23,52 +26,63 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 // Create inserts a new user into PostgreSQL database
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (email, password_hash, first_name, last_name) 
-		VALUES ($1, $2, $3, $4) 
+		INSERT INTO users (email, password_hash, first_name, last_name, email_verified, password_reset_required) 
+		VALUES ($1, $2, $3, $4, $5, $6) 
 		RETURNING id, created_at, updated_at`
 
-	err := r.db.QueryRowContext(ctx, query, user.Email, user.Password, user.FirstName, user.LastName).Scan(
+	err := r.db.QueryRowContext(ctx, query,
+		user.Email, user.Password, user.FirstName, user.LastName, user.EmailVerified, user.PasswordResetRequired,
+	).Scan(
 		&user.ID, &user.CreatedAt, &user.UpdatedAt,
 	)
+	if isDuplicateKey(err) {
+		return models.ErrEmailTaken
+	}
+	if err != nil {
+		return err
+	}
 
-	return err
+	user.NormalizeTimestamps()
+	return nil
 }
 
 // GetByID retrieves user by ID from PostgreSQL
 func (r *UserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
 	query := `
-		SELECT id, email, password_hash, first_name, last_name, created_at, updated_at 
+		SELECT id, email, password_hash, first_name, last_name, role, preferences, email_verified, password_reset_required, created_at, updated_at 
 		FROM users WHERE id = $1`
 
 	user := &models.User{}
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.Role, &user.Preferences, &user.EmailVerified, &user.PasswordResetRequired, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
+	user.NormalizeTimestamps()
 	return user, nil
 }
 
 // GetByEmail retrieves user by email from PostgreSQL
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT id, email, password_hash, first_name, last_name, created_at, updated_at 
+		SELECT id, email, password_hash, first_name, last_name, role, preferences, email_verified, password_reset_required, created_at, updated_at 
 		FROM users WHERE email = $1`
 
 	user := &models.User{}
 	err := r.db.QueryRowContext(ctx, query, email).Scan(
 		&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.Role, &user.Preferences, &user.EmailVerified, &user.PasswordResetRequired, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
+	user.NormalizeTimestamps()
 	return user, nil
 }
 
@@ -77,9 +91,44 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	query := `
 		UPDATE users 
 		SET email = $1, first_name = $2, last_name = $3, updated_at = NOW() 
-		WHERE id = $4`
+		WHERE id = $4 
+		RETURNING updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, user.Email, user.FirstName, user.LastName, user.ID).Scan(&user.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	user.NormalizeTimestamps()
+	return nil
+}
+
+// UpdatePreferences replaces the user's stored preferences in PostgreSQL
+func (r *UserRepository) UpdatePreferences(ctx context.Context, userID int, prefs models.UserPreferences) error {
+	query := `
+		UPDATE users 
+		SET preferences = $1, updated_at = NOW() 
+		WHERE id = $2`
+
+	_, err := r.db.ExecContext(ctx, query, prefs, userID)
+	return err
+}
+
+// MarkEmailVerified flags the user's email as verified in PostgreSQL
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userID int) error {
+	query := `UPDATE users SET email_verified = TRUE, updated_at = NOW() WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+// UpdatePassword stores a new password hash and clears any pending reset in PostgreSQL
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	query := `
+		UPDATE users 
+		SET password_hash = $1, password_reset_required = FALSE, updated_at = NOW() 
+		WHERE id = $2`
 
-	_, err := r.db.ExecContext(ctx, query, user.Email, user.FirstName, user.LastName, user.ID)
+	_, err := r.db.ExecContext(ctx, query, passwordHash, userID)
 	return err
 }
 
@@ -89,3 +138,9 @@ func (r *UserRepository) Delete(ctx context.Context, id int) error {
 	_, err := r.db.ExecContext(ctx, query, id)
 	return err
 }
+
+// isDuplicateKey reports whether err is a PostgreSQL unique-index violation
+func isDuplicateKey(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" // unique_violation
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestUserRepository_GetByID_SerializesUTC(t *testing.T) {
+	// Simulate a server running outside UTC
+	originalLocal := time.Local
+	time.Local = time.FixedZone("UTC+9", 9*60*60)
+	defer func() { time.Local = originalLocal }()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// The driver hands back timestamps in a non-UTC zone
+	stored := time.Date(2024, 3, 1, 9, 30, 0, 0, time.FixedZone("EST", -5*60*60))
+	rows := sqlmock.NewRows([]string{
+		"id", "email", "password_hash", "first_name", "last_name", "role", "preferences", "email_verified", "password_reset_required", "created_at", "updated_at",
+	}).AddRow(1, "tz@example.com", "hash", "Time", "Zone", "user", "{}", true, false, stored, stored.In(time.Local))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users WHERE id =")).WithArgs(1).WillReturnRows(rows)
+
+	user, err := NewUserRepository(db).GetByID(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+
+	data, err := json.Marshal(user)
+	if err != nil {
+		t.Fatalf("failed to marshal user: %v", err)
+	}
+
+	const want = "2024-03-01T14:30:00Z"
+	for _, field := range []string{"created_at", "updated_at"} {
+		if !strings.Contains(string(data), `"`+field+`":"`+want+`"`) {
+			t.Errorf("%s not serialized as %s in %s", field, want, data)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestUserRepository_CreateAndUpdate_UseDatabaseTimestamps(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	created := time.Date(2001, 2, 3, 4, 5, 6, 0, time.FixedZone("EST", -5*60*60))
+	updated := created.Add(time.Hour)
+
+	mock.ExpectQuery(regexp.QuoteMeta("RETURNING id, created_at, updated_at")).
+		WithArgs("db@example.com", "hash", "Data", "Base", true, false).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(7, created, created))
+	mock.ExpectQuery(regexp.QuoteMeta("RETURNING updated_at")).
+		WithArgs("db@example.com", "Data", "Base", 7).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(updated))
+
+	repo := NewUserRepository(db)
+	user := &models.User{Email: "db@example.com", Password: "hash", FirstName: "Data", LastName: "Base", EmailVerified: true}
+	if err := repo.Create(context.Background(), user); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if user.ID != 7 || !user.CreatedAt.Equal(created) || !user.UpdatedAt.Equal(created) {
+		t.Errorf("after Create: id=%d created_at=%v updated_at=%v, want 7 and %v", user.ID, user.CreatedAt, user.UpdatedAt, created)
+	}
+
+	if err := repo.Update(context.Background(), user); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if !user.UpdatedAt.Equal(updated) || user.UpdatedAt.Location() != time.UTC {
+		t.Errorf("updated_at after Update = %v, want %v in UTC", user.UpdatedAt, updated)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+// SQLite implementation of APIKeyRepository interface
+// Handles API key persistence specific to SQLite database
+// Keys are stored by hash; permissions are stored as a JSON array
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"conflux/internal/models"
+)
+
+// APIKeyRepository implements service.APIKeyRepository for SQLite
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepository creates a new SQLite API key repository
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Create inserts a new API key into SQLite database
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	permissions, err := json.Marshal(key.Permissions)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO api_keys (user_id, name, key_hash, permissions, expires_at, is_active) 
+		VALUES (?, ?, ?, ?, ?, ?) 
+		RETURNING id, created_at`
+
+	err = r.db.QueryRowContext(ctx, query,
+		key.UserID, key.Name, key.KeyHash, string(permissions), key.ExpiresAt, key.IsActive,
+	).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	key.NormalizeTimestamps()
+	return nil
+}
+
+// GetByHash retrieves an API key by the hash of its plaintext
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	query := `
+		SELECT id, user_id, name, key_hash, permissions, last_used_at, expires_at, created_at, is_active
+		FROM api_keys WHERE key_hash = ?`
+
+	key := &models.APIKey{}
+	var permissions string
+	err := r.db.QueryRowContext(ctx, query, keyHash).Scan(
+		&key.ID, &key.UserID, &key.Name, &key.KeyHash, &permissions,
+		&key.LastUsedAt, &key.ExpiresAt, &key.CreatedAt, &key.IsActive,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(permissions), &key.Permissions); err != nil {
+		return nil, err
+	}
+
+	key.NormalizeTimestamps()
+	return key, nil
+}
+
+// DeactivateAllForUser deactivates every active key owned by the user
+// Returns the number of keys deactivated
+func (r *APIKeyRepository) DeactivateAllForUser(ctx context.Context, userID int) (int64, error) {
+	query := `UPDATE api_keys SET is_active = FALSE WHERE user_id = ? AND is_active = TRUE`
+
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
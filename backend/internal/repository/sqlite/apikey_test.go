@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestAPIKeyRepository_GetByHash(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := createUser(t, db, "keys@example.com")
+	repo := NewAPIKeyRepository(db)
+
+	key := &models.APIKey{UserID: user.ID, Name: "ci", KeyHash: "abc123", Permissions: []string{"configs:read"}, IsActive: true}
+	if err := repo.Create(ctx, key); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	got, err := repo.GetByHash(ctx, "abc123")
+	if err != nil {
+		t.Fatalf("GetByHash() error = %v", err)
+	}
+	if got.ID != key.ID || got.UserID != user.ID || !got.IsActive || !reflect.DeepEqual(got.Permissions, key.Permissions) {
+		t.Errorf("GetByHash() = %+v, want %+v", got, key)
+	}
+
+	if _, err := repo.GetByHash(ctx, "missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetByHash() missing error = %v, want sql.ErrNoRows", err)
+	}
+}
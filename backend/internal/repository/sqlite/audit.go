@@ -0,0 +1,82 @@
+// SQLite implementation of AuditRepository interface
+// Persists audit log entries specific to SQLite database
+// Entries are insert-only
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"conflux/internal/models"
+)
+
+// AuditRepository implements service.AuditRepository for SQLite
+type AuditRepository struct {
+	db *sql.DB
+}
+
+// NewAuditRepository creates a new SQLite audit repository
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Create inserts a new audit entry into SQLite database
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (actor_id, action, target_user_id, target_config_id, target_name, ip_address, details) 
+		VALUES (?, ?, ?, ?, ?, ?, ?) 
+		RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		entry.ActorID, entry.Action, entry.TargetUserID, entry.TargetConfigID, entry.TargetName,
+		entry.IPAddress, entry.Details,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	entry.NormalizeTimestamps()
+	return nil
+}
+
+// ListByActor returns a page of the actor's entries whose action starts with actionPrefix, newest first
+func (r *AuditRepository) ListByActor(
+	ctx context.Context, actorID int, actionPrefix string, page, limit int,
+) ([]*models.AuditEntry, int64, error) {
+	pattern := actionPrefix + "%"
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM audit_log WHERE actor_id = ? AND action LIKE ?`
+	if err := r.db.QueryRowContext(ctx, countQuery, actorID, pattern).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT id, actor_id, action, target_user_id, target_config_id, target_name, ip_address, details, created_at
+		FROM audit_log WHERE actor_id = ? AND action LIKE ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?`
+
+	rows, err := r.db.QueryContext(ctx, query, actorID, pattern, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditEntry{}
+	for rows.Next() {
+		entry := &models.AuditEntry{}
+		var details sql.NullString
+		if err := rows.Scan(
+			&entry.ID, &entry.ActorID, &entry.Action, &entry.TargetUserID, &entry.TargetConfigID,
+			&entry.TargetName, &entry.IPAddress, &details, &entry.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		entry.Details = details.String
+		entry.NormalizeTimestamps()
+		entries = append(entries, entry)
+	}
+
+	return entries, total, rows.Err()
+}
@@ -0,0 +1,172 @@
+// SQLite implementation of AuthRepository interface
+// Handles authentication session operations specific to SQLite database
+// Implements session management and token validation for SQLite
+// Times are compared through julianday(), since stored values may carry a zone offset
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"conflux/internal/models"
+)
+
+// AuthRepository implements service.AuthRepository for SQLite
+type AuthRepository struct {
+	db *sql.DB
+}
+
+// NewAuthRepository creates a new SQLite auth repository
+func NewAuthRepository(db *sql.DB) *AuthRepository {
+	return &AuthRepository{db: db}
+}
+
+// CreateSession creates a new session record in SQLite
+// last_activity is set explicitly; SQLite can't default an added column to the current time
+func (r *AuthRepository) CreateSession(ctx context.Context, userID int, sessionID, token string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO sessions (user_id, session_id, token, expires_at, last_activity)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)`
+
+	_, err := r.db.ExecContext(ctx, query, userID, sessionID, token, expiresAt)
+	return err
+}
+
+// ValidateSession validates session token and returns user if valid
+// Sessions idle for longer than idleTimeout are rejected; 0 disables the check
+func (r *AuthRepository) ValidateSession(ctx context.Context, token string, idleTimeout time.Duration) (*models.User, error) {
+	return r.validateSession(ctx, "token", token, idleTimeout)
+}
+
+// ValidateSessionID validates an opaque session ID and returns the user if valid
+// Sessions idle for longer than idleTimeout are rejected; 0 disables the check
+func (r *AuthRepository) ValidateSessionID(ctx context.Context, sessionID string, idleTimeout time.Duration) (*models.User, error) {
+	return r.validateSession(ctx, "session_id", sessionID, idleTimeout)
+}
+
+// validateSession looks up the user for the session whose column matches value
+// and records the lookup as activity on the session
+func (r *AuthRepository) validateSession(ctx context.Context, column, value string, idleTimeout time.Duration) (*models.User, error) {
+	query := `
+		SELECT u.id, u.email, u.password_hash, u.first_name, u.last_name, u.created_at, u.updated_at
+		FROM users u
+		INNER JOIN sessions s ON u.id = s.user_id
+		WHERE s.` + column + ` = ?1 AND julianday(s.expires_at) > julianday('now')
+		AND (?2 = 0 OR julianday(s.last_activity) > julianday('now', '-' || ?2 || ' seconds'))`
+
+	idle := idleSeconds(idleTimeout)
+	user := &models.User{}
+	err := r.db.QueryRowContext(ctx, query, value, idle).Scan(
+		&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName,
+		&user.CreatedAt, &user.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	touch := `UPDATE sessions SET last_activity = CURRENT_TIMESTAMP WHERE ` + column + ` = ?`
+	if _, err := r.db.ExecContext(ctx, touch, value); err != nil {
+		return nil, err
+	}
+
+	user.NormalizeTimestamps()
+	return user, nil
+}
+
+// ListSessions returns a user's unexpired sessions that have a session ID, newest first
+// Sessions idle for longer than idleTimeout are left out; 0 disables the check
+func (r *AuthRepository) ListSessions(ctx context.Context, userID int, idleTimeout time.Duration) ([]*models.Session, error) {
+	query := `
+		SELECT id, user_id, session_id, expires_at, last_activity, created_at
+		FROM sessions
+		WHERE user_id = ?1 AND session_id IS NOT NULL AND julianday(expires_at) > julianday('now')
+		AND (?2 = 0 OR julianday(last_activity) > julianday('now', '-' || ?2 || ' seconds'))
+		ORDER BY created_at DESC, id DESC`
+
+	idle := idleSeconds(idleTimeout)
+	rows, err := r.db.QueryContext(ctx, query, userID, idle)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		session := &models.Session{}
+		if err := rows.Scan(
+			&session.ID, &session.UserID, &session.SessionID, &session.ExpiresAt,
+			&session.LastActivity, &session.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		session.NormalizeTimestamps()
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// InvalidateSession removes session from SQLite database
+func (r *AuthRepository) InvalidateSession(ctx context.Context, token string) error {
+	query := `DELETE FROM sessions WHERE token = ?`
+	_, err := r.db.ExecContext(ctx, query, token)
+	return err
+}
+
+// InvalidateSessionID removes one of a user's sessions by its session ID
+// Reports whether a session was removed
+func (r *AuthRepository) InvalidateSessionID(ctx context.Context, userID int, sessionID string) (bool, error) {
+	query := `DELETE FROM sessions WHERE user_id = ? AND session_id = ?`
+	result, err := r.db.ExecContext(ctx, query, userID, sessionID)
+	if err != nil {
+		return false, err
+	}
+	removed, err := result.RowsAffected()
+	return removed > 0, err
+}
+
+// InvalidateAllSessions removes every session belonging to a user
+// Returns the number of sessions removed
+func (r *AuthRepository) InvalidateAllSessions(ctx context.Context, userID int) (int64, error) {
+	query := `DELETE FROM sessions WHERE user_id = ?`
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// InvalidateOtherSessions removes every session of a user except keepSessionID
+// Sessions without a session ID can't be told apart and are removed too
+// Returns the number of sessions removed
+func (r *AuthRepository) InvalidateOtherSessions(ctx context.Context, userID int, keepSessionID string) (int64, error) {
+	query := `DELETE FROM sessions WHERE user_id = ? AND (session_id IS NULL OR session_id <> ?)`
+	result, err := r.db.ExecContext(ctx, query, userID, keepSessionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteExpiredSessions removes sessions past their expiry or, when idleTimeout
+// is positive, idle for longer than it
+// Returns the number of sessions removed
+func (r *AuthRepository) DeleteExpiredSessions(ctx context.Context, idleTimeout time.Duration) (int64, error) {
+	query := `
+		DELETE FROM sessions
+		WHERE julianday(expires_at) <= julianday('now')
+		OR (?1 > 0 AND julianday(last_activity) <= julianday('now', '-' || ?1 || ' seconds'))`
+
+	idle := idleSeconds(idleTimeout)
+	result, err := r.db.ExecContext(ctx, query, idle)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// idleSeconds converts an idle timeout to whole seconds for SQL date modifiers
+func idleSeconds(timeout time.Duration) int64 {
+	return int64(timeout / time.Second)
+}
@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAuthRepository_SessionLifecycle(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	user := createUser(t, db, "a@example.com")
+	repo := NewAuthRepository(db)
+
+	// Expiry is bound from Go in a non-UTC zone; comparisons must still use the instant
+	zone := time.FixedZone("UTC-5", -5*60*60)
+	if err := repo.CreateSession(ctx, user.ID, "live", "live-token", time.Now().Add(time.Hour).In(zone)); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if err := repo.CreateSession(ctx, user.ID, "expired", "expired-token", time.Now().Add(-time.Minute).In(zone)); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	if got, err := repo.ValidateSessionID(ctx, "live", time.Hour); err != nil || got.ID != user.ID {
+		t.Errorf("ValidateSessionID(live) = %v, %v; want user %d", got, err, user.ID)
+	}
+	if _, err := repo.ValidateSession(ctx, "expired-token", 0); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("ValidateSession(expired) error = %v, want %v", err, sql.ErrNoRows)
+	}
+
+	sessions, err := repo.ListSessions(ctx, user.ID, 0)
+	if err != nil || len(sessions) != 1 || sessions[0].SessionID != "live" {
+		t.Errorf("ListSessions() = %v, %v; want the live session", sessions, err)
+	}
+	if removed, err := repo.DeleteExpiredSessions(ctx, 0); err != nil || removed != 1 {
+		t.Errorf("DeleteExpiredSessions() = %d, %v; want 1", removed, err)
+	}
+}
+
+func TestAuthRepository_InvalidateOtherSessions(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	user := createUser(t, db, "a@example.com")
+	other := createUser(t, db, "b@example.com")
+	repo := NewAuthRepository(db)
+
+	expiresAt := time.Now().Add(time.Hour)
+	for _, s := range []struct {
+		userID    int
+		sessionID string
+	}{{user.ID, "current"}, {user.ID, "laptop"}, {user.ID, "phone"}, {other.ID, "elsewhere"}} {
+		if err := repo.CreateSession(ctx, s.userID, s.sessionID, s.sessionID+"-token", expiresAt); err != nil {
+			t.Fatalf("CreateSession(%s) error = %v", s.sessionID, err)
+		}
+	}
+
+	if removed, err := repo.InvalidateOtherSessions(ctx, user.ID, "current"); err != nil || removed != 2 {
+		t.Errorf("InvalidateOtherSessions() = %d, %v; want 2", removed, err)
+	}
+	for _, sessionID := range []string{"current", "elsewhere"} {
+		if _, err := repo.ValidateSessionID(ctx, sessionID, 0); err != nil {
+			t.Errorf("ValidateSessionID(%s) error = %v, want it kept", sessionID, err)
+		}
+	}
+}
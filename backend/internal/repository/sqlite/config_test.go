@@ -0,0 +1,174 @@
+package sqlite
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+	"conflux/internal/repository"
+)
+
+func TestConfigRepository_UserConfigs(t *testing.T) {
+	db := newTestDB(t)
+	owner := createUser(t, db, "owner@example.com")
+	repo := NewConfigRepository(db, repository.ContentCodec{Compress: true, MinBytes: 1}, true)
+
+	template := &models.ConfigTemplate{
+		Name: "app", Version: "1.0.0", Format: models.FormatYAML, DefaultContent: "port: 8080\n",
+		SupportedFormats: []models.ConfigFormat{models.FormatYAML, models.FormatJSON},
+		Variables:        []models.ConfigVariable{{Name: "PORT", Path: "port", Type: "number"}},
+	}
+	if err := repo.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	stored, err := repo.GetTemplate(template.ID)
+	if err != nil || len(stored.Variables) != 1 || len(stored.SupportedFormats) != 2 {
+		t.Fatalf("GetTemplate() = %+v, %v; want one variable and two formats", stored, err)
+	}
+	if templates, total, err := repo.GetTemplates("", "APP", 1, 10); err != nil || total != 1 || len(templates) != 1 {
+		t.Errorf("GetTemplates(search APP) = %d of %d, %v; want the template", len(templates), total, err)
+	}
+
+	content := strings.Repeat("port: 8080\n", 100)
+	config := &models.UserConfig{UserID: owner.ID, TemplateID: &template.ID, Name: "App", Format: models.FormatYAML, Content: content}
+	if err := repo.CreateUserConfig(config); err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	err = repo.CreateUserConfig(&models.UserConfig{UserID: owner.ID, Name: "APP", Format: models.FormatYAML})
+	if err == nil || !strings.Contains(err.Error(), "already have a config named") {
+		t.Errorf("CreateUserConfig() duplicate error = %v, want already have a config named", err)
+	}
+
+	got, err := repo.GetUserConfig(config.ID)
+	if err != nil || got.Content != content {
+		t.Errorf("GetUserConfig() content matches = %v, error = %v; want the original content", err == nil && got.Content == content, err)
+	}
+	if _, err := repo.GetUserConfig(config.ID + 1); !errors.Is(err, repository.ErrConfigNotFound) {
+		t.Errorf("GetUserConfig() missing error = %v, want %v", err, repository.ErrConfigNotFound)
+	}
+
+	// Deleting the template keeps the configuration
+	if err := repo.DeleteTemplate(template.ID); err != nil {
+		t.Fatalf("DeleteTemplate() error = %v", err)
+	}
+	if got, err := repo.GetUserConfig(config.ID); err != nil || got.TemplateID != nil {
+		t.Errorf("GetUserConfig() after deleting the template = %+v, %v; want no template", got, err)
+	}
+}
+
+func TestConfigRepository_UpdateTemplateVersioned(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewConfigRepository(db, repository.ContentCodec{}, false)
+
+	template := &models.ConfigTemplate{
+		Name: "app", Version: "1.0.0", Format: models.FormatYAML, DefaultContent: "port: 8080\n",
+		Variables: []models.ConfigVariable{{Name: "PORT", Path: "port", Type: "number"}},
+	}
+	if err := repo.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+
+	// The plan sees the stored template and variables; its version is applied and recorded
+	updates := &models.ConfigTemplate{DefaultContent: "port: 9090\n"}
+	bump := func(existing *models.ConfigTemplate, variables []*models.ConfigVariable) *models.TemplateVersion {
+		if existing.Version != "1.0.0" || len(variables) != 1 {
+			t.Errorf("plan saw version %q with %d variables, want 1.0.0 with 1", existing.Version, len(variables))
+		}
+		previous := existing.DefaultContent
+		return &models.TemplateVersion{
+			Version: "1.0.1", PreviousVersion: existing.Version, Bump: models.BumpPatch,
+			Changes: []string{"default content changed"}, PreviousContent: &previous,
+		}
+	}
+	if err := repo.UpdateTemplate(template.ID, updates, bump); err != nil {
+		t.Fatalf("UpdateTemplate() error = %v", err)
+	}
+	if updates.Version != "1.0.1" || updates.DefaultContent != "port: 9090\n" {
+		t.Errorf("UpdateTemplate() stored version %q, content %q; want 1.0.1 with the new content", updates.Version, updates.DefaultContent)
+	}
+
+	// Without a bump the version and history are left alone
+	noBump := func(*models.ConfigTemplate, []*models.ConfigVariable) *models.TemplateVersion { return nil }
+	if err := repo.UpdateTemplate(template.ID, &models.ConfigTemplate{Description: "App"}, noBump); err != nil {
+		t.Fatalf("UpdateTemplate() error = %v", err)
+	}
+
+	versions, err := repo.GetTemplateVersions(template.ID)
+	if err != nil || len(versions) != 1 {
+		t.Fatalf("GetTemplateVersions() = %d versions, %v; want 1", len(versions), err)
+	}
+	if v := versions[0]; v.Version != "1.0.1" || v.PreviousContent == nil || *v.PreviousContent != "port: 8080\n" {
+		t.Errorf("version = %+v, want 1.0.1 with the previous content", v)
+	}
+	if stored, _ := repo.GetTemplate(template.ID); stored.Version != "1.0.1" {
+		t.Errorf("stored version = %q, want 1.0.1", stored.Version)
+	}
+
+	if err := repo.UpdateTemplate(template.ID+1, &models.ConfigTemplate{Name: "x"}, noBump); !errors.Is(err, repository.ErrTemplateNotFound) {
+		t.Errorf("UpdateTemplate() missing error = %v, want %v", err, repository.ErrTemplateNotFound)
+	}
+}
+
+func TestConfigRepository_ShareConfig(t *testing.T) {
+	db := newTestDB(t)
+	owner := createUser(t, db, "owner@example.com")
+	reader := createUser(t, db, "reader@example.com")
+	repo := NewConfigRepository(db, repository.ContentCodec{}, false)
+
+	config := &models.UserConfig{UserID: owner.ID, Name: "app", Format: models.FormatYAML, Content: "port: 8080\n"}
+	if err := repo.CreateUserConfig(config); err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+
+	// A missing recipient anywhere in the list records none of the shares
+	err := repo.ShareConfig([]*models.ConfigShare{
+		{ConfigID: config.ID, UserID: reader.ID, Permission: models.ShareRead, SharedBy: owner.ID},
+		{ConfigID: config.ID, UserID: reader.ID + 100, Permission: models.ShareRead, SharedBy: owner.ID},
+	})
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		t.Fatalf("ShareConfig() error = %v, want %v", err, repository.ErrUserNotFound)
+	}
+	if share, err := repo.GetConfigShare(config.ID, reader.ID); err != nil || share != nil {
+		t.Errorf("GetConfigShare() after a failed share = %+v, %v; want none", share, err)
+	}
+
+	share := &models.ConfigShare{ConfigID: config.ID, UserID: reader.ID, Permission: models.ShareWrite, SharedBy: owner.ID}
+	if err := repo.ShareConfig([]*models.ConfigShare{share}); err != nil {
+		t.Fatalf("ShareConfig() error = %v", err)
+	}
+	if share.CreatedAt.IsZero() {
+		t.Error("ShareConfig() didn't fill in created_at")
+	}
+	if got, err := repo.GetConfigShare(config.ID, reader.ID); err != nil || got == nil || got.Permission != models.ShareWrite {
+		t.Errorf("GetConfigShare() = %+v, %v; want a write share", got, err)
+	}
+}
+
+func TestConfigRepository_AcquireConfigLock(t *testing.T) {
+	db := newTestDB(t)
+	owner := createUser(t, db, "owner@example.com")
+	other := createUser(t, db, "other@example.com")
+	repo := NewConfigRepository(db, repository.ContentCodec{}, false)
+
+	config := &models.UserConfig{UserID: owner.ID, Name: "app", Format: models.FormatYAML, Content: "a: 1\n"}
+	if err := repo.CreateUserConfig(config); err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+
+	lock, err := repo.AcquireConfigLock(config.ID, owner.ID, time.Minute)
+	if err != nil || lock.HolderID != owner.ID || !lock.ExpiresAt.After(time.Now()) {
+		t.Fatalf("AcquireConfigLock() = %+v, %v; want an unexpired lock held by the owner", lock, err)
+	}
+	if lock, err := repo.AcquireConfigLock(config.ID, other.ID, time.Minute); err != nil || lock.HolderID != owner.ID {
+		t.Errorf("AcquireConfigLock() by another user = %+v, %v; want the owner's lock", lock, err)
+	}
+
+	if err := repo.ReleaseConfigLock(config.ID, owner.ID); err != nil {
+		t.Fatalf("ReleaseConfigLock() error = %v", err)
+	}
+	if lock, err := repo.GetConfigLock(config.ID); err != nil || lock != nil {
+		t.Errorf("GetConfigLock() after release = %+v, %v; want none", lock, err)
+	}
+}
@@ -0,0 +1,83 @@
+// SQLite implementation of EmailVerificationRepository interface
+// Handles account email verification token persistence specific to SQLite database
+// Tokens are stored by hash, so a leaked row can't verify an account
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"conflux/internal/models"
+)
+
+// EmailVerificationRepository implements service.EmailVerificationRepository for SQLite
+type EmailVerificationRepository struct {
+	db *sql.DB
+}
+
+// NewEmailVerificationRepository creates a new SQLite email verification repository
+func NewEmailVerificationRepository(db *sql.DB) *EmailVerificationRepository {
+	return &EmailVerificationRepository{db: db}
+}
+
+// Create inserts a verification token into SQLite database
+func (r *EmailVerificationRepository) Create(ctx context.Context, verification *models.EmailVerification) error {
+	query := `
+		INSERT INTO email_verifications (user_id, token_hash, expires_at) 
+		VALUES (?, ?, ?) 
+		RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		verification.UserID, verification.TokenHash, verification.ExpiresAt,
+	).Scan(&verification.ID, &verification.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	verification.NormalizeTimestamps()
+	return nil
+}
+
+// GetByTokenHash retrieves a verification by its token hash
+func (r *EmailVerificationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.EmailVerification, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, created_at
+		FROM email_verifications WHERE token_hash = ?`
+
+	verification := &models.EmailVerification{}
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&verification.ID, &verification.UserID, &verification.TokenHash, &verification.ExpiresAt, &verification.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	verification.NormalizeTimestamps()
+	return verification, nil
+}
+
+// GetLatestForUser retrieves the user's most recently issued verification
+func (r *EmailVerificationRepository) GetLatestForUser(ctx context.Context, userID int) (*models.EmailVerification, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, created_at
+		FROM email_verifications WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC LIMIT 1`
+
+	verification := &models.EmailVerification{}
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&verification.ID, &verification.UserID, &verification.TokenHash, &verification.ExpiresAt, &verification.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	verification.NormalizeTimestamps()
+	return verification, nil
+}
+
+// DeleteForUser removes every verification token for the user
+func (r *EmailVerificationRepository) DeleteForUser(ctx context.Context, userID int) error {
+	query := `DELETE FROM email_verifications WHERE user_id = ?`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
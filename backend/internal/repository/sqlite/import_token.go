@@ -0,0 +1,46 @@
+// SQLite implementation of ImportTokenRepository interface
+// Stores each user's (already encrypted) access token per import source
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ImportTokenRepository implements service.ImportTokenRepository for SQLite
+type ImportTokenRepository struct {
+	db *sql.DB
+}
+
+// NewImportTokenRepository creates a new SQLite import token repository
+func NewImportTokenRepository(db *sql.DB) *ImportTokenRepository {
+	return &ImportTokenRepository{db: db}
+}
+
+// GetToken returns the stored token for the user and source, or "" when there is none
+func (r *ImportTokenRepository) GetToken(ctx context.Context, userID int, sourceType string) (string, error) {
+	var token string
+	query := `SELECT token FROM import_tokens WHERE user_id = ? AND source_type = ?`
+	err := r.db.QueryRowContext(ctx, query, userID, sourceType).Scan(&token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return token, err
+}
+
+// SetToken stores the token for the user and source, replacing any previous one
+func (r *ImportTokenRepository) SetToken(ctx context.Context, userID int, sourceType, token string) error {
+	query := `
+		INSERT INTO import_tokens (user_id, source_type, token) 
+		VALUES (?, ?, ?) 
+		ON CONFLICT (user_id, source_type) DO UPDATE SET token = excluded.token, updated_at = CURRENT_TIMESTAMP`
+	_, err := r.db.ExecContext(ctx, query, userID, sourceType, token)
+	return err
+}
+
+// DeleteToken removes the token for the user and source, if any
+func (r *ImportTokenRepository) DeleteToken(ctx context.Context, userID int, sourceType string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM import_tokens WHERE user_id = ? AND source_type = ?`, userID, sourceType)
+	return err
+}
@@ -0,0 +1,33 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+)
+
+func TestImportTokenRepository(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	user := createUser(t, db, "a@example.com")
+	repo := NewImportTokenRepository(db)
+
+	if token, err := repo.GetToken(ctx, user.ID, "github"); err != nil || token != "" {
+		t.Fatalf("GetToken() before set = %q, %v; want empty", token, err)
+	}
+
+	for _, want := range []string{"first", "second"} {
+		if err := repo.SetToken(ctx, user.ID, "github", want); err != nil {
+			t.Fatalf("SetToken(%q) error = %v", want, err)
+		}
+		if token, err := repo.GetToken(ctx, user.ID, "github"); err != nil || token != want {
+			t.Errorf("GetToken() = %q, %v; want %q", token, err, want)
+		}
+	}
+
+	if err := repo.DeleteToken(ctx, user.ID, "github"); err != nil {
+		t.Fatalf("DeleteToken() error = %v", err)
+	}
+	if token, err := repo.GetToken(ctx, user.ID, "github"); err != nil || token != "" {
+		t.Errorf("GetToken() after delete = %q, %v; want empty", token, err)
+	}
+}
@@ -0,0 +1,42 @@
+// SQLite implementation of KnownDeviceRepository interface
+// Remembers which devices each user has signed in from, by fingerprint only
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+)
+
+// KnownDeviceRepository implements service.KnownDeviceRepository for SQLite
+type KnownDeviceRepository struct {
+	db *sql.DB
+}
+
+// NewKnownDeviceRepository creates a new SQLite known device repository
+func NewKnownDeviceRepository(db *sql.DB) *KnownDeviceRepository {
+	return &KnownDeviceRepository{db: db}
+}
+
+// CountDevices returns how many devices the user has signed in from
+func (r *KnownDeviceRepository) CountDevices(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM known_devices WHERE user_id = ?`, userID).Scan(&count)
+	return count, err
+}
+
+// RememberDevice records a sign-in from the device, reporting whether it was new
+func (r *KnownDeviceRepository) RememberDevice(ctx context.Context, userID int, fingerprint string) (bool, error) {
+	query := `INSERT INTO known_devices (user_id, fingerprint) VALUES (?, ?) ON CONFLICT (user_id, fingerprint) DO NOTHING`
+	result, err := r.db.ExecContext(ctx, query, userID, fingerprint)
+	if err != nil {
+		return false, err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil || inserted > 0 {
+		return inserted > 0, err
+	}
+
+	touch := `UPDATE known_devices SET last_seen_at = CURRENT_TIMESTAMP WHERE user_id = ? AND fingerprint = ?`
+	_, err = r.db.ExecContext(ctx, touch, userID, fingerprint)
+	return false, err
+}
@@ -0,0 +1,19 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+)
+
+func TestKnownDeviceRepository_RememberDevice(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	user := createUser(t, db, "a@example.com")
+	repo := NewKnownDeviceRepository(db)
+
+	for i, want := range []bool{true, false} {
+		if inserted, err := repo.RememberDevice(ctx, user.ID, "fingerprint"); err != nil || inserted != want {
+			t.Errorf("RememberDevice() call %d = %v, %v; want %v", i+1, inserted, err, want)
+		}
+	}
+}
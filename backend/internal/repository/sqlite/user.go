@@ -0,0 +1,147 @@
+// SQLite implementation of UserRepository interface
+// Handles user CRUD operations specific to SQLite database
+// Implements SQL queries and transaction management for SQLite
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"conflux/internal/models"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// UserRepository implements repository.UserRepository for SQLite
+type UserRepository struct {
+	db *sql.DB
+}
+
+// NewUserRepository creates a new SQLite user repository
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Create inserts a new user into SQLite database
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	query := `
+		INSERT INTO users (email, password_hash, first_name, last_name, email_verified, password_reset_required) 
+		VALUES (?, ?, ?, ?, ?, ?) 
+		RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		user.Email, user.Password, user.FirstName, user.LastName, user.EmailVerified, user.PasswordResetRequired,
+	).Scan(
+		&user.ID, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if isDuplicateKey(err) {
+		return models.ErrEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	user.NormalizeTimestamps()
+	return nil
+}
+
+// GetByID retrieves user by ID from SQLite
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	query := `
+		SELECT id, email, password_hash, first_name, last_name, role, preferences, email_verified, password_reset_required, created_at, updated_at 
+		FROM users WHERE id = ?`
+
+	user := &models.User{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName,
+		&user.Role, &user.Preferences, &user.EmailVerified, &user.PasswordResetRequired, &user.CreatedAt, &user.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user.NormalizeTimestamps()
+	return user, nil
+}
+
+// GetByEmail retrieves user by email from SQLite
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	query := `
+		SELECT id, email, password_hash, first_name, last_name, role, preferences, email_verified, password_reset_required, created_at, updated_at 
+		FROM users WHERE email = ?`
+
+	user := &models.User{}
+	err := r.db.QueryRowContext(ctx, query, email).Scan(
+		&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName,
+		&user.Role, &user.Preferences, &user.EmailVerified, &user.PasswordResetRequired, &user.CreatedAt, &user.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user.NormalizeTimestamps()
+	return user, nil
+}
+
+// Update updates user information in SQLite
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	query := `
+		UPDATE users 
+		SET email = ?, first_name = ?, last_name = ?, updated_at = CURRENT_TIMESTAMP 
+		WHERE id = ? 
+		RETURNING updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, user.Email, user.FirstName, user.LastName, user.ID).Scan(&user.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	user.NormalizeTimestamps()
+	return nil
+}
+
+// UpdatePreferences replaces the user's stored preferences in SQLite
+func (r *UserRepository) UpdatePreferences(ctx context.Context, userID int, prefs models.UserPreferences) error {
+	query := `
+		UPDATE users 
+		SET preferences = ?, updated_at = CURRENT_TIMESTAMP 
+		WHERE id = ?`
+
+	_, err := r.db.ExecContext(ctx, query, prefs, userID)
+	return err
+}
+
+// MarkEmailVerified flags the user's email as verified in SQLite
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userID int) error {
+	query := `UPDATE users SET email_verified = TRUE, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+// UpdatePassword stores a new password hash and clears any pending reset in SQLite
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	query := `
+		UPDATE users 
+		SET password_hash = ?, password_reset_required = FALSE, updated_at = CURRENT_TIMESTAMP 
+		WHERE id = ?`
+
+	_, err := r.db.ExecContext(ctx, query, passwordHash, userID)
+	return err
+}
+
+// Delete removes user from SQLite database
+func (r *UserRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM users WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// isDuplicateKey reports whether err is a SQLite unique-index violation
+func isDuplicateKey(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"conflux/internal/config"
+	"conflux/internal/database"
+	"conflux/internal/models"
+)
+
+// newTestDB opens a migrated in-memory SQLite database
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := database.NewConnectionFactory(&config.Config{DBType: database.DriverSQLite, DBName: ":memory:"}).NewConnection()
+	if err != nil {
+		t.Fatalf("NewConnection() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := database.NewMigrator(db, database.DriverSQLite, slog.New(slog.NewTextHandler(io.Discard, nil))).Up(); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	return db
+}
+
+// createUser stores a user for tests that need an owner
+func createUser(t *testing.T, db *sql.DB, email string) *models.User {
+	t.Helper()
+	user := &models.User{Email: email, Password: "hash", FirstName: "Test", LastName: "User", EmailVerified: true}
+	if err := NewUserRepository(db).Create(context.Background(), user); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	return user
+}
+
+func TestUserRepository_Create_DuplicateEmail(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "a@example.com")
+	if user.ID == 0 || user.CreatedAt.IsZero() || user.CreatedAt.Location() != time.UTC {
+		t.Errorf("user = id %d, created_at %v; want an ID and a UTC timestamp", user.ID, user.CreatedAt)
+	}
+
+	err := NewUserRepository(db).Create(context.Background(), &models.User{Email: "a@example.com", Password: "hash"})
+	if !errors.Is(err, models.ErrEmailTaken) {
+		t.Errorf("Create() duplicate error = %v, want %v", err, models.ErrEmailTaken)
+	}
+}
@@ -0,0 +1,151 @@
+// API key service layer
+// Manages API keys for programmatic access
+// Keys are stored hashed; plaintext is only returned when a key is issued
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"conflux/internal/models"
+)
+
+// apiKeyPrefix marks Conflux API keys so they are easy to spot in leaked secrets
+const apiKeyPrefix = "cfx_"
+
+// APIKeyRepository defines data access methods for API keys
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	DeactivateAllForUser(ctx context.Context, userID int) (int64, error)
+}
+
+// APIKeyService handles API key business logic
+type APIKeyService struct {
+	keyRepo      APIKeyRepository
+	userRepo     UserRepository
+	auditService *AuditService
+	logger       *slog.Logger
+}
+
+// NewAPIKeyService creates an API key service with its dependencies
+func NewAPIKeyService(
+	keyRepo APIKeyRepository, userRepo UserRepository, auditService *AuditService, logger *slog.Logger,
+) *APIKeyService {
+	return &APIKeyService{
+		keyRepo:      keyRepo,
+		userRepo:     userRepo,
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// Authenticate resolves a plaintext API key to the user that owns it
+// Unknown, revoked, and expired keys are rejected alike
+func (s *APIKeyService) Authenticate(ctx context.Context, secret string) (*models.User, error) {
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	key, err := s.keyRepo.GetByHash(ctx, HashAPIKey(secret))
+	if err != nil || !key.IsActive || (key.ExpiresAt != nil && !time.Now().Before(*key.ExpiresAt)) {
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, key.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	// Sanitize user data
+	user.Password = ""
+	return user, nil
+}
+
+// RotateKeys deactivates all of a user's API keys and optionally issues a fresh one
+// Users may rotate their own keys; admins may rotate anyone's
+// The audit entry is best-effort: once keys have changed, a failed audit write is
+// logged rather than returned, so the new key's secret is never lost
+func (s *APIKeyService) RotateKeys(
+	ctx context.Context, actorID int, isAdmin bool, req *models.RotateAPIKeysRequest, ipAddress string,
+) (*models.RotateAPIKeysResponse, error) {
+	targetUserID := actorID
+	if req.UserID != nil {
+		targetUserID = *req.UserID
+	}
+	if targetUserID != actorID && !isAdmin {
+		return nil, fmt.Errorf("unauthorized to rotate keys for user %d", targetUserID)
+	}
+
+	revoked, err := s.keyRepo.DeactivateAllForUser(ctx, targetUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deactivate keys: %w", err)
+	}
+
+	response := &models.RotateAPIKeysResponse{Revoked: revoked}
+
+	if req.IssueNew {
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			name = "rotated-" + time.Now().UTC().Format("20060102-150405")
+		}
+
+		secret, err := generateAPIKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+
+		key := &models.APIKey{
+			UserID:      targetUserID,
+			Name:        name,
+			KeyHash:     HashAPIKey(secret),
+			Permissions: []string{},
+			IsActive:    true,
+		}
+		if err := s.keyRepo.Create(ctx, key); err != nil {
+			return nil, fmt.Errorf("failed to create key: %w", err)
+		}
+
+		response.Key = key
+		response.Secret = secret
+	}
+
+	details := fmt.Sprintf("revoked %d key(s)", revoked)
+	if response.Key != nil {
+		details += fmt.Sprintf(", issued key %d", response.Key.ID)
+	}
+	if err := s.auditService.Record(ctx, &models.AuditEntry{
+		ActorID:      actorID,
+		Action:       models.AuditAPIKeysRotated,
+		TargetUserID: &targetUserID,
+		IPAddress:    ipAddress,
+		Details:      details,
+	}); err != nil {
+		s.logger.Error("Failed to audit API key rotation",
+			"actor_id", actorID, "target_user_id", targetUserID, "details", details, "error", err)
+	}
+
+	return response, nil
+}
+
+// HashAPIKey returns the stored hash for a plaintext API key
+// SHA-256 is sufficient because keys are long random values, and it allows lookup by hash
+func HashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateAPIKey returns a new random plaintext API key
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+)
+
+// discardLogger drops the log output of services under test
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// MockAPIKeyRepository is an in-memory implementation of APIKeyRepository
+type MockAPIKeyRepository struct {
+	keys      []*models.APIKey
+	nextID    int
+	createErr error
+}
+
+func NewMockAPIKeyRepository() *MockAPIKeyRepository {
+	return &MockAPIKeyRepository{nextID: 1}
+}
+
+func (m *MockAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	if m.createErr != nil {
+		return m.createErr
+	}
+	key.ID = m.nextID
+	m.nextID++
+	keyCopy := *key
+	m.keys = append(m.keys, &keyCopy)
+	return nil
+}
+
+func (m *MockAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	for _, key := range m.keys {
+		if key.KeyHash == keyHash {
+			keyCopy := *key
+			return &keyCopy, nil
+		}
+	}
+	return nil, errors.New("api key not found")
+}
+
+func (m *MockAPIKeyRepository) DeactivateAllForUser(ctx context.Context, userID int) (int64, error) {
+	var n int64
+	for _, key := range m.keys {
+		if key.UserID == userID && key.IsActive {
+			key.IsActive = false
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MockAPIKeyRepository) activeKeys(userID int) []*models.APIKey {
+	var active []*models.APIKey
+	for _, key := range m.keys {
+		if key.UserID == userID && key.IsActive {
+			active = append(active, key)
+		}
+	}
+	return active
+}
+
+// MockAuditRepository records audit entries in memory
+type MockAuditRepository struct {
+	entries   []*models.AuditEntry
+	createErr error
+}
+
+func (m *MockAuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	if m.createErr != nil {
+		return m.createErr
+	}
+	entry.ID = len(m.entries) + 1
+	entryCopy := *entry
+	m.entries = append(m.entries, &entryCopy)
+	return nil
+}
+
+func (m *MockAuditRepository) ListByActor(
+	ctx context.Context, actorID int, actionPrefix string, page, limit int,
+) ([]*models.AuditEntry, int64, error) {
+	var matched []*models.AuditEntry
+	for i := len(m.entries) - 1; i >= 0; i-- {
+		if entry := m.entries[i]; entry.ActorID == actorID && strings.HasPrefix(entry.Action, actionPrefix) {
+			matched = append(matched, entry)
+		}
+	}
+
+	start := (page - 1) * limit
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := start + limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[start:end], int64(len(matched)), nil
+}
+
+func TestAPIKeyService_RotateKeys(t *testing.T) {
+	const ownerID, otherID = 1, 2
+	intPtr := func(i int) *int { return &i }
+
+	tests := []struct {
+		name          string
+		actorID       int
+		isAdmin       bool
+		req           models.RotateAPIKeysRequest
+		wantRevoked   int64
+		wantNewKey    bool
+		wantErr       bool
+		errorContains string
+	}{
+		{name: "revoke own keys", actorID: ownerID, wantRevoked: 2},
+		{
+			name: "revoke and issue new key", actorID: ownerID,
+			req:         models.RotateAPIKeysRequest{IssueNew: true, Name: "ci"},
+			wantRevoked: 2, wantNewKey: true,
+		},
+		{
+			name: "admin rotates another user", actorID: otherID, isAdmin: true,
+			req:         models.RotateAPIKeysRequest{UserID: intPtr(ownerID)},
+			wantRevoked: 2,
+		},
+		{
+			name: "non-admin cannot rotate another user", actorID: otherID,
+			req:     models.RotateAPIKeysRequest{UserID: intPtr(ownerID)},
+			wantErr: true, errorContains: "unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyRepo := NewMockAPIKeyRepository()
+			auditRepo := &MockAuditRepository{}
+			service := NewAPIKeyService(keyRepo, NewMockUserRepository(), NewAuditService(auditRepo), discardLogger)
+
+			for _, name := range []string{"laptop", "server"} {
+				_ = keyRepo.Create(context.Background(), &models.APIKey{UserID: ownerID, Name: name, IsActive: true})
+			}
+
+			resp, err := service.RotateKeys(context.Background(), tt.actorID, tt.isAdmin, &tt.req, "198.51.100.7")
+
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+					t.Fatalf("RotateKeys() error = %v, want %q", err, tt.errorContains)
+				}
+				if n := len(keyRepo.activeKeys(ownerID)); n != 2 {
+					t.Errorf("active keys = %d, want 2 after rejected rotation", n)
+				}
+				if len(auditRepo.entries) != 0 {
+					t.Error("rejected rotation should not be audited")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RotateKeys() error = %v", err)
+			}
+
+			if resp.Revoked != tt.wantRevoked {
+				t.Errorf("revoked = %d, want %d", resp.Revoked, tt.wantRevoked)
+			}
+
+			active := keyRepo.activeKeys(ownerID)
+			if tt.wantNewKey {
+				if resp.Key == nil || !strings.HasPrefix(resp.Secret, apiKeyPrefix) {
+					t.Fatalf("expected new key with secret, got %+v", resp)
+				}
+				if len(active) != 1 || active[0].KeyHash != HashAPIKey(resp.Secret) {
+					t.Errorf("expected only the new key to be active, got %d active", len(active))
+				}
+				if active[0].KeyHash == resp.Secret {
+					t.Error("plaintext secret must not be stored")
+				}
+			} else {
+				if resp.Key != nil || resp.Secret != "" {
+					t.Errorf("no key should be issued, got %+v", resp)
+				}
+				if len(active) != 0 {
+					t.Errorf("active keys = %d, want 0", len(active))
+				}
+			}
+
+			if len(auditRepo.entries) != 1 {
+				t.Fatalf("audit entries = %d, want 1", len(auditRepo.entries))
+			}
+			entry := auditRepo.entries[0]
+			if entry.Action != models.AuditAPIKeysRotated || entry.ActorID != tt.actorID ||
+				entry.TargetUserID == nil || *entry.TargetUserID != ownerID || entry.IPAddress != "198.51.100.7" {
+				t.Errorf("unexpected audit entry: %+v", entry)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_RotateKeys_AuditFailure(t *testing.T) {
+	auditRepo := &MockAuditRepository{createErr: errors.New("database error")}
+	service := NewAPIKeyService(NewMockAPIKeyRepository(), NewMockUserRepository(), NewAuditService(auditRepo), discardLogger)
+
+	// The new key is returned even though the rotation couldn't be audited
+	resp, err := service.RotateKeys(context.Background(), 1, false, &models.RotateAPIKeysRequest{IssueNew: true}, "")
+	if err != nil {
+		t.Fatalf("RotateKeys() error = %v, want the audit failure logged only", err)
+	}
+	if resp.Key == nil || resp.Secret == "" {
+		t.Errorf("RotateKeys() = %+v, want the new key and its secret", resp)
+	}
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	users := NewMockUserRepository()
+	owner := &models.User{Email: "ci@example.com", Password: "hash", Role: models.RoleAdmin}
+	if err := users.Create(ctx, owner); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	keyRepo := NewMockAPIKeyRepository()
+	service := NewAPIKeyService(keyRepo, users, NewAuditService(&MockAuditRepository{}), discardLogger)
+
+	issue := func() string {
+		resp, err := service.RotateKeys(ctx, owner.ID, false, &models.RotateAPIKeysRequest{IssueNew: true}, "")
+		if err != nil {
+			t.Fatalf("RotateKeys() error = %v", err)
+		}
+		return resp.Secret
+	}
+
+	first := issue()
+	user, err := service.Authenticate(ctx, first)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if user.ID != owner.ID || user.Role != models.RoleAdmin || user.Password != "" {
+		t.Errorf("Authenticate() = %+v, want the sanitized owner", user)
+	}
+
+	// Rotating revokes the first key
+	second := issue()
+	if _, err := service.Authenticate(ctx, first); err == nil {
+		t.Error("Authenticate() accepted a revoked key")
+	}
+
+	// Expired, unknown, and malformed keys are rejected
+	expired := time.Now().Add(-time.Minute)
+	keyRepo.activeKeys(owner.ID)[0].ExpiresAt = &expired
+	for name, secret := range map[string]string{"expired": second, "unknown": apiKeyPrefix + "deadbeef", "no prefix": "secret"} {
+		if _, err := service.Authenticate(ctx, secret); err == nil {
+			t.Errorf("Authenticate() accepted the %s key", name)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Audit log service layer
+// Records security-relevant actions performed by users and admins
+// Used by other services to leave an incident-response trail
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"conflux/internal/models"
+)
+
+// AuditRepository defines data access methods for audit entries
+type AuditRepository interface {
+	Create(ctx context.Context, entry *models.AuditEntry) error
+	ListByActor(ctx context.Context, actorID int, actionPrefix string, page, limit int) ([]*models.AuditEntry, int64, error)
+}
+
+// AuditService records audit log entries
+type AuditService struct {
+	auditRepo AuditRepository
+}
+
+// NewAuditService creates an audit service with repository dependency
+func NewAuditService(auditRepo AuditRepository) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+	}
+}
+
+// Record stores an audit entry; the database stamps its creation time
+func (s *AuditService) Record(ctx context.Context, entry *models.AuditEntry) error {
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListActivity returns a page of the user's own configuration activity, newest first
+func (s *AuditService) ListActivity(ctx context.Context, userID, page, limit int) ([]*models.AuditEntry, int64, error) {
+	entries, total, err := s.auditRepo.ListByActor(ctx, userID, models.AuditConfigActionPrefix, page, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list activity: %w", err)
+	}
+	return entries, total, nil
+}
@@ -5,7 +5,11 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 
 	"conflux/internal/models"
@@ -13,35 +17,95 @@ import (
 	"conflux/pkg/utils"
 )
 
+// Session token length bounds, in random bytes before hex encoding
+const (
+	DefaultSessionTokenBytes = 32
+	MinSessionTokenBytes     = 16
+	MaxSessionTokenBytes     = 64 // Fits the 128-character session_id column
+)
+
 // AuthRepository defines data access methods for authentication
+// Sessions are looked up by their opaque session ID; token lookups remain for
+// sessions created before session IDs existed. A positive idleTimeout also treats
+// sessions with no activity for that long as expired; 0 disables the check
 type AuthRepository interface {
-	CreateSession(ctx context.Context, userID int, token string, expiresAt time.Time) error
-	ValidateSession(ctx context.Context, token string) (*models.User, error)
+	CreateSession(ctx context.Context, userID int, sessionID, token string, expiresAt time.Time) error
+	ValidateSession(ctx context.Context, token string, idleTimeout time.Duration) (*models.User, error)
+	ValidateSessionID(ctx context.Context, sessionID string, idleTimeout time.Duration) (*models.User, error)
+	ListSessions(ctx context.Context, userID int, idleTimeout time.Duration) ([]*models.Session, error)
 	InvalidateSession(ctx context.Context, token string) error
+	InvalidateSessionID(ctx context.Context, userID int, sessionID string) (bool, error)
+	InvalidateAllSessions(ctx context.Context, userID int) (int64, error)
+	InvalidateOtherSessions(ctx context.Context, userID int, keepSessionID string) (int64, error)
+	DeleteExpiredSessions(ctx context.Context, idleTimeout time.Duration) (int64, error)
 }
 
 // AuthService handles authentication business logic
 type AuthService struct {
-	userRepo     UserRepository
-	authRepo     AuthRepository
-	tokenManager *jwt.TokenManager
+	userRepo          UserRepository
+	authRepo          AuthRepository
+	tokenManager      *jwt.TokenManager
+	auditService      *AuditService // nil when session actions aren't audited
+	sessionTokenBytes int
+	idleTimeout       time.Duration // Sessions unused this long expire early; 0 disables
+
+	// Security notifications; devices is nil when new-device sign-ins aren't tracked
+	notifier Notifier
+	devices  KnownDeviceRepository
+	logger   *slog.Logger
+	pending  sync.WaitGroup // Notifications still being delivered
 }
 
-// NewAuthService creates authentication service with dependencies
-func NewAuthService(userRepo UserRepository, authRepo AuthRepository) *AuthService {
-	// Initialize token manager with a default secret (should come from config)
-	tokenManager := jwt.NewTokenManager("default-secret", "conflux")
+// AuthServiceOption customizes an AuthService
+type AuthServiceOption func(*AuthService)
+
+// WithSessionAudit records session creation and admin session purges in the audit log
+func WithSessionAudit(auditService *AuditService) AuthServiceOption {
+	return func(s *AuthService) {
+		s.auditService = auditService
+	}
+}
+
+// WithSessionTokenBytes sets how many random bytes back each session ID
+// Values outside MinSessionTokenBytes..MaxSessionTokenBytes are clamped
+func WithSessionTokenBytes(n int) AuthServiceOption {
+	return func(s *AuthService) {
+		s.sessionTokenBytes = min(max(n, MinSessionTokenBytes), MaxSessionTokenBytes)
+	}
+}
 
-	return &AuthService{
-		userRepo:     userRepo,
-		authRepo:     authRepo,
-		tokenManager: tokenManager,
+// WithSecurityNotifications notifies users of security events through notifier
+// Sign-ins are fingerprinted and recorded in devices, and a sign-in from a device
+// the user hasn't used before is reported. Delivery failures are logged to logger
+func WithSecurityNotifications(notifier Notifier, devices KnownDeviceRepository, logger *slog.Logger) AuthServiceOption {
+	return func(s *AuthService) {
+		s.notifier = notifier
+		s.devices = devices
+		s.logger = logger
 	}
 }
 
+// NewAuthService creates authentication service with dependencies
+// tokenManager signs and validates session JWTs; share it with the auth middleware
+func NewAuthService(userRepo UserRepository, authRepo AuthRepository, tokenManager *jwt.TokenManager, opts ...AuthServiceOption) *AuthService {
+	s := &AuthService{
+		userRepo:          userRepo,
+		authRepo:          authRepo,
+		tokenManager:      tokenManager,
+		sessionTokenBytes: DefaultSessionTokenBytes,
+		notifier:          NopNotifier{},
+		logger:            slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
 // Login authenticates user credentials and returns JWT token
-// Validates credentials, generates JWT, creates session record
-func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*models.AuthResponse, error) {
+// Validates credentials, creates a session record, and issues a JWT referencing it
+// userAgent and ipAddress identify the device for new-device notifications
+func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest, ipAddress, userAgent string) (*models.AuthResponse, error) {
 	// Validate login request
 	if err := req.Validate(); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
@@ -58,19 +122,42 @@ func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*mod
 		return nil, fmt.Errorf("invalid credentials")
 	}
 
-	// Generate JWT token
+	// Checked after the password so unverified status isn't revealed to guessers
+	if !user.EmailVerified {
+		return nil, fmt.Errorf("email not verified")
+	}
+
+	// Generate the opaque session ID and a JWT carrying it as jti
+	sessionID, err := generateSessionID(s.sessionTokenBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session id: %w", err)
+	}
 	duration := time.Hour * 24 // 24 hours
-	token, err := s.tokenManager.GenerateToken(user.ID, user.Email, duration)
+	token, err := s.tokenManager.GenerateSessionToken(user.ID, user.Email, user.Role, sessionID, duration)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
 	// Create session record
 	expiresAt := time.Now().Add(duration)
-	if err := s.authRepo.CreateSession(ctx, user.ID, token, expiresAt); err != nil {
+	if err := s.authRepo.CreateSession(ctx, user.ID, sessionID, token, expiresAt); err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
 
+	if s.auditService != nil {
+		if err := s.auditService.Record(ctx, &models.AuditEntry{
+			ActorID:   user.ID,
+			Action:    models.AuditSessionCreated,
+			IPAddress: ipAddress,
+			// Only a prefix, so the audit log can't be used to look sessions up
+			Details: fmt.Sprintf("session %s... created with a %d-byte id", sessionID[:8], s.sessionTokenBytes),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	s.checkDevice(user.ID, user.Email, ipAddress, userAgent)
+
 	// Sanitize user data
 	user.Password = ""
 
@@ -81,6 +168,73 @@ func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*mod
 	}, nil
 }
 
+// NotifySecurityEvent tells the user at email about a security event in the background
+// Flows that change credentials call this once the change is committed
+func (s *AuthService) NotifySecurityEvent(email string, event SecurityEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	s.inBackground(func(ctx context.Context) {
+		s.deliver(ctx, email, event)
+	})
+}
+
+// checkDevice records the sign-in device in the background and, when the user has
+// signed in before but never from this device, sends a new-device notification
+// A user's first sign-in isn't reported; every device would be new
+func (s *AuthService) checkDevice(userID int, email, ipAddress, userAgent string) {
+	if s.devices == nil {
+		return
+	}
+	event := SecurityEvent{
+		Kind:      SecurityEventNewDevice,
+		Time:      time.Now(),
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+	}
+	s.inBackground(func(ctx context.Context) {
+		known, err := s.devices.CountDevices(ctx, userID)
+		if err != nil {
+			s.logger.Warn("Failed to look up known devices", "user_id", userID, "error", err)
+			return
+		}
+		isNew, err := s.devices.RememberDevice(ctx, userID, deviceFingerprint(userAgent, ipAddress))
+		if err != nil {
+			s.logger.Warn("Failed to record sign-in device", "user_id", userID, "error", err)
+			return
+		}
+		if isNew && known > 0 {
+			s.deliver(ctx, email, event)
+		}
+	})
+}
+
+// inBackground runs fn on its own goroutine with a bounded context
+// The request's context isn't used, as it ends when the response is written
+func (s *AuthService) inBackground(fn func(ctx context.Context)) {
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		fn(ctx)
+	}()
+}
+
+// WaitForNotifications blocks until notifications already started have been delivered
+// Each is bounded by notifyTimeout; the server calls this on shutdown, once requests
+// have drained, so notifications in flight aren't dropped
+func (s *AuthService) WaitForNotifications() {
+	s.pending.Wait()
+}
+
+// deliver sends one notification, logging rather than returning failures
+func (s *AuthService) deliver(ctx context.Context, email string, event SecurityEvent) {
+	if err := s.notifier.Notify(ctx, email, event); err != nil {
+		s.logger.Warn("Failed to send security notification", "event", event.Kind, "error", err)
+	}
+}
+
 // ValidateToken verifies JWT token and returns user information
 func (s *AuthService) ValidateToken(ctx context.Context, token string) (*models.User, error) {
 	// Validate JWT token
@@ -89,8 +243,14 @@ func (s *AuthService) ValidateToken(ctx context.Context, token string) (*models.
 		return nil, fmt.Errorf("invalid token: %w", err)
 	}
 
-	// Validate session in database
-	user, err := s.authRepo.ValidateSession(ctx, token)
+	// Validate session in database, by jti when the token carries one
+	// This also records the activity that keeps an idle timeout from expiring it
+	var user *models.User
+	if claims.ID != "" {
+		user, err = s.authRepo.ValidateSessionID(ctx, claims.ID, s.idleTimeout)
+	} else {
+		user, err = s.authRepo.ValidateSession(ctx, token, s.idleTimeout)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("session not found or expired: %w", err)
 	}
@@ -107,5 +267,111 @@ func (s *AuthService) ValidateToken(ctx context.Context, token string) (*models.
 
 // Logout invalidates user session
 func (s *AuthService) Logout(ctx context.Context, token string) error {
+	if claims, err := s.tokenManager.ValidateToken(token); err == nil && claims.ID != "" {
+		_, err := s.authRepo.InvalidateSessionID(ctx, claims.UserID, claims.ID)
+		return err
+	}
 	return s.authRepo.InvalidateSession(ctx, token)
 }
+
+// ListSessions returns the user's active sessions, marking the one token belongs to
+// Sessions created before session IDs existed aren't listed; they expire on their own
+func (s *AuthService) ListSessions(ctx context.Context, userID int, token string) ([]*models.Session, error) {
+	sessions, err := s.authRepo.ListSessions(ctx, userID, s.idleTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	var currentID string
+	if claims, err := s.tokenManager.ValidateToken(token); err == nil {
+		currentID = claims.ID
+	}
+	for _, session := range sessions {
+		session.Current = currentID != "" && session.SessionID == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeSession invalidates one of the user's own sessions by its session ID
+// Revoking the session making the request is allowed and acts as a logout
+func (s *AuthService) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	if sessionID == "" {
+		return fmt.Errorf("validation failed: session id is required")
+	}
+	removed, err := s.authRepo.InvalidateSessionID(ctx, userID, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if !removed {
+		return fmt.Errorf("session not found")
+	}
+	return nil
+}
+
+// PurgeUserSessions invalidates every session of another user on an admin's behalf
+// Used to force a logout during incident response; returns how many sessions were removed
+func (s *AuthService) PurgeUserSessions(
+	ctx context.Context, actorID int, isAdmin bool, targetUserID int, ipAddress string,
+) (int64, error) {
+	if !isAdmin {
+		return 0, fmt.Errorf("unauthorized to purge sessions for user %d", targetUserID)
+	}
+	if targetUserID < 1 {
+		return 0, fmt.Errorf("validation failed: invalid user id")
+	}
+	if _, err := s.userRepo.GetByID(ctx, targetUserID); err != nil {
+		return 0, fmt.Errorf("user not found")
+	}
+
+	removed, err := s.authRepo.InvalidateAllSessions(ctx, targetUserID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to invalidate sessions: %w", err)
+	}
+
+	if s.auditService != nil {
+		if err := s.auditService.Record(ctx, &models.AuditEntry{
+			ActorID:      actorID,
+			Action:       models.AuditSessionsPurged,
+			TargetUserID: &targetUserID,
+			IPAddress:    ipAddress,
+			Details:      fmt.Sprintf("purged %d session(s)", removed),
+		}); err != nil {
+			return 0, err
+		}
+	}
+	return removed, nil
+}
+
+// CompletePasswordChange signs the user out of every session except the one token
+// belongs to and notifies them that their password changed
+// Call it once the new password is committed; the notification is best-effort
+func (s *AuthService) CompletePasswordChange(ctx context.Context, userID int, token, ipAddress, userAgent string) error {
+	var keepSessionID string
+	if claims, err := s.tokenManager.ValidateToken(token); err == nil {
+		keepSessionID = claims.ID
+	}
+	if _, err := s.authRepo.InvalidateOtherSessions(ctx, userID, keepSessionID); err != nil {
+		return fmt.Errorf("failed to invalidate sessions: %w", err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.Warn("Failed to look up user for password change notification", "user_id", userID, "error", err)
+		return nil
+	}
+	s.NotifySecurityEvent(user.Email, SecurityEvent{
+		Kind:      SecurityEventPasswordChanged,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+	})
+	return nil
+}
+
+// generateSessionID returns a new random opaque session ID of n bytes, hex encoded
+func generateSessionID(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -3,14 +3,22 @@ package service
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"conflux/internal/api/middleware"
 	"conflux/internal/models"
+	"conflux/pkg/jwt"
 	"conflux/pkg/utils"
 )
 
+// testTokenManager signs the tokens AuthService issues in tests
+var testTokenManager = jwt.NewTokenManager("test-secret", "conflux")
+
 // MockAuthRepository is a mock implementation of the AuthRepository interface.
 // It is used for testing purposes to simulate the behavior of an authentication repository.
 // 
@@ -41,17 +49,19 @@ func NewMockAuthRepository() *MockAuthRepository {
 }
 
 // CreateSession implements AuthRepository.CreateSession
-func (m *MockAuthRepository) CreateSession(ctx context.Context, userID int, token string, expiresAt time.Time) error {
+func (m *MockAuthRepository) CreateSession(ctx context.Context, userID int, sessionID, token string, expiresAt time.Time) error {
 	if m.createSessionErr != nil {
 		return m.createSessionErr
 	}
 
 	session := &models.Session{
-		ID:        len(m.sessions) + 1,
-		UserID:    userID,
-		Token:     token,
-		ExpiresAt: expiresAt,
-		CreatedAt: time.Now(),
+		ID:           len(m.sessions) + 1,
+		UserID:       userID,
+		SessionID:    sessionID,
+		Token:        token,
+		ExpiresAt:    expiresAt,
+		LastActivity: time.Now(),
+		CreatedAt:    time.Now(),
 	}
 
 	m.sessions[token] = session
@@ -59,7 +69,7 @@ func (m *MockAuthRepository) CreateSession(ctx context.Context, userID int, toke
 }
 
 // ValidateSession implements AuthRepository.ValidateSession
-func (m *MockAuthRepository) ValidateSession(ctx context.Context, token string) (*models.User, error) {
+func (m *MockAuthRepository) ValidateSession(ctx context.Context, token string, idleTimeout time.Duration) (*models.User, error) {
 	if m.validateSessionErr != nil {
 		return nil, m.validateSessionErr
 	}
@@ -69,9 +79,10 @@ func (m *MockAuthRepository) ValidateSession(ctx context.Context, token string)
 		return nil, errors.New("session not found")
 	}
 
-	if session.ExpiresAt.Before(time.Now()) {
+	if session.ExpiresAt.Before(time.Now()) || sessionIdle(session, idleTimeout) {
 		return nil, errors.New("session expired")
 	}
+	session.LastActivity = time.Now()
 
 	if m.userForSession != nil {
 		return m.userForSession, nil
@@ -84,6 +95,33 @@ func (m *MockAuthRepository) ValidateSession(ctx context.Context, token string)
 	}, nil
 }
 
+// ValidateSessionID implements AuthRepository.ValidateSessionID
+func (m *MockAuthRepository) ValidateSessionID(ctx context.Context, sessionID string, idleTimeout time.Duration) (*models.User, error) {
+	if m.validateSessionErr != nil {
+		return nil, m.validateSessionErr
+	}
+
+	for token, session := range m.sessions {
+		if session.SessionID != "" && session.SessionID == sessionID {
+			return m.ValidateSession(ctx, token, idleTimeout)
+		}
+	}
+	return nil, errors.New("session not found")
+}
+
+// ListSessions implements AuthRepository.ListSessions
+func (m *MockAuthRepository) ListSessions(ctx context.Context, userID int, idleTimeout time.Duration) ([]*models.Session, error) {
+	sessions := []*models.Session{}
+	for _, session := range m.sessions {
+		if session.UserID == userID && session.SessionID != "" && session.ExpiresAt.After(time.Now()) && !sessionIdle(session, idleTimeout) {
+			copied := *session
+			sessions = append(sessions, &copied)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID > sessions[j].ID })
+	return sessions, nil
+}
+
 // InvalidateSession implements AuthRepository.InvalidateSession
 func (m *MockAuthRepository) InvalidateSession(ctx context.Context, token string) error {
 	if m.invalidateSessionErr != nil {
@@ -94,6 +132,70 @@ func (m *MockAuthRepository) InvalidateSession(ctx context.Context, token string
 	return nil
 }
 
+// InvalidateSessionID implements AuthRepository.InvalidateSessionID
+func (m *MockAuthRepository) InvalidateSessionID(ctx context.Context, userID int, sessionID string) (bool, error) {
+	if m.invalidateSessionErr != nil {
+		return false, m.invalidateSessionErr
+	}
+
+	for token, session := range m.sessions {
+		if session.UserID == userID && session.SessionID == sessionID {
+			delete(m.sessions, token)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// InvalidateAllSessions implements AuthRepository.InvalidateAllSessions
+func (m *MockAuthRepository) InvalidateAllSessions(ctx context.Context, userID int) (int64, error) {
+	if m.invalidateSessionErr != nil {
+		return 0, m.invalidateSessionErr
+	}
+
+	var removed int64
+	for token, session := range m.sessions {
+		if session.UserID == userID {
+			delete(m.sessions, token)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// InvalidateOtherSessions implements AuthRepository.InvalidateOtherSessions
+func (m *MockAuthRepository) InvalidateOtherSessions(ctx context.Context, userID int, keepSessionID string) (int64, error) {
+	if m.invalidateSessionErr != nil {
+		return 0, m.invalidateSessionErr
+	}
+
+	var removed int64
+	for token, session := range m.sessions {
+		if session.UserID == userID && (session.SessionID == "" || session.SessionID != keepSessionID) {
+			delete(m.sessions, token)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// DeleteExpiredSessions implements AuthRepository.DeleteExpiredSessions
+func (m *MockAuthRepository) DeleteExpiredSessions(ctx context.Context, idleTimeout time.Duration) (int64, error) {
+	var removed int64
+	for token, session := range m.sessions {
+		if !session.ExpiresAt.After(time.Now()) || sessionIdle(session, idleTimeout) {
+			delete(m.sessions, token)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// sessionIdle reports whether a session has gone unused for longer than idleTimeout
+func sessionIdle(session *models.Session, idleTimeout time.Duration) bool {
+	return idleTimeout > 0 && time.Since(session.LastActivity) > idleTimeout
+}
+
 // Helper methods for testing
 func (m *MockAuthRepository) SetCreateSessionError(err error) {
 	m.createSessionErr = err
@@ -125,7 +227,7 @@ func TestNewAuthService(t *testing.T) {
 	mockUserRepo := NewMockUserRepository()
 	mockAuthRepo := NewMockAuthRepository()
 
-	authService := NewAuthService(mockUserRepo, mockAuthRepo)
+	authService := NewAuthService(mockUserRepo, mockAuthRepo, testTokenManager)
 
 	if authService == nil {
 		t.Fatal("NewAuthService returned nil")
@@ -136,8 +238,54 @@ func TestNewAuthService(t *testing.T) {
 	if authService.authRepo != mockAuthRepo {
 		t.Error("AuthService not initialized with correct auth repository")
 	}
-	if authService.tokenManager == nil {
-		t.Error("AuthService token manager not initialized")
+	if authService.tokenManager != testTokenManager {
+		t.Error("AuthService not initialized with the given token manager")
+	}
+}
+
+func TestAuthService_MiddlewareAcceptsTokenUntilLogout(t *testing.T) {
+	mockUserRepo := NewMockUserRepository()
+	user := &models.User{Email: "test@example.com", Password: mustHashPassword("password123"), EmailVerified: true}
+	if err := mockUserRepo.Create(context.Background(), user); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	authService := NewAuthService(mockUserRepo, NewMockAuthRepository(), testTokenManager)
+
+	resp, err := authService.Login(context.Background(),
+		&models.LoginRequest{Email: "test@example.com", Password: "password123"}, "192.0.2.1", "test-agent")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	serve := func(auth *middleware.Auth) (int, int) {
+		var userID int
+		handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID = middleware.UserID(r)
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/api/me/sessions", nil)
+		req.Header.Set("Authorization", "Bearer "+resp.Token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code, userID
+	}
+
+	// Middleware sharing the service's token manager accepts the token
+	auth := middleware.NewAuth(testTokenManager, authService, nil)
+	if status, userID := serve(auth); status != http.StatusOK || userID != user.ID {
+		t.Errorf("status, user ID = %d, %d, want %d, %d", status, userID, http.StatusOK, user.ID)
+	}
+
+	// A different secret rejects it
+	if status, _ := serve(middleware.NewAuth(jwt.NewTokenManager("other-secret", "conflux"), authService, nil)); status != http.StatusUnauthorized {
+		t.Errorf("status with another secret = %d, want %d", status, http.StatusUnauthorized)
+	}
+
+	// Logging out revokes access even though the JWT hasn't expired
+	if err := authService.Logout(context.Background(), resp.Token); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if status, _ := serve(auth); status != http.StatusUnauthorized {
+		t.Errorf("status after logout = %d, want %d", status, http.StatusUnauthorized)
 	}
 }
 
@@ -159,11 +307,12 @@ func TestAuthService_Login(t *testing.T) {
 				Password: "password123",
 			},
 			setupUser: &models.User{
-				ID:        1,
-				Email:     "test@example.com",
-				Password:  mustHashPassword("password123"),
-				FirstName: "John",
-				LastName:  "Doe",
+				ID:            1,
+				Email:         "test@example.com",
+				Password:      mustHashPassword("password123"),
+				FirstName:     "John",
+				LastName:      "Doe",
+				EmailVerified: true,
 			},
 			wantErr:          false,
 			validateResponse: true,
@@ -198,14 +347,29 @@ func TestAuthService_Login(t *testing.T) {
 				Email:    "test@example.com",
 				Password: "password123",
 			},
+			setupUser: &models.User{
+				ID:            1,
+				Email:         "test@example.com",
+				Password:      mustHashPassword("password123"),
+				EmailVerified: true,
+			},
+			authRepoErr:   errors.New("database error"),
+			wantErr:       true,
+			errorContains: "failed to create session",
+		},
+		{
+			name: "unverified email",
+			loginReq: &models.LoginRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+			},
 			setupUser: &models.User{
 				ID:       1,
 				Email:    "test@example.com",
 				Password: mustHashPassword("password123"),
 			},
-			authRepoErr:   errors.New("database error"),
 			wantErr:       true,
-			errorContains: "failed to create session",
+			errorContains: "email not verified",
 		},
 		{
 			name: "empty email",
@@ -236,7 +400,7 @@ func TestAuthService_Login(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockUserRepo := NewMockUserRepository()
 			mockAuthRepo := NewMockAuthRepository()
-			authService := NewAuthService(mockUserRepo, mockAuthRepo)
+			authService := NewAuthService(mockUserRepo, mockAuthRepo, testTokenManager)
 
 			// Set up user if needed
 			if tt.setupUser != nil {
@@ -255,7 +419,7 @@ func TestAuthService_Login(t *testing.T) {
 			}
 
 			// Execute test
-			response, err := authService.Login(context.Background(), tt.loginReq)
+			response, err := authService.Login(context.Background(), tt.loginReq, "127.0.0.1", "test-agent")
 
 			// Validate results
 			if tt.wantErr {
@@ -361,7 +525,7 @@ func TestAuthService_ValidateToken(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockUserRepo := NewMockUserRepository()
 			mockAuthRepo := NewMockAuthRepository()
-			authService := NewAuthService(mockUserRepo, mockAuthRepo)
+			authService := NewAuthService(mockUserRepo, mockAuthRepo, testTokenManager)
 
 			var token string
 			if tt.generateToken && tt.setupUser != nil {
@@ -380,6 +544,7 @@ func TestAuthService_ValidateToken(t *testing.T) {
 				err = mockAuthRepo.CreateSession(
 					context.Background(),
 					tt.setupUser.ID,
+					"",
 					token,
 					time.Now().Add(time.Hour),
 				)
@@ -465,7 +630,7 @@ func TestAuthService_Logout(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockUserRepo := NewMockUserRepository()
 			mockAuthRepo := NewMockAuthRepository()
-			authService := NewAuthService(mockUserRepo, mockAuthRepo)
+			authService := NewAuthService(mockUserRepo, mockAuthRepo, testTokenManager)
 
 			// Set up repository error
 			if tt.repoErr != nil {
@@ -494,16 +659,17 @@ func TestAuthService_Logout(t *testing.T) {
 func TestAuthService_Integration(t *testing.T) {
 	mockUserRepo := NewMockUserRepository()
 	mockAuthRepo := NewMockAuthRepository()
-	authService := NewAuthService(mockUserRepo, mockAuthRepo)
+	authService := NewAuthService(mockUserRepo, mockAuthRepo, testTokenManager)
 
 	ctx := context.Background()
 
 	// 1. Create a test user
 	testUser := &models.User{
-		Email:     "integration@example.com",
-		Password:  mustHashPassword("testpassword123"),
-		FirstName: "Integration",
-		LastName:  "Test",
+		Email:         "integration@example.com",
+		Password:      mustHashPassword("testpassword123"),
+		FirstName:     "Integration",
+		LastName:      "Test",
+		EmailVerified: true,
 	}
 	err := mockUserRepo.Create(ctx, testUser)
 	if err != nil {
@@ -516,7 +682,7 @@ func TestAuthService_Integration(t *testing.T) {
 		Password: "testpassword123",
 	}
 
-	authResponse, err := authService.Login(ctx, loginReq)
+	authResponse, err := authService.Login(ctx, loginReq, "127.0.0.1", "test-agent")
 	if err != nil {
 		t.Fatalf("Login failed: %v", err)
 	}
@@ -563,7 +729,7 @@ func TestAuthService_Integration(t *testing.T) {
 		Email:    "integration@example.com",
 		Password: "wrongpassword",
 	}
-	_, err = authService.Login(ctx, wrongLoginReq)
+	_, err = authService.Login(ctx, wrongLoginReq, "127.0.0.1", "test-agent")
 	if err == nil {
 		t.Error("Login with wrong password should fail")
 	}
@@ -581,11 +747,148 @@ func mustHashPassword(password string) string {
 	return hash
 }
 
+func TestAuthService_PurgeUserSessions(t *testing.T) {
+	ctx := context.Background()
+	mockUserRepo := NewMockUserRepository()
+	mockAuthRepo := NewMockAuthRepository()
+	auditRepo := &MockAuditRepository{}
+	authService := NewAuthService(mockUserRepo, mockAuthRepo, testTokenManager, WithSessionAudit(NewAuditService(auditRepo)))
+
+	target := &models.User{Email: "target@example.com"}
+	if err := mockUserRepo.Create(ctx, target); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	expiresAt := time.Now().Add(time.Hour)
+	for _, session := range []struct {
+		userID int
+		token  string
+	}{{target.ID, "target-1"}, {target.ID, "target-2"}, {target.ID + 1, "other"}} {
+		if err := mockAuthRepo.CreateSession(ctx, session.userID, "", session.token, expiresAt); err != nil {
+			t.Fatalf("CreateSession() error = %v", err)
+		}
+	}
+
+	// Non-admins are rejected before anything is touched
+	if _, err := authService.PurgeUserSessions(ctx, 99, false, target.ID, ""); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Fatalf("PurgeUserSessions() as non-admin error = %v, want unauthorized", err)
+	}
+	if len(mockAuthRepo.sessions) != 3 || len(auditRepo.entries) != 0 {
+		t.Fatal("non-admin purge removed sessions or wrote an audit entry")
+	}
+
+	for _, id := range []int{0, -1} {
+		if _, err := authService.PurgeUserSessions(ctx, 99, true, id, ""); err == nil || !strings.Contains(err.Error(), "validation failed") {
+			t.Errorf("PurgeUserSessions(%d) error = %v, want validation failure", id, err)
+		}
+	}
+	if _, err := authService.PurgeUserSessions(ctx, 99, true, target.ID+100, ""); err == nil || !strings.Contains(err.Error(), "user not found") {
+		t.Errorf("PurgeUserSessions() for unknown user error = %v, want user not found", err)
+	}
+
+	removed, err := authService.PurgeUserSessions(ctx, 99, true, target.ID, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("PurgeUserSessions() error = %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("removed = %d, want 2", removed)
+	}
+	if _, ok := mockAuthRepo.sessions["other"]; !ok || len(mockAuthRepo.sessions) != 1 {
+		t.Errorf("remaining sessions = %v, want only the other user's", mockAuthRepo.sessions)
+	}
+
+	if len(auditRepo.entries) != 1 {
+		t.Fatalf("audit entries = %d, want 1", len(auditRepo.entries))
+	}
+	entry := auditRepo.entries[0]
+	if entry.Action != models.AuditSessionsPurged || entry.ActorID != 99 || entry.TargetUserID == nil || *entry.TargetUserID != target.ID {
+		t.Errorf("audit entry = %+v, want %s by 99 targeting %d", entry, models.AuditSessionsPurged, target.ID)
+	}
+}
+
+func TestAuthService_SessionIDs(t *testing.T) {
+	ctx := context.Background()
+	mockUserRepo := NewMockUserRepository()
+	mockAuthRepo := NewMockAuthRepository()
+	auditRepo := &MockAuditRepository{}
+	authService := NewAuthService(mockUserRepo, mockAuthRepo, testTokenManager,
+		WithSessionAudit(NewAuditService(auditRepo)), WithSessionTokenBytes(24))
+
+	user := &models.User{Email: "devices@example.com", Password: mustHashPassword("password123"), EmailVerified: true}
+	if err := mockUserRepo.Create(ctx, user); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	loginReq := &models.LoginRequest{Email: user.Email, Password: "password123"}
+
+	// Two devices log in; each session gets its own opaque ID in the jti
+	var tokens []string
+	for range 2 {
+		response, err := authService.Login(ctx, loginReq, "10.0.0.1", "test-agent")
+		if err != nil {
+			t.Fatalf("Login() error = %v", err)
+		}
+		tokens = append(tokens, response.Token)
+	}
+	sessions, err := authService.ListSessions(ctx, user.ID, tokens[0])
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("ListSessions() returned %d sessions, want 2", len(sessions))
+	}
+	var current string
+	for _, session := range sessions {
+		if len(session.SessionID) != 48 {
+			t.Errorf("session ID %q has %d characters, want 48", session.SessionID, len(session.SessionID))
+		}
+		if session.Current {
+			current = session.SessionID
+		}
+	}
+	if claims, _ := authService.tokenManager.ValidateToken(tokens[0]); claims == nil || claims.ID != current {
+		t.Errorf("current session = %q, want the first token's jti", current)
+	}
+
+	if len(auditRepo.entries) != 2 || auditRepo.entries[0].Action != models.AuditSessionCreated {
+		t.Fatalf("audit entries = %+v, want two %s entries", auditRepo.entries, models.AuditSessionCreated)
+	}
+	if strings.Contains(auditRepo.entries[0].Details, current) {
+		t.Error("audit entry contains the full session ID")
+	}
+
+	// Revoking one device leaves the other signed in
+	if err := authService.RevokeSession(ctx, user.ID, current); err != nil {
+		t.Fatalf("RevokeSession() error = %v", err)
+	}
+	if _, err := authService.ValidateToken(ctx, tokens[0]); err == nil {
+		t.Error("ValidateToken() succeeded for a revoked session")
+	}
+	if _, err := authService.ValidateToken(ctx, tokens[1]); err != nil {
+		t.Errorf("ValidateToken() for the other session error = %v", err)
+	}
+	if err := authService.RevokeSession(ctx, user.ID, current); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("RevokeSession() twice error = %v, want not found", err)
+	}
+
+	// Sessions can't be revoked by another user
+	remaining, _ := authService.tokenManager.ValidateToken(tokens[1])
+	if err := authService.RevokeSession(ctx, user.ID+1, remaining.ID); err == nil {
+		t.Error("RevokeSession() by another user succeeded")
+	}
+
+	// Logout removes the session behind the token's jti
+	if err := authService.Logout(ctx, tokens[1]); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if len(mockAuthRepo.sessions) != 0 {
+		t.Errorf("sessions after logout = %d, want 0", len(mockAuthRepo.sessions))
+	}
+}
+
 // Benchmark tests
 func BenchmarkAuthService_Login(b *testing.B) {
 	mockUserRepo := NewMockUserRepository()
 	mockAuthRepo := NewMockAuthRepository()
-	authService := NewAuthService(mockUserRepo, mockAuthRepo)
+	authService := NewAuthService(mockUserRepo, mockAuthRepo, testTokenManager)
 
 	// Set up test user
 	testUser := &models.User{
@@ -604,7 +907,7 @@ func BenchmarkAuthService_Login(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, err := authService.Login(context.Background(), loginReq)
+		_, err := authService.Login(context.Background(), loginReq, "127.0.0.1", "test-agent")
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -614,7 +917,7 @@ func BenchmarkAuthService_Login(b *testing.B) {
 func BenchmarkAuthService_ValidateToken(b *testing.B) {
 	mockUserRepo := NewMockUserRepository()
 	mockAuthRepo := NewMockAuthRepository()
-	authService := NewAuthService(mockUserRepo, mockAuthRepo)
+	authService := NewAuthService(mockUserRepo, mockAuthRepo, testTokenManager)
 
 	// Set up test user and token
 	testUser := &models.User{
@@ -632,7 +935,7 @@ func BenchmarkAuthService_ValidateToken(b *testing.B) {
 		Password: "password123",
 	}
 
-	authResponse, err := authService.Login(context.Background(), loginReq)
+	authResponse, err := authService.Login(context.Background(), loginReq, "127.0.0.1", "test-agent")
 	if err != nil {
 		b.Fatal(err)
 	}
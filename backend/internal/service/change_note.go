@@ -0,0 +1,50 @@
+// Change note policy
+// Optionally requires a change note on configuration edits that touch many lines
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"conflux/pkg/config"
+)
+
+// WithChangeNotePolicy requires a change note when an update changes at least
+// minLines lines; zero or less turns the policy off, which is the default
+func WithChangeNotePolicy(minLines int) ConfigServiceOption {
+	return func(s *ConfigService) {
+		s.changeNoteMinLines = max(minLines, 0)
+	}
+}
+
+// checkChangeNote enforces the change note policy for an edit from oldContent to newContent
+// Edits below the threshold, including no-op saves, never need a note
+func (s *ConfigService) checkChangeNote(oldContent, newContent, changeNote string) error {
+	if s.changeNoteMinLines == 0 || strings.TrimSpace(changeNote) != "" {
+		return nil
+	}
+	if changed := changedLineCount(oldContent, newContent); changed >= s.changeNoteMinLines {
+		return fmt.Errorf("validation failed: a change note is required for changes of %d or more lines (this change touches %d)",
+			s.changeNoteMinLines, changed)
+	}
+	return nil
+}
+
+// changedLineCount returns how many lines an edit touches
+// A run of removed lines replaced by added lines counts its longer side, so
+// editing one line in place counts once
+func changedLineCount(oldContent, newContent string) int {
+	changed, removed, added := 0, 0, 0
+	for _, op := range config.DiffLines(oldContent, newContent) {
+		switch op.Kind {
+		case config.LineRemoved:
+			removed++
+		case config.LineAdded:
+			added++
+		default:
+			changed += max(removed, added)
+			removed, added = 0, 0
+		}
+	}
+	return changed + max(removed, added)
+}
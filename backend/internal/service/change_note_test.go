@@ -0,0 +1,73 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestChangedLineCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     int
+	}{
+		{name: "no change", old: "a\nb\n", new: "a\nb\n", want: 0},
+		{name: "one line edited", old: "a\nb\nc\n", new: "a\nB\nc\n", want: 1},
+		{name: "lines added", old: "a\n", new: "a\nb\nc\n", want: 2},
+		{name: "lines removed", old: "a\nb\nc\n", new: "c\n", want: 2},
+		{name: "separate edits", old: "a\nb\nc\nd\n", new: "A\nb\nC\nD\n", want: 3},
+		{name: "replaced by fewer lines", old: "a\nb\nc\n", new: "x\n", want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := changedLineCount(tt.old, tt.new); got != tt.want {
+				t.Errorf("changedLineCount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigService_ChangeNotePolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		minLines   int
+		content    string
+		changeNote string
+		wantErr    bool
+	}{
+		{name: "policy off", content: "a: 1\nb: 2\nc: 3\n"},
+		{name: "below threshold", minLines: 3, content: "port: 9090\n"},
+		{name: "no-op save", minLines: 1, content: "port: 8080\n"},
+		{name: "at threshold without note", minLines: 2, content: "port: 9090\nhost: example.com\n", wantErr: true},
+		{name: "blank note", minLines: 2, content: "port: 9090\nhost: example.com\n", changeNote: "  ", wantErr: true},
+		{name: "at threshold with note", minLines: 2, content: "port: 9090\nhost: example.com\n", changeNote: "Move to production"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewConfigService(NewMockConfigRepository(), WithChangeNotePolicy(tt.minLines))
+			template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 8080\n"}
+			if err := service.CreateTemplate(template); err != nil {
+				t.Fatalf("CreateTemplate() error = %v", err)
+			}
+			userConfig, err := service.CreateUserConfig(1, template.ID, "mine", nil)
+			if err != nil {
+				t.Fatalf("CreateUserConfig() error = %v", err)
+			}
+
+			_, err = service.UpdateUserConfig(userConfig.ID, 1, tt.content, tt.changeNote, nil)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("UpdateUserConfig() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "validation failed") || !strings.Contains(err.Error(), "touches 2") {
+				t.Errorf("UpdateUserConfig() error = %v, want a validation failure reporting the change size", err)
+			}
+		})
+	}
+}
@@ -4,80 +4,220 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"conflux/internal/models"
 	"conflux/pkg/config"
+	"conflux/pkg/utils"
 )
 
 // ConfigService provides configuration management functionality
 type ConfigService struct {
-	configRepo ConfigRepository
-	parser     *config.Parser
+	configRepo   ConfigRepository
+	parser       *config.Parser
+	importWorker *ImportWorker
+	httpClient   *http.Client // URL imports; refuses internal addresses unless allowed
+	githubClient *http.Client
+	githubAPIURL string
+	importTokens ImportTokenSource // nil when imports can't use stored tokens
+	scanSecrets  bool
+	secrets      config.SecretSource
+	templates    *templateCache // nil when caching is disabled
+	audit        *AuditService  // nil when activity isn't recorded
+
+	versionPolicy      TemplateVersionPolicy
+	changeNoteMinLines int  // 0 when change notes are optional
+	uniqueNames        bool // Each user's configuration names must differ
+	editLockTTL        time.Duration
+
+	createLimiter *templateRateLimiter // nil when creation isn't rate limited
+
+	allowPrivateImports bool // URL imports may reach internal addresses
+}
+
+// ConfigServiceOption customizes a ConfigService
+type ConfigServiceOption func(*ConfigService)
+
+// WithSecretScan enables or disables warnings for secret-like values on save
+// Scanning is on by default
+func WithSecretScan(enabled bool) ConfigServiceOption {
+	return func(s *ConfigService) {
+		s.scanSecrets = enabled
+	}
 }
 
 // ConfigRepository defines the interface for configuration data access
+// Create and update methods fill in the model's ID and database-generated
+// created_at/updated_at values; callers never set timestamps themselves
 type ConfigRepository interface {
 	// Template management
 	CreateTemplate(template *models.ConfigTemplate) error
 	GetTemplate(id int) (*models.ConfigTemplate, error)
 	GetTemplates(category, search string, page, limit int) ([]*models.ConfigTemplate, int64, error)
-	UpdateTemplate(id int, updates *models.ConfigTemplate) error
+	// plan, when not nil, decides the version bump inside the update's transaction
+	UpdateTemplate(id int, updates *models.ConfigTemplate, plan models.TemplateVersionPlanner) error
 	DeleteTemplate(id int) error
 
+	// Template version history, newest first; entries are added by UpdateTemplate
+	GetTemplateVersions(templateID int) ([]*models.TemplateVersion, error)
+
+	// Configurations created from a template with their owners, most recently updated first
+	GetTemplateDependents(templateID, page, limit int) ([]*models.TemplateDependent, int64, error)
+
 	// User configuration management
 	CreateUserConfig(config *models.UserConfig) error
 	GetUserConfig(id int) (*models.UserConfig, error)
-	GetUserConfigs(userID int, templateID *int, page, limit int) ([]*models.UserConfig, int64, error)
+	GetUserConfigs(userID int, templateID *int, order models.ListSort, page, limit int) ([]*models.UserConfig, int64, error)
+	GetUserConfigsAfter(userID int, templateID *int, afterID, limit int) ([]*models.UserConfig, error) // Keyset page in ID order
+	// Whether the user has a configuration with this name, ignoring case
+	UserConfigNameExists(userID int, name string) (bool, error)
 	UpdateUserConfig(id int, config *models.UserConfig) error
 	DeleteUserConfig(id int) error
 
+	// Edit locks; a lock past its expiry counts as released
+	// AcquireConfigLock locks for holderID until ttl from now, or renews the holder's lock,
+	// unless someone else holds it; it returns the lock in force either way
+	AcquireConfigLock(configID, holderID int, ttl time.Duration) (*models.ConfigLock, error)
+	GetConfigLock(configID int) (*models.ConfigLock, error) // nil when unlocked
+	ReleaseConfigLock(configID, holderID int) error
+
+	// Shares; sharing with a user who already has a share replaces its permission.
+	// ShareConfig records all the shares or, when a recipient doesn't exist, none of them
+	ShareConfig(shares []*models.ConfigShare) error
+	GetConfigShare(configID, userID int) (*models.ConfigShare, error) // nil when not shared with the user
+
 	// Version management
 	CreateVersion(version *models.ConfigVersion) error
 	GetConfigVersion(id int) (*models.ConfigVersion, error)
-	GetConfigVersions(configID int, page, limit int) ([]*models.ConfigVersion, int64, error)
+	GetConfigVersions(configID int, order models.ListSort, page, limit int) ([]*models.ConfigVersion, int64, error)
+	GetConfigVersionsBefore(configID, beforeID, limit int) ([]*models.ConfigVersion, error) // Keyset page, newest first; beforeID 0 starts at the newest
 
 	// Import management
 	CreateImport(importRecord *models.ConfigImport) error
 	GetImport(id int) (*models.ConfigImport, error)
 	UpdateImport(id int, updates *models.ConfigImport) error
+	GetLatestCompletedImport(userID int, sourceURL string) (*models.ConfigImport, error) // Most recent completed import of a source
+
+	// Imports in a status across all users, oldest first, for operators
+	GetImportsByStatus(status models.ImportStatus, page, limit int) ([]*models.ConfigImport, int64, error)
+
+	// Template variables
+	GetTemplateVariables(templateID int) ([]*models.ConfigVariable, error)
+}
+
+// WithSecretSource sets where ${secret:NAME} placeholders are resolved from on export
+// Defaults to environment variables prefixed with config.DefaultSecretEnvPrefix
+func WithSecretSource(source config.SecretSource) ConfigServiceOption {
+	return func(s *ConfigService) {
+		s.secrets = source
+	}
+}
+
+// WithTemplateCache caches templates in memory for ttl; a zero ttl disables caching
+// Updates and deletes through the service invalidate the cached entry
+func WithTemplateCache(ttl time.Duration) ConfigServiceOption {
+	return func(s *ConfigService) {
+		s.templates = nil
+		if ttl > 0 {
+			s.templates = newTemplateCache(ttl)
+		}
+	}
+}
+
+// WithAuditLog records configuration creations, updates, restores, and imports
+// as audit entries, which back each user's activity feed
+func WithAuditLog(audit *AuditService) ConfigServiceOption {
+	return func(s *ConfigService) {
+		s.audit = audit
+	}
 }
 
 // NewConfigService creates a new configuration service
-func NewConfigService(configRepo ConfigRepository) *ConfigService {
-	return &ConfigService{
-		configRepo: configRepo,
-		parser:     config.NewParser(),
+func NewConfigService(configRepo ConfigRepository, opts ...ConfigServiceOption) *ConfigService {
+	s := &ConfigService{
+		configRepo:   configRepo,
+		parser:       config.NewParser(),
+		importWorker: NewImportWorker(defaultMaxConcurrentImports),
+		githubClient: &http.Client{Timeout: importFetchTimeout},
+		githubAPIURL: DefaultGitHubAPIURL,
+		scanSecrets:  true,
+		secrets:      config.EnvSecretSource{Prefix: config.DefaultSecretEnvPrefix},
+
+		versionPolicy: DefaultTemplateVersionPolicy,
+		editLockTTL:   DefaultEditLockTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	s.httpClient = newImportHTTPClient(s.allowPrivateImports)
+	return s
 }
 
 // Template Management
 
 // CreateTemplate creates a new configuration template
+// Content that looks like a different format than declared is saved with a warning
 func (s *ConfigService) CreateTemplate(template *models.ConfigTemplate) error {
 	// Validate the template content
-	if err := s.validateTemplateContent(template); err != nil {
+	warnings, err := s.validateTemplateContent(template)
+	if err != nil {
 		return fmt.Errorf("template validation failed: %w", err)
 	}
 
-	template.CreatedAt = time.Now()
-	template.UpdatedAt = time.Now()
-
-	return s.configRepo.CreateTemplate(template)
+	if template.Version == "" {
+		template.Version = initialTemplateVersion
+	}
+	template.Warnings = nil
+	if err := s.configRepo.CreateTemplate(template); err != nil {
+		return err
+	}
+	template.Warnings = warnings
+	return nil
 }
 
 // GetTemplate retrieves a configuration template by ID
+// Served from the template cache when enabled
 func (s *ConfigService) GetTemplate(id int) (*models.ConfigTemplate, error) {
-	return s.configRepo.GetTemplate(id)
+	if s.templates == nil {
+		return s.configRepo.GetTemplate(id)
+	}
+
+	template, generation, ok := s.templates.get(id)
+	if ok {
+		return template, nil
+	}
+
+	template, err := s.configRepo.GetTemplate(id)
+	if err != nil {
+		return nil, err
+	}
+	s.templates.set(id, template, generation)
+	return template, nil
 }
 
 // GetTemplates retrieves all configuration templates with optional filtering
 func (s *ConfigService) GetTemplates(category, search string, page, limit int) ([]*models.ConfigTemplate, int64, error) {
-	return s.configRepo.GetTemplates(category, search, page, limit)
+	templates, total, err := s.configRepo.GetTemplates(category, search, page, limit)
+	return emptyIfNil(templates), total, err
 }
 
 // UpdateTemplate updates an existing configuration template
+// The version is managed here: changes to the default content, schema, or variables
+// bump it according to the version policy, and each bump is recorded in the history
+// The bump is decided against the template as stored inside the update's
+// transaction, so concurrent updates can't record the same version twice
+// New content is checked against the template's format; mismatch warnings land in updates.Warnings
 func (s *ConfigService) UpdateTemplate(id int, updates *models.ConfigTemplate) error {
 	existing, err := s.configRepo.GetTemplate(id)
 	if err != nil {
@@ -85,39 +225,105 @@ func (s *ConfigService) UpdateTemplate(id int, updates *models.ConfigTemplate) e
 	}
 
 	// Validate updated content
+	var warnings []string
 	if updates.DefaultContent != "" {
 		updates.Format = existing.Format // Keep original format
-		if err := s.validateTemplateContent(updates); err != nil {
+		if warnings, err = s.validateTemplateContent(updates); err != nil {
 			return fmt.Errorf("template validation failed: %w", err)
 		}
 	}
+	updates.Warnings = nil
+	updates.Version = "" // Only a bump changes the version
 
-	updates.UpdatedAt = time.Now()
-	return s.configRepo.UpdateTemplate(id, updates)
+	plan := func(current *models.ConfigTemplate, variables []*models.ConfigVariable) *models.TemplateVersion {
+		bump, changes := s.templateBump(current, variables, updates)
+		if bump == models.BumpNone {
+			return nil
+		}
+		previousContent := current.DefaultContent
+		return &models.TemplateVersion{
+			Version:         bumpVersion(current.Version, bump),
+			PreviousVersion: current.Version,
+			Bump:            bump,
+			Changes:         changes,
+			PreviousContent: &previousContent,
+		}
+	}
+
+	defer s.invalidateTemplate(id)
+	if err := s.configRepo.UpdateTemplate(id, updates, plan); err != nil {
+		return err
+	}
+	updates.Warnings = warnings
+	return nil
 }
 
 // DeleteTemplate deletes a configuration template
 func (s *ConfigService) DeleteTemplate(id int) error {
+	defer s.invalidateTemplate(id)
 	return s.configRepo.DeleteTemplate(id)
 }
 
+// GetTemplateDependents lists the configurations created from a template, for admins
+// Templates have no owner, so this is limited to admins as it exposes other users' configs
+func (s *ConfigService) GetTemplateDependents(
+	templateID int, isAdmin bool, page, limit int,
+) ([]*models.TemplateDependent, int64, error) {
+	if !isAdmin {
+		return nil, 0, fmt.Errorf("unauthorized to list dependents of template %d", templateID)
+	}
+	if _, err := s.GetTemplate(templateID); err != nil {
+		return nil, 0, fmt.Errorf("template not found: %w", err)
+	}
+	dependents, total, err := s.configRepo.GetTemplateDependents(templateID, page, limit)
+	return emptyIfNil(dependents), total, err
+}
+
+// invalidateTemplate drops a template from the cache, if enabled
+func (s *ConfigService) invalidateTemplate(id int) {
+	if s.templates != nil {
+		s.templates.invalidate(id)
+	}
+}
+
 // User Configuration Management
 
 // CreateUserConfig creates a new user configuration from a template
-func (s *ConfigService) CreateUserConfig(userID, templateID int, name string) (*models.UserConfig, error) {
-	template, err := s.configRepo.GetTemplate(templateID)
+// Properties the template's content leaves out are filled from its schema defaults,
+// then values, keyed by variable name, are set at each template variable's path.
+// Missing required variables and invalid values fail validation before anything is stored.
+// Only requests that pass validation count against the template's creation rate limit
+func (s *ConfigService) CreateUserConfig(userID, templateID int, name string, values map[string]string) (*models.UserConfig, error) {
+	template, err := s.GetTemplate(templateID)
 	if err != nil {
 		return nil, fmt.Errorf("template not found: %w", err)
 	}
 
+	if err := s.checkConfigName(userID, name); err != nil {
+		return nil, err
+	}
+
+	content := template.DefaultContent
+	if template.Schema != nil {
+		if content, err = s.parser.ApplyDefaults(content, template.Format, *template.Schema); err != nil {
+			return nil, fmt.Errorf("failed to apply schema defaults: %w", err)
+		}
+	}
+	if content, err = s.renderVariables(template, content, values); err != nil {
+		return nil, err
+	}
+	if err := s.allowCreate(templateID); err != nil {
+		return nil, err
+	}
+
 	userConfig := &models.UserConfig{
 		UserID:     userID,
 		TemplateID: &templateID,
 		Name:       name,
-		Content:    template.DefaultContent,
+		Content:    content,
 		Format:     template.Format,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+
+		TemplateVersion: template.Version,
 	}
 
 	if err := s.configRepo.CreateUserConfig(userConfig); err != nil {
@@ -125,41 +331,120 @@ func (s *ConfigService) CreateUserConfig(userID, templateID int, name string) (*
 	}
 
 	// Create initial version
-	if err := s.createConfigVersion(userConfig, "Initial version"); err != nil {
+	if _, err := s.createConfigVersion(userConfig, userID, "Initial version", nil); err != nil {
 		return nil, fmt.Errorf("failed to create initial version: %w", err)
 	}
 
+	s.recordActivity(models.AuditConfigCreated, userID, userConfig,
+		fmt.Sprintf("Created %q from template %q", userConfig.Name, template.Name))
+
+	userConfig.Warnings = s.secretWarnings(userConfig.Content, userConfig.Format)
 	return userConfig, nil
 }
 
-// GetUserConfig retrieves a user configuration by ID
+// GetUserConfig retrieves a configuration the user can read
+// Owners and users it is shared with can read it; see configAccess
 func (s *ConfigService) GetUserConfig(id, userID int) (*models.UserConfig, error) {
-	config, err := s.configRepo.GetUserConfig(id)
+	return s.getConfigWithAccess(id, userID, models.ShareRead)
+}
+
+// GetUserConfigs retrieves all configurations for a user in the given order
+func (s *ConfigService) GetUserConfigs(
+	userID int, templateID *int, order models.ListSort, page, limit int,
+) ([]*models.UserConfig, int64, error) {
+	configs, total, err := s.configRepo.GetUserConfigs(userID, templateID, order, page, limit)
+	return emptyIfNil(configs), total, err
+}
+
+// GetUserConfigsByCursor retrieves a page of a user's configurations in ID order
+// Returns the cursor for the following page, or "" on the last page
+func (s *ConfigService) GetUserConfigsByCursor(
+	userID int, templateID *int, cursor string, limit int,
+) ([]*models.UserConfig, string, error) {
+	afterID, err := utils.DecodeCursor(cursor)
 	if err != nil {
-		return nil, err
+		return nil, "", fmt.Errorf("validation failed: %w", err)
 	}
 
-	if config.UserID != userID {
-		return nil, fmt.Errorf("unauthorized access to configuration")
+	configs, err := s.configRepo.GetUserConfigsAfter(userID, templateID, afterID, limit+1)
+	if err != nil {
+		return nil, "", err
 	}
-
-	return config, nil
-}
-
-// GetUserConfigs retrieves all configurations for a user
-func (s *ConfigService) GetUserConfigs(userID int, templateID *int, page, limit int) ([]*models.UserConfig, int64, error) {
-	return s.configRepo.GetUserConfigs(userID, templateID, page, limit)
+	if len(configs) <= limit {
+		return emptyIfNil(configs), "", nil
+	}
+	configs = configs[:limit]
+	return configs, utils.EncodeCursor(configs[limit-1].ID), nil
 }
 
 // UpdateUserConfig updates a user configuration and creates a new version
+// Large edits without a change note fail validation when the change note policy is on,
+// and a *ConfigLockedError is returned while someone else holds the edit lock
 func (s *ConfigService) UpdateUserConfig(
 	id, userID int, content, changeNote string, format *models.ConfigFormat,
 ) (*models.UserConfig, error) {
-	config, err := s.GetUserConfig(id, userID)
+	config, err := s.getWritableConfig(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkEditLock(id, userID); err != nil {
+		return nil, err
+	}
+	if err := s.checkChangeNote(config.Content, content, changeNote); err != nil {
+		return nil, err
+	}
+
+	updated, _, err := s.saveUserConfig(config, userID, content, changeNote, format, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := fmt.Sprintf("Updated %q", updated.Name)
+	if changeNote != "" {
+		summary += ": " + changeNote
+	}
+	s.recordActivity(models.AuditConfigUpdated, userID, updated, summary)
+	return updated, nil
+}
+
+// PatchUserConfig applies a JSON merge patch (RFC 7396) to a configuration and versions the result
+// The patched content is written in the configuration's format and validated like any update
+func (s *ConfigService) PatchUserConfig(id, userID int, patch []byte, changeNote string) (*models.UserConfig, error) {
+	config, err := s.getWritableConfig(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkEditLock(id, userID); err != nil {
+		return nil, err
+	}
+
+	content, err := s.parser.ApplyMergePatch(config.Content, config.Format, patch)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.checkChangeNote(config.Content, content, changeNote); err != nil {
+		return nil, err
+	}
+
+	updated, _, err := s.saveUserConfig(config, userID, content, changeNote, nil, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	summary := fmt.Sprintf("Patched %q", updated.Name)
+	if changeNote != "" {
+		summary += ": " + changeNote
+	}
+	s.recordActivity(models.AuditConfigUpdated, userID, updated, summary)
+	return updated, nil
+}
+
+// saveUserConfig validates and stores new content for a configuration, then versions it
+// actorID is the user making the change, who may not own the configuration.
+// restoredFrom is the ID of the version being restored, if any; returns the new version
+func (s *ConfigService) saveUserConfig(
+	config *models.UserConfig, actorID int, content, changeNote string, format *models.ConfigFormat, restoredFrom *int,
+) (*models.UserConfig, *models.ConfigVersion, error) {
 	// Validate new content
 	actualFormat := config.Format
 	if format != nil {
@@ -167,7 +452,7 @@ func (s *ConfigService) UpdateUserConfig(
 	}
 
 	if err := s.validateConfigContent(content, actualFormat); err != nil {
-		return nil, fmt.Errorf("configuration validation failed: %w", err)
+		return nil, nil, fmt.Errorf("configuration validation failed: %w", err)
 	}
 
 	// Update configuration
@@ -175,23 +460,24 @@ func (s *ConfigService) UpdateUserConfig(
 	if format != nil {
 		config.Format = *format
 	}
-	config.UpdatedAt = time.Now()
 
-	if err := s.configRepo.UpdateUserConfig(id, config); err != nil {
-		return nil, err
+	if err := s.configRepo.UpdateUserConfig(config.ID, config); err != nil {
+		return nil, nil, err
 	}
 
 	// Create new version
-	if err := s.createConfigVersion(config, changeNote); err != nil {
-		return nil, fmt.Errorf("failed to create version: %w", err)
+	version, err := s.createConfigVersion(config, actorID, changeNote, restoredFrom)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create version: %w", err)
 	}
 
-	return config, nil
+	config.Warnings = s.secretWarnings(config.Content, config.Format)
+	return config, version, nil
 }
 
 // DeleteUserConfig deletes a user configuration
 func (s *ConfigService) DeleteUserConfig(id, userID int) error {
-	config, err := s.GetUserConfig(id, userID)
+	config, err := s.getOwnedConfig(id, userID)
 	if err != nil {
 		return err
 	}
@@ -201,14 +487,43 @@ func (s *ConfigService) DeleteUserConfig(id, userID int) error {
 
 // Version Management
 
-// GetConfigVersions retrieves version history for a configuration
-func (s *ConfigService) GetConfigVersions(configID, userID, page, limit int) ([]*models.ConfigVersion, int64, error) {
-	// Verify user owns the configuration
+// GetConfigVersions retrieves version history for a configuration in the given order
+func (s *ConfigService) GetConfigVersions(
+	configID, userID int, order models.ListSort, page, limit int,
+) ([]*models.ConfigVersion, int64, error) {
+	// Verify user can read the configuration
 	if _, err := s.GetUserConfig(configID, userID); err != nil {
 		return nil, 0, err
 	}
 
-	return s.configRepo.GetConfigVersions(configID, page, limit)
+	versions, total, err := s.configRepo.GetConfigVersions(configID, order, page, limit)
+	return emptyIfNil(versions), total, err
+}
+
+// GetConfigVersionsByCursor retrieves a page of version history, newest first
+// Returns the cursor for the following page, or "" on the last page
+func (s *ConfigService) GetConfigVersionsByCursor(
+	configID, userID int, cursor string, limit int,
+) ([]*models.ConfigVersion, string, error) {
+	// Verify user can read the configuration
+	if _, err := s.GetUserConfig(configID, userID); err != nil {
+		return nil, "", err
+	}
+
+	beforeID, err := utils.DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", fmt.Errorf("validation failed: %w", err)
+	}
+
+	versions, err := s.configRepo.GetConfigVersionsBefore(configID, beforeID, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(versions) <= limit {
+		return emptyIfNil(versions), "", nil
+	}
+	versions = versions[:limit]
+	return versions, utils.EncodeCursor(versions[limit-1].ID), nil
 }
 
 // GetConfigVersion retrieves a specific version
@@ -218,7 +533,7 @@ func (s *ConfigService) GetConfigVersion(versionID, userID int) (*models.ConfigV
 		return nil, err
 	}
 
-	// Verify user owns the configuration
+	// Verify user can read the configuration
 	if _, err := s.GetUserConfig(version.ConfigID, userID); err != nil {
 		return nil, err
 	}
@@ -227,9 +542,14 @@ func (s *ConfigService) GetConfigVersion(versionID, userID int) (*models.ConfigV
 }
 
 // RestoreConfigVersion restores a configuration to a previous version
-func (s *ConfigService) RestoreConfigVersion(configID, versionID, userID int) (*models.UserConfig, error) {
-	// Verify user owns the configuration
-	if _, err := s.GetUserConfig(configID, userID); err != nil {
+// The restore is saved as a new version; the result names both it and the source version
+func (s *ConfigService) RestoreConfigVersion(configID, versionID, userID int) (*models.RestoreResult, error) {
+	// Restoring changes the content, so it needs write access
+	config, err := s.getWritableConfig(configID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkEditLock(configID, userID); err != nil {
 		return nil, err
 	}
 
@@ -243,110 +563,844 @@ func (s *ConfigService) RestoreConfigVersion(configID, versionID, userID int) (*
 	}
 
 	// Update configuration with version content
-	return s.UpdateUserConfig(configID, userID, version.Content, fmt.Sprintf("Restored to version %d", version.Version), nil)
+	changeNote := fmt.Sprintf("Restored to version %d", version.Version)
+	restored, created, err := s.saveUserConfig(config, userID, version.Content, changeNote, nil, &version.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordActivity(models.AuditConfigRestored, userID, restored,
+		fmt.Sprintf("Restored %q to version %d", restored.Name, version.Version))
+	return &models.RestoreResult{
+		Config:              restored,
+		Version:             created.Version,
+		VersionID:           created.ID,
+		RestoredFrom:        version.ID,
+		RestoredFromVersion: version.Version,
+	}, nil
+}
+
+// GetVersionGraph returns a configuration's version lineage, oldest first
+func (s *ConfigService) GetVersionGraph(configID, userID int) ([]*models.VersionNode, error) {
+	// Verify user can read the configuration
+	if _, err := s.GetUserConfig(configID, userID); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.allConfigVersions(configID)
+	if err != nil {
+		return nil, err
+	}
+
+	versionNumbers := make(map[int]int, len(versions))
+	for _, version := range versions {
+		versionNumbers[version.ID] = version.Version
+	}
+
+	nodes := make([]*models.VersionNode, 0, len(versions))
+	for i := len(versions) - 1; i >= 0; i-- {
+		version := versions[i]
+		node := &models.VersionNode{
+			ID:         version.ID,
+			Version:    version.Version,
+			ChangeNote: version.ChangeNote,
+			CreatedBy:  version.CreatedBy,
+			CreatedAt:  version.CreatedAt,
+		}
+		if len(nodes) > 0 {
+			parent := nodes[len(nodes)-1].Version
+			node.Parent = &parent
+		}
+		if version.RestoredFrom != nil {
+			if restored, ok := versionNumbers[*version.RestoredFrom]; ok {
+				node.RestoredFrom = &restored
+			}
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+// versionPageSize is how many versions are fetched per query when walking full history
+const versionPageSize = 100
+
+// allConfigVersions returns every version of a configuration, newest first
+// Walks the history by keyset so versions saved mid-walk don't shift pages
+func (s *ConfigService) allConfigVersions(configID int) ([]*models.ConfigVersion, error) {
+	var versions []*models.ConfigVersion
+	beforeID := 0
+	for {
+		batch, err := s.configRepo.GetConfigVersionsBefore(configID, beforeID, versionPageSize)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, batch...)
+		if len(batch) < versionPageSize {
+			return versions, nil
+		}
+		beforeID = batch[len(batch)-1].ID
+	}
 }
 
 // Format Detection and Conversion
 
+// formatAmbiguityMargin is how close the top two detection candidates must be
+// for the content to count as ambiguous
+const formatAmbiguityMargin = 0.15
+
+// AmbiguousFormatError is returned when content is plausibly valid in several formats
+type AmbiguousFormatError struct {
+	Candidates []config.FormatCandidate
+}
+
+func (e *AmbiguousFormatError) Error() string {
+	formats := make([]string, len(e.Candidates))
+	for i, candidate := range e.Candidates {
+		formats[i] = string(candidate.Format)
+	}
+	return "ambiguous format: content could be " + strings.Join(formats, ", ")
+}
+
 // DetectFormat automatically detects the format of configuration content
-func (s *ConfigService) DetectFormat(content string) (models.ConfigFormat, error) {
-	return s.parser.DetectFormat(content)
+// Returns an *AmbiguousFormatError listing the close candidates unless bestGuess
+// is set, in which case the highest-scoring format is always returned
+func (s *ConfigService) DetectFormat(content string, bestGuess bool) (models.ConfigFormat, error) {
+	if err := config.CheckText(content); err != nil {
+		return "", err
+	}
+
+	candidates, err := s.parser.DetectFormatCandidates(content)
+	if err != nil {
+		return "", err
+	}
+
+	if !bestGuess {
+		var ambiguous []config.FormatCandidate
+		for _, candidate := range candidates {
+			if candidates[0].Confidence-candidate.Confidence < formatAmbiguityMargin {
+				ambiguous = append(ambiguous, candidate)
+			}
+		}
+		if len(ambiguous) > 1 {
+			return "", &AmbiguousFormatError{Candidates: ambiguous}
+		}
+	}
+
+	return candidates[0].Format, nil
 }
 
 // ConvertFormat converts configuration from one format to another
-func (s *ConfigService) ConvertFormat(content string, fromFormat, toFormat models.ConfigFormat) (string, error) {
-	return s.parser.ConvertFormat(content, fromFormat, toFormat)
+func (s *ConfigService) ConvertFormat(
+	content string, fromFormat, toFormat models.ConfigFormat, opts ...config.SerializeOption,
+) (string, error) {
+	return s.parser.ConvertFormat(content, fromFormat, toFormat, opts...)
+}
+
+// ConvertFormatWithWarnings converts configuration and reports values the target can't represent
+func (s *ConfigService) ConvertFormatWithWarnings(
+	content string, fromFormat, toFormat models.ConfigFormat, opts ...config.SerializeOption,
+) (string, []config.ConversionWarning, error) {
+	return s.parser.ConvertFormatWithWarnings(content, fromFormat, toFormat, opts...)
+}
+
+// MergeConfigs deep-merges override onto base, both in format
+func (s *ConfigService) MergeConfigs(
+	base, override string, format models.ConfigFormat, opts ...config.MergeOption,
+) (string, error) {
+	return s.parser.Merge(base, override, format, opts...)
+}
+
+// ConvertFile converts an uploaded file's content to another format
+// Without fromFormat, the source format comes from the filename's extension and
+// then from the content itself; an *AmbiguousFormatError asks the caller to choose.
+// Returns the converted content and the source format that was used
+func (s *ConfigService) ConvertFile(
+	content, filename string, fromFormat, toFormat models.ConfigFormat,
+) (string, models.ConfigFormat, error) {
+	if err := config.CheckText(content); err != nil {
+		return "", "", fmt.Errorf("validation failed: %w", err)
+	}
+	if _, ok := config.LookupCodec(toFormat); !ok {
+		return "", "", fmt.Errorf("validation failed: unsupported target format %q", toFormat)
+	}
+
+	if fromFormat == "" {
+		detected, ok := config.FormatForExtension(path.Ext(filename))
+		if !ok {
+			var err error
+			if detected, err = s.DetectFormat(content, false); err != nil {
+				return "", "", err
+			}
+		}
+		fromFormat = detected
+	} else if _, ok := config.LookupCodec(fromFormat); !ok {
+		return "", "", fmt.Errorf("validation failed: unsupported source format %q", fromFormat)
+	}
+
+	converted, err := s.parser.ConvertFormat(content, fromFormat, toFormat)
+	if err != nil {
+		return "", "", fmt.Errorf("validation failed: %w", err)
+	}
+	return converted, fromFormat, nil
+}
+
+const (
+	// maxConvertBatchSize caps the number of items in one batch conversion
+	maxConvertBatchSize = 100
+
+	// convertBatchConcurrency bounds how many batch items convert at once
+	convertBatchConcurrency = 8
+)
+
+// ConvertBatch converts many snippets concurrently
+// A failing item is reported in its result without failing the rest of the batch
+func (s *ConfigService) ConvertBatch(items []models.ConvertBatchItem) (*models.ConvertBatchResponse, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("validation failed: batch is empty")
+	}
+	if len(items) > maxConvertBatchSize {
+		return nil, fmt.Errorf("validation failed: batch exceeds %d items", maxConvertBatchSize)
+	}
+
+	results := make([]models.ConvertBatchResult, len(items))
+	slots := make(chan struct{}, convertBatchConcurrency)
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, item models.ConvertBatchItem) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			results[i].Index = i
+			output, err := s.ConvertFormat(item.Content, item.From, item.To)
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Output = output
+		}(i, item)
+	}
+	wg.Wait()
+
+	response := &models.ConvertBatchResponse{Results: results}
+	for _, result := range results {
+		if result.Error != "" {
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+	}
+	return response, nil
+}
+
+// TemplateValidationError reports why content isn't ready to use with a template
+// Schema violations, unfilled required variables, and, in strict mode, unexpected
+// keys are collected together
+type TemplateValidationError struct {
+	SchemaErrors      []string
+	UnfilledVariables []*models.ConfigVariable
+	UnknownKeys       []string
+}
+
+func (e *TemplateValidationError) Error() string {
+	problems := append([]string(nil), e.SchemaErrors...)
+	if len(e.UnfilledVariables) > 0 {
+		names := make([]string, len(e.UnfilledVariables))
+		for i, variable := range e.UnfilledVariables {
+			names[i] = variable.Name
+		}
+		problems = append(problems, "required variables not set: "+strings.Join(names, ", "))
+	}
+	if len(e.UnknownKeys) > 0 {
+		problems = append(problems, "unexpected keys: "+strings.Join(e.UnknownKeys, ", "))
+	}
+	return "validation failed: " + strings.Join(problems, "; ")
+}
+
+// ValidateOptions adds unknown key checks to ValidateConfig
+type ValidateOptions struct {
+	AllowedKeys []string // Permitted top-level keys, for content without a schema; nil allows any
+	Strict      bool     // Unexpected keys fail validation instead of producing warnings
+
+	IncludeParsed bool // Return the canonical parsed data alongside the result
+}
+
+// ValidationResult is what ValidateConfig found besides outright failures
+type ValidationResult struct {
+	Warnings []string
+	Parsed   map[string]interface{} // Canonical parsed content, when requested
 }
 
 // ValidateConfig validates configuration content
-func (s *ConfigService) ValidateConfig(content string, format models.ConfigFormat, templateID *int) error {
+// With a template, content must also satisfy the template schema and set every
+// required variable; failures are returned as a *TemplateValidationError.
+// Keys the schema closes off with "additionalProperties": false or that are
+// missing from opts.AllowedKeys are warnings, or errors when opts.Strict is set.
+// Once the content parses, the result is returned even when validation fails,
+// so callers can show how the content was interpreted
+func (s *ConfigService) ValidateConfig(
+	content string, format models.ConfigFormat, templateID *int, opts ValidateOptions,
+) (*ValidationResult, error) {
 	// Basic format validation
-	if err := s.validateConfigContent(content, format); err != nil {
-		return err
+	data, err := s.parser.ParseConfig(content, format)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ValidationResult{Warnings: []string{}}
+	if opts.IncludeParsed {
+		if result.Parsed, err = config.Canonicalize(data); err != nil {
+			return nil, err
+		}
 	}
 
+	var parserOpts []config.ValidateOption
+	if opts.AllowedKeys != nil {
+		parserOpts = append(parserOpts, config.WithAllowedKeys(opts.AllowedKeys...))
+	}
+
+	validationErr := &TemplateValidationError{}
+	var unknown []string
+	var variables []*models.ConfigVariable
+
 	// Template-specific validation if provided
+	var schema *string
 	if templateID != nil {
-		template, err := s.configRepo.GetTemplate(*templateID)
+		template, err := s.GetTemplate(*templateID)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		schema = template.Schema
+
+		if variables, err = s.configRepo.GetTemplateVariables(template.ID); err != nil {
+			return nil, fmt.Errorf("failed to load template variables: %w", err)
+		}
+	}
 
-		// Use template schema if available
-		return s.parser.ValidateConfig(content, format, template.Schema)
+	// Schema and allowlist checks; unknown keys are sorted out below
+	var unknownErr *config.UnknownKeysError
+	if err := s.parser.ValidateConfig(content, format, schema, parserOpts...); errors.As(err, &unknownErr) {
+		unknown = unknownErr.Keys
+	} else if err != nil {
+		validationErr.SchemaErrors = append(validationErr.SchemaErrors, err.Error())
 	}
+	validationErr.UnfilledVariables = unfilledVariables(data, variables)
 
+	if opts.Strict {
+		validationErr.UnknownKeys = unknown
+	} else if len(unknown) > 0 {
+		result.Warnings = append(result.Warnings, "unexpected keys: "+strings.Join(unknown, ", "))
+	}
+
+	if len(validationErr.SchemaErrors) > 0 || len(validationErr.UnfilledVariables) > 0 || len(validationErr.UnknownKeys) > 0 {
+		return result, validationErr
+	}
+	return result, nil
+}
+
+// ValidateUserConfig re-validates a stored configuration against its template's current
+// schema and variables, for checking configurations after the template changes
+// Configurations without a template are checked for format and unknown keys only
+func (s *ConfigService) ValidateUserConfig(configID, userID int, opts ValidateOptions) (*ValidationResult, error) {
+	userConfig, err := s.GetUserConfig(configID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.ValidateConfig(userConfig.Content, userConfig.Format, userConfig.TemplateID, opts)
+	if result != nil && userConfig.TemplateID != nil {
+		if template, tmplErr := s.GetTemplate(*userConfig.TemplateID); tmplErr == nil && template.Version != userConfig.TemplateVersion {
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"configuration is based on template version %s; the template is now at %s",
+				userConfig.TemplateVersion, template.Version))
+		}
+	}
+	return result, err
+}
+
+// unfilledVariables returns the required variables whose path is missing,
+// empty, or still set to the variable's default value
+func unfilledVariables(data map[string]interface{}, variables []*models.ConfigVariable) []*models.ConfigVariable {
+	var unfilled []*models.ConfigVariable
+	for _, variable := range variables {
+		if !variable.Required {
+			continue
+		}
+
+		value, ok := config.LookupPath(data, variable.Path)
+		if !ok || isEmptyValue(value) ||
+			(variable.DefaultValue != nil && fmt.Sprint(value) == *variable.DefaultValue) {
+			unfilled = append(unfilled, variable)
+		}
+	}
+	return unfilled
+}
+
+// isEmptyValue reports whether a parsed value carries no setting
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// PreviewTemplate renders a template's default content with sample variable values
+// Variables without a supplied value fall back to their default; required variables
+// left without a value of their own are reported rather than failing the preview
+func (s *ConfigService) PreviewTemplate(templateID int, req *models.TemplatePreviewRequest) (*models.TemplatePreview, error) {
+	template, err := s.GetTemplate(templateID)
+	if err != nil {
+		return nil, fmt.Errorf("template not found: %w", err)
+	}
+	variables, err := s.configRepo.GetTemplateVariables(template.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template variables: %w", err)
+	}
+
+	data, err := s.parser.ParseConfig(template.DefaultContent, template.Format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template content: %w", err)
+	}
+
+	preview := &models.TemplatePreview{
+		TemplateID: template.ID,
+		Format:     template.Format,
+	}
+	if _, preview.UnfilledVariables, err = applyVariables(data, variables, req.Values); err != nil {
+		return nil, err
+	}
+
+	if preview.Content, err = s.parser.SerializeConfig(data, template.Format); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return preview, nil
+}
+
+// applyVariables sets each variable's supplied value, or else its default, at its path in data
+// Names that aren't variables fail validation, as do values of the wrong type or that
+// don't match the variable's validation rule. Reports whether anything was set, and
+// returns the required variables that still have no value. A required variable with a
+// default needs a value of its own: its default is set, but like unfilledVariables
+// it counts as unfilled while its value is the default
+func applyVariables(
+	data map[string]interface{}, variables []*models.ConfigVariable, values map[string]interface{},
+) (bool, []*models.ConfigVariable, error) {
+	known := make(map[string]bool, len(variables))
+	for _, variable := range variables {
+		known[variable.Name] = true
+	}
+	var unknown []string
+	for name := range values {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return false, nil, fmt.Errorf("validation failed: unknown variables: %s", strings.Join(unknown, ", "))
+	}
+
+	applied := false
+	unfilled := []*models.ConfigVariable{}
+	for _, variable := range variables {
+		raw, provided := values[variable.Name]
+		if variable.Required && variable.DefaultValue != nil &&
+			(!provided || fmt.Sprint(raw) == *variable.DefaultValue) {
+			unfilled = append(unfilled, variable)
+		}
+		if !provided && variable.DefaultValue != nil {
+			raw, provided = *variable.DefaultValue, true
+		}
+		if !provided {
+			if value, ok := config.LookupPath(data, variable.Path); variable.Required && (!ok || isEmptyValue(value)) {
+				unfilled = append(unfilled, variable)
+			}
+			continue
+		}
+
+		if err := checkValidationRule(variable, raw); err != nil {
+			return false, nil, fmt.Errorf("validation failed: variable %s: %w", variable.Name, err)
+		}
+		value, err := variableValue(variable, raw)
+		if err != nil {
+			return false, nil, fmt.Errorf("validation failed: variable %s: %w", variable.Name, err)
+		}
+		if !config.SetPath(data, variable.Path, value) {
+			return false, nil, fmt.Errorf("validation failed: variable %s: path %q can't be set in the template", variable.Name, variable.Path)
+		}
+		applied = true
+	}
+	return applied, unfilled, nil
+}
+
+// checkValidationRule matches a value against the variable's validation rule, a regular
+// expression that, like a JSON Schema pattern, may match anywhere unless anchored
+// Non-string values are matched in their default text form
+func checkValidationRule(variable *models.ConfigVariable, raw interface{}) error {
+	if variable.ValidationRule == nil || *variable.ValidationRule == "" {
+		return nil
+	}
+	rule, err := regexp.Compile(*variable.ValidationRule)
+	if err != nil {
+		return fmt.Errorf("invalid validation rule %q: %w", *variable.ValidationRule, err)
+	}
+
+	text, ok := raw.(string)
+	if !ok {
+		text = fmt.Sprint(raw)
+	}
+	if !rule.MatchString(text) {
+		return fmt.Errorf("%q does not match %s", text, *variable.ValidationRule)
+	}
 	return nil
 }
 
+// renderVariables applies variable values to content created from a template
+// Content is re-serialized only when a value or default was set
+func (s *ConfigService) renderVariables(
+	template *models.ConfigTemplate, content string, values map[string]string,
+) (string, error) {
+	variables, err := s.configRepo.GetTemplateVariables(template.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load template variables: %w", err)
+	}
+	if len(variables) == 0 && len(values) == 0 {
+		return content, nil
+	}
+
+	data, err := s.parser.ParseConfig(content, template.Format)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template content: %w", err)
+	}
+	supplied := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		supplied[name] = value
+	}
+
+	applied, unfilled, err := applyVariables(data, variables, supplied)
+	if err != nil {
+		return "", err
+	}
+	if len(unfilled) > 0 {
+		names := make([]string, len(unfilled))
+		for i, variable := range unfilled {
+			names[i] = variable.Name
+		}
+		return "", fmt.Errorf("validation failed: missing required variables: %s", strings.Join(names, ", "))
+	}
+	if !applied {
+		return content, nil
+	}
+
+	rendered, err := s.parser.SerializeConfig(data, template.Format)
+	if err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return rendered, nil
+}
+
+// variableValue converts a supplied value to the variable's declared type
+// Non-string values are taken as already typed
+func variableValue(variable *models.ConfigVariable, raw interface{}) (interface{}, error) {
+	text, ok := raw.(string)
+	if !ok {
+		return raw, nil
+	}
+
+	switch variable.Type {
+	case "number":
+		if n, err := strconv.Atoi(text); err == nil {
+			return n, nil
+		}
+		n, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", text)
+		}
+		return n, nil
+	case "boolean":
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", text)
+		}
+		return b, nil
+	case "array":
+		var items []interface{}
+		if json.Unmarshal([]byte(text), &items) == nil {
+			return items, nil
+		}
+		for _, item := range strings.Split(text, ",") {
+			items = append(items, strings.TrimSpace(item))
+		}
+		return items, nil
+	default:
+		return text, nil
+	}
+}
+
 // ImportConfig imports configuration from external source
+// format may be empty to detect it; ambiguous content then fails the import so
+// the user can retry with an explicit format. With dedupe, content matching an
+// existing config reuses it instead of creating a duplicate
 func (s *ConfigService) ImportConfig(
-	userID int, sourceType models.ConfigSourceType, sourceURL string,
+	userID int, sourceType models.ConfigSourceType, sourceURL string, format models.ConfigFormat, dedupe bool,
 ) (*models.ConfigImport, error) {
+	switch sourceType {
+	case models.SourceURL, models.SourceGitHub:
+	default:
+		return nil, fmt.Errorf("validation failed: unsupported import source: %q", sourceType)
+	}
+	if strings.TrimSpace(sourceURL) == "" {
+		return nil, fmt.Errorf("validation failed: source_url is required")
+	}
+	if format != "" {
+		if _, ok := config.LookupCodec(format); !ok {
+			return nil, fmt.Errorf("validation failed: unsupported format: %s", format)
+		}
+	}
+	switch sourceType {
+	case models.SourceURL:
+		if err := validateImportURL(sourceURL); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+	case models.SourceGitHub:
+		if _, err := ParseGitHubSource(sourceURL); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+	}
+
 	// Create import record
 	importRecord := &models.ConfigImport{
 		UserID:     userID,
 		SourceType: sourceType,
 		SourceURL:  sourceURL,
 		Status:     models.ImportPending,
-		CreatedAt:  time.Now(),
+		Dedupe:     dedupe,
 	}
 
 	if err := s.configRepo.CreateImport(importRecord); err != nil {
 		return nil, err
 	}
 
-	// TODO: Process import asynchronously
-	// This would handle URL fetching, Git clone, file upload, etc.
+	// Process the import in the background; the worker owns a copy of the record
+	job := *importRecord
+	s.importWorker.Submit(job.ID, func(ctx context.Context) {
+		s.processImport(ctx, job, format)
+	})
 
 	return importRecord, nil
 }
 
+// GetImport returns an import's status, including any scheduled retry
+// Admins may view any user's import
+func (s *ConfigService) GetImport(importID, userID int, isAdmin bool) (*models.ConfigImport, error) {
+	importRecord, err := s.configRepo.GetImport(importID)
+	if err != nil {
+		return nil, err
+	}
+
+	if importRecord.UserID != userID && !isAdmin {
+		return nil, fmt.Errorf("unauthorized access to import")
+	}
+
+	return importRecord, nil
+}
+
+// CancelImport aborts an in-flight import owned by the user
+// Admins may cancel any user's import
+func (s *ConfigService) CancelImport(importID, userID int, isAdmin bool) (*models.ConfigImport, error) {
+	importRecord, err := s.configRepo.GetImport(importID)
+	if err != nil {
+		return nil, err
+	}
+
+	if importRecord.UserID != userID && !isAdmin {
+		return nil, fmt.Errorf("unauthorized access to import")
+	}
+
+	if importRecord.Status != models.ImportPending && importRecord.Status != models.ImportProcessing {
+		return nil, fmt.Errorf("import is not in progress")
+	}
+
+	if !s.importWorker.Cancel(importID) {
+		// No worker owns the import (e.g. the server restarted mid-import)
+		s.failImport(importRecord, importCancelledMessage)
+		return importRecord, nil
+	}
+
+	// The worker recorded the final status before Cancel returned
+	return s.configRepo.GetImport(importID)
+}
+
 // ExportConfig exports configuration in specified format
-func (s *ConfigService) ExportConfig(configID, userID int, format models.ConfigFormat) (string, error) {
-	config, err := s.GetUserConfig(configID, userID)
+// With resolveSecrets, ${secret:NAME} placeholders are substituted from the secret
+// source and any missing secret fails the export with *config.UnresolvedSecretsError;
+// otherwise placeholders are left as-is
+func (s *ConfigService) ExportConfig(
+	configID, userID int, format models.ConfigFormat, resolveSecrets bool,
+) (string, error) {
+	userConfig, err := s.GetUserConfig(configID, userID)
 	if err != nil {
 		return "", err
 	}
 
-	if config.Format == format {
-		return config.Content, nil
+	if !resolveSecrets || !config.HasSecretRefs(userConfig.Content) {
+		if userConfig.Format == format {
+			return userConfig.Content, nil
+		}
+		return s.parser.ConvertFormat(userConfig.Content, userConfig.Format, format)
 	}
 
-	return s.parser.ConvertFormat(config.Content, config.Format, format)
+	// Substitute in parsed values so secrets are escaped correctly for the target format
+	data, err := s.parser.ParseConfig(userConfig.Content, userConfig.Format)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse configuration: %w", err)
+	}
+	resolved, err := config.ResolveSecrets(data, s.secrets)
+	if err != nil {
+		return "", err
+	}
+	return s.parser.SerializeConfig(resolved, format)
 }
 
 // Private helper methods
 
-func (s *ConfigService) validateTemplateContent(template *models.ConfigTemplate) error {
-	return s.validateConfigContent(template.DefaultContent, template.Format)
+// recordActivity adds an audit entry for a change actorID made to a configuration
+// Best effort: the change is already saved, so a failed audit write doesn't fail it
+func (s *ConfigService) recordActivity(action string, actorID int, userConfig *models.UserConfig, summary string) {
+	if s.audit == nil {
+		return
+	}
+	configID := userConfig.ID
+	_ = s.audit.Record(context.Background(), &models.AuditEntry{
+		ActorID:        actorID,
+		Action:         action,
+		TargetConfigID: &configID,
+		TargetName:     userConfig.Name,
+		Details:        summary,
+	})
+}
+
+// validateTemplateContent checks the default content is valid in the template's declared format
+// Returns a warning when the content looks more like another format
+func (s *ConfigService) validateTemplateContent(template *models.ConfigTemplate) ([]string, error) {
+	warning, err := s.parser.VerifyFormat(template.DefaultContent, template.Format)
+	if err != nil || warning == "" {
+		return nil, err
+	}
+	return []string{warning}, nil
+}
+
+// secretWarnings returns advisories for secret-like values in content
+// Returns nil when scanning is disabled or the content doesn't parse
+func (s *ConfigService) secretWarnings(content string, format models.ConfigFormat) []string {
+	if !s.scanSecrets {
+		return nil
+	}
+
+	data, err := s.parser.ParseConfig(content, format)
+	if err != nil {
+		return nil
+	}
+
+	var warnings []string
+	for _, finding := range config.FindSecrets(data) {
+		warnings = append(warnings, finding.Message())
+	}
+	return warnings
 }
 
+// validateConfigContent checks content is text that parses in format
 func (s *ConfigService) validateConfigContent(content string, format models.ConfigFormat) error {
+	if err := config.CheckText(content); err != nil {
+		return err
+	}
 	_, err := s.parser.ParseConfig(content, format)
 	return err
 }
 
-func (s *ConfigService) createConfigVersion(config *models.UserConfig, changeNote string) error {
-	// Get the next version number
-	versions, _, err := s.configRepo.GetConfigVersions(config.ID, 1, 1)
+// changeSet summarizes the key-level differences between two contents
+// Returns nil when either side doesn't parse as format, e.g. after a format change
+func (s *ConfigService) changeSet(previous, current string, format models.ConfigFormat) *models.ChangeSet {
+	oldData, err := s.parser.ParseConfig(previous, format)
 	if err != nil {
-		return err
+		return nil
+	}
+	newData, err := s.parser.ParseConfig(current, format)
+	if err != nil {
+		return nil
+	}
+
+	changeSet := &models.ChangeSet{Keys: []string{}}
+	touched := make(map[string]bool)
+	for _, change := range config.Diff(oldData, newData) {
+		switch change.Type {
+		case config.ChangeAdded:
+			changeSet.Added++
+		case config.ChangeRemoved:
+			changeSet.Removed++
+		case config.ChangeModified:
+			changeSet.Modified++
+		}
+		if key := change.TopLevelKey(); !touched[key] {
+			touched[key] = true
+			changeSet.Keys = append(changeSet.Keys, key)
+		}
+	}
+	sort.Strings(changeSet.Keys)
+	return changeSet
+}
+
+// createConfigVersion records the configuration's current content as its next version,
+// created by the given user
+func (s *ConfigService) createConfigVersion(
+	config *models.UserConfig, createdBy int, changeNote string, restoredFrom *int,
+) (*models.ConfigVersion, error) {
+	// Get the next version number from the newest version
+	versions, _, err := s.configRepo.GetConfigVersions(config.ID, models.DefaultConfigVersionSort, 1, 1)
+	if err != nil {
+		return nil, err
 	}
 
 	versionNumber := 1
+	var changeSet *models.ChangeSet
 	if len(versions) > 0 {
 		versionNumber = versions[0].Version + 1
+		// Only diff when the content actually changed
+		if versions[0].Content != config.Content {
+			changeSet = s.changeSet(versions[0].Content, config.Content, config.Format)
+		}
 	}
 
 	version := &models.ConfigVersion{
-		ConfigID:   config.ID,
-		Version:    versionNumber,
-		Content:    config.Content,
-		ChangeNote: changeNote,
-		CreatedBy:  config.UserID,
-		CreatedAt:  time.Now(),
+		ConfigID:     config.ID,
+		Version:      versionNumber,
+		Content:      config.Content,
+		ChangeNote:   changeNote,
+		RestoredFrom: restoredFrom,
+		ChangeSet:    changeSet,
+		CreatedBy:    createdBy,
 	}
 
-	return s.configRepo.CreateVersion(version)
+	if err := s.configRepo.CreateVersion(version); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// emptyIfNil returns an empty slice in place of nil, so lists serialize as [] rather than null
+func emptyIfNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
 }
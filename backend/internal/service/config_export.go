@@ -0,0 +1,95 @@
+// Bulk configuration export
+// Packages every configuration a user owns into one zip for backup or migration
+package service
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strings"
+
+	"conflux/internal/models"
+	"conflux/pkg/config"
+)
+
+// exportPageSize is how many configurations are loaded per query when exporting
+const exportPageSize = 100
+
+// ExportAll streams a zip holding each of the user's configurations as {name}.{ext} to w
+// A nil format keeps each configuration's own format; otherwise all are converted to it.
+// Configurations are written a page at a time, so an error, such as a configuration that
+// can't be converted, may come after part of the archive has reached w; the archive is
+// then left without its central directory and won't open. Nothing is written before
+// the format is validated. A user without configurations gets an empty zip
+func (s *ConfigService) ExportAll(userID int, format *models.ConfigFormat, w io.Writer) error {
+	if format != nil {
+		if _, ok := config.LookupCodec(*format); !ok {
+			return fmt.Errorf("validation failed: unsupported format: %s", *format)
+		}
+	}
+
+	zw := zip.NewWriter(w)
+	used := make(map[string]bool)
+
+	for afterID := 0; ; {
+		configs, err := s.configRepo.GetUserConfigsAfter(userID, nil, afterID, exportPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, userConfig := range configs {
+			content, target := userConfig.Content, userConfig.Format
+			if format != nil && *format != userConfig.Format {
+				target = *format
+				if content, err = s.parser.ConvertFormat(userConfig.Content, userConfig.Format, target); err != nil {
+					return fmt.Errorf("validation failed: configuration %q can't be converted to %s: %w",
+						userConfig.Name, target, err)
+				}
+			}
+
+			entry, err := zw.CreateHeader(&zip.FileHeader{
+				Name:     exportEntryName(userConfig.Name, target, used),
+				Method:   zip.Deflate,
+				Modified: userConfig.UpdatedAt,
+			})
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(entry, content); err != nil {
+				return err
+			}
+		}
+
+		if len(configs) < exportPageSize {
+			break
+		}
+		afterID = configs[len(configs)-1].ID
+	}
+
+	return zw.Close()
+}
+
+// exportEntryName returns a unique, flat file name for a configuration in the archive
+// Path separators are replaced, an extension already matching the format isn't doubled,
+// and repeated names get a numeric suffix
+func exportEntryName(name string, format models.ConfigFormat, used map[string]bool) string {
+	extension := string(format)
+	if codec, ok := config.LookupCodec(format); ok {
+		extension = codec.Extensions[0]
+	}
+
+	base := strings.NewReplacer("/", "_", "\\", "_").Replace(strings.TrimSpace(name))
+	if strings.HasSuffix(strings.ToLower(base), "."+extension) {
+		base = base[:len(base)-len(extension)-1]
+	}
+	if strings.Trim(base, ".") == "" {
+		base = "config"
+	}
+
+	candidate := base + "." + extension
+	for n := 2; used[strings.ToLower(candidate)]; n++ {
+		candidate = fmt.Sprintf("%s (%d).%s", base, n, extension)
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+// readExport unzips an export into file contents by name
+func readExport(t *testing.T, body []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("archive is not a zip: %v", err)
+	}
+
+	files := make(map[string]string, len(zr.File))
+	for _, file := range zr.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("opening %s: %v", file.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[file.Name] = string(content)
+	}
+	return files
+}
+
+func TestConfigService_ExportAll(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+	for _, userConfig := range []*models.UserConfig{
+		{UserID: 1, Name: "app.yaml", Format: models.FormatYAML, Content: "port: 8080\n"},
+		{UserID: 1, Name: "app", Format: models.FormatYAML, Content: "port: 9090\n"},
+		{UserID: 1, Name: "settings", Format: models.FormatJSON, Content: `{"debug": true}`},
+		{UserID: 2, Name: "someone else", Format: models.FormatYAML, Content: "port: 1\n"},
+	} {
+		if err := repo.CreateUserConfig(userConfig); err != nil {
+			t.Fatalf("CreateUserConfig() error = %v", err)
+		}
+	}
+
+	jsonFormat := models.FormatJSON
+	tests := []struct {
+		name      string
+		userID    int
+		format    *models.ConfigFormat
+		wantFiles []string
+	}{
+		{name: "original formats", userID: 1, wantFiles: []string{"app (2).yaml", "app.yaml", "settings.json"}},
+		{name: "converted", userID: 1, format: &jsonFormat, wantFiles: []string{"app.json", "app.yaml.json", "settings.json"}},
+		{name: "no configs", userID: 3, wantFiles: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var archive bytes.Buffer
+			if err := service.ExportAll(tt.userID, tt.format, &archive); err != nil {
+				t.Fatalf("ExportAll() error = %v", err)
+			}
+
+			files := readExport(t, archive.Bytes())
+			names := make([]string, 0, len(files))
+			for name := range files {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if strings.Join(names, ",") != strings.Join(tt.wantFiles, ",") {
+				t.Errorf("entries = %v, want %v", names, tt.wantFiles)
+			}
+			if tt.format != nil && !strings.Contains(files["app.yaml.json"], `"port": 8080`) {
+				t.Errorf("converted entry = %q, want JSON content", files["app.yaml.json"])
+			}
+		})
+	}
+}
+
+func TestConfigService_ExportAllInvalidFormat(t *testing.T) {
+	service := NewConfigService(NewMockConfigRepository())
+	format := models.ConfigFormat("xml")
+
+	var archive bytes.Buffer
+	if err := service.ExportAll(1, &format, &archive); err == nil || !strings.Contains(err.Error(), "validation failed") {
+		t.Errorf("ExportAll() error = %v, want validation failed", err)
+	}
+	if archive.Len() != 0 {
+		t.Errorf("ExportAll() wrote %d bytes for an invalid format, want none", archive.Len())
+	}
+}
@@ -0,0 +1,61 @@
+// Configuration forking
+// Lets a user take an independent, editable copy of a configuration they can read,
+// including configurations shared with them read-only
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"conflux/internal/models"
+)
+
+// ForkUserConfig copies a readable configuration into a new one owned by userID
+// Only read access is needed; the fork records its source in ForkedFrom and
+// starts its own version history. An empty name derives one from the source
+func (s *ConfigService) ForkUserConfig(configID, userID int, name string) (*models.UserConfig, error) {
+	source, err := s.configRepo.GetUserConfig(configID)
+	if err != nil {
+		return nil, fmt.Errorf("configuration not found: %w", err)
+	}
+	access, err := s.configAccess(source, userID)
+	if err != nil {
+		return nil, err
+	}
+	if access == "" {
+		return nil, fmt.Errorf("unauthorized access to configuration")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name, err = s.availableConfigName(userID, source.Name+" (fork)")
+	} else {
+		err = s.checkConfigName(userID, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fork := &models.UserConfig{
+		UserID:          userID,
+		TemplateID:      source.TemplateID,
+		TemplateVersion: source.TemplateVersion,
+		Name:            name,
+		Description:     source.Description,
+		Format:          source.Format,
+		Content:         source.Content,
+		ForkedFrom:      &source.ID,
+	}
+	if err := s.configRepo.CreateUserConfig(fork); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.createConfigVersion(fork, userID, fmt.Sprintf("Forked from configuration %d", source.ID), nil); err != nil {
+		return nil, fmt.Errorf("failed to create initial version: %w", err)
+	}
+
+	s.recordActivity(models.AuditConfigCreated, userID, fork, fmt.Sprintf("Forked %q from %q", fork.Name, source.Name))
+
+	fork.Warnings = s.secretWarnings(fork.Content, fork.Format)
+	return fork, nil
+}
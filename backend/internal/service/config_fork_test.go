@@ -0,0 +1,68 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestConfigService_ForkUserConfig(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 8080\n"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	source, err := service.CreateUserConfig(1, template.ID, "owner config", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+
+	// Private configurations can't be forked by other users
+	if _, err := service.ForkUserConfig(source.ID, 2, ""); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Fatalf("ForkUserConfig() of a private config error = %v, want unauthorized", err)
+	}
+	if _, err := service.ForkUserConfig(source.ID+100, 2, ""); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("ForkUserConfig() of a missing config error = %v, want not found", err)
+	}
+
+	repo.configs[source.ID].IsShared = true
+	fork, err := service.ForkUserConfig(source.ID, 2, "")
+	if err != nil {
+		t.Fatalf("ForkUserConfig() error = %v", err)
+	}
+	if fork.ID == source.ID || fork.UserID != 2 || fork.IsShared {
+		t.Errorf("fork = %+v, want a new unshared config owned by user 2", fork)
+	}
+	if fork.ForkedFrom == nil || *fork.ForkedFrom != source.ID {
+		t.Errorf("ForkedFrom = %v, want %d", fork.ForkedFrom, source.ID)
+	}
+	if fork.Name != "owner config (fork)" || fork.Content != source.Content || *fork.TemplateID != template.ID {
+		t.Errorf("fork = %+v, want a copy of the source", fork)
+	}
+
+	// The fork has its own history and is editable by its new owner only
+	versions, _, err := service.GetConfigVersions(fork.ID, 2, models.DefaultConfigVersionSort, 1, 10)
+	if err != nil || len(versions) != 1 {
+		t.Fatalf("fork versions = %d (err %v), want 1", len(versions), err)
+	}
+	if _, err := service.UpdateUserConfig(fork.ID, 2, "port: 9090\n", "mine now", nil); err != nil {
+		t.Errorf("UpdateUserConfig() on fork error = %v", err)
+	}
+	if _, err := service.UpdateUserConfig(source.ID, 2, "port: 9090\n", "not mine", nil); err == nil {
+		t.Error("UpdateUserConfig() on the shared source succeeded for a reader")
+	}
+	if unchanged, _ := service.GetUserConfig(source.ID, 1); unchanged.Content != "port: 8080\n" {
+		t.Errorf("source content = %q, want it unchanged", unchanged.Content)
+	}
+
+	named, err := service.ForkUserConfig(source.ID, 1, "  staging  ")
+	if err != nil {
+		t.Fatalf("ForkUserConfig() by owner error = %v", err)
+	}
+	if named.Name != "staging" {
+		t.Errorf("fork name = %q, want %q", named.Name, "staging")
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+)
+
+func TestConfigService_EditLock(t *testing.T) {
+	repo := NewMockConfigRepository()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+	service := NewConfigService(repo, WithEditLockTTL(10*time.Minute))
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 8080\n"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	userConfig, err := service.CreateUserConfig(1, template.ID, "shared", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+
+	lock, err := service.LockUserConfig(userConfig.ID, 1)
+	if err != nil {
+		t.Fatalf("LockUserConfig() error = %v", err)
+	}
+	if lock.HolderID != 1 || !lock.ExpiresAt.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("lock = %+v, want held by user 1 for 10m", lock)
+	}
+
+	// The holder can still edit, and the detail response shows the lock
+	if _, err := service.UpdateUserConfig(userConfig.ID, 1, "port: 9000\n", "", nil); err != nil {
+		t.Fatalf("UpdateUserConfig() by the holder error = %v", err)
+	}
+	detail, err := service.GetUserConfigDetail(userConfig.ID, 1)
+	if err != nil {
+		t.Fatalf("GetUserConfigDetail() error = %v", err)
+	}
+	if detail.Lock == nil || detail.Lock.HolderID != 1 {
+		t.Errorf("detail lock = %+v, want held by user 1", detail.Lock)
+	}
+
+	// Renewing pushes the expiry out
+	now = now.Add(5 * time.Minute)
+	if lock, err = service.LockUserConfig(userConfig.ID, 1); err != nil {
+		t.Fatalf("LockUserConfig() renew error = %v", err)
+	}
+	if !lock.ExpiresAt.Equal(now.Add(10 * time.Minute)) {
+		t.Errorf("renewed expiry = %s, want %s", lock.ExpiresAt, now.Add(10*time.Minute))
+	}
+
+	if err := service.UnlockUserConfig(userConfig.ID, 1); err != nil {
+		t.Fatalf("UnlockUserConfig() error = %v", err)
+	}
+	if detail, _ = service.GetUserConfigDetail(userConfig.ID, 1); detail.Lock != nil {
+		t.Errorf("lock after unlock = %+v, want none", detail.Lock)
+	}
+	if err := service.UnlockUserConfig(userConfig.ID, 1); err != nil {
+		t.Errorf("UnlockUserConfig() when unlocked error = %v, want nil", err)
+	}
+}
+
+func TestConfigService_EditLockBlocksOtherEditors(t *testing.T) {
+	repo := NewMockConfigRepository()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 8080\n"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	userConfig, err := service.CreateUserConfig(1, template.ID, "shared", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+
+	// Another user, such as a teammate the config is shared with, holds the lock
+	if _, err := repo.AcquireConfigLock(userConfig.ID, 2, DefaultEditLockTTL); err != nil {
+		t.Fatalf("AcquireConfigLock() error = %v", err)
+	}
+
+	checks := map[string]func() error{
+		"update": func() error {
+			_, err := service.UpdateUserConfig(userConfig.ID, 1, "port: 9000\n", "", nil)
+			return err
+		},
+		"lock":   func() error { _, err := service.LockUserConfig(userConfig.ID, 1); return err },
+		"unlock": func() error { return service.UnlockUserConfig(userConfig.ID, 1) },
+	}
+	for name, check := range checks {
+		var locked *ConfigLockedError
+		if err := check(); !errors.As(err, &locked) || locked.Lock.HolderID != 2 {
+			t.Errorf("%s error = %v, want *ConfigLockedError held by user 2", name, err)
+		}
+	}
+
+	// Once the lock lapses the configuration is editable again
+	now = now.Add(DefaultEditLockTTL)
+	if _, err := service.UpdateUserConfig(userConfig.ID, 1, "port: 9000\n", "", nil); err != nil {
+		t.Errorf("UpdateUserConfig() after expiry error = %v", err)
+	}
+}
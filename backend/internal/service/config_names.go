@@ -0,0 +1,55 @@
+// Configuration name uniqueness
+// Optionally keeps each user's configuration names distinct, ignoring case
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxNameSuffix bounds the numbered names tried for generated configuration names
+const maxNameSuffix = 100
+
+// WithUniqueConfigNames requires each user's configurations to have distinct names
+// Names compare case-insensitively, and a deleted configuration's name is free again.
+// Off by default, so users may keep several configurations with the same name
+func WithUniqueConfigNames(enabled bool) ConfigServiceOption {
+	return func(s *ConfigService) {
+		s.uniqueNames = enabled
+	}
+}
+
+// checkConfigName rejects a name the user already has when names must be unique
+// The repository's unique index backs this up for concurrent creates
+func (s *ConfigService) checkConfigName(userID int, name string) error {
+	if !s.uniqueNames {
+		return nil
+	}
+	exists, err := s.configRepo.UserConfigNameExists(userID, name)
+	if err != nil {
+		return fmt.Errorf("failed to check configuration name: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("you already have a config named %q", name)
+	}
+	return nil
+}
+
+// availableConfigName returns name, or the first free "name (n)" when names must be unique
+// Used for names the service generates, so imports and forks don't fail on a clash
+func (s *ConfigService) availableConfigName(userID int, name string) (string, error) {
+	candidate := name
+	for n := 2; n <= maxNameSuffix; n++ {
+		err := s.checkConfigName(userID, candidate)
+		if err == nil || !isDuplicateNameError(err) {
+			return candidate, err
+		}
+		candidate = fmt.Sprintf("%s (%d)", name, n)
+	}
+	return "", s.checkConfigName(userID, candidate)
+}
+
+// isDuplicateNameError reports whether err is checkConfigName's clash error
+func isDuplicateNameError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "you already have a config named")
+}
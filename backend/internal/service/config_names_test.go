@@ -0,0 +1,66 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestConfigService_UniqueConfigNames(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo, WithUniqueConfigNames(true))
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 8080\n"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	first, err := service.CreateUserConfig(1, template.ID, "Production", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+
+	if _, err := service.CreateUserConfig(1, template.ID, "production", nil); err == nil ||
+		!strings.Contains(err.Error(), `you already have a config named "production"`) {
+		t.Errorf("duplicate CreateUserConfig() error = %v, want a name clash", err)
+	}
+	if _, err := service.CreateUserConfig(2, template.ID, "Production", nil); err != nil {
+		t.Errorf("CreateUserConfig() for another user error = %v", err)
+	}
+
+	// Generated fork names are numbered instead of clashing; explicit names still clash
+	for _, want := range []string{"Production (fork)", "Production (fork) (2)"} {
+		fork, err := service.ForkUserConfig(first.ID, 1, "")
+		if err != nil {
+			t.Fatalf("ForkUserConfig() error = %v", err)
+		}
+		if fork.Name != want {
+			t.Errorf("fork name = %q, want %q", fork.Name, want)
+		}
+	}
+	if _, err := service.ForkUserConfig(first.ID, 1, "PRODUCTION"); err == nil || !strings.Contains(err.Error(), "already have a config named") {
+		t.Errorf("ForkUserConfig() with a taken name error = %v, want a name clash", err)
+	}
+
+	// Deleting a configuration frees its name
+	if err := service.DeleteUserConfig(first.ID, 1); err != nil {
+		t.Fatalf("DeleteUserConfig() error = %v", err)
+	}
+	if _, err := service.CreateUserConfig(1, template.ID, "Production", nil); err != nil {
+		t.Errorf("CreateUserConfig() after delete error = %v", err)
+	}
+}
+
+func TestConfigService_DuplicateConfigNamesAllowedByDefault(t *testing.T) {
+	service := NewConfigService(NewMockConfigRepository())
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 8080\n"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := service.CreateUserConfig(1, template.ID, "same", nil); err != nil {
+			t.Fatalf("CreateUserConfig() #%d error = %v", i+1, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Configuration rebasing
+// Moves a configuration onto its template's current version with a three-way merge,
+// pulling in the template's changes while keeping the user's own edits
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"conflux/internal/models"
+	"conflux/pkg/config"
+)
+
+// RebaseUserConfig merges the template changes made since a configuration was based on it
+// The base is the template's default content at the configuration's template version,
+// one side the configuration, the other the template's current content. Keys both sides
+// changed differently keep the configuration's value and are returned as conflicts for
+// the user to resolve. The merged content is saved as a new version
+func (s *ConfigService) RebaseUserConfig(configID, userID int) (*models.RebaseResult, error) {
+	userConfig, err := s.getWritableConfig(configID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkEditLock(configID, userID); err != nil {
+		return nil, err
+	}
+	if userConfig.TemplateID == nil {
+		return nil, fmt.Errorf("validation failed: configuration is not based on a template")
+	}
+
+	template, err := s.GetTemplate(*userConfig.TemplateID)
+	if err != nil {
+		return nil, fmt.Errorf("template not found: %w", err)
+	}
+	if userConfig.TemplateVersion == template.Version {
+		return nil, fmt.Errorf("validation failed: configuration is already based on template version %s", template.Version)
+	}
+
+	var warnings []string
+	baseContent, err := s.templateContentAt(template.ID, userConfig.TemplateVersion)
+	if err != nil {
+		return nil, err
+	}
+	if baseContent == nil {
+		// Without a base every difference from the template looks like a clash
+		warnings = append(warnings, fmt.Sprintf(
+			"template content for version %q isn't recorded; keys that differ from the template are reported as conflicts",
+			userConfig.TemplateVersion))
+		empty := ""
+		baseContent = &empty
+	}
+
+	theirContent := template.DefaultContent
+	if template.Schema != nil {
+		if theirContent, err = s.parser.ApplyDefaults(theirContent, template.Format, *template.Schema); err != nil {
+			return nil, fmt.Errorf("failed to apply schema defaults: %w", err)
+		}
+	}
+
+	base, err := s.parseForMerge(*baseContent, template.Format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template version %s: %w", userConfig.TemplateVersion, err)
+	}
+	ours, err := s.parseForMerge(userConfig.Content, userConfig.Format)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: configuration content can't be parsed: %w", err)
+	}
+	theirs, err := s.parseForMerge(theirContent, template.Format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	merged, conflicts := config.ThreeWayMerge(base, ours, theirs)
+	content, err := s.parser.SerializeConfig(merged, userConfig.Format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize merged configuration: %w", err)
+	}
+
+	fromVersion := userConfig.TemplateVersion
+	userConfig.TemplateVersion = template.Version
+	changeNote := fmt.Sprintf("Rebased onto template version %s", template.Version)
+	if len(conflicts) > 0 {
+		changeNote += fmt.Sprintf(" (%d conflicts kept the configuration's values)", len(conflicts))
+	}
+	rebased, created, err := s.saveUserConfig(userConfig, userID, content, changeNote, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordActivity(models.AuditConfigUpdated, userID, rebased,
+		fmt.Sprintf("Rebased %q from template version %s to %s", rebased.Name, fromVersion, template.Version))
+	return &models.RebaseResult{
+		Config:              rebased,
+		Version:             created.Version,
+		VersionID:           created.ID,
+		FromTemplateVersion: fromVersion,
+		ToTemplateVersion:   template.Version,
+		Conflicts:           emptyIfNil(conflicts),
+		Warnings:            warnings,
+	}, nil
+}
+
+// templateContentAt returns a template's default content as of version
+// Returns nil when the history doesn't record it
+func (s *ConfigService) templateContentAt(templateID int, version string) (*string, error) {
+	history, err := s.configRepo.GetTemplateVersions(templateID)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range history {
+		if entry.PreviousVersion == version && entry.PreviousContent != nil {
+			return entry.PreviousContent, nil
+		}
+	}
+	return nil, nil
+}
+
+// parseForMerge parses content for a merge, treating empty content as an empty document
+func (s *ConfigService) parseForMerge(content string, format models.ConfigFormat) (map[string]interface{}, error) {
+	if strings.TrimSpace(content) == "" {
+		return map[string]interface{}{}, nil
+	}
+	return s.parser.ParseConfig(content, format)
+}
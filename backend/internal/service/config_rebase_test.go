@@ -0,0 +1,123 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestConfigService_RebaseUserConfig(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{
+		Name:           "app",
+		Format:         models.FormatYAML,
+		DefaultContent: "port: 8080\nhost: localhost\nlog: info\n",
+	}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	userConfig, err := service.CreateUserConfig(1, template.ID, "mine", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	if _, err := service.UpdateUserConfig(userConfig.ID, 1, "port: 9000\nhost: example.com\nlog: info\n", "", nil); err != nil {
+		t.Fatalf("UpdateUserConfig() error = %v", err)
+	}
+
+	// The template changes the port too, changes logging, and adds a timeout
+	updated := &models.ConfigTemplate{DefaultContent: "port: 8443\nhost: localhost\nlog: debug\ntimeout: 30\n"}
+	if err := service.UpdateTemplate(template.ID, updated); err != nil {
+		t.Fatalf("UpdateTemplate() error = %v", err)
+	}
+
+	result, err := service.RebaseUserConfig(userConfig.ID, 1)
+	if err != nil {
+		t.Fatalf("RebaseUserConfig() error = %v", err)
+	}
+
+	wantContent := "host: example.com\nlog: debug\nport: 9000\ntimeout: 30\n"
+	if result.Config.Content != wantContent {
+		t.Errorf("content = %q, want %q", result.Config.Content, wantContent)
+	}
+	wantConflicts := []models.MergeConflict{{Path: "port", Base: 8080, Ours: 9000, Theirs: 8443}}
+	if !reflect.DeepEqual(result.Conflicts, wantConflicts) {
+		t.Errorf("conflicts = %+v, want %+v", result.Conflicts, wantConflicts)
+	}
+	if result.FromTemplateVersion != "1.0.0" || result.ToTemplateVersion != "1.0.1" {
+		t.Errorf("template versions = %s -> %s, want 1.0.0 -> 1.0.1", result.FromTemplateVersion, result.ToTemplateVersion)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("warnings = %v, want none", result.Warnings)
+	}
+
+	// The merge is stored as a new version and the config now tracks the current template
+	stored, _ := repo.GetUserConfig(userConfig.ID)
+	if stored.Content != wantContent || stored.TemplateVersion != "1.0.1" {
+		t.Errorf("stored config = %q at %q, want the merge at 1.0.1", stored.Content, stored.TemplateVersion)
+	}
+	versions, _, _ := repo.GetConfigVersions(userConfig.ID, models.DefaultConfigVersionSort, 1, 10)
+	if len(versions) != 3 || versions[0].ID != result.VersionID || versions[0].Version != result.Version {
+		t.Fatalf("versions = %+v, want the rebase as version 3", versions)
+	}
+	if !strings.Contains(versions[0].ChangeNote, "1.0.1") {
+		t.Errorf("change note = %q, want it to name the template version", versions[0].ChangeNote)
+	}
+
+	// Rebasing again has nothing to do
+	if _, err := service.RebaseUserConfig(userConfig.ID, 1); err == nil || !strings.Contains(err.Error(), "validation failed") {
+		t.Errorf("second RebaseUserConfig() error = %v, want validation failure", err)
+	}
+	if _, err := service.RebaseUserConfig(userConfig.ID, 2); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("RebaseUserConfig() for another user error = %v, want unauthorized", err)
+	}
+}
+
+func TestConfigService_RebaseUserConfigWithoutRecordedBase(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatJSON, DefaultContent: `{"port": 8080}`}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	userConfig, err := service.CreateUserConfig(1, template.ID, "mine", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	if err := service.UpdateTemplate(template.ID, &models.ConfigTemplate{DefaultContent: `{"port": 8080, "tls": true}`}); err != nil {
+		t.Fatalf("UpdateTemplate() error = %v", err)
+	}
+
+	// History written before content snapshots has no base to merge from
+	for _, entry := range repo.history {
+		entry.PreviousContent = nil
+	}
+
+	result, err := service.RebaseUserConfig(userConfig.ID, 1)
+	if err != nil {
+		t.Fatalf("RebaseUserConfig() error = %v", err)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("warnings = %v, want one about the missing base", result.Warnings)
+	}
+	if len(result.Conflicts) != 0 || !strings.Contains(result.Config.Content, `"tls": true`) {
+		t.Errorf("rebase = %q with conflicts %+v, want tls added cleanly", result.Config.Content, result.Conflicts)
+	}
+}
+
+func TestConfigService_RebaseUserConfigWithoutTemplate(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	userConfig := &models.UserConfig{UserID: 1, Name: "loose", Format: models.FormatYAML, Content: "a: 1\n"}
+	if err := repo.CreateUserConfig(userConfig); err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	if _, err := service.RebaseUserConfig(userConfig.ID, 1); err == nil || !strings.Contains(err.Error(), "validation failed") {
+		t.Errorf("RebaseUserConfig() error = %v, want validation failure", err)
+	}
+}
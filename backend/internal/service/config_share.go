@@ -0,0 +1,113 @@
+// Configuration sharing
+// Owners grant other users read or write access to a configuration
+// Every per-configuration operation checks access through configAccess
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"conflux/internal/models"
+)
+
+// ShareUserConfig shares a configuration with the users in req; only the owner may share
+// Sharing with a user again replaces their permission. Nothing is shared when any of
+// the users doesn't exist. Returns the recorded shares
+func (s *ConfigService) ShareUserConfig(configID, ownerID int, req *models.ShareRequest) ([]*models.ConfigShare, error) {
+	userConfig, err := s.getOwnedConfig(configID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	shares := []*models.ConfigShare{}
+	seen := make(map[int]bool, len(req.ShareWith))
+	for _, userID := range req.ShareWith {
+		if userID == ownerID {
+			return nil, fmt.Errorf("validation failed: a configuration can't be shared with its owner")
+		}
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		share := &models.ConfigShare{
+			ConfigID:   configID,
+			UserID:     userID,
+			Permission: req.Permissions,
+			SharedBy:   ownerID,
+		}
+		shares = append(shares, share)
+	}
+
+	if err := s.configRepo.ShareConfig(shares); err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to share configuration: %w", err)
+	}
+
+	s.recordActivity(models.AuditConfigShared, ownerID, userConfig,
+		fmt.Sprintf("Shared %q with %d user(s) (%s)", userConfig.Name, len(shares), req.Permissions))
+	return shares, nil
+}
+
+// configAccess returns the permission a user has on a configuration, or "" for none
+// Owners have write access, shares grant their permission, and configurations
+// marked is_shared are readable by anyone
+func (s *ConfigService) configAccess(userConfig *models.UserConfig, userID int) (models.SharePermission, error) {
+	if userConfig.UserID == userID {
+		return models.ShareWrite, nil
+	}
+
+	share, err := s.configRepo.GetConfigShare(userConfig.ID, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get share: %w", err)
+	}
+	if share != nil {
+		return share.Permission, nil
+	}
+	if userConfig.IsShared {
+		return models.ShareRead, nil
+	}
+	return "", nil
+}
+
+// getConfigWithAccess loads a configuration the user has at least the needed permission on
+func (s *ConfigService) getConfigWithAccess(id, userID int, need models.SharePermission) (*models.UserConfig, error) {
+	userConfig, err := s.configRepo.GetUserConfig(id)
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := s.configAccess(userConfig, userID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case access == "":
+		return nil, fmt.Errorf("unauthorized access to configuration")
+	case need == models.ShareWrite && access != models.ShareWrite:
+		return nil, fmt.Errorf("unauthorized: configuration is shared with you read-only")
+	}
+	return userConfig, nil
+}
+
+// getWritableConfig loads a configuration the user owns or has a write share on
+func (s *ConfigService) getWritableConfig(id, userID int) (*models.UserConfig, error) {
+	return s.getConfigWithAccess(id, userID, models.ShareWrite)
+}
+
+// getOwnedConfig loads a configuration only its owner may act on, such as to delete or share it
+func (s *ConfigService) getOwnedConfig(id, userID int) (*models.UserConfig, error) {
+	userConfig, err := s.configRepo.GetUserConfig(id)
+	if err != nil {
+		return nil, err
+	}
+	if userConfig.UserID != userID {
+		return nil, fmt.Errorf("unauthorized: only the owner can do this")
+	}
+	return userConfig, nil
+}
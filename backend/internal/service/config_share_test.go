@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestConfigService_ShareUserConfig(t *testing.T) {
+	const owner, reader, writer, stranger = 1, 2, 3, 4
+
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 8080\n"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	userConfig, err := service.CreateUserConfig(owner, template.ID, "team", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+
+	shares, err := service.ShareUserConfig(userConfig.ID, owner,
+		&models.ShareRequest{ShareWith: []int{reader, reader}, Permissions: models.ShareRead})
+	if err != nil {
+		t.Fatalf("ShareUserConfig() read error = %v", err)
+	}
+	if len(shares) != 1 || shares[0].UserID != reader || shares[0].SharedBy != owner {
+		t.Errorf("shares = %+v, want one read share for user %d", shares, reader)
+	}
+	if _, err := service.ShareUserConfig(userConfig.ID, owner,
+		&models.ShareRequest{ShareWith: []int{writer}, Permissions: models.ShareWrite}); err != nil {
+		t.Fatalf("ShareUserConfig() write error = %v", err)
+	}
+
+	// Both share recipients can read; a stranger can't
+	for _, userID := range []int{owner, reader, writer} {
+		if _, err := service.GetUserConfig(userConfig.ID, userID); err != nil {
+			t.Errorf("GetUserConfig() by user %d error = %v", userID, err)
+		}
+	}
+	if _, err := service.GetUserConfig(userConfig.ID, stranger); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("GetUserConfig() by a stranger error = %v, want unauthorized", err)
+	}
+
+	// A read-only share rejects every write
+	writes := map[string]func(userID int) error{
+		"update": func(userID int) error {
+			_, err := service.UpdateUserConfig(userConfig.ID, userID, "port: 9000\n", "", nil)
+			return err
+		},
+		"patch": func(userID int) error {
+			_, err := service.PatchUserConfig(userConfig.ID, userID, []byte(`{"port": 9001}`), "")
+			return err
+		},
+		"lock": func(userID int) error { _, err := service.LockUserConfig(userConfig.ID, userID); return err },
+	}
+	for name, write := range writes {
+		if err := write(reader); err == nil || !strings.Contains(err.Error(), "read-only") {
+			t.Errorf("%s by a reader error = %v, want read-only unauthorized", name, err)
+		}
+	}
+	if _, err := service.UpdateUserConfig(userConfig.ID, writer, "port: 9000\n", "", nil); err != nil {
+		t.Errorf("UpdateUserConfig() by a writer error = %v", err)
+	}
+
+	// Deleting and resharing stay with the owner, even for writers
+	if err := service.DeleteUserConfig(userConfig.ID, writer); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("DeleteUserConfig() by a writer error = %v, want unauthorized", err)
+	}
+	if _, err := service.ShareUserConfig(userConfig.ID, writer,
+		&models.ShareRequest{ShareWith: []int{stranger}, Permissions: models.ShareRead}); err == nil ||
+		!strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("ShareUserConfig() by a writer error = %v, want unauthorized", err)
+	}
+
+	// Sharing again replaces the permission
+	if _, err := service.ShareUserConfig(userConfig.ID, owner,
+		&models.ShareRequest{ShareWith: []int{reader}, Permissions: models.ShareWrite}); err != nil {
+		t.Fatalf("ShareUserConfig() upgrade error = %v", err)
+	}
+	if err := writes["update"](reader); err != nil {
+		t.Errorf("UpdateUserConfig() after upgrading the share error = %v", err)
+	}
+}
+
+func TestConfigService_ShareUserConfigUnknownUser(t *testing.T) {
+	repo := NewMockConfigRepository()
+	repo.missingUsers = map[int]bool{3: true}
+	service := NewConfigService(repo)
+	userConfig := &models.UserConfig{UserID: 1, Name: "app", Format: models.FormatYAML, Content: "port: 8080\n"}
+	if err := repo.CreateUserConfig(userConfig); err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+
+	_, err := service.ShareUserConfig(userConfig.ID, 1,
+		&models.ShareRequest{ShareWith: []int{2, 3}, Permissions: models.ShareRead})
+	if err == nil || !strings.Contains(err.Error(), "user not found") {
+		t.Fatalf("ShareUserConfig() error = %v, want user not found", err)
+	}
+	if _, err := service.GetUserConfig(userConfig.ID, 2); err == nil {
+		t.Error("GetUserConfig() by user 2 succeeded, want no share recorded")
+	}
+}
+
+func TestConfigService_SharedEditsRecordActor(t *testing.T) {
+	const owner, writer = 1, 2
+
+	repo := NewMockConfigRepository()
+	auditService := NewAuditService(&MockAuditRepository{})
+	service := NewConfigService(repo, WithAuditLog(auditService))
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 8080\n"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	userConfig, err := service.CreateUserConfig(owner, template.ID, "team", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	if _, err := service.ShareUserConfig(userConfig.ID, owner,
+		&models.ShareRequest{ShareWith: []int{writer}, Permissions: models.ShareWrite}); err != nil {
+		t.Fatalf("ShareUserConfig() error = %v", err)
+	}
+
+	if _, err := service.UpdateUserConfig(userConfig.ID, writer, "port: 9000\n", "", nil); err != nil {
+		t.Fatalf("UpdateUserConfig() error = %v", err)
+	}
+	if _, err := service.PatchUserConfig(userConfig.ID, writer, []byte(`{"port": 9001}`), ""); err != nil {
+		t.Fatalf("PatchUserConfig() error = %v", err)
+	}
+	versions, _, _ := repo.GetConfigVersions(userConfig.ID, models.DefaultConfigVersionSort, 1, 10)
+	if _, err := service.RestoreConfigVersion(userConfig.ID, versions[len(versions)-1].ID, writer); err != nil {
+		t.Fatalf("RestoreConfigVersion() error = %v", err)
+	}
+
+	// The initial version is the owner's; every later one is the writer's
+	versions, _, _ = repo.GetConfigVersions(userConfig.ID, models.DefaultConfigVersionSort, 1, 10)
+	if len(versions) != 4 {
+		t.Fatalf("got %d versions, want 4", len(versions))
+	}
+	for _, version := range versions {
+		want := writer
+		if version.Version == 1 {
+			want = owner
+		}
+		if version.CreatedBy != want {
+			t.Errorf("version %d created_by = %d, want %d", version.Version, version.CreatedBy, want)
+		}
+	}
+
+	// The writer's edits are in the writer's feed, not the owner's
+	entries, total, err := auditService.ListActivity(context.Background(), writer, 1, 10)
+	if err != nil {
+		t.Fatalf("ListActivity() error = %v", err)
+	}
+	if total != 3 {
+		t.Errorf("writer's activity = %d entries, want 3", total)
+	}
+	for _, entry := range entries {
+		if entry.ActorID != writer {
+			t.Errorf("%s entry actor = %d, want %d", entry.Action, entry.ActorID, writer)
+		}
+	}
+	if _, total, _ := auditService.ListActivity(context.Background(), owner, 1, 10); total != 2 {
+		t.Errorf("owner's activity = %d entries, want 2 (create and share)", total)
+	}
+}
+
+func TestConfigService_ShareUserConfigValidation(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+	userConfig := &models.UserConfig{UserID: 1, Name: "app", Format: models.FormatYAML, Content: "port: 8080\n"}
+	if err := repo.CreateUserConfig(userConfig); err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		req  models.ShareRequest
+	}{
+		{name: "no users", req: models.ShareRequest{Permissions: models.ShareRead}},
+		{name: "unknown permission", req: models.ShareRequest{ShareWith: []int{2}, Permissions: "admin"}},
+		{name: "invalid user", req: models.ShareRequest{ShareWith: []int{0}, Permissions: models.ShareRead}},
+		{name: "owner", req: models.ShareRequest{ShareWith: []int{1}, Permissions: models.ShareRead}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.ShareUserConfig(userConfig.ID, 1, &tt.req); err == nil ||
+				!strings.Contains(err.Error(), "validation failed") {
+				t.Errorf("ShareUserConfig() error = %v, want validation failed", err)
+			}
+		})
+	}
+}
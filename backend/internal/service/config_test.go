@@ -0,0 +1,1635 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+	"conflux/pkg/config"
+	"conflux/pkg/utils"
+)
+
+// MockConfigRepository is an in-memory implementation of the ConfigRepository
+// interface for unit tests. It is safe for concurrent use because imports are
+// processed by background workers.
+//
+// Behavioral Characteristics:
+// - Each entity type has its own ID sequence starting from 1.
+// - Methods store and return copies to prevent unintended mutations.
+// - List methods honor their ListSort, breaking ties by ID like ListSort.OrderBy.
+// - Creates and updates stamp timestamps from now, standing in for the database clock.
+type MockConfigRepository struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	templates map[int]*models.ConfigTemplate
+	configs   map[int]*models.UserConfig
+	versions  map[int]*models.ConfigVersion
+	imports   map[int]*models.ConfigImport
+	variables map[int]*models.ConfigVariable
+	history   map[int]*models.TemplateVersion
+	locks     map[int]*models.ConfigLock
+	shares    map[[2]int]*models.ConfigShare // Keyed by config ID and user ID
+	nextID    int
+
+	missingUsers map[int]bool // User IDs ShareConfig treats as nonexistent
+}
+
+// NewMockConfigRepository creates a new mock config repository
+func NewMockConfigRepository() *MockConfigRepository {
+	return &MockConfigRepository{
+		templates: make(map[int]*models.ConfigTemplate),
+		configs:   make(map[int]*models.UserConfig),
+		versions:  make(map[int]*models.ConfigVersion),
+		imports:   make(map[int]*models.ConfigImport),
+		variables: make(map[int]*models.ConfigVariable),
+		history:   make(map[int]*models.TemplateVersion),
+		locks:     make(map[int]*models.ConfigLock),
+		shares:    make(map[[2]int]*models.ConfigShare),
+		nextID:    1,
+		now:       time.Now,
+	}
+}
+
+func (m *MockConfigRepository) newID() int {
+	id := m.nextID
+	m.nextID++
+	return id
+}
+
+// Template management
+
+func (m *MockConfigRepository) CreateTemplate(template *models.ConfigTemplate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	template.ID = m.newID()
+	template.CreatedAt = m.now()
+	template.UpdatedAt = template.CreatedAt
+	templateCopy := *template
+	m.templates[template.ID] = &templateCopy
+	return nil
+}
+
+func (m *MockConfigRepository) GetTemplate(id int) (*models.ConfigTemplate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	template, ok := m.templates[id]
+	if !ok {
+		return nil, errors.New("template not found")
+	}
+	templateCopy := *template
+	return &templateCopy, nil
+}
+
+func (m *MockConfigRepository) GetTemplates(category, search string, page, limit int) ([]*models.ConfigTemplate, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var templates []*models.ConfigTemplate
+	for _, template := range m.templates {
+		if category != "" && template.Category != category {
+			continue
+		}
+		templateCopy := *template
+		templates = append(templates, &templateCopy)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ID < templates[j].ID })
+	return templates, int64(len(templates)), nil
+}
+
+func (m *MockConfigRepository) UpdateTemplate(id int, updates *models.ConfigTemplate, plan models.TemplateVersionPlanner) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	existing, ok := m.templates[id]
+	if !ok {
+		return errors.New("template not found")
+	}
+
+	updates.Version = existing.Version
+	if plan != nil {
+		existingCopy := *existing
+		var variables []*models.ConfigVariable
+		for _, variable := range m.variables {
+			if variable.TemplateID == id {
+				variableCopy := *variable
+				variables = append(variables, &variableCopy)
+			}
+		}
+		sort.Slice(variables, func(i, j int) bool { return variables[i].ID < variables[j].ID })
+
+		if version := plan(&existingCopy, variables); version != nil {
+			version.TemplateID = id
+			version.ID = m.newID()
+			version.CreatedAt = m.now()
+			versionCopy := *version
+			m.history[version.ID] = &versionCopy
+			updates.Version = version.Version
+		}
+	}
+
+	updates.CreatedAt = existing.CreatedAt
+	updates.UpdatedAt = m.now()
+	templateCopy := *updates
+	templateCopy.ID = id
+	m.templates[id] = &templateCopy
+	return nil
+}
+
+func (m *MockConfigRepository) DeleteTemplate(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.templates, id)
+	return nil
+}
+
+// Template version history
+
+func (m *MockConfigRepository) GetTemplateVersions(templateID int) ([]*models.TemplateVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var versions []*models.TemplateVersion
+	for _, version := range m.history {
+		if version.TemplateID == templateID {
+			versionCopy := *version
+			versions = append(versions, &versionCopy)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].ID > versions[j].ID })
+	return versions, nil
+}
+
+func (m *MockConfigRepository) GetTemplateDependents(
+	templateID, page, limit int,
+) ([]*models.TemplateDependent, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var dependents []*models.TemplateDependent
+	for _, config := range m.configs {
+		if config.TemplateID != nil && *config.TemplateID == templateID {
+			dependents = append(dependents, &models.TemplateDependent{
+				ConfigID:        config.ID,
+				Name:            config.Name,
+				OwnerID:         config.UserID,
+				TemplateVersion: config.TemplateVersion,
+				UpdatedAt:       config.UpdatedAt,
+			})
+		}
+	}
+	sort.Slice(dependents, func(i, j int) bool {
+		if !dependents[i].UpdatedAt.Equal(dependents[j].UpdatedAt) {
+			return dependents[i].UpdatedAt.After(dependents[j].UpdatedAt)
+		}
+		return dependents[i].ConfigID > dependents[j].ConfigID
+	})
+	total := int64(len(dependents))
+	start := min((page-1)*limit, len(dependents))
+	return dependents[start:min(start+limit, len(dependents))], total, nil
+}
+
+// User configuration management
+
+func (m *MockConfigRepository) CreateUserConfig(config *models.UserConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	config.ID = m.newID()
+	config.CreatedAt = m.now()
+	config.UpdatedAt = config.CreatedAt
+	configCopy := *config
+	m.configs[config.ID] = &configCopy
+	return nil
+}
+
+func (m *MockConfigRepository) GetUserConfig(id int) (*models.UserConfig, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	config, ok := m.configs[id]
+	if !ok {
+		return nil, errors.New("configuration not found")
+	}
+	configCopy := *config
+	return &configCopy, nil
+}
+
+func (m *MockConfigRepository) GetUserConfigs(
+	userID int, templateID *int, order models.ListSort, page, limit int,
+) ([]*models.UserConfig, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var configs []*models.UserConfig
+	for _, config := range m.configs {
+		if config.UserID != userID {
+			continue
+		}
+		if templateID != nil && (config.TemplateID == nil || *config.TemplateID != *templateID) {
+			continue
+		}
+		configCopy := *config
+		configs = append(configs, &configCopy)
+	}
+	sortByColumn(configs, order, func(config *models.UserConfig) (interface{}, int) {
+		switch order.Column {
+		case "name":
+			return config.Name, config.ID
+		case "created_at":
+			return config.CreatedAt, config.ID
+		case "updated_at":
+			return config.UpdatedAt, config.ID
+		default:
+			return config.ID, config.ID
+		}
+	})
+	return configs, int64(len(configs)), nil
+}
+
+func (m *MockConfigRepository) GetUserConfigsAfter(userID int, templateID *int, afterID, limit int) ([]*models.UserConfig, error) {
+	configs, _, err := m.GetUserConfigs(userID, templateID, models.DefaultUserConfigSort, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	page := configs[:0]
+	for _, config := range configs {
+		if config.ID > afterID && len(page) < limit {
+			page = append(page, config)
+		}
+	}
+	return page, nil
+}
+
+func (m *MockConfigRepository) UserConfigNameExists(userID int, name string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, config := range m.configs {
+		if config.UserID == userID && strings.EqualFold(config.Name, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MockConfigRepository) UpdateUserConfig(id int, config *models.UserConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	existing, ok := m.configs[id]
+	if !ok {
+		return errors.New("configuration not found")
+	}
+	config.CreatedAt = existing.CreatedAt
+	config.UpdatedAt = m.now()
+	configCopy := *config
+	configCopy.ID = id
+	m.configs[id] = &configCopy
+	return nil
+}
+
+func (m *MockConfigRepository) DeleteUserConfig(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.configs, id)
+	delete(m.locks, id)
+	for versionID, version := range m.versions {
+		if version.ConfigID == id {
+			delete(m.versions, versionID)
+		}
+	}
+	return nil
+}
+
+// Edit locks
+
+func (m *MockConfigRepository) AcquireConfigLock(configID, holderID int, ttl time.Duration) (*models.ConfigLock, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	lock, ok := m.locks[configID]
+	switch {
+	case !ok || !now.Before(lock.ExpiresAt):
+		lock = &models.ConfigLock{ConfigID: configID, HolderID: holderID, AcquiredAt: now}
+		m.locks[configID] = lock
+	case lock.HolderID != holderID:
+		lockCopy := *lock
+		return &lockCopy, nil
+	}
+	lock.ExpiresAt = now.Add(ttl)
+	lockCopy := *lock
+	return &lockCopy, nil
+}
+
+func (m *MockConfigRepository) GetConfigLock(configID int) (*models.ConfigLock, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lock, ok := m.locks[configID]
+	if !ok || !m.now().Before(lock.ExpiresAt) {
+		return nil, nil
+	}
+	lockCopy := *lock
+	return &lockCopy, nil
+}
+
+func (m *MockConfigRepository) ReleaseConfigLock(configID, holderID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if lock, ok := m.locks[configID]; ok && lock.HolderID == holderID {
+		delete(m.locks, configID)
+	}
+	return nil
+}
+
+// Shares
+
+func (m *MockConfigRepository) ShareConfig(shares []*models.ConfigShare) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, share := range shares {
+		if m.missingUsers[share.UserID] {
+			return fmt.Errorf("share with user %d: user not found", share.UserID)
+		}
+	}
+	for _, share := range shares {
+		share.CreatedAt = m.now()
+		shareCopy := *share
+		m.shares[[2]int{share.ConfigID, share.UserID}] = &shareCopy
+	}
+	return nil
+}
+
+func (m *MockConfigRepository) GetConfigShare(configID, userID int) (*models.ConfigShare, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	share, ok := m.shares[[2]int{configID, userID}]
+	if !ok {
+		return nil, nil
+	}
+	shareCopy := *share
+	return &shareCopy, nil
+}
+
+// Version management
+
+func (m *MockConfigRepository) CreateVersion(version *models.ConfigVersion) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	version.ID = m.newID()
+	version.CreatedAt = m.now()
+	versionCopy := *version
+	m.versions[version.ID] = &versionCopy
+	return nil
+}
+
+func (m *MockConfigRepository) GetConfigVersion(id int) (*models.ConfigVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	version, ok := m.versions[id]
+	if !ok {
+		return nil, errors.New("version not found")
+	}
+	versionCopy := *version
+	return &versionCopy, nil
+}
+
+func (m *MockConfigRepository) GetConfigVersions(
+	configID int, order models.ListSort, page, limit int,
+) ([]*models.ConfigVersion, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var versions []*models.ConfigVersion
+	for _, version := range m.versions {
+		if version.ConfigID == configID {
+			versionCopy := *version
+			versions = append(versions, &versionCopy)
+		}
+	}
+	sortByColumn(versions, order, func(version *models.ConfigVersion) (interface{}, int) {
+		if order.Column == "created_at" {
+			return version.CreatedAt, version.ID
+		}
+		return version.Version, version.ID
+	})
+
+	total := int64(len(versions))
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * limit
+	if limit <= 0 || start >= len(versions) {
+		return versions[:0], total, nil
+	}
+	end := start + limit
+	if end > len(versions) {
+		end = len(versions)
+	}
+	return versions[start:end], total, nil
+}
+
+func (m *MockConfigRepository) GetConfigVersionsBefore(configID, beforeID, limit int) ([]*models.ConfigVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var versions []*models.ConfigVersion
+	for _, version := range m.versions {
+		if version.ConfigID == configID && (beforeID == 0 || version.ID < beforeID) {
+			versionCopy := *version
+			versions = append(versions, &versionCopy)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].ID > versions[j].ID })
+	if len(versions) > limit {
+		versions = versions[:limit]
+	}
+	return versions, nil
+}
+
+// Import management
+
+func (m *MockConfigRepository) CreateImport(importRecord *models.ConfigImport) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	importRecord.ID = m.newID()
+	importRecord.CreatedAt = m.now()
+	importCopy := *importRecord
+	m.imports[importRecord.ID] = &importCopy
+	return nil
+}
+
+func (m *MockConfigRepository) GetImport(id int) (*models.ConfigImport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	importRecord, ok := m.imports[id]
+	if !ok {
+		return nil, errors.New("import not found")
+	}
+	importCopy := *importRecord
+	return &importCopy, nil
+}
+
+func (m *MockConfigRepository) UpdateImport(id int, updates *models.ConfigImport) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.imports[id]; !ok {
+		return errors.New("import not found")
+	}
+	importCopy := *updates
+	importCopy.ID = id
+	m.imports[id] = &importCopy
+	return nil
+}
+
+func (m *MockConfigRepository) GetLatestCompletedImport(userID int, sourceURL string) (*models.ConfigImport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var latest *models.ConfigImport
+	for _, importRecord := range m.imports {
+		if importRecord.UserID != userID || importRecord.SourceURL != sourceURL || importRecord.Status != models.ImportCompleted {
+			continue
+		}
+		if latest == nil || importRecord.ID > latest.ID {
+			latest = importRecord
+		}
+	}
+	if latest == nil {
+		return nil, errors.New("import not found")
+	}
+	importCopy := *latest
+	return &importCopy, nil
+}
+
+func (m *MockConfigRepository) GetImportsByStatus(
+	status models.ImportStatus, page, limit int,
+) ([]*models.ConfigImport, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var imports []*models.ConfigImport
+	for _, importRecord := range m.imports {
+		if importRecord.Status == status {
+			importCopy := *importRecord
+			imports = append(imports, &importCopy)
+		}
+	}
+	sort.Slice(imports, func(i, j int) bool {
+		if !imports[i].CreatedAt.Equal(imports[j].CreatedAt) {
+			return imports[i].CreatedAt.Before(imports[j].CreatedAt)
+		}
+		return imports[i].ID < imports[j].ID
+	})
+	total := int64(len(imports))
+	start := min((page-1)*limit, len(imports))
+	return imports[start:min(start+limit, len(imports))], total, nil
+}
+
+// Template variables
+
+func (m *MockConfigRepository) GetTemplateVariables(templateID int) ([]*models.ConfigVariable, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var variables []*models.ConfigVariable
+	for _, variable := range m.variables {
+		if variable.TemplateID == templateID {
+			variableCopy := *variable
+			variables = append(variables, &variableCopy)
+		}
+	}
+	sort.Slice(variables, func(i, j int) bool { return variables[i].ID < variables[j].ID })
+	return variables, nil
+}
+
+// Helper methods for testing
+
+func (m *MockConfigRepository) AddVariable(variable *models.ConfigVariable) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	variable.ID = m.newID()
+	variableCopy := *variable
+	m.variables[variable.ID] = &variableCopy
+}
+
+func (m *MockConfigRepository) ConfigCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.configs)
+}
+
+// sortByColumn orders items the way ListSort.OrderBy does, with id as the tiebreaker
+// key returns the sort column's value (int, string, or time.Time) and the item's ID
+func sortByColumn[T any](items []T, order models.ListSort, key func(T) (interface{}, int)) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, aID := key(items[i])
+		b, bID := key(items[j])
+		c := compareColumn(a, b)
+		if c == 0 {
+			c = cmp.Compare(aID, bID)
+		}
+		if order.Descending {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+// compareColumn compares two values of the same sortable column
+func compareColumn(a, b interface{}) int {
+	switch a := a.(type) {
+	case int:
+		return cmp.Compare(a, b.(int))
+	case string:
+		return cmp.Compare(a, b.(string))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	default:
+		return 0
+	}
+}
+
+func TestConfigService_UsesRepositoryTimestamps(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	// The database clock deliberately disagrees with the app clock
+	dbTime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	repo.now = func() time.Time { return dbTime }
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 1"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	if !template.CreatedAt.Equal(dbTime) || !template.UpdatedAt.Equal(dbTime) {
+		t.Errorf("template timestamps = %v/%v, want %v", template.CreatedAt, template.UpdatedAt, dbTime)
+	}
+
+	config, err := service.CreateUserConfig(1, template.ID, "app", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	if !config.CreatedAt.Equal(dbTime) || !config.UpdatedAt.Equal(dbTime) {
+		t.Errorf("config timestamps = %v/%v, want %v", config.CreatedAt, config.UpdatedAt, dbTime)
+	}
+
+	updateTime := dbTime.Add(time.Hour)
+	repo.now = func() time.Time { return updateTime }
+
+	updated, err := service.UpdateUserConfig(config.ID, 1, "port: 2", "edit", nil)
+	if err != nil {
+		t.Fatalf("UpdateUserConfig() error = %v", err)
+	}
+	if !updated.CreatedAt.Equal(dbTime) {
+		t.Errorf("created_at after update = %v, want %v", updated.CreatedAt, dbTime)
+	}
+	if !updated.UpdatedAt.Equal(updateTime) {
+		t.Errorf("updated_at after update = %v, want %v", updated.UpdatedAt, updateTime)
+	}
+
+	versions, _, _ := repo.GetConfigVersions(config.ID, models.DefaultConfigVersionSort, 1, 1)
+	if !versions[0].CreatedAt.Equal(updateTime) {
+		t.Errorf("version created_at = %v, want %v", versions[0].CreatedAt, updateTime)
+	}
+
+	if err := service.UpdateTemplate(template.ID, &models.ConfigTemplate{Name: "app", DefaultContent: "port: 3"}); err != nil {
+		t.Fatalf("UpdateTemplate() error = %v", err)
+	}
+	stored, _ := service.GetTemplate(template.ID)
+	if !stored.CreatedAt.Equal(dbTime) || !stored.UpdatedAt.Equal(updateTime) {
+		t.Errorf("template timestamps after update = %v/%v, want %v/%v", stored.CreatedAt, stored.UpdatedAt, dbTime, updateTime)
+	}
+}
+
+func TestConfigService_ValidateConfig_RequiredVariables(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{
+		Name:           "cross-seed",
+		Format:         models.FormatYAML,
+		DefaultContent: "delay: 30\ntorrentDir: \"\"\nclient:\n  url: http://localhost",
+	}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	defaultURL := "http://localhost"
+	for _, variable := range []*models.ConfigVariable{
+		{TemplateID: template.ID, Name: "TORRENT_DIR", Path: "torrentDir", Required: true},
+		{TemplateID: template.ID, Name: "CLIENT_URL", Path: "client.url", Required: true, DefaultValue: &defaultURL},
+		{TemplateID: template.ID, Name: "DELAY", Path: "delay"},
+	} {
+		repo.AddVariable(variable)
+	}
+
+	tests := []struct {
+		name         string
+		content      string
+		wantUnfilled []string
+	}{
+		{
+			name:         "template defaults",
+			content:      template.DefaultContent,
+			wantUnfilled: []string{"TORRENT_DIR", "CLIENT_URL"},
+		},
+		{
+			name:         "missing paths",
+			content:      "delay: 10",
+			wantUnfilled: []string{"TORRENT_DIR", "CLIENT_URL"},
+		},
+		{
+			name:         "partially filled",
+			content:      "torrentDir: /data\nclient:\n  url: http://localhost",
+			wantUnfilled: []string{"CLIENT_URL"},
+		},
+		{
+			name:    "ready to use",
+			content: "torrentDir: /data\nclient:\n  url: http://qbittorrent:8080",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ValidateConfig(tt.content, models.FormatYAML, &template.ID, ValidateOptions{})
+			if len(tt.wantUnfilled) == 0 {
+				if err != nil {
+					t.Fatalf("ValidateConfig() error = %v", err)
+				}
+				return
+			}
+
+			var validationErr *TemplateValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("ValidateConfig() error = %v, want *TemplateValidationError", err)
+			}
+			var names []string
+			for _, variable := range validationErr.UnfilledVariables {
+				names = append(names, variable.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantUnfilled) {
+				t.Errorf("unfilled = %v, want %v", names, tt.wantUnfilled)
+			}
+			if !strings.Contains(err.Error(), "validation failed: required variables not set: ") {
+				t.Errorf("error = %q, want a validation failure listing the variables", err)
+			}
+		})
+	}
+
+	// Without a template only the format is checked
+	if _, err := service.ValidateConfig("delay: 10", models.FormatYAML, nil, ValidateOptions{}); err != nil {
+		t.Errorf("ValidateConfig() without template error = %v", err)
+	}
+}
+
+func TestConfigService_ValidateUserConfig(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 8080\n"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	userConfig, err := service.CreateUserConfig(1, template.ID, "mine", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+
+	result, err := service.ValidateUserConfig(userConfig.ID, 1, ValidateOptions{})
+	if err != nil {
+		t.Fatalf("ValidateUserConfig() error = %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("warnings = %v, want none", result.Warnings)
+	}
+
+	// The template later requires a host and moves to a new version
+	repo.AddVariable(&models.ConfigVariable{TemplateID: template.ID, Name: "HOST", Path: "host", Required: true})
+	if err := service.UpdateTemplate(template.ID, &models.ConfigTemplate{DefaultContent: "port: 8080\nhost: \"\"\n"}); err != nil {
+		t.Fatalf("UpdateTemplate() error = %v", err)
+	}
+
+	result, err = service.ValidateUserConfig(userConfig.ID, 1, ValidateOptions{})
+	var validationErr *TemplateValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.UnfilledVariables) != 1 {
+		t.Fatalf("ValidateUserConfig() error = %v, want the unfilled HOST variable", err)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "1.0.1") {
+		t.Errorf("warnings = %v, want one naming the current template version", result.Warnings)
+	}
+
+	if _, err := service.ValidateUserConfig(userConfig.ID, 2, ValidateOptions{}); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("ValidateUserConfig() for another user error = %v, want unauthorized", err)
+	}
+}
+
+func TestConfigService_ValidateConfig_UnknownKeys(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	schema := `{"additionalProperties": false, "properties": {"port": {}}}`
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 8080\n", Schema: &schema}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+
+	result, err := service.ValidateConfig("port: 1\nprot: 2\n", models.FormatYAML, &template.ID, ValidateOptions{})
+	if err != nil {
+		t.Fatalf("ValidateConfig() error = %v", err)
+	}
+	if want := []string{"unexpected keys: prot"}; !reflect.DeepEqual(result.Warnings, want) {
+		t.Errorf("warnings = %v, want %v", result.Warnings, want)
+	}
+
+	_, err = service.ValidateConfig("port: 1\nprot: 2\n", models.FormatYAML, &template.ID, ValidateOptions{Strict: true})
+	var validationErr *TemplateValidationError
+	if !errors.As(err, &validationErr) || !reflect.DeepEqual(validationErr.UnknownKeys, []string{"prot"}) {
+		t.Fatalf("strict ValidateConfig() error = %v, want unknown key prot", err)
+	}
+
+	// An allowlist works without a template
+	opts := ValidateOptions{AllowedKeys: []string{"port"}, Strict: true}
+	if _, err := service.ValidateConfig("port: 1\ndebug: true\n", models.FormatYAML, nil, opts); err == nil ||
+		!strings.Contains(err.Error(), "unexpected keys: debug") {
+		t.Errorf("allowlist ValidateConfig() error = %v, want unexpected keys: debug", err)
+	}
+}
+
+func TestConfigService_ValidateConfigIncludeParsed(t *testing.T) {
+	service := NewConfigService(NewMockConfigRepository())
+
+	// ENV values stay strings, and the parsed data is returned even when validation fails
+	opts := ValidateOptions{AllowedKeys: []string{"PORT"}, Strict: true, IncludeParsed: true}
+	result, err := service.ValidateConfig("PORT=8080\nDEBUG=true\n", models.FormatENV, nil, opts)
+	if err == nil {
+		t.Fatal("ValidateConfig() error = nil, want unexpected key DEBUG")
+	}
+	if result == nil {
+		t.Fatal("ValidateConfig() result = nil, want the parsed data")
+	}
+	want := map[string]interface{}{"PORT": "8080", "DEBUG": "true"}
+	if !reflect.DeepEqual(result.Parsed, want) {
+		t.Errorf("parsed = %#v, want %#v", result.Parsed, want)
+	}
+
+	// YAML numbers come back as numbers
+	result, err = service.ValidateConfig("port: 8080\n", models.FormatYAML, nil, ValidateOptions{IncludeParsed: true})
+	if err != nil {
+		t.Fatalf("ValidateConfig() error = %v", err)
+	}
+	if want := map[string]interface{}{"port": json.Number("8080")}; !reflect.DeepEqual(result.Parsed, want) {
+		t.Errorf("parsed = %#v, want %#v", result.Parsed, want)
+	}
+
+	result, _ = service.ValidateConfig("port: 8080\n", models.FormatYAML, nil, ValidateOptions{})
+	if result.Parsed != nil {
+		t.Errorf("parsed = %v without IncludeParsed, want nil", result.Parsed)
+	}
+}
+
+func TestConfigService_TemplateFormatWarnings(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: `{"port": 8080}`}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	if len(template.Warnings) != 1 || !strings.Contains(template.Warnings[0], "looks like json") {
+		t.Errorf("create warnings = %v, want a json mismatch warning", template.Warnings)
+	}
+	stored, _ := repo.GetTemplate(template.ID)
+	if len(stored.Warnings) != 0 {
+		t.Errorf("stored template warnings = %v, want none persisted", stored.Warnings)
+	}
+
+	updates := &models.ConfigTemplate{DefaultContent: "port: 9090\n"}
+	if err := service.UpdateTemplate(template.ID, updates); err != nil {
+		t.Fatalf("UpdateTemplate() error = %v", err)
+	}
+	if len(updates.Warnings) != 0 {
+		t.Errorf("update warnings = %v, want none for YAML content", updates.Warnings)
+	}
+
+	err := service.UpdateTemplate(template.ID, &models.ConfigTemplate{DefaultContent: "port: 1\n---\nport: 2\n"})
+	if err == nil || !strings.Contains(err.Error(), "template validation failed") {
+		t.Errorf("multi-document update error = %v, want validation failure", err)
+	}
+}
+
+func TestConfigService_CreateUserConfig_SchemaDefaults(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	schema := `{"properties": {"port": {"default": 8080}, "server": {"properties": {"host": {"default": "localhost"}}}}}`
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 9090\n", Schema: &schema}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+
+	userConfig, err := service.CreateUserConfig(1, template.ID, "mine", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	want := "port: 9090\nserver:\n    host: localhost\n"
+	if userConfig.Content != want {
+		t.Errorf("content = %q, want %q", userConfig.Content, want)
+	}
+	versions, _, _ := repo.GetConfigVersions(userConfig.ID, models.DefaultConfigVersionSort, 1, 10)
+	if len(versions) != 1 || versions[0].Content != want {
+		t.Errorf("initial version = %+v, want the filled content", versions)
+	}
+}
+
+func TestConfigService_PreviewTemplate(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{
+		Name:           "cross-seed",
+		Format:         models.FormatYAML,
+		DefaultContent: "delay: 30\ntorrentDir: \"\"\nclient:\n  url: http://localhost\n",
+	}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	defaultURL := "http://qbittorrent:8080"
+	for _, variable := range []*models.ConfigVariable{
+		{TemplateID: template.ID, Name: "TORRENT_DIR", Path: "torrentDir", Type: "string", Required: true},
+		{TemplateID: template.ID, Name: "CLIENT_URL", Path: "client.url", Type: "string", Required: true, DefaultValue: &defaultURL},
+		{TemplateID: template.ID, Name: "DELAY", Path: "delay", Type: "number"},
+		{TemplateID: template.ID, Name: "TRACKERS", Path: "trackers", Type: "array"},
+	} {
+		repo.AddVariable(variable)
+	}
+
+	t.Run("sample values and defaults", func(t *testing.T) {
+		preview, err := service.PreviewTemplate(template.ID, &models.TemplatePreviewRequest{
+			Values: map[string]interface{}{"TORRENT_DIR": "/data", "DELAY": "10", "TRACKERS": "a, b"},
+		})
+		if err != nil {
+			t.Fatalf("PreviewTemplate() error = %v", err)
+		}
+		// CLIENT_URL's default is rendered, but a required variable needs a value of its own
+		if len(preview.UnfilledVariables) != 1 || preview.UnfilledVariables[0].Name != "CLIENT_URL" {
+			t.Errorf("unfilled = %v, want CLIENT_URL", preview.UnfilledVariables)
+		}
+
+		data, err := service.parser.ParseConfig(preview.Content, models.FormatYAML)
+		if err != nil {
+			t.Fatalf("rendered content is not valid YAML: %v", err)
+		}
+		want := map[string]interface{}{
+			"delay":      10,
+			"torrentDir": "/data",
+			"client":     map[string]interface{}{"url": defaultURL},
+			"trackers":   []interface{}{"a", "b"},
+		}
+		if !reflect.DeepEqual(data, want) {
+			t.Errorf("rendered = %v, want %v", data, want)
+		}
+	})
+
+	t.Run("unfilled required variable", func(t *testing.T) {
+		preview, err := service.PreviewTemplate(template.ID, &models.TemplatePreviewRequest{})
+		if err != nil {
+			t.Fatalf("PreviewTemplate() error = %v", err)
+		}
+		if len(preview.UnfilledVariables) != 2 || preview.UnfilledVariables[0].Name != "TORRENT_DIR" ||
+			preview.UnfilledVariables[1].Name != "CLIENT_URL" {
+			t.Errorf("unfilled = %v, want TORRENT_DIR and CLIENT_URL", preview.UnfilledVariables)
+		}
+	})
+
+	for name, values := range map[string]map[string]interface{}{
+		"unknown variable": {"NOPE": "x"},
+		"mistyped value":   {"DELAY": "soon"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := service.PreviewTemplate(template.ID, &models.TemplatePreviewRequest{Values: values})
+			if err == nil || !strings.Contains(err.Error(), "validation failed") {
+				t.Errorf("PreviewTemplate() error = %v, want validation failure", err)
+			}
+		})
+	}
+
+	if repo.ConfigCount() != 0 {
+		t.Errorf("preview created %d configurations", repo.ConfigCount())
+	}
+}
+
+func TestConfigService_DetectFormat(t *testing.T) {
+	service := NewConfigService(NewMockConfigRepository())
+
+	tests := []struct {
+		name           string
+		content        string
+		bestGuess      bool
+		want           models.ConfigFormat
+		wantCandidates []models.ConfigFormat
+	}{
+		{name: "clear YAML", content: "name: app", want: models.FormatYAML},
+		{name: "clear ENV", content: "DB_HOST=localhost", want: models.FormatENV},
+		{
+			name:           "TOML or ENV is ambiguous",
+			content:        `NAME = "app"`,
+			wantCandidates: []models.ConfigFormat{models.FormatTOML, models.FormatENV},
+		},
+		{name: "best guess resolves ambiguity", content: `NAME = "app"`, bestGuess: true, want: models.FormatTOML},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := service.DetectFormat(tt.content, tt.bestGuess)
+
+			if tt.wantCandidates != nil {
+				var ambiguous *AmbiguousFormatError
+				if !errors.As(err, &ambiguous) {
+					t.Fatalf("DetectFormat() error = %v, want *AmbiguousFormatError", err)
+				}
+				if len(ambiguous.Candidates) != len(tt.wantCandidates) {
+					t.Fatalf("candidates = %v, want %v", ambiguous.Candidates, tt.wantCandidates)
+				}
+				for i, candidate := range ambiguous.Candidates {
+					if candidate.Format != tt.wantCandidates[i] {
+						t.Errorf("candidate %d = %s, want %s", i, candidate.Format, tt.wantCandidates[i])
+					}
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("DetectFormat() error = %v", err)
+			}
+			if format != tt.want {
+				t.Errorf("DetectFormat() = %s, want %s", format, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigService_GetVersionGraph(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 1"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	config, err := service.CreateUserConfig(1, template.ID, "app", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	for _, content := range []string{"port: 2", "port: 3"} {
+		if _, err := service.UpdateUserConfig(config.ID, 1, content, "edit", nil); err != nil {
+			t.Fatalf("UpdateUserConfig() error = %v", err)
+		}
+	}
+
+	versions, _, _ := repo.GetConfigVersions(config.ID, models.DefaultConfigVersionSort, 1, 10)
+	first := versions[len(versions)-1]
+	restored, err := service.RestoreConfigVersion(config.ID, first.ID, 1)
+	if err != nil {
+		t.Fatalf("RestoreConfigVersion() error = %v", err)
+	}
+	if restored.Config.Content != "port: 1" {
+		t.Errorf("restored content = %q, want version 1 content", restored.Config.Content)
+	}
+	// Versions 1-3 existed, so the restore created version 4 from version 1
+	if restored.Version != 4 || restored.RestoredFromVersion != 1 || restored.RestoredFrom != first.ID {
+		t.Errorf("restore result = version %d from version %d (id %d), want version 4 from version 1 (id %d)",
+			restored.Version, restored.RestoredFromVersion, restored.RestoredFrom, first.ID)
+	}
+	if created, err := repo.GetConfigVersion(restored.VersionID); err != nil || created.Version != 4 ||
+		created.RestoredFrom == nil || *created.RestoredFrom != first.ID {
+		t.Errorf("created version = %+v (err %v), want version 4 restored from %d", created, err, first.ID)
+	}
+
+	nodes, err := service.GetVersionGraph(config.ID, 1)
+	if err != nil {
+		t.Fatalf("GetVersionGraph() error = %v", err)
+	}
+	if len(nodes) != 4 {
+		t.Fatalf("nodes = %d, want 4", len(nodes))
+	}
+	for i, node := range nodes {
+		if node.Version != i+1 {
+			t.Errorf("node %d version = %d, want %d", i, node.Version, i+1)
+		}
+		if i == 0 && node.Parent != nil {
+			t.Errorf("initial version parent = %d, want none", *node.Parent)
+		}
+		if i > 0 && (node.Parent == nil || *node.Parent != i) {
+			t.Errorf("version %d parent = %v, want %d", node.Version, node.Parent, i)
+		}
+	}
+	restore := nodes[3]
+	if restore.RestoredFrom == nil || *restore.RestoredFrom != 1 {
+		t.Errorf("restored_from = %v, want 1", restore.RestoredFrom)
+	}
+	for _, node := range nodes[:3] {
+		if node.RestoredFrom != nil {
+			t.Errorf("version %d restored_from = %d, want none", node.Version, *node.RestoredFrom)
+		}
+	}
+
+	if _, err := service.GetVersionGraph(config.ID, 2); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("other user error = %v, want unauthorized", err)
+	}
+}
+
+func TestConfigService_ListSort(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 1"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	var configIDs []int
+	for _, name := range []string{"beta", "alpha", "gamma"} {
+		config, err := service.CreateUserConfig(1, template.ID, name, nil)
+		if err != nil {
+			t.Fatalf("CreateUserConfig() error = %v", err)
+		}
+		configIDs = append(configIDs, config.ID)
+	}
+	for _, content := range []string{"port: 2", "port: 3"} {
+		if _, err := service.UpdateUserConfig(configIDs[0], 1, content, "edit", nil); err != nil {
+			t.Fatalf("UpdateUserConfig() error = %v", err)
+		}
+	}
+
+	configs, _, err := service.GetUserConfigs(1, nil, models.ListSort{Column: "name", Descending: true}, 1, 10)
+	if err != nil {
+		t.Fatalf("GetUserConfigs() error = %v", err)
+	}
+	var names []string
+	for _, config := range configs {
+		names = append(names, config.Name)
+	}
+	if got := strings.Join(names, ","); got != "gamma,beta,alpha" {
+		t.Errorf("configs by name desc = %s, want gamma,beta,alpha", got)
+	}
+
+	// The default version order is newest first; version numbering depends on it
+	for _, tt := range []struct {
+		order models.ListSort
+		want  []int
+	}{
+		{order: models.DefaultConfigVersionSort, want: []int{3, 2, 1}},
+		{order: models.ListSort{Column: "version"}, want: []int{1, 2, 3}},
+	} {
+		versions, _, err := service.GetConfigVersions(configIDs[0], 1, tt.order, 1, 10)
+		if err != nil {
+			t.Fatalf("GetConfigVersions() error = %v", err)
+		}
+		var got []int
+		for _, version := range versions {
+			got = append(got, version.Version)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("versions with %+v = %v, want %v", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestConfigService_CursorPagination(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 1"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	var configIDs []int
+	for _, name := range []string{"a", "b", "c"} {
+		config, err := service.CreateUserConfig(1, template.ID, name, nil)
+		if err != nil {
+			t.Fatalf("CreateUserConfig() error = %v", err)
+		}
+		configIDs = append(configIDs, config.ID)
+	}
+	for _, content := range []string{"port: 2", "port: 3", "port: 4", "port: 5"} {
+		if _, err := service.UpdateUserConfig(configIDs[0], 1, content, "edit", nil); err != nil {
+			t.Fatalf("UpdateUserConfig() error = %v", err)
+		}
+	}
+
+	t.Run("versions", func(t *testing.T) {
+		var got []int
+		cursor := ""
+		for pages := 0; ; pages++ {
+			if pages > 5 {
+				t.Fatal("pagination did not terminate")
+			}
+			versions, next, err := service.GetConfigVersionsByCursor(configIDs[0], 1, cursor, 2)
+			if err != nil {
+				t.Fatalf("GetConfigVersionsByCursor() error = %v", err)
+			}
+			for _, version := range versions {
+				got = append(got, version.Version)
+			}
+			if next == "" {
+				break
+			}
+			cursor = next
+
+			// A version saved mid-walk must not shift the remaining pages
+			if pages == 0 {
+				if _, err := service.UpdateUserConfig(configIDs[0], 1, "port: 6", "concurrent", nil); err != nil {
+					t.Fatalf("UpdateUserConfig() error = %v", err)
+				}
+			}
+		}
+		if want := []int{5, 4, 3, 2, 1}; !reflect.DeepEqual(got, want) {
+			t.Errorf("versions = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("configs", func(t *testing.T) {
+		configs, next, err := service.GetUserConfigsByCursor(1, nil, "", 2)
+		if err != nil {
+			t.Fatalf("GetUserConfigsByCursor() error = %v", err)
+		}
+		if len(configs) != 2 || configs[0].ID != configIDs[0] || next == "" {
+			t.Fatalf("first page = %d configs, next %q", len(configs), next)
+		}
+		configs, next, err = service.GetUserConfigsByCursor(1, nil, next, 2)
+		if err != nil {
+			t.Fatalf("GetUserConfigsByCursor() error = %v", err)
+		}
+		if len(configs) != 1 || configs[0].ID != configIDs[2] || next != "" {
+			t.Errorf("last page = %d configs, next %q; want config %d and no cursor", len(configs), next, configIDs[2])
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		if _, _, err := service.GetUserConfigsByCursor(1, nil, "bogus", 2); !errors.Is(err, utils.ErrInvalidCursor) {
+			t.Errorf("GetUserConfigsByCursor() error = %v, want ErrInvalidCursor", err)
+		}
+	})
+}
+
+func TestConfigService_VersionChangeSet(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{
+		Name:           "app",
+		Format:         models.FormatYAML,
+		DefaultContent: "name: app\nserver:\n  host: localhost\n  port: 8080\ndebug: true",
+	}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	config, err := service.CreateUserConfig(1, template.ID, "app", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+
+	updated := "name: app\nserver:\n  host: 0.0.0.0\n  port: 8080\n  tls: true\nlevel: info"
+	for _, content := range []string{updated, updated} {
+		if _, err := service.UpdateUserConfig(config.ID, 1, content, "edit", nil); err != nil {
+			t.Fatalf("UpdateUserConfig() error = %v", err)
+		}
+	}
+
+	versions, _, _ := repo.GetConfigVersions(config.ID, models.DefaultConfigVersionSort, 1, 10)
+	if len(versions) != 3 {
+		t.Fatalf("versions = %d, want 3", len(versions))
+	}
+	unchanged, edit, initial := versions[0], versions[1], versions[2]
+
+	if initial.ChangeSet != nil {
+		t.Errorf("initial change set = %+v, want none", initial.ChangeSet)
+	}
+	want := &models.ChangeSet{Added: 2, Removed: 1, Modified: 1, Keys: []string{"debug", "level", "server"}}
+	if !reflect.DeepEqual(edit.ChangeSet, want) {
+		t.Errorf("change set = %+v, want %+v", edit.ChangeSet, want)
+	}
+	if unchanged.ChangeSet != nil {
+		t.Errorf("unchanged content change set = %+v, want none", unchanged.ChangeSet)
+	}
+}
+
+func TestConfigService_PatchUserConfig(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{
+		Name:           "app",
+		Format:         models.FormatTOML,
+		DefaultContent: "debug = true\n\n[server]\nhost = \"localhost\"\nport = 8080\n",
+	}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	config, err := service.CreateUserConfig(1, template.ID, "app", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+
+	// null removes debug; server merges, keeping host and changing port
+	patch := `{"debug": null, "server": {"port": 9090, "tls": {"enabled": true}}}`
+	patched, err := service.PatchUserConfig(config.ID, 1, []byte(patch), "switch port")
+	if err != nil {
+		t.Fatalf("PatchUserConfig() error = %v", err)
+	}
+
+	data, err := service.parser.ParseConfig(patched.Content, models.FormatTOML)
+	if err != nil {
+		t.Fatalf("patched content doesn't parse: %v\n%s", err, patched.Content)
+	}
+	want := map[string]interface{}{
+		"server": map[string]interface{}{
+			"host": "localhost",
+			"port": int64(9090),
+			"tls":  map[string]interface{}{"enabled": true},
+		},
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("patched data = %v, want %v", data, want)
+	}
+
+	versions, _, _ := repo.GetConfigVersions(config.ID, models.DefaultConfigVersionSort, 1, 10)
+	if len(versions) != 2 || versions[0].ChangeNote != "switch port" {
+		t.Errorf("versions = %d, latest note %q; want a new version noted \"switch port\"", len(versions), versions[0].ChangeNote)
+	}
+
+	// A patch that isn't an object fails validation and changes nothing
+	if _, err := service.PatchUserConfig(config.ID, 1, []byte(`"x"`), ""); err == nil || !strings.Contains(err.Error(), "validation failed") {
+		t.Errorf("PatchUserConfig() with a string error = %v, want validation failure", err)
+	}
+	if _, err := service.PatchUserConfig(config.ID, 2, []byte(`{}`), ""); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("PatchUserConfig() by another user error = %v, want unauthorized", err)
+	}
+}
+
+func TestConfigService_SecretWarnings(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []ConfigServiceOption
+		wantWarnings []string
+	}{
+		{
+			name:         "scan enabled by default",
+			wantWarnings: []string{"database.password looks like a secret; consider a secret reference"},
+		},
+		{name: "scan disabled", opts: []ConfigServiceOption{WithSecretScan(false)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockConfigRepository()
+			service := NewConfigService(repo, tt.opts...)
+
+			template := &models.ConfigTemplate{Name: "db", Format: models.FormatYAML, DefaultContent: "database:\n  host: localhost"}
+			if err := service.CreateTemplate(template); err != nil {
+				t.Fatalf("CreateTemplate() error = %v", err)
+			}
+			config, err := service.CreateUserConfig(1, template.ID, "db", nil)
+			if err != nil {
+				t.Fatalf("CreateUserConfig() error = %v", err)
+			}
+			if len(config.Warnings) != 0 {
+				t.Errorf("create warnings = %v, want none", config.Warnings)
+			}
+
+			updated, err := service.UpdateUserConfig(config.ID, 1, "database:\n  host: localhost\n  password: hunter2", "add password", nil)
+			if err != nil {
+				t.Fatalf("UpdateUserConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(updated.Warnings, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", updated.Warnings, tt.wantWarnings)
+			}
+
+			// Warnings are advisory and never block the save
+			stored, _ := repo.GetUserConfig(config.ID)
+			if !strings.Contains(stored.Content, "password") {
+				t.Error("content with secrets should still be saved")
+			}
+		})
+	}
+}
+
+// mapSecretSource serves secrets from a map
+type mapSecretSource map[string]string
+
+func (m mapSecretSource) LookupSecret(name string) (string, bool) {
+	value, ok := m[name]
+	return value, ok
+}
+
+func TestConfigService_ExportConfig_ResolveSecrets(t *testing.T) {
+	repo := NewMockConfigRepository()
+	stored := &models.UserConfig{
+		UserID:  1,
+		Name:    "db",
+		Format:  models.FormatYAML,
+		Content: "# comment\npassword: ${secret:DB_PASSWORD}\n",
+	}
+	_ = repo.CreateUserConfig(stored)
+
+	tests := []struct {
+		name       string
+		secrets    mapSecretSource
+		resolve    bool
+		format     models.ConfigFormat
+		want       string
+		wantErrStr string
+	}{
+		{
+			name:    "placeholders kept without resolution",
+			secrets: mapSecretSource{"DB_PASSWORD": "hunter2"},
+			format:  models.FormatYAML,
+			want:    stored.Content,
+		},
+		{
+			name:    "resolved into target format",
+			secrets: mapSecretSource{"DB_PASSWORD": `hun"ter2`},
+			resolve: true,
+			format:  models.FormatJSON,
+			want:    `"password": "hun\"ter2"`,
+		},
+		{
+			name:       "missing secret rejects export",
+			secrets:    mapSecretSource{},
+			resolve:    true,
+			format:     models.FormatYAML,
+			wantErrStr: "unresolved secrets: DB_PASSWORD",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewConfigService(repo, WithSecretSource(tt.secrets))
+
+			content, err := service.ExportConfig(stored.ID, 1, tt.format, tt.resolve)
+			if tt.wantErrStr != "" {
+				if err == nil || err.Error() != tt.wantErrStr {
+					t.Errorf("ExportConfig() error = %v, want %q", err, tt.wantErrStr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExportConfig() error = %v", err)
+			}
+			if !strings.Contains(content, tt.want) {
+				t.Errorf("ExportConfig() = %q, want it to contain %q", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigService_ConvertBatch(t *testing.T) {
+	service := NewConfigService(NewMockConfigRepository())
+
+	t.Run("partial failure", func(t *testing.T) {
+		items := []models.ConvertBatchItem{
+			{Content: `{"port": 8080}`, From: models.FormatJSON, To: models.FormatYAML},
+			{Content: `{not json`, From: models.FormatJSON, To: models.FormatYAML},
+			{Content: "PORT=8080", From: models.FormatENV, To: models.FormatJSON},
+		}
+
+		response, err := service.ConvertBatch(items)
+		if err != nil {
+			t.Fatalf("ConvertBatch() error = %v", err)
+		}
+		if response.Succeeded != 2 || response.Failed != 1 {
+			t.Errorf("summary = %d ok / %d failed, want 2 / 1", response.Succeeded, response.Failed)
+		}
+		for i, result := range response.Results {
+			if result.Index != i {
+				t.Errorf("result %d index = %d", i, result.Index)
+			}
+		}
+		if response.Results[0].Output != "port: 8080\n" {
+			t.Errorf("item 0 output = %q", response.Results[0].Output)
+		}
+		if response.Results[1].Error == "" || response.Results[1].Output != "" {
+			t.Errorf("item 1 = %+v, want an error", response.Results[1])
+		}
+		if !strings.Contains(response.Results[2].Output, `"PORT": "8080"`) {
+			t.Errorf("item 2 output = %q", response.Results[2].Output)
+		}
+	})
+
+	t.Run("batch limits", func(t *testing.T) {
+		if _, err := service.ConvertBatch(nil); err == nil {
+			t.Error("expected error for empty batch")
+		}
+		tooMany := make([]models.ConvertBatchItem, maxConvertBatchSize+1)
+		if _, err := service.ConvertBatch(tooMany); err == nil || !strings.Contains(err.Error(), "exceeds") {
+			t.Errorf("error = %v, want batch size error", err)
+		}
+	})
+}
+
+func TestConfigService_RecordsActivity(t *testing.T) {
+	repo := NewMockConfigRepository()
+	auditService := NewAuditService(&MockAuditRepository{})
+	service := NewConfigService(repo, WithAuditLog(auditService))
+
+	template := &models.ConfigTemplate{Name: "base", Format: models.FormatYAML, DefaultContent: "port: 1"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	config, err := service.CreateUserConfig(1, template.ID, "app", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	if _, err := service.UpdateUserConfig(config.ID, 1, "port: 2", "bump port", nil); err != nil {
+		t.Fatalf("UpdateUserConfig() error = %v", err)
+	}
+	versions, _, _ := repo.GetConfigVersions(config.ID, models.DefaultConfigVersionSort, 1, 10)
+	if _, err := service.RestoreConfigVersion(config.ID, versions[len(versions)-1].ID, 1); err != nil {
+		t.Fatalf("RestoreConfigVersion() error = %v", err)
+	}
+
+	// Another user's activity never shows up in the feed
+	if _, err := service.CreateUserConfig(2, template.ID, "other", nil); err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+
+	entries, total, err := auditService.ListActivity(context.Background(), 1, 1, 2)
+	if err != nil {
+		t.Fatalf("ListActivity() error = %v", err)
+	}
+	if total != 3 || len(entries) != 2 {
+		t.Fatalf("ListActivity() = %d entries, total %d; want 2 and 3", len(entries), total)
+	}
+
+	want := []struct{ action, details string }{
+		{models.AuditConfigRestored, `Restored "app" to version 1`},
+		{models.AuditConfigUpdated, `Updated "app": bump port`},
+	}
+	for i, entry := range entries {
+		if entry.Action != want[i].action || entry.Details != want[i].details {
+			t.Errorf("entry %d = %s %q, want %s %q", i, entry.Action, entry.Details, want[i].action, want[i].details)
+		}
+		if entry.TargetConfigID == nil || *entry.TargetConfigID != config.ID || entry.TargetName != "app" {
+			t.Errorf("entry %d target = %v %q, want %d app", i, entry.TargetConfigID, entry.TargetName, config.ID)
+		}
+	}
+
+	older, _, _ := auditService.ListActivity(context.Background(), 1, 2, 2)
+	if len(older) != 1 || older[0].Details != `Created "app" from template "base"` {
+		t.Errorf("page 2 = %+v, want the creation entry", older)
+	}
+}
+
+func TestConfigService_EmptyListsAreNotNil(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 8080\n"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+
+	if configs, _, err := service.GetUserConfigs(1, nil, models.DefaultUserConfigSort, 1, 10); err != nil || configs == nil {
+		t.Errorf("GetUserConfigs() = %v, %v, want an empty slice", configs, err)
+	}
+	if configs, _, err := service.GetUserConfigsByCursor(1, nil, "", 10); err != nil || configs == nil {
+		t.Errorf("GetUserConfigsByCursor() = %v, %v, want an empty slice", configs, err)
+	}
+	if templates, _, err := service.GetTemplates("none", "", 1, 10); err != nil || templates == nil {
+		t.Errorf("GetTemplates() = %v, %v, want an empty slice", templates, err)
+	}
+	if versions, err := service.GetTemplateVersions(template.ID); err != nil || versions == nil {
+		t.Errorf("GetTemplateVersions() = %v, %v, want an empty slice", versions, err)
+	}
+	if dependents, _, err := service.GetTemplateDependents(template.ID, true, 1, 10); err != nil || dependents == nil {
+		t.Errorf("GetTemplateDependents() = %v, %v, want an empty slice", dependents, err)
+	}
+}
+
+func TestConfigService_CreateUserConfigVariables(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{
+		Name:           "cross-seed",
+		Format:         models.FormatYAML,
+		DefaultContent: "delay: 30\ntorrentDir: \"\"\nclient:\n  url: http://localhost\n",
+	}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	defaultURL, defaultKey := "http://qbittorrent:8080", "changeme"
+	delayRule := `^[0-9]+$`
+	for _, variable := range []*models.ConfigVariable{
+		{TemplateID: template.ID, Name: "TORRENT_DIR", Path: "torrentDir", Type: "string", Required: true},
+		{TemplateID: template.ID, Name: "CLIENT_URL", Path: "client.url", Type: "string", DefaultValue: &defaultURL},
+		{TemplateID: template.ID, Name: "API_KEY", Path: "apiKey", Type: "string", Required: true, DefaultValue: &defaultKey},
+		{TemplateID: template.ID, Name: "DELAY", Path: "delay", Type: "number", ValidationRule: &delayRule},
+	} {
+		repo.AddVariable(variable)
+	}
+
+	tests := []struct {
+		name    string
+		values  map[string]string
+		wantErr string
+		want    map[string]interface{} // Expected values by path
+	}{
+		{
+			name:   "values and defaults",
+			values: map[string]string{"TORRENT_DIR": "/data", "API_KEY": "secret", "DELAY": "10"},
+			want:   map[string]interface{}{"torrentDir": "/data", "client.url": defaultURL, "apiKey": "secret", "delay": 10},
+		},
+		{
+			name:    "required variable missing",
+			values:  map[string]string{"API_KEY": "secret", "DELAY": "10"},
+			wantErr: "missing required variables: TORRENT_DIR",
+		},
+		{
+			name:    "required variable with a default not supplied",
+			values:  map[string]string{"TORRENT_DIR": "/data"},
+			wantErr: "missing required variables: API_KEY",
+		},
+		{
+			name:    "required variable left at its default",
+			values:  map[string]string{"TORRENT_DIR": "/data", "API_KEY": defaultKey},
+			wantErr: "missing required variables: API_KEY",
+		},
+		{
+			name:    "value fails validation rule",
+			values:  map[string]string{"TORRENT_DIR": "/data", "DELAY": "-5"},
+			wantErr: `variable DELAY: "-5" does not match ^[0-9]+$`,
+		},
+		{
+			name:    "unknown variable",
+			values:  map[string]string{"TORRENT_DIR": "/data", "PORT": "80"},
+			wantErr: "unknown variables: PORT",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(repo.configs)
+			userConfig, err := service.CreateUserConfig(1, template.ID, fmt.Sprintf("config-%d", i), tt.values)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), "validation failed") || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("CreateUserConfig() error = %v, want validation failed: %s", err, tt.wantErr)
+				}
+				if len(repo.configs) != before {
+					t.Error("CreateUserConfig() stored a configuration despite invalid variables")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateUserConfig() error = %v", err)
+			}
+
+			data, err := service.parser.ParseConfig(userConfig.Content, models.FormatYAML)
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+			for path, want := range tt.want {
+				if got, _ := config.LookupPath(data, path); got != want {
+					t.Errorf("%s = %v (%T), want %v (%T)", path, got, got, want, want)
+				}
+			}
+
+			// A configuration that could be created also passes validation
+			if _, err := service.ValidateUserConfig(userConfig.ID, 1, ValidateOptions{}); err != nil {
+				t.Errorf("ValidateUserConfig() error = %v", err)
+			}
+		})
+	}
+}
@@ -4,8 +4,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"time"
 
@@ -13,17 +14,26 @@ import (
 	"conflux/pkg/jwt"
 )
 
+// Development user credentials, shared with the 004_seed_dev_user migration
+// Migration 019_align_dev_user_password moves seeded accounts to this password
+const (
+	DevUserEmail    = "dev@conflux.local"
+	DevUserPassword = "password123"
+)
+
 // DevService provides development-specific utilities
 type DevService struct {
 	userService *UserService
 	authService *AuthService
+	logger      *slog.Logger
 }
 
 // NewDevService creates a new development service
-func NewDevService(userService *UserService, authService *AuthService) *DevService {
+func NewDevService(userService *UserService, authService *AuthService, logger *slog.Logger) *DevService {
 	return &DevService{
 		userService: userService,
 		authService: authService,
+		logger:      logger,
 	}
 }
 
@@ -34,7 +44,7 @@ func (s *DevService) GetDevToken(ctx context.Context) (string, error) {
 	}
 
 	// Get the development user
-	user, err := s.userService.GetUserByEmail(ctx, "dev@conflux.local")
+	user, err := s.userService.GetUserByEmail(ctx, DevUserEmail)
 	if err != nil {
 		return "", fmt.Errorf("development user not found: %w", err)
 	}
@@ -46,7 +56,7 @@ func (s *DevService) GetDevToken(ctx context.Context) (string, error) {
 	}
 
 	tokenManager := jwt.NewTokenManager(jwtSecret, "conflux-dev")
-	token, err := tokenManager.GenerateToken(user.ID, user.Email, 24*time.Hour)
+	token, err := tokenManager.GenerateTokenWithRole(user.ID, user.Email, user.Role, 24*time.Hour)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate dev token: %w", err)
 	}
@@ -55,31 +65,43 @@ func (s *DevService) GetDevToken(ctx context.Context) (string, error) {
 }
 
 // CreateDevUser ensures the development user exists (fallback if migration doesn't run)
+// Idempotent: concurrent calls, or a seeding migration racing with one, all succeed
+// and leave a single verified dev user
 func (s *DevService) CreateDevUser(ctx context.Context) error {
 	if os.Getenv("ENVIRONMENT") != "development" {
 		return fmt.Errorf("dev user creation only available in development environment")
 	}
 
 	// Check if dev user already exists
-	_, err := s.userService.GetUserByEmail(ctx, "dev@conflux.local")
+	_, err := s.userService.GetUserByEmail(ctx, DevUserEmail)
 	if err == nil {
-		log.Println("Development user already exists")
+		s.logger.Debug("Development user already exists")
 		return nil
 	}
 
 	// Create the development user
 	req := &models.RegisterRequest{
-		Email:     "dev@conflux.local",
-		Password:  "password123",
+		Email:     DevUserEmail,
+		Password:  DevUserPassword,
 		FirstName: "Dev",
 		LastName:  "User",
 	}
 
-	_, err = s.userService.CreateUser(ctx, req)
+	user, err := s.userService.CreateUser(ctx, req)
+	if errors.Is(err, models.ErrEmailTaken) {
+		// Created by a concurrent request or the seed migration since the check above
+		s.logger.Debug("Development user created concurrently")
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create dev user: %w", err)
 	}
 
-	log.Println("Development user created: dev@conflux.local / password123")
+	// The dev user has no real inbox, so skip email verification
+	if err := s.userService.MarkEmailVerified(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to create dev user: %w", err)
+	}
+
+	s.logger.Info("Development user created", "email", DevUserEmail, "password", DevUserPassword)
 	return nil
 }
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+// racingUserRepository is a concurrency-safe user store whose first lookups all
+// miss until every caller has made one, so each caller passes the existence check
+// before any of them creates the user. Create enforces unique emails like the database
+type racingUserRepository struct {
+	UserRepository
+
+	mu      sync.Mutex
+	users   map[string]*models.User
+	waiting int
+	release chan struct{}
+}
+
+func newRacingUserRepository(callers int) *racingUserRepository {
+	return &racingUserRepository{users: make(map[string]*models.User), waiting: callers, release: make(chan struct{})}
+}
+
+func (r *racingUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.Lock()
+	if r.waiting > 0 {
+		r.waiting--
+		if r.waiting == 0 {
+			close(r.release)
+		}
+		r.mu.Unlock()
+		<-r.release
+		return nil, errors.New("user not found")
+	}
+	defer r.mu.Unlock()
+	user, ok := r.users[email]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	userCopy := *user
+	return &userCopy, nil
+}
+
+func (r *racingUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[user.Email]; ok {
+		return models.ErrEmailTaken
+	}
+	user.ID = len(r.users) + 1
+	userCopy := *user
+	r.users[user.Email] = &userCopy
+	return nil
+}
+
+func (r *racingUserRepository) MarkEmailVerified(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.ID == userID {
+			user.EmailVerified = true
+		}
+	}
+	return nil
+}
+
+func TestDevService_CreateDevUser_Concurrent(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "development")
+
+	const callers = 8
+	repo := newRacingUserRepository(callers)
+	service := NewDevService(NewUserService(repo), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	errs := make(chan error, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- service.CreateDevUser(context.Background())
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("CreateDevUser() error = %v", err)
+		}
+	}
+	if len(repo.users) != 1 {
+		t.Fatalf("dev users = %d, want 1", len(repo.users))
+	}
+	if user := repo.users[DevUserEmail]; user == nil || !user.EmailVerified {
+		t.Errorf("dev user = %+v, want a verified %s", user, DevUserEmail)
+	}
+}
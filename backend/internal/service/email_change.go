@@ -0,0 +1,193 @@
+// Email change service layer
+// Changes a user's email in two steps: request, then confirm with a token sent to the new address
+// The current email stays active until confirmation, guarding against typos and account takeover
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"conflux/internal/models"
+)
+
+// emailChangeTTL is how long an email change token stays valid
+const emailChangeTTL = 24 * time.Hour
+
+// EmailChangeRepository defines data access methods for pending email changes
+type EmailChangeRepository interface {
+	Create(ctx context.Context, change *models.EmailChange) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.EmailChange, error)
+	DeleteForUser(ctx context.Context, userID int) error
+}
+
+// EmailSender delivers verification messages to users
+type EmailSender interface {
+	SendEmailChangeVerification(ctx context.Context, to, token string) error
+	SendEmailVerification(ctx context.Context, to, token string) error
+}
+
+// LogEmailSender logs verification tokens instead of sending mail
+// Used until a mail provider is configured; suitable for development only
+type LogEmailSender struct {
+	logger *slog.Logger
+}
+
+// NewLogEmailSender creates an email sender that writes to the logger
+func NewLogEmailSender(logger *slog.Logger) *LogEmailSender {
+	return &LogEmailSender{logger: logger}
+}
+
+// SendEmailChangeVerification logs the token for the new address
+func (s *LogEmailSender) SendEmailChangeVerification(ctx context.Context, to, token string) error {
+	s.logger.Info("Email change verification", "to", to, "token", token)
+	return nil
+}
+
+// SendEmailVerification logs the token for a newly registered address
+func (s *LogEmailSender) SendEmailVerification(ctx context.Context, to, token string) error {
+	s.logger.Info("Email verification", "to", to, "token", token)
+	return nil
+}
+
+// EmailChangeService handles email change business logic
+type EmailChangeService struct {
+	userRepo     UserRepository
+	changeRepo   EmailChangeRepository
+	sender       EmailSender
+	auditService *AuditService
+	now          func() time.Time
+}
+
+// NewEmailChangeService creates an email change service with its dependencies
+func NewEmailChangeService(
+	userRepo UserRepository, changeRepo EmailChangeRepository, sender EmailSender, auditService *AuditService,
+) *EmailChangeService {
+	return &EmailChangeService{
+		userRepo:     userRepo,
+		changeRepo:   changeRepo,
+		sender:       sender,
+		auditService: auditService,
+		now:          time.Now,
+	}
+}
+
+// RequestEmailChange stores a pending change and sends a token to the new address
+// Any earlier pending change for the user is replaced
+func (s *EmailChangeService) RequestEmailChange(ctx context.Context, userID int, req *models.EmailChangeRequest) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if strings.EqualFold(user.Email, req.NewEmail) {
+		return fmt.Errorf("validation failed: new email matches the current email")
+	}
+	if err := s.checkEmailAvailable(ctx, req.NewEmail); err != nil {
+		return err
+	}
+
+	token, err := generateVerificationToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	if err := s.changeRepo.DeleteForUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to clear pending email change: %w", err)
+	}
+	change := &models.EmailChange{
+		UserID:    userID,
+		NewEmail:  req.NewEmail,
+		TokenHash: hashVerificationToken(token),
+		ExpiresAt: s.now().Add(emailChangeTTL),
+	}
+	if err := s.changeRepo.Create(ctx, change); err != nil {
+		return fmt.Errorf("failed to store email change: %w", err)
+	}
+
+	if err := s.sender.SendEmailChangeVerification(ctx, req.NewEmail, token); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange applies the pending change matching the token
+// The token must belong to the caller and be unexpired
+func (s *EmailChangeService) ConfirmEmailChange(
+	ctx context.Context, userID int, req *models.ConfirmEmailChangeRequest, ipAddress string,
+) (*models.User, error) {
+	token := strings.TrimSpace(req.Token)
+	if token == "" {
+		return nil, fmt.Errorf("validation failed: token is required")
+	}
+
+	change, err := s.changeRepo.GetByTokenHash(ctx, hashVerificationToken(token))
+	if err != nil || change.UserID != userID || !s.now().Before(change.ExpiresAt) {
+		return nil, fmt.Errorf("invalid or expired email change token")
+	}
+
+	// The address may have been taken since the change was requested
+	if err := s.checkEmailAvailable(ctx, change.NewEmail); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	oldEmail := user.Email
+	user.Email = change.NewEmail
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update email: %w", err)
+	}
+
+	if err := s.changeRepo.DeleteForUser(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to clear pending email change: %w", err)
+	}
+
+	if err := s.auditService.Record(ctx, &models.AuditEntry{
+		ActorID:      userID,
+		Action:       models.AuditEmailChanged,
+		TargetUserID: &userID,
+		IPAddress:    ipAddress,
+		Details:      fmt.Sprintf("changed email from %s to %s", oldEmail, change.NewEmail),
+	}); err != nil {
+		return nil, err
+	}
+
+	user.Password = ""
+	return user, nil
+}
+
+// checkEmailAvailable fails if another account already uses the address
+func (s *EmailChangeService) checkEmailAvailable(ctx context.Context, email string) error {
+	existingUser, err := s.userRepo.GetByEmail(ctx, email)
+	if err == nil && existingUser != nil {
+		return fmt.Errorf("email already exists")
+	}
+	return nil
+}
+
+// generateVerificationToken returns a new random token for emailed verification links
+func generateVerificationToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashVerificationToken returns the stored hash for a verification token
+// SHA-256 is sufficient because tokens are long random values, and it allows lookup by hash
+func hashVerificationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
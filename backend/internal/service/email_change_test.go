@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+)
+
+// MockEmailChangeRepository stores pending email changes in memory
+type MockEmailChangeRepository struct {
+	changes []*models.EmailChange
+	nextID  int
+}
+
+func (m *MockEmailChangeRepository) Create(ctx context.Context, change *models.EmailChange) error {
+	m.nextID++
+	change.ID = m.nextID
+	changeCopy := *change
+	m.changes = append(m.changes, &changeCopy)
+	return nil
+}
+
+func (m *MockEmailChangeRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.EmailChange, error) {
+	for _, change := range m.changes {
+		if change.TokenHash == tokenHash {
+			changeCopy := *change
+			return &changeCopy, nil
+		}
+	}
+	return nil, errors.New("email change not found")
+}
+
+func (m *MockEmailChangeRepository) DeleteForUser(ctx context.Context, userID int) error {
+	kept := m.changes[:0]
+	for _, change := range m.changes {
+		if change.UserID != userID {
+			kept = append(kept, change)
+		}
+	}
+	m.changes = kept
+	return nil
+}
+
+// recordingEmailSender keeps the last token sent to each address
+type recordingEmailSender struct {
+	tokens map[string]string
+	err    error
+}
+
+func (s *recordingEmailSender) SendEmailChangeVerification(ctx context.Context, to, token string) error {
+	s.tokens[to] = token
+	return nil
+}
+
+func (s *recordingEmailSender) SendEmailVerification(ctx context.Context, to, token string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.tokens[to] = token
+	return nil
+}
+
+type emailChangeFixture struct {
+	service   *EmailChangeService
+	users     *MockUserRepository
+	changes   *MockEmailChangeRepository
+	sender    *recordingEmailSender
+	auditRepo *MockAuditRepository
+	userID    int
+}
+
+func newEmailChangeFixture(t *testing.T) *emailChangeFixture {
+	t.Helper()
+	f := &emailChangeFixture{
+		users:     NewMockUserRepository(),
+		changes:   &MockEmailChangeRepository{},
+		sender:    &recordingEmailSender{tokens: make(map[string]string)},
+		auditRepo: &MockAuditRepository{},
+	}
+	f.service = NewEmailChangeService(f.users, f.changes, f.sender, NewAuditService(f.auditRepo))
+
+	for _, email := range []string{"old@example.com", "taken@example.com"} {
+		user := &models.User{Email: email, FirstName: "Test", LastName: "User"}
+		if err := f.users.Create(context.Background(), user); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if f.userID == 0 {
+			f.userID = user.ID
+		}
+	}
+	return f
+}
+
+func (f *emailChangeFixture) email(t *testing.T) string {
+	t.Helper()
+	user, err := f.users.GetByID(context.Background(), f.userID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	return user.Email
+}
+
+func TestEmailChangeService_RequestAndConfirm(t *testing.T) {
+	ctx := context.Background()
+	f := newEmailChangeFixture(t)
+
+	if err := f.service.RequestEmailChange(ctx, f.userID, &models.EmailChangeRequest{NewEmail: " new@example.com "}); err != nil {
+		t.Fatalf("RequestEmailChange() error = %v", err)
+	}
+	token := f.sender.tokens["new@example.com"]
+	if token == "" {
+		t.Fatal("no verification token sent to the new address")
+	}
+	if f.changes.changes[0].TokenHash == token {
+		t.Error("token stored in plaintext")
+	}
+
+	// Until confirmed, the old email stays active
+	if got := f.email(t); got != "old@example.com" {
+		t.Errorf("email before confirm = %q, want old@example.com", got)
+	}
+
+	user, err := f.service.ConfirmEmailChange(ctx, f.userID, &models.ConfirmEmailChangeRequest{Token: token}, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("ConfirmEmailChange() error = %v", err)
+	}
+	if user.Email != "new@example.com" || f.email(t) != "new@example.com" {
+		t.Errorf("email after confirm = %q, want new@example.com", user.Email)
+	}
+	if len(f.changes.changes) != 0 {
+		t.Errorf("pending changes = %d, want 0", len(f.changes.changes))
+	}
+	if len(f.auditRepo.entries) != 1 || f.auditRepo.entries[0].Action != models.AuditEmailChanged {
+		t.Errorf("audit entries = %+v, want one %s entry", f.auditRepo.entries, models.AuditEmailChanged)
+	}
+
+	// Tokens are single-use
+	if _, err := f.service.ConfirmEmailChange(ctx, f.userID, &models.ConfirmEmailChangeRequest{Token: token}, ""); err == nil {
+		t.Error("ConfirmEmailChange() reused token, want error")
+	}
+}
+
+func TestEmailChangeService_RequestRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		newEmail string
+		wantErr  string
+	}{
+		{name: "invalid address", newEmail: "not-an-email", wantErr: "validation failed"},
+		{name: "empty address", newEmail: "  ", wantErr: "validation failed"},
+		{name: "same address", newEmail: "OLD@example.com", wantErr: "validation failed"},
+		{name: "address in use", newEmail: "taken@example.com", wantErr: "already exists"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newEmailChangeFixture(t)
+			err := f.service.RequestEmailChange(context.Background(), f.userID, &models.EmailChangeRequest{NewEmail: tt.newEmail})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("RequestEmailChange() error = %v, want %q", err, tt.wantErr)
+			}
+			if len(f.changes.changes) != 0 || len(f.sender.tokens) != 0 {
+				t.Error("rejected request stored a change or sent a token")
+			}
+		})
+	}
+}
+
+func TestEmailChangeService_ConfirmRejected(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("expired token", func(t *testing.T) {
+		f := newEmailChangeFixture(t)
+		now := time.Now()
+		f.service.now = func() time.Time { return now }
+		if err := f.service.RequestEmailChange(ctx, f.userID, &models.EmailChangeRequest{NewEmail: "new@example.com"}); err != nil {
+			t.Fatalf("RequestEmailChange() error = %v", err)
+		}
+
+		now = now.Add(emailChangeTTL)
+		req := &models.ConfirmEmailChangeRequest{Token: f.sender.tokens["new@example.com"]}
+		if _, err := f.service.ConfirmEmailChange(ctx, f.userID, req, ""); err == nil || !strings.Contains(err.Error(), "invalid or expired") {
+			t.Errorf("ConfirmEmailChange() error = %v, want invalid or expired", err)
+		}
+		if got := f.email(t); got != "old@example.com" {
+			t.Errorf("email = %q, want unchanged", got)
+		}
+	})
+
+	t.Run("another user's token", func(t *testing.T) {
+		f := newEmailChangeFixture(t)
+		if err := f.service.RequestEmailChange(ctx, f.userID, &models.EmailChangeRequest{NewEmail: "new@example.com"}); err != nil {
+			t.Fatalf("RequestEmailChange() error = %v", err)
+		}
+
+		req := &models.ConfirmEmailChangeRequest{Token: f.sender.tokens["new@example.com"]}
+		if _, err := f.service.ConfirmEmailChange(ctx, f.userID+1, req, ""); err == nil {
+			t.Error("ConfirmEmailChange() accepted another user's token")
+		}
+	})
+
+	t.Run("superseded token", func(t *testing.T) {
+		f := newEmailChangeFixture(t)
+		for _, email := range []string{"first@example.com", "second@example.com"} {
+			if err := f.service.RequestEmailChange(ctx, f.userID, &models.EmailChangeRequest{NewEmail: email}); err != nil {
+				t.Fatalf("RequestEmailChange() error = %v", err)
+			}
+		}
+
+		req := &models.ConfirmEmailChangeRequest{Token: f.sender.tokens["first@example.com"]}
+		if _, err := f.service.ConfirmEmailChange(ctx, f.userID, req, ""); err == nil {
+			t.Error("ConfirmEmailChange() accepted a superseded token")
+		}
+	})
+
+	t.Run("address taken after request", func(t *testing.T) {
+		f := newEmailChangeFixture(t)
+		if err := f.service.RequestEmailChange(ctx, f.userID, &models.EmailChangeRequest{NewEmail: "new@example.com"}); err != nil {
+			t.Fatalf("RequestEmailChange() error = %v", err)
+		}
+		if err := f.users.Create(ctx, &models.User{Email: "new@example.com", FirstName: "Other", LastName: "User"}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+
+		req := &models.ConfirmEmailChangeRequest{Token: f.sender.tokens["new@example.com"]}
+		if _, err := f.service.ConfirmEmailChange(ctx, f.userID, req, ""); err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("ConfirmEmailChange() error = %v, want already exists", err)
+		}
+	})
+}
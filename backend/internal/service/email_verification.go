@@ -0,0 +1,125 @@
+// Email verification service layer
+// Confirms that newly registered users own their email address
+// Only enforced when REQUIRE_EMAIL_VERIFICATION is enabled; existing accounts count as verified
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"conflux/internal/models"
+)
+
+const (
+	// emailVerificationTTL is how long a verification token stays valid
+	emailVerificationTTL = 48 * time.Hour
+
+	// verificationResendCooldown is the minimum gap between tokens for one user
+	verificationResendCooldown = time.Minute
+)
+
+// EmailVerificationRepository defines data access methods for verification tokens
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, verification *models.EmailVerification) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.EmailVerification, error)
+	GetLatestForUser(ctx context.Context, userID int) (*models.EmailVerification, error)
+	DeleteForUser(ctx context.Context, userID int) error
+}
+
+// EmailVerificationService handles email verification business logic
+type EmailVerificationService struct {
+	userRepo         UserRepository
+	verificationRepo EmailVerificationRepository
+	sender           EmailSender
+	now              func() time.Time
+}
+
+// NewEmailVerificationService creates an email verification service with its dependencies
+func NewEmailVerificationService(
+	userRepo UserRepository, verificationRepo EmailVerificationRepository, sender EmailSender,
+) *EmailVerificationService {
+	return &EmailVerificationService{
+		userRepo:         userRepo,
+		verificationRepo: verificationRepo,
+		sender:           sender,
+		now:              time.Now,
+	}
+}
+
+// SendVerification issues a fresh token for the user and emails it
+// Earlier tokens for the user stop working
+func (s *EmailVerificationService) SendVerification(ctx context.Context, user *models.User) error {
+	token, err := generateVerificationToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	if err := s.verificationRepo.DeleteForUser(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to clear verification tokens: %w", err)
+	}
+	verification := &models.EmailVerification{
+		UserID:    user.ID,
+		TokenHash: hashVerificationToken(token),
+		ExpiresAt: s.now().Add(emailVerificationTTL),
+	}
+	if err := s.verificationRepo.Create(ctx, verification); err != nil {
+		return fmt.Errorf("failed to store verification: %w", err)
+	}
+
+	if err := s.sender.SendEmailVerification(ctx, user.Email, token); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail marks the token's user as verified
+func (s *EmailVerificationService) VerifyEmail(ctx context.Context, req *models.VerifyEmailRequest) (*models.User, error) {
+	token := strings.TrimSpace(req.Token)
+	if token == "" {
+		return nil, fmt.Errorf("validation failed: token is required")
+	}
+
+	verification, err := s.verificationRepo.GetByTokenHash(ctx, hashVerificationToken(token))
+	if err != nil || !s.now().Before(verification.ExpiresAt) {
+		return nil, fmt.Errorf("invalid or expired verification token")
+	}
+
+	if err := s.userRepo.MarkEmailVerified(ctx, verification.UserID); err != nil {
+		return nil, fmt.Errorf("failed to verify email: %w", err)
+	}
+	if err := s.verificationRepo.DeleteForUser(ctx, verification.UserID); err != nil {
+		return nil, fmt.Errorf("failed to clear verification tokens: %w", err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, verification.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	user.Password = ""
+	return user, nil
+}
+
+// ResendVerification sends a new token to an unverified account
+// Unknown and already-verified addresses succeed silently so the endpoint
+// can't be used to discover which emails are registered
+func (s *EmailVerificationService) ResendVerification(ctx context.Context, req *models.ResendVerificationRequest) error {
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
+		return fmt.Errorf("validation failed: email is required")
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil || user.EmailVerified {
+		return nil
+	}
+
+	if latest, err := s.verificationRepo.GetLatestForUser(ctx, user.ID); err == nil {
+		if wait := latest.CreatedAt.Add(verificationResendCooldown).Sub(s.now()); wait > 0 {
+			return fmt.Errorf("too many verification requests; retry in %ds", int(wait.Seconds())+1)
+		}
+	}
+
+	return s.SendVerification(ctx, user)
+}
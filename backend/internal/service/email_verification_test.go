@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+)
+
+// MockEmailVerificationRepository stores verification tokens in memory
+// CreatedAt is stamped from now, standing in for the database default
+type MockEmailVerificationRepository struct {
+	verifications []*models.EmailVerification
+	nextID        int
+	now           func() time.Time
+}
+
+func (m *MockEmailVerificationRepository) Create(ctx context.Context, verification *models.EmailVerification) error {
+	m.nextID++
+	verification.ID = m.nextID
+	verification.CreatedAt = m.now()
+	verificationCopy := *verification
+	m.verifications = append(m.verifications, &verificationCopy)
+	return nil
+}
+
+func (m *MockEmailVerificationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.EmailVerification, error) {
+	for _, verification := range m.verifications {
+		if verification.TokenHash == tokenHash {
+			verificationCopy := *verification
+			return &verificationCopy, nil
+		}
+	}
+	return nil, errors.New("verification not found")
+}
+
+func (m *MockEmailVerificationRepository) GetLatestForUser(ctx context.Context, userID int) (*models.EmailVerification, error) {
+	for i := len(m.verifications) - 1; i >= 0; i-- {
+		if m.verifications[i].UserID == userID {
+			verificationCopy := *m.verifications[i]
+			return &verificationCopy, nil
+		}
+	}
+	return nil, errors.New("verification not found")
+}
+
+func (m *MockEmailVerificationRepository) DeleteForUser(ctx context.Context, userID int) error {
+	kept := m.verifications[:0]
+	for _, verification := range m.verifications {
+		if verification.UserID != userID {
+			kept = append(kept, verification)
+		}
+	}
+	m.verifications = kept
+	return nil
+}
+
+type verificationFixture struct {
+	users         *MockUserRepository
+	sender        *recordingEmailSender
+	verifier      *EmailVerificationService
+	userService   *UserService
+	authService   *AuthService
+	verifications *MockEmailVerificationRepository
+	now           time.Time
+}
+
+func newVerificationFixture() *verificationFixture {
+	f := &verificationFixture{
+		users:  NewMockUserRepository(),
+		sender: &recordingEmailSender{tokens: make(map[string]string)},
+		now:    time.Now(),
+	}
+	clock := func() time.Time { return f.now }
+	f.verifications = &MockEmailVerificationRepository{now: clock}
+	f.verifier = NewEmailVerificationService(f.users, f.verifications, f.sender)
+	f.verifier.now = clock
+	f.userService = NewUserService(f.users, WithEmailVerification(f.verifier))
+	f.authService = NewAuthService(f.users, NewMockAuthRepository(), testTokenManager)
+	return f
+}
+
+func (f *verificationFixture) register(t *testing.T, email string) *models.User {
+	t.Helper()
+	user, err := f.userService.CreateUser(context.Background(), &models.RegisterRequest{
+		Email: email, Password: "password123", FirstName: "New", LastName: "User",
+	})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	return user
+}
+
+func (f *verificationFixture) login(email string) error {
+	_, err := f.authService.Login(context.Background(), &models.LoginRequest{Email: email, Password: "password123"}, "127.0.0.1", "test-agent")
+	return err
+}
+
+func TestEmailVerification_SendFailureKeepsUser(t *testing.T) {
+	ctx := context.Background()
+	f := newVerificationFixture()
+	f.sender.err = errors.New("mail server unavailable")
+
+	// The committed user is returned; the token can be resent later
+	user := f.register(t, "new@example.com")
+	if user.ID == 0 || user.EmailVerified {
+		t.Errorf("CreateUser() = %+v, want the unverified user", user)
+	}
+
+	f.sender.err = nil
+	f.now = f.now.Add(verificationResendCooldown)
+	if err := f.verifier.ResendVerification(ctx, &models.ResendVerificationRequest{Email: "new@example.com"}); err != nil {
+		t.Fatalf("ResendVerification() error = %v", err)
+	}
+	if f.sender.tokens["new@example.com"] == "" {
+		t.Error("no verification token sent on resend")
+	}
+}
+
+func TestEmailVerification_RegisterVerifyLogin(t *testing.T) {
+	ctx := context.Background()
+	f := newVerificationFixture()
+
+	user := f.register(t, "new@example.com")
+	if user.EmailVerified {
+		t.Error("new user created verified, want unverified")
+	}
+	token := f.sender.tokens["new@example.com"]
+	if token == "" {
+		t.Fatal("no verification token sent at registration")
+	}
+
+	if err := f.login("new@example.com"); err == nil || !strings.Contains(err.Error(), "email not verified") {
+		t.Fatalf("Login() before verification error = %v, want email not verified", err)
+	}
+
+	verified, err := f.verifier.VerifyEmail(ctx, &models.VerifyEmailRequest{Token: token})
+	if err != nil {
+		t.Fatalf("VerifyEmail() error = %v", err)
+	}
+	if !verified.EmailVerified || verified.ID != user.ID {
+		t.Errorf("VerifyEmail() = %+v, want user %d verified", verified, user.ID)
+	}
+	if err := f.login("new@example.com"); err != nil {
+		t.Errorf("Login() after verification error = %v", err)
+	}
+
+	// Tokens are single-use
+	if _, err := f.verifier.VerifyEmail(ctx, &models.VerifyEmailRequest{Token: token}); err == nil {
+		t.Error("VerifyEmail() reused token, want error")
+	}
+}
+
+func TestEmailVerification_DisabledByDefault(t *testing.T) {
+	users := NewMockUserRepository()
+	user, err := NewUserService(users).CreateUser(context.Background(), &models.RegisterRequest{
+		Email: "new@example.com", Password: "password123", FirstName: "New", LastName: "User",
+	})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if !user.EmailVerified {
+		t.Error("user created unverified with verification disabled")
+	}
+}
+
+func TestEmailVerification_ExpiredToken(t *testing.T) {
+	f := newVerificationFixture()
+	f.register(t, "new@example.com")
+
+	f.now = f.now.Add(emailVerificationTTL)
+	req := &models.VerifyEmailRequest{Token: f.sender.tokens["new@example.com"]}
+	if _, err := f.verifier.VerifyEmail(context.Background(), req); err == nil || !strings.Contains(err.Error(), "invalid or expired") {
+		t.Errorf("VerifyEmail() error = %v, want invalid or expired", err)
+	}
+}
+
+func TestEmailVerification_Resend(t *testing.T) {
+	ctx := context.Background()
+	f := newVerificationFixture()
+	f.register(t, "new@example.com")
+	first := f.sender.tokens["new@example.com"]
+
+	// Resending within the cooldown is rate limited
+	err := f.verifier.ResendVerification(ctx, &models.ResendVerificationRequest{Email: "new@example.com"})
+	if err == nil || !strings.Contains(err.Error(), "too many") {
+		t.Fatalf("ResendVerification() within cooldown error = %v, want too many", err)
+	}
+
+	f.now = f.now.Add(verificationResendCooldown)
+	if err := f.verifier.ResendVerification(ctx, &models.ResendVerificationRequest{Email: "new@example.com"}); err != nil {
+		t.Fatalf("ResendVerification() error = %v", err)
+	}
+	second := f.sender.tokens["new@example.com"]
+	if second == first {
+		t.Fatal("resend did not issue a new token")
+	}
+
+	// Only the newest token works
+	if _, err := f.verifier.VerifyEmail(ctx, &models.VerifyEmailRequest{Token: first}); err == nil {
+		t.Error("VerifyEmail() accepted a superseded token")
+	}
+	if _, err := f.verifier.VerifyEmail(ctx, &models.VerifyEmailRequest{Token: second}); err != nil {
+		t.Errorf("VerifyEmail() error = %v", err)
+	}
+
+	// Unknown and verified addresses succeed without sending anything
+	sent := len(f.sender.tokens)
+	for _, email := range []string{"unknown@example.com", "new@example.com"} {
+		if err := f.verifier.ResendVerification(ctx, &models.ResendVerificationRequest{Email: email}); err != nil {
+			t.Errorf("ResendVerification(%s) error = %v", email, err)
+		}
+	}
+	if len(f.sender.tokens) != sent || len(f.verifications.verifications) != 0 {
+		t.Error("resend issued a token for an unknown or verified address")
+	}
+}
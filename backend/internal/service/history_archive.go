@@ -0,0 +1,151 @@
+// Version history archives
+// Packages a configuration's full history as a tar.gz so it can be imported
+// into another version control system, as snapshots or as patches applied in order
+package service
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"conflux/internal/models"
+	"conflux/pkg/config"
+)
+
+// HistoryArchiveMode chooses how versions are written to a history archive
+type HistoryArchiveMode string
+
+const (
+	// HistoryArchiveSnapshots writes each version's full content as versions/NNNN.<ext>
+	HistoryArchiveSnapshots HistoryArchiveMode = "snapshots"
+	// HistoryArchivePatches writes patches/NNNN.patch, unified diffs that rebuild
+	// config.<ext> when applied in order with patch -p1
+	HistoryArchivePatches HistoryArchiveMode = "patches"
+)
+
+// HistoryArchive is a configuration's version history ready to be written out
+type HistoryArchive struct {
+	Config   *models.UserConfig
+	Versions []*models.ConfigVersion // Oldest first
+	Mode     HistoryArchiveMode
+}
+
+// GetHistoryArchive loads a configuration's history for archiving
+// Ownership is checked here, before the caller starts writing a response
+func (s *ConfigService) GetHistoryArchive(configID, userID int, mode HistoryArchiveMode) (*HistoryArchive, error) {
+	if mode != HistoryArchiveSnapshots && mode != HistoryArchivePatches {
+		return nil, fmt.Errorf("validation failed: mode must be %q or %q", HistoryArchiveSnapshots, HistoryArchivePatches)
+	}
+
+	userConfig, err := s.GetUserConfig(configID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	newestFirst, err := s.allConfigVersions(configID)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]*models.ConfigVersion, len(newestFirst))
+	for i, version := range newestFirst {
+		versions[len(versions)-1-i] = version
+	}
+
+	return &HistoryArchive{Config: userConfig, Versions: versions, Mode: mode}, nil
+}
+
+// Write streams the archive to w as a gzipped tar with manifest.json first
+// Versions store content only, so every file takes the configuration's current format
+func (a *HistoryArchive) Write(w io.Writer) error {
+	extension := string(a.Config.Format)
+	if codec, ok := config.LookupCodec(a.Config.Format); ok {
+		extension = codec.Extensions[0]
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	exportedAt := time.Now().UTC()
+
+	versionNumbers := make(map[int]int, len(a.Versions))
+	manifest := models.HistoryManifest{
+		ConfigID:   a.Config.ID,
+		Name:       a.Config.Name,
+		Format:     a.Config.Format,
+		Mode:       string(a.Mode),
+		ExportedAt: exportedAt,
+		Versions:   make([]models.HistoryManifestEntry, 0, len(a.Versions)),
+	}
+	for _, version := range a.Versions {
+		versionNumbers[version.ID] = version.Version
+		entry := models.HistoryManifestEntry{
+			Version:    version.Version,
+			File:       a.versionFile(version, extension),
+			ChangeNote: version.ChangeNote,
+			CreatedBy:  version.CreatedBy,
+			CreatedAt:  version.CreatedAt,
+		}
+		if version.RestoredFrom != nil {
+			if restored, ok := versionNumbers[*version.RestoredFrom]; ok {
+				entry.RestoredFrom = &restored
+			}
+		}
+		manifest.Versions = append(manifest.Versions, entry)
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, "manifest.json", append(manifestJSON, '\n'), exportedAt); err != nil {
+		return err
+	}
+
+	previous := ""
+	for i, version := range a.Versions {
+		body := version.Content
+		if a.Mode == HistoryArchivePatches {
+			name := "config." + extension
+			oldName := "a/" + name
+			if i == 0 {
+				oldName = "/dev/null" // The first patch creates the file
+			}
+			body = config.UnifiedDiff(oldName, "b/"+name, config.DiffLines(previous, version.Content), unifiedDiffContext)
+			previous = version.Content
+		}
+		if err := writeTarFile(tw, manifest.Versions[i].File, []byte(body), version.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// versionFile returns the archive path of a version's snapshot or patch
+func (a *HistoryArchive) versionFile(version *models.ConfigVersion, extension string) string {
+	if a.Mode == HistoryArchivePatches {
+		return fmt.Sprintf("patches/%04d.patch", version.Version)
+	}
+	return fmt.Sprintf("versions/%04d.%s", version.Version, extension)
+}
+
+// writeTarFile adds one regular file to a tar archive
+func writeTarFile(tw *tar.Writer, name string, body []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(body)),
+		ModTime: modTime,
+		Format:  tar.FormatPAX,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(body)
+	return err
+}
@@ -0,0 +1,509 @@
+// Background configuration import processing
+// Runs imports asynchronously with a bounded number of concurrent jobs
+// Tracks a cancel function per import so in-flight imports can be aborted
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"conflux/internal/models"
+	"conflux/pkg/config"
+)
+
+const (
+	// defaultMaxConcurrentImports bounds how many imports run at once
+	defaultMaxConcurrentImports = 4
+
+	// importCancelledMessage is recorded on imports aborted by the user
+	importCancelledMessage = "cancelled"
+
+	// maxImportSize caps how much content an import may download
+	maxImportSize = 5 << 20
+
+	// maxImportAttempts bounds how often a rate-limited import is fetched
+	maxImportAttempts = 3
+
+	// minImportRetryDelay and maxImportRetryDelay clamp upstream retry hints;
+	// an import asked to wait longer than the maximum fails instead
+	minImportRetryDelay = time.Second
+	maxImportRetryDelay = 15 * time.Minute
+
+	// defaultImportRetryDelay is used when a rate-limited response has no hint
+	defaultImportRetryDelay = time.Minute
+
+	// dedupScanPageSize is how many configs are compared per query when deduplicating
+	dedupScanPageSize = 100
+)
+
+// ErrImportSource matches failures of the import source itself, as opposed to
+// problems with the content it returned; see ImportSourceError
+var ErrImportSource = errors.New("import source failed")
+
+// ImportSourceError is returned when an import source is unreachable or responds with an error
+// StatusCode is the upstream HTTP status, or 0 when no response arrived
+type ImportSourceError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *ImportSourceError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("import source unreachable: %v", e.Err)
+	}
+	message := fmt.Sprintf("import source returned status %d (%s)", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	return message
+}
+
+func (e *ImportSourceError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes every ImportSourceError match ErrImportSource
+func (e *ImportSourceError) Is(target error) bool {
+	return target == ErrImportSource
+}
+
+// UpstreamRateLimitError is returned when an import source rate-limits us
+type UpstreamRateLimitError struct {
+	RetryAt time.Time
+}
+
+func (e *UpstreamRateLimitError) Error() string {
+	return fmt.Sprintf("import source rate limited, retry after %s", e.RetryAt.UTC().Format(time.RFC3339))
+}
+
+// Is makes a rate limit that outlasts its retries count as a source failure
+func (e *UpstreamRateLimitError) Is(target error) bool {
+	return target == ErrImportSource
+}
+
+// ImportWorker runs import jobs in the background
+// Each job gets its own context so it can be cancelled independently
+type ImportWorker struct {
+	mu    sync.Mutex
+	jobs  map[int]*importJob
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+// importJob tracks a single in-flight import
+type importJob struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewImportWorker creates a worker running at most maxConcurrent imports at once
+func NewImportWorker(maxConcurrent int) *ImportWorker {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	return &ImportWorker{
+		jobs:  make(map[int]*importJob),
+		slots: make(chan struct{}, maxConcurrent),
+	}
+}
+
+// Submit schedules job to run once a concurrency slot is free
+// The job always runs, even if cancelled while queued, so it can record its final status
+func (w *ImportWorker) Submit(importID int, job func(ctx context.Context)) {
+	w.SubmitAfter(importID, 0, job)
+}
+
+// SubmitAfter schedules job to run after delay, once a concurrency slot is free
+// The job is registered immediately, so it can be cancelled while waiting
+func (w *ImportWorker) SubmitAfter(importID int, delay time.Duration, job func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &importJob{cancel: cancel, done: make(chan struct{})}
+
+	w.mu.Lock()
+	w.jobs[importID] = j
+	w.mu.Unlock()
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer w.finish(importID, j)
+
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+			}
+		}
+
+		select {
+		case w.slots <- struct{}{}:
+			defer func() { <-w.slots }()
+		case <-ctx.Done():
+		}
+
+		job(ctx)
+	}()
+}
+
+// Cancel aborts an in-flight import and waits for its job to return
+// A job that rescheduled itself while being cancelled is cancelled too
+// Returns false if no job is running for the import
+func (w *ImportWorker) Cancel(importID int) bool {
+	cancelled := false
+	for {
+		w.mu.Lock()
+		j, ok := w.jobs[importID]
+		w.mu.Unlock()
+		if !ok {
+			return cancelled
+		}
+
+		j.cancel()
+		<-j.done
+		cancelled = true
+	}
+}
+
+// Abort cancels an import's job without waiting for it to return
+// Used to release an import whose job may be stuck; the slot frees once the job notices
+// Returns false if no job is registered for the import
+func (w *ImportWorker) Abort(importID int) bool {
+	w.mu.Lock()
+	j, ok := w.jobs[importID]
+	w.mu.Unlock()
+	if ok {
+		j.cancel()
+	}
+	return ok
+}
+
+// InFlight returns the number of imports that are queued or running
+func (w *ImportWorker) InFlight() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.jobs)
+}
+
+// Wait blocks until all submitted jobs have returned
+func (w *ImportWorker) Wait() {
+	w.wg.Wait()
+}
+
+// finish releases the job's context and removes it from the registry
+func (w *ImportWorker) finish(importID int, j *importJob) {
+	j.cancel()
+
+	w.mu.Lock()
+	if w.jobs[importID] == j {
+		delete(w.jobs, importID)
+	}
+	w.mu.Unlock()
+
+	close(j.done)
+}
+
+// processImport runs an import and records its final status
+func (s *ConfigService) processImport(ctx context.Context, importRecord models.ConfigImport, format models.ConfigFormat) {
+	if ctx.Err() == nil {
+		importRecord.Status = models.ImportProcessing
+		importRecord.Attempts++
+		importRecord.RetryAfter = nil
+		importRecord.SourceFailed = false
+		if err := s.configRepo.UpdateImport(importRecord.ID, &importRecord); err != nil {
+			s.failImport(&importRecord, fmt.Sprintf("failed to update import status: %v", err))
+			return
+		}
+	}
+
+	configID, err := s.runImport(ctx, &importRecord, format)
+
+	var limited *UpstreamRateLimitError
+	if errors.As(err, &limited) && ctx.Err() == nil && s.retryImport(&importRecord, format, limited.RetryAt) {
+		return
+	}
+
+	if err != nil {
+		// Remove anything created before the import failed or was cancelled
+		if configID != nil {
+			_ = s.configRepo.DeleteUserConfig(*configID)
+		}
+
+		message := err.Error()
+		importRecord.SourceFailed = errors.Is(err, ErrImportSource)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			// Keep the reason recorded by an administrator who failed the import
+			if stored, err := s.configRepo.GetImport(importRecord.ID); err == nil && stored.Status == models.ImportFailed {
+				return
+			}
+			message = importCancelledMessage
+			importRecord.SourceFailed = false
+		}
+		s.failImport(&importRecord, message)
+		return
+	}
+
+	now := time.Now()
+	importRecord.Status = models.ImportCompleted
+	importRecord.ConfigID = configID
+	importRecord.CompletedAt = &now
+	_ = s.configRepo.UpdateImport(importRecord.ID, &importRecord)
+}
+
+// retryImport requeues a rate-limited import to run again at retryAt
+// Returns false when attempts are exhausted or the wait is too long
+func (s *ConfigService) retryImport(importRecord *models.ConfigImport, format models.ConfigFormat, retryAt time.Time) bool {
+	if importRecord.Attempts >= maxImportAttempts {
+		return false
+	}
+
+	delay := time.Until(retryAt)
+	if delay > maxImportRetryDelay {
+		return false
+	}
+	if delay < minImportRetryDelay {
+		delay = minImportRetryDelay
+	}
+
+	scheduled := time.Now().Add(delay)
+	importRecord.Status = models.ImportPending
+	importRecord.RetryAfter = &scheduled
+	if err := s.configRepo.UpdateImport(importRecord.ID, importRecord); err != nil {
+		return false
+	}
+
+	job := *importRecord
+	s.importWorker.SubmitAfter(job.ID, delay, func(ctx context.Context) {
+		s.processImport(ctx, job, format)
+	})
+	return true
+}
+
+// runImport fetches the import source and stores it as a new user configuration
+// Returns the created configuration ID, if any, even when a later step fails
+func (s *ConfigService) runImport(
+	ctx context.Context, importRecord *models.ConfigImport, format models.ConfigFormat,
+) (*int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var content, fileName string
+	var err error
+	switch importRecord.SourceType {
+	case models.SourceURL:
+		fileName = path.Base(importRecord.SourceURL)
+		content, err = s.fetchURL(ctx, importRecord.SourceURL)
+	case models.SourceGitHub:
+		var source *GitHubSource
+		if source, err = ParseGitHubSource(importRecord.SourceURL); err == nil {
+			fileName = path.Base(source.Path)
+			content, err = s.fetchGitHub(ctx, importRecord.UserID, source)
+		}
+	default:
+		err = fmt.Errorf("unsupported import source: %s", importRecord.SourceType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Both branches reject binary content before any parser runs
+	if format == "" {
+		format, err = s.DetectFormat(content, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect format: %w", err)
+		}
+	} else if err := s.validateConfigContent(content, format); err != nil {
+		return nil, err
+	}
+
+	if importRecord.Dedupe {
+		configID, result, err := s.dedupImport(ctx, importRecord, content, format)
+		if err != nil {
+			return nil, fmt.Errorf("failed to deduplicate import: %w", err)
+		}
+		if result != models.DedupNone {
+			importRecord.DedupResult = result
+			return &configID, nil
+		}
+	}
+
+	name, err := s.availableConfigName(importRecord.UserID, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	userConfig := &models.UserConfig{
+		UserID:  importRecord.UserID,
+		Name:    name,
+		Format:  format,
+		Content: content,
+	}
+
+	if err := s.configRepo.CreateUserConfig(userConfig); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.createConfigVersion(userConfig, importRecord.UserID, "Imported from "+importRecord.SourceURL, nil); err != nil {
+		return &userConfig.ID, fmt.Errorf("failed to create initial version: %w", err)
+	}
+
+	// A cancel that arrives after the config was written still rolls it back
+	if err := ctx.Err(); err != nil {
+		return &userConfig.ID, err
+	}
+
+	s.recordActivity(models.AuditConfigImported, importRecord.UserID, userConfig,
+		fmt.Sprintf("Imported %q from %s", userConfig.Name, importRecord.SourceURL))
+	return &userConfig.ID, nil
+}
+
+// dedupImport reuses one of the user's configurations for a deduplicating import
+// The config from an earlier import of the same source gets a new version if its
+// content changed; otherwise any config with identical canonical content is linked.
+// Returns DedupNone when nothing matches and a new config should be created
+func (s *ConfigService) dedupImport(
+	ctx context.Context, importRecord *models.ConfigImport, content string, format models.ConfigFormat,
+) (int, models.DedupResult, error) {
+	hash, err := s.canonicalHash(content, format)
+	if err != nil {
+		return 0, models.DedupNone, err
+	}
+
+	previous, err := s.configRepo.GetLatestCompletedImport(importRecord.UserID, importRecord.SourceURL)
+	if err == nil && previous.ConfigID != nil {
+		existing, err := s.configRepo.GetUserConfig(*previous.ConfigID)
+		if err == nil && existing.UserID == importRecord.UserID {
+			if existingHash, err := s.canonicalHash(existing.Content, existing.Format); err == nil && existingHash == hash {
+				return existing.ID, models.DedupLinked, nil
+			}
+
+			// Nothing is rolled back after this point, so honor a cancel first
+			if err := ctx.Err(); err != nil {
+				return 0, models.DedupNone, err
+			}
+			updated, _, err := s.saveUserConfig(existing, importRecord.UserID, content, "Re-imported from "+importRecord.SourceURL, &format, nil)
+			if err != nil {
+				return 0, models.DedupNone, err
+			}
+			s.recordActivity(models.AuditConfigImported, importRecord.UserID, updated,
+				fmt.Sprintf("Re-imported %q from %s", updated.Name, importRecord.SourceURL))
+			return updated.ID, models.DedupNewVersion, nil
+		}
+	}
+
+	for afterID := 0; ; {
+		configs, err := s.configRepo.GetUserConfigsAfter(importRecord.UserID, nil, afterID, dedupScanPageSize)
+		if err != nil {
+			return 0, models.DedupNone, err
+		}
+		for _, existing := range configs {
+			if existingHash, err := s.canonicalHash(existing.Content, existing.Format); err == nil && existingHash == hash {
+				return existing.ID, models.DedupLinked, nil
+			}
+		}
+		if len(configs) < dedupScanPageSize {
+			return 0, models.DedupNone, nil
+		}
+		afterID = configs[len(configs)-1].ID
+	}
+}
+
+// canonicalHash parses content and returns its format-independent hash
+func (s *ConfigService) canonicalHash(content string, format models.ConfigFormat) (string, error) {
+	data, err := s.parser.ParseConfig(content, format)
+	if err != nil {
+		return "", err
+	}
+	return config.CanonicalHash(data)
+}
+
+// fetchURL downloads import content, honoring cancellation of ctx
+// Only http and https URLs are fetched, and never from internal addresses
+func (s *ConfigService) fetchURL(ctx context.Context, url string) (string, error) {
+	if err := validateImportURL(url); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid import URL: %w", err)
+	}
+	return s.fetchSource(s.httpClient, req, nil)
+}
+
+// fetchSource sends an import source request with client and returns the response body
+// describeStatus may explain a non-200 status to the user; nil reports it alone
+func (s *ConfigService) fetchSource(
+	client *http.Client, req *http.Request, describeStatus func(status int) error,
+) (string, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", &ImportSourceError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	if retryAt, limited := rateLimitReset(resp, time.Now()); limited {
+		return "", &UpstreamRateLimitError{RetryAt: retryAt}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		sourceErr := &ImportSourceError{StatusCode: resp.StatusCode}
+		if describeStatus != nil {
+			sourceErr.Err = describeStatus(resp.StatusCode)
+		}
+		return "", sourceErr
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxImportSize+1))
+	if err != nil {
+		return "", &ImportSourceError{Err: fmt.Errorf("failed to read response: %w", err)}
+	}
+	if len(body) > maxImportSize {
+		return "", fmt.Errorf("import source exceeds %d bytes", maxImportSize)
+	}
+
+	return string(body), nil
+}
+
+// rateLimitReset reports whether resp is an upstream rate limit and when to retry
+// Honors Retry-After (seconds or HTTP date), then X-RateLimit-Reset (Unix time),
+// as sent by GitHub and GitLab on 429 and rate-limited 403 responses
+func rateLimitReset(resp *http.Response, now time.Time) (time.Time, bool) {
+	exhausted := resp.Header.Get("X-RateLimit-Remaining") == "0"
+	if resp.StatusCode != http.StatusTooManyRequests && !(resp.StatusCode == http.StatusForbidden && exhausted) {
+		return time.Time{}, false
+	}
+
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return now.Add(time.Duration(seconds) * time.Second), true
+		}
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return at, true
+		}
+	}
+
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		return time.Unix(reset, 0), true
+	}
+
+	return now.Add(defaultImportRetryDelay), true
+}
+
+// failImport marks an import as failed with the given message
+func (s *ConfigService) failImport(importRecord *models.ConfigImport, message string) {
+	now := time.Now()
+	importRecord.Status = models.ImportFailed
+	importRecord.ErrorMessage = &message
+	importRecord.RetryAfter = nil
+	importRecord.CompletedAt = &now
+	_ = s.configRepo.UpdateImport(importRecord.ID, importRecord)
+}
@@ -0,0 +1,110 @@
+// Import recovery for operators
+// Lists imports by status and fails imports left stuck by a crashed or hung worker
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"conflux/internal/models"
+)
+
+const (
+	// importAdminFailMessage is recorded on imports failed by an administrator
+	importAdminFailMessage = "failed by an administrator"
+
+	// importInterruptedMessage is recorded on imports left processing by a previous run
+	importInterruptedMessage = "interrupted by a server restart"
+
+	// DefaultImportStaleAfter is how long an import may stay processing before
+	// startup reconciliation treats it as abandoned
+	DefaultImportStaleAfter = 30 * time.Minute
+)
+
+// ListImportsByStatus returns every user's imports in a status, oldest first, with their age
+func (s *ConfigService) ListImportsByStatus(
+	status models.ImportStatus, isAdmin bool, page, limit int,
+) ([]*models.ImportWithAge, int64, error) {
+	if !isAdmin {
+		return nil, 0, fmt.Errorf("unauthorized to list imports")
+	}
+	switch status {
+	case models.ImportPending, models.ImportProcessing, models.ImportCompleted, models.ImportFailed:
+	default:
+		return nil, 0, fmt.Errorf("validation failed: unknown import status %q", status)
+	}
+
+	imports, total, err := s.configRepo.GetImportsByStatus(status, page, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list imports: %w", err)
+	}
+
+	now := time.Now()
+	listed := make([]*models.ImportWithAge, 0, len(imports))
+	for _, importRecord := range imports {
+		listed = append(listed, &models.ImportWithAge{
+			ConfigImport: importRecord,
+			AgeSeconds:   int64(now.Sub(importRecord.CreatedAt).Seconds()),
+		})
+	}
+	return listed, total, nil
+}
+
+// ForceFailImport marks a pending or processing import as failed on an admin's behalf
+// Any job still registered for it is cancelled without waiting, so a hung job
+// can't block the request; its concurrency slot frees once the job returns
+func (s *ConfigService) ForceFailImport(importID int, isAdmin bool) (*models.ConfigImport, error) {
+	if !isAdmin {
+		return nil, fmt.Errorf("unauthorized to fail import %d", importID)
+	}
+
+	importRecord, err := s.configRepo.GetImport(importID)
+	if err != nil {
+		return nil, err
+	}
+	if importRecord.Status != models.ImportPending && importRecord.Status != models.ImportProcessing {
+		return nil, fmt.Errorf("import is not in progress")
+	}
+
+	s.importWorker.Abort(importID)
+	s.failImport(importRecord, importAdminFailMessage)
+	return importRecord, nil
+}
+
+// ReconcileStuckImports fails imports a previous run left processing for longer than staleAfter
+// Meant to run once at startup, before new imports are accepted; returns how many were failed
+func (s *ConfigService) ReconcileStuckImports(staleAfter time.Duration) (int, error) {
+	cutoff := time.Now().Add(-staleAfter)
+
+	// Collect first: failing an import moves it out of the listing and would shift pages
+	var stale []*models.ConfigImport
+	for page := 1; ; page++ {
+		imports, _, err := s.configRepo.GetImportsByStatus(models.ImportProcessing, page, importScanPageSize)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list processing imports: %w", err)
+		}
+		for _, importRecord := range imports {
+			if !importRecord.CreatedAt.Before(cutoff) {
+				return s.failStaleImports(stale), nil // Oldest first, so the rest are newer
+			}
+			stale = append(stale, importRecord)
+		}
+		if len(imports) < importScanPageSize {
+			return s.failStaleImports(stale), nil
+		}
+	}
+}
+
+// importScanPageSize is how many imports are fetched per query during reconciliation
+const importScanPageSize = 100
+
+// failStaleImports fails imports abandoned by a previous run and returns how many there were
+func (s *ConfigService) failStaleImports(imports []*models.ConfigImport) int {
+	for _, importRecord := range imports {
+		if s.importWorker.Abort(importRecord.ID) {
+			continue // Owned by this process after all
+		}
+		s.failImport(importRecord, importInterruptedMessage)
+	}
+	return len(imports)
+}
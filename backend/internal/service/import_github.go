@@ -0,0 +1,119 @@
+// GitHub import sources
+// Fetches a single file from a GitHub repository through the contents API
+// Private repositories are read with the importing user's token, when one is available
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"conflux/internal/models"
+)
+
+// DefaultGitHubAPIURL is the GitHub REST API that GitHub imports are fetched from
+const DefaultGitHubAPIURL = "https://api.github.com"
+
+// GitHubSource identifies a file in a GitHub repository
+// Ref is a branch, tag, or commit SHA; empty means the repository's default branch
+type GitHubSource struct {
+	Owner string
+	Repo  string
+	Path  string
+	Ref   string
+}
+
+func (g *GitHubSource) String() string {
+	source := g.Owner + "/" + g.Repo + "/" + g.Path
+	if g.Ref != "" {
+		source += "@" + g.Ref
+	}
+	return source
+}
+
+// ImportTokenSource supplies the access token a user's imports authenticate with
+// An empty token means the source is fetched anonymously
+type ImportTokenSource interface {
+	ImportToken(ctx context.Context, userID int, sourceType models.ConfigSourceType) (string, error)
+}
+
+// WithGitHubAPI points GitHub imports at apiURL, such as a GitHub Enterprise
+// Server's /api/v3 endpoint, and reads private repositories with tokens from
+// tokens; a nil tokens imports public repositories only
+func WithGitHubAPI(apiURL string, tokens ImportTokenSource) ConfigServiceOption {
+	return func(s *ConfigService) {
+		if apiURL != "" {
+			s.githubAPIURL = strings.TrimSuffix(apiURL, "/")
+		}
+		s.importTokens = tokens
+	}
+}
+
+// ParseGitHubSource parses a source like github.com/owner/repo/path/to/config.yaml@ref
+// The scheme and the @ref suffix are optional
+func ParseGitHubSource(source string) (*GitHubSource, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(source, "https://"), "http://")
+	rest, ok := strings.CutPrefix(trimmed, "github.com/")
+	if !ok {
+		return nil, fmt.Errorf("GitHub source must start with github.com/: %q", source)
+	}
+
+	var ref string
+	if at := strings.LastIndex(rest, "@"); at >= 0 {
+		rest, ref = rest[:at], rest[at+1:]
+		if ref == "" {
+			return nil, fmt.Errorf("GitHub source has an empty ref: %q", source)
+		}
+	}
+
+	parts := strings.SplitN(rest, "/", 3)
+	if len(parts) < 3 || parts[0] == "" || parts[1] == "" || strings.Trim(parts[2], "/") == "" {
+		return nil, fmt.Errorf("GitHub source must name an owner, repository, and file path: %q", source)
+	}
+	return &GitHubSource{Owner: parts[0], Repo: parts[1], Path: strings.Trim(parts[2], "/"), Ref: ref}, nil
+}
+
+// fetchGitHub downloads a file's raw content through the GitHub contents API
+func (s *ConfigService) fetchGitHub(ctx context.Context, userID int, source *GitHubSource) (string, error) {
+	var token string
+	if s.importTokens != nil {
+		var err error
+		if token, err = s.importTokens.ImportToken(ctx, userID, models.SourceGitHub); err != nil {
+			return "", fmt.Errorf("failed to load GitHub token: %w", err)
+		}
+	}
+
+	segments := strings.Split(source.Path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/contents/%s", s.githubAPIURL,
+		url.PathEscape(source.Owner), url.PathEscape(source.Repo), strings.Join(segments, "/"))
+	if source.Ref != "" {
+		endpoint += "?ref=" + url.QueryEscape(source.Ref)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid GitHub source: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github.raw+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return s.fetchSource(s.githubClient, req, func(status int) error {
+		switch {
+		case status == http.StatusNotFound && token == "":
+			return fmt.Errorf("%s not found; private repositories need a GitHub token", source)
+		case status == http.StatusNotFound:
+			return fmt.Errorf("%s not found or not readable with your GitHub token", source)
+		case status == http.StatusUnauthorized:
+			return fmt.Errorf("GitHub rejected the token")
+		}
+		return nil
+	})
+}
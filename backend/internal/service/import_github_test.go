@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+)
+
+// staticImportTokens hands out one token per user
+type staticImportTokens map[int]string
+
+func (t staticImportTokens) ImportToken(ctx context.Context, userID int, sourceType models.ConfigSourceType) (string, error) {
+	return t[userID], nil
+}
+
+// newGitHubAPI mocks the contents API for acme/public and the private acme/infra,
+// which is only readable with the "secret-token" token
+func newGitHubAPI(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/vnd.github.raw+json" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		switch {
+		case r.URL.Path == "/repos/acme/public/contents/deploy/app.yaml":
+			_, _ = w.Write([]byte("server:\n  port: 8080\n"))
+		case r.URL.Path == "/repos/acme/infra/contents/settings.json" &&
+			r.Header.Get("Authorization") == "Bearer secret-token" && r.URL.Query().Get("ref") == "v1.2":
+			_, _ = w.Write([]byte(`{"debug": false}`))
+		case r.URL.Path == "/repos/acme/limited/contents/app.yaml":
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestParseGitHubSource(t *testing.T) {
+	tests := []struct {
+		source  string
+		want    GitHubSource
+		wantErr bool
+	}{
+		{source: "github.com/acme/infra/config.yaml", want: GitHubSource{Owner: "acme", Repo: "infra", Path: "config.yaml"}},
+		{source: "https://github.com/acme/infra/deploy/prod/app.yaml@release/v2",
+			want: GitHubSource{Owner: "acme", Repo: "infra", Path: "deploy/prod/app.yaml", Ref: "release/v2"}},
+		{source: "github.com/acme/infra/app.yaml@", wantErr: true},
+		{source: "github.com/acme/infra", wantErr: true},
+		{source: "gitlab.com/acme/infra/app.yaml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, err := ParseGitHubSource(tt.source)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseGitHubSource() = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil || *got != tt.want {
+				t.Errorf("ParseGitHubSource() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigService_ImportConfig_GitHub(t *testing.T) {
+	api := newGitHubAPI(t)
+	tokens := staticImportTokens{2: "secret-token"}
+
+	tests := []struct {
+		name        string
+		userID      int
+		source      string
+		wantName    string
+		wantFormat  models.ConfigFormat
+		wantMessage string // Expected error message for failed imports
+	}{
+		{name: "public repository", userID: 1, source: "github.com/acme/public/deploy/app.yaml",
+			wantName: "app.yaml", wantFormat: models.FormatYAML},
+		{name: "private repository at ref", userID: 2, source: "github.com/acme/infra/settings.json@v1.2",
+			wantName: "settings.json", wantFormat: models.FormatJSON},
+		{name: "private repository without token", userID: 1, source: "github.com/acme/infra/settings.json@v1.2",
+			wantMessage: "status 404 (Not Found): acme/infra/settings.json@v1.2 not found; private repositories need a GitHub token"},
+		{name: "missing file with token", userID: 2, source: "github.com/acme/infra/missing.yaml",
+			wantMessage: "not found or not readable with your GitHub token"},
+		{name: "rate limited", userID: 1, source: "github.com/acme/limited/app.yaml",
+			wantMessage: "import source rate limited, retry after"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockConfigRepository()
+			service := NewConfigService(repo, WithGitHubAPI(api.URL+"/", tokens))
+
+			importRecord, err := service.ImportConfig(tt.userID, models.SourceGitHub, tt.source, "", false)
+			if err != nil {
+				t.Fatalf("ImportConfig() error = %v", err)
+			}
+			service.importWorker.Wait()
+
+			stored, _ := repo.GetImport(importRecord.ID)
+			if tt.wantMessage != "" {
+				if stored.Status != models.ImportFailed || !stored.SourceFailed {
+					t.Fatalf("import = %s (source_failed %v), want a failed source", stored.Status, stored.SourceFailed)
+				}
+				if stored.ErrorMessage == nil || !strings.Contains(*stored.ErrorMessage, tt.wantMessage) {
+					t.Errorf("error message = %v, want it to contain %q", stored.ErrorMessage, tt.wantMessage)
+				}
+				return
+			}
+
+			if stored.Status != models.ImportCompleted {
+				t.Fatalf("status = %q (error: %v), want completed", stored.Status, stored.ErrorMessage)
+			}
+			config, err := repo.GetUserConfig(*stored.ConfigID)
+			if err != nil {
+				t.Fatalf("GetUserConfig() error = %v", err)
+			}
+			if config.Name != tt.wantName || config.Format != tt.wantFormat || config.UserID != tt.userID {
+				t.Errorf("config = %q (%s) for user %d, want %q (%s) for user %d",
+					config.Name, config.Format, config.UserID, tt.wantName, tt.wantFormat, tt.userID)
+			}
+		})
+	}
+}
+
+func TestConfigService_ImportConfig_InvalidGitHubSource(t *testing.T) {
+	service := NewConfigService(NewMockConfigRepository())
+
+	_, err := service.ImportConfig(1, models.SourceGitHub, "github.com/acme", "", false)
+	if err == nil || !strings.Contains(err.Error(), "validation failed") {
+		t.Errorf("ImportConfig() error = %v, want validation failed", err)
+	}
+}
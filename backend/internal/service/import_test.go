@@ -0,0 +1,687 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+)
+
+// newBlockingSource returns a server whose responses hang until the client
+// goes away, plus a channel signalled each time a request arrives
+func newBlockingSource(t *testing.T) (*httptest.Server, <-chan struct{}) {
+	t.Helper()
+	started := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	return server, started
+}
+
+func waitFor(t *testing.T, ch <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for import to start")
+	}
+}
+
+func TestConfigService_CancelImport(t *testing.T) {
+	const ownerID = 1
+
+	tests := []struct {
+		name          string
+		callerID      int
+		isAdmin       bool
+		wantErr       bool
+		errorContains string
+	}{
+		{name: "owner cancels", callerID: ownerID},
+		{name: "admin cancels another user's import", callerID: 2, isAdmin: true},
+		{name: "other user is rejected", callerID: 2, wantErr: true, errorContains: "unauthorized"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, started := newBlockingSource(t)
+			repo := NewMockConfigRepository()
+			service := NewConfigService(repo, WithPrivateImportURLs(true))
+			defer service.importWorker.Wait()
+
+			importRecord, err := service.ImportConfig(ownerID, models.SourceURL, source.URL+"/app.yaml", "", false)
+			if err != nil {
+				t.Fatalf("ImportConfig() error = %v", err)
+			}
+			waitFor(t, started)
+
+			cancelled, err := service.CancelImport(importRecord.ID, tt.callerID, tt.isAdmin)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+					t.Errorf("CancelImport() error = %v, want %q", err, tt.errorContains)
+				}
+				// Clean up the still-running import
+				if _, err := service.CancelImport(importRecord.ID, ownerID, false); err != nil {
+					t.Fatalf("owner cancel failed: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CancelImport() error = %v", err)
+			}
+
+			if cancelled.Status != models.ImportFailed {
+				t.Errorf("status = %q, want %q", cancelled.Status, models.ImportFailed)
+			}
+			if cancelled.ErrorMessage == nil || *cancelled.ErrorMessage != importCancelledMessage {
+				t.Errorf("error message = %v, want %q", cancelled.ErrorMessage, importCancelledMessage)
+			}
+			if cancelled.CompletedAt == nil {
+				t.Error("expected completed_at to be set")
+			}
+			if n := service.importWorker.InFlight(); n != 0 {
+				t.Errorf("in-flight imports = %d, want 0", n)
+			}
+			if n := repo.ConfigCount(); n != 0 {
+				t.Errorf("configs created = %d, want 0", n)
+			}
+
+			// A finished import can't be cancelled again
+			if _, err := service.CancelImport(importRecord.ID, ownerID, false); err == nil ||
+				!strings.Contains(err.Error(), "not in progress") {
+				t.Errorf("second cancel error = %v, want not in progress", err)
+			}
+		})
+	}
+}
+
+func TestConfigService_CancelImport_NoWorker(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	// An import left processing by a previous server process has no worker
+	orphan := &models.ConfigImport{UserID: 1, SourceType: models.SourceURL, Status: models.ImportProcessing}
+	if err := repo.CreateImport(orphan); err != nil {
+		t.Fatalf("CreateImport() error = %v", err)
+	}
+
+	cancelled, err := service.CancelImport(orphan.ID, 1, false)
+	if err != nil {
+		t.Fatalf("CancelImport() error = %v", err)
+	}
+
+	stored, _ := repo.GetImport(orphan.ID)
+	if cancelled.Status != models.ImportFailed || stored.Status != models.ImportFailed {
+		t.Errorf("status = %q (stored %q), want %q", cancelled.Status, stored.Status, models.ImportFailed)
+	}
+}
+
+func TestConfigService_ImportConfig_Completes(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("server:\n  port: 8080\n"))
+	}))
+	defer source.Close()
+
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo, WithPrivateImportURLs(true))
+
+	importRecord, err := service.ImportConfig(1, models.SourceURL, source.URL+"/app.yaml", "", false)
+	if err != nil {
+		t.Fatalf("ImportConfig() error = %v", err)
+	}
+	service.importWorker.Wait()
+
+	stored, err := repo.GetImport(importRecord.ID)
+	if err != nil {
+		t.Fatalf("GetImport() error = %v", err)
+	}
+	if stored.Status != models.ImportCompleted {
+		t.Fatalf("status = %q, want %q (error: %v)", stored.Status, models.ImportCompleted, stored.ErrorMessage)
+	}
+	if stored.ConfigID == nil {
+		t.Fatal("expected config_id to be set")
+	}
+
+	config, err := repo.GetUserConfig(*stored.ConfigID)
+	if err != nil {
+		t.Fatalf("GetUserConfig() error = %v", err)
+	}
+	if config.Name != "app.yaml" || config.Format != models.FormatYAML {
+		t.Errorf("config = %q (%s), want app.yaml (yaml)", config.Name, config.Format)
+	}
+}
+
+func TestConfigService_ImportConfig_Dedupe(t *testing.T) {
+	var mu sync.Mutex
+	bodies := map[string]string{
+		"/app.yaml":   "server:\n  port: 8080\n",
+		"/other.json": `{"server": {"port": 8080}}`,
+	}
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = w.Write([]byte(bodies[r.URL.Path]))
+	}))
+	defer source.Close()
+
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo, WithPrivateImportURLs(true))
+
+	runImport := func(path string, dedupe bool) *models.ConfigImport {
+		t.Helper()
+		importRecord, err := service.ImportConfig(1, models.SourceURL, source.URL+path, "", dedupe)
+		if err != nil {
+			t.Fatalf("ImportConfig() error = %v", err)
+		}
+		service.importWorker.Wait()
+		stored, err := repo.GetImport(importRecord.ID)
+		if err != nil {
+			t.Fatalf("GetImport() error = %v", err)
+		}
+		if stored.Status != models.ImportCompleted || stored.ConfigID == nil {
+			t.Fatalf("import status = %q (error: %v), want completed with a config", stored.Status, stored.ErrorMessage)
+		}
+		return stored
+	}
+
+	first := runImport("/app.yaml", true)
+	if first.DedupResult != models.DedupNone {
+		t.Errorf("first import dedup_result = %q, want none", first.DedupResult)
+	}
+
+	// Re-importing unchanged content links the existing config
+	again := runImport("/app.yaml", true)
+	if again.DedupResult != models.DedupLinked || *again.ConfigID != *first.ConfigID {
+		t.Errorf("re-import = %q config %d, want linked to %d", again.DedupResult, *again.ConfigID, *first.ConfigID)
+	}
+
+	// A different source with the same values in another format links too
+	other := runImport("/other.json", true)
+	if other.DedupResult != models.DedupLinked || *other.ConfigID != *first.ConfigID {
+		t.Errorf("equivalent import = %q config %d, want linked to %d", other.DedupResult, *other.ConfigID, *first.ConfigID)
+	}
+	if n := repo.ConfigCount(); n != 1 {
+		t.Fatalf("configs = %d, want 1", n)
+	}
+
+	// Changed content at a known source becomes a new version of its config
+	mu.Lock()
+	bodies["/app.yaml"] = "server:\n  port: 9090\n"
+	mu.Unlock()
+	changed := runImport("/app.yaml", true)
+	if changed.DedupResult != models.DedupNewVersion || *changed.ConfigID != *first.ConfigID {
+		t.Errorf("changed import = %q config %d, want new_version of %d", changed.DedupResult, *changed.ConfigID, *first.ConfigID)
+	}
+	versions, total, _ := repo.GetConfigVersions(*first.ConfigID, models.DefaultConfigVersionSort, 1, 10)
+	if total != 2 || versions[0].Content != "server:\n  port: 9090\n" {
+		t.Errorf("versions = %d, newest content %q; want 2 with the re-imported content", total, versions[0].Content)
+	}
+
+	// Without dedupe every import creates a config
+	if plain := runImport("/app.yaml", false); plain.DedupResult != models.DedupNone || *plain.ConfigID == *first.ConfigID {
+		t.Errorf("plain import = %q config %d, want a new config", plain.DedupResult, *plain.ConfigID)
+	}
+	if n := repo.ConfigCount(); n != 2 {
+		t.Errorf("configs = %d, want 2", n)
+	}
+}
+
+func TestImportWorker_CancelReleasesSlot(t *testing.T) {
+	worker := NewImportWorker(1)
+	defer worker.Wait()
+
+	running := make(chan struct{})
+	worker.Submit(1, func(ctx context.Context) {
+		close(running)
+		<-ctx.Done()
+	})
+	<-running
+
+	// The second job queues behind the first; cancelling it must not wait for a slot
+	queuedRan := make(chan error, 1)
+	worker.Submit(2, func(ctx context.Context) {
+		queuedRan <- ctx.Err()
+	})
+	if !worker.Cancel(2) {
+		t.Fatal("expected queued job to be cancellable")
+	}
+	if err := <-queuedRan; err == nil {
+		t.Error("queued job should observe a cancelled context")
+	}
+
+	if !worker.Cancel(1) {
+		t.Fatal("expected running job to be cancellable")
+	}
+	if worker.Cancel(1) {
+		t.Error("cancelling a finished job should report false")
+	}
+
+	// The slot is free again
+	done := make(chan struct{})
+	worker.Submit(3, func(ctx context.Context) { close(done) })
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("slot was not released after cancel")
+	}
+}
+
+func TestConfigService_ImportConfig_AmbiguousFormat(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`NAME = "app"`))
+	}))
+	defer source.Close()
+
+	tests := []struct {
+		name       string
+		format     models.ConfigFormat
+		wantStatus models.ImportStatus
+	}{
+		{name: "detection fails on ambiguous content", wantStatus: models.ImportFailed},
+		{name: "explicit format skips detection", format: models.FormatENV, wantStatus: models.ImportCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockConfigRepository()
+			service := NewConfigService(repo, WithPrivateImportURLs(true))
+
+			importRecord, err := service.ImportConfig(1, models.SourceURL, source.URL+"/app.conf", tt.format, false)
+			if err != nil {
+				t.Fatalf("ImportConfig() error = %v", err)
+			}
+			service.importWorker.Wait()
+
+			stored, _ := repo.GetImport(importRecord.ID)
+			if stored.Status != tt.wantStatus {
+				t.Fatalf("status = %q, want %q (error: %v)", stored.Status, tt.wantStatus, stored.ErrorMessage)
+			}
+			if tt.wantStatus == models.ImportFailed &&
+				!strings.Contains(*stored.ErrorMessage, "ambiguous format: content could be toml, env") {
+				t.Errorf("error message = %q, want candidate list", *stored.ErrorMessage)
+			}
+			if tt.wantStatus == models.ImportCompleted {
+				config, _ := repo.GetUserConfig(*stored.ConfigID)
+				if config.Format != tt.format {
+					t.Errorf("format = %s, want %s", config.Format, tt.format)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigService_ImportConfig_RejectsBinary(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x10"))
+	}))
+	defer source.Close()
+
+	for _, format := range []models.ConfigFormat{"", models.FormatYAML} {
+		t.Run("format "+string(format), func(t *testing.T) {
+			repo := NewMockConfigRepository()
+			service := NewConfigService(repo, WithPrivateImportURLs(true))
+
+			importRecord, err := service.ImportConfig(1, models.SourceURL, source.URL+"/logo.png", format, false)
+			if err != nil {
+				t.Fatalf("ImportConfig() error = %v", err)
+			}
+			service.importWorker.Wait()
+
+			stored, _ := repo.GetImport(importRecord.ID)
+			if stored.Status != models.ImportFailed || !strings.Contains(*stored.ErrorMessage, "not a text config") {
+				t.Errorf("import = %s (error: %v), want failed as not a text config", stored.Status, stored.ErrorMessage)
+			}
+			if repo.ConfigCount() != 0 {
+				t.Errorf("configs = %d, want none created", repo.ConfigCount())
+			}
+		})
+	}
+}
+
+func TestRateLimitReset(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		status      int
+		headers     map[string]string
+		wantLimited bool
+		want        time.Time
+	}{
+		{name: "success", status: http.StatusOK},
+		{name: "plain forbidden", status: http.StatusForbidden},
+		{
+			name:        "retry-after seconds",
+			status:      http.StatusTooManyRequests,
+			headers:     map[string]string{"Retry-After": "30"},
+			wantLimited: true,
+			want:        now.Add(30 * time.Second),
+		},
+		{
+			name:        "retry-after date",
+			status:      http.StatusTooManyRequests,
+			headers:     map[string]string{"Retry-After": "Mon, 01 Jan 2024 12:05:00 GMT"},
+			wantLimited: true,
+			want:        now.Add(5 * time.Minute),
+		},
+		{
+			name:        "github exhausted quota",
+			status:      http.StatusForbidden,
+			headers:     map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1704110520"},
+			wantLimited: true,
+			want:        now.Add(2 * time.Minute),
+		},
+		{
+			name:        "no hint",
+			status:      http.StatusTooManyRequests,
+			wantLimited: true,
+			want:        now.Add(defaultImportRetryDelay),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			for key, value := range tt.headers {
+				resp.Header.Set(key, value)
+			}
+
+			got, limited := rateLimitReset(resp, now)
+			if limited != tt.wantLimited {
+				t.Fatalf("limited = %v, want %v", limited, tt.wantLimited)
+			}
+			if limited && !got.Equal(tt.want) {
+				t.Errorf("retry at = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newRateLimitedSource returns a server that rate-limits the first limitedRequests
+// requests with the given Retry-After, then serves YAML
+func newRateLimitedSource(t *testing.T, limitedRequests int, retryAfter string) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		n := requests
+		mu.Unlock()
+
+		if n <= limitedRequests {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("server:\n  port: 8080\n"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestConfigService_ImportConfig_RetriesAfterRateLimit(t *testing.T) {
+	source := newRateLimitedSource(t, 1, "1")
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo, WithPrivateImportURLs(true))
+
+	importRecord, err := service.ImportConfig(1, models.SourceURL, source.URL+"/app.yaml", "", false)
+	if err != nil {
+		t.Fatalf("ImportConfig() error = %v", err)
+	}
+	service.importWorker.Wait()
+
+	stored, _ := repo.GetImport(importRecord.ID)
+	if stored.Status != models.ImportCompleted {
+		t.Fatalf("status = %q, want %q (error: %v)", stored.Status, models.ImportCompleted, stored.ErrorMessage)
+	}
+	if stored.Attempts != 2 {
+		t.Errorf("attempts = %d, want 2", stored.Attempts)
+	}
+	if stored.RetryAfter != nil {
+		t.Errorf("retry_after = %v, want cleared once the retry ran", stored.RetryAfter)
+	}
+}
+
+func TestConfigService_ImportConfig_RetryTooFarFails(t *testing.T) {
+	source := newRateLimitedSource(t, 1, "3600")
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo, WithPrivateImportURLs(true))
+
+	importRecord, _ := service.ImportConfig(1, models.SourceURL, source.URL+"/app.yaml", "", false)
+	service.importWorker.Wait()
+
+	stored, _ := repo.GetImport(importRecord.ID)
+	if stored.Status != models.ImportFailed {
+		t.Fatalf("status = %q, want %q", stored.Status, models.ImportFailed)
+	}
+	if stored.ErrorMessage == nil || !strings.Contains(*stored.ErrorMessage, "rate limited") {
+		t.Errorf("error message = %v, want rate limit error", stored.ErrorMessage)
+	}
+}
+
+func TestConfigService_CancelImport_WhileAwaitingRetry(t *testing.T) {
+	source := newRateLimitedSource(t, 1, "600")
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo, WithPrivateImportURLs(true))
+	defer service.importWorker.Wait()
+
+	importRecord, _ := service.ImportConfig(1, models.SourceURL, source.URL+"/app.yaml", "", false)
+
+	// Wait for the retry to be scheduled
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err := service.GetImport(importRecord.ID, 1, false)
+		if err != nil {
+			t.Fatalf("GetImport() error = %v", err)
+		}
+		if status.RetryAfter != nil {
+			if status.Status != models.ImportPending {
+				t.Errorf("status while awaiting retry = %q, want pending", status.Status)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for retry to be scheduled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancelled, err := service.CancelImport(importRecord.ID, 1, false)
+	if err != nil {
+		t.Fatalf("CancelImport() error = %v", err)
+	}
+	if cancelled.Status != models.ImportFailed || *cancelled.ErrorMessage != importCancelledMessage {
+		t.Errorf("import = %s (%v), want cancelled", cancelled.Status, cancelled.ErrorMessage)
+	}
+	if cancelled.RetryAfter != nil {
+		t.Error("retry_after should be cleared on a finished import")
+	}
+	if n := service.importWorker.InFlight(); n != 0 {
+		t.Errorf("in-flight imports = %d, want 0", n)
+	}
+}
+
+func TestConfigService_ForceFailImport(t *testing.T) {
+	source, started := newBlockingSource(t)
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo, WithPrivateImportURLs(true))
+	defer service.importWorker.Wait()
+
+	importRecord, err := service.ImportConfig(1, models.SourceURL, source.URL+"/app.yaml", "", false)
+	if err != nil {
+		t.Fatalf("ImportConfig() error = %v", err)
+	}
+	waitFor(t, started)
+
+	if _, err := service.ForceFailImport(importRecord.ID, false); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("ForceFailImport() as non-admin error = %v, want unauthorized", err)
+	}
+
+	failed, err := service.ForceFailImport(importRecord.ID, true)
+	if err != nil {
+		t.Fatalf("ForceFailImport() error = %v", err)
+	}
+	if failed.Status != models.ImportFailed {
+		t.Errorf("status = %q, want %q", failed.Status, models.ImportFailed)
+	}
+
+	// The aborted job returns and frees its slot without overwriting the reason
+	service.importWorker.Wait()
+	if n := service.importWorker.InFlight(); n != 0 {
+		t.Errorf("in-flight imports = %d, want 0", n)
+	}
+	stored, _ := repo.GetImport(importRecord.ID)
+	if stored.ErrorMessage == nil || *stored.ErrorMessage != importAdminFailMessage {
+		t.Errorf("error message = %v, want %q", stored.ErrorMessage, importAdminFailMessage)
+	}
+
+	if _, err := service.ForceFailImport(importRecord.ID, true); err == nil || !strings.Contains(err.Error(), "not in progress") {
+		t.Errorf("second ForceFailImport() error = %v, want not in progress", err)
+	}
+}
+
+func TestConfigService_ListImportsByStatus(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	now := time.Now()
+	for i, status := range []models.ImportStatus{models.ImportProcessing, models.ImportCompleted, models.ImportProcessing} {
+		createdAt := now.Add(-time.Duration(3-i) * time.Hour)
+		repo.now = func() time.Time { return createdAt }
+		if err := repo.CreateImport(&models.ConfigImport{UserID: i + 1, SourceType: models.SourceURL, Status: status}); err != nil {
+			t.Fatalf("CreateImport() error = %v", err)
+		}
+	}
+
+	if _, _, err := service.ListImportsByStatus(models.ImportProcessing, false, 1, 10); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("ListImportsByStatus() as non-admin error = %v, want unauthorized", err)
+	}
+	if _, _, err := service.ListImportsByStatus("stuck", true, 1, 10); err == nil || !strings.Contains(err.Error(), "validation failed") {
+		t.Errorf("ListImportsByStatus() with unknown status error = %v, want validation failed", err)
+	}
+
+	imports, total, err := service.ListImportsByStatus(models.ImportProcessing, true, 1, 10)
+	if err != nil {
+		t.Fatalf("ListImportsByStatus() error = %v", err)
+	}
+	if total != 2 || len(imports) != 2 {
+		t.Fatalf("got %d imports of %d, want 2 of 2", len(imports), total)
+	}
+	if imports[0].UserID != 1 || imports[1].UserID != 3 {
+		t.Errorf("imports by user = [%d %d], want oldest first [1 3]", imports[0].UserID, imports[1].UserID)
+	}
+	if age := imports[0].AgeSeconds; age < 3*3600 || age > 3*3600+60 {
+		t.Errorf("age = %ds, want about 3h", age)
+	}
+}
+
+func TestConfigService_ReconcileStuckImports(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	now := time.Now()
+	create := func(status models.ImportStatus, age time.Duration) int {
+		repo.now = func() time.Time { return now.Add(-age) }
+		importRecord := &models.ConfigImport{UserID: 1, SourceType: models.SourceURL, Status: status}
+		if err := repo.CreateImport(importRecord); err != nil {
+			t.Fatalf("CreateImport() error = %v", err)
+		}
+		return importRecord.ID
+	}
+	stale := create(models.ImportProcessing, 2*time.Hour)
+	recent := create(models.ImportProcessing, time.Minute)
+	pending := create(models.ImportPending, 2*time.Hour)
+
+	reconciled, err := service.ReconcileStuckImports(30 * time.Minute)
+	if err != nil {
+		t.Fatalf("ReconcileStuckImports() error = %v", err)
+	}
+	if reconciled != 1 {
+		t.Errorf("reconciled = %d, want 1", reconciled)
+	}
+
+	want := map[int]models.ImportStatus{stale: models.ImportFailed, recent: models.ImportProcessing, pending: models.ImportPending}
+	for id, status := range want {
+		stored, _ := repo.GetImport(id)
+		if stored.Status != status {
+			t.Errorf("import %d status = %q, want %q", id, stored.Status, status)
+		}
+	}
+	stored, _ := repo.GetImport(stale)
+	if stored.ErrorMessage == nil || *stored.ErrorMessage != importInterruptedMessage {
+		t.Errorf("error message = %v, want %q", stored.ErrorMessage, importInterruptedMessage)
+	}
+}
+
+func TestConfigService_ImportConfig_SourceFailures(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	hanging, _ := newBlockingSource(t)
+	invalid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte{0x00, 0xff, 0x00})
+	}))
+	defer invalid.Close()
+
+	tests := []struct {
+		name             string
+		url              string
+		wantSourceFailed bool
+		wantMessage      string
+	}{
+		{name: "upstream 500", url: failing.URL + "/app.yaml", wantSourceFailed: true, wantMessage: "status 500"},
+		{name: "timeout", url: hanging.URL + "/app.yaml", wantSourceFailed: true, wantMessage: "import source unreachable"},
+		{name: "invalid content", url: invalid.URL + "/app.yaml", wantMessage: "not a text config"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockConfigRepository()
+			service := NewConfigService(repo, WithPrivateImportURLs(true))
+			service.httpClient.Timeout = 100 * time.Millisecond
+
+			importRecord, err := service.ImportConfig(1, models.SourceURL, tt.url, "", false)
+			if err != nil {
+				t.Fatalf("ImportConfig() error = %v", err)
+			}
+			service.importWorker.Wait()
+
+			stored, _ := repo.GetImport(importRecord.ID)
+			if stored.Status != models.ImportFailed || stored.SourceFailed != tt.wantSourceFailed {
+				t.Errorf("import = %s (source_failed %v), want failed with source_failed %v",
+					stored.Status, stored.SourceFailed, tt.wantSourceFailed)
+			}
+			if stored.ErrorMessage == nil || !strings.Contains(*stored.ErrorMessage, tt.wantMessage) {
+				t.Errorf("error message = %v, want it to contain %q", stored.ErrorMessage, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestImportSourceError_MatchesErrImportSource(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := fmt.Errorf("import: %w", &ImportSourceError{Err: cause})
+	if !errors.Is(err, ErrImportSource) || !errors.Is(err, cause) {
+		t.Errorf("errors.Is(%v) should match ErrImportSource and the cause", err)
+	}
+
+	var sourceErr *ImportSourceError
+	upstream := &ImportSourceError{StatusCode: http.StatusBadGateway, Err: errors.New("unexpected status 502")}
+	if !errors.As(error(upstream), &sourceErr) || sourceErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("errors.As() did not recover the status code")
+	}
+	if errors.Is(errors.New("validation failed"), ErrImportSource) {
+		t.Error("unrelated error matched ErrImportSource")
+	}
+}
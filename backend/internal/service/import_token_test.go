@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+// memoryImportTokens stores tokens in memory, keyed by user and source
+type memoryImportTokens map[string]string
+
+func (m memoryImportTokens) key(userID int, sourceType string) string {
+	return fmt.Sprintf("%d/%s", userID, sourceType)
+}
+
+func (m memoryImportTokens) GetToken(ctx context.Context, userID int, sourceType string) (string, error) {
+	return m[m.key(userID, sourceType)], nil
+}
+
+func (m memoryImportTokens) SetToken(ctx context.Context, userID int, sourceType, token string) error {
+	m[m.key(userID, sourceType)] = token
+	return nil
+}
+
+func (m memoryImportTokens) DeleteToken(ctx context.Context, userID int, sourceType string) error {
+	delete(m, m.key(userID, sourceType))
+	return nil
+}
+
+func TestImportTokenService_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	repo := memoryImportTokens{}
+	svc := NewImportTokenService(repo, "server-secret")
+
+	if token, err := svc.ImportToken(ctx, 1, models.SourceGitHub); err != nil || token != "" {
+		t.Fatalf("ImportToken() with none saved = %q, %v; want empty", token, err)
+	}
+
+	if err := svc.SetToken(ctx, 1, models.SourceGitHub, " ghp_secret "); err != nil {
+		t.Fatalf("SetToken() error = %v", err)
+	}
+	if stored := repo[repo.key(1, "github")]; stored == "" || strings.Contains(stored, "ghp_secret") {
+		t.Errorf("stored token %q is not encrypted", stored)
+	}
+	if token, err := svc.ImportToken(ctx, 1, models.SourceGitHub); err != nil || token != "ghp_secret" {
+		t.Errorf("ImportToken() = %q, %v; want ghp_secret", token, err)
+	}
+	if token, _ := svc.ImportToken(ctx, 2, models.SourceGitHub); token != "" {
+		t.Errorf("ImportToken() for another user = %q, want empty", token)
+	}
+
+	// A different server secret can't read the stored token
+	if _, err := NewImportTokenService(repo, "rotated").ImportToken(ctx, 1, models.SourceGitHub); err == nil {
+		t.Error("ImportToken() with a rotated secret: expected an error")
+	}
+
+	if err := svc.DeleteToken(ctx, 1, models.SourceGitHub); err != nil {
+		t.Fatalf("DeleteToken() error = %v", err)
+	}
+	if token, err := svc.ImportToken(ctx, 1, models.SourceGitHub); err != nil || token != "" {
+		t.Errorf("ImportToken() after delete = %q, %v; want empty", token, err)
+	}
+}
+
+func TestImportTokenService_SetTokenValidation(t *testing.T) {
+	svc := NewImportTokenService(memoryImportTokens{}, "server-secret")
+
+	tests := []struct {
+		name   string
+		source models.ConfigSourceType
+		token  string
+	}{
+		{"unsupported source", models.SourceURL, "token"},
+		{"empty token", models.SourceGitHub, "  "},
+		{"oversized token", models.SourceGitHub, strings.Repeat("x", maxImportTokenLength+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.SetToken(context.Background(), 1, tt.source, tt.token)
+			if err == nil || !strings.Contains(err.Error(), "validation failed") {
+				t.Errorf("SetToken() error = %v, want a validation error", err)
+			}
+		})
+	}
+}
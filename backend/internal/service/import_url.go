@@ -0,0 +1,99 @@
+// URL import sources
+// Guards imports from user-supplied URLs against server-side request forgery:
+// only http and https are fetched, and connections to loopback, private, and
+// link-local addresses are refused at dial time, so redirects and DNS answers
+// can't reach internal services either
+package service
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// importFetchTimeout bounds one import download, including redirects
+	importFetchTimeout = 5 * time.Minute
+
+	// maxImportRedirects bounds how many redirects an import follows
+	maxImportRedirects = 10
+)
+
+// WithPrivateImportURLs lets URL imports reach loopback, private, and link-local
+// addresses, for deployments that import from an internal network
+// Off by default; the GitHub API configured with WithGitHubAPI is always reachable
+func WithPrivateImportURLs(allow bool) ConfigServiceOption {
+	return func(s *ConfigService) {
+		s.allowPrivateImports = allow
+	}
+}
+
+// validateImportURL accepts absolute http and https URLs only
+func validateImportURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid import URL: %w", err)
+	}
+	if scheme := strings.ToLower(u.Scheme); scheme != "http" && scheme != "https" {
+		return fmt.Errorf("import URL must use http or https")
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("import URL must include a host")
+	}
+	return nil
+}
+
+// newImportHTTPClient returns the client URL imports are fetched with
+// Unless allowPrivate is set, every connection, including those made for
+// redirects, is checked against the resolved address before it is opened
+func newImportHTTPClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = rejectInternalAddress
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	if !allowPrivate {
+		// A proxy would be dialed instead of the target, bypassing the address check
+		transport.Proxy = nil
+	}
+
+	return &http.Client{
+		Timeout:   importFetchTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxImportRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxImportRedirects)
+			}
+			return validateImportURL(req.URL.String())
+		},
+	}
+}
+
+// rejectInternalAddress is a net.Dialer Control function that refuses
+// connections to addresses inside the server's own network
+func rejectInternalAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("import source address %q: %w", address, err)
+	}
+	if isInternalAddress(addrPort.Addr()) {
+		return fmt.Errorf("import source resolves to a disallowed address: %s", addrPort.Addr())
+	}
+	return nil
+}
+
+// isInternalAddress reports whether addr is loopback, private, link-local,
+// unspecified, or multicast
+func isInternalAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || addr.IsUnspecified()
+}
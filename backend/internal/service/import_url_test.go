@@ -0,0 +1,81 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestConfigService_ImportConfig_RejectsNonHTTPURLs(t *testing.T) {
+	service := NewConfigService(NewMockConfigRepository())
+
+	for _, url := range []string{"file:///etc/passwd", "gopher://example.com/", "ftp://example.com/app.yaml", "/app.yaml"} {
+		_, err := service.ImportConfig(1, models.SourceURL, url, "", false)
+		if err == nil || !strings.Contains(err.Error(), "validation failed") {
+			t.Errorf("ImportConfig(%q) error = %v, want a validation error", url, err)
+		}
+	}
+}
+
+func TestConfigService_ImportConfig_RefusesInternalAddresses(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secret: internal\n"))
+	}))
+	defer internal.Close()
+
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	importRecord, err := service.ImportConfig(1, models.SourceURL, internal.URL+"/app.yaml", "", false)
+	if err != nil {
+		t.Fatalf("ImportConfig() error = %v", err)
+	}
+	service.importWorker.Wait()
+
+	stored, _ := repo.GetImport(importRecord.ID)
+	if stored.Status != models.ImportFailed || !stored.SourceFailed {
+		t.Errorf("import = %s (source_failed %v), want failed with source_failed", stored.Status, stored.SourceFailed)
+	}
+	if stored.ErrorMessage == nil || !strings.Contains(*stored.ErrorMessage, "disallowed address") {
+		t.Errorf("error message = %v, want it to mention a disallowed address", stored.ErrorMessage)
+	}
+}
+
+func TestImportHTTPClient_RefusesRedirectsToOtherSchemes(t *testing.T) {
+	source := httptest.NewServer(http.RedirectHandler("file:///etc/passwd", http.StatusFound))
+	defer source.Close()
+
+	if _, err := newImportHTTPClient(true).Get(source.URL); err == nil || !strings.Contains(err.Error(), "http or https") {
+		t.Errorf("Get() error = %v, want the redirect refused", err)
+	}
+}
+
+func TestIsInternalAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"0.0.0.0", true},
+		{"::ffff:127.0.0.1", true},
+		{"8.8.8.8", false},
+		{"2606:4700::1111", false},
+	}
+
+	for _, tt := range tests {
+		if got := isInternalAddress(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("isInternalAddress(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Security notifications
+// Tells users about security-relevant account events, such as a sign-in from a new device
+// Delivery is asynchronous and best-effort: a failed notification never fails the request
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// notifyTimeout bounds the background work done for one notification,
+// including every exchange with the mail server
+const notifyTimeout = 30 * time.Second
+
+// SecurityEventKind identifies a security-relevant account event
+type SecurityEventKind string
+
+// Security events users are notified about
+const (
+	SecurityEventNewDevice         SecurityEventKind = "new_device_login"
+	SecurityEventPasswordChanged   SecurityEventKind = "password_changed"
+	SecurityEventTwoFactorEnrolled SecurityEventKind = "two_factor_enrolled"
+)
+
+// SecurityEvent describes one event for a notification
+type SecurityEvent struct {
+	Kind      SecurityEventKind
+	Time      time.Time
+	IPAddress string
+	UserAgent string
+}
+
+// Notifier delivers security notifications to users
+type Notifier interface {
+	Notify(ctx context.Context, to string, event SecurityEvent) error
+}
+
+// KnownDeviceRepository remembers the devices each user has signed in from
+// Devices are stored only as fingerprints
+type KnownDeviceRepository interface {
+	// CountDevices returns how many devices the user has signed in from
+	CountDevices(ctx context.Context, userID int) (int, error)
+	// RememberDevice records a sign-in from the device, reporting whether it was new
+	RememberDevice(ctx context.Context, userID int, fingerprint string) (bool, error)
+}
+
+// NopNotifier discards notifications
+// It is the default, so auth works without a mail server configured
+type NopNotifier struct{}
+
+// Notify does nothing
+func (NopNotifier) Notify(ctx context.Context, to string, event SecurityEvent) error {
+	return nil
+}
+
+// SMTPNotifier sends security notifications as plain-text email over SMTP
+// The connection is bound to the context passed to Notify, so a stalled mail
+// server fails the notification at the context's deadline instead of hanging
+type SMTPNotifier struct {
+	host string
+	addr string
+	from string
+	auth smtp.Auth // nil when the server needs no login
+	dial func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// NewSMTPNotifier creates a notifier that sends mail through host:port
+// The server must offer STARTTLS when a username is set, as net/smtp refuses
+// to send credentials in the clear to anything but localhost
+func NewSMTPNotifier(host string, port int, username, password, from string) *SMTPNotifier {
+	n := &SMTPNotifier{
+		host: host,
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+		dial: (&net.Dialer{}).DialContext,
+	}
+	if username != "" {
+		n.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return n
+}
+
+// Notify sends one notification email
+func (n *SMTPNotifier) Notify(ctx context.Context, to string, event SecurityEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid recipient address")
+	}
+
+	subject, body := securityEventMessage(event)
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", event.Time.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	if err := n.send(ctx, to, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send %s notification: %w", event.Kind, err)
+	}
+	return nil
+}
+
+// send delivers msg to one recipient, as smtp.SendMail does, over a connection
+// that is closed once ctx is done
+func (n *SMTPNotifier) send(ctx context.Context, to string, msg []byte) error {
+	conn, err := n.dial(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return err
+		}
+	}
+	if n.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp: server doesn't support AUTH")
+		}
+		if err := c.Auth(n.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(n.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// securityEventMessage returns the subject and body of the email for an event
+func securityEventMessage(event SecurityEvent) (string, string) {
+	var subject, summary string
+	switch event.Kind {
+	case SecurityEventNewDevice:
+		subject = "New sign-in to your Conflux account"
+		summary = "Your account was signed in to from a device we haven't seen before."
+	case SecurityEventPasswordChanged:
+		subject = "Your Conflux password was changed"
+		summary = "The password for your account was changed."
+	case SecurityEventTwoFactorEnrolled:
+		subject = "Two-factor authentication enabled on your Conflux account"
+		summary = "Two-factor authentication was turned on for your account."
+	default:
+		subject = "Security activity on your Conflux account"
+		summary = "There was security-relevant activity on your account."
+	}
+
+	var body strings.Builder
+	body.WriteString(summary + "\n\n")
+	fmt.Fprintf(&body, "Time: %s\n", event.Time.UTC().Format(time.RFC1123))
+	if event.IPAddress != "" {
+		fmt.Fprintf(&body, "IP address: %s\n", event.IPAddress)
+	}
+	if event.UserAgent != "" {
+		fmt.Fprintf(&body, "Device: %s\n", event.UserAgent)
+	}
+	body.WriteString("\nIf this was you, no action is needed. If not, change your password and sign out your other sessions.\n")
+	return subject, body.String()
+}
+
+// deviceFingerprint identifies a device by a hash of its user agent and IP address
+// Only the hash is stored, so the table doesn't keep a history of addresses
+func deviceFingerprint(userAgent, ipAddress string) string {
+	sum := sha256.Sum256([]byte(userAgent + "\x00" + ipAddress))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+)
+
+// fakeKnownDevices is an in-memory KnownDeviceRepository
+type fakeKnownDevices struct {
+	mu      sync.Mutex
+	devices map[int]map[string]bool
+	err     error
+}
+
+func newFakeKnownDevices() *fakeKnownDevices {
+	return &fakeKnownDevices{devices: make(map[int]map[string]bool)}
+}
+
+func (f *fakeKnownDevices) CountDevices(ctx context.Context, userID int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.devices[userID]), f.err
+}
+
+func (f *fakeKnownDevices) RememberDevice(ctx context.Context, userID int, fingerprint string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return false, f.err
+	}
+	if f.devices[userID] == nil {
+		f.devices[userID] = make(map[string]bool)
+	}
+	isNew := !f.devices[userID][fingerprint]
+	f.devices[userID][fingerprint] = true
+	return isNew, nil
+}
+
+// recordingNotifier keeps every notification it is asked to send
+type recordingNotifier struct {
+	mu     sync.Mutex
+	sent   []SecurityEvent
+	to     []string
+	failed bool
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, to string, event SecurityEvent) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.failed {
+		return errors.New("mail server unavailable")
+	}
+	n.sent = append(n.sent, event)
+	n.to = append(n.to, to)
+	return nil
+}
+
+func newNotifyingAuthService(t *testing.T, notifier Notifier, devices KnownDeviceRepository) *AuthService {
+	t.Helper()
+	userRepo := NewMockUserRepository()
+	if err := userRepo.Create(context.Background(), &models.User{
+		Email:         "notify@example.com",
+		Password:      mustHashPassword("password123"),
+		EmailVerified: true,
+	}); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewAuthService(userRepo, NewMockAuthRepository(), testTokenManager, WithSecurityNotifications(notifier, devices, logger))
+}
+
+func loginFrom(t *testing.T, s *AuthService, ipAddress, userAgent string) {
+	t.Helper()
+	req := &models.LoginRequest{Email: "notify@example.com", Password: "password123"}
+	if _, err := s.Login(context.Background(), req, ipAddress, userAgent); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	s.WaitForNotifications()
+}
+
+func TestAuthService_NewDeviceNotification(t *testing.T) {
+	notifier := &recordingNotifier{}
+	devices := newFakeKnownDevices()
+	s := newNotifyingAuthService(t, notifier, devices)
+
+	// The first sign-in only records the device
+	loginFrom(t, s, "10.0.0.1", "laptop")
+	if len(notifier.sent) != 0 {
+		t.Fatalf("first login sent %d notifications, want none", len(notifier.sent))
+	}
+
+	// The same device again is known
+	loginFrom(t, s, "10.0.0.1", "laptop")
+	if len(notifier.sent) != 0 {
+		t.Fatalf("repeat login sent %d notifications, want none", len(notifier.sent))
+	}
+
+	// A different user agent or address is a new device
+	loginFrom(t, s, "10.0.0.1", "phone")
+	loginFrom(t, s, "192.168.1.5", "laptop")
+	if len(notifier.sent) != 2 {
+		t.Fatalf("sent %d notifications, want 2", len(notifier.sent))
+	}
+	event := notifier.sent[0]
+	if event.Kind != SecurityEventNewDevice || event.IPAddress != "10.0.0.1" || event.UserAgent != "phone" {
+		t.Errorf("event = %+v, want a new-device event for the phone", event)
+	}
+	if notifier.to[0] != "notify@example.com" {
+		t.Errorf("notified %q, want the user's email", notifier.to[0])
+	}
+
+	// Only fingerprints are stored
+	for fingerprint := range devices.devices[1] {
+		if len(fingerprint) != 64 || strings.Contains(fingerprint, "laptop") {
+			t.Errorf("stored device %q, want a sha256 hex fingerprint", fingerprint)
+		}
+	}
+}
+
+func TestAuthService_NotificationFailuresDontFailLogin(t *testing.T) {
+	notifier := &recordingNotifier{failed: true}
+	devices := newFakeKnownDevices()
+	s := newNotifyingAuthService(t, notifier, devices)
+
+	loginFrom(t, s, "10.0.0.1", "laptop")
+	loginFrom(t, s, "10.0.0.2", "laptop")
+
+	devices.err = errors.New("database unavailable")
+	loginFrom(t, s, "10.0.0.3", "laptop")
+}
+
+func TestAuthService_NotifySecurityEvent(t *testing.T) {
+	notifier := &recordingNotifier{}
+	s := newNotifyingAuthService(t, notifier, nil)
+
+	s.NotifySecurityEvent("notify@example.com", SecurityEvent{Kind: SecurityEventPasswordChanged})
+	s.WaitForNotifications()
+
+	if len(notifier.sent) != 1 || notifier.sent[0].Kind != SecurityEventPasswordChanged {
+		t.Fatalf("sent = %+v, want one password-changed event", notifier.sent)
+	}
+	if notifier.sent[0].Time.IsZero() {
+		t.Error("event time not set")
+	}
+}
+
+func TestAuthService_CompletePasswordChange(t *testing.T) {
+	ctx := context.Background()
+	notifier := &recordingNotifier{}
+	s := newNotifyingAuthService(t, notifier, nil)
+	sessions := s.authRepo.(*MockAuthRepository)
+
+	req := &models.LoginRequest{Email: "notify@example.com", Password: "password123"}
+	var tokens []string
+	for i := 0; i < 3; i++ {
+		resp, err := s.Login(ctx, req, "10.0.0.1", "laptop")
+		if err != nil {
+			t.Fatalf("Login() error = %v", err)
+		}
+		tokens = append(tokens, resp.Token)
+	}
+
+	if err := s.CompletePasswordChange(ctx, 1, tokens[0], "10.0.0.9", "phone"); err != nil {
+		t.Fatalf("CompletePasswordChange() error = %v", err)
+	}
+	s.WaitForNotifications()
+
+	// Only the session that changed the password stays signed in
+	if sessions.SessionCount() != 1 || !sessions.HasSession(tokens[0]) {
+		t.Errorf("sessions = %d (current kept: %v), want only the current one", sessions.SessionCount(), sessions.HasSession(tokens[0]))
+	}
+	if len(notifier.sent) != 1 {
+		t.Fatalf("sent %d notifications, want 1", len(notifier.sent))
+	}
+	event := notifier.sent[0]
+	if event.Kind != SecurityEventPasswordChanged || event.IPAddress != "10.0.0.9" || event.UserAgent != "phone" {
+		t.Errorf("event = %+v, want a password-changed event from the phone", event)
+	}
+	if notifier.to[0] != "notify@example.com" {
+		t.Errorf("notified %q, want the user's email", notifier.to[0])
+	}
+}
+
+// smtpTranscript is what a fakeSMTPServer received
+type smtpTranscript struct {
+	from, data string
+	to         []string
+}
+
+// serveFakeSMTP answers one SMTP session on conn, without STARTTLS or AUTH,
+// and sends what it received on the returned channel when the session ends
+func serveFakeSMTP(conn net.Conn) <-chan smtpTranscript {
+	done := make(chan smtpTranscript, 1)
+	go func() {
+		defer conn.Close()
+		var got smtpTranscript
+		defer func() { done <- got }()
+
+		text := textproto.NewConn(conn)
+		reply := func(line string) bool { return text.PrintfLine("%s", line) == nil }
+		if !reply("220 mail.example.com ESMTP") {
+			return
+		}
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch verb {
+			case "EHLO", "HELO":
+				reply("250 mail.example.com")
+			case "MAIL":
+				got.from = strings.TrimSuffix(strings.TrimPrefix(line, "MAIL FROM:<"), ">")
+				reply("250 OK")
+			case "RCPT":
+				got.to = append(got.to, strings.TrimSuffix(strings.TrimPrefix(line, "RCPT TO:<"), ">"))
+				reply("250 OK")
+			case "DATA":
+				reply("354 Go ahead")
+				data, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				got.data = string(data)
+				reply("250 Queued")
+			case "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("502 Not implemented")
+			}
+		}
+	}()
+	return done
+}
+
+func TestSMTPNotifier_Notify(t *testing.T) {
+	n := NewSMTPNotifier("smtp.example.com", 587, "", "", "security@example.com")
+
+	var gotAddr string
+	var received <-chan smtpTranscript
+	n.dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		gotAddr = addr
+		client, server := net.Pipe()
+		received = serveFakeSMTP(server)
+		return client, nil
+	}
+
+	event := SecurityEvent{
+		Kind:      SecurityEventNewDevice,
+		Time:      time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		IPAddress: "203.0.113.7",
+		UserAgent: "Mozilla/5.0",
+	}
+	if err := n.Notify(context.Background(), "user@example.com", event); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	got := <-received
+	if gotAddr != "smtp.example.com:587" || got.from != "security@example.com" {
+		t.Errorf("sent via %s from %s, want smtp.example.com:587 from security@example.com", gotAddr, got.from)
+	}
+	if len(got.to) != 1 || got.to[0] != "user@example.com" {
+		t.Errorf("recipients = %v, want user@example.com", got.to)
+	}
+	for _, want := range []string{"To: user@example.com\n", "Subject: New sign-in", "203.0.113.7", "Mozilla/5.0"} {
+		if !strings.Contains(got.data, want) {
+			t.Errorf("message missing %q:\n%s", want, got.data)
+		}
+	}
+
+	// Header injection through the recipient is refused
+	if err := n.Notify(context.Background(), "user@example.com\r\nBcc: x@example.com", event); err == nil {
+		t.Error("Notify() with a CRLF recipient succeeded, want error")
+	}
+}
+
+func TestSMTPNotifier_NotifyStalledServer(t *testing.T) {
+	n := NewSMTPNotifier("smtp.example.com", 587, "", "", "security@example.com")
+
+	// The server accepts the connection but never greets
+	n.dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		t.Cleanup(func() { server.Close() })
+		return client, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result := make(chan error, 1)
+	go func() {
+		result <- n.Notify(ctx, "user@example.com", SecurityEvent{Kind: SecurityEventNewDevice, Time: time.Now()})
+	}()
+
+	select {
+	case err := <-result:
+		if err == nil {
+			t.Error("Notify() to a stalled server succeeded, want error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Notify() to a stalled server didn't return after its context expired")
+	}
+}
@@ -0,0 +1,47 @@
+// Session expiry
+// Idle timeouts end sessions that go unused, independently of the token's absolute
+// expiry, and the janitor deletes sessions that have expired either way
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultSessionJanitorInterval is how often expired sessions are deleted
+const DefaultSessionJanitorInterval = 15 * time.Minute
+
+// WithSessionIdleTimeout expires sessions with no validated request for timeout,
+// even when their token is still within its absolute expiry; 0 disables it
+func WithSessionIdleTimeout(timeout time.Duration) AuthServiceOption {
+	return func(s *AuthService) {
+		s.idleTimeout = max(timeout, 0)
+	}
+}
+
+// CleanupSessions deletes sessions past their absolute expiry or idle timeout
+// Returns how many were removed
+func (s *AuthService) CleanupSessions(ctx context.Context) (int64, error) {
+	return s.authRepo.DeleteExpiredSessions(ctx, s.idleTimeout)
+}
+
+// RunSessionJanitor calls CleanupSessions every interval until ctx is done
+// Expired sessions are already rejected; this only keeps the table from growing
+func (s *AuthService) RunSessionJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := s.CleanupSessions(ctx)
+			if err != nil {
+				s.logger.Warn("Session cleanup failed", "error", err)
+			} else if removed > 0 {
+				s.logger.Info("Removed expired sessions", "count", removed)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+)
+
+func TestAuthService_SessionIdleTimeout(t *testing.T) {
+	ctx := context.Background()
+	mockUserRepo := NewMockUserRepository()
+	mockAuthRepo := NewMockAuthRepository()
+
+	user := &models.User{Email: "idle@example.com", Password: mustHashPassword("password123"), EmailVerified: true}
+	if err := mockUserRepo.Create(ctx, user); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	loginReq := &models.LoginRequest{Email: user.Email, Password: "password123"}
+
+	// Without an idle timeout only the token's expiry applies
+	defaultService := NewAuthService(mockUserRepo, mockAuthRepo, testTokenManager)
+	idleService := NewAuthService(mockUserRepo, mockAuthRepo, testTokenManager, WithSessionIdleTimeout(time.Hour))
+
+	active, err := idleService.Login(ctx, loginReq, "10.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	idle, err := idleService.Login(ctx, loginReq, "10.0.0.2", "test-agent")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	mockAuthRepo.sessions[idle.Token].LastActivity = time.Now().Add(-2 * time.Hour)
+
+	if _, err := defaultService.ValidateToken(ctx, idle.Token); err != nil {
+		t.Errorf("ValidateToken() without idle timeout error = %v", err)
+	}
+	mockAuthRepo.sessions[idle.Token].LastActivity = time.Now().Add(-2 * time.Hour)
+
+	if _, err := idleService.ValidateToken(ctx, active.Token); err != nil {
+		t.Errorf("ValidateToken() for active session error = %v", err)
+	}
+	if _, err := idleService.ValidateToken(ctx, idle.Token); err == nil {
+		t.Error("ValidateToken() succeeded for an idle session")
+	}
+
+	sessions, err := idleService.ListSessions(ctx, user.ID, active.Token)
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(sessions) != 1 || !sessions[0].Current {
+		t.Errorf("ListSessions() = %+v, want only the active session", sessions)
+	}
+
+	// The janitor removes idle sessions but keeps active ones
+	removed, err := idleService.CleanupSessions(ctx)
+	if err != nil {
+		t.Fatalf("CleanupSessions() error = %v", err)
+	}
+	if removed != 1 || !mockAuthRepo.HasSession(active.Token) || mockAuthRepo.HasSession(idle.Token) {
+		t.Errorf("CleanupSessions() removed %d, want only the idle session", removed)
+	}
+}
+
+func TestAuthService_CleanupSessionsWithoutIdleTimeout(t *testing.T) {
+	ctx := context.Background()
+	mockAuthRepo := NewMockAuthRepository()
+	authService := NewAuthService(NewMockUserRepository(), mockAuthRepo, testTokenManager)
+
+	_ = mockAuthRepo.CreateSession(ctx, 1, "expired", "expired-token", time.Now().Add(-time.Minute))
+	_ = mockAuthRepo.CreateSession(ctx, 1, "stale", "stale-token", time.Now().Add(time.Hour))
+	mockAuthRepo.sessions["stale-token"].LastActivity = time.Now().Add(-30 * 24 * time.Hour)
+
+	removed, err := authService.CleanupSessions(ctx)
+	if err != nil {
+		t.Fatalf("CleanupSessions() error = %v", err)
+	}
+	if removed != 1 || !mockAuthRepo.HasSession("stale-token") {
+		t.Errorf("CleanupSessions() removed %d, want only the expired session", removed)
+	}
+}
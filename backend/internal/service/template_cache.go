@@ -0,0 +1,94 @@
+// In-memory cache for configuration templates
+// Templates are shared and change rarely, so reads are served from memory for a TTL
+// Only templates are cached; user-specific data always comes from the repository
+package service
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"conflux/internal/models"
+)
+
+// templateCache holds templates by ID until they expire or are invalidated
+type templateCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu         sync.RWMutex
+	entries    map[int]templateCacheEntry
+	generation uint64 // Bumped on every invalidation
+}
+
+type templateCacheEntry struct {
+	template  *models.ConfigTemplate
+	expiresAt time.Time
+}
+
+func newTemplateCache(ttl time.Duration) *templateCache {
+	return &templateCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[int]templateCacheEntry),
+	}
+}
+
+// get returns a deep copy of the cached template, if present and unexpired,
+// along with the generation to pass to set after a miss
+func (c *templateCache) get(id int) (*models.ConfigTemplate, uint64, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[id]
+	if !ok || !c.now().Before(entry.expiresAt) {
+		return nil, c.generation, false
+	}
+	return cloneTemplate(entry.template), c.generation, true
+}
+
+// set caches a deep copy of a template loaded from the repository
+// The entry is dropped if an invalidation happened since the load began,
+// so a read racing an update can't cache the old template
+func (c *templateCache) set(id int, template *models.ConfigTemplate, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	c.entries[id] = templateCacheEntry{template: cloneTemplate(template), expiresAt: c.now().Add(c.ttl)}
+}
+
+// invalidate removes a template from the cache
+func (c *templateCache) invalidate(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, id)
+	c.generation++
+}
+
+// cloneTemplate deep-copies a template, so neither the cache nor its callers
+// can change the other's slices or pointed-to values
+func cloneTemplate(template *models.ConfigTemplate) *models.ConfigTemplate {
+	clone := *template
+	clone.SupportedFormats = slices.Clone(template.SupportedFormats)
+	clone.Schema = clonePtr(template.Schema)
+	clone.Warnings = slices.Clone(template.Warnings)
+	clone.Variables = slices.Clone(template.Variables)
+	for i := range clone.Variables {
+		clone.Variables[i].DefaultValue = clonePtr(clone.Variables[i].DefaultValue)
+		clone.Variables[i].ValidationRule = clonePtr(clone.Variables[i].ValidationRule)
+	}
+	return &clone
+}
+
+// clonePtr returns a pointer to a copy of *p, or nil when p is nil
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+)
+
+// countingConfigRepository counts template reads that reach the repository
+type countingConfigRepository struct {
+	*MockConfigRepository
+	templateReads atomic.Int64
+	afterRead     func() // Runs once after the next template read
+}
+
+func (r *countingConfigRepository) GetTemplate(id int) (*models.ConfigTemplate, error) {
+	r.templateReads.Add(1)
+	template, err := r.MockConfigRepository.GetTemplate(id)
+	if hook := r.afterRead; hook != nil {
+		r.afterRead = nil
+		hook()
+	}
+	return template, err
+}
+
+func TestConfigService_TemplateCache(t *testing.T) {
+	repo := &countingConfigRepository{MockConfigRepository: NewMockConfigRepository()}
+	service := NewConfigService(repo, WithTemplateCache(time.Minute))
+
+	now := time.Now()
+	service.templates.now = func() time.Time { return now }
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 1"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+
+	// Repeated reads and config creation share one repository read
+	for i := 0; i < 3; i++ {
+		if _, err := service.GetTemplate(template.ID); err != nil {
+			t.Fatalf("GetTemplate() error = %v", err)
+		}
+	}
+	if _, err := service.CreateUserConfig(1, template.ID, "app", nil); err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	if reads := repo.templateReads.Load(); reads != 1 {
+		t.Errorf("repository reads = %d, want 1", reads)
+	}
+
+	// Callers get copies, so mutating one doesn't poison the cache
+	cached, _ := service.GetTemplate(template.ID)
+	cached.DefaultContent = "mutated"
+	if again, _ := service.GetTemplate(template.ID); again.DefaultContent != "port: 1" {
+		t.Errorf("cached content = %q, want unchanged", again.DefaultContent)
+	}
+
+	// Updates invalidate
+	if err := service.UpdateTemplate(template.ID, &models.ConfigTemplate{Name: "app", DefaultContent: "port: 2"}); err != nil {
+		t.Fatalf("UpdateTemplate() error = %v", err)
+	}
+	updated, err := service.GetTemplate(template.ID)
+	if err != nil {
+		t.Fatalf("GetTemplate() error = %v", err)
+	}
+	if updated.DefaultContent != "port: 2" {
+		t.Errorf("content after update = %q, want port: 2", updated.DefaultContent)
+	}
+
+	// Entries expire after the TTL
+	reads := repo.templateReads.Load()
+	now = now.Add(time.Minute)
+	if _, err := service.GetTemplate(template.ID); err != nil {
+		t.Fatalf("GetTemplate() error = %v", err)
+	}
+	if got := repo.templateReads.Load(); got != reads+1 {
+		t.Errorf("repository reads after expiry = %d, want %d", got, reads+1)
+	}
+
+	// Deletes invalidate
+	if err := service.DeleteTemplate(template.ID); err != nil {
+		t.Fatalf("DeleteTemplate() error = %v", err)
+	}
+	if _, err := service.GetTemplate(template.ID); err == nil {
+		t.Error("GetTemplate() after delete returned a cached template")
+	}
+}
+
+func TestTemplateCache_DeepCopies(t *testing.T) {
+	cache := newTemplateCache(time.Minute)
+	schema, defaultValue := `{"type": "object"}`, "8080"
+	template := &models.ConfigTemplate{
+		ID:               1,
+		SupportedFormats: []models.ConfigFormat{models.FormatYAML},
+		Schema:           &schema,
+		Variables:        []models.ConfigVariable{{Name: "PORT", DefaultValue: &defaultValue}},
+	}
+
+	// Changing the caller's template after set doesn't reach the cache
+	_, generation, _ := cache.get(1)
+	cache.set(1, template, generation)
+	template.SupportedFormats[0] = models.FormatJSON
+	*template.Schema = "{}"
+	template.Variables[0].Name = "HOST"
+	*template.Variables[0].DefaultValue = "80"
+
+	// Nor does changing a template returned by get
+	got, _, _ := cache.get(1)
+	got.SupportedFormats[0] = models.FormatTOML
+	*got.Schema = "[]"
+	got.Variables[0].Name = "ADDR"
+	*got.Variables[0].DefaultValue = "443"
+
+	cached, _, _ := cache.get(1)
+	if cached.SupportedFormats[0] != models.FormatYAML || *cached.Schema != `{"type": "object"}` ||
+		cached.Variables[0].Name != "PORT" || *cached.Variables[0].DefaultValue != "8080" {
+		t.Errorf("cached template = %+v, want it unchanged", cached)
+	}
+}
+
+func TestConfigService_TemplateCacheDisabled(t *testing.T) {
+	repo := &countingConfigRepository{MockConfigRepository: NewMockConfigRepository()}
+	service := NewConfigService(repo, WithTemplateCache(0))
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 1"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := service.GetTemplate(template.ID); err != nil {
+			t.Fatalf("GetTemplate() error = %v", err)
+		}
+	}
+	if reads := repo.templateReads.Load(); reads != 3 {
+		t.Errorf("repository reads = %d, want 3", reads)
+	}
+}
+
+func TestConfigService_TemplateCacheReadRacingUpdate(t *testing.T) {
+	repo := &countingConfigRepository{MockConfigRepository: NewMockConfigRepository()}
+	service := NewConfigService(repo, WithTemplateCache(time.Minute))
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 1"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+
+	// The update lands after the miss read the old template but before it is cached
+	repo.afterRead = func() {
+		if err := service.UpdateTemplate(template.ID, &models.ConfigTemplate{Name: "app", DefaultContent: "port: 2"}); err != nil {
+			t.Fatalf("UpdateTemplate() error = %v", err)
+		}
+	}
+	if stale, _ := service.GetTemplate(template.ID); stale.DefaultContent != "port: 1" {
+		t.Fatalf("racing read = %q, want the old template", stale.DefaultContent)
+	}
+
+	current, err := service.GetTemplate(template.ID)
+	if err != nil {
+		t.Fatalf("GetTemplate() error = %v", err)
+	}
+	if current.DefaultContent != "port: 2" {
+		t.Errorf("content = %q, want port: 2 (stale entry cached)", current.DefaultContent)
+	}
+}
+
+// Run with -race: readers and writers hit the same template concurrently,
+// and once writers finish every read must see the final content
+func TestConfigService_TemplateCacheConcurrent(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo, WithTemplateCache(time.Minute))
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 0"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+
+	const writes = 50
+	var wg sync.WaitGroup
+	for r := 0; r < 8; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				if _, err := service.GetTemplate(template.ID); err != nil {
+					t.Errorf("GetTemplate() error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= writes; i++ {
+			updates := &models.ConfigTemplate{Name: "app", DefaultContent: fmt.Sprintf("port: %d", i)}
+			if err := service.UpdateTemplate(template.ID, updates); err != nil {
+				t.Errorf("UpdateTemplate() error = %v", err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	final, err := service.GetTemplate(template.ID)
+	if err != nil {
+		t.Fatalf("GetTemplate() error = %v", err)
+	}
+	if want := fmt.Sprintf("port: %d", writes); final.DefaultContent != want {
+		t.Errorf("content = %q, want %q (stale cache entry)", final.DefaultContent, want)
+	}
+}
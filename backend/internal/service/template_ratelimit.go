@@ -0,0 +1,124 @@
+// Per-template creation rate limiting
+// Caps how fast configurations are created from any one template, so a popular
+// template under a traffic spike can't flood the template read and version write paths
+package service
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// minTemplateBucketIdleTTL is the shortest time an unused template bucket is kept
+// Limits that take longer to refill a whole burst keep their buckets that long instead
+const minTemplateBucketIdleTTL = 10 * time.Minute
+
+// RateLimitError reports that a template's creation limit was exceeded
+type RateLimitError struct {
+	TemplateID int
+	RetryAfter time.Duration // How long until the template accepts another creation
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded for template %d, retry in %s",
+		e.TemplateID, e.RetryAfter.Round(time.Second))
+}
+
+// templateRateLimiter is a token bucket per template ID
+type templateRateLimiter struct {
+	rate    float64 // Tokens added per second
+	burst   int
+	idleTTL time.Duration // How long an unused bucket is kept; it has refilled completely by then
+	now     func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[int]*templateBucket
+	lastSweep time.Time
+}
+
+type templateBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+func newTemplateRateLimiter(perMinute, burst int) *templateRateLimiter {
+	if burst <= 0 {
+		burst = perMinute
+	}
+	rate := float64(perMinute) / 60
+
+	// Dropping a bucket that has refilled completely changes nothing
+	idleTTL := time.Duration(float64(burst) / rate * float64(time.Second))
+	if idleTTL < minTemplateBucketIdleTTL {
+		idleTTL = minTemplateBucketIdleTTL
+	}
+
+	return &templateRateLimiter{
+		rate:    rate,
+		burst:   burst,
+		idleTTL: idleTTL,
+		now:     time.Now,
+		buckets: make(map[int]*templateBucket),
+	}
+}
+
+// WithTemplateCreateRateLimit limits configuration creation to perMinute per template,
+// allowing bursts of up to burst (perMinute when burst is 0); perMinute 0 disables the limit
+func WithTemplateCreateRateLimit(perMinute, burst int) ConfigServiceOption {
+	return func(s *ConfigService) {
+		s.createLimiter = nil
+		if perMinute > 0 {
+			s.createLimiter = newTemplateRateLimiter(perMinute, burst)
+		}
+	}
+}
+
+// allowCreate consumes a creation token for the template, if the limit is enabled
+func (s *ConfigService) allowCreate(templateID int) error {
+	if s.createLimiter == nil {
+		return nil
+	}
+	return s.createLimiter.take(templateID)
+}
+
+// take consumes a token from the template's bucket or reports when one will be available
+func (l *templateRateLimiter) take(templateID int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[templateID]
+	if !ok {
+		bucket = &templateBucket{tokens: float64(l.burst), lastSeen: now}
+		l.buckets[templateID] = bucket
+	}
+
+	// Refill based on elapsed time since the last creation
+	elapsed := now.Sub(bucket.lastSeen).Seconds()
+	bucket.tokens = math.Min(float64(l.burst), bucket.tokens+elapsed*l.rate)
+	bucket.lastSeen = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+		return &RateLimitError{TemplateID: templateID, RetryAfter: wait}
+	}
+	bucket.tokens--
+	return nil
+}
+
+// sweep evicts idle buckets so the map doesn't grow with every template ever used
+func (l *templateRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for id, bucket := range l.buckets {
+		if now.Sub(bucket.lastSeen) > l.idleTTL {
+			delete(l.buckets, id)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"conflux/internal/models"
+)
+
+func TestConfigService_TemplateCreateRateLimit(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo, WithTemplateCreateRateLimit(6, 2))
+
+	now := time.Now()
+	service.createLimiter.now = func() time.Time { return now }
+
+	var templates []*models.ConfigTemplate
+	for _, name := range []string{"popular", "quiet"} {
+		template := &models.ConfigTemplate{Name: name, Format: models.FormatYAML, DefaultContent: "port: 1"}
+		if err := service.CreateTemplate(template); err != nil {
+			t.Fatalf("CreateTemplate() error = %v", err)
+		}
+		templates = append(templates, template)
+	}
+	popular, quiet := templates[0].ID, templates[1].ID
+
+	// The burst is spent, then the template is refused until a token refills
+	for i := 0; i < 2; i++ {
+		if _, err := service.CreateUserConfig(1, popular, "app", nil); err != nil {
+			t.Fatalf("CreateUserConfig() #%d error = %v", i+1, err)
+		}
+	}
+	_, err := service.CreateUserConfig(2, popular, "app", nil)
+	var limited *RateLimitError
+	if !errors.As(err, &limited) {
+		t.Fatalf("CreateUserConfig() error = %v, want RateLimitError", err)
+	}
+	if limited.RetryAfter != 10*time.Second {
+		t.Errorf("RetryAfter = %v, want 10s", limited.RetryAfter)
+	}
+
+	// Other templates have their own budget
+	if _, err := service.CreateUserConfig(2, quiet, "app", nil); err != nil {
+		t.Errorf("CreateUserConfig() on another template error = %v", err)
+	}
+
+	// Refused requests don't create anything
+	configs, total, _ := repo.GetUserConfigs(2, &popular, models.DefaultUserConfigSort, 1, 10)
+	if total != 0 {
+		t.Errorf("configs created while limited = %d (%v), want 0", total, configs)
+	}
+
+	now = now.Add(10 * time.Second)
+	if _, err := service.CreateUserConfig(2, popular, "app", nil); err != nil {
+		t.Errorf("CreateUserConfig() after refill error = %v", err)
+	}
+}
+
+func TestConfigService_TemplateCreateRateLimitSkipsFailedCreates(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo, WithTemplateCreateRateLimit(6, 1), WithUniqueConfigNames(true))
+
+	now := time.Now()
+	service.createLimiter.now = func() time.Time { return now }
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 1"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	if _, err := service.CreateUserConfig(1, template.ID, "taken", nil); err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	now = now.Add(10 * time.Second)
+
+	// A missing template, unknown variables, and a duplicate name leave the token alone
+	if _, err := service.CreateUserConfig(1, template.ID+100, "app", nil); err == nil {
+		t.Error("CreateUserConfig() from a missing template succeeded")
+	}
+	if _, err := service.CreateUserConfig(1, template.ID, "app", map[string]string{"NOPE": "x"}); err == nil {
+		t.Error("CreateUserConfig() with an unknown variable succeeded")
+	}
+	if _, err := service.CreateUserConfig(1, template.ID, "taken", nil); err == nil {
+		t.Error("CreateUserConfig() with a duplicate name succeeded")
+	}
+	if len(service.createLimiter.buckets) != 1 {
+		t.Errorf("buckets = %d, want only the existing template's", len(service.createLimiter.buckets))
+	}
+	if _, err := service.CreateUserConfig(2, template.ID, "app", nil); err != nil {
+		t.Errorf("CreateUserConfig() after failed creates error = %v", err)
+	}
+}
+
+func TestTemplateRateLimiter_IdleTTLCoversRefill(t *testing.T) {
+	tests := []struct {
+		perMinute, burst int
+		want             time.Duration
+	}{
+		{perMinute: 60, burst: 10, want: minTemplateBucketIdleTTL},
+		{perMinute: 1, burst: 30, want: 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := newTemplateRateLimiter(tt.perMinute, tt.burst).idleTTL; got != tt.want {
+			t.Errorf("idleTTL(%d/min, burst %d) = %v, want %v", tt.perMinute, tt.burst, got, tt.want)
+		}
+	}
+}
+
+func TestConfigService_TemplateCreateRateLimitOffByDefault(t *testing.T) {
+	service := NewConfigService(NewMockConfigRepository())
+	if service.createLimiter != nil {
+		t.Fatal("createLimiter is set without WithTemplateCreateRateLimit")
+	}
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 1"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		if _, err := service.CreateUserConfig(1, template.ID, "app", nil); err != nil {
+			t.Fatalf("CreateUserConfig() #%d error = %v", i+1, err)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+// Template versioning
+// Bumps a template's semantic version when its content, schema, or variables change
+// and compares configurations against the template version they were created from
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"conflux/internal/models"
+)
+
+// initialTemplateVersion is assigned to templates created without a version
+const initialTemplateVersion = "1.0.0"
+
+// TemplateVersionPolicy chooses which version component each kind of template change bumps
+// BumpNone leaves the version alone for that kind of change
+type TemplateVersionPolicy struct {
+	Content   models.VersionBump // Default content changed
+	Additions models.VersionBump // Schema changed, or variables added or modified
+	Removals  models.VersionBump // Variables or schema properties removed
+}
+
+// DefaultTemplateVersionPolicy bumps patch for content, minor for additions, and major for removals
+var DefaultTemplateVersionPolicy = TemplateVersionPolicy{
+	Content:   models.BumpPatch,
+	Additions: models.BumpMinor,
+	Removals:  models.BumpMajor,
+}
+
+// WithTemplateVersionPolicy overrides how template updates bump the template version
+func WithTemplateVersionPolicy(policy TemplateVersionPolicy) ConfigServiceOption {
+	return func(s *ConfigService) {
+		s.versionPolicy = policy
+	}
+}
+
+// GetTemplateVersions returns a template's version history, newest first
+func (s *ConfigService) GetTemplateVersions(templateID int) ([]*models.TemplateVersion, error) {
+	if _, err := s.GetTemplate(templateID); err != nil {
+		return nil, err
+	}
+	versions, err := s.configRepo.GetTemplateVersions(templateID)
+	return emptyIfNil(versions), err
+}
+
+// GetTemplateDrift reports how many template versions a configuration is behind
+// Configurations created before versioning count every recorded bump
+func (s *ConfigService) GetTemplateDrift(configID, userID int) (*models.TemplateDrift, error) {
+	userConfig, err := s.GetUserConfig(configID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if userConfig.TemplateID == nil {
+		return nil, fmt.Errorf("validation failed: configuration is not based on a template")
+	}
+
+	template, err := s.GetTemplate(*userConfig.TemplateID)
+	if err != nil {
+		return nil, fmt.Errorf("template not found: %w", err)
+	}
+	history, err := s.configRepo.GetTemplateVersions(template.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := &models.TemplateDrift{
+		TemplateID:     template.ID,
+		ConfigVersion:  userConfig.TemplateVersion,
+		CurrentVersion: template.Version,
+	}
+	for _, version := range history {
+		if version.Version == userConfig.TemplateVersion {
+			break
+		}
+		drift.VersionsBehind++
+	}
+	return drift, nil
+}
+
+// templateBump works out how an update changes a template and the resulting bump
+// variables are the template's current variables; nil fields in updates are left unchanged
+func (s *ConfigService) templateBump(
+	existing *models.ConfigTemplate, variables []*models.ConfigVariable, updates *models.ConfigTemplate,
+) (models.VersionBump, []string) {
+	bump := models.BumpNone
+	var changes []string
+	record := func(level models.VersionBump, change string) {
+		if level == models.BumpNone {
+			return
+		}
+		if bumpRank(level) > bumpRank(bump) {
+			bump = level
+		}
+		changes = append(changes, change)
+	}
+
+	if updates.DefaultContent != "" && updates.DefaultContent != existing.DefaultContent {
+		record(s.versionPolicy.Content, "default content changed")
+	}
+
+	if updates.Schema != nil && (existing.Schema == nil || *existing.Schema != *updates.Schema) {
+		var previous string
+		if existing.Schema != nil {
+			previous = *existing.Schema
+		}
+		if removed := removedKeys(schemaProperties(previous), schemaProperties(*updates.Schema)); len(removed) > 0 {
+			record(s.versionPolicy.Removals, "schema properties removed: "+strings.Join(removed, ", "))
+		} else {
+			record(s.versionPolicy.Additions, "schema changed")
+		}
+	}
+
+	if updates.Variables != nil {
+		before := make(map[string]models.ConfigVariable, len(variables))
+		for _, variable := range variables {
+			before[variable.Name] = *variable
+		}
+		after := make(map[string]models.ConfigVariable, len(updates.Variables))
+		for _, variable := range updates.Variables {
+			after[variable.Name] = variable
+		}
+
+		var added, modified []string
+		for name, variable := range after {
+			previous, ok := before[name]
+			switch {
+			case !ok:
+				added = append(added, name)
+			case !sameVariable(previous, variable):
+				modified = append(modified, name)
+			}
+		}
+		sort.Strings(added)
+		sort.Strings(modified)
+		if removed := removedKeys(before, after); len(removed) > 0 {
+			record(s.versionPolicy.Removals, "variables removed: "+strings.Join(removed, ", "))
+		}
+		if len(added) > 0 {
+			record(s.versionPolicy.Additions, "variables added: "+strings.Join(added, ", "))
+		}
+		if len(modified) > 0 {
+			record(s.versionPolicy.Additions, "variables changed: "+strings.Join(modified, ", "))
+		}
+	}
+
+	return bump, changes
+}
+
+// bumpVersion increments one component of a semantic version, resetting the lower ones
+// A leading "v" is kept; unparseable versions are treated as 0.0.0
+func bumpVersion(version string, bump models.VersionBump) string {
+	prefix := ""
+	if strings.HasPrefix(version, "v") {
+		prefix = "v"
+	}
+
+	var parts [3]int
+	core := strings.TrimPrefix(version, prefix)
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i] // Pre-release and build metadata don't survive a bump
+	}
+	fields := strings.Split(core, ".")
+	if len(fields) == 3 {
+		for i, field := range fields {
+			n, err := strconv.Atoi(field)
+			if err != nil || n < 0 {
+				parts = [3]int{}
+				break
+			}
+			parts[i] = n
+		}
+	}
+
+	switch bump {
+	case models.BumpMajor:
+		parts = [3]int{parts[0] + 1, 0, 0}
+	case models.BumpMinor:
+		parts = [3]int{parts[0], parts[1] + 1, 0}
+	case models.BumpPatch:
+		parts[2]++
+	}
+	return fmt.Sprintf("%s%d.%d.%d", prefix, parts[0], parts[1], parts[2])
+}
+
+// bumpRank orders bumps from least to most significant
+func bumpRank(bump models.VersionBump) int {
+	switch bump {
+	case models.BumpPatch:
+		return 1
+	case models.BumpMinor:
+		return 2
+	case models.BumpMajor:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// schemaProperties returns the top-level property names declared by a JSON schema
+func schemaProperties(schema string) map[string]struct{} {
+	var parsed struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	properties := make(map[string]struct{})
+	if json.Unmarshal([]byte(schema), &parsed) != nil {
+		return properties
+	}
+	for name := range parsed.Properties {
+		properties[name] = struct{}{}
+	}
+	return properties
+}
+
+// removedKeys returns the sorted keys of before that are missing from after
+func removedKeys[B, A any](before map[string]B, after map[string]A) []string {
+	var removed []string
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// sameVariable reports whether two variable definitions match, ignoring storage IDs
+func sameVariable(a, b models.ConfigVariable) bool {
+	return a.Path == b.Path && a.Type == b.Type && a.Description == b.Description &&
+		a.Required == b.Required && equalStringPtr(a.DefaultValue, b.DefaultValue) &&
+		equalStringPtr(a.ValidationRule, b.ValidationRule)
+}
+
+// equalStringPtr compares two optional strings by value
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestBumpVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		bump    models.VersionBump
+		want    string
+	}{
+		{version: "1.2.3", bump: models.BumpPatch, want: "1.2.4"},
+		{version: "1.2.3", bump: models.BumpMinor, want: "1.3.0"},
+		{version: "1.2.3", bump: models.BumpMajor, want: "2.0.0"},
+		{version: "v0.9.1", bump: models.BumpMinor, want: "v0.10.0"},
+		{version: "2.0.0-beta.1", bump: models.BumpPatch, want: "2.0.1"},
+		{version: "", bump: models.BumpPatch, want: "0.0.1"},
+		{version: "latest", bump: models.BumpMajor, want: "1.0.0"},
+	}
+
+	for _, tt := range tests {
+		if got := bumpVersion(tt.version, tt.bump); got != tt.want {
+			t.Errorf("bumpVersion(%q, %s) = %q, want %q", tt.version, tt.bump, got, tt.want)
+		}
+	}
+}
+
+func TestConfigService_UpdateTemplateBumpsVersion(t *testing.T) {
+	schema := func(s string) *string { return &s }
+	port := models.ConfigVariable{Name: "PORT", Path: "port", Type: "number"}
+	host := models.ConfigVariable{Name: "HOST", Path: "host", Type: "string"}
+
+	tests := []struct {
+		name        string
+		policy      *TemplateVersionPolicy
+		updates     models.ConfigTemplate
+		wantVersion string
+		wantBump    models.VersionBump
+	}{
+		{
+			name:        "content change",
+			updates:     models.ConfigTemplate{DefaultContent: "port: 9090\n"},
+			wantVersion: "1.0.1",
+			wantBump:    models.BumpPatch,
+		},
+		{
+			name:        "schema property added",
+			updates:     models.ConfigTemplate{Schema: schema(`{"properties": {"port": {}, "host": {}}}`)},
+			wantVersion: "1.1.0",
+			wantBump:    models.BumpMinor,
+		},
+		{
+			name:        "schema property removed",
+			updates:     models.ConfigTemplate{Schema: schema(`{"properties": {}}`)},
+			wantVersion: "2.0.0",
+			wantBump:    models.BumpMajor,
+		},
+		{
+			name:        "variable added alongside content",
+			updates:     models.ConfigTemplate{DefaultContent: "port: 9090\n", Variables: []models.ConfigVariable{port, host}},
+			wantVersion: "1.1.0",
+			wantBump:    models.BumpMinor,
+		},
+		{
+			name:        "variable removed",
+			updates:     models.ConfigTemplate{Variables: []models.ConfigVariable{}},
+			wantVersion: "2.0.0",
+			wantBump:    models.BumpMajor,
+		},
+		{
+			name:        "nothing material changed",
+			updates:     models.ConfigTemplate{Description: "New description", DefaultContent: "port: 8080\n"},
+			wantVersion: "1.0.0",
+		},
+		{
+			name:        "custom policy",
+			policy:      &TemplateVersionPolicy{Content: models.BumpNone, Additions: models.BumpPatch, Removals: models.BumpMinor},
+			updates:     models.ConfigTemplate{Variables: []models.ConfigVariable{}},
+			wantVersion: "1.1.0",
+			wantBump:    models.BumpMinor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockConfigRepository()
+			var opts []ConfigServiceOption
+			if tt.policy != nil {
+				opts = append(opts, WithTemplateVersionPolicy(*tt.policy))
+			}
+			service := NewConfigService(repo, opts...)
+
+			template := &models.ConfigTemplate{
+				Name:           "app",
+				Format:         models.FormatYAML,
+				DefaultContent: "port: 8080\n",
+				Schema:         schema(`{"properties": {"port": {}}}`),
+			}
+			if err := service.CreateTemplate(template); err != nil {
+				t.Fatalf("CreateTemplate() error = %v", err)
+			}
+			if template.Version != initialTemplateVersion {
+				t.Fatalf("new template version = %q, want %q", template.Version, initialTemplateVersion)
+			}
+			portCopy := port
+			portCopy.TemplateID = template.ID
+			repo.AddVariable(&portCopy)
+
+			updates := tt.updates
+			updates.Version = "9.9.9" // Client-supplied versions are ignored
+			if err := service.UpdateTemplate(template.ID, &updates); err != nil {
+				t.Fatalf("UpdateTemplate() error = %v", err)
+			}
+
+			updated, err := service.GetTemplate(template.ID)
+			if err != nil {
+				t.Fatalf("GetTemplate() error = %v", err)
+			}
+			if updated.Version != tt.wantVersion {
+				t.Errorf("version = %q, want %q", updated.Version, tt.wantVersion)
+			}
+
+			history, err := service.GetTemplateVersions(template.ID)
+			if err != nil {
+				t.Fatalf("GetTemplateVersions() error = %v", err)
+			}
+			if tt.wantBump == models.BumpNone {
+				if len(history) != 0 {
+					t.Errorf("history = %+v, want none", history)
+				}
+				return
+			}
+			if len(history) != 1 {
+				t.Fatalf("history has %d entries, want 1", len(history))
+			}
+			entry := history[0]
+			if entry.Bump != tt.wantBump || entry.PreviousVersion != "1.0.0" || entry.Version != tt.wantVersion {
+				t.Errorf("history entry = %+v, want %s bump from 1.0.0 to %s", entry, tt.wantBump, tt.wantVersion)
+			}
+			if len(entry.Changes) == 0 {
+				t.Error("history entry lists no changes")
+			}
+		})
+	}
+}
+
+func TestConfigService_GetTemplateDrift(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 8080\n"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	userConfig, err := service.CreateUserConfig(1, template.ID, "mine", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	if userConfig.TemplateVersion != "1.0.0" {
+		t.Errorf("config template version = %q, want 1.0.0", userConfig.TemplateVersion)
+	}
+
+	for _, content := range []string{"port: 8081\n", "port: 8082\n"} {
+		if err := service.UpdateTemplate(template.ID, &models.ConfigTemplate{DefaultContent: content}); err != nil {
+			t.Fatalf("UpdateTemplate() error = %v", err)
+		}
+	}
+
+	drift, err := service.GetTemplateDrift(userConfig.ID, 1)
+	if err != nil {
+		t.Fatalf("GetTemplateDrift() error = %v", err)
+	}
+	want := models.TemplateDrift{TemplateID: template.ID, ConfigVersion: "1.0.0", CurrentVersion: "1.0.2", VersionsBehind: 2}
+	if *drift != want {
+		t.Errorf("GetTemplateDrift() = %+v, want %+v", *drift, want)
+	}
+
+	if _, err := service.GetTemplateDrift(userConfig.ID, 2); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("GetTemplateDrift() for another user error = %v, want unauthorized", err)
+	}
+}
+
+func TestConfigService_GetTemplateDependents(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "port: 8080\n"}
+	other := &models.ConfigTemplate{Name: "other", Format: models.FormatYAML, DefaultContent: "port: 8080\n"}
+	for _, tmpl := range []*models.ConfigTemplate{template, other} {
+		if err := service.CreateTemplate(tmpl); err != nil {
+			t.Fatalf("CreateTemplate() error = %v", err)
+		}
+	}
+	var ids []int
+	for userID := 1; userID <= 3; userID++ {
+		userConfig, err := service.CreateUserConfig(userID, template.ID, "mine", nil)
+		if err != nil {
+			t.Fatalf("CreateUserConfig() error = %v", err)
+		}
+		ids = append(ids, userConfig.ID)
+	}
+	if _, err := service.CreateUserConfig(1, other.ID, "unrelated", nil); err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	// Editing the oldest config makes it the most recently updated
+	if _, err := service.UpdateUserConfig(ids[0], 1, "port: 9090\n", "", nil); err != nil {
+		t.Fatalf("UpdateUserConfig() error = %v", err)
+	}
+
+	if _, _, err := service.GetTemplateDependents(template.ID, false, 1, 10); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("GetTemplateDependents() as non-admin error = %v, want unauthorized", err)
+	}
+	if _, _, err := service.GetTemplateDependents(template.ID+100, true, 1, 10); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("GetTemplateDependents() for a missing template error = %v, want not found", err)
+	}
+
+	dependents, total, err := service.GetTemplateDependents(template.ID, true, 1, 2)
+	if err != nil {
+		t.Fatalf("GetTemplateDependents() error = %v", err)
+	}
+	if total != 3 || len(dependents) != 2 {
+		t.Fatalf("got %d dependents of %d, want 2 of 3", len(dependents), total)
+	}
+	if dependents[0].ConfigID != ids[0] || dependents[0].OwnerID != 1 || dependents[0].TemplateVersion != "1.0.0" {
+		t.Errorf("first dependent = %+v, want the recently updated config of user 1", dependents[0])
+	}
+	if dependents[1].ConfigID != ids[2] {
+		t.Errorf("second dependent = %d, want %d", dependents[1].ConfigID, ids[2])
+	}
+}
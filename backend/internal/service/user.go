@@ -5,9 +5,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 
 	"conflux/internal/models"
+	"conflux/pkg/config"
 	"conflux/pkg/utils"
 )
 
@@ -17,24 +20,62 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id int) (*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
+	UpdatePreferences(ctx context.Context, userID int, prefs models.UserPreferences) error
+	MarkEmailVerified(ctx context.Context, userID int) error
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) error
 	Delete(ctx context.Context, id int) error
 }
 
 // UserService handles user business logic
 type UserService struct {
 	userRepo UserRepository
+	verifier *EmailVerificationService // Non-nil when new users must verify their email
+	logger   *slog.Logger
+}
+
+// UserServiceOption configures optional UserService behavior
+type UserServiceOption func(*UserService)
+
+// WithEmailVerification creates new users unverified and sends them a verification token
+func WithEmailVerification(verifier *EmailVerificationService) UserServiceOption {
+	return func(s *UserService) {
+		s.verifier = verifier
+	}
+}
+
+// WithUserLogger sets the logger for failures that don't fail the request,
+// such as an undelivered verification email
+func WithUserLogger(logger *slog.Logger) UserServiceOption {
+	return func(s *UserService) {
+		s.logger = logger
+	}
 }
 
 // NewUserService creates a new user service with repository dependency
-func NewUserService(userRepo UserRepository) *UserService {
-	return &UserService{
+func NewUserService(userRepo UserRepository, opts ...UserServiceOption) *UserService {
+	s := &UserService{
 		userRepo: userRepo,
+		logger:   slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
+// MinPasswordLength is the shortest password a user may change to
+const MinPasswordLength = 8
+
 // CreateUser handles user registration business logic
 // Validates input, hashes password, and creates user record
 func (s *UserService) CreateUser(ctx context.Context, req *models.RegisterRequest) (*models.User, error) {
+	return s.createUser(ctx, req, false)
+}
+
+// createUser creates a user, flagged to change their password when it is a temporary one
+func (s *UserService) createUser(
+	ctx context.Context, req *models.RegisterRequest, resetRequired bool,
+) (*models.User, error) {
 	// Validate registration request
 	if err := req.Validate(); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
@@ -43,7 +84,7 @@ func (s *UserService) CreateUser(ctx context.Context, req *models.RegisterReques
 	// Check if email already exists
 	existingUser, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err == nil && existingUser != nil {
-		return nil, fmt.Errorf("email already exists")
+		return nil, models.ErrEmailTaken
 	}
 
 	// Hash password
@@ -54,16 +95,30 @@ func (s *UserService) CreateUser(ctx context.Context, req *models.RegisterReques
 
 	// Create user record
 	user := &models.User{
-		Email:     req.Email,
-		Password:  hashedPassword,
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
+		Email:         req.Email,
+		Password:      hashedPassword,
+		FirstName:     req.FirstName,
+		LastName:      req.LastName,
+		EmailVerified: s.verifier == nil,
+
+		PasswordResetRequired: resetRequired,
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
+		if errors.Is(err, models.ErrEmailTaken) {
+			return nil, err // Registered concurrently, after the check above
+		}
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	// The account exists either way, so a failed send is logged rather than
+	// returned; the user can request another token via resend
+	if s.verifier != nil {
+		if err := s.verifier.SendVerification(ctx, user); err != nil {
+			s.logger.Warn("Failed to send verification email", "user_id", user.ID, "error", err)
+		}
+	}
+
 	// Return sanitized user data (without password)
 	user.Password = ""
 	return user, nil
@@ -104,3 +159,55 @@ func (s *UserService) UpdateUser(ctx context.Context, user *models.User) error {
 
 	return nil
 }
+
+// ChangePassword replaces the user's password after checking the current one
+// Clears the reset flag on accounts created with a temporary password
+func (s *UserService) ChangePassword(ctx context.Context, userID int, req *models.ChangePasswordRequest) error {
+	if len(req.NewPassword) < MinPasswordLength {
+		return fmt.Errorf("validation failed: new password must be at least %d characters", MinPasswordLength)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found")
+	}
+	if !utils.VerifyPassword(req.CurrentPassword, user.Password) {
+		return fmt.Errorf("invalid credentials")
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return fmt.Errorf("validation failed: new password must differ from the current one")
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	return nil
+}
+
+// MarkEmailVerified marks the user's email as verified without a token
+// Used for accounts created by trusted code paths such as the dev user
+func (s *UserService) MarkEmailVerified(ctx context.Context, userID int) error {
+	if err := s.userRepo.MarkEmailVerified(ctx, userID); err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+	return nil
+}
+
+// UpdatePreferences validates and stores the user's preferences
+func (s *UserService) UpdatePreferences(ctx context.Context, userID int, prefs models.UserPreferences) (*models.User, error) {
+	if prefs.DefaultExportFormat != "" {
+		if _, ok := config.LookupCodec(prefs.DefaultExportFormat); !ok {
+			return nil, fmt.Errorf("validation failed: unsupported export format: %s", prefs.DefaultExportFormat)
+		}
+	}
+
+	if err := s.userRepo.UpdatePreferences(ctx, userID, prefs); err != nil {
+		return nil, fmt.Errorf("failed to update preferences: %w", err)
+	}
+
+	return s.GetUserByID(ctx, userID)
+}
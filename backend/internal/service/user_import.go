@@ -0,0 +1,133 @@
+// Bulk user import
+// Lets administrators onboard a team in one request: each row becomes an account
+// with a temporary password the user must change on first login
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"conflux/internal/models"
+)
+
+// MaxUserImportRows caps one import; every row costs a password hash
+const MaxUserImportRows = 100
+
+// temporaryPasswordBytes is the entropy of a generated temporary password
+const temporaryPasswordBytes = 12
+
+// ImportUsers creates an account for each row on an admin's behalf
+// Rows are independent: an email that already has an account is skipped and an
+// invalid row fails without stopping the rest. Only the batch itself can error
+func (s *UserService) ImportUsers(
+	ctx context.Context, isAdmin bool, rows []models.UserImportRow,
+) ([]models.UserImportResult, error) {
+	if !isAdmin {
+		return nil, fmt.Errorf("unauthorized to import users")
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("validation failed: no users to import")
+	}
+	if len(rows) > MaxUserImportRows {
+		return nil, fmt.Errorf("validation failed: %d users exceeds the limit of %d per import", len(rows), MaxUserImportRows)
+	}
+
+	results := make([]models.UserImportResult, 0, len(rows))
+	for i, row := range rows {
+		result := models.UserImportResult{Row: i + 1, Email: strings.TrimSpace(row.Email)}
+		user, password, err := s.importUser(ctx, row)
+		switch {
+		case errors.Is(err, models.ErrEmailTaken):
+			result.Status = models.UserImportSkipped
+			result.Error = err.Error()
+		case err != nil:
+			result.Status = models.UserImportFailed
+			result.Error = err.Error()
+		default:
+			result.Status = models.UserImportCreated
+			result.UserID = user.ID
+			result.TemporaryPassword = password
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// importUser validates one row and creates its account with a temporary password
+func (s *UserService) importUser(ctx context.Context, row models.UserImportRow) (*models.User, string, error) {
+	candidate := &models.User{Email: row.Email, FirstName: row.FirstName, LastName: row.LastName}
+	if err := candidate.Validate(); err != nil {
+		return nil, "", fmt.Errorf("validation failed: %w", err)
+	}
+
+	password, err := generateTemporaryPassword()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate password: %w", err)
+	}
+
+	user, err := s.createUser(ctx, &models.RegisterRequest{
+		Email:     candidate.Email,
+		Password:  password,
+		FirstName: candidate.FirstName,
+		LastName:  candidate.LastName,
+	}, true)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, password, nil
+}
+
+// ParseUserImportCSV reads import rows from CSV with a header row
+// The header names the email, first_name, and last_name columns, in any order
+func ParseUserImportCSV(r io.Reader) ([]models.UserImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: missing CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"email", "first_name", "last_name"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("validation failed: CSV header is missing the %s column", required)
+		}
+	}
+
+	var rows []models.UserImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+		if len(rows) == MaxUserImportRows {
+			return nil, fmt.Errorf("validation failed: more than %d users in one import", MaxUserImportRows)
+		}
+		rows = append(rows, models.UserImportRow{
+			Email:     record[columns["email"]],
+			FirstName: record[columns["first_name"]],
+			LastName:  record[columns["last_name"]],
+		})
+	}
+}
+
+// generateTemporaryPassword returns a random URL-safe password for an imported account
+func generateTemporaryPassword() (string, error) {
+	buf := make([]byte, temporaryPasswordBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
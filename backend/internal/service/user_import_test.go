@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+	"conflux/pkg/utils"
+)
+
+func TestUserService_ImportUsers(t *testing.T) {
+	repo := NewMockUserRepository()
+	service := NewUserService(repo)
+	ctx := context.Background()
+
+	if _, err := service.CreateUser(ctx, &models.RegisterRequest{
+		Email: "taken@example.com", Password: "password123", FirstName: "Old", LastName: "User",
+	}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	results, err := service.ImportUsers(ctx, true, []models.UserImportRow{
+		{Email: " ada@example.com ", FirstName: "Ada", LastName: "Lovelace"},
+		{Email: "taken@example.com", FirstName: "Someone", LastName: "Else"},
+		{Email: "not-an-email", FirstName: "Bad", LastName: "Row"},
+		{Email: "ada@example.com", FirstName: "Ada", LastName: "Again"},
+	})
+	if err != nil {
+		t.Fatalf("ImportUsers() error = %v", err)
+	}
+
+	wantStatuses := []string{
+		models.UserImportCreated, models.UserImportSkipped, models.UserImportFailed, models.UserImportSkipped,
+	}
+	if len(results) != len(wantStatuses) {
+		t.Fatalf("got %d results, want %d", len(results), len(wantStatuses))
+	}
+	for i, want := range wantStatuses {
+		if results[i].Row != i+1 || results[i].Status != want {
+			t.Errorf("row %d = %+v, want status %s", i+1, results[i], want)
+		}
+	}
+
+	created := results[0]
+	if created.TemporaryPassword == "" || created.UserID == 0 {
+		t.Fatalf("created row = %+v, want a user ID and temporary password", created)
+	}
+	user := repo.users[created.UserID]
+	if user.Email != "ada@example.com" || !user.PasswordResetRequired {
+		t.Errorf("imported user = %+v, want trimmed email and a required reset", user)
+	}
+	if !utils.VerifyPassword(created.TemporaryPassword, user.Password) {
+		t.Error("temporary password does not match the stored hash")
+	}
+	if results[1].TemporaryPassword != "" || results[2].Error == "" {
+		t.Errorf("skipped and failed rows = %+v, %+v", results[1], results[2])
+	}
+}
+
+func TestUserService_ImportUsersRejectsBatch(t *testing.T) {
+	service := NewUserService(NewMockUserRepository())
+	tooMany := make([]models.UserImportRow, MaxUserImportRows+1)
+
+	tests := []struct {
+		name    string
+		isAdmin bool
+		rows    []models.UserImportRow
+		wantErr string
+	}{
+		{name: "not an admin", rows: []models.UserImportRow{{Email: "a@example.com"}}, wantErr: "unauthorized"},
+		{name: "empty", isAdmin: true, wantErr: "validation failed"},
+		{name: "too many rows", isAdmin: true, rows: tooMany, wantErr: "exceeds the limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ImportUsers(context.Background(), tt.isAdmin, tt.rows)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ImportUsers() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseUserImportCSV(t *testing.T) {
+	rows, err := ParseUserImportCSV(strings.NewReader(
+		"Last_Name,email,first_name\nLovelace,ada@example.com,Ada\n\"Hopper, Jr\", grace@example.com,Grace\n",
+	))
+	if err != nil {
+		t.Fatalf("ParseUserImportCSV() error = %v", err)
+	}
+	want := []models.UserImportRow{
+		{Email: "ada@example.com", FirstName: "Ada", LastName: "Lovelace"},
+		{Email: "grace@example.com", FirstName: "Grace", LastName: "Hopper, Jr"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("rows = %+v, want %+v", rows, want)
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i+1, rows[i], want[i])
+		}
+	}
+
+	for name, content := range map[string]string{
+		"missing column": "email,first_name\na@example.com,A\n",
+		"ragged row":     "email,first_name,last_name\na@example.com,A\n",
+		"empty document": "",
+		"too many rows":  "email,first_name,last_name\n" + strings.Repeat("a@example.com,A,B\n", MaxUserImportRows+1),
+	} {
+		if _, err := ParseUserImportCSV(strings.NewReader(content)); err == nil || !strings.Contains(err.Error(), "validation failed") {
+			t.Errorf("%s: error = %v, want a validation error", name, err)
+		}
+	}
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	repo := NewMockUserRepository()
+	service := NewUserService(repo)
+	ctx := context.Background()
+
+	results, err := service.ImportUsers(ctx, true, []models.UserImportRow{
+		{Email: "ada@example.com", FirstName: "Ada", LastName: "Lovelace"},
+	})
+	if err != nil {
+		t.Fatalf("ImportUsers() error = %v", err)
+	}
+	userID, temporary := results[0].UserID, results[0].TemporaryPassword
+
+	tests := []struct {
+		name    string
+		req     models.ChangePasswordRequest
+		wantErr string
+	}{
+		{name: "too short", req: models.ChangePasswordRequest{CurrentPassword: temporary, NewPassword: "short"}, wantErr: "at least"},
+		{name: "wrong current", req: models.ChangePasswordRequest{CurrentPassword: "nope", NewPassword: "new-password"}, wantErr: "invalid credentials"},
+		{name: "unchanged", req: models.ChangePasswordRequest{CurrentPassword: temporary, NewPassword: temporary}, wantErr: "must differ"},
+		{name: "changed", req: models.ChangePasswordRequest{CurrentPassword: temporary, NewPassword: "new-password"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.ChangePassword(ctx, userID, &tt.req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ChangePassword() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ChangePassword() error = %v", err)
+			}
+			user := repo.users[userID]
+			if user.PasswordResetRequired || !utils.VerifyPassword("new-password", user.Password) {
+				t.Errorf("user = %+v, want the new password and no required reset", user)
+			}
+		})
+	}
+}
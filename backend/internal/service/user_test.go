@@ -113,6 +113,52 @@ func (m *MockUserRepository) Update(ctx context.Context, user *models.User) erro
 	return nil
 }
 
+// UpdatePreferences implements UserRepository.UpdatePreferences
+func (m *MockUserRepository) UpdatePreferences(ctx context.Context, userID int, prefs models.UserPreferences) error {
+	if m.updateErr != nil {
+		return m.updateErr
+	}
+
+	user, exists := m.users[userID]
+	if !exists {
+		return errors.New("user not found")
+	}
+
+	user.Preferences = prefs
+	return nil
+}
+
+// MarkEmailVerified implements UserRepository.MarkEmailVerified
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, userID int) error {
+	if m.updateErr != nil {
+		return m.updateErr
+	}
+
+	user, exists := m.users[userID]
+	if !exists {
+		return errors.New("user not found")
+	}
+
+	user.EmailVerified = true
+	return nil
+}
+
+// UpdatePassword implements UserRepository.UpdatePassword
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	if m.updateErr != nil {
+		return m.updateErr
+	}
+
+	user, exists := m.users[userID]
+	if !exists {
+		return errors.New("user not found")
+	}
+
+	user.Password = passwordHash
+	user.PasswordResetRequired = false
+	return nil
+}
+
 // Delete implements UserRepository.Delete
 func (m *MockUserRepository) Delete(ctx context.Context, id int) error {
 	if m.deleteErr != nil {
@@ -512,6 +558,23 @@ func TestUserService_UpdateUser(t *testing.T) {
 			wantErr:       true,
 			errorContains: "failed to update user",
 		},
+		{
+			name: "empty email rejected before persisting",
+			user: &models.User{
+				ID:        1,
+				Email:     "  ",
+				FirstName: "John",
+				LastName:  "Doe",
+			},
+			setupUser: &models.User{
+				Email:     "test@example.com",
+				FirstName: "John",
+				LastName:  "Doe",
+			},
+			repoErr:       errors.New("repository must not be called"),
+			wantErr:       true,
+			errorContains: "validation failed: email is required",
+		},
 	}
 
 	for _, tt := range tests {
@@ -554,6 +617,75 @@ func TestUserService_UpdateUser(t *testing.T) {
 }
 
 // Integration test
+func TestUserService_UpdatePreferences(t *testing.T) {
+	tests := []struct {
+		name          string
+		prefs         models.UserPreferences
+		repoErr       error
+		wantErr       bool
+		errorContains string
+	}{
+		{
+			name:  "set default export format",
+			prefs: models.UserPreferences{DefaultExportFormat: models.FormatTOML},
+		},
+		{
+			name:  "clear default export format",
+			prefs: models.UserPreferences{},
+		},
+		{
+			name:          "unsupported format",
+			prefs:         models.UserPreferences{DefaultExportFormat: "xml"},
+			wantErr:       true,
+			errorContains: "unsupported export format",
+		},
+		{
+			name:          "repository error",
+			prefs:         models.UserPreferences{DefaultExportFormat: models.FormatJSON},
+			repoErr:       errors.New("database error"),
+			wantErr:       true,
+			errorContains: "failed to update preferences",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := NewMockUserRepository()
+			service := NewUserService(mockRepo)
+
+			user := &models.User{Email: "prefs@example.com", Password: "hash"}
+			if err := mockRepo.Create(context.Background(), user); err != nil {
+				t.Fatalf("Failed to create test user: %v", err)
+			}
+
+			if tt.repoErr != nil {
+				mockRepo.SetUpdateError(tt.repoErr)
+			}
+
+			updated, err := service.UpdatePreferences(context.Background(), user.ID, tt.prefs)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				} else if tt.errorContains != "" && !strings.Contains(err.Error(), tt.errorContains) {
+					t.Errorf("error %q should contain %q", err.Error(), tt.errorContains)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if updated.Preferences != tt.prefs {
+				t.Errorf("preferences = %+v, want %+v", updated.Preferences, tt.prefs)
+			}
+			if updated.Password != "" {
+				t.Error("password should be sanitized")
+			}
+		})
+	}
+}
+
 func TestUserService_Integration(t *testing.T) {
 	mockRepo := NewMockUserRepository()
 	service := NewUserService(mockRepo)
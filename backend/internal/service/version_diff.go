@@ -0,0 +1,141 @@
+// Version comparison
+// Line-level diffs between two stored versions of a configuration,
+// as structured entries or as a unified diff for patch and diff viewers,
+// and key-level diffs of the parsed versions
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"conflux/internal/models"
+	"conflux/pkg/config"
+)
+
+// unifiedDiffContext is the number of unchanged lines shown around each hunk
+const unifiedDiffContext = 3
+
+// DiffVersionLines compares two versions of a configuration line by line
+// A removed line followed by an added one is reported as a single modification
+func (s *ConfigService) DiffVersionLines(configID, fromID, toID, userID int) ([]models.ConfigDiff, error) {
+	_, from, to, err := s.versionsToCompare(configID, fromID, toID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ops := config.DiffLines(from.Content, to.Content)
+	diffs := make([]models.ConfigDiff, 0)
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == config.LineContext {
+			i++
+			continue
+		}
+
+		// Collect the block of removals and additions, then pair them up
+		var removed, added []config.LineOp
+		for ; i < len(ops) && ops[i].Kind != config.LineContext; i++ {
+			if ops[i].Kind == config.LineRemoved {
+				removed = append(removed, ops[i])
+			} else {
+				added = append(added, ops[i])
+			}
+		}
+		for j := 0; j < len(removed) || j < len(added); j++ {
+			switch {
+			case j < len(removed) && j < len(added):
+				diffs = append(diffs, models.ConfigDiff{
+					LineNumber: added[j].NewLine,
+					Type:       "modified",
+					OldContent: removed[j].Text,
+					NewContent: added[j].Text,
+				})
+			case j < len(removed):
+				diffs = append(diffs, models.ConfigDiff{LineNumber: removed[j].OldLine, Type: "removed", OldContent: removed[j].Text})
+			default:
+				diffs = append(diffs, models.ConfigDiff{LineNumber: added[j].NewLine, Type: "added", NewContent: added[j].Text})
+			}
+		}
+	}
+	return diffs, nil
+}
+
+// DiffVersions compares two versions key by key, as parsed in the configuration's format
+// Nested keys are reported by dotted path; arrays and scalars are compared whole
+func (s *ConfigService) DiffVersions(configID, versionA, versionB, userID int) ([]models.ConfigDiff, error) {
+	userConfig, from, to, err := s.versionsToCompare(configID, versionA, versionB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := make([]map[string]interface{}, 0, 2)
+	for _, version := range []*models.ConfigVersion{from, to} {
+		data, err := s.parser.ParseConfig(version.Content, userConfig.Format)
+		if err != nil {
+			return nil, fmt.Errorf("validation failed: version %d is not valid %s: %w", version.Version, userConfig.Format, err)
+		}
+		parsed = append(parsed, data)
+	}
+
+	changes := config.Diff(parsed[0], parsed[1])
+	diffs := make([]models.ConfigDiff, 0, len(changes))
+	for _, change := range changes {
+		diffs = append(diffs, models.ConfigDiff{
+			Path:       change.Path,
+			Type:       string(change.Type),
+			OldContent: diffValueText(change.OldValue),
+			NewContent: diffValueText(change.NewValue),
+			OldValue:   change.OldValue,
+			NewValue:   change.NewValue,
+		})
+	}
+	return diffs, nil
+}
+
+// diffValueText renders a parsed value for display: strings as they are, anything else as JSON
+func diffValueText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	text, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(text)
+}
+
+// UnifiedVersionDiff renders the change between two versions as a unified diff
+// Returns an empty string when the versions have the same content
+func (s *ConfigService) UnifiedVersionDiff(configID, fromID, toID, userID int) (string, error) {
+	userConfig, from, to, err := s.versionsToCompare(configID, fromID, toID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	oldName := fmt.Sprintf("a/%s\tversion %d", userConfig.Name, from.Version)
+	newName := fmt.Sprintf("b/%s\tversion %d", userConfig.Name, to.Version)
+	return config.UnifiedDiff(oldName, newName, config.DiffLines(from.Content, to.Content), unifiedDiffContext), nil
+}
+
+// versionsToCompare loads two versions after checking the user can read the configuration
+// Versions of other configurations are reported as not found
+func (s *ConfigService) versionsToCompare(
+	configID, fromID, toID, userID int,
+) (*models.UserConfig, *models.ConfigVersion, *models.ConfigVersion, error) {
+	userConfig, err := s.GetUserConfig(configID, userID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	versions := make([]*models.ConfigVersion, 0, 2)
+	for _, versionID := range []int{fromID, toID} {
+		version, err := s.configRepo.GetConfigVersion(versionID)
+		if err != nil || version.ConfigID != configID {
+			return nil, nil, nil, fmt.Errorf("version %d not found", versionID)
+		}
+		versions = append(versions, version)
+	}
+	return userConfig, versions[0], versions[1], nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestConfigService_VersionDiff(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{Name: "app", Format: models.FormatYAML, DefaultContent: "host: a\nport: 1\n"}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	config, err := service.CreateUserConfig(1, template.ID, "app.yaml", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	if _, err := service.UpdateUserConfig(config.ID, 1, "host: b\nport: 1\ndebug: true\n", "edit", nil); err != nil {
+		t.Fatalf("UpdateUserConfig() error = %v", err)
+	}
+
+	versions, _, _ := repo.GetConfigVersions(config.ID, models.DefaultConfigVersionSort, 1, 10)
+	from, to := versions[1].ID, versions[0].ID
+
+	diffs, err := service.DiffVersionLines(config.ID, from, to, 1)
+	if err != nil {
+		t.Fatalf("DiffVersionLines() error = %v", err)
+	}
+	want := []models.ConfigDiff{
+		{LineNumber: 1, Type: "modified", OldContent: "host: a", NewContent: "host: b"},
+		{LineNumber: 3, Type: "added", NewContent: "debug: true"},
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("DiffVersionLines() = %+v, want %+v", diffs, want)
+	}
+
+	unified, err := service.UnifiedVersionDiff(config.ID, from, to, 1)
+	if err != nil {
+		t.Fatalf("UnifiedVersionDiff() error = %v", err)
+	}
+	wantUnified := "--- a/app.yaml\tversion 1\n+++ b/app.yaml\tversion 2\n" +
+		"@@ -1,2 +1,3 @@\n-host: a\n+host: b\n port: 1\n+debug: true\n"
+	if unified != wantUnified {
+		t.Errorf("UnifiedVersionDiff() =\n%s\nwant\n%s", unified, wantUnified)
+	}
+
+	if _, err := service.DiffVersionLines(config.ID, from, to, 2); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("other user error = %v, want unauthorized", err)
+	}
+
+	other, err := service.CreateUserConfig(1, template.ID, "other", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	otherVersions, _, _ := repo.GetConfigVersions(other.ID, models.DefaultConfigVersionSort, 1, 10)
+	if _, err := service.UnifiedVersionDiff(config.ID, from, otherVersions[0].ID, 1); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("foreign version error = %v, want not found", err)
+	}
+}
+
+func TestConfigService_DiffVersions(t *testing.T) {
+	repo := NewMockConfigRepository()
+	service := NewConfigService(repo)
+
+	template := &models.ConfigTemplate{
+		Name: "app", Format: models.FormatYAML,
+		DefaultContent: "server:\n  host: a\n  port: 80\nlegacy: true\n",
+	}
+	if err := service.CreateTemplate(template); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	config, err := service.CreateUserConfig(1, template.ID, "app.yaml", nil)
+	if err != nil {
+		t.Fatalf("CreateUserConfig() error = %v", err)
+	}
+	if _, err := service.UpdateUserConfig(config.ID, 1, "server:\n  host: a\n  port: 8080\n  tls: true\n", "edit", nil); err != nil {
+		t.Fatalf("UpdateUserConfig() error = %v", err)
+	}
+
+	versions, _, _ := repo.GetConfigVersions(config.ID, models.DefaultConfigVersionSort, 1, 10)
+	from, to := versions[1].ID, versions[0].ID
+
+	diffs, err := service.DiffVersions(config.ID, from, to, 1)
+	if err != nil {
+		t.Fatalf("DiffVersions() error = %v", err)
+	}
+	want := []models.ConfigDiff{
+		{Path: "legacy", Type: "removed", OldContent: "true", OldValue: true},
+		{Path: "server.port", Type: "modified", OldContent: "80", NewContent: "8080", OldValue: 80, NewValue: 8080},
+		{Path: "server.tls", Type: "added", NewContent: "true", NewValue: true},
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("DiffVersions() = %+v, want %+v", diffs, want)
+	}
+
+	if _, err := service.DiffVersions(config.ID, from, to, 2); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("other user error = %v, want unauthorized", err)
+	}
+}
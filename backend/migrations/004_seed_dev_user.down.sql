@@ -1,2 +0,0 @@
--- Rollback development seed data
-DELETE FROM users WHERE email = 'dev@conflux.local';
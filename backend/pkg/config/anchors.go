@@ -0,0 +1,197 @@
+// YAML anchor and alias support for serialization
+// Detects repeated subtrees and re-emits them as &anchor/*alias references
+// Keeps large YAML configs DRY after a round trip through other formats
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SerializeOption customizes how configuration data is serialized
+type SerializeOption func(*serializeOptions)
+
+type serializeOptions struct {
+	yamlAnchors   bool
+	tomlDatetimes bool
+}
+
+// WithYAMLAnchors makes YAML output re-anchor repeated subtrees
+// Identical mappings or sequences are emitted once with an &anchor and
+// referenced elsewhere with *alias. Parsing always expands aliases, so this
+// restores the DRY structure that a conversion through JSON/TOML/ENV loses.
+func WithYAMLAnchors() SerializeOption {
+	return func(o *serializeOptions) {
+		o.yamlAnchors = true
+	}
+}
+
+func newSerializeOptions(opts []SerializeOption) serializeOptions {
+	var o serializeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+var anchorNameSanitizer = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// serializeYAMLWithAnchors encodes data as YAML, replacing repeated subtrees with aliases
+func (p *Parser) serializeYAMLWithAnchors(data map[string]interface{}) (string, error) {
+	var root yaml.Node
+	if err := root.Encode(data); err != nil {
+		return "", err
+	}
+
+	counts := make(map[string]int)
+	countSubtrees(&root, counts)
+
+	a := &anchorer{
+		counts:  counts,
+		anchors: make(map[string]*yaml.Node),
+		names:   make(map[string]bool),
+		used:    make(map[*yaml.Node]bool),
+	}
+	a.walk(&root, "")
+	a.dropUnusedAnchors(&root)
+
+	var buf strings.Builder
+	encoder := yaml.NewEncoder(&buf)
+	if err := encoder.Encode(&root); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// anchorer assigns anchors to the first occurrence of each repeated subtree
+// and replaces later occurrences with aliases
+type anchorer struct {
+	counts  map[string]int
+	anchors map[string]*yaml.Node // subtree fingerprint -> anchored node
+	names   map[string]bool
+	used    map[*yaml.Node]bool // anchored nodes referenced by at least one alias
+}
+
+func (a *anchorer) walk(node *yaml.Node, hint string) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			a.walk(child, hint)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			node.Content[i+1] = a.visit(value, key.Value)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			node.Content[i] = a.visit(child, fmt.Sprintf("%s_%d", hint, i))
+		}
+	}
+}
+
+// visit returns the node to emit in place of node: either the node itself
+// (possibly anchored) or an alias to an earlier identical subtree
+func (a *anchorer) visit(node *yaml.Node, hint string) *yaml.Node {
+	if !isCollection(node) {
+		return node
+	}
+
+	fingerprint := subtreeFingerprint(node)
+	if a.counts[fingerprint] > 1 {
+		if anchored, ok := a.anchors[fingerprint]; ok {
+			a.used[anchored] = true
+			return &yaml.Node{Kind: yaml.AliasNode, Value: anchored.Anchor, Alias: anchored}
+		}
+		node.Anchor = a.uniqueName(hint)
+		a.anchors[fingerprint] = node
+	}
+
+	a.walk(node, hint)
+	return node
+}
+
+func (a *anchorer) uniqueName(hint string) string {
+	base := strings.Trim(anchorNameSanitizer.ReplaceAllString(hint, "_"), "_")
+	if base == "" {
+		base = "anchor"
+	}
+
+	name := base
+	for i := 2; a.names[name]; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	a.names[name] = true
+	return name
+}
+
+// dropUnusedAnchors clears anchors whose subtree ended up never being aliased
+// (e.g. a nested repeat whose only other copy sits inside an aliased parent)
+func (a *anchorer) dropUnusedAnchors(node *yaml.Node) {
+	if node.Kind == yaml.AliasNode {
+		return
+	}
+	if node.Anchor != "" && !a.used[node] {
+		node.Anchor = ""
+	}
+	for _, child := range node.Content {
+		a.dropUnusedAnchors(child)
+	}
+}
+
+// countSubtrees records how many times each non-empty collection appears
+func countSubtrees(node *yaml.Node, counts map[string]int) {
+	if isCollection(node) {
+		counts[subtreeFingerprint(node)]++
+	}
+	for _, child := range node.Content {
+		countSubtrees(child, counts)
+	}
+}
+
+func isCollection(node *yaml.Node) bool {
+	return (node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode) && len(node.Content) > 0
+}
+
+// subtreeFingerprint returns a canonical string identifying a subtree's structure and values
+func subtreeFingerprint(node *yaml.Node) string {
+	var b strings.Builder
+	writeFingerprint(&b, node)
+	return b.String()
+}
+
+func writeFingerprint(b *strings.Builder, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		pairs := make([]string, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			var pair strings.Builder
+			writeFingerprint(&pair, node.Content[i])
+			pair.WriteByte(':')
+			writeFingerprint(&pair, node.Content[i+1])
+			pairs = append(pairs, pair.String())
+		}
+		sort.Strings(pairs)
+		b.WriteString("{" + strings.Join(pairs, ",") + "}")
+	case yaml.SequenceNode:
+		b.WriteByte('[')
+		for i, child := range node.Content {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeFingerprint(b, child)
+		}
+		b.WriteByte(']')
+	case yaml.AliasNode:
+		writeFingerprint(b, node.Alias)
+	default:
+		fmt.Fprintf(b, "%s=%q", node.Tag, node.Value)
+	}
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+const anchoredYAML = `defaults: &defaults
+  adapter: postgres
+  host: localhost
+  pool: 5
+development:
+  <<: *defaults
+  database: dev
+test:
+  <<: *defaults
+  database: test
+servers:
+  primary: &server
+    host: db1
+    port: 5432
+  replica: *server
+`
+
+func TestParser_YAMLAnchorsExpandOnParse(t *testing.T) {
+	parser := NewParser()
+
+	data, err := parser.ParseConfig(anchoredYAML, models.FormatYAML)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path []string
+		want interface{}
+	}{
+		{name: "merge key expanded", path: []string{"development", "adapter"}, want: "postgres"},
+		{name: "local key kept alongside merge", path: []string{"test", "database"}, want: "test"},
+		{name: "alias expanded", path: []string{"servers", "replica", "host"}, want: "db1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var current interface{} = data
+			for _, key := range tt.path {
+				m, ok := current.(map[string]interface{})
+				if !ok {
+					t.Fatalf("expected map at %q, got %T", key, current)
+				}
+				current = m[key]
+			}
+			if current != tt.want {
+				t.Errorf("value at %v = %v, want %v", tt.path, current, tt.want)
+			}
+		})
+	}
+
+	// Conversion to JSON must not contain YAML-only syntax
+	converted, err := parser.ConvertFormat(anchoredYAML, models.FormatYAML, models.FormatJSON)
+	if err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+	if strings.Contains(converted, "<<") || strings.Contains(converted, "*server") {
+		t.Errorf("expected expanded JSON, got:\n%s", converted)
+	}
+}
+
+func TestParser_SerializeYAMLWithAnchors(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name        string
+		data        map[string]interface{}
+		wantAnchors int
+	}{
+		{
+			name: "repeated mapping",
+			data: map[string]interface{}{
+				"primary": map[string]interface{}{"host": "db", "port": 5432},
+				"replica": map[string]interface{}{"host": "db", "port": 5432},
+			},
+			wantAnchors: 1,
+		},
+		{
+			name: "repeated sequence",
+			data: map[string]interface{}{
+				"a": []interface{}{"x", "y"},
+				"b": []interface{}{"x", "y"},
+				"c": []interface{}{"x", "y"},
+			},
+			wantAnchors: 1,
+		},
+		{
+			name: "nested repeat only inside aliased parent",
+			data: map[string]interface{}{
+				"one": map[string]interface{}{"inner": map[string]interface{}{"k": "v"}},
+				"two": map[string]interface{}{"inner": map[string]interface{}{"k": "v"}},
+			},
+			wantAnchors: 1,
+		},
+		{
+			name: "no repeats",
+			data: map[string]interface{}{
+				"a": map[string]interface{}{"k": 1},
+				"b": map[string]interface{}{"k": 2},
+			},
+			wantAnchors: 0,
+		},
+		{
+			name: "empty collections are not anchored",
+			data: map[string]interface{}{
+				"a": map[string]interface{}{},
+				"b": map[string]interface{}{},
+			},
+			wantAnchors: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parser.SerializeConfig(tt.data, models.FormatYAML, WithYAMLAnchors())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := strings.Count(result, "&"); got != tt.wantAnchors {
+				t.Errorf("anchor count = %d, want %d\n%s", got, tt.wantAnchors, result)
+			}
+			if tt.wantAnchors > 0 && !strings.Contains(result, "*") {
+				t.Errorf("expected at least one alias in output:\n%s", result)
+			}
+
+			// Re-parsing must expand back to the original structure
+			reparsed, err := parser.ParseConfig(result, models.FormatYAML)
+			if err != nil {
+				t.Fatalf("anchored output is not valid YAML: %v", err)
+			}
+			want, _ := parser.ParseConfig(mustSerialize(t, parser, tt.data), models.FormatYAML)
+			if !reflect.DeepEqual(reparsed, want) {
+				t.Errorf("round trip mismatch:\ngot  %v\nwant %v", reparsed, want)
+			}
+		})
+	}
+}
+
+func TestParser_ConvertFormatWithYAMLAnchors(t *testing.T) {
+	parser := NewParser()
+
+	// YAML -> JSON -> YAML restores anchors when requested
+	asJSON, err := parser.ConvertFormat(anchoredYAML, models.FormatYAML, models.FormatJSON)
+	if err != nil {
+		t.Fatalf("YAML to JSON failed: %v", err)
+	}
+
+	plain, err := parser.ConvertFormat(asJSON, models.FormatJSON, models.FormatYAML)
+	if err != nil {
+		t.Fatalf("JSON to YAML failed: %v", err)
+	}
+	if strings.Contains(plain, "&") {
+		t.Errorf("anchors should be opt-in, got:\n%s", plain)
+	}
+
+	anchored, err := parser.ConvertFormat(asJSON, models.FormatJSON, models.FormatYAML, WithYAMLAnchors())
+	if err != nil {
+		t.Fatalf("JSON to anchored YAML failed: %v", err)
+	}
+	if !strings.Contains(anchored, "&") || !strings.Contains(anchored, "*") {
+		t.Errorf("expected anchors and aliases, got:\n%s", anchored)
+	}
+	if len(anchored) >= len(plain) {
+		t.Errorf("anchored output (%d bytes) should be smaller than expanded output (%d bytes)",
+			len(anchored), len(plain))
+	}
+}
+
+func mustSerialize(t *testing.T, parser *Parser, data map[string]interface{}) string {
+	t.Helper()
+	out, err := parser.SerializeConfig(data, models.FormatYAML)
+	if err != nil {
+		t.Fatalf("serialize failed: %v", err)
+	}
+	return out
+}
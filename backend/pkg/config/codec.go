@@ -0,0 +1,197 @@
+// Codec registry for supported configuration formats
+// Describes each format's parse/serialize functions, media type, and capabilities
+// Single source of truth for format metadata and conversion caveats
+package config
+
+import (
+	"strings"
+
+	"conflux/internal/models"
+)
+
+// Codec describes a configuration format and how to read and write it
+type Codec struct {
+	Format      models.ConfigFormat
+	ContentType string
+	Extensions  []string // Preferred extension first
+
+	// Capabilities used to derive conversion caveats
+	SupportsComments bool // Comments can appear in source documents
+	SupportsNesting  bool // Nested objects and arrays are representable
+	SupportsTypes    bool // Numbers and booleans keep their types
+	SupportsNull     bool // Null values are representable
+
+	parse     func(p *Parser, content string) (map[string]interface{}, error)
+	serialize func(p *Parser, data map[string]interface{}) (string, error)
+}
+
+// FormatInfo is the public description of a supported format
+type FormatInfo struct {
+	Format           models.ConfigFormat `json:"format"`
+	ContentType      string              `json:"content_type"`
+	Extensions       []string            `json:"extensions"`
+	SupportsComments bool                `json:"supports_comments"`
+	SupportsNesting  bool                `json:"supports_nesting"`
+	SupportsTypes    bool                `json:"supports_types"`
+}
+
+// Conversion describes whether one format can be converted to another
+type Conversion struct {
+	From      models.ConfigFormat `json:"from"`
+	To        models.ConfigFormat `json:"to"`
+	Supported bool                `json:"supported"`
+	Caveats   []string            `json:"caveats"`
+}
+
+// codecs lists the registered formats in display order
+var codecs = []*Codec{
+	{
+		Format:           models.FormatYAML,
+		ContentType:      "application/x-yaml",
+		Extensions:       []string{"yaml", "yml"},
+		SupportsComments: true,
+		SupportsNesting:  true,
+		SupportsTypes:    true,
+		SupportsNull:     true,
+		parse:            (*Parser).parseYAML,
+		serialize:        (*Parser).serializeYAML,
+	},
+	{
+		Format:          models.FormatJSON,
+		ContentType:     "application/json",
+		Extensions:      []string{"json"},
+		SupportsNesting: true,
+		SupportsTypes:   true,
+		SupportsNull:    true,
+		parse:           (*Parser).parseJSON,
+		serialize:       (*Parser).serializeJSON,
+	},
+	{
+		// Comments survive only a JSONC-to-JSONC ConvertFormatPreserveComments;
+		// serializing always writes standard JSON
+		Format:           models.FormatJSONC,
+		ContentType:      "application/json",
+		Extensions:       []string{"jsonc"},
+		SupportsComments: true,
+		SupportsNesting:  true,
+		SupportsTypes:    true,
+		SupportsNull:     true,
+		parse:            (*Parser).parseJSONC,
+		serialize:        (*Parser).serializeJSON,
+	},
+	{
+		Format:           models.FormatTOML,
+		ContentType:      "application/toml",
+		Extensions:       []string{"toml"},
+		SupportsComments: true,
+		SupportsNesting:  true,
+		SupportsTypes:    true,
+		parse:            (*Parser).parseTOML,
+		serialize:        (*Parser).serializeTOML,
+	},
+	{
+		Format:           models.FormatENV,
+		ContentType:      "text/plain",
+		Extensions:       []string{"env"},
+		SupportsComments: true,
+		parse:            (*Parser).parseEnv,
+		serialize:        (*Parser).serializeEnv,
+	},
+	{
+		// Sections give one level of nesting; deeper values are written as JSON
+		Format:           models.FormatINI,
+		ContentType:      "text/plain",
+		Extensions:       []string{"ini", "cfg"},
+		SupportsComments: true,
+		parse:            (*Parser).parseINI,
+		serialize:        (*Parser).serializeINI,
+	},
+}
+
+// LookupCodec returns the codec registered for a format
+func LookupCodec(format models.ConfigFormat) (*Codec, bool) {
+	for _, codec := range codecs {
+		if codec.Format == format {
+			return codec, true
+		}
+	}
+	return nil, false
+}
+
+// FormatForExtension returns the format registered for a file extension,
+// given with or without the leading dot
+func FormatForExtension(ext string) (models.ConfigFormat, bool) {
+	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+	for _, codec := range codecs {
+		for _, candidate := range codec.Extensions {
+			if candidate == ext {
+				return codec.Format, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Formats returns metadata for every registered format
+func (p *Parser) Formats() []FormatInfo {
+	infos := make([]FormatInfo, 0, len(codecs))
+	for _, codec := range codecs {
+		infos = append(infos, FormatInfo{
+			Format:           codec.Format,
+			ContentType:      codec.ContentType,
+			Extensions:       codec.Extensions,
+			SupportsComments: codec.SupportsComments,
+			SupportsNesting:  codec.SupportsNesting,
+			SupportsTypes:    codec.SupportsTypes,
+		})
+	}
+	return infos
+}
+
+// Conversions returns the conversion matrix between all registered formats
+func (p *Parser) Conversions() []Conversion {
+	conversions := make([]Conversion, 0, len(codecs)*len(codecs))
+	for _, from := range codecs {
+		for _, to := range codecs {
+			if from == to {
+				continue
+			}
+			conversions = append(conversions, Conversion{
+				From:      from.Format,
+				To:        to.Format,
+				Supported: true,
+				Caveats:   conversionCaveats(from, to),
+			})
+		}
+	}
+	return conversions
+}
+
+// conversionCaveats lists the information lost when converting between two codecs
+func conversionCaveats(from, to *Codec) []string {
+	caveats := []string{}
+
+	if from.SupportsComments {
+		caveats = append(caveats, "comments dropped")
+	}
+	if from.SupportsNesting && to.Format == models.FormatINI {
+		caveats = append(caveats, "top-level objects become sections; deeper objects and arrays serialized as JSON strings")
+	} else if from.SupportsNesting && !to.SupportsNesting {
+		caveats = append(caveats, "nested objects and arrays serialized as JSON strings")
+	}
+	if from.SupportsTypes && !to.SupportsTypes {
+		caveats = append(caveats, "numbers and booleans become strings")
+	}
+	if !from.SupportsTypes && to.SupportsTypes {
+		caveats = append(caveats, "all values emitted as strings")
+	}
+	if from.SupportsNull && !to.SupportsNull {
+		caveats = append(caveats, "null values not representable")
+	}
+	if isJSONFormat(from.Format) && to.Format == models.FormatTOML {
+		// JSON numbers decode as float64, which TOML always writes with a decimal point
+		caveats = append(caveats, "integers emitted as floats")
+	}
+
+	return caveats
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestLookupCodec(t *testing.T) {
+	tests := []struct {
+		name            string
+		format          models.ConfigFormat
+		wantOK          bool
+		wantContentType string
+	}{
+		{name: "yaml", format: models.FormatYAML, wantOK: true, wantContentType: "application/x-yaml"},
+		{name: "json", format: models.FormatJSON, wantOK: true, wantContentType: "application/json"},
+		{name: "toml", format: models.FormatTOML, wantOK: true, wantContentType: "application/toml"},
+		{name: "env", format: models.FormatENV, wantOK: true, wantContentType: "text/plain"},
+		{name: "unknown", format: "xml", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, ok := LookupCodec(tt.format)
+			if ok != tt.wantOK {
+				t.Fatalf("LookupCodec(%q) ok = %v, want %v", tt.format, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if codec.ContentType != tt.wantContentType {
+				t.Errorf("ContentType = %q, want %q", codec.ContentType, tt.wantContentType)
+			}
+			if len(codec.Extensions) == 0 {
+				t.Error("expected at least one extension")
+			}
+		})
+	}
+}
+
+func TestFormatForExtension(t *testing.T) {
+	tests := map[string]models.ConfigFormat{
+		"yaml":  models.FormatYAML,
+		".yml":  models.FormatYAML,
+		".JSON": models.FormatJSON,
+		"toml":  models.FormatTOML,
+		".env":  models.FormatENV,
+	}
+	for ext, want := range tests {
+		if got, ok := FormatForExtension(ext); !ok || got != want {
+			t.Errorf("FormatForExtension(%q) = %q, %v, want %q", ext, got, ok, want)
+		}
+	}
+
+	if _, ok := FormatForExtension(".xml"); ok {
+		t.Error("FormatForExtension(.xml) ok = true, want false")
+	}
+}
+
+func TestParser_Formats(t *testing.T) {
+	parser := NewParser()
+
+	formats := parser.Formats()
+	if len(formats) != len(codecs) {
+		t.Fatalf("Formats() returned %d entries, want %d", len(formats), len(codecs))
+	}
+
+	// Every advertised format must actually parse and serialize
+	for _, info := range formats {
+		if _, err := parser.SerializeConfig(map[string]interface{}{"key": "value"}, info.Format); err != nil {
+			t.Errorf("advertised format %s cannot serialize: %v", info.Format, err)
+		}
+	}
+}
+
+func TestParser_Conversions(t *testing.T) {
+	parser := NewParser()
+
+	conversions := parser.Conversions()
+	if want := len(codecs) * (len(codecs) - 1); len(conversions) != want {
+		t.Fatalf("Conversions() returned %d pairs, want %d", len(conversions), want)
+	}
+
+	tests := []struct {
+		name       string
+		from, to   models.ConfigFormat
+		wantCaveat string
+		noCaveat   string
+	}{
+		{name: "yaml to json drops comments", from: models.FormatYAML, to: models.FormatJSON, wantCaveat: "comments dropped"},
+		{
+			name: "yaml to env flattens nesting", from: models.FormatYAML, to: models.FormatENV,
+			wantCaveat: "nested objects and arrays serialized as JSON strings",
+		},
+		{name: "json to toml loses null", from: models.FormatJSON, to: models.FormatTOML, wantCaveat: "null values not representable"},
+		{name: "json to yaml keeps comments caveat off", from: models.FormatJSON, to: models.FormatYAML, noCaveat: "comments dropped"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var found *Conversion
+			for i := range conversions {
+				if conversions[i].From == tt.from && conversions[i].To == tt.to {
+					found = &conversions[i]
+				}
+			}
+			if found == nil {
+				t.Fatalf("no conversion entry for %s -> %s", tt.from, tt.to)
+			}
+			if !found.Supported {
+				t.Error("expected conversion to be supported")
+			}
+			if tt.wantCaveat != "" && !containsString(found.Caveats, tt.wantCaveat) {
+				t.Errorf("caveats %v missing %q", found.Caveats, tt.wantCaveat)
+			}
+			if tt.noCaveat != "" && containsString(found.Caveats, tt.noCaveat) {
+				t.Errorf("caveats %v should not contain %q", found.Caveats, tt.noCaveat)
+			}
+		})
+	}
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
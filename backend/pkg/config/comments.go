@@ -0,0 +1,357 @@
+// Comment-preserving conversion
+// Keeps explanatory comments when hand-maintained configs are normalized or converted
+// YAML to YAML is exact; between YAML and TOML comments follow their keys best-effort
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"conflux/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// keyComments are the comments attached to one key, without their # markers
+type keyComments struct {
+	Head []string // Lines directly above the key
+	Line string   // Trailing comment on the key's line
+}
+
+// commentMap maps key paths, in LookupPath notation, to their comments
+// The empty path holds the comment block at the top of the document
+type commentMap map[string]*keyComments
+
+// ConvertFormatPreserveComments converts like ConvertFormat but keeps comments where it can
+// YAML to YAML re-emits the document with its comments, anchors, and key order intact,
+// and JSONC to JSONC re-indents the document with its comments and key order.
+// Between YAML and TOML, comments above a key and at the end of its line are
+// carried to the same key in the output. Other formats drop comments as ConvertFormat does
+func (p *Parser) ConvertFormatPreserveComments(
+	content string, fromFormat, toFormat models.ConfigFormat, opts ...SerializeOption,
+) (converted string, err error) {
+	if fromFormat == models.FormatYAML && toFormat == models.FormatYAML {
+		defer recoverParserPanic("normalize", fromFormat, &err)
+		return normalizeYAML(content)
+	}
+	if fromFormat == models.FormatJSONC && toFormat == models.FormatJSONC {
+		defer recoverParserPanic("normalize", fromFormat, &err)
+		return p.reformatJSONC(content)
+	}
+
+	converted, err = p.ConvertFormat(content, fromFormat, toFormat, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	defer recoverParserPanic("comment transfer", toFormat, &err)
+	comments, err := extractComments(content, fromFormat)
+	if err != nil || len(comments) == 0 {
+		return converted, err
+	}
+	return applyComments(converted, toFormat, comments)
+}
+
+// normalizeYAML re-encodes a YAML document through yaml.Node, which keeps comments
+func normalizeYAML(content string) (string, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+		return "", fmt.Errorf("failed to parse source format: %w", err)
+	}
+	if root.Kind == 0 {
+		return "", nil
+	}
+	return encodeYAMLNode(&root)
+}
+
+// encodeYAMLNode writes a node with the same encoder settings as serializeYAML
+func encodeYAMLNode(node *yaml.Node) (string, error) {
+	var buf strings.Builder
+	encoder := yaml.NewEncoder(&buf)
+	if err := encoder.Encode(node); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// extractComments collects the comments in content by key path
+// Formats without comment support yield an empty map
+func extractComments(content string, format models.ConfigFormat) (commentMap, error) {
+	switch format {
+	case models.FormatYAML:
+		var root yaml.Node
+		if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+			return nil, err
+		}
+		comments := make(commentMap)
+		if root.Kind == yaml.DocumentNode {
+			comments.add("", commentLines(root.HeadComment), "")
+			walkYAMLMapping(root.Content[0], "", func(path string, key, value *yaml.Node) {
+				line := key.LineComment
+				if line == "" {
+					line = value.LineComment
+				}
+				comments.add(path, commentLines(key.HeadComment), commentText(line))
+			})
+		}
+		return comments, nil
+	case models.FormatTOML:
+		comments := make(commentMap)
+		var pending []string
+		seenKey := false
+		for _, line := range scanTOMLLines(content) {
+			switch line.kind {
+			case tomlComment:
+				pending = append(pending, line.comment)
+			case tomlBlank:
+				// A block separated from everything below it only survives at the top
+				if !seenKey {
+					comments.add("", pending, "")
+				}
+				pending = nil
+			case tomlTable, tomlKey:
+				comments.add(line.path, pending, line.comment)
+				pending, seenKey = nil, true
+			default:
+				pending, seenKey = nil, true
+			}
+		}
+		return comments, nil
+	}
+	return nil, nil
+}
+
+// applyComments writes comments onto the matching keys of serialized content
+func applyComments(content string, format models.ConfigFormat, comments commentMap) (string, error) {
+	switch format {
+	case models.FormatYAML:
+		var root yaml.Node
+		if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+			return "", err
+		}
+		if root.Kind != yaml.DocumentNode {
+			return content, nil
+		}
+		if doc := comments[""]; doc != nil {
+			root.HeadComment = formatCommentLines(doc.Head)
+		}
+		walkYAMLMapping(root.Content[0], "", func(path string, key, value *yaml.Node) {
+			c := comments[path]
+			if c == nil {
+				return
+			}
+			key.HeadComment = formatCommentLines(c.Head)
+			if c.Line == "" {
+				return
+			}
+			// Only a scalar's comment can follow the value; a collection's follows the key
+			if value.Kind == yaml.ScalarNode {
+				value.LineComment = "# " + c.Line
+			} else {
+				key.LineComment = "# " + c.Line
+			}
+		})
+		return encodeYAMLNode(&root)
+	case models.FormatTOML:
+		var out strings.Builder
+		if doc := comments[""]; doc != nil && len(doc.Head) > 0 {
+			out.WriteString(formatCommentLines(doc.Head) + "\n\n")
+		}
+		lines := strings.Split(content, "\n")
+		scanned := scanTOMLLines(content)
+		for i, text := range lines {
+			line := scanned[i]
+			if c := comments[line.path]; c != nil && (line.kind == tomlTable || line.kind == tomlKey) {
+				indent := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+				for _, head := range c.Head {
+					out.WriteString(indent + "# " + head + "\n")
+				}
+				if c.Line != "" {
+					text += " # " + c.Line
+				}
+			}
+			out.WriteString(text)
+			if i < len(lines)-1 {
+				out.WriteString("\n")
+			}
+		}
+		return out.String(), nil
+	}
+	return content, nil
+}
+
+// add records comments for path, ignoring empty ones
+func (m commentMap) add(path string, head []string, line string) {
+	if len(head) == 0 && line == "" {
+		return
+	}
+	m[path] = &keyComments{Head: head, Line: line}
+}
+
+// walkYAMLMapping calls fn for every key of a mapping and of the mappings nested in it
+func walkYAMLMapping(node *yaml.Node, path string, fn func(path string, key, value *yaml.Node)) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keyPath := joinPath(path, key.Value)
+		fn(keyPath, key, value)
+		walkYAMLMapping(value, keyPath, fn)
+	}
+}
+
+// commentLines splits a yaml.v3 comment block into its lines of text
+func commentLines(block string) []string {
+	var lines []string
+	for _, line := range strings.Split(block, "\n") {
+		if text := commentText(line); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return lines
+}
+
+// commentText strips the # marker and surrounding space from one comment line
+func commentText(line string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#"))
+}
+
+// formatCommentLines renders lines of text as a yaml.v3 comment block
+func formatCommentLines(lines []string) string {
+	formatted := make([]string, len(lines))
+	for i, line := range lines {
+		formatted[i] = "# " + line
+	}
+	return strings.Join(formatted, "\n")
+}
+
+// tomlLineKind classifies a line of TOML for comment transfer
+type tomlLineKind int
+
+const (
+	tomlBlank tomlLineKind = iota
+	tomlComment
+	tomlTable
+	tomlKey
+	tomlOther // Continuation lines and arrays of tables, which carry no comments
+)
+
+// tomlLine is one scanned line of TOML
+type tomlLine struct {
+	kind    tomlLineKind
+	path    string // Key or table path, for keys and table headers
+	comment string // The comment text: the whole line for comments, the trailing part otherwise
+}
+
+// scanTOMLLines classifies each line of TOML content, one entry per line
+// It is a line scanner rather than a parser: multi-line strings and arrays are
+// skipped, and keys inside arrays of tables aren't given paths
+func scanTOMLLines(content string) []tomlLine {
+	raw := strings.Split(content, "\n")
+	lines := make([]tomlLine, len(raw))
+
+	table := ""
+	inArrayTable := false
+	closing := "" // Delimiter ending the multi-line value being skipped
+	depth := 0    // Open brackets of a multi-line array
+
+	for i, text := range raw {
+		text = strings.TrimSpace(text)
+		switch {
+		case closing != "":
+			lines[i].kind = tomlOther
+			if strings.Contains(text, closing) {
+				closing = ""
+			}
+		case depth > 0:
+			lines[i].kind = tomlOther
+			depth += bracketBalance(text)
+		case text == "":
+			lines[i].kind = tomlBlank
+		case strings.HasPrefix(text, "#"):
+			lines[i] = tomlLine{kind: tomlComment, comment: commentText(text)}
+		case strings.HasPrefix(text, "[["):
+			lines[i].kind = tomlOther
+			inArrayTable = true
+		case strings.HasPrefix(text, "["):
+			header, comment := splitTOMLComment(text)
+			table = normalizeTOMLKey(strings.TrimSuffix(strings.TrimPrefix(header, "["), "]"))
+			inArrayTable = false
+			lines[i] = tomlLine{kind: tomlTable, path: table, comment: comment}
+		default:
+			key, value, ok := strings.Cut(text, "=")
+			if !ok {
+				lines[i].kind = tomlOther
+				continue
+			}
+			value, comment := splitTOMLComment(strings.TrimSpace(value))
+			for _, delim := range []string{`"""`, `'''`} {
+				if strings.HasPrefix(value, delim) && strings.Count(value, delim) == 1 {
+					closing = delim
+				}
+			}
+			if closing == "" && strings.HasPrefix(value, "[") {
+				depth = bracketBalance(value)
+			}
+
+			lines[i].kind = tomlOther
+			if !inArrayTable {
+				lines[i] = tomlLine{kind: tomlKey, path: joinPath(table, normalizeTOMLKey(key)), comment: comment}
+			}
+		}
+	}
+	return lines
+}
+
+// splitTOMLComment splits a trailing # comment from a line, ignoring # inside strings
+func splitTOMLComment(text string) (string, string) {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return strings.TrimSpace(text[:i]), commentText(text[i:])
+		}
+	}
+	return text, ""
+}
+
+// bracketBalance counts [ minus ] outside strings
+func bracketBalance(text string) int {
+	text, _ = splitTOMLComment(text)
+	balance := 0
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '[':
+			balance++
+		case quote == 0 && c == ']':
+			balance--
+		}
+	}
+	return balance
+}
+
+// normalizeTOMLKey turns a possibly dotted, quoted TOML key into a LookupPath path
+func normalizeTOMLKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
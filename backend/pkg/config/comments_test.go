@@ -0,0 +1,126 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+const documentedYAML = `# Cross-seed settings
+# Edit with care
+
+# Seconds between searches
+delay: 30 # keep above 10
+client:
+    # Torrent client URL
+    url: http://localhost:8080
+    retries: 3 # per request
+base: &base
+    tls: true
+mirror: *base
+# trailing notes
+`
+
+func TestParser_ConvertFormatPreserveComments_YAMLRoundTrip(t *testing.T) {
+	parser := NewParser()
+
+	got, err := parser.ConvertFormatPreserveComments(documentedYAML, models.FormatYAML, models.FormatYAML)
+	if err != nil {
+		t.Fatalf("ConvertFormatPreserveComments() error = %v", err)
+	}
+	if got != documentedYAML {
+		t.Errorf("YAML round trip changed the document:\n%s\nwant\n%s", got, documentedYAML)
+	}
+
+	// The plain conversion is what loses them
+	plain, err := parser.ConvertFormat(documentedYAML, models.FormatYAML, models.FormatYAML)
+	if err != nil {
+		t.Fatalf("ConvertFormat() error = %v", err)
+	}
+	if strings.Contains(plain, "#") {
+		t.Errorf("ConvertFormat() kept comments; the round trip test no longer covers anything:\n%s", plain)
+	}
+}
+
+func TestParser_ConvertFormatPreserveComments_YAMLToTOML(t *testing.T) {
+	parser := NewParser()
+
+	got, err := parser.ConvertFormatPreserveComments(documentedYAML, models.FormatYAML, models.FormatTOML)
+	if err != nil {
+		t.Fatalf("ConvertFormatPreserveComments() error = %v", err)
+	}
+	for _, want := range []string{
+		"# Cross-seed settings\n# Edit with care\n\n",
+		"# Seconds between searches\ndelay = 30 # keep above 10\n",
+		"  # Torrent client URL\n  url = \"http://localhost:8080\"\n",
+		"retries = 3 # per request",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("TOML output missing %q:\n%s", want, got)
+		}
+	}
+
+	data, err := parser.ParseConfig(got, models.FormatTOML)
+	if err != nil {
+		t.Fatalf("output is not valid TOML: %v\n%s", err, got)
+	}
+	if url, _ := LookupPath(data, "client.url"); url != "http://localhost:8080" {
+		t.Errorf("client.url = %v after conversion", url)
+	}
+}
+
+func TestParser_ConvertFormatPreserveComments_TOMLToYAML(t *testing.T) {
+	parser := NewParser()
+	content := `# Service settings
+
+# Listen address
+host = "0.0.0.0" # all interfaces
+motd = "no # comment here"
+ports = [
+  8080, # http
+  8443,
+]
+
+# Database connection
+[database]
+# Pool size
+pool = 10
+`
+
+	got, err := parser.ConvertFormatPreserveComments(content, models.FormatTOML, models.FormatYAML)
+	if err != nil {
+		t.Fatalf("ConvertFormatPreserveComments() error = %v", err)
+	}
+	for _, want := range []string{
+		"# Service settings\n\n",
+		"# Database connection\ndatabase:\n",
+		"    # Pool size\n    pool: 10\n",
+		"# Listen address\nhost: 0.0.0.0 # all interfaces\n",
+		"motd: 'no # comment here'\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("YAML output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "# http") {
+		t.Errorf("comment inside a multi-line array was attached to a key:\n%s", got)
+	}
+}
+
+func TestParser_ConvertFormatPreserveComments_OtherFormats(t *testing.T) {
+	parser := NewParser()
+
+	got, err := parser.ConvertFormatPreserveComments(documentedYAML, models.FormatYAML, models.FormatJSON)
+	if err != nil {
+		t.Fatalf("ConvertFormatPreserveComments() error = %v", err)
+	}
+	want, _ := parser.ConvertFormat(documentedYAML, models.FormatYAML, models.FormatJSON)
+	if got != want {
+		t.Errorf("JSON output = %s, want the plain conversion %s", got, want)
+	}
+
+	if _, err := parser.ConvertFormatPreserveComments("a: [", models.FormatYAML, models.FormatYAML); err == nil {
+		t.Error("expected error for invalid YAML")
+	}
+}
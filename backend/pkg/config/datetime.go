@@ -0,0 +1,171 @@
+// TOML datetime and table handling
+// TOML has offset datetimes plus local datetimes, dates, and times, which the TOML
+// library marks with special time.Location values that other formats know nothing
+// about. Formats without datetimes get ISO 8601 strings in the form TOML itself uses
+package config
+
+import (
+	"time"
+
+	"conflux/internal/models"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Layouts for TOML's local datetime kinds
+const (
+	localDatetimeLayout = "2006-01-02T15:04:05.999999999"
+	localDateLayout     = "2006-01-02"
+	localTimeLayout     = "15:04:05.999999999"
+)
+
+// The TOML library's locations for its local kinds live in an internal package;
+// decoding one value of each kind yields the same pointers its encoder checks for
+var tomlLocalDatetime, tomlLocalDate, tomlLocalTime = tomlLocalLocations()
+
+func tomlLocalLocations() (*time.Location, *time.Location, *time.Location) {
+	// Decoded into interface{}: a time.Time field would drop the location
+	var sample map[string]interface{}
+	if err := toml.Unmarshal([]byte("datetime = 2000-01-01T00:00:00\ndate = 2000-01-01\ntime = 00:00:00"), &sample); err != nil {
+		panic("toml: decoding local datetimes: " + err.Error())
+	}
+	location := func(key string) *time.Location {
+		return sample[key].(time.Time).Location()
+	}
+	return location("datetime"), location("date"), location("time")
+}
+
+// WithTOMLDatetimes makes TOML output write datetime-looking strings as TOML datetimes
+// RFC 3339 strings become offset datetimes and zoneless ones local datetimes,
+// dates, or times, so content that came through JSON gets its types back
+func WithTOMLDatetimes() SerializeOption {
+	return func(o *serializeOptions) {
+		o.tomlDatetimes = true
+	}
+}
+
+// FormatDatetime returns the ISO 8601 text of a parsed datetime
+// TOML local kinds keep their shape: a local date stays "1979-05-27" rather
+// than gaining a midnight UTC time
+func FormatDatetime(t time.Time) string {
+	switch t.Location() {
+	case tomlLocalDatetime:
+		return t.Format(localDatetimeLayout)
+	case tomlLocalDate:
+		return t.Format(localDateLayout)
+	case tomlLocalTime:
+		return t.Format(localTimeLayout)
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// prepareDatetimes returns data with datetimes in the form the target format can hold
+// YAML has timestamps but no local kinds, and JSON, ENV, and INI have no datetimes,
+// so those get strings; TOML optionally turns strings back into datetimes.
+// data itself is never modified
+func prepareDatetimes(data map[string]interface{}, format models.ConfigFormat, options serializeOptions) map[string]interface{} {
+	var convert func(value interface{}) interface{}
+	switch format {
+	case models.FormatTOML:
+		if !options.tomlDatetimes {
+			return data
+		}
+		convert = func(value interface{}) interface{} {
+			if s, ok := value.(string); ok {
+				if t, ok := parseDatetime(s); ok {
+					return t
+				}
+			}
+			return value
+		}
+	case models.FormatYAML:
+		convert = func(value interface{}) interface{} {
+			if t, ok := value.(time.Time); ok && isLocalDatetime(t) {
+				return FormatDatetime(t)
+			}
+			return value
+		}
+	default:
+		convert = func(value interface{}) interface{} {
+			if t, ok := value.(time.Time); ok {
+				return FormatDatetime(t)
+			}
+			return value
+		}
+	}
+	return mapValues(data, convert).(map[string]interface{})
+}
+
+// mapValues copies a parsed document, replacing each scalar with convert(scalar)
+func mapValues(value interface{}, convert func(interface{}) interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			copied[key] = mapValues(child, convert)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, child := range v {
+			copied[i] = mapValues(child, convert)
+		}
+		return copied
+	default:
+		return convert(value)
+	}
+}
+
+// isLocalDatetime reports whether t is one of TOML's zoneless kinds
+func isLocalDatetime(t time.Time) bool {
+	switch t.Location() {
+	case tomlLocalDatetime, tomlLocalDate, tomlLocalTime:
+		return true
+	}
+	return false
+}
+
+// parseDatetime parses an ISO 8601 string in one of the forms TOML can write
+func parseDatetime(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, true
+	}
+	for _, layout := range []struct {
+		layout   string
+		location *time.Location
+	}{
+		{localDatetimeLayout, tomlLocalDatetime},
+		{localDateLayout, tomlLocalDate},
+		{localTimeLayout, tomlLocalTime},
+	} {
+		if t, err := time.ParseInLocation(layout.layout, s, layout.location); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// normalizeTOMLTables turns decoded arrays of tables into []interface{}
+// The TOML library decodes [[table]] arrays as []map[string]interface{}, a shape
+// the rest of the package, and every other format's parser, never produces
+func normalizeTOMLTables(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = normalizeTOMLTables(child)
+		}
+		return v
+	case []map[string]interface{}:
+		tables := make([]interface{}, len(v))
+		for i, table := range v {
+			tables[i] = normalizeTOMLTables(table)
+		}
+		return tables
+	case []interface{}:
+		for i, child := range v {
+			v[i] = normalizeTOMLTables(child)
+		}
+		return v
+	}
+	return value
+}
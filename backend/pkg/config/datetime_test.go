@@ -0,0 +1,140 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+const datetimeTOML = `title = "inventory"
+created = 1979-05-27T07:32:00-08:00
+updated = 1979-05-27T07:32:00.5
+released = 1979-05-27
+opens = 07:32:00
+
+[owner]
+name = "Tom"
+
+[owner.address]
+city = "Springfield"
+
+[[fruit]]
+name = "apple"
+picked = 2024-06-01
+
+[fruit.physical]
+color = "red"
+
+[[fruit]]
+name = "banana"
+`
+
+func TestParser_TOMLDatetimesToJSON(t *testing.T) {
+	parser := NewParser()
+
+	converted, err := parser.ConvertFormat(datetimeTOML, models.FormatTOML, models.FormatJSON)
+	if err != nil {
+		t.Fatalf("ConvertFormat() error = %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(converted), &got); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	want := map[string]interface{}{
+		"created":  "1979-05-27T07:32:00-08:00",
+		"updated":  "1979-05-27T07:32:00.5",
+		"released": "1979-05-27",
+		"opens":    "07:32:00",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %v, want %q", key, got[key], value)
+		}
+	}
+	fruit, _ := got["fruit"].([]interface{})
+	if len(fruit) != 2 {
+		t.Fatalf("fruit = %v, want the array of tables as two objects", got["fruit"])
+	}
+	if picked := fruit[0].(map[string]interface{})["picked"]; picked != "2024-06-01" {
+		t.Errorf("fruit[0].picked = %v, want 2024-06-01", picked)
+	}
+}
+
+func TestParser_TOMLRoundTrips(t *testing.T) {
+	parser := NewParser()
+
+	original, err := parser.ParseConfig(datetimeTOML, models.FormatTOML)
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+	if _, ok := original["fruit"].([]interface{}); !ok {
+		t.Fatalf("fruit parsed as %T, want []interface{}", original["fruit"])
+	}
+
+	t.Run("TOML to TOML", func(t *testing.T) {
+		out, err := parser.SerializeConfig(original, models.FormatTOML)
+		if err != nil {
+			t.Fatalf("SerializeConfig() error = %v", err)
+		}
+		for _, want := range []string{"released = 1979-05-27\n", "[owner.address]", "[[fruit]]", "[fruit.physical]"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+		again, err := parser.ParseConfig(out, models.FormatTOML)
+		if err != nil {
+			t.Fatalf("ParseConfig() error = %v", err)
+		}
+		if !reflect.DeepEqual(again, original) {
+			t.Errorf("round trip = %v, want %v", again, original)
+		}
+	})
+
+	t.Run("TOML through JSON with datetimes restored", func(t *testing.T) {
+		viaJSON, err := parser.ConvertFormat(datetimeTOML, models.FormatTOML, models.FormatJSON)
+		if err != nil {
+			t.Fatalf("ConvertFormat() error = %v", err)
+		}
+		out, err := parser.ConvertFormat(viaJSON, models.FormatJSON, models.FormatTOML, WithTOMLDatetimes())
+		if err != nil {
+			t.Fatalf("ConvertFormat() error = %v", err)
+		}
+		for _, want := range []string{
+			"created = 1979-05-27T07:32:00-08:00\n",
+			"updated = 1979-05-27T07:32:00.5\n",
+			"released = 1979-05-27\n",
+			"opens = 07:32:00\n",
+			"picked = 2024-06-01\n",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("JSON datetimes stay strings by default", func(t *testing.T) {
+		out, err := parser.ConvertFormat(`{"released": "1979-05-27"}`, models.FormatJSON, models.FormatTOML)
+		if err != nil {
+			t.Fatalf("ConvertFormat() error = %v", err)
+		}
+		if out != "released = \"1979-05-27\"\n" {
+			t.Errorf("output = %q, want a quoted string", out)
+		}
+	})
+
+	t.Run("YAML gets local kinds as strings", func(t *testing.T) {
+		out, err := parser.SerializeConfig(original, models.FormatYAML)
+		if err != nil {
+			t.Fatalf("SerializeConfig() error = %v", err)
+		}
+		for _, want := range []string{"released: \"1979-05-27\"", "created: 1979-05-27T07:32:00-08:00"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+	})
+}
@@ -0,0 +1,130 @@
+// JSON schema default filling
+// Adds the `default` values a schema declares for properties the content leaves out
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"conflux/internal/models"
+)
+
+// schemaNode is the subset of a JSON schema that default filling reads
+type schemaNode struct {
+	Type       interface{}            `json:"type"`
+	Default    interface{}            `json:"default"`
+	Properties map[string]*schemaNode `json:"properties"`
+}
+
+// ApplyDefaults fills properties missing from content with the defaults declared in schema
+// Nested object schemas are walked too: an absent object is created when any of its
+// properties has a default, and a present one has its own missing properties filled.
+// Existing values are never replaced. Content is returned unchanged, formatting
+// included, when there is nothing to fill
+func (p *Parser) ApplyDefaults(content string, format models.ConfigFormat, schema string) (string, error) {
+	if strings.TrimSpace(schema) == "" {
+		return content, nil
+	}
+
+	var root schemaNode
+	decoder := json.NewDecoder(strings.NewReader(schema))
+	decoder.UseNumber() // Keep integer defaults integers in TOML and YAML
+	if err := decoder.Decode(&root); err != nil {
+		return "", fmt.Errorf("invalid schema: %w", err)
+	}
+
+	data := map[string]interface{}{}
+	if strings.TrimSpace(content) != "" {
+		parsed, err := p.ParseConfig(content, format)
+		if err != nil {
+			return "", fmt.Errorf("invalid configuration: %w", err)
+		}
+		data = parsed
+	}
+
+	if !fillDefaults(data, &root) {
+		return content, nil
+	}
+	return p.SerializeConfig(data, format)
+}
+
+// fillDefaults adds missing defaults to object and reports whether anything was added
+func fillDefaults(object map[string]interface{}, node *schemaNode) bool {
+	filled := false
+	for name, property := range node.Properties {
+		if property == nil {
+			continue
+		}
+
+		value, present := object[name]
+		if !present {
+			if property.Default != nil {
+				value = schemaValue(property.Default)
+			} else if property.isObject() {
+				value = map[string]interface{}{}
+			} else {
+				continue
+			}
+		}
+
+		// Defaults inside an object default, or inside content, still apply below it
+		nested, isMap := value.(map[string]interface{})
+		nestedFilled := isMap && fillDefaults(nested, property)
+
+		if !present && (property.Default != nil || nestedFilled) {
+			object[name] = value
+			filled = true
+		} else if nestedFilled {
+			filled = true
+		}
+	}
+	return filled
+}
+
+// isObject reports whether the schema describes an object with declared properties
+func (n *schemaNode) isObject() bool {
+	if len(n.Properties) == 0 {
+		return false
+	}
+	switch t := n.Type.(type) {
+	case nil:
+		return true
+	case string:
+		return t == "object"
+	case []interface{}:
+		for _, name := range t {
+			if name == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schemaValue copies a decoded default so each config gets its own instance,
+// turning JSON numbers into int64 when integral and float64 otherwise
+func schemaValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = schemaValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = schemaValue(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestParser_ApplyDefaults(t *testing.T) {
+	parser := NewParser()
+	schema := `{
+		"type": "object",
+		"properties": {
+			"port": {"type": "integer", "default": 8080},
+			"ratio": {"type": "number", "default": 0.5},
+			"debug": {"type": "boolean"},
+			"server": {
+				"type": "object",
+				"properties": {
+					"host": {"type": "string", "default": "localhost"},
+					"tls": {
+						"type": "object",
+						"properties": {"enabled": {"type": "boolean", "default": false}}
+					}
+				}
+			},
+			"limits": {
+				"type": "object",
+				"default": {"rps": 10},
+				"properties": {"burst": {"type": "integer", "default": 20}}
+			}
+		}
+	}`
+
+	tests := []struct {
+		name    string
+		content string
+		format  models.ConfigFormat
+		want    map[string]interface{}
+	}{
+		{
+			name:    "empty content gets every default",
+			content: "",
+			format:  models.FormatYAML,
+			want: map[string]interface{}{
+				"port":   8080,
+				"ratio":  0.5,
+				"server": map[string]interface{}{"host": "localhost", "tls": map[string]interface{}{"enabled": false}},
+				"limits": map[string]interface{}{"rps": 10, "burst": 20},
+			},
+		},
+		{
+			name:    "existing values are kept and nested gaps filled",
+			content: "port = 9090\n\n[server]\nhost = \"example.com\"\n\n[limits]\nrps = 5\n",
+			format:  models.FormatTOML,
+			want: map[string]interface{}{
+				"port":   int64(9090),
+				"ratio":  0.5,
+				"server": map[string]interface{}{"host": "example.com", "tls": map[string]interface{}{"enabled": false}},
+				"limits": map[string]interface{}{"rps": int64(5), "burst": int64(20)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filled, err := parser.ApplyDefaults(tt.content, tt.format, schema)
+			if err != nil {
+				t.Fatalf("ApplyDefaults() error = %v", err)
+			}
+			got, err := parser.ParseConfig(filled, tt.format)
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v\n%s", err, filled)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyDefaults() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_ApplyDefaults_NothingToFill(t *testing.T) {
+	parser := NewParser()
+	content := "# keep me\nport: 9090\n"
+
+	for _, schema := range []string{"", `{"properties": {"port": {"default": 8080}, "debug": {"type": "boolean"}}}`} {
+		filled, err := parser.ApplyDefaults(content, models.FormatYAML, schema)
+		if err != nil {
+			t.Fatalf("ApplyDefaults(%q) error = %v", schema, err)
+		}
+		if filled != content {
+			t.Errorf("ApplyDefaults(%q) = %q, want content unchanged", schema, filled)
+		}
+	}
+
+	if _, err := parser.ApplyDefaults(content, models.FormatYAML, "{not json"); err == nil {
+		t.Error("ApplyDefaults() with an invalid schema returned no error")
+	}
+}
@@ -0,0 +1,168 @@
+// Format detection candidates
+// Scores every format the content could plausibly be instead of taking the first match
+// Lets callers spot content that is valid in several formats and ask the user to choose
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"conflux/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Confidence scores for each detection signal
+// JSON is strict enough to be conclusive; YAML scores low when the content only
+// parses as a bare scalar, which is how it swallows ENV and TOML lines. TOML and
+// ENV start equal and gain from their idioms: table headers and quoted strings
+// for TOML, UPPER_SNAKE keys written KEY=value for ENV
+const (
+	confidenceJSON       = 1.0
+	confidenceJSONC      = 1.0 // Only scored when plain JSON fails to parse
+	confidenceYAMLMap    = 0.8
+	confidenceYAMLScalar = 0.2
+	confidenceTOML       = 0.6
+	confidenceINI        = 0.7
+	confidenceENV        = 0.6
+
+	idiomBoostTOMLTable  = 0.15 // A [table] header
+	idiomBoostTOMLString = 0.1  // A key = "quoted string" line
+	idiomBoostENV        = 0.25 // Every key is UPPER_SNAKE with no spaces around =
+
+	// minDetectConfidence is the least DetectFormat accepts; a lone YAML scalar
+	// is what any text parses as, so it isn't a detection
+	minDetectConfidence = 0.5
+)
+
+// Idioms that raise a format's score
+var (
+	tomlTableLine  = regexp.MustCompile(`(?m)^\s*\[[^\[\]]+\]\s*(#.*)?$`)
+	tomlStringLine = regexp.MustCompile(`(?m)^\s*[A-Za-z0-9_.-]+\s*=\s*["']`)
+	envLine        = regexp.MustCompile(`^(export\s+)?[A-Z_][A-Z0-9_]*=`)
+)
+
+// FormatCandidate is a format the content may be written in
+type FormatCandidate struct {
+	Format     models.ConfigFormat `json:"format"`
+	Confidence float64             `json:"confidence"`
+}
+
+// DetectFormatCandidates returns every format the content parses as, most likely first
+func (p *Parser) DetectFormatCandidates(content string) ([]FormatCandidate, error) {
+	content = strings.TrimSpace(content)
+
+	if content == "" {
+		return nil, fmt.Errorf("empty content")
+	}
+
+	var candidates []FormatCandidate
+	if p.isValidJSON(content) {
+		candidates = append(candidates, FormatCandidate{models.FormatJSON, confidenceJSON})
+	} else if hasJSONCSyntax(content) && p.isValidJSON(StripJSONC(content)) {
+		candidates = append(candidates, FormatCandidate{models.FormatJSONC, confidenceJSONC})
+	}
+	var yml interface{}
+	if unmarshalsCleanly(yaml.Unmarshal, content, &yml) {
+		confidence := confidenceYAMLScalar
+		if _, ok := yml.(map[string]interface{}); ok {
+			confidence = confidenceYAMLMap
+		}
+		candidates = append(candidates, FormatCandidate{models.FormatYAML, confidence})
+	}
+	if p.isValidTOML(content) {
+		confidence := confidenceTOML
+		if tomlTableLine.MatchString(content) {
+			confidence += idiomBoostTOMLTable
+		}
+		if tomlStringLine.MatchString(content) {
+			confidence += idiomBoostTOMLString
+		}
+		candidates = append(candidates, FormatCandidate{models.FormatTOML, confidence})
+	}
+	if p.looksLikeINI(content) {
+		candidates = append(candidates, FormatCandidate{models.FormatINI, confidenceINI})
+	}
+	if p.looksLikeEnv(content) {
+		confidence := confidenceENV
+		if isIdiomaticEnv(content) {
+			confidence += idiomBoostENV
+		}
+		candidates = append(candidates, FormatCandidate{models.FormatENV, confidence})
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("unable to detect configuration format")
+	}
+
+	// Stable so ties keep the JSON(C), YAML, TOML, INI, ENV order
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	return candidates, nil
+}
+
+// isIdiomaticEnv reports whether every assignment is written KEY=value with an UPPER_SNAKE key
+func isIdiomaticEnv(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !envLine.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// VerifyFormat checks that content is valid in its declared format, more strictly than ParseConfig
+// Returns a warning when the content is conclusively another format, which
+// usually means the declared format is a mislabel
+func (p *Parser) VerifyFormat(content string, declared models.ConfigFormat) (string, error) {
+	if _, err := p.ParseConfig(content, declared); err != nil {
+		return "", err
+	}
+
+	switch declared {
+	case models.FormatYAML:
+		// ParseConfig reads only the first document of a stream
+		decoder := yaml.NewDecoder(strings.NewReader(content))
+		var document interface{}
+		if err := decoder.Decode(&document); err == nil {
+			if err := decoder.Decode(&document); !errors.Is(err, io.EOF) {
+				return "", fmt.Errorf("content holds more than one YAML document")
+			}
+		}
+	case models.FormatENV:
+		for _, line := range strings.Split(content, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			key := strings.TrimSpace(strings.SplitN(line, "=", 2)[0])
+			if key == "" || strings.ContainsAny(key, " \t\"'") {
+				return "", fmt.Errorf("invalid env variable name: %q", key)
+			}
+		}
+	}
+
+	// Only conclusive signals warn: scores between the looser formats overlap on
+	// ordinary content, e.g. PORT=8080 is valid ENV and TOML alike
+	candidates, err := p.DetectFormatCandidates(content)
+	if err != nil || candidates[0].Format == declared {
+		return "", nil
+	}
+	declaredIsCandidate := false
+	for _, candidate := range candidates {
+		declaredIsCandidate = declaredIsCandidate || candidate.Format == declared
+	}
+	if declaredIsCandidate && candidates[0].Confidence < confidenceJSON {
+		return "", nil
+	}
+	return fmt.Sprintf("content is declared as %s but looks like %s; check the template format", declared, candidates[0].Format), nil
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestParser_DetectFormatCandidates(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name    string
+		content string
+		want    []models.ConfigFormat
+		wantErr bool
+	}{
+		{
+			name:    "JSON outranks YAML",
+			content: `{"name": "app", "port": 8080}`,
+			want:    []models.ConfigFormat{models.FormatJSON, models.FormatYAML},
+		},
+		{
+			name:    "YAML mapping",
+			content: "name: app\nport: 8080",
+			want:    []models.ConfigFormat{models.FormatYAML},
+		},
+		{
+			name:    "TOML section",
+			content: "[server]\nport = 8080",
+			want:    []models.ConfigFormat{models.FormatTOML, models.FormatINI, models.FormatYAML},
+		},
+		{
+			name:    "INI with unquoted values",
+			content: "[server]\nhost = example.com",
+			want:    []models.ConfigFormat{models.FormatINI, models.FormatYAML},
+		},
+		{
+			name:    "ENV lines only parse as a YAML scalar",
+			content: "DB_HOST=localhost\nDB_PORT=5432",
+			want:    []models.ConfigFormat{models.FormatENV, models.FormatYAML},
+		},
+		{
+			name:    "quoted assignment is both TOML and ENV",
+			content: `NAME = "app"`,
+			want:    []models.ConfigFormat{models.FormatTOML, models.FormatENV, models.FormatYAML},
+		},
+		{
+			name:    "empty content",
+			content: "  ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates, err := parser.DetectFormatCandidates(tt.content)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DetectFormatCandidates() error = %v", err)
+			}
+
+			if len(candidates) != len(tt.want) {
+				t.Fatalf("candidates = %v, want formats %v", candidates, tt.want)
+			}
+			for i, candidate := range candidates {
+				if candidate.Format != tt.want[i] {
+					t.Errorf("candidate %d = %s, want %s", i, candidate.Format, tt.want[i])
+				}
+				if i > 0 && candidate.Confidence > candidates[i-1].Confidence {
+					t.Errorf("candidates not sorted by confidence: %v", candidates)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyFormat(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name        string
+		content     string
+		declared    models.ConfigFormat
+		wantWarning string
+		wantErr     bool
+	}{
+		{name: "yaml as yaml", content: "port: 8080\nhost: localhost\n", declared: models.FormatYAML},
+		{name: "json as json", content: `{"port": 8080}`, declared: models.FormatJSON},
+		{name: "env as env", content: "PORT=8080\n", declared: models.FormatENV},
+		{name: "json labeled yaml", content: `{"port": 8080}`, declared: models.FormatYAML, wantWarning: "looks like json"},
+		{name: "env that is also toml", content: "PORT=8080\nNAME=\"app\"\n", declared: models.FormatENV},
+		{name: "yaml stream", content: "port: 1\n---\nport: 2\n", declared: models.FormatYAML, wantErr: true},
+		{name: "env key with spaces", content: "MY PORT=8080\n", declared: models.FormatENV, wantErr: true},
+		{name: "not json", content: "port: 8080\n", declared: models.FormatJSON, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warning, err := parser.VerifyFormat(tt.content, tt.declared)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyFormat() error = %v", err)
+			}
+			if tt.wantWarning == "" && warning != "" {
+				t.Errorf("VerifyFormat() warning = %q, want none", warning)
+			}
+			if !strings.Contains(warning, tt.wantWarning) {
+				t.Errorf("VerifyFormat() warning = %q, want it to mention %q", warning, tt.wantWarning)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Structural diff of parsed configuration data
+// Compares two documents key by key rather than line by line
+// Nested objects are walked; arrays and scalars are compared as whole values
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ChangeType classifies a single key change
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeRemoved  ChangeType = "removed"
+	ChangeModified ChangeType = "modified"
+)
+
+// Change is one differing key between two documents
+type Change struct {
+	Path     string      `json:"path"` // Dotted key path, e.g. server.port
+	Type     ChangeType  `json:"type"`
+	OldValue interface{} `json:"old_value,omitempty"`
+	NewValue interface{} `json:"new_value,omitempty"`
+}
+
+// Diff returns the key-level changes from oldData to newData, sorted by path
+func Diff(oldData, newData map[string]interface{}) []Change {
+	var changes []Change
+	diffMaps("", oldData, newData, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// diffMaps records changes between two objects at path
+func diffMaps(path string, oldData, newData map[string]interface{}, changes *[]Change) {
+	for key, oldValue := range oldData {
+		keyPath := joinPath(path, key)
+		newValue, ok := newData[key]
+		if !ok {
+			*changes = append(*changes, Change{Path: keyPath, Type: ChangeRemoved, OldValue: oldValue})
+			continue
+		}
+
+		oldMap, oldIsMap := oldValue.(map[string]interface{})
+		newMap, newIsMap := newValue.(map[string]interface{})
+		if oldIsMap && newIsMap {
+			diffMaps(keyPath, oldMap, newMap, changes)
+			continue
+		}
+
+		if !reflect.DeepEqual(oldValue, newValue) {
+			*changes = append(*changes, Change{Path: keyPath, Type: ChangeModified, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+
+	for key, newValue := range newData {
+		if _, ok := oldData[key]; !ok {
+			*changes = append(*changes, Change{Path: joinPath(path, key), Type: ChangeAdded, NewValue: newValue})
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// TopLevelKey returns the first segment of a change path
+func (c Change) TopLevelKey() string {
+	key, _, _ := strings.Cut(c.Path, ".")
+	return key
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	oldData := map[string]interface{}{
+		"name": "app",
+		"server": map[string]interface{}{
+			"host": "localhost",
+			"port": 8080,
+		},
+		"tags":  []interface{}{"a", "b"},
+		"debug": true,
+	}
+	newData := map[string]interface{}{
+		"name": "app",
+		"server": map[string]interface{}{
+			"host": "0.0.0.0",
+			"port": 8080,
+			"tls":  true,
+		},
+		"tags": []interface{}{"a", "c"},
+	}
+
+	want := []Change{
+		{Path: "debug", Type: ChangeRemoved, OldValue: true},
+		{Path: "server.host", Type: ChangeModified, OldValue: "localhost", NewValue: "0.0.0.0"},
+		{Path: "server.tls", Type: ChangeAdded, NewValue: true},
+		{Path: "tags", Type: ChangeModified, OldValue: []interface{}{"a", "b"}, NewValue: []interface{}{"a", "c"}},
+	}
+	if got := Diff(oldData, newData); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+
+	if got := Diff(oldData, oldData); len(got) != 0 {
+		t.Errorf("Diff() of identical data = %+v, want none", got)
+	}
+}
+
+func TestChange_TopLevelKey(t *testing.T) {
+	for path, want := range map[string]string{"name": "name", "server.tls.cert": "server"} {
+		if got := (Change{Path: path}).TopLevelKey(); got != want {
+			t.Errorf("TopLevelKey(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// ENV variable expansion
+// Resolves ${VAR} and $VAR references between the keys of a .env file,
+// e.g. BASE_URL=http://${HOST}:${PORT}
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseOption customizes how configuration content is parsed
+type ParseOption func(*parseOptions)
+
+type parseOptions struct {
+	expandEnv bool
+	envLookup func(name string) (string, bool) // Fallback for names the file doesn't define
+}
+
+// WithEnvExpansion resolves ${VAR} and $VAR references in ENV values
+// A reference takes the nearest earlier definition of the name, so PATH=$PATH:/bin
+// extends the previous value, or else the file's later definition. Names the
+// file never defines expand to an empty string, $$ is a literal $, and
+// single-quoted values aren't expanded. Circular references are an error.
+// Other formats ignore this option
+func WithEnvExpansion() ParseOption {
+	return func(o *parseOptions) {
+		o.expandEnv = true
+	}
+}
+
+// WithEnvExpansionFallback expands like WithEnvExpansion, resolving names the
+// file doesn't define through lookup, such as os.LookupEnv
+func WithEnvExpansionFallback(lookup func(name string) (string, bool)) ParseOption {
+	return func(o *parseOptions) {
+		o.expandEnv = true
+		o.envLookup = lookup
+	}
+}
+
+func newParseOptions(opts []ParseOption) parseOptions {
+	var o parseOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// envExpander resolves the values of ENV entries, memoizing each one
+type envExpander struct {
+	entries  []envEntry
+	lookup   func(name string) (string, bool)
+	resolved map[int]string
+	visiting map[int]bool
+	stack    []int // Entries being resolved, for cycle errors
+}
+
+// parseEnvExpanded parses ENV content with references between keys expanded
+func parseEnvExpanded(content string, lookup func(name string) (string, bool)) (map[string]interface{}, error) {
+	entries, err := parseEnvEntries(content)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &envExpander{
+		entries:  entries,
+		lookup:   lookup,
+		resolved: make(map[int]string, len(entries)),
+		visiting: make(map[int]bool),
+	}
+	data := make(map[string]interface{}, len(entries))
+	for i, entry := range entries {
+		value, err := e.value(i)
+		if err != nil {
+			return nil, err
+		}
+		data[entry.key] = value
+	}
+	return data, nil
+}
+
+// value returns the expanded value of entry i
+func (e *envExpander) value(i int) (string, error) {
+	if value, ok := e.resolved[i]; ok {
+		return value, nil
+	}
+	entry := e.entries[i]
+	if e.visiting[i] {
+		var cycle []string
+		for k := len(e.stack) - 1; k >= 0; k-- {
+			cycle = append([]string{e.entries[e.stack[k]].key}, cycle...)
+			if e.stack[k] == i {
+				break
+			}
+		}
+		cycle = append(cycle, entry.key)
+		return "", fmt.Errorf("line %d: circular variable reference: %s", entry.line, strings.Join(cycle, " -> "))
+	}
+	if entry.literal {
+		return entry.value, nil
+	}
+
+	e.visiting[i] = true
+	e.stack = append(e.stack, i)
+	value, err := e.expand(i, entry.value)
+	e.stack = e.stack[:len(e.stack)-1]
+	delete(e.visiting, i)
+	if err != nil {
+		return "", err
+	}
+
+	e.resolved[i] = value
+	return value, nil
+}
+
+// expand replaces the references in the value of entry i
+func (e *envExpander) expand(i int, value string) (string, error) {
+	var out strings.Builder
+	for pos := 0; pos < len(value); pos++ {
+		c := value[pos]
+		if c != '$' || pos+1 == len(value) {
+			out.WriteByte(c)
+			continue
+		}
+
+		var name string
+		switch next := value[pos+1]; {
+		case next == '$':
+			out.WriteByte('$')
+			pos++
+			continue
+		case next == '{':
+			end := strings.IndexByte(value[pos+2:], '}')
+			if end < 0 || !isEnvName(value[pos+2:pos+2+end]) {
+				out.WriteByte(c) // Not a reference; kept as written
+				continue
+			}
+			name = value[pos+2 : pos+2+end]
+			pos += end + 2
+		default:
+			n := envNameLength(value[pos+1:])
+			if n == 0 {
+				out.WriteByte(c)
+				continue
+			}
+			name = value[pos+1 : pos+1+n]
+			pos += n
+		}
+
+		resolved, err := e.resolve(i, name)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(resolved)
+	}
+	return out.String(), nil
+}
+
+// resolve finds the value name refers to from entry i
+// The nearest earlier definition wins, then the last definition after i, then the fallback
+func (e *envExpander) resolve(i int, name string) (string, error) {
+	for j := i - 1; j >= 0; j-- {
+		if e.entries[j].key == name {
+			return e.value(j)
+		}
+	}
+	for j := len(e.entries) - 1; j > i; j-- {
+		if e.entries[j].key == name {
+			return e.value(j)
+		}
+	}
+	if e.lookup != nil {
+		if value, ok := e.lookup(name); ok {
+			return value, nil
+		}
+	}
+	return "", nil
+}
+
+// envNameLength returns the length of the variable name at the start of s
+func envNameLength(s string) int {
+	n := 0
+	for n < len(s) {
+		c := s[n]
+		isLetter := c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+		if !isLetter && (n == 0 || c < '0' || c > '9') {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// isEnvName reports whether s is a whole variable name
+func isEnvName(s string) bool {
+	return s != "" && envNameLength(s) == len(s)
+}
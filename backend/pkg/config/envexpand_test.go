@@ -0,0 +1,125 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestParser_ParseConfigEnvExpansion(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]interface{}
+	}{
+		{
+			name:    "braced and bare references",
+			content: "HOST=localhost\nPORT=8080\nBASE_URL=http://${HOST}:$PORT/api",
+			want:    map[string]interface{}{"HOST": "localhost", "PORT": "8080", "BASE_URL": "http://localhost:8080/api"},
+		},
+		{
+			name:    "nested references",
+			content: "SCHEME=https\nHOST=example.com\nORIGIN=${SCHEME}://${HOST}\nLOGIN_URL=${ORIGIN}/login",
+			want: map[string]interface{}{
+				"SCHEME": "https", "HOST": "example.com", "ORIGIN": "https://example.com", "LOGIN_URL": "https://example.com/login",
+			},
+		},
+		{
+			name:    "forward reference",
+			content: "URL=http://$HOST\nHOST=db",
+			want:    map[string]interface{}{"URL": "http://db", "HOST": "db"},
+		},
+		{
+			name:    "redefinition extends the earlier value",
+			content: "FLAGS=-v\nFLAGS=\"$FLAGS -x\"",
+			want:    map[string]interface{}{"FLAGS": "-v -x"},
+		},
+		{
+			name:    "missing variables expand to empty",
+			content: "URL=http://${MISSING}:$ALSO_MISSING/",
+			want:    map[string]interface{}{"URL": "http://:/"},
+		},
+		{
+			name:    "escapes and non-references stay literal",
+			content: "HOST=db\nPRICE=$$5 ${HOST\nSINGLE='$HOST'\nTRAILING=cost$\nDIGIT=$1",
+			want: map[string]interface{}{
+				"HOST": "db", "PRICE": "$5 ${HOST", "SINGLE": "$HOST", "TRAILING": "cost$", "DIGIT": "$1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parser.ParseConfig(tt.content, models.FormatENV, WithEnvExpansion())
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseConfig() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_ParseConfigEnvExpansionFallback(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		value, ok := map[string]string{"HOME": "/home/app", "HOST": "from-process"}[name]
+		return value, ok
+	}
+
+	got, err := NewParser().ParseConfig("HOST=from-file\nCACHE=$HOME/.cache\nURL=http://$HOST\nNONE=[$UNSET]",
+		models.FormatENV, WithEnvExpansionFallback(lookup))
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+	want := map[string]interface{}{"HOST": "from-file", "CACHE": "/home/app/.cache", "URL": "http://from-file", "NONE": "[]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseConfig() = %v, want %v", got, want)
+	}
+}
+
+func TestParser_ParseConfigEnvExpansionCycles(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantLine  int
+		wantCycle string
+	}{
+		{name: "two keys", content: "A=$B\nB=${A}", wantLine: 1, wantCycle: "A -> B -> A"},
+		{name: "longer cycle", content: "START=$A\nA=$B\nB=$C\nC=$A", wantLine: 2, wantCycle: "A -> B -> C -> A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser().ParseConfig(tt.content, models.FormatENV, WithEnvExpansion())
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("ParseConfig() error = %v, want *ParseError", err)
+			}
+			if parseErr.Line != tt.wantLine || !strings.Contains(parseErr.Message, tt.wantCycle) {
+				t.Errorf("error = line %d %q, want line %d naming %s", parseErr.Line, parseErr.Message, tt.wantLine, tt.wantCycle)
+			}
+		})
+	}
+
+	// A self reference with nothing earlier to extend is not a cycle
+	got, err := NewParser().ParseConfig("PATH=$PATH:/bin", models.FormatENV, WithEnvExpansion())
+	if err != nil || got["PATH"] != ":/bin" {
+		t.Errorf("ParseConfig() = %v, %v; want PATH=:/bin", got, err)
+	}
+}
+
+func TestParser_ParseConfigEnvDefaultsToLiteral(t *testing.T) {
+	got, err := NewParser().ParseConfig("HOST=db\nURL=http://${HOST}", models.FormatENV)
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+	if got["URL"] != "http://${HOST}" {
+		t.Errorf("URL = %v, want the reference kept literally", got["URL"])
+	}
+}
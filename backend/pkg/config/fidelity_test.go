@@ -0,0 +1,161 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+// fidelityInput holds one value of each kind the codecs have to carry
+// Values are the Go types handlers and merges produce before serializing
+func fidelityInput() map[string]interface{} {
+	return map[string]interface{}{
+		"string":      "hello",
+		"numeric_str": "42",
+		"bool_str":    "true",
+		"int":         42,
+		"int64":       int64(7),
+		"float":       1.5,
+		"whole_float": 2.0,
+		"bool":        true,
+		"null":        nil,
+		"list":        []interface{}{1, "a", true},
+		"nested":      map[string]interface{}{"port": 8080, "host": "localhost"},
+	}
+}
+
+// TestSerializeParseFidelity pins down what each format returns after Serialize→Parse
+// A change here is a change to the conversion contract that diff and merge rely on,
+// so update the expectations and the lossy-conversion warnings together
+func TestSerializeParseFidelity(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		format models.ConfigFormat
+		want   map[string]interface{}
+	}{
+		{
+			// Every number decodes as float64
+			format: models.FormatJSON,
+			want: map[string]interface{}{
+				"string":      "hello",
+				"numeric_str": "42",
+				"bool_str":    "true",
+				"int":         float64(42),
+				"int64":       float64(7),
+				"float":       1.5,
+				"whole_float": float64(2),
+				"bool":        true,
+				"null":        nil,
+				"list":        []interface{}{float64(1), "a", true},
+				"nested":      map[string]interface{}{"port": float64(8080), "host": "localhost"},
+			},
+		},
+		{
+			// Integers decode as int, and so do floats with no fractional part
+			format: models.FormatYAML,
+			want: map[string]interface{}{
+				"string":      "hello",
+				"numeric_str": "42",
+				"bool_str":    "true",
+				"int":         42,
+				"int64":       7,
+				"float":       1.5,
+				"whole_float": 2,
+				"bool":        true,
+				"null":        nil,
+				"list":        []interface{}{1, "a", true},
+				"nested":      map[string]interface{}{"port": 8080, "host": "localhost"},
+			},
+		},
+		{
+			// Integers decode as int64 and floats stay floats; null keys are dropped
+			format: models.FormatTOML,
+			want: map[string]interface{}{
+				"string":      "hello",
+				"numeric_str": "42",
+				"bool_str":    "true",
+				"int":         int64(42),
+				"int64":       int64(7),
+				"float":       1.5,
+				"whole_float": float64(2),
+				"bool":        true,
+				"list":        []interface{}{int64(1), "a", true},
+				"nested":      map[string]interface{}{"port": int64(8080), "host": "localhost"},
+			},
+		},
+		{
+			// Everything is a string; null is the string "null" and nested values are JSON
+			format: models.FormatENV,
+			want: map[string]interface{}{
+				"string":      "hello",
+				"numeric_str": "42",
+				"bool_str":    "true",
+				"int":         "42",
+				"int64":       "7",
+				"float":       "1.5",
+				"whole_float": "2",
+				"bool":        "true",
+				"null":        "null",
+				"list":        `[1,"a",true]`,
+				"nested":      `{"host":"localhost","port":8080}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			serialized, err := parser.SerializeConfig(fidelityInput(), tt.format)
+			if err != nil {
+				t.Fatalf("SerializeConfig() error = %v", err)
+			}
+			parsed, err := parser.ParseConfig(serialized, tt.format)
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v\n%s", err, serialized)
+			}
+
+			for key, want := range tt.want {
+				got, ok := parsed[key]
+				if !ok {
+					t.Errorf("%s: missing after round trip", key)
+					continue
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%s = %#v (%T), want %#v (%T)", key, got, got, want, want)
+				}
+			}
+			for key := range parsed {
+				if _, ok := tt.want[key]; !ok {
+					t.Errorf("%s: unexpected key after round trip", key)
+				}
+			}
+		})
+	}
+}
+
+// TestSerializeParseFidelity_Stable checks that a second round trip changes nothing,
+// so repeated saves in the same format don't drift
+func TestSerializeParseFidelity_Stable(t *testing.T) {
+	parser := NewParser()
+
+	for _, format := range []models.ConfigFormat{models.FormatJSON, models.FormatYAML, models.FormatTOML, models.FormatENV} {
+		t.Run(string(format), func(t *testing.T) {
+			var rounds [2]map[string]interface{}
+			data := fidelityInput()
+			for i := range rounds {
+				serialized, err := parser.SerializeConfig(data, format)
+				if err != nil {
+					t.Fatalf("SerializeConfig() error = %v", err)
+				}
+				if data, err = parser.ParseConfig(serialized, format); err != nil {
+					t.Fatalf("ParseConfig() error = %v", err)
+				}
+				rounds[i] = data
+			}
+			if !reflect.DeepEqual(rounds[0], rounds[1]) {
+				t.Errorf("second round trip = %v, want %v", rounds[1], rounds[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+// Canonical content hashing
+// Identifies configurations by their parsed values rather than their text,
+// so reformatting, key order, and comments don't count as changes
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"conflux/internal/models"
+)
+
+// CanonicalHash returns a SHA-256 hex digest of parsed configuration data
+// Keys are sorted and whitespace ignored; equal documents in different formats
+// hash alike when their values parse to the same types
+func CanonicalHash(data map[string]interface{}) (string, error) {
+	canonical, err := canonicalJSON(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Canonicalize returns parsed configuration data as the plain JSON values CanonicalHash hashes
+// Format-specific types become their JSON equivalents (TOML datetimes turn into strings,
+// for example), and numbers keep their exact text as json.Number
+func Canonicalize(data map[string]interface{}) (map[string]interface{}, error) {
+	canonical, err := canonicalJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(canonical))
+	decoder.UseNumber()
+	normalized := map[string]interface{}{}
+	if err := decoder.Decode(&normalized); err != nil {
+		return nil, fmt.Errorf("failed to canonicalize configuration: %w", err)
+	}
+	return normalized, nil
+}
+
+// canonicalJSON encodes data with sorted keys and no insignificant whitespace
+func canonicalJSON(data map[string]interface{}) ([]byte, error) {
+	// encoding/json writes map keys in sorted order, which makes the output canonical;
+	// datetimes are written as FormatDatetime does, so a TOML local date stays a date
+	canonical, err := json.Marshal(prepareDatetimes(data, models.FormatJSON, serializeOptions{}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize configuration: %w", err)
+	}
+	return canonical, nil
+}
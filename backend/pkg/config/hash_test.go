@@ -0,0 +1,77 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestCanonicalHash(t *testing.T) {
+	parser := NewParser()
+	hash := func(content string, format models.ConfigFormat) string {
+		t.Helper()
+		data, err := parser.ParseConfig(content, format)
+		if err != nil {
+			t.Fatalf("ParseConfig() error = %v", err)
+		}
+		sum, err := CanonicalHash(data)
+		if err != nil {
+			t.Fatalf("CanonicalHash() error = %v", err)
+		}
+		return sum
+	}
+
+	base := hash("name: app\nserver:\n  port: 8080\n  tls: true\n", models.FormatYAML)
+
+	equivalent := map[string]struct {
+		content string
+		format  models.ConfigFormat
+	}{
+		"reordered keys":    {"server:\n  tls: true\n  port: 8080\nname: app\n", models.FormatYAML},
+		"comments":          {"# app settings\nname: app # inline\nserver:\n  port: 8080\n  tls: true\n", models.FormatYAML},
+		"flow style":        {"{name: app, server: {port: 8080, tls: true}}", models.FormatYAML},
+		"same data as json": {`{"server": {"tls": true, "port": 8080}, "name": "app"}`, models.FormatJSON},
+	}
+	for name, tt := range equivalent {
+		if got := hash(tt.content, tt.format); got != base {
+			t.Errorf("%s: hash differs from the original", name)
+		}
+	}
+
+	if got := hash("name: app\nserver:\n  port: 8081\n  tls: true\n", models.FormatYAML); got == base {
+		t.Error("changed value produced the same hash")
+	}
+	if got := hash("name: app\nserver:\n  port: \"8080\"\n  tls: true\n", models.FormatYAML); got == base {
+		t.Error("changed value type produced the same hash")
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	parser := NewParser()
+	data, err := parser.ParseConfig("title = \"app\"\nstarted = 2024-01-02T03:04:05Z\n[server]\nport = 8080\nratio = 0.5\n", models.FormatTOML)
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+
+	got, err := Canonicalize(data)
+	if err != nil {
+		t.Fatalf("Canonicalize() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"title":   "app",
+		"started": "2024-01-02T03:04:05Z",
+		"server":  map[string]interface{}{"port": json.Number("8080"), "ratio": json.Number("0.5")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Canonicalize() = %#v, want %#v", got, want)
+	}
+
+	// The canonical form hashes the same as the data it came from
+	before, _ := CanonicalHash(data)
+	after, _ := CanonicalHash(got)
+	if before != after {
+		t.Error("canonical form hashes differently from the parsed data")
+	}
+}
@@ -0,0 +1,220 @@
+// JSON with comments (JSONC)
+// Reads the JSON dialect used by VS Code settings and tsconfig: // and /* */
+// comments and trailing commas. Output is always standard JSON, except for
+// JSONC-to-JSONC reformatting, which keeps the comments
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"conflux/internal/models"
+)
+
+// StripJSONC turns JSONC into standard JSON by blanking comments and trailing commas
+// Removed characters become spaces and newlines are kept, so offsets and line
+// numbers in a JSON syntax error still point into the original content
+func StripJSONC(content string) string {
+	out := []byte(content)
+	lastComma := -1 // Offset of a comma not yet followed by a value
+	inString := false
+
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		switch {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString, lastComma = true, -1
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := strings.Index(content[i+2:], "*/")
+			stop := len(out)
+			if end >= 0 {
+				stop = i + 2 + end + 2
+			}
+			for ; i < stop; i++ {
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+			i--
+		case c == ',':
+			lastComma = i
+		case c == '}' || c == ']':
+			if lastComma >= 0 {
+				out[lastComma] = ' '
+			}
+			lastComma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			lastComma = -1
+		}
+	}
+	return string(out)
+}
+
+// isJSONFormat reports whether format is JSON or JSONC, which decode alike
+func isJSONFormat(format models.ConfigFormat) bool {
+	return format == models.FormatJSON || format == models.FormatJSONC
+}
+
+// hasJSONCSyntax reports whether content uses comments or trailing commas
+func hasJSONCSyntax(content string) bool {
+	return StripJSONC(content) != content
+}
+
+func (p *Parser) parseJSONC(content string) (map[string]interface{}, error) {
+	return p.parseJSON(StripJSONC(content))
+}
+
+// jsoncToken is one lexical element of a JSONC document
+type jsoncToken struct {
+	text    string
+	comment bool
+	line    int // Source line the token starts on
+}
+
+// reformatJSONC re-indents JSONC as serializeJSON would, keeping its comments
+// A comment on the same line as the value before it stays there; any other
+// comment gets a line of its own. Trailing commas are dropped
+func (p *Parser) reformatJSONC(content string) (string, error) {
+	if _, err := p.parseJSONC(content); err != nil {
+		return "", fmt.Errorf("failed to parse source format: %w", err)
+	}
+	tokens := tokenizeJSONC(content)
+
+	var buf strings.Builder
+	depth := 0
+	newline := func() {
+		buf.WriteString("\n")
+		buf.WriteString(strings.Repeat("  ", depth))
+	}
+	// nextValue returns the next non-comment token after i
+	nextValue := func(i int) string {
+		for j := i + 1; j < len(tokens); j++ {
+			if !tokens[j].comment {
+				return tokens[j].text
+			}
+		}
+		return ""
+	}
+
+	pendingLine := false // A value, opener, or comma was written; the next item starts a line
+	for i, token := range tokens {
+		if token.comment {
+			if i > 0 && tokens[i-1].line == token.line && buf.Len() > 0 {
+				buf.WriteString(" ")
+			} else if buf.Len() > 0 {
+				newline()
+			}
+			buf.WriteString(token.text)
+			pendingLine = true
+			continue
+		}
+
+		switch token.text {
+		case "{", "[":
+			if pendingLine {
+				newline()
+			}
+			buf.WriteString(token.text)
+			if next := tokens[i+1].text; next == "}" || next == "]" {
+				pendingLine = false // Empty, written {} or []
+				continue
+			}
+			depth++
+			pendingLine = true
+		case "}", "]":
+			if previous := tokens[i-1].text; previous != "{" && previous != "[" {
+				depth--
+				newline()
+			}
+			buf.WriteString(token.text)
+			pendingLine = false
+		case ",":
+			if next := nextValue(i); next == "}" || next == "]" {
+				continue // Trailing comma
+			}
+			buf.WriteString(",")
+			pendingLine = true
+		case ":":
+			buf.WriteString(": ")
+		default:
+			if pendingLine {
+				newline()
+			}
+			buf.WriteString(token.text)
+			pendingLine = false
+		}
+	}
+	return buf.String(), nil
+}
+
+// tokenizeJSONC splits content that parses as JSONC into tokens
+// Whitespace is dropped and literals are normalized through encoding/json
+func tokenizeJSONC(content string) []jsoncToken {
+	var tokens []jsoncToken
+	line := 1
+	for i := 0; i < len(content); {
+		c := content[i]
+		start, startLine := i, line
+		switch {
+		case c == '\n':
+			line++
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(content[i:], "//"):
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			tokens = append(tokens, jsoncToken{text: strings.TrimRight(content[start:i], " \t\r"), comment: true, line: startLine})
+			continue
+		case strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				i = len(content)
+			} else {
+				i += 2 + end + 2
+			}
+			line += strings.Count(content[start:i], "\n")
+			tokens = append(tokens, jsoncToken{text: content[start:i], comment: true, line: startLine})
+			continue
+		case strings.ContainsRune("{}[],:", rune(c)):
+			i++
+		case c == '"':
+			for i++; i < len(content) && content[i] != '"'; i++ {
+				if content[i] == '\\' {
+					i++
+				}
+			}
+			i++
+		default:
+			for i < len(content) && !strings.ContainsRune("{}[],: \t\r\n/\"", rune(content[i])) {
+				i++
+			}
+		}
+		text := content[start:i]
+		if c == '"' {
+			// Re-encode strings so escapes match serializeJSON
+			var s string
+			if err := json.Unmarshal([]byte(text), &s); err == nil {
+				encoded, _ := json.Marshal(s)
+				text = string(encoded)
+			}
+		}
+		tokens = append(tokens, jsoncToken{text: text, line: startLine})
+	}
+	return tokens
+}
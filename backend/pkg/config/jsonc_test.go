@@ -0,0 +1,141 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+const tsconfigJSONC = `// Compiler settings
+{
+  /* Emit modern output */
+  "compilerOptions": {
+    "target": "es2022", // Node 18+
+    "paths": {"@/*": ["src/*"],},
+    "strict": true,
+  },
+  "include": ["src", "tests",], // Trailing commas everywhere
+}`
+
+func TestParser_ParseJSONC(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]interface{}
+	}{
+		{
+			name:    "comments and trailing commas",
+			content: tsconfigJSONC,
+			want: map[string]interface{}{
+				"compilerOptions": map[string]interface{}{
+					"target": "es2022",
+					"paths":  map[string]interface{}{"@/*": []interface{}{"src/*"}},
+					"strict": true,
+				},
+				"include": []interface{}{"src", "tests"},
+			},
+		},
+		{
+			name:    "comment markers inside strings",
+			content: `{"url": "http://example.com/a", "glob": "src/**/*.ts", "note": "a, }"}`,
+			want:    map[string]interface{}{"url": "http://example.com/a", "glob": "src/**/*.ts", "note": "a, }"},
+		},
+		{
+			name:    "escaped quote before a comment",
+			content: "{\"a\": \"say \\\"hi\\\"\" // greeting\n}",
+			want:    map[string]interface{}{"a": `say "hi"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parser.ParseConfig(tt.content, models.FormatJSON); err == nil && tt.content == tsconfigJSONC {
+				t.Fatal("standard JSON accepted JSONC input")
+			}
+			got, err := parser.ParseConfig(tt.content, models.FormatJSONC)
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseConfig() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_ParseJSONCErrorPosition(t *testing.T) {
+	parser := NewParser()
+
+	// The blanked comment must not shift the reported position
+	_, err := parser.ParseConfig("{\n  // comment\n  \"a\": tru\n}", models.FormatJSONC)
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("error = %v, want *ParseError", err)
+	}
+	if parseErr.Line != 3 {
+		t.Errorf("line = %d, want 3", parseErr.Line)
+	}
+}
+
+func TestParser_DetectJSONC(t *testing.T) {
+	parser := NewParser()
+
+	format, err := parser.DetectFormat(tsconfigJSONC)
+	if err != nil || format != models.FormatJSONC {
+		t.Errorf("DetectFormat(JSONC) = %v, %v; want jsonc", format, err)
+	}
+	format, err = parser.DetectFormat(`{"a": 1}`)
+	if err != nil || format != models.FormatJSON {
+		t.Errorf("DetectFormat(JSON) = %v, %v; want json", format, err)
+	}
+}
+
+func TestParser_SerializeJSONCWritesJSON(t *testing.T) {
+	parser := NewParser()
+
+	converted, err := parser.ConvertFormat(tsconfigJSONC, models.FormatJSONC, models.FormatJSONC)
+	if err != nil {
+		t.Fatalf("ConvertFormat() error = %v", err)
+	}
+	if !parser.isValidJSON(converted) {
+		t.Errorf("output is not standard JSON:\n%s", converted)
+	}
+}
+
+func TestParser_ReformatJSONCKeepsComments(t *testing.T) {
+	parser := NewParser()
+
+	got, err := parser.ConvertFormatPreserveComments(tsconfigJSONC, models.FormatJSONC, models.FormatJSONC)
+	if err != nil {
+		t.Fatalf("ConvertFormatPreserveComments() error = %v", err)
+	}
+	want := `// Compiler settings
+{
+  /* Emit modern output */
+  "compilerOptions": {
+    "target": "es2022", // Node 18+
+    "paths": {
+      "@/*": [
+        "src/*"
+      ]
+    },
+    "strict": true
+  },
+  "include": [
+    "src",
+    "tests"
+  ] // Trailing commas everywhere
+}`
+	if got != want {
+		t.Errorf("reformatted =\n%s\nwant\n%s", got, want)
+	}
+
+	if empty, err := parser.ConvertFormatPreserveComments(`{"a": {}, "b": [ ]}`, models.FormatJSONC, models.FormatJSONC); err != nil ||
+		empty != "{\n  \"a\": {},\n  \"b\": []\n}" {
+		t.Errorf("empty containers = %q, %v", empty, err)
+	}
+}
@@ -0,0 +1,100 @@
+// Unknown key detection
+// Flags keys a configuration sets but its schema or an allowlist doesn't permit,
+// which usually means a typo the target application would silently ignore
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidateOption customizes ValidateConfig
+type ValidateOption func(*validateOptions)
+
+type validateOptions struct {
+	allowedKeys []string // nil when no allowlist applies
+}
+
+// WithAllowedKeys permits only the given top-level keys, for configurations
+// without a full schema; nested keys are not checked
+func WithAllowedKeys(keys ...string) ValidateOption {
+	return func(o *validateOptions) {
+		o.allowedKeys = append([]string{}, keys...)
+	}
+}
+
+func newValidateOptions(opts []ValidateOption) validateOptions {
+	var o validateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// UnknownKeysError lists keys that a schema or allowlist doesn't permit
+// Keys use LookupPath notation, e.g. server.hots
+type UnknownKeysError struct {
+	Keys []string
+}
+
+func (e *UnknownKeysError) Error() string {
+	return "unexpected keys: " + strings.Join(e.Keys, ", ")
+}
+
+// keySchema is the subset of a JSON schema that unknown key detection reads
+type keySchema struct {
+	Properties           map[string]*keySchema `json:"properties"`
+	AdditionalProperties json.RawMessage       `json:"additionalProperties"`
+}
+
+// UnknownSchemaKeys returns the sorted keys of data that the schema forbids
+// through "additionalProperties": false, at the top level and in nested objects
+// declared under "properties"
+func UnknownSchemaKeys(data map[string]interface{}, schema string) ([]string, error) {
+	var root keySchema
+	if err := json.Unmarshal([]byte(schema), &root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	var unknown []string
+	collectUnknownKeys(data, &root, "", &unknown)
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
+// collectUnknownKeys appends the keys of object that node forbids, prefixed with path
+func collectUnknownKeys(object map[string]interface{}, node *keySchema, path string, unknown *[]string) {
+	closed := bytes.Equal(bytes.TrimSpace(node.AdditionalProperties), []byte("false"))
+	for key, value := range object {
+		property, declared := node.Properties[key]
+		if !declared {
+			if closed {
+				*unknown = append(*unknown, joinPath(path, key))
+			}
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok && property != nil {
+			collectUnknownKeys(nested, property, joinPath(path, key), unknown)
+		}
+	}
+}
+
+// UnknownKeys returns the sorted top-level keys of data missing from allowed
+func UnknownKeys(data map[string]interface{}, allowed []string) []string {
+	permitted := make(map[string]struct{}, len(allowed))
+	for _, key := range allowed {
+		permitted[key] = struct{}{}
+	}
+
+	var unknown []string
+	for key := range data {
+		if _, ok := permitted[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestUnknownSchemaKeys(t *testing.T) {
+	schema := `{
+		"additionalProperties": false,
+		"properties": {
+			"port": {"type": "integer"},
+			"server": {"properties": {"host": {}}, "additionalProperties": false},
+			"labels": {"additionalProperties": {"type": "string"}}
+		}
+	}`
+	data := map[string]interface{}{
+		"port":   8080,
+		"prot":   8081,
+		"server": map[string]interface{}{"host": "localhost", "hots": "typo"},
+		"labels": map[string]interface{}{"anything": "goes"},
+	}
+
+	got, err := UnknownSchemaKeys(data, schema)
+	if err != nil {
+		t.Fatalf("UnknownSchemaKeys() error = %v", err)
+	}
+	if want := []string{"prot", "server.hots"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UnknownSchemaKeys() = %v, want %v", got, want)
+	}
+
+	// Open schemas allow anything
+	if got, _ := UnknownSchemaKeys(data, `{"properties": {"port": {}}}`); len(got) != 0 {
+		t.Errorf("UnknownSchemaKeys() for an open schema = %v, want none", got)
+	}
+}
+
+func TestParser_ValidateConfig_UnknownKeys(t *testing.T) {
+	parser := NewParser()
+	schema := `{"additionalProperties": false, "properties": {"port": {}, "host": {}}}`
+
+	tests := []struct {
+		name    string
+		content string
+		schema  *string
+		opts    []ValidateOption
+		want    []string
+	}{
+		{name: "schema", content: "port: 1\nhsot: x\n", schema: &schema, want: []string{"hsot"}},
+		{name: "allowlist", content: "port: 1\ndebug: true\n", opts: []ValidateOption{WithAllowedKeys("port")}, want: []string{"debug"}},
+		{
+			name:    "schema and allowlist merged",
+			content: "port: 1\nhost: x\nextra: y\n",
+			schema:  &schema,
+			opts:    []ValidateOption{WithAllowedKeys("port", "extra")},
+			want:    []string{"extra", "host"},
+		},
+		{name: "all allowed", content: "port: 1\n", schema: &schema, opts: []ValidateOption{WithAllowedKeys("port")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parser.ValidateConfig(tt.content, models.FormatYAML, tt.schema, tt.opts...)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("ValidateConfig() error = %v", err)
+				}
+				return
+			}
+			var unknown *UnknownKeysError
+			if !errors.As(err, &unknown) {
+				t.Fatalf("ValidateConfig() error = %v, want *UnknownKeysError", err)
+			}
+			if !reflect.DeepEqual(unknown.Keys, tt.want) {
+				t.Errorf("unknown keys = %v, want %v", unknown.Keys, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Conversion safety checks
+// Inspects parsed data against the target codec's capabilities before converting,
+// so callers learn which keys will be flattened, stringified, or dropped
+package config
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"conflux/internal/models"
+)
+
+// Conversion warning codes
+const (
+	WarningNestingFlattened = "nesting_flattened"
+	WarningTypesStringified = "types_stringified"
+	WarningNullsLost        = "nulls_lost"
+	WarningIntegersAsFloats = "integers_as_floats"
+	WarningCommentsDropped  = "comments_dropped"
+)
+
+// ConversionWarning describes data that a conversion will not carry over faithfully
+type ConversionWarning struct {
+	Code    string   `json:"code"`
+	Message string   `json:"message"`
+	Paths   []string `json:"paths,omitempty"` // Affected keys, in LookupPath notation
+}
+
+// ConvertFormatWithWarnings converts like ConvertFormat and also reports lossy conversions
+func (p *Parser) ConvertFormatWithWarnings(
+	content string, fromFormat, toFormat models.ConfigFormat, opts ...SerializeOption,
+) (string, []ConversionWarning, error) {
+	data, err := p.ParseConfig(content, fromFormat)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse source format: %w", err)
+	}
+
+	converted, err := p.SerializeConfig(data, toFormat, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	warnings := CheckConversion(data, fromFormat, toFormat)
+	if from, ok := LookupCodec(fromFormat); ok && from.SupportsComments && fromFormat != toFormat && hasComments(content) {
+		warnings = append(warnings, ConversionWarning{
+			Code:    WarningCommentsDropped,
+			Message: "comments in the source are not carried over",
+		})
+	}
+	return converted, warnings, nil
+}
+
+// CheckConversion reports which values in data the target format can't represent as-is
+// An empty result means the conversion keeps every value's structure and type
+func CheckConversion(data map[string]interface{}, fromFormat, toFormat models.ConfigFormat) []ConversionWarning {
+	to, ok := LookupCodec(toFormat)
+	if !ok || fromFormat == toFormat {
+		return []ConversionWarning{}
+	}
+
+	var nested, typed, nulls, integers []string
+	var walk func(path string, value interface{}, topLevel bool)
+	walk = func(path string, value interface{}, topLevel bool) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if !to.SupportsNesting && topLevel {
+				nested = append(nested, path)
+				return
+			}
+			for key, child := range v {
+				walk(path+"."+key, child, false)
+			}
+		case []interface{}:
+			if !to.SupportsNesting && topLevel {
+				nested = append(nested, path)
+				return
+			}
+			for i, child := range v {
+				walk(fmt.Sprintf("%s[%d]", path, i), child, false)
+			}
+		case nil:
+			if !to.SupportsNull {
+				nulls = append(nulls, path)
+			}
+		case bool, int, int64, uint64:
+			if !to.SupportsTypes {
+				typed = append(typed, path)
+			}
+		case float64:
+			if !to.SupportsTypes {
+				typed = append(typed, path)
+			} else if isJSONFormat(fromFormat) && toFormat == models.FormatTOML && v == math.Trunc(v) {
+				// JSON numbers decode as float64, which TOML always writes with a decimal point
+				integers = append(integers, path)
+			}
+		}
+	}
+	for key, value := range data {
+		walk(key, value, true)
+	}
+
+	warnings := []ConversionWarning{}
+	add := func(code, message string, paths []string) {
+		if len(paths) == 0 {
+			return
+		}
+		sort.Strings(paths)
+		warnings = append(warnings, ConversionWarning{Code: code, Message: message, Paths: paths})
+	}
+	add(WarningNestingFlattened,
+		fmt.Sprintf("%s can't represent nested objects or arrays; they are written as JSON strings", toFormat), nested)
+	add(WarningTypesStringified,
+		fmt.Sprintf("%s has no number or boolean types; these values become strings", toFormat), typed)
+	add(WarningNullsLost, nullMessage(toFormat), nulls)
+	add(WarningIntegersAsFloats, "integers are written as floats", integers)
+	return warnings
+}
+
+// nullMessage describes what the target format does with null values
+func nullMessage(format models.ConfigFormat) string {
+	if format == models.FormatTOML {
+		return "toml has no null; keys with null values are dropped"
+	}
+	return fmt.Sprintf("%s has no null; null values are written as the string \"null\"", format)
+}
+
+// hasComments reports whether any line of content is a # comment
+func hasComments(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestCheckConversion(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name    string
+		content string
+		from    models.ConfigFormat
+		to      models.ConfigFormat
+		want    map[string][]string // Warning code -> paths
+	}{
+		{
+			name:    "nested yaml to env",
+			content: "name: app\nport: 8080\ndebug: true\nserver:\n  host: localhost\nhosts: [a, b]\nempty: null\n",
+			from:    models.FormatYAML,
+			to:      models.FormatENV,
+			want: map[string][]string{
+				WarningNestingFlattened: {"hosts", "server"},
+				WarningTypesStringified: {"debug", "port"},
+				WarningNullsLost:        {"empty"},
+			},
+		},
+		{
+			name:    "yaml nulls to toml",
+			content: "name: app\nserver:\n  host: null\n",
+			from:    models.FormatYAML,
+			to:      models.FormatTOML,
+			want:    map[string][]string{WarningNullsLost: {"server.host"}},
+		},
+		{
+			name:    "json integers to toml",
+			content: `{"port": 8080, "ratio": 0.5, "servers": [{"weight": 2}]}`,
+			from:    models.FormatJSON,
+			to:      models.FormatTOML,
+			want:    map[string][]string{WarningIntegersAsFloats: {"port", "servers[0].weight"}},
+		},
+		{
+			name:    "lossless yaml to json",
+			content: "name: app\nserver:\n  port: 8080\n  tags: [a, null]\n",
+			from:    models.FormatYAML,
+			to:      models.FormatJSON,
+			want:    map[string][]string{},
+		},
+		{
+			name:    "flat env to yaml",
+			content: "NAME=app\nPORT=8080\n",
+			from:    models.FormatENV,
+			to:      models.FormatYAML,
+			want:    map[string][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parser.ParseConfig(tt.content, tt.from)
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+
+			got := make(map[string][]string)
+			for _, warning := range CheckConversion(data, tt.from, tt.to) {
+				if warning.Message == "" {
+					t.Errorf("warning %s has no message", warning.Code)
+				}
+				got[warning.Code] = warning.Paths
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckConversion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_ConvertFormatWithWarnings(t *testing.T) {
+	parser := NewParser()
+
+	content := "# Service settings\nserver:\n  port: 8080\n"
+	converted, warnings, err := parser.ConvertFormatWithWarnings(content, models.FormatYAML, models.FormatENV)
+	if err != nil {
+		t.Fatalf("ConvertFormatWithWarnings() error = %v", err)
+	}
+	if plain, _ := parser.ConvertFormat(content, models.FormatYAML, models.FormatENV); converted != plain {
+		t.Errorf("converted = %q, want same output as ConvertFormat %q", converted, plain)
+	}
+
+	var codes []string
+	for _, warning := range warnings {
+		codes = append(codes, warning.Code)
+	}
+	want := []string{WarningNestingFlattened, WarningCommentsDropped}
+	if !reflect.DeepEqual(codes, want) {
+		t.Errorf("warning codes = %v, want %v", codes, want)
+	}
+
+	if _, _, err := parser.ConvertFormatWithWarnings("{", models.FormatJSON, models.FormatYAML); err == nil {
+		t.Error("ConvertFormatWithWarnings() with invalid source returned no error")
+	}
+}
@@ -0,0 +1,84 @@
+// Configuration layering
+// Deep-merges an override configuration onto a base, e.g. user overrides on a template
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"conflux/internal/models"
+)
+
+// MergeOption customizes how Merge combines two configurations
+type MergeOption func(*mergeOptions)
+
+type mergeOptions struct {
+	appendArrays bool
+}
+
+// WithAppendArrays appends the override's arrays to the base's instead of replacing them
+func WithAppendArrays() MergeOption {
+	return func(o *mergeOptions) {
+		o.appendArrays = true
+	}
+}
+
+// Merge deep-merges override onto base, both in format, and serializes the result
+// in that format. Maps merge recursively; any other override value, arrays
+// included, replaces the base value at its key. Empty content is an empty map
+func (p *Parser) Merge(base, override string, format models.ConfigFormat, opts ...MergeOption) (string, error) {
+	baseData, err := p.parseMergeInput(base, format)
+	if err != nil {
+		return "", fmt.Errorf("invalid base configuration: %w", err)
+	}
+	overrideData, err := p.parseMergeInput(override, format)
+	if err != nil {
+		return "", fmt.Errorf("invalid override configuration: %w", err)
+	}
+
+	return p.SerializeConfig(DeepMerge(baseData, overrideData, opts...), format)
+}
+
+// parseMergeInput parses one side of a merge, treating blank content as empty
+func (p *Parser) parseMergeInput(content string, format models.ConfigFormat) (map[string]interface{}, error) {
+	if strings.TrimSpace(content) == "" {
+		if _, ok := LookupCodec(format); !ok {
+			return nil, fmt.Errorf("unsupported format: %s", format)
+		}
+		return map[string]interface{}{}, nil
+	}
+	return p.ParseConfig(content, format)
+}
+
+// DeepMerge merges override into base and returns the result
+// base is updated in place; values taken from override are not copied
+func DeepMerge(base, override map[string]interface{}, opts ...MergeOption) map[string]interface{} {
+	var options mergeOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return deepMerge(base, override, options)
+}
+
+func deepMerge(base, override map[string]interface{}, options mergeOptions) map[string]interface{} {
+	if base == nil {
+		base = make(map[string]interface{}, len(override))
+	}
+	for key, value := range override {
+		switch overrideValue := value.(type) {
+		case map[string]interface{}:
+			if baseMap, ok := base[key].(map[string]interface{}); ok {
+				base[key] = deepMerge(baseMap, overrideValue, options)
+				continue
+			}
+		case []interface{}:
+			if baseArray, ok := base[key].([]interface{}); ok && options.appendArrays {
+				merged := make([]interface{}, 0, len(baseArray)+len(overrideValue))
+				base[key] = append(append(merged, baseArray...), overrideValue...)
+				continue
+			}
+		}
+		base[key] = value
+	}
+	return base
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestDeepMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     map[string]interface{}
+		override map[string]interface{}
+		opts     []MergeOption
+		want     map[string]interface{}
+	}{
+		{
+			name:     "override wins on scalars",
+			base:     map[string]interface{}{"host": "localhost", "port": 8080, "debug": false},
+			override: map[string]interface{}{"port": 9090, "debug": true},
+			want:     map[string]interface{}{"host": "localhost", "port": 9090, "debug": true},
+		},
+		{
+			name: "nested maps merge recursively",
+			base: map[string]interface{}{
+				"server": map[string]interface{}{"host": "localhost", "tls": map[string]interface{}{"enabled": false, "cert": "a.pem"}},
+				"log":    map[string]interface{}{"level": "info"},
+			},
+			override: map[string]interface{}{
+				"server": map[string]interface{}{"tls": map[string]interface{}{"enabled": true}},
+			},
+			want: map[string]interface{}{
+				"server": map[string]interface{}{"host": "localhost", "tls": map[string]interface{}{"enabled": true, "cert": "a.pem"}},
+				"log":    map[string]interface{}{"level": "info"},
+			},
+		},
+		{
+			name:     "map replaces a scalar and a scalar replaces a map",
+			base:     map[string]interface{}{"a": "flat", "b": map[string]interface{}{"c": 1}},
+			override: map[string]interface{}{"a": map[string]interface{}{"x": 1}, "b": "flat"},
+			want:     map[string]interface{}{"a": map[string]interface{}{"x": 1}, "b": "flat"},
+		},
+		{
+			name:     "arrays replace by default",
+			base:     map[string]interface{}{"hosts": []interface{}{"a", "b"}},
+			override: map[string]interface{}{"hosts": []interface{}{"c"}},
+			want:     map[string]interface{}{"hosts": []interface{}{"c"}},
+		},
+		{
+			name:     "arrays append when asked",
+			base:     map[string]interface{}{"hosts": []interface{}{"a", "b"}},
+			override: map[string]interface{}{"hosts": []interface{}{"c"}},
+			opts:     []MergeOption{WithAppendArrays()},
+			want:     map[string]interface{}{"hosts": []interface{}{"a", "b", "c"}},
+		},
+		{
+			name:     "nil base",
+			override: map[string]interface{}{"a": 1},
+			want:     map[string]interface{}{"a": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DeepMerge(tt.base, tt.override, tt.opts...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DeepMerge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_Merge(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name     string
+		base     string
+		override string
+		format   models.ConfigFormat
+		want     map[string]interface{}
+		wantErr  string
+	}{
+		{
+			name:     "YAML nested override",
+			base:     "server:\n  host: localhost\n  port: 8080\nlog: info\n",
+			override: "server:\n  port: 9090\n",
+			format:   models.FormatYAML,
+			want: map[string]interface{}{
+				"server": map[string]interface{}{"host": "localhost", "port": 9090},
+				"log":    "info",
+			},
+		},
+		{
+			name:     "TOML table override",
+			base:     "[db]\nhost = \"localhost\"\npool = 5\n",
+			override: "[db]\npool = 20\n",
+			format:   models.FormatTOML,
+			want:     map[string]interface{}{"db": map[string]interface{}{"host": "localhost", "pool": int64(20)}},
+		},
+		{
+			name:   "empty override keeps base",
+			base:   `{"a": "b"}`,
+			format: models.FormatJSON,
+			want:   map[string]interface{}{"a": "b"},
+		},
+		{
+			name:     "invalid override",
+			base:     `{"a": "b"}`,
+			override: `{"a": `,
+			format:   models.FormatJSON,
+			wantErr:  "invalid override configuration",
+		},
+		{
+			name:    "unsupported format",
+			format:  "xml",
+			wantErr: "unsupported format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := parser.Merge(tt.base, tt.override, tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Merge() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Merge() error = %v", err)
+			}
+			got, err := parser.ParseConfig(merged, tt.format)
+			if err != nil {
+				t.Fatalf("merged output does not parse: %v\n%s", err, merged)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// JSON Merge Patch (RFC 7396)
+// Applies a merge patch to configuration content in any format
+// The patch is JSON; the result is written back in the content's own format
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"conflux/internal/models"
+)
+
+// ApplyMergePatch applies a JSON merge patch document to content
+// Objects in the patch merge into the content recursively, null removes a key,
+// and any other value, arrays included, replaces the value at its key whole.
+// The patch must be a JSON object, since a configuration is always a map
+func (p *Parser) ApplyMergePatch(content string, format models.ConfigFormat, patch []byte) (string, error) {
+	var decoded interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(patch)))
+	decoder.UseNumber() // Keep integers integers in TOML and YAML
+	if err := decoder.Decode(&decoded); err != nil {
+		return "", fmt.Errorf("invalid merge patch: %w", err)
+	}
+	patchObject, ok := decoded.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("invalid merge patch: must be a JSON object")
+	}
+
+	data := map[string]interface{}{}
+	if strings.TrimSpace(content) != "" {
+		parsed, err := p.ParseConfig(content, format)
+		if err != nil {
+			return "", fmt.Errorf("invalid configuration: %w", err)
+		}
+		data = parsed
+	}
+
+	return p.SerializeConfig(MergePatch(data, patchObject), format)
+}
+
+// MergePatch merges patch into target following RFC 7396 and returns the result
+// target is updated in place where it is already an object
+func MergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = make(map[string]interface{}, len(patch))
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		patchObject, isObject := value.(map[string]interface{})
+		if !isObject {
+			target[key] = schemaValue(value)
+			continue
+		}
+		existing, _ := target[key].(map[string]interface{}) // A non-object is replaced
+		target[key] = MergePatch(existing, patchObject)
+	}
+	return target
+}
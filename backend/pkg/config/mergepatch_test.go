@@ -0,0 +1,113 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		target map[string]interface{}
+		patch  map[string]interface{}
+		want   map[string]interface{}
+	}{
+		{
+			name:   "replaces and adds keys",
+			target: map[string]interface{}{"a": "b", "c": 1},
+			patch:  map[string]interface{}{"a": "z", "d": true},
+			want:   map[string]interface{}{"a": "z", "c": 1, "d": true},
+		},
+		{
+			name:   "null deletes a key",
+			target: map[string]interface{}{"a": "b", "c": "d"},
+			patch:  map[string]interface{}{"a": nil, "missing": nil},
+			want:   map[string]interface{}{"c": "d"},
+		},
+		{
+			name: "objects merge recursively",
+			target: map[string]interface{}{
+				"server": map[string]interface{}{"host": "localhost", "port": 8080, "tls": map[string]interface{}{"enabled": false}},
+			},
+			patch: map[string]interface{}{
+				"server": map[string]interface{}{"port": 9090, "tls": map[string]interface{}{"enabled": true, "cert": "a.pem"}},
+			},
+			want: map[string]interface{}{
+				"server": map[string]interface{}{
+					"host": "localhost", "port": 9090, "tls": map[string]interface{}{"enabled": true, "cert": "a.pem"},
+				},
+			},
+		},
+		{
+			name:   "nested null deletes inside an object",
+			target: map[string]interface{}{"db": map[string]interface{}{"user": "app", "password": "x"}},
+			patch:  map[string]interface{}{"db": map[string]interface{}{"password": nil}},
+			want:   map[string]interface{}{"db": map[string]interface{}{"user": "app"}},
+		},
+		{
+			name:   "arrays are replaced whole",
+			target: map[string]interface{}{"hosts": []interface{}{"a", "b"}},
+			patch:  map[string]interface{}{"hosts": []interface{}{"c"}},
+			want:   map[string]interface{}{"hosts": []interface{}{"c"}},
+		},
+		{
+			name:   "an object replaces a scalar",
+			target: map[string]interface{}{"log": "info"},
+			patch:  map[string]interface{}{"log": map[string]interface{}{"level": "debug", "file": nil}},
+			want:   map[string]interface{}{"log": map[string]interface{}{"level": "debug"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MergePatch(tt.target, tt.patch); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergePatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_ApplyMergePatch(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name    string
+		content string
+		format  models.ConfigFormat
+		patch   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "YAML keeps integers",
+			content: "server:\n  host: localhost\n  port: 8080\ndebug: true\n",
+			format:  models.FormatYAML,
+			patch:   `{"server": {"port": 9090}, "debug": null}`,
+			want:    "server:\n    host: localhost\n    port: 9090\n",
+		},
+		{
+			name:    "TOML",
+			content: "[server]\nport = 8080\n",
+			format:  models.FormatTOML,
+			patch:   `{"server": {"port": 9090, "ratio": 0.5}}`,
+			want:    "[server]\n  port = 9090\n  ratio = 0.5\n",
+		},
+		{name: "patch must be an object", content: "a: 1\n", format: models.FormatYAML, patch: `["a"]`, wantErr: true},
+		{name: "patch must be JSON", content: "a: 1\n", format: models.FormatYAML, patch: `a: 2`, wantErr: true},
+		{name: "content must parse", content: "a: [1\n", format: models.FormatYAML, patch: `{}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parser.ApplyMergePatch(tt.content, tt.format, []byte(tt.patch))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyMergePatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ApplyMergePatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Positioned parse errors
+// Turns format library errors into a ParseError with a line and column, so
+// editors can point at the offending line
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ParseError is a syntax error in configuration content
+// Line and Column are 1-based and zero when the format doesn't report them:
+// YAML gives only a line, TOML and JSON give both. Error returns the library's
+// message unchanged; Message is the same text without its position prefix
+type ParseError struct {
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+
+	err error
+}
+
+func (e *ParseError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the format library's error
+func (e *ParseError) Unwrap() error {
+	return e.err
+}
+
+// linePrefix matches the "line N: " position YAML, INI, and ENV errors start with
+var linePrefix = regexp.MustCompile(`^(?:yaml: )?line (\d+): `)
+
+// newParseError wraps a parse error from a format library with its position in content
+func newParseError(content string, err error) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return err
+	}
+	parseErr = &ParseError{Message: err.Error(), err: err}
+
+	var tomlErr toml.ParseError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var yamlErr *yaml.TypeError
+	switch {
+	case errors.As(err, &tomlErr):
+		parseErr.Line, parseErr.Column = offsetPosition(content, tomlErr.Position.Start)
+		parseErr.Message = tomlErr.Message
+	case errors.As(err, &syntaxErr):
+		// encoding/json offsets point just past the byte at fault
+		parseErr.Line, parseErr.Column = offsetPosition(content, int(syntaxErr.Offset)-1)
+	case errors.As(err, &typeErr):
+		parseErr.Line, parseErr.Column = offsetPosition(content, int(typeErr.Offset)-1)
+	case errors.As(err, &yamlErr) && len(yamlErr.Errors) > 0:
+		// Only the first of several unmarshal errors is located
+		parseErr.Line, parseErr.Message = splitLinePrefix(yamlErr.Errors[0])
+	default:
+		parseErr.Line, parseErr.Message = splitLinePrefix(parseErr.Message)
+	}
+	return parseErr
+}
+
+// splitLinePrefix separates a leading "line N: " from a message
+func splitLinePrefix(message string) (int, string) {
+	match := linePrefix.FindStringSubmatch(message)
+	if match == nil {
+		return 0, message
+	}
+	line, _ := strconv.Atoi(match[1])
+	return line, message[len(match[0]):]
+}
+
+// offsetPosition converts a byte offset in content to a 1-based line and column
+func offsetPosition(content string, offset int) (int, int) {
+	offset = min(max(offset, 0), len(content))
+	before := content[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := offset - strings.LastIndex(before, "\n")
+	return line, column
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestParser_ParseConfigErrorPosition(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name        string
+		content     string
+		format      models.ConfigFormat
+		wantLine    int
+		wantColumn  int
+		wantMessage string
+	}{
+		{
+			name:        "YAML mapping indented under a scalar",
+			content:     "name: app\nserver: local\n  port: 8080\n",
+			format:      models.FormatYAML,
+			wantLine:    3,
+			wantMessage: "mapping values are not allowed in this context",
+		},
+		{
+			name:        "YAML duplicate key",
+			content:     "name: app\nport: 1\nport: 2\n",
+			format:      models.FormatYAML,
+			wantLine:    3,
+			wantMessage: `mapping key "port" already defined at line 2`,
+		},
+		{
+			name:        "TOML missing value",
+			content:     "name = \"app\"\nport = \n",
+			format:      models.FormatTOML,
+			wantLine:    2,
+			wantColumn:  8,
+			wantMessage: "expected value but found '\\n' instead",
+		},
+		{
+			name:       "JSON missing value",
+			content:    "{\n  \"name\": \"app\",\n  \"port\": }\n",
+			format:     models.FormatJSON,
+			wantLine:   3,
+			wantColumn: 11,
+		},
+		{
+			name:        "INI",
+			content:     "[server]\nport = 80\n[broken\n",
+			format:      models.FormatINI,
+			wantLine:    3,
+			wantMessage: "unterminated section header: [broken",
+		},
+		{
+			name:        "ENV",
+			content:     "# app\nNAME=app\nPORT\n",
+			format:      models.FormatENV,
+			wantLine:    3,
+			wantMessage: "invalid env line: PORT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parser.ParseConfig(tt.content, tt.format)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("ParseConfig() error = %v (%T), want *ParseError", err, err)
+			}
+			if parseErr.Line != tt.wantLine || parseErr.Column != tt.wantColumn {
+				t.Errorf("position = %d:%d, want %d:%d (%v)", parseErr.Line, parseErr.Column, tt.wantLine, tt.wantColumn, err)
+			}
+			if tt.wantMessage != "" && parseErr.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", parseErr.Message, tt.wantMessage)
+			}
+			if strings.HasPrefix(parseErr.Message, "line ") {
+				t.Errorf("message %q still carries its position", parseErr.Message)
+			}
+		})
+	}
+}
+
+func TestParser_ParseErrorKeepsLibraryMessage(t *testing.T) {
+	_, err := NewParser().ParseConfig("a: 1\n  b: 2\n", models.FormatYAML)
+	if err == nil || err.Error() != "yaml: line 2: mapping values are not allowed in this context" {
+		t.Errorf("Error() = %v, want the yaml library's message", err)
+	}
+}
@@ -1,11 +1,14 @@
 // Configuration parser and format detection utilities
 // Automatically detects config format and provides parsing/validation capabilities
-// Supports YAML, JSON, TOML, and ENV formats with validation
+// Supports YAML, JSON, TOML, INI, and ENV formats with validation
 package config
 
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
 	"conflux/internal/models"
@@ -22,55 +25,50 @@ func NewParser() *Parser {
 	return &Parser{}
 }
 
-// DetectFormat attempts to automatically detect the configuration format
+// DetectFormat returns the format the content most idiomatically reads as
+// Every format is scored by DetectFormatCandidates rather than taking the first
+// that parses, since YAML accepts most ENV and TOML lines as a plain scalar
 func (p *Parser) DetectFormat(content string) (models.ConfigFormat, error) {
-	content = strings.TrimSpace(content)
-
-	if content == "" {
-		return "", fmt.Errorf("empty content")
-	}
-
-	// Try JSON first (most strict)
-	if p.isValidJSON(content) {
-		return models.FormatJSON, nil
-	}
-
-	// Try YAML
-	if p.isValidYAML(content) {
-		return models.FormatYAML, nil
-	}
-
-	// Try TOML
-	if p.isValidTOML(content) {
-		return models.FormatTOML, nil
+	candidates, err := p.DetectFormatCandidates(content)
+	if err != nil {
+		return "", err
 	}
-
-	// Check if it looks like ENV format
-	if p.looksLikeEnv(content) {
-		return models.FormatENV, nil
+	if candidates[0].Confidence < minDetectConfidence {
+		return "", fmt.Errorf("unable to detect configuration format")
 	}
-
-	return "", fmt.Errorf("unable to detect configuration format")
+	return candidates[0].Format, nil
 }
 
 // ParseConfig parses configuration content based on the specified format
-func (p *Parser) ParseConfig(content string, format models.ConfigFormat) (map[string]interface{}, error) {
-	switch format {
-	case models.FormatJSON:
-		return p.parseJSON(content)
-	case models.FormatYAML:
-		return p.parseYAML(content)
-	case models.FormatTOML:
-		return p.parseTOML(content)
-	case models.FormatENV:
-		return p.parseEnv(content)
-	default:
+// Syntax errors are returned as a *ParseError locating the problem where the
+// format library reports it. A panic inside the format library is returned as an error
+func (p *Parser) ParseConfig(
+	content string, format models.ConfigFormat, opts ...ParseOption,
+) (data map[string]interface{}, err error) {
+	codec, ok := LookupCodec(format)
+	if !ok {
 		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
+
+	defer recoverParserPanic("parse", format, &err)
+	options := newParseOptions(opts)
+	if format == models.FormatENV && options.expandEnv {
+		data, err = parseEnvExpanded(content, options.envLookup)
+	} else {
+		data, err = codec.parse(p, content)
+	}
+	if err != nil {
+		return nil, newParseError(content, err)
+	}
+	return data, nil
 }
 
 // ConvertFormat converts configuration from one format to another
-func (p *Parser) ConvertFormat(content string, fromFormat, toFormat models.ConfigFormat) (string, error) {
+// YAML anchors/aliases in the source are always expanded; pass WithYAMLAnchors
+// to re-anchor repeated subtrees when the target is YAML
+func (p *Parser) ConvertFormat(
+	content string, fromFormat, toFormat models.ConfigFormat, opts ...SerializeOption,
+) (string, error) {
 	// Parse the source format
 	data, err := p.ParseConfig(content, fromFormat)
 	if err != nil {
@@ -78,59 +76,98 @@ func (p *Parser) ConvertFormat(content string, fromFormat, toFormat models.Confi
 	}
 
 	// Convert to target format
-	return p.SerializeConfig(data, toFormat)
+	return p.SerializeConfig(data, toFormat, opts...)
 }
 
 // SerializeConfig serializes configuration data to the specified format
-func (p *Parser) SerializeConfig(data map[string]interface{}, format models.ConfigFormat) (string, error) {
-	switch format {
-	case models.FormatJSON:
-		return p.serializeJSON(data)
-	case models.FormatYAML:
-		return p.serializeYAML(data)
-	case models.FormatTOML:
-		return p.serializeTOML(data)
-	case models.FormatENV:
-		return p.serializeEnv(data)
-	default:
+// A panic inside the format library is returned as an error
+func (p *Parser) SerializeConfig(
+	data map[string]interface{}, format models.ConfigFormat, opts ...SerializeOption,
+) (content string, err error) {
+	codec, ok := LookupCodec(format)
+	if !ok {
 		return "", fmt.Errorf("unsupported format: %s", format)
 	}
+
+	defer recoverParserPanic("serialize", format, &err)
+
+	options := newSerializeOptions(opts)
+	data = prepareDatetimes(data, format, options)
+	if format == models.FormatYAML && options.yamlAnchors {
+		return p.serializeYAMLWithAnchors(data)
+	}
+
+	return codec.serialize(p, data)
 }
 
 // ValidateConfig validates configuration against a JSON schema if provided
-func (p *Parser) ValidateConfig(content string, format models.ConfigFormat, schema *string) error {
+// Keys the schema forbids with "additionalProperties": false, or that are missing
+// from a WithAllowedKeys allowlist, are returned together as an *UnknownKeysError
+func (p *Parser) ValidateConfig(content string, format models.ConfigFormat, schema *string, opts ...ValidateOption) error {
 	// Parse the configuration
 	data, err := p.ParseConfig(content, format)
 	if err != nil {
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	// If no schema provided, just check if it's parseable
-	if schema == nil {
-		return nil
+	options := newValidateOptions(opts)
+	var unknown []string
+	if options.allowedKeys != nil {
+		unknown = UnknownKeys(data, options.allowedKeys)
 	}
 
-	// TODO: Implement JSON schema validation
+	// TODO: Implement the rest of JSON schema validation
 	// This would use a library like github.com/xeipuuv/gojsonschema
-	_ = data
+	if schema != nil && strings.TrimSpace(*schema) != "" {
+		schemaUnknown, err := UnknownSchemaKeys(data, *schema)
+		if err != nil {
+			return err
+		}
+		unknown = append(unknown, schemaUnknown...)
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return &UnknownKeysError{Keys: slices.Compact(unknown)}
+	}
 	return nil
 }
 
 // Private helper methods
 
+// recoverParserPanic turns a panic in a format library into an error naming the format
+// Parsing runs in background import workers, outside the HTTP recovery middleware,
+// so adversarial input must not be able to crash the process
+func recoverParserPanic(operation string, format models.ConfigFormat, err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("%s %s failed: parser panicked: %v", format, operation, r)
+	}
+}
+
+// unmarshalsCleanly reports whether unmarshal succeeds without error or panic
+// Detection treats a panicking library as "not this format"
+func unmarshalsCleanly(unmarshal func([]byte, interface{}) error, content string, v interface{}) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return unmarshal([]byte(content), v) == nil
+}
+
 func (p *Parser) isValidJSON(content string) bool {
 	var js interface{}
-	return json.Unmarshal([]byte(content), &js) == nil
+	return unmarshalsCleanly(json.Unmarshal, content, &js)
 }
 
 func (p *Parser) isValidYAML(content string) bool {
 	var yml interface{}
-	return yaml.Unmarshal([]byte(content), &yml) == nil
+	return unmarshalsCleanly(yaml.Unmarshal, content, &yml)
 }
 
 func (p *Parser) isValidTOML(content string) bool {
 	var tml interface{}
-	return toml.Unmarshal([]byte(content), &tml) == nil
+	return unmarshalsCleanly(toml.Unmarshal, content, &tml)
 }
 
 func (p *Parser) looksLikeEnv(content string) bool {
@@ -153,6 +190,29 @@ func (p *Parser) looksLikeEnv(content string) bool {
 	return validLines > 0
 }
 
+// looksLikeINI reports whether every line is an INI section header, comment, or
+// key=value pair, with at least one header or `;` comment to tell it from ENV
+func (p *Parser) looksLikeINI(content string) bool {
+	signals, pairs := 0, 0
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, ";"):
+			signals++
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			signals++
+		case strings.Contains(line, "="):
+			pairs++
+		default:
+			return false
+		}
+	}
+
+	return signals > 0 && pairs > 0
+}
+
 func (p *Parser) parseJSON(content string) (map[string]interface{}, error) {
 	var data map[string]interface{}
 	err := json.Unmarshal([]byte(content), &data)
@@ -167,15 +227,38 @@ func (p *Parser) parseYAML(content string) (map[string]interface{}, error) {
 
 func (p *Parser) parseTOML(content string) (map[string]interface{}, error) {
 	var data map[string]interface{}
-	err := toml.Unmarshal([]byte(content), &data)
-	return data, err
+	if err := toml.Unmarshal([]byte(content), &data); err != nil {
+		return nil, err
+	}
+	normalizeTOMLTables(data)
+	return data, nil
 }
 
 func (p *Parser) parseEnv(content string) (map[string]interface{}, error) {
-	data := make(map[string]interface{})
-	lines := strings.Split(content, "\n")
+	entries, err := parseEnvEntries(content)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, line := range lines {
+	data := make(map[string]interface{}, len(entries))
+	for _, entry := range entries {
+		data[entry.key] = entry.value
+	}
+	return data, nil
+}
+
+// envEntry is one KEY=value assignment in ENV content
+type envEntry struct {
+	key     string
+	value   string // Unquoted value
+	literal bool   // Single-quoted, so never expanded
+	line    int
+}
+
+// parseEnvEntries returns the assignments in ENV content in file order
+func parseEnvEntries(content string) ([]envEntry, error) {
+	var entries []envEntry
+	for i, line := range strings.Split(content, "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
@@ -183,24 +266,138 @@ func (p *Parser) parseEnv(content string) (map[string]interface{}, error) {
 
 		parts := strings.SplitN(line, "=", 2)
 		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid env line: %s", line)
+			return nil, fmt.Errorf("line %d: invalid env line: %s", i+1, line)
 		}
 
+		// Shell-sourced files write `export KEY=value`
 		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
+		if fields := strings.Fields(key); len(fields) == 2 && fields[0] == "export" {
+			key = fields[1]
+		}
+
+		entry := envEntry{key: key, value: strings.TrimSpace(stripEnvComment(parts[1])), line: i + 1}
+
+		// Remove quotes if present; double-quoted values may use Go escapes,
+		// which is how serializeEnv writes them
+		value := entry.value
+		if len(value) >= 2 && strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				entry.value = unquoted
+			} else {
+				entry.value = value[1 : len(value)-1]
+			}
+		} else if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+			entry.value = value[1 : len(value)-1]
+			entry.literal = true
+		}
+
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
 
-		// Remove quotes if present
-		if (strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"")) ||
-			(strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'")) {
-			value = value[1 : len(value)-1]
+// stripEnvComment removes a trailing `# comment` from an ENV value
+// Unquoted values start a comment at a # preceded by whitespace, so URL
+// fragments and colors like #fff survive; quoted values end at their closing quote
+func stripEnvComment(value string) string {
+	trimmed := strings.TrimLeft(value, " \t")
+	if trimmed != "" && (trimmed[0] == '"' || trimmed[0] == '\'') {
+		quote := trimmed[0]
+		for i := 1; i < len(trimmed); i++ {
+			if quote == '"' && trimmed[i] == '\\' {
+				i++
+				continue
+			}
+			if trimmed[i] == quote {
+				if rest := strings.TrimSpace(trimmed[i+1:]); rest == "" || strings.HasPrefix(rest, "#") {
+					return trimmed[:i+1]
+				}
+				break
+			}
 		}
+		return value
+	}
 
-		data[key] = value
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return value[:i]
+		}
+	}
+	return value
+}
+
+// parseINI reads [section] headers into nested maps and keys before the first
+// header into the top level. Values are strings; a repeated section is merged
+// into the first and a repeated key keeps its last value
+func (p *Parser) parseINI(content string) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	current := data
+
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section header: %s", i+1, line)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if name == "" {
+				return nil, fmt.Errorf("line %d: empty section name", i+1)
+			}
+			switch existing := data[name].(type) {
+			case nil:
+				current = make(map[string]interface{})
+				data[name] = current
+			case map[string]interface{}:
+				current = existing
+			default:
+				return nil, fmt.Errorf("line %d: section %q clashes with a global key", i+1, name)
+			}
+			continue
+		}
+
+		// A key without a value, as in my.cnf's skip-networking, is present but empty
+		key, value, _ := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing key: %s", i+1, line)
+		}
+		current[key] = parseINIValue(strings.TrimSpace(value))
 	}
 
 	return data, nil
 }
 
+// isINISection reports whether a parsed value is a section
+func isINISection(value interface{}) bool {
+	_, ok := value.(map[string]interface{})
+	return ok
+}
+
+// parseINIValue unquotes a value, or strips an inline `;` or `#` comment from an unquoted one
+func parseINIValue(value string) string {
+	if len(value) >= 2 && strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+		return value[1 : len(value)-1]
+	}
+	if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+		return value[1 : len(value)-1]
+	}
+
+	// Inline comments need whitespace before them so values like "a;b" survive
+	for i := 1; i < len(value); i++ {
+		if (value[i] == ';' || value[i] == '#') && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return strings.TrimSpace(value[:i])
+		}
+	}
+	return value
+}
+
 func (p *Parser) serializeJSON(data map[string]interface{}) (string, error) {
 	bytes, err := json.MarshalIndent(data, "", "  ")
 	return string(bytes), err
@@ -217,10 +414,17 @@ func (p *Parser) serializeTOML(data map[string]interface{}) (string, error) {
 	return buf.String(), err
 }
 
+// serializeEnv writes one KEY=value line per key, sorted so output is stable across saves
 func (p *Parser) serializeEnv(data map[string]interface{}) (string, error) {
-	lines := make([]string, 0, len(data))
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-	for key, value := range data {
+	lines := make([]string, 0, len(data))
+	for _, key := range keys {
+		value := data[key]
 		// Convert value to string
 		var valueStr string
 		switch v := value.(type) {
@@ -241,7 +445,7 @@ func (p *Parser) serializeEnv(data map[string]interface{}) (string, error) {
 
 		// Quote values that contain spaces or special characters
 		if strings.ContainsAny(valueStr, " \t\n\"'\\") {
-			valueStr = fmt.Sprintf("%q", strings.ReplaceAll(valueStr, "\"", "\\\""))
+			valueStr = strconv.Quote(valueStr)
 		}
 
 		lines = append(lines, fmt.Sprintf("%s=%s", key, valueStr))
@@ -249,3 +453,80 @@ func (p *Parser) serializeEnv(data map[string]interface{}) (string, error) {
 
 	return strings.Join(lines, "\n"), nil
 }
+
+// serializeINI writes top-level scalars as global keys, then one section per
+// top-level map, all sorted by name so the output is stable across saves
+// Values nested deeper than a section, and arrays, are written as JSON
+func (p *Parser) serializeINI(data map[string]interface{}) (string, error) {
+	var globals, sections []string
+	for key, value := range data {
+		if isINISection(value) {
+			sections = append(sections, key)
+		} else {
+			globals = append(globals, key)
+		}
+	}
+	sort.Strings(globals)
+	sort.Strings(sections)
+
+	var buf strings.Builder
+	if err := writeINIPairs(&buf, data, globals); err != nil {
+		return "", err
+	}
+
+	for _, name := range sections {
+		if name == "" || strings.ContainsAny(name, "[]\r\n") {
+			return "", fmt.Errorf("section name %q can't be written as INI", name)
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "[%s]\n", name)
+
+		section := data[name].(map[string]interface{})
+		keys := make([]string, 0, len(section))
+		for key := range section {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if err := writeINIPairs(&buf, section, keys); err != nil {
+			return "", err
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// writeINIPairs writes key=value lines for keys in the given order
+func writeINIPairs(buf *strings.Builder, data map[string]interface{}, keys []string) error {
+	for _, key := range keys {
+		if key == "" || strings.ContainsAny(key, "=[;#\r\n") || strings.TrimSpace(key) != key {
+			return fmt.Errorf("key %q can't be written as INI", key)
+		}
+
+		var valueStr string
+		switch v := data[key].(type) {
+		case nil:
+		case string:
+			valueStr = v
+		case bool:
+			valueStr = fmt.Sprintf("%t", v)
+		case int, int64, float64:
+			valueStr = fmt.Sprintf("%v", v)
+		default:
+			bytes, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			valueStr = string(bytes)
+		}
+
+		// Quote values that wouldn't read back unchanged
+		if strings.ContainsAny(valueStr, ";#\"'\\\r\n") || strings.TrimSpace(valueStr) != valueStr {
+			valueStr = strconv.Quote(valueStr)
+		}
+
+		fmt.Fprintf(buf, "%s = %s\n", key, valueStr)
+	}
+	return nil
+}
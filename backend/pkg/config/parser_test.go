@@ -2,6 +2,8 @@ package config
 
 import (
 	"conflux/internal/models"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -33,22 +35,28 @@ func TestParser_DetectFormat(t *testing.T) {
 			expected: models.FormatYAML,
 			wantErr:  false,
 		},
-		// Note: These tests are commented out because YAML parser is very permissive
-		// and can parse simple key=value formats that we expect to be ENV or TOML
-		/*
-			{
-				name:     "valid TOML",
-				content:  "[section]\nkey = \"value\"",
-				expected: models.FormatTOML,
-				wantErr:  false,
-			},
-			{
-				name:     "simple ENV",
-				content:  "KEY=value",
-				expected: models.FormatENV,
-				wantErr:  false,
-			},
-		*/
+		{
+			name:     "valid TOML",
+			content:  "[section]\nkey = \"value\"",
+			expected: models.FormatTOML,
+			wantErr:  false,
+		},
+		{
+			name:     "simple ENV",
+			content:  "KEY=value",
+			expected: models.FormatENV,
+			wantErr:  false,
+		},
+		{
+			name:     "ENV that is also valid TOML",
+			content:  "PORT=8080\nDEBUG=true",
+			expected: models.FormatENV,
+		},
+		{
+			name:     "TOML that is also valid ENV",
+			content:  "name = \"app\"\nport = 8080",
+			expected: models.FormatTOML,
+		},
 		{
 			name:    "empty content",
 			content: "",
@@ -59,14 +67,11 @@ func TestParser_DetectFormat(t *testing.T) {
 			content: "   \n\t  ",
 			wantErr: true,
 		},
-		// Note: These tests are commented out because YAML parser accepts many formats
-		/*
-			{
-				name:    "random text",
-				content: "this is just some random text",
-				wantErr: true,
-			},
-		*/
+		{
+			name:    "random text",
+			content: "this is just some random text",
+			wantErr: true,
+		},
 		{
 			name:     "JSON array",
 			content:  `[{"key": "value"}]`,
@@ -79,15 +84,22 @@ func TestParser_DetectFormat(t *testing.T) {
 			expected: models.FormatYAML,
 			wantErr:  false,
 		},
-		// Note: Commented out because YAML parser is permissive with ENV format
-		/*
-			{
-				name:     "ENV with comments",
-				content:  "# Comment\nKEY=value",
-				expected: models.FormatENV,
-				wantErr:  false,
-			},
-		*/
+		{
+			name:     "INI with semicolon comments",
+			content:  "; php.ini excerpt\n[PHP]\nmemory_limit = 128M\nerror_reporting = E_ALL & ~E_DEPRECATED",
+			expected: models.FormatINI,
+		},
+		{
+			name:     "INI section header with unquoted value",
+			content:  "[remote \"origin\"]\nurl = https://example.com/repo.git",
+			expected: models.FormatINI,
+		},
+		{
+			name:     "ENV with comments",
+			content:  "# Comment\nKEY=value",
+			expected: models.FormatENV,
+			wantErr:  false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -453,6 +465,14 @@ func TestParser_ENVFormatSpecialCases(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:    "escaped quotes",
+			content: `KEY="say \"hi\""`,
+			expected: map[string]interface{}{
+				"KEY": `say "hi"`,
+			},
+			wantErr: false,
+		},
 		{
 			name:    "values with equals signs",
 			content: "URL=http://example.com?param=value",
@@ -461,6 +481,36 @@ func TestParser_ENVFormatSpecialCases(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:    "export prefix",
+			content: "export KEY=value\nexport\tOTHER=\"quoted value\"\nexporter=x",
+			expected: map[string]interface{}{
+				"KEY":      "value",
+				"OTHER":    "quoted value",
+				"exporter": "x",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "inline comments",
+			content: "KEY=value # trailing comment\nEMPTY= # nothing here\nQUOTED=\"a # b\" # comment\nSINGLE='c # d'  # comment",
+			expected: map[string]interface{}{
+				"KEY":    "value",
+				"EMPTY":  "",
+				"QUOTED": "a # b",
+				"SINGLE": "c # d",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "hash without preceding whitespace",
+			content: "COLOR=#fff\nURL=http://example.com/page#section?param=value",
+			expected: map[string]interface{}{
+				"COLOR": "#fff",
+				"URL":   "http://example.com/page#section?param=value",
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -488,6 +538,115 @@ func TestParser_ENVFormatSpecialCases(t *testing.T) {
 	}
 }
 
+func TestParser_INIFormat(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name     string
+		content  string
+		expected map[string]interface{}
+		wantErr  bool
+	}{
+		{
+			name:    "sections and global keys",
+			content: "; global settings\nname = app\n\n[server]\nhost = localhost\nport = 8080\n",
+			expected: map[string]interface{}{
+				"name":   "app",
+				"server": map[string]interface{}{"host": "localhost", "port": "8080"},
+			},
+		},
+		{
+			name:    "duplicate sections merge and the last key wins",
+			content: "[db]\nhost = a\nuser = root\n[cache]\nttl = 5\n[db]\nhost = b\n",
+			expected: map[string]interface{}{
+				"db":    map[string]interface{}{"host": "b", "user": "root"},
+				"cache": map[string]interface{}{"ttl": "5"},
+			},
+		},
+		{
+			name:    "inline comments and quoting",
+			content: "[s]\na = plain ; comment\nb = \"kept ; here\"\nc = x;y\nd = 'single'\ne\n",
+			expected: map[string]interface{}{
+				"s": map[string]interface{}{"a": "plain", "b": "kept ; here", "c": "x;y", "d": "single", "e": ""},
+			},
+		},
+		{
+			name:    "section clashing with a global key",
+			content: "db = x\n[db]\nhost = a\n",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated section header",
+			content: "[db\nhost = a\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parser.ParseConfig(tt.content, models.FormatINI)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParser_SerializeINI(t *testing.T) {
+	parser := NewParser()
+
+	data := map[string]interface{}{
+		"zeta":  "last",
+		"alpha": 1,
+		"server": map[string]interface{}{
+			"port":  8080,
+			"host":  "localhost",
+			"tls":   true,
+			"motd":  "hi; there",
+			"peers": []interface{}{"a", "b"},
+		},
+		"cache": map[string]interface{}{"ttl": "5m"},
+	}
+
+	want := "alpha = 1\nzeta = last\n\n" +
+		"[cache]\nttl = 5m\n\n" +
+		"[server]\nhost = localhost\nmotd = \"hi; there\"\npeers = \"[\\\"a\\\",\\\"b\\\"]\"\nport = 8080\ntls = true\n"
+
+	// Map order must not leak into the output
+	for i := 0; i < 10; i++ {
+		got, err := parser.SerializeConfig(data, models.FormatINI)
+		if err != nil {
+			t.Fatalf("SerializeConfig() error = %v", err)
+		}
+		if got != want {
+			t.Fatalf("SerializeConfig() =\n%s\nwant\n%s", got, want)
+		}
+	}
+
+	got, _ := parser.SerializeConfig(data, models.FormatINI)
+	parsed, err := parser.ParseConfig(got, models.FormatINI)
+	if err != nil {
+		t.Fatalf("could not parse serialized INI: %v", err)
+	}
+	server := parsed["server"].(map[string]interface{})
+	if server["motd"] != "hi; there" || server["peers"] != `["a","b"]` {
+		t.Errorf("round trip = %v", parsed)
+	}
+
+	if _, err := parser.SerializeConfig(map[string]interface{}{"bad]name": map[string]interface{}{}}, models.FormatINI); err == nil {
+		t.Error("expected error for a section name INI can't hold")
+	}
+}
+
 func TestParser_SerializeENVSpecialCases(t *testing.T) {
 	parser := NewParser()
 
@@ -547,6 +706,40 @@ func TestParser_SerializeENVSpecialCases(t *testing.T) {
 	}
 }
 
+func TestParser_SerializeConfigIsDeterministic(t *testing.T) {
+	parser := NewParser()
+
+	data := map[string]interface{}{}
+	for _, key := range []string{"zulu", "alpha", "mike", "echo", "kilo", "bravo", "yankee", "delta"} {
+		data[key] = key + "-value"
+	}
+	data["nested"] = map[string]interface{}{"z": 1, "a": 2, "m": 3}
+
+	for _, codec := range codecs {
+		t.Run(string(codec.Format), func(t *testing.T) {
+			first, err := parser.SerializeConfig(data, codec.Format)
+			if err != nil {
+				t.Fatalf("SerializeConfig() error = %v", err)
+			}
+			// Map iteration order is randomized per range, so repeats would catch unsorted output
+			for i := 0; i < 20; i++ {
+				again, err := parser.SerializeConfig(data, codec.Format)
+				if err != nil {
+					t.Fatalf("SerializeConfig() error = %v", err)
+				}
+				if again != first {
+					t.Fatalf("serialization %d differs:\n%s\nfirst:\n%s", i+2, again, first)
+				}
+			}
+		})
+	}
+
+	env, _ := parser.SerializeConfig(data, models.FormatENV)
+	if !strings.HasPrefix(env, "alpha=alpha-value\nbravo=bravo-value\n") {
+		t.Errorf("ENV keys not sorted:\n%s", env)
+	}
+}
+
 func TestParser_RoundTripConversion(t *testing.T) {
 	parser := NewParser()
 
@@ -649,3 +842,64 @@ func BenchmarkParser_ConvertFormat(b *testing.B) {
 		}
 	}
 }
+
+func TestParser_PathologicalInputs(t *testing.T) {
+	parser := NewParser()
+
+	// Inputs that have crashed or stalled YAML/TOML libraries; each must yield
+	// a result or an error, never a panic
+	inputs := map[models.ConfigFormat][]string{
+		models.FormatYAML: {
+			"0: [:!00 \xef",
+			"a: &a [*a]",
+			"<<: [1, 2]",
+			"a: !!binary |\n  ====\n",
+			strings.Repeat("[", 10000),
+			"\t\v\x00",
+		},
+		models.FormatTOML: {
+			"a = 1\na.b = 2",
+			strings.Repeat("a = [", 10000),
+			"[[a]]\n[a]",
+			"x = 1979-05-27T07:32:00.999999999999Z",
+			"\x00=\x00",
+		},
+		models.FormatJSON: {
+			strings.Repeat("[", 100000),
+			`{"a":` + strings.Repeat(`{"a":`, 20000),
+		},
+	}
+
+	for format, contents := range inputs {
+		for _, content := range contents {
+			_, _ = parser.ParseConfig(content, format)
+			_, _ = parser.DetectFormat(content)
+			_, _ = parser.DetectFormatCandidates(content)
+		}
+	}
+}
+
+func TestParser_RecoversFromLibraryPanics(t *testing.T) {
+	parser := NewParser()
+	codec, _ := LookupCodec(models.FormatTOML)
+
+	originalParse, originalSerialize := codec.parse, codec.serialize
+	codec.parse = func(*Parser, string) (map[string]interface{}, error) { panic("index out of range") }
+	codec.serialize = func(*Parser, map[string]interface{}) (string, error) { panic("nil map") }
+	defer func() { codec.parse, codec.serialize = originalParse, originalSerialize }()
+
+	_, err := parser.ParseConfig("a = 1", models.FormatTOML)
+	if err == nil || !strings.Contains(err.Error(), "toml parse failed: parser panicked: index out of range") {
+		t.Errorf("ParseConfig() error = %v, want recovered toml panic", err)
+	}
+
+	_, err = parser.SerializeConfig(map[string]interface{}{"a": 1}, models.FormatTOML)
+	if err == nil || !strings.Contains(err.Error(), "toml serialize failed: parser panicked: nil map") {
+		t.Errorf("SerializeConfig() error = %v, want recovered toml panic", err)
+	}
+
+	_, err = parser.ConvertFormat("a: 1", models.FormatYAML, models.FormatTOML)
+	if err == nil || !strings.Contains(err.Error(), "toml serialize failed") {
+		t.Errorf("ConvertFormat() error = %v, want recovered toml panic", err)
+	}
+}
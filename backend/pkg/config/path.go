@@ -0,0 +1,115 @@
+// Key path lookup in parsed configuration data
+// Paths use dots for nested keys and [n] for array elements, e.g. servers[0].host
+// The same notation is used by secret findings and template variables
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LookupPath returns the value at path and whether it exists
+// A leading "$." (JSONPath root) is accepted and ignored
+func LookupPath(data map[string]interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(path, "$.")
+	if path == "" {
+		return nil, false
+	}
+
+	var current interface{} = data
+	for _, segment := range strings.Split(path, ".") {
+		key, indexes := splitIndexes(segment)
+		if key != "" {
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if current, ok = object[key]; !ok {
+				return nil, false
+			}
+		}
+		for _, index := range indexes {
+			array, ok := current.([]interface{})
+			if !ok || index < 0 || index >= len(array) {
+				return nil, false
+			}
+			current = array[index]
+		}
+	}
+	return current, true
+}
+
+// SetPath sets the value at path and reports whether it could be set
+// Missing objects along the way are created; array elements must already exist
+func SetPath(data map[string]interface{}, path string, value interface{}) bool {
+	path = strings.TrimPrefix(path, "$.")
+	if path == "" {
+		return false
+	}
+
+	segments := strings.Split(path, ".")
+	var current interface{} = data
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		key, indexes := splitIndexes(segment)
+		if key == "" && len(indexes) == 0 {
+			return false
+		}
+		if key != "" {
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return false
+			}
+			if last && len(indexes) == 0 {
+				object[key] = value
+				return true
+			}
+			next, ok := object[key]
+			if !ok {
+				if len(indexes) > 0 {
+					return false
+				}
+				next = make(map[string]interface{})
+				object[key] = next
+			}
+			current = next
+		}
+		for j, index := range indexes {
+			array, ok := current.([]interface{})
+			if !ok || index < 0 || index >= len(array) {
+				return false
+			}
+			if last && j == len(indexes)-1 {
+				array[index] = value
+				return true
+			}
+			current = array[index]
+		}
+	}
+	return false
+}
+
+// splitIndexes splits "servers[0][1]" into "servers" and [0 1]
+// Malformed indexes yield -1 so the lookup fails
+func splitIndexes(segment string) (string, []int) {
+	open := strings.IndexByte(segment, '[')
+	if open < 0 {
+		return segment, nil
+	}
+
+	key, rest := segment[:open], segment[open:]
+	var indexes []int
+	for rest != "" {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 {
+			return key, []int{-1}
+		}
+		index, err := strconv.Atoi(rest[1:end])
+		if err != nil {
+			index = -1
+		}
+		indexes = append(indexes, index)
+		rest = rest[end+1:]
+	}
+	return key, indexes
+}
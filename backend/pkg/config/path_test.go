@@ -0,0 +1,87 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookupPath(t *testing.T) {
+	data := map[string]interface{}{
+		"delay": 30,
+		"server": map[string]interface{}{
+			"host": "localhost",
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"token": "abc"},
+			[]interface{}{"nested"},
+		},
+	}
+
+	tests := []struct {
+		path   string
+		want   interface{}
+		wantOK bool
+	}{
+		{path: "delay", want: 30, wantOK: true},
+		{path: "$.delay", want: 30, wantOK: true},
+		{path: "server.host", want: "localhost", wantOK: true},
+		{path: "servers[0].token", want: "abc", wantOK: true},
+		{path: "servers[1][0]", want: "nested", wantOK: true},
+		{path: "server", want: map[string]interface{}{"host": "localhost"}, wantOK: true},
+		{path: "missing"},
+		{path: "server.port"},
+		{path: "delay.value"},
+		{path: "servers[2]"},
+		{path: "servers[x]"},
+		{path: "servers[0"},
+		{path: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := LookupPath(data, tt.path)
+			if ok != tt.wantOK {
+				t.Fatalf("LookupPath(%q) ok = %v, want %v", tt.path, ok, tt.wantOK)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LookupPath(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetPath(t *testing.T) {
+	tests := []struct {
+		path   string
+		want   map[string]interface{}
+		wantOK bool
+	}{
+		{path: "delay", want: map[string]interface{}{"delay": 60}, wantOK: true},
+		{path: "$.server.port", want: map[string]interface{}{"server": map[string]interface{}{"host": "localhost", "port": 60}}, wantOK: true},
+		{path: "limits.max", want: map[string]interface{}{"limits": map[string]interface{}{"max": 60}}, wantOK: true},
+		{path: "servers[0]", want: map[string]interface{}{"servers": []interface{}{60}}, wantOK: true},
+		{path: "servers[1]"},
+		{path: "missing[0]"},
+		{path: "delay.value"},
+		{path: "a..b"},
+		{path: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			data := map[string]interface{}{
+				"delay":   30,
+				"server":  map[string]interface{}{"host": "localhost"},
+				"servers": []interface{}{"a"},
+			}
+			if got := SetPath(data, tt.path, 60); got != tt.wantOK {
+				t.Fatalf("SetPath(%q) = %v, want %v", tt.path, got, tt.wantOK)
+			}
+			for key, want := range tt.want {
+				if !reflect.DeepEqual(data[key], want) {
+					t.Errorf("after SetPath(%q), %s = %v, want %v", tt.path, key, data[key], want)
+				}
+			}
+		})
+	}
+}
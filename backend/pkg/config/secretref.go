@@ -0,0 +1,101 @@
+// Secret references in configuration content
+// Configs store ${secret:NAME} placeholders instead of plaintext secrets
+// Placeholders are resolved from a SecretSource only when content is exported
+package config
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultSecretEnvPrefix namespaces the env vars EnvSecretSource may read,
+// so placeholders can't reach unrelated server settings like JWT_SECRET
+const DefaultSecretEnvPrefix = "CONFLUX_SECRET_"
+
+// secretRefPattern matches ${secret:NAME} placeholders
+var secretRefPattern = regexp.MustCompile(`\$\{secret:([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// SecretSource looks up secret values by name
+type SecretSource interface {
+	LookupSecret(name string) (string, bool)
+}
+
+// EnvSecretSource reads secrets from environment variables named Prefix+NAME
+type EnvSecretSource struct {
+	Prefix string
+}
+
+// LookupSecret returns the value of the prefixed environment variable
+func (s EnvSecretSource) LookupSecret(name string) (string, bool) {
+	return os.LookupEnv(s.Prefix + name)
+}
+
+// UnresolvedSecretsError is returned when placeholders have no value in the source
+type UnresolvedSecretsError struct {
+	Names []string
+}
+
+func (e *UnresolvedSecretsError) Error() string {
+	return "unresolved secrets: " + strings.Join(e.Names, ", ")
+}
+
+// IsSecretRef reports whether value is exactly one ${secret:NAME} placeholder
+func IsSecretRef(value string) bool {
+	match := secretRefPattern.FindStringIndex(value)
+	return match != nil && match[0] == 0 && match[1] == len(value)
+}
+
+// ResolveSecrets returns a copy of data with every placeholder in string values
+// replaced from source. All placeholders are required: if any are missing, an
+// *UnresolvedSecretsError listing them is returned
+func ResolveSecrets(data map[string]interface{}, source SecretSource) (map[string]interface{}, error) {
+	missing := make(map[string]bool)
+	resolved := resolveSecretValue(data, source, missing).(map[string]interface{})
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, &UnresolvedSecretsError{Names: names}
+	}
+	return resolved, nil
+}
+
+// resolveSecretValue substitutes placeholders in value and everything nested beneath it
+func resolveSecretValue(value interface{}, source SecretSource, missing map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			out[key] = resolveSecretValue(child, source, missing)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = resolveSecretValue(child, source, missing)
+		}
+		return out
+	case string:
+		return secretRefPattern.ReplaceAllStringFunc(v, func(ref string) string {
+			name := secretRefPattern.FindStringSubmatch(ref)[1]
+			secret, ok := source.LookupSecret(name)
+			if !ok {
+				missing[name] = true
+				return ref
+			}
+			return secret
+		})
+	default:
+		return value
+	}
+}
+
+// HasSecretRefs reports whether content contains any placeholders
+func HasSecretRefs(content string) bool {
+	return secretRefPattern.MatchString(content)
+}
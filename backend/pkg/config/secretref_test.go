@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// mapSecretSource serves secrets from a map
+type mapSecretSource map[string]string
+
+func (m mapSecretSource) LookupSecret(name string) (string, bool) {
+	value, ok := m[name]
+	return value, ok
+}
+
+func TestResolveSecrets(t *testing.T) {
+	data := map[string]interface{}{
+		"database": map[string]interface{}{
+			"password": "${secret:DB_PASSWORD}",
+			"dsn":      "postgres://app:${secret:DB_PASSWORD}@db/app",
+		},
+		"tokens": []interface{}{"${secret:API_TOKEN}", "literal"},
+		"port":   5432,
+	}
+	source := mapSecretSource{"DB_PASSWORD": `p"ss`, "API_TOKEN": "t0k"}
+
+	got, err := ResolveSecrets(data, source)
+	if err != nil {
+		t.Fatalf("ResolveSecrets() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"database": map[string]interface{}{
+			"password": `p"ss`,
+			"dsn":      `postgres://app:p"ss@db/app`,
+		},
+		"tokens": []interface{}{"t0k", "literal"},
+		"port":   5432,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveSecrets() = %v, want %v", got, want)
+	}
+
+	// The input is left untouched
+	if data["database"].(map[string]interface{})["password"] != "${secret:DB_PASSWORD}" {
+		t.Error("ResolveSecrets must not modify its input")
+	}
+}
+
+func TestResolveSecrets_Unresolved(t *testing.T) {
+	data := map[string]interface{}{
+		"a": "${secret:MISSING_B}",
+		"b": "${secret:MISSING_A}",
+		"c": "${secret:PRESENT}",
+	}
+
+	_, err := ResolveSecrets(data, mapSecretSource{"PRESENT": "x"})
+
+	var unresolved *UnresolvedSecretsError
+	if !errors.As(err, &unresolved) {
+		t.Fatalf("error = %v, want *UnresolvedSecretsError", err)
+	}
+	if want := []string{"MISSING_A", "MISSING_B"}; !reflect.DeepEqual(unresolved.Names, want) {
+		t.Errorf("Names = %v, want %v", unresolved.Names, want)
+	}
+}
+
+func TestEnvSecretSource_UsesPrefix(t *testing.T) {
+	t.Setenv("CONFLUX_SECRET_DB_PASSWORD", "from-env")
+	t.Setenv("JWT_SECRET", "server-secret")
+	source := EnvSecretSource{Prefix: DefaultSecretEnvPrefix}
+
+	if value, ok := source.LookupSecret("DB_PASSWORD"); !ok || value != "from-env" {
+		t.Errorf("LookupSecret(DB_PASSWORD) = %q, %v", value, ok)
+	}
+	if _, ok := source.LookupSecret("JWT_SECRET"); ok {
+		t.Error("unprefixed server env vars must not be resolvable")
+	}
+}
+
+func TestFindSecrets_IgnoresSecretRefs(t *testing.T) {
+	data := map[string]interface{}{"password": "${secret:DB_PASSWORD}"}
+	if findings := FindSecrets(data); len(findings) != 0 {
+		t.Errorf("FindSecrets() = %v, want none for placeholders", findings)
+	}
+}
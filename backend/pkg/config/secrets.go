@@ -0,0 +1,120 @@
+// Secret detection for parsed configuration data
+// Flags values that are probably credentials stored in plaintext
+// Findings are advisory; callers surface them as warnings rather than errors
+package config
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// secretKeyPatterns match normalized key names that usually hold credentials
+// Keys are lowercased with '_', '-' and '.' removed before matching
+var secretKeyPatterns = []string{
+	"password", "passwd", "secret", "token", "apikey", "accesskey",
+	"privatekey", "credential", "signingkey", "encryptionkey",
+}
+
+const (
+	// minTokenLength is the shortest value considered for entropy checks
+	minTokenLength = 24
+
+	// minTokenEntropy is the Shannon entropy (bits per character) above which
+	// a value looks like a generated token rather than a word or identifier
+	minTokenEntropy = 4.0
+)
+
+// SecretFinding describes a value that looks like a plaintext secret
+type SecretFinding struct {
+	Path   string `json:"path"`   // Dotted key path, e.g. database.password or servers[0].token
+	Reason string `json:"reason"` // "key" when the key name matched, "entropy" for token-like values
+}
+
+// Message returns a user-facing warning for the finding
+func (f SecretFinding) Message() string {
+	if f.Reason == "entropy" {
+		return fmt.Sprintf("%s has a token-like value; consider a secret reference", f.Path)
+	}
+	return fmt.Sprintf("%s looks like a secret; consider a secret reference", f.Path)
+}
+
+// FindSecrets walks parsed configuration data and returns secret-like values,
+// sorted by path
+func FindSecrets(data map[string]interface{}) []SecretFinding {
+	var findings []SecretFinding
+	walkSecrets("", data, &findings)
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Path < findings[j].Path })
+	return findings
+}
+
+// walkSecrets records findings for value and everything nested beneath it
+func walkSecrets(path string, value interface{}, findings *[]SecretFinding) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			if s, ok := child.(string); ok && s != "" && !IsSecretRef(s) && isSecretKey(key) {
+				*findings = append(*findings, SecretFinding{Path: childPath, Reason: "key"})
+				continue
+			}
+			walkSecrets(childPath, child, findings)
+		}
+	case []interface{}:
+		for i, child := range v {
+			walkSecrets(fmt.Sprintf("%s[%d]", path, i), child, findings)
+		}
+	case string:
+		if !IsSecretRef(v) && looksLikeToken(v) {
+			*findings = append(*findings, SecretFinding{Path: path, Reason: "entropy"})
+		}
+	}
+}
+
+// isSecretKey reports whether a key name suggests its value is a credential
+func isSecretKey(key string) bool {
+	normalized := strings.NewReplacer("_", "", "-", "", ".", "").Replace(strings.ToLower(key))
+	for _, pattern := range secretKeyPatterns {
+		if strings.Contains(normalized, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// looksLikeToken reports whether a value resembles a generated key or token:
+// long, unbroken, mixing letters and digits, and with high character entropy
+func looksLikeToken(value string) bool {
+	if len(value) < minTokenLength || strings.ContainsAny(value, " \t\n") || strings.Contains(value, "://") {
+		return false
+	}
+
+	hasLetter := strings.IndexFunc(value, func(r rune) bool { return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' }) >= 0
+	hasDigit := strings.IndexFunc(value, func(r rune) bool { return r >= '0' && r <= '9' }) >= 0
+	if !hasLetter || !hasDigit {
+		return false
+	}
+
+	return shannonEntropy(value) >= minTokenEntropy
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
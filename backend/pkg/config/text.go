@@ -0,0 +1,42 @@
+// Text content checks
+// Rejects binary uploads before format detection or parsing sees them,
+// so users get one clear error instead of whichever parser failed last
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrNotText is returned for content that isn't a text configuration
+var ErrNotText = errors.New("not a text config")
+
+// maxControlRatio is the share of control characters above which content is treated as binary
+// Tabs and line breaks don't count
+const maxControlRatio = 0.05
+
+// CheckText reports whether content looks like a text configuration
+// Content must be valid UTF-8, free of NUL bytes, and mostly printable
+func CheckText(content string) error {
+	if !utf8.ValidString(content) {
+		return fmt.Errorf("%w: content is not valid UTF-8", ErrNotText)
+	}
+	if strings.IndexByte(content, 0) >= 0 {
+		return fmt.Errorf("%w: content contains NUL bytes", ErrNotText)
+	}
+
+	var total, control int
+	for _, r := range content {
+		total++
+		if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+			control++
+		}
+	}
+	if total > 0 && float64(control)/float64(total) > maxControlRatio {
+		return fmt.Errorf("%w: content is mostly control characters", ErrNotText)
+	}
+	return nil
+}
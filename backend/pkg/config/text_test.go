@@ -0,0 +1,37 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckText(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "yaml", content: "server:\n\tport: 8080\r\n"},
+		{name: "unicode text", content: "greeting: \"héllo wörld ✓\"\n"},
+		{name: "empty", content: ""},
+		{name: "png header", content: "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", wantErr: "not valid UTF-8"},
+		{name: "nul bytes", content: "key: value\x00\x00", wantErr: "NUL bytes"},
+		{name: "control characters", content: "a\x01b\x02c\x03d\x04e", wantErr: "control characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckText(tt.content)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckText() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrNotText) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckText() error = %v, want %v mentioning %q", err, ErrNotText, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+// Line-level text diff and unified diff rendering
+// Complements the structural Diff for tools that expect patch-style output
+// Output follows the GNU unified format, so it can be applied with patch
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxLineDiffCells bounds the LCS table; larger inputs fall back to a full replacement
+const maxLineDiffCells = 4 << 20
+
+// noNewlineMarker follows a line that isn't terminated by a newline
+const noNewlineMarker = `\ No newline at end of file`
+
+// LineKind classifies a line in a line-level diff
+type LineKind byte
+
+const (
+	LineContext LineKind = ' '
+	LineRemoved LineKind = '-'
+	LineAdded   LineKind = '+'
+)
+
+// LineOp is one line of a line-level diff
+type LineOp struct {
+	Kind      LineKind
+	Text      string
+	OldLine   int  // 1-based line in the old text; 0 for added lines
+	NewLine   int  // 1-based line in the new text; 0 for removed lines
+	NoNewline bool // Last line of its text, without a trailing newline
+}
+
+// DiffLines compares two texts line by line
+// Removed lines come before the added lines that replace them
+func DiffLines(oldText, newText string) []LineOp {
+	oldLines, oldNoEOL := splitLines(oldText)
+	newLines, newNoEOL := splitLines(newText)
+
+	// A last line without a newline differs from the same text with one
+	key := func(lines []string, i int, noEOL bool) string {
+		if noEOL && i == len(lines)-1 {
+			return lines[i] + "\x00"
+		}
+		return lines[i]
+	}
+	oldKeys := make([]string, len(oldLines))
+	for i := range oldLines {
+		oldKeys[i] = key(oldLines, i, oldNoEOL)
+	}
+	newKeys := make([]string, len(newLines))
+	for i := range newLines {
+		newKeys[i] = key(newLines, i, newNoEOL)
+	}
+
+	var ops []LineOp
+	emit := func(kind LineKind, oldIndex, newIndex int) {
+		op := LineOp{Kind: kind}
+		if kind != LineAdded {
+			op.Text, op.OldLine = oldLines[oldIndex], oldIndex+1
+			op.NoNewline = oldNoEOL && oldIndex == len(oldLines)-1
+		}
+		if kind != LineRemoved {
+			op.Text, op.NewLine = newLines[newIndex], newIndex+1
+			op.NoNewline = newNoEOL && newIndex == len(newLines)-1
+		}
+		ops = append(ops, op)
+	}
+
+	// Common prefix and suffix need no table
+	prefix := 0
+	for prefix < len(oldKeys) && prefix < len(newKeys) && oldKeys[prefix] == newKeys[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldKeys)-prefix && suffix < len(newKeys)-prefix &&
+		oldKeys[len(oldKeys)-1-suffix] == newKeys[len(newKeys)-1-suffix] {
+		suffix++
+	}
+
+	for i := 0; i < prefix; i++ {
+		emit(LineContext, i, i)
+	}
+
+	oldMid := oldKeys[prefix : len(oldKeys)-suffix]
+	newMid := newKeys[prefix : len(newKeys)-suffix]
+	for _, step := range lcsSteps(oldMid, newMid) {
+		emit(step.kind, prefix+step.oldIndex, prefix+step.newIndex)
+	}
+
+	for i := 0; i < suffix; i++ {
+		emit(LineContext, len(oldKeys)-suffix+i, len(newKeys)-suffix+i)
+	}
+	return ops
+}
+
+// lcsStep is one edit produced by lcsSteps, indexed into its inputs
+type lcsStep struct {
+	kind               LineKind
+	oldIndex, newIndex int
+}
+
+// lcsSteps returns the edits turning a into b along a longest common subsequence
+// Inputs too large for the table are treated as a full replacement
+func lcsSteps(a, b []string) []lcsStep {
+	var steps []lcsStep
+	if len(a)*len(b) > maxLineDiffCells {
+		for i := range a {
+			steps = append(steps, lcsStep{kind: LineRemoved, oldIndex: i})
+		}
+		for j := range b {
+			steps = append(steps, lcsStep{kind: LineAdded, newIndex: j})
+		}
+		return steps
+	}
+
+	// lengths[i][j] is the LCS length of a[i:] and b[j:]
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			steps = append(steps, lcsStep{kind: LineContext, oldIndex: i, newIndex: j})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lengths[i+1][j] >= lengths[i][j+1]):
+			steps = append(steps, lcsStep{kind: LineRemoved, oldIndex: i})
+			i++
+		default:
+			steps = append(steps, lcsStep{kind: LineAdded, newIndex: j})
+			j++
+		}
+	}
+	return steps
+}
+
+// UnifiedDiff renders ops as a unified diff with the given lines of context
+// Returns an empty string when nothing changed
+func UnifiedDiff(oldName, newName string, ops []LineOp, context int) string {
+	// Lines of each text consumed before ops[i], for hunk headers
+	oldBefore := make([]int, len(ops)+1)
+	newBefore := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldBefore[i+1], newBefore[i+1] = oldBefore[i], newBefore[i]
+		if op.Kind != LineAdded {
+			oldBefore[i+1]++
+		}
+		if op.Kind != LineRemoved {
+			newBefore[i+1]++
+		}
+	}
+
+	var b strings.Builder
+	for start := 0; start < len(ops); {
+		// Find the next change and extend the hunk while changes are close together
+		first := start
+		for first < len(ops) && ops[first].Kind == LineContext {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		end := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].Kind != LineContext {
+				end = i + 1
+			} else if i-end >= 2*context {
+				break
+			}
+		}
+
+		hunkStart := max(first-context, start)
+		hunkEnd := min(end+context, len(ops))
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n",
+			hunkRange(oldBefore[hunkStart], oldBefore[hunkEnd]-oldBefore[hunkStart]),
+			hunkRange(newBefore[hunkStart], newBefore[hunkEnd]-newBefore[hunkStart]))
+		for _, op := range ops[hunkStart:hunkEnd] {
+			b.WriteByte(byte(op.Kind))
+			b.WriteString(op.Text)
+			b.WriteByte('\n')
+			if op.NoNewline {
+				b.WriteString(noNewlineMarker + "\n")
+			}
+		}
+		start = hunkEnd
+	}
+	return b.String()
+}
+
+// hunkRange formats a hunk range from the number of lines before it and its length
+// An empty range names the line before it; a count of 1 is omitted
+func hunkRange(before, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", before)
+	case 1:
+		return fmt.Sprintf("%d", before+1)
+	default:
+		return fmt.Sprintf("%d,%d", before+1, count)
+	}
+}
+
+// splitLines splits text into lines without their newlines
+// Reports whether the last line is missing its trailing newline
+func splitLines(text string) ([]string, bool) {
+	if text == "" {
+		return nil, false
+	}
+	lines := strings.Split(text, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1], false
+	}
+	return lines, true
+}
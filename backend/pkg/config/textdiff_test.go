@@ -0,0 +1,79 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{
+			name: "identical",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: "",
+		},
+		{
+			name: "modified line with context",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n",
+			new:  "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			want: "--- a/app.yaml\n+++ b/app.yaml\n" +
+				"@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "separate hunks",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			new:  "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			want: "--- a/app.yaml\n+++ b/app.yaml\n" +
+				"@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n" +
+				"@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		},
+		{
+			name: "insertion into empty text",
+			old:  "",
+			new:  "a\nb\n",
+			want: "--- a/app.yaml\n+++ b/app.yaml\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "deletion of everything",
+			old:  "a\n",
+			new:  "",
+			want: "--- a/app.yaml\n+++ b/app.yaml\n@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			name: "missing trailing newline",
+			old:  "a\nb",
+			new:  "a\nb\n",
+			want: "--- a/app.yaml\n+++ b/app.yaml\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := UnifiedDiff("a/app.yaml", "b/app.yaml", DiffLines(tt.old, tt.new), 3)
+			if got != tt.want {
+				t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	ops := DiffLines("host: a\nport: 1\ndebug: true\n", "host: b\nport: 1\n")
+
+	var got []string
+	for _, op := range ops {
+		got = append(got, string(op.Kind)+op.Text)
+	}
+	want := []string{"-host: a", "+host: b", " port: 1", "-debug: true"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("DiffLines() = %q, want %q", got, want)
+	}
+	if ops[0].OldLine != 1 || ops[1].NewLine != 1 || ops[3].OldLine != 3 {
+		t.Errorf("DiffLines() line numbers = %+v", ops)
+	}
+}
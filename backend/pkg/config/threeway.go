@@ -0,0 +1,84 @@
+// Three-way merge of parsed configuration data
+// Combines two documents that both diverged from a common base, key by key
+// Nested objects are merged recursively; arrays and scalars are merged as whole values
+package config
+
+import (
+	"reflect"
+	"sort"
+
+	"conflux/internal/models"
+)
+
+// ThreeWayMerge merges the changes ours and theirs each made to base
+// A key changed on only one side takes that side's value, including removal.
+// A key changed on both sides to different values is a conflict: the merge keeps
+// ours and reports the key, sorted by path. Objects changed on both sides are
+// merged key by key, so only the keys that actually clash conflict
+func ThreeWayMerge(base, ours, theirs map[string]interface{}) (map[string]interface{}, []models.MergeConflict) {
+	var conflicts []models.MergeConflict
+	merged := mergeThreeWay("", base, ours, theirs, &conflicts)
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Path < conflicts[j].Path })
+	return merged, conflicts
+}
+
+// mergeThreeWay merges one level of the documents at path
+func mergeThreeWay(
+	path string, base, ours, theirs map[string]interface{}, conflicts *[]models.MergeConflict,
+) map[string]interface{} {
+	keys := make(map[string]struct{}, len(ours)+len(theirs))
+	for _, side := range []map[string]interface{}{base, ours, theirs} {
+		for key := range side {
+			keys[key] = struct{}{}
+		}
+	}
+
+	merged := make(map[string]interface{}, len(keys))
+	for key := range keys {
+		baseValue, inBase := base[key]
+		ourValue, inOurs := ours[key]
+		theirValue, inTheirs := theirs[key]
+
+		switch {
+		case sameValue(ourValue, inOurs, theirValue, inTheirs), sameValue(theirValue, inTheirs, baseValue, inBase):
+			// Both sides agree, or only ours changed
+			if inOurs {
+				merged[key] = ourValue
+			}
+			continue
+		case sameValue(ourValue, inOurs, baseValue, inBase):
+			// Only theirs changed
+			if inTheirs {
+				merged[key] = theirValue
+			}
+			continue
+		}
+
+		ourMap, ourIsMap := ourValue.(map[string]interface{})
+		theirMap, theirIsMap := theirValue.(map[string]interface{})
+		if ourIsMap && theirIsMap {
+			baseMap, _ := baseValue.(map[string]interface{}) // Nil when the object is new on both sides
+			merged[key] = mergeThreeWay(joinPath(path, key), baseMap, ourMap, theirMap, conflicts)
+			continue
+		}
+
+		*conflicts = append(*conflicts, models.MergeConflict{
+			Path:   joinPath(path, key),
+			Base:   baseValue,
+			Ours:   ourValue,
+			Theirs: theirValue,
+		})
+		if inOurs {
+			merged[key] = ourValue
+		}
+	}
+	return merged
+}
+
+// sameValue reports whether two optional values are equal, treating two absent values as equal
+func sameValue(a interface{}, aPresent bool, b interface{}, bPresent bool) bool {
+	if !aPresent || !bPresent {
+		return aPresent == bPresent
+	}
+	return reflect.DeepEqual(a, b)
+}
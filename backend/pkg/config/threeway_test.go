@@ -0,0 +1,109 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"conflux/internal/models"
+)
+
+func TestThreeWayMerge(t *testing.T) {
+	base := map[string]interface{}{
+		"name":    "app",
+		"debug":   false,
+		"timeout": 30,
+		"legacy":  "on",
+		"server": map[string]interface{}{
+			"host": "localhost",
+			"port": 8080,
+		},
+		"tags": []interface{}{"a"},
+	}
+	ours := map[string]interface{}{
+		"name":    "my-app", // Changed by the user only
+		"debug":   true,     // Changed differently on both sides
+		"timeout": 30,       // Unchanged by the user
+		"legacy":  "on",     // Removed by the template
+		"extra":   "mine",   // Added by the user
+		"server": map[string]interface{}{
+			"host": "0.0.0.0", // Changed by the user only
+			"port": 9000,      // Changed differently on both sides
+		},
+		"tags": []interface{}{"a", "b"}, // Same change on both sides
+	}
+	theirs := map[string]interface{}{
+		"name":    "app",
+		"debug":   "verbose",
+		"timeout": 60, // Changed by the template only
+		"server": map[string]interface{}{
+			"host": "localhost",
+			"port": 8443,
+			"tls":  true, // Added by the template
+		},
+		"tags":    []interface{}{"a", "b"},
+		"metrics": true, // Added by the template
+	}
+
+	merged, conflicts := ThreeWayMerge(base, ours, theirs)
+
+	wantMerged := map[string]interface{}{
+		"name":    "my-app",
+		"debug":   true,
+		"timeout": 60,
+		"extra":   "mine",
+		"server": map[string]interface{}{
+			"host": "0.0.0.0",
+			"port": 9000,
+			"tls":  true,
+		},
+		"tags":    []interface{}{"a", "b"},
+		"metrics": true,
+	}
+	if !reflect.DeepEqual(merged, wantMerged) {
+		t.Errorf("merged = %+v, want %+v", merged, wantMerged)
+	}
+
+	wantConflicts := []models.MergeConflict{
+		{Path: "debug", Base: false, Ours: true, Theirs: "verbose"},
+		{Path: "server.port", Base: 8080, Ours: 9000, Theirs: 8443},
+	}
+	if !reflect.DeepEqual(conflicts, wantConflicts) {
+		t.Errorf("conflicts = %+v, want %+v", conflicts, wantConflicts)
+	}
+}
+
+func TestThreeWayMerge_RemovalConflicts(t *testing.T) {
+	base := map[string]interface{}{"port": 8080, "host": "localhost"}
+	ours := map[string]interface{}{"port": 9000}                      // Edited port, removed host
+	theirs := map[string]interface{}{"host": "0.0.0.0", "new": "yes"} // Removed port, edited host
+
+	merged, conflicts := ThreeWayMerge(base, ours, theirs)
+
+	// Each side's edit clashes with the other's removal; ours wins both
+	if want := map[string]interface{}{"port": 9000, "new": "yes"}; !reflect.DeepEqual(merged, want) {
+		t.Errorf("merged = %+v, want %+v", merged, want)
+	}
+	wantConflicts := []models.MergeConflict{
+		{Path: "host", Base: "localhost", Theirs: "0.0.0.0"},
+		{Path: "port", Base: 8080, Ours: 9000},
+	}
+	if !reflect.DeepEqual(conflicts, wantConflicts) {
+		t.Errorf("conflicts = %+v, want %+v", conflicts, wantConflicts)
+	}
+}
+
+func TestThreeWayMerge_NoBase(t *testing.T) {
+	ours := map[string]interface{}{"server": map[string]interface{}{"port": 1}, "same": "x"}
+	theirs := map[string]interface{}{"server": map[string]interface{}{"port": 2, "tls": true}, "same": "x"}
+
+	// Without a base, additions on one side apply and differing values conflict
+	merged, conflicts := ThreeWayMerge(nil, ours, theirs)
+
+	want := map[string]interface{}{"server": map[string]interface{}{"port": 1, "tls": true}, "same": "x"}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("merged = %+v, want %+v", merged, want)
+	}
+	if len(conflicts) != 1 || conflicts[0].Path != "server.port" {
+		t.Errorf("conflicts = %+v, want server.port only", conflicts)
+	}
+}
@@ -28,19 +28,34 @@ func NewTokenManager(secretKey, issuer string) *TokenManager {
 type Claims struct {
 	UserID int    `json:"user_id"`
 	Email  string `json:"email"`
+	Role   string `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // GenerateToken creates a new JWT token for user
 // Includes user ID, email, and expiration claims
 func (tm *TokenManager) GenerateToken(userID int, email string, duration time.Duration) (string, error) {
+	return tm.GenerateTokenWithRole(userID, email, "", duration)
+}
+
+// GenerateTokenWithRole creates a new JWT token carrying the user's role
+// Handlers use the role claim for authorization without a database lookup
+func (tm *TokenManager) GenerateTokenWithRole(userID int, email, role string, duration time.Duration) (string, error) {
+	return tm.GenerateSessionToken(userID, email, role, "", duration)
+}
+
+// GenerateSessionToken creates a JWT token bound to a server-side session
+// The session ID is carried in the jti claim; an empty ID omits the claim
+func (tm *TokenManager) GenerateSessionToken(userID int, email, role, sessionID string, duration time.Duration) (string, error) {
 	// Token generation implementation
 	claims := Claims{
 		UserID: userID,
 		Email:  email,
+		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    tm.issuer,
 			Subject:   fmt.Sprintf("%d", userID),
+			ID:        sessionID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
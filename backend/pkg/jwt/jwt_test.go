@@ -1,6 +1,7 @@
 package jwt
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -301,6 +302,67 @@ func TestTokenManager_DifferentSecretKeys(t *testing.T) {
 	}
 }
 
+func TestTokenManager_GenerateTokenWithRole(t *testing.T) {
+	tm := NewTokenManager("test-secret", "test-issuer")
+
+	tests := []struct {
+		name string
+		role string
+	}{
+		{name: "admin role", role: "admin"},
+		{name: "user role", role: "user"},
+		{name: "no role", role: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tm.GenerateTokenWithRole(42, "role@example.com", tt.role, time.Hour)
+			if err != nil {
+				t.Fatalf("failed to generate token: %v", err)
+			}
+
+			claims, err := tm.ValidateToken(token)
+			if err != nil {
+				t.Fatalf("failed to validate token: %v", err)
+			}
+			if claims.Role != tt.role {
+				t.Errorf("expected Role %q, got %q", tt.role, claims.Role)
+			}
+			if claims.UserID != 42 {
+				t.Errorf("expected UserID 42, got %d", claims.UserID)
+			}
+		})
+	}
+}
+
+func TestTokenManager_GenerateSessionToken(t *testing.T) {
+	tm := NewTokenManager("test-secret", "test-issuer")
+
+	token, err := tm.GenerateSessionToken(42, "session@example.com", "user", "opaque-session-id", time.Hour)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	claims, err := tm.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("failed to validate token: %v", err)
+	}
+	if claims.ID != "opaque-session-id" {
+		t.Errorf("expected jti %q, got %q", "opaque-session-id", claims.ID)
+	}
+
+	// Tokens without a session carry no jti
+	token, err = tm.GenerateTokenWithRole(42, "session@example.com", "user", time.Hour)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	if claims, err = tm.ValidateToken(token); err != nil {
+		t.Fatalf("failed to validate token: %v", err)
+	}
+	if claims.ID != "" {
+		t.Errorf("expected no jti, got %q", claims.ID)
+	}
+}
+
 // Helper function to split JWT token into parts
 func splitToken(token string) []string {
 	return strings.Split(token, ".")
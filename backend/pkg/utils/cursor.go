@@ -0,0 +1,40 @@
+// Pagination cursor utilities
+// Encodes keyset positions as opaque tokens for cursor-based list endpoints
+// Clients pass the token back unchanged; its contents are not part of the API
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// cursorPrefix tags the keyset column so the encoding can change without ambiguity
+const cursorPrefix = "id:"
+
+// ErrInvalidCursor is returned when a cursor token can't be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor returns an opaque token positioned after the row with the given ID
+func EncodeCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(id)))
+}
+
+// DecodeCursor returns the row ID a token was positioned after
+// An empty token starts from the beginning and decodes to 0
+func DecodeCursor(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !strings.HasPrefix(string(data), cursorPrefix) {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(string(data), cursorPrefix))
+	if err != nil || id < 1 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
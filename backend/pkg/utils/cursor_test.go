@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, id := range []int{1, 42, 1 << 40} {
+		got, err := DecodeCursor(EncodeCursor(id))
+		if err != nil {
+			t.Fatalf("DecodeCursor(EncodeCursor(%d)) error = %v", id, err)
+		}
+		if got != id {
+			t.Errorf("DecodeCursor(EncodeCursor(%d)) = %d", id, got)
+		}
+	}
+
+	if got, err := DecodeCursor(""); err != nil || got != 0 {
+		t.Errorf("DecodeCursor(\"\") = %d, %v, want 0, nil", got, err)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	tests := []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("42")),
+		base64.RawURLEncoding.EncodeToString([]byte("id:abc")),
+		base64.RawURLEncoding.EncodeToString([]byte("id:0")),
+		base64.RawURLEncoding.EncodeToString([]byte("id:-3")),
+	}
+
+	for _, token := range tests {
+		if _, err := DecodeCursor(token); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", token, err)
+		}
+	}
+}
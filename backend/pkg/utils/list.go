@@ -0,0 +1,82 @@
+// Uniform envelope for list endpoints
+// Every listing responds with {"items": [...], "pagination": {...}} whatever the element type
+// Legacy per-resource keys (e.g. "configs") can be kept alongside "items" while clients migrate
+package utils
+
+import (
+	"encoding/json"
+)
+
+// Pagination describes where a page sits in a listing
+// Offset pages report page and total; cursor pages report next_cursor, null on the last page
+type Pagination struct {
+	Page       int     `json:"page,omitempty"`
+	Limit      int     `json:"limit"`
+	Total      *int64  `json:"total,omitempty"`
+	Sort       string  `json:"sort,omitempty"`
+	Order      string  `json:"order,omitempty"`
+	NextCursor *string `json:"next_cursor,omitempty"`
+
+	cursor bool // Always write next_cursor, even when null
+}
+
+// OffsetPagination describes a page of an offset-paginated listing
+func OffsetPagination(page, limit int, total int64) Pagination {
+	return Pagination{Page: page, Limit: limit, Total: &total}
+}
+
+// CursorPagination describes a page of a keyset-paginated listing
+// An empty nextCursor marks the last page
+func CursorPagination(limit int, nextCursor string) Pagination {
+	pagination := Pagination{Limit: limit, cursor: true}
+	if nextCursor != "" {
+		pagination.NextCursor = &nextCursor
+	}
+	return pagination
+}
+
+// MarshalJSON writes next_cursor as null on a cursor listing's last page
+func (p Pagination) MarshalJSON() ([]byte, error) {
+	type plain Pagination
+	if !p.cursor {
+		return json.Marshal(plain(p))
+	}
+	return json.Marshal(struct {
+		plain
+		NextCursor *string `json:"next_cursor"`
+	}{plain(p), p.NextCursor})
+}
+
+// ListResponse is the envelope for a page of T
+type ListResponse[T any] struct {
+	Items      []T
+	Pagination Pagination
+	LegacyKey  string // Deprecated top-level key that also carries the items; empty to omit
+}
+
+// NewListResponse wraps a page of items
+func NewListResponse[T any](items []T, pagination Pagination) ListResponse[T] {
+	return ListResponse[T]{Items: items, Pagination: pagination}
+}
+
+// WithLegacyKey also writes the items under key, for clients still reading per-resource keys
+func (r ListResponse[T]) WithLegacyKey(key string) ListResponse[T] {
+	r.LegacyKey = key
+	return r
+}
+
+// MarshalJSON writes the envelope; an empty page is an empty array, never null
+func (r ListResponse[T]) MarshalJSON() ([]byte, error) {
+	items := r.Items
+	if items == nil {
+		items = []T{}
+	}
+	body := map[string]interface{}{
+		"items":      items,
+		"pagination": r.Pagination,
+	}
+	if r.LegacyKey != "" {
+		body[r.LegacyKey] = items
+	}
+	return json.Marshal(body)
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestListResponse_MarshalJSON(t *testing.T) {
+	type item struct {
+		ID int `json:"id"`
+	}
+
+	tests := []struct {
+		name     string
+		response interface{}
+		want     string
+	}{
+		{
+			name:     "structs with offset pagination",
+			response: NewListResponse([]item{{ID: 1}, {ID: 2}}, OffsetPagination(2, 10, 12)),
+			want:     `{"items":[{"id":1},{"id":2}],"pagination":{"page":2,"limit":10,"total":12}}`,
+		},
+		{
+			name:     "pointers with a legacy key",
+			response: NewListResponse([]*item{{ID: 3}}, OffsetPagination(1, 20, 1)).WithLegacyKey("configs"),
+			want:     `{"configs":[{"id":3}],"items":[{"id":3}],"pagination":{"page":1,"limit":20,"total":1}}`,
+		},
+		{
+			name:     "empty page of strings",
+			response: NewListResponse[string](nil, OffsetPagination(1, 20, 0)),
+			want:     `{"items":[],"pagination":{"page":1,"limit":20,"total":0}}`,
+		},
+		{
+			name:     "cursor page",
+			response: NewListResponse([]int{4, 5}, CursorPagination(2, "abc")),
+			want:     `{"items":[4,5],"pagination":{"limit":2,"next_cursor":"abc"}}`,
+		},
+		{
+			name:     "last cursor page",
+			response: NewListResponse([]int{6}, CursorPagination(2, "")),
+			want:     `{"items":[6],"pagination":{"limit":2,"next_cursor":null}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.response)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
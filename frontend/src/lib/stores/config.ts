@@ -56,7 +56,7 @@ function createTemplatesStore() {
 				const response = await configAPI.getTemplates(params);
 				update(state => ({
 					...state,
-					templates: response.templates,
+					templates: response.items,
 					total: response.pagination.total,
 					page: response.pagination.page,
 					limit: response.pagination.limit,
@@ -128,7 +128,7 @@ function createConfigsStore() {
 				const response = await configAPI.getUserConfigs(params);
 				update(state => ({
 					...state,
-					configs: response.configs,
+					configs: response.items,
 					total: response.pagination.total,
 					page: response.pagination.page,
 					limit: response.pagination.limit,
@@ -242,7 +242,7 @@ function createEditorStore() {
 				const versionsResponse = await configAPI.getConfigVersions(id);
 				update(state => ({
 					...state,
-					versions: versionsResponse.versions
+					versions: versionsResponse.items
 				}));
 			} catch (error) {
 				update(state => ({
@@ -361,7 +361,7 @@ function createEditorStore() {
 					config: updatedConfig,
 					isDirty: false,
 					saving: false,
-					versions: versionsResponse.versions
+					versions: versionsResponse.items
 				}));
 			} catch (error) {
 				update(s => ({
@@ -381,8 +381,19 @@ function createEditorStore() {
 			if (!state.config) return;
 			
 			try {
-				const restoredConfig = await configAPI.restoreConfigVersion(state.config.id, versionId);
-				const versionsResponse = await configAPI.getConfigVersions(state.config.id);
+				const result = await configAPI.restoreConfigVersion(state.config.id, versionId);
+				const restoredConfig = result.config;
+				// The restore created a new version; add it to the history without refetching
+				const restoredVersion: ConfigVersion = {
+					id: result.version_id,
+					config_id: restoredConfig.id,
+					version: result.version,
+					content: restoredConfig.content,
+					change_note: `Restored to version ${result.restored_from_version}`,
+					restored_from: result.restored_from,
+					created_by: restoredConfig.user_id,
+					created_at: restoredConfig.updated_at
+				};
 				
 				update(s => ({
 					...s,
@@ -390,7 +401,7 @@ function createEditorStore() {
 					content: restoredConfig.content,
 					format: restoredConfig.format,
 					isDirty: false,
-					versions: versionsResponse.versions
+					versions: [restoredVersion, ...s.versions]
 				}));
 			} catch (error) {
 				update(s => ({
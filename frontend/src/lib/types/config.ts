@@ -38,10 +38,19 @@ export interface UserConfig {
 	format: ConfigFormat;
 	content: string;
 	is_shared: boolean;
+	forked_from?: number;
 	created_at: string;
 	updated_at: string;
 	template?: ConfigTemplate;
 	versions?: ConfigVersion[];
+	lock?: ConfigLock;
+}
+
+export interface ConfigLock {
+	config_id: number;
+	holder_id: number;
+	acquired_at: string;
+	expires_at: string;
 }
 
 export interface ConfigVersion {
@@ -50,10 +59,19 @@ export interface ConfigVersion {
 	version: number;
 	content: string;
 	change_note: string;
+	restored_from?: number;
 	created_by: number;
 	created_at: string;
 }
 
+export interface RestoreResult {
+	config: UserConfig;
+	version: number;
+	version_id: number;
+	restored_from: number;
+	restored_from_version: number;
+}
+
 export interface ConfigImport {
 	id: number;
 	user_id: number;
@@ -61,22 +79,26 @@ export interface ConfigImport {
 	source_url: string;
 	status: ImportStatus;
 	error_message?: string;
+	source_failed?: boolean; // The source was unreachable or errored (served as 502)
 	config_id?: number;
 	created_at: string;
 	completed_at?: string;
 }
 
-export type ConfigFormat = 'yaml' | 'json' | 'toml' | 'env';
+export type ConfigFormat = 'yaml' | 'json' | 'jsonc' | 'toml' | 'env' | 'ini';
 
 export type ConfigSourceType = 'local' | 'url' | 'github' | 'gitlab';
 
 export type ImportStatus = 'pending' | 'processing' | 'completed' | 'failed';
 
 export interface ConfigDiff {
-	line_number: number;
+	line_number?: number; // Line diffs
+	path?: string; // Structural diffs: dotted key path
 	type: 'added' | 'removed' | 'modified';
 	old_content: string;
 	new_content: string;
+	old_value?: unknown;
+	new_value?: unknown;
 }
 
 export interface ShareRequest {
@@ -85,6 +107,14 @@ export interface ShareRequest {
 	permissions: 'read' | 'write';
 }
 
+export interface ConfigShare {
+	config_id: number;
+	user_id: number;
+	permission: 'read' | 'write';
+	shared_by: number;
+	created_at: string;
+}
+
 export interface APIKey {
 	id: number;
 	user_id: number;
@@ -100,6 +130,7 @@ export interface APIKey {
 export interface CreateConfigRequest {
 	template_id: number;
 	name: string;
+	variables?: Record<string, string>; // Values keyed by template variable name
 }
 
 export interface UpdateConfigRequest {
@@ -114,6 +145,8 @@ export interface ValidateConfigRequest {
 	content: string;
 	format: ConfigFormat;
 	template_id?: number;
+	allowed_keys?: string[]; // Permitted top-level keys when there's no schema
+	strict?: boolean; // Unexpected keys are errors rather than warnings
 }
 
 export interface ConvertFormatRequest {
@@ -126,41 +159,24 @@ export interface DetectFormatRequest {
 	content: string;
 }
 
-export interface PaginatedResponse<T> {
-	data: T[];
+// Envelope shared by every list endpoint; cursor listings return next_cursor instead of page/total
+export interface ListResponse<T> {
+	items: T[];
 	pagination: {
 		page: number;
 		limit: number;
 		total: number;
+		sort?: string;
+		order?: 'asc' | 'desc';
+		next_cursor?: string | null;
 	};
 }
 
-export interface TemplatesResponse {
-	templates: ConfigTemplate[];
-	pagination: {
-		page: number;
-		limit: number;
-		total: number;
-	};
-}
+export type TemplatesResponse = ListResponse<ConfigTemplate>;
 
-export interface ConfigsResponse {
-	configs: UserConfig[];
-	pagination: {
-		page: number;
-		limit: number;
-		total: number;
-	};
-}
+export type ConfigsResponse = ListResponse<UserConfig>;
 
-export interface VersionsResponse {
-	versions: ConfigVersion[];
-	pagination: {
-		page: number;
-		limit: number;
-		total: number;
-	};
-}
+export type VersionsResponse = ListResponse<ConfigVersion>;
 
 // Utility types
 export interface FormatDetectionResult {
@@ -173,4 +189,13 @@ export interface ConversionResult {
 
 export interface ValidationResult {
 	message: string;
+	warnings: string[];
+	parsed?: Record<string, unknown>; // Canonical parsed content, with ?include=parsed
+}
+
+// A syntax error in the validated content; line and column are omitted when the format doesn't report them
+export interface ParseError {
+	line?: number;
+	column?: number;
+	message: string;
 }
@@ -14,6 +14,7 @@ import type {
 	TemplatesResponse,
 	ConfigsResponse,
 	VersionsResponse,
+	RestoreResult,
 	FormatDetectionResult,
 	ConversionResult,
 	ValidationResult,
@@ -118,7 +119,7 @@ class ConfigAPI {
 		return apiClient.request(url);
 	}
 
-	async restoreConfigVersion(configId: number, versionId: number): Promise<UserConfig> {
+	async restoreConfigVersion(configId: number, versionId: number): Promise<RestoreResult> {
 		return apiClient.request(`/configs/${configId}/versions/${versionId}/restore`, {
 			method: 'POST'
 		});